@@ -15,6 +15,67 @@ See header HeliosDac.h for function and usage documentation
 
 
 #include "HeliosDac.h"
+#include <atomic>
+
+// Wire capture state, shared by all instances since IDN packets are sent from a global hook
+static std::mutex wireLock;
+static std::atomic<bool> wireEnabled(false);
+static HeliosWireCallback wireCallback = NULL;
+static void* wireContext = NULL;
+
+// Passes a transfer to the wire callback, if one is set
+static void WireRecord(int direction, int transport, unsigned int endpoint, const sockaddr_in* addr, int result, const std::uint8_t* data, int length)
+{
+	if (!wireEnabled)
+		return;
+
+	std::lock_guard<std::mutex> lock(wireLock);
+	if (wireCallback == NULL)
+		return;
+
+	HeliosWireRecord record;
+	record.direction = direction;
+	record.transport = transport;
+	record.endpoint = endpoint;
+	record.address = addr != NULL ? ntohl(addr->sin_addr.s_addr) : 0;
+	record.port = addr != NULL ? ntohs(addr->sin_port) : 0;
+	record.result = result;
+	record.data = data;
+	record.length = length > 0 ? length : 0;
+	wireCallback(wireContext, &record);
+}
+
+static void WireIdnSendHook(const struct sockaddr_in* serverSockAddr, const uint8_t* packet, unsigned packetLen)
+{
+	WireRecord(HELIOS_WIRE_SENT, HELIOS_WIRE_UDP, 0, serverSockAddr, 0, packet, packetLen);
+}
+
+// libusb_interrupt_transfer() and libusb_bulk_transfer(), passing the transfer to the wire callback
+static int WireInterruptTransfer(libusb_device_handle* handle, unsigned char endpoint, unsigned char* data, int length, int* transferred, unsigned int timeout)
+{
+	int result = libusb_interrupt_transfer(handle, endpoint, data, length, transferred, timeout);
+	if (endpoint & LIBUSB_ENDPOINT_IN)
+		WireRecord(HELIOS_WIRE_RECEIVED, HELIOS_WIRE_USB_INTERRUPT, endpoint, NULL, result, data, *transferred);
+	else
+		WireRecord(HELIOS_WIRE_SENT, HELIOS_WIRE_USB_INTERRUPT, endpoint, NULL, result, data, result == LIBUSB_SUCCESS ? *transferred : length);
+	return result;
+}
+
+static int WireBulkTransfer(libusb_device_handle* handle, unsigned char endpoint, unsigned char* data, int length, int* transferred, unsigned int timeout)
+{
+	int result = libusb_bulk_transfer(handle, endpoint, data, length, transferred, timeout);
+	WireRecord(HELIOS_WIRE_SENT, HELIOS_WIRE_USB_BULK, endpoint, NULL, result, data, result == LIBUSB_SUCCESS ? *transferred : length);
+	return result;
+}
+
+void HeliosDac::SetWireCallback(HeliosWireCallback callback, void* context)
+{
+	std::lock_guard<std::mutex> lock(wireLock);
+	wireCallback = callback;
+	wireContext = context;
+	wireEnabled = callback != NULL;
+	idnSendHook.store(WireIdnSendHook);
+}
 
 HeliosDac::HeliosDac()
 {
@@ -121,6 +182,56 @@ int HeliosDac::ReScanDevicesOnlyNetwork()
 	return (int)deviceList.size();
 }
 
+int HeliosDac::OpenNetworkDevice(const char* address, unsigned int serviceId, const char* name, const std::uint8_t* unitId)
+{
+	if (!idnInited)
+	{
+		plt_sockStartup();
+
+		// Validate monotonic time reference
+		if (plt_validateMonoTime() != 0)
+		{
+			logError("Monotonic time init failed");
+			return HELIOS_ERROR_NETWORK;
+		}
+	}
+	idnInited = true;
+
+	struct in_addr addr;
+	if (address == NULL || inet_pton(AF_INET, address, &addr) != 1)
+	{
+		logError("Invalid IDN DAC address: %s", address == NULL ? "(null)" : address);
+		return HELIOS_ERROR_NETWORK;
+	}
+
+	// Keep an already open connection to the same service
+	for (int j = 0; j < deviceList.size(); j++)
+	{
+		if (deviceList[j]->GetIsUsb() || deviceList[j]->GetIsClosed())
+			continue;
+
+		if (((HeliosDacIdnDevice*)deviceList[j].get())->GetIsService(addr.s_addr, serviceId))
+			return j;
+	}
+
+	IDNCONTEXT* context = new IDNCONTEXT {};
+	context->serverSockAddr.sin_family = AF_INET;
+	context->serverSockAddr.sin_port = htons(IDN_PORT);
+	context->serverSockAddr.sin_addr = addr;
+	context->name = std::string(name != NULL ? name : address);
+	context->serviceId = serviceId;
+	context->isStoppedOrTimeout = true;
+	context->packetNumFragments = 1;
+	if (unitId != NULL)
+		memcpy(context->unitId, unitId, IDNSL_UNITID_LENGTH);
+
+	logInfo("Opened IDN DAC by address: %s\n", context->name.c_str());
+	deviceList.push_back(std::make_unique<HeliosDacIdnDevice>(context));
+	inited = true;
+
+	return (int)deviceList.size() - 1;
+}
+
 // Internal function. inPlace = Whether to keep the current opened devices in their device number slots and only scan for changes.
 int HeliosDac::_OpenUsbDevices(bool inPlace)
 {
@@ -232,7 +343,7 @@ int HeliosDac::_OpenUsbDevices(bool inPlace)
 
 		// Successfully opened, add to device list
 
-		std::unique_ptr<HeliosDacDevice> device = std::make_unique<HeliosDacUsbDevice>(devHandle);
+		std::unique_ptr<HeliosDacDevice> device = std::make_unique<HeliosDacUsbDevice>(devHandle, usbTransferOptions);
 
 		if (inPlace)
 		{
@@ -702,6 +813,17 @@ int HeliosDac::SetName(unsigned int devNum, char* name)
 	return dev->SetName(name);
 }
 
+void HeliosDac::SetUsbTransferOptions(const HeliosUsbTransferOptions* options)
+{
+	std::lock_guard<std::mutex> lock(threadLock);
+	usbTransferOptions = options != NULL ? *options : HeliosUsbTransferOptions{};
+	for (auto& dev : deviceList)
+	{
+		if (dev != NULL)
+			dev->SetUsbTransferOptions(usbTransferOptions);
+	}
+}
+
 int HeliosDac::Stop(unsigned int devNum)
 {
 	if (!inited)
@@ -799,10 +921,11 @@ int HeliosDac::EraseFirmware(unsigned int devNum)
 /// HeliosDacUsbDevice START (one instance for each connected USB DAC)
 /// -----------------------------------------------------------------------
 
-HeliosDac::HeliosDacUsbDevice::HeliosDacUsbDevice(libusb_device_handle* handle)
+HeliosDac::HeliosDacUsbDevice::HeliosDacUsbDevice(libusb_device_handle* handle, const HeliosUsbTransferOptions& options)
 {
 	closed = true;
 	usbHandle = handle;
+	transferOptions = options;
 	std::this_thread::sleep_for(std::chrono::milliseconds(100));
 	std::lock_guard<std::mutex>lock(frameLock);
 
@@ -812,7 +935,7 @@ HeliosDac::HeliosDacUsbDevice::HeliosDacUsbDevice(libusb_device_handle* handle)
 
 	//catch any lingering transfers
 	std::uint8_t ctrlBuffer0[32];
-	while (libusb_interrupt_transfer(usbHandle, EP_INT_IN, ctrlBuffer0, 32, &actualLength, 5) == LIBUSB_SUCCESS);
+	while (WireInterruptTransfer(usbHandle, EP_INT_IN, ctrlBuffer0, 32, &actualLength, 5) == LIBUSB_SUCCESS);
 
 	//get firmware version
 	firmwareVersion = 0;
@@ -820,13 +943,13 @@ HeliosDac::HeliosDacUsbDevice::HeliosDacUsbDevice(libusb_device_handle* handle)
 	for (int i = 0; ((i < 2) && repeat); i++) //retry command if necessary
 	{
 		std::uint8_t ctrlBuffer[2] = { 0x04, 0 };
-		int transferResult = libusb_interrupt_transfer(usbHandle, EP_INT_OUT, ctrlBuffer, 2, &actualLength, 32);
+		int transferResult = WireInterruptTransfer(usbHandle, EP_INT_OUT, ctrlBuffer, 2, &actualLength, ControlTimeout(32));
 		if ((transferResult == LIBUSB_SUCCESS) && (actualLength == 2))
 		{
 			for (int j = 0; ((j < 3) && repeat); j++) //retry response getting if necessary
 			{
 				std::uint8_t ctrlBuffer2[32];
-				transferResult = libusb_interrupt_transfer(usbHandle, EP_INT_IN, ctrlBuffer2, 32, &actualLength, 32);
+				transferResult = WireInterruptTransfer(usbHandle, EP_INT_IN, ctrlBuffer2, 32, &actualLength, ControlTimeout(32));
 				if (transferResult == LIBUSB_SUCCESS)
 				{
 					if (ctrlBuffer2[0] == 0x84)
@@ -848,7 +971,7 @@ HeliosDac::HeliosDacUsbDevice::HeliosDacUsbDevice(libusb_device_handle* handle)
 	for (int i = 0; ((i < 2) && repeat); i++) //retry command if necessary
 	{
 		std::uint8_t ctrlBuffer3[2] = { 0x07, HELIOS_SDK_VERSION };
-		int transferResult = libusb_interrupt_transfer(usbHandle, EP_INT_OUT, ctrlBuffer3, 2, &actualLength, 32);
+		int transferResult = WireInterruptTransfer(usbHandle, EP_INT_OUT, ctrlBuffer3, 2, &actualLength, ControlTimeout(32));
 		if ((transferResult == LIBUSB_SUCCESS) && (actualLength == 2))
 			repeat = false;
 	}
@@ -1199,11 +1322,23 @@ int HeliosDac::HeliosDacUsbDevice::DoFrame()
 	if (GetIsClosed())
 		return HELIOS_ERROR_DEVICE_CLOSED;
 
-	int actualLength = 0;
+	HeliosUsbTransferOptions options;
+	{
+		std::lock_guard<std::mutex> lock(optionsLock);
+		options = transferOptions;
+	}
+	unsigned int transferSize = options.bulkTransferSize - options.bulkTransferSize % HELIOS_USB_PACKET_SIZE;
+	if ((transferSize == 0) || (transferSize > frameBufferSize))
+		transferSize = frameBufferSize;
+	unsigned int timeout = options.frameTimeout != 0 ? options.frameTimeout : 8 + (transferSize >> 5);
 
 	//auto then = std::chrono::high_resolution_clock::now();
 
-	int transferResult = libusb_bulk_transfer(usbHandle, EP_BULK_OUT, frameBuffer, frameBufferSize, &actualLength, 8 + (frameBufferSize >> 5));
+	int transferResult;
+	if ((options.asyncTransfers > 1) && (transferSize < frameBufferSize))
+		transferResult = SendBulkAsync(transferSize, options.asyncTransfers, timeout);
+	else
+		transferResult = SendBulk(transferSize, timeout);
 
 	//auto now = std::chrono::high_resolution_clock::now();
 	//auto time = std::chrono::duration_cast<std::chrono::milliseconds>(now - then);
@@ -1215,6 +1350,116 @@ int HeliosDac::HeliosDacUsbDevice::DoFrame()
 		return HELIOS_ERROR_LIBUSB_BASE + transferResult;
 }
 
+// Sends the frame buffer in consecutive bulk transfers of at most transferSize bytes, returning a libusb result
+int HeliosDac::HeliosDacUsbDevice::SendBulk(unsigned int transferSize, unsigned int timeout)
+{
+	for (unsigned int offset = 0; offset < frameBufferSize; offset += transferSize)
+	{
+		int length = (int)std::min(transferSize, frameBufferSize - offset);
+		int actualLength = 0;
+		int transferResult = WireBulkTransfer(usbHandle, EP_BULK_OUT, frameBuffer + offset, length, &actualLength, timeout);
+		if (transferResult != LIBUSB_SUCCESS)
+			return transferResult;
+	}
+	return LIBUSB_SUCCESS;
+}
+
+// State of the asynchronous bulk transfers of one frame, see SendBulkAsync()
+struct HeliosBulkSend
+{
+	std::uint8_t* buffer;
+	unsigned int size;
+	unsigned int transferSize;
+	unsigned int next;		// offset of the next chunk to submit
+	int pending;			// transfers submitted and not completed
+	int done;				// set when pending drops to zero, for libusb_handle_events_completed()
+	int result;				// first libusb error
+};
+
+static void LIBUSB_CALL HeliosBulkSendCallback(struct libusb_transfer* transfer)
+{
+	HeliosBulkSend* send = (HeliosBulkSend*)transfer->user_data;
+	int result = LIBUSB_SUCCESS;
+	if (transfer->status == LIBUSB_TRANSFER_TIMED_OUT)
+		result = LIBUSB_ERROR_TIMEOUT;
+	else if (transfer->status == LIBUSB_TRANSFER_NO_DEVICE)
+		result = LIBUSB_ERROR_NO_DEVICE;
+	else if (transfer->status != LIBUSB_TRANSFER_COMPLETED)
+		result = LIBUSB_ERROR_IO;
+	WireRecord(HELIOS_WIRE_SENT, HELIOS_WIRE_USB_BULK, EP_BULK_OUT, NULL, result, transfer->buffer, result == LIBUSB_SUCCESS ? transfer->actual_length : transfer->length);
+	if ((result != LIBUSB_SUCCESS) && (send->result == LIBUSB_SUCCESS))
+		send->result = result;
+
+	// Reuse the transfer for the next chunk, unless a transfer has failed
+	if ((send->result == LIBUSB_SUCCESS) && (send->next < send->size))
+	{
+		transfer->buffer = send->buffer + send->next;
+		transfer->length = (int)std::min(send->transferSize, send->size - send->next);
+		int submitResult = libusb_submit_transfer(transfer);
+		if (submitResult == LIBUSB_SUCCESS)
+		{
+			send->next += transfer->length;
+			return;
+		}
+		send->result = submitResult;
+	}
+	send->pending--;
+	if (send->pending == 0)
+		send->done = 1;
+}
+
+// Sends the frame buffer in bulk transfers of at most transferSize bytes, keeping up to numTransfers in flight, returning a libusb result
+// Transfers to one endpoint complete in the order submitted, so the DAC receives the same stream of packets as from SendBulk()
+int HeliosDac::HeliosDacUsbDevice::SendBulkAsync(unsigned int transferSize, unsigned int numTransfers, unsigned int timeout)
+{
+	HeliosBulkSend send = { frameBuffer, frameBufferSize, transferSize, 0, 0, 0, LIBUSB_SUCCESS };
+	std::vector<libusb_transfer*> transfers;
+	for (unsigned int i = 0; (i < numTransfers) && (send.next < send.size); i++)
+	{
+		libusb_transfer* transfer = libusb_alloc_transfer(0);
+		if (transfer == NULL)
+		{
+			send.result = LIBUSB_ERROR_NO_MEM;
+			break;
+		}
+		transfers.push_back(transfer);
+		int length = (int)std::min(transferSize, send.size - send.next);
+		libusb_fill_bulk_transfer(transfer, usbHandle, EP_BULK_OUT, send.buffer + send.next, length, HeliosBulkSendCallback, &send, timeout);
+		int submitResult = libusb_submit_transfer(transfer);
+		if (submitResult != LIBUSB_SUCCESS)
+		{
+			send.result = submitResult;
+			break;
+		}
+		send.next += length;
+		send.pending++;
+	}
+
+	// The callbacks run in this thread, or in another thread handling events at the same time
+	while (send.pending > 0)
+	{
+		struct timeval tv = { 0, 100000 };
+		libusb_handle_events_timeout_completed(NULL, &tv, &send.done);
+	}
+
+	for (libusb_transfer* transfer : transfers)
+		libusb_free_transfer(transfer);
+	return send.result;
+}
+
+// Returns the timeout of a control transfer, defaultTimeout unless set with SetUsbTransferOptions()
+unsigned int HeliosDac::HeliosDacUsbDevice::ControlTimeout(unsigned int defaultTimeout)
+{
+	std::lock_guard<std::mutex> lock(optionsLock);
+	return transferOptions.controlTimeout != 0 ? transferOptions.controlTimeout : defaultTimeout;
+}
+
+void HeliosDac::HeliosDacUsbDevice::SetUsbTransferOptions(const HeliosUsbTransferOptions& options)
+{
+	std::lock_guard<std::mutex> lock(optionsLock);
+	transferOptions = options;
+}
+
 // Continually running thread, when a frame is ready, it is sent to the DAC
 // Only used if HELIOS_FLAGS_DONT_BLOCK is used with WriteFrame
 void HeliosDac::HeliosDacUsbDevice::BackgroundFrameHandler()
@@ -1267,7 +1512,7 @@ int HeliosDac::HeliosDacUsbDevice::GetName(char* dacName)
 		if (SendControl(ctrlBuffer4, 2) == HELIOS_SUCCESS)
 		{
 			std::uint8_t ctrlBuffer5[32];
-			int transferResult = libusb_interrupt_transfer(usbHandle, EP_INT_IN, ctrlBuffer5, sizeof(ctrlBuffer5), &actualLength, 32);
+			int transferResult = WireInterruptTransfer(usbHandle, EP_INT_IN, ctrlBuffer5, sizeof(ctrlBuffer5), &actualLength, ControlTimeout(32));
 
 			if (transferResult == LIBUSB_SUCCESS)
 			{
@@ -1314,7 +1559,7 @@ int HeliosDac::HeliosDacUsbDevice::GetStatus()
 	if (SendControl(ctrlBuffer, 2) == HELIOS_SUCCESS)
 	{
 		std::uint8_t ctrlBuffer2[32];
-		int transferResult = libusb_interrupt_transfer(usbHandle, EP_INT_IN, ctrlBuffer2, 32, &actualLength, 16);
+		int transferResult = WireInterruptTransfer(usbHandle, EP_INT_IN, ctrlBuffer2, 32, &actualLength, ControlTimeout(16));
 
 		//auto now = std::chrono::high_resolution_clock::now();
 		//auto time = std::chrono::duration_cast<std::chrono::milliseconds>(now - then);
@@ -1468,7 +1713,7 @@ int HeliosDac::HeliosDacUsbDevice::SendControl(std::uint8_t* bufferAddress, unsi
 	//auto then = std::chrono::high_resolution_clock::now();
 
 	int actualLength = 0;
-	int transferResult = libusb_interrupt_transfer(usbHandle, EP_INT_OUT, bufferAddress, length, &actualLength, 16);
+	int transferResult = WireInterruptTransfer(usbHandle, EP_INT_OUT, bufferAddress, length, &actualLength, ControlTimeout(16));
 
 	if (transferResult == LIBUSB_SUCCESS)
 		return HELIOS_SUCCESS;
@@ -1962,6 +2207,7 @@ int HeliosDac::HeliosDacIdnDevice::GetFirmwareVersion()
 	char buffer[20] = { (char)0xE5, (char)0x2 };
 	int sentBytes = 0;
 	sentBytes = sendto(managementSocket, buffer, 2, 0, (const sockaddr*)&managementSocketAddr, sizeof(managementSocketAddr));
+	WireRecord(HELIOS_WIRE_SENT, HELIOS_WIRE_UDP, 0, &managementSocketAddr, 0, (const std::uint8_t*)buffer, 2);
 
 	if (sentBytes != 2)
 	{
@@ -1975,6 +2221,7 @@ int HeliosDac::HeliosDacIdnDevice::GetFirmwareVersion()
 	socklen_t responseAddrLength = sizeof(managementSocketAddr);
 
 	int numBytes = recvfrom(managementSocket, buffer, sizeof(buffer), 0, &responseAddr, &responseAddrLength);
+	WireRecord(HELIOS_WIRE_RECEIVED, HELIOS_WIRE_UDP, 0, &managementSocketAddr, 0, (const std::uint8_t*)buffer, numBytes);
 	if (numBytes > 3)
 	{
 		if (buffer[0] == (char)0xE6 && buffer[1] == (char)0x2)
@@ -2099,6 +2346,7 @@ int HeliosDac::HeliosDacIdnDevice::SetName(char* name)
 	buffer[22] = '\0'; // Safety
 	int sentBytes = 0;
 	sentBytes = sendto(managementSocket, buffer, 22, 0, (const sockaddr*)&managementSocketAddr, sizeof(managementSocketAddr));
+	WireRecord(HELIOS_WIRE_SENT, HELIOS_WIRE_UDP, 0, &managementSocketAddr, 0, (const std::uint8_t*)buffer, 22);
 
 	if (sentBytes != 22)
 	{
@@ -2112,6 +2360,7 @@ int HeliosDac::HeliosDacIdnDevice::SetName(char* name)
 	socklen_t responseAddrLength = sizeof(managementSocketAddr);
 
 	int numBytes = recvfrom(managementSocket, buffer, sizeof(buffer), 0, &responseAddr, &responseAddrLength);
+	WireRecord(HELIOS_WIRE_RECEIVED, HELIOS_WIRE_UDP, 0, &managementSocketAddr, 0, (const std::uint8_t*)buffer, numBytes);
 	if (numBytes >= 2)
 	{
 		if (buffer[0] == (char)0xE6 && buffer[1] == (char)0x3)
@@ -143,6 +143,17 @@ Unless otherwise specified, functions return a negative error code on failure.
 
 #define MANAGEMENT_PORT 7355
 
+// Wire capture, see SetWireCallback()
+#define HELIOS_WIRE_SENT			0
+#define HELIOS_WIRE_RECEIVED		1
+
+#define HELIOS_WIRE_USB_INTERRUPT	0
+#define HELIOS_WIRE_USB_BULK		1
+#define HELIOS_WIRE_UDP				2
+
+// Size of the packets of the bulk endpoint; bulk transfers are split at multiples of it, see SetUsbTransferOptions()
+#define HELIOS_USB_PACKET_SIZE		64
+
 #ifdef _DEBUG
 #define LIBUSB_LOG_LEVEL LIBUSB_LOG_LEVEL_WARNING
 #else
@@ -183,6 +194,30 @@ typedef struct
 	std::uint16_t user4; // Unsigned 16 bit (valid values from 0 to 0xFFFF). Z position, X-prime, field change, or custom. Optional.
 } HeliosPointExt;
 
+// Tuning of the transfers to USB DACs, see SetUsbTransferOptions(). Zero fields keep the defaults.
+typedef struct
+{
+	unsigned int frameTimeout;		// Timeout of each bulk transfer of frame data, in ms. Zero means 8 ms plus 1 ms per 32 bytes transferred.
+	unsigned int controlTimeout;	// Timeout of control transfers (status, name, shutter etc.), in ms. Zero means 16 or 32 ms depending on the command.
+	unsigned int bulkTransferSize;	// Largest bulk transfer a frame is sent in, in bytes, rounded down to a multiple of HELIOS_USB_PACKET_SIZE. Zero sends each frame in one transfer.
+	unsigned int asyncTransfers;	// Number of bulk transfers of a frame in flight at once when bulkTransferSize splits it. Zero or one sends them one at a time.
+} HeliosUsbTransferOptions;
+
+// One USB transfer or network packet exchanged with a DAC, passed to the callback set with SetWireCallback().
+typedef struct
+{
+	int direction;				// HELIOS_WIRE_SENT or HELIOS_WIRE_RECEIVED.
+	int transport;				// HELIOS_WIRE_USB_INTERRUPT, HELIOS_WIRE_USB_BULK or HELIOS_WIRE_UDP.
+	unsigned int endpoint;		// USB endpoint address. Zero for network packets.
+	std::uint32_t address;		// IPv4 address of a network DAC, in host byte order. Zero for USB transfers.
+	unsigned int port;			// UDP port of a network DAC. Zero for USB transfers.
+	int result;					// libusb result of a USB transfer. Zero for network packets.
+	const std::uint8_t* data;	// Bytes transferred; for failed sends, the bytes that were to be sent. Only valid during the callback.
+	unsigned int length;		// Number of bytes in data.
+} HeliosWireRecord;
+
+typedef void (*HeliosWireCallback)(void* context, const HeliosWireRecord* record);
+
 class HeliosDac
 {
 public:
@@ -220,6 +255,13 @@ public:
 	int ReScanDevicesOnlyUsb();
 	int ReScanDevicesOnlyNetwork();
 
+	// Opens a connection to one service of an IDN network device at a known IPv4 address, without scanning, so applications doing their own discovery can pick which devices to open.
+	// Like ReScanDevices*(), this preserves existing devices and their device numbers, and the new device is added to the end of the device list.
+	// address: IPv4 address in dotted-decimal notation. serviceId: IDN service to stream to, as reported by the device's service map.
+	// name: name returned by GetName(), or NULL to use the address. unitId: the device's 16-byte IDN unit ID, used to recognize it on rescans, or NULL.
+	// Returns the device number, which is that of the existing device if the service is already open, or a negative error code.
+	int OpenNetworkDevice(const char* address, unsigned int serviceId, const char* name, const std::uint8_t* unitId);
+
 	// Closes and frees all devices.
 	int CloseDevices();
 
@@ -295,6 +337,16 @@ public:
 	// NB: For advanced use only, most software should never call this. 
 	int EraseFirmware(unsigned int devNum);
 
+	// Sets a callback receiving every USB transfer and network packet exchanged with any DAC of any HeliosDac instance, for protocol debugging.
+	// The callback is called on the thread doing the transfer, and must not call SetWireCallback() itself. Pass NULL to stop capturing.
+	// When this returns, calls to a previous callback have finished.
+	static void SetWireCallback(HeliosWireCallback callback, void* context);
+
+	// Sets the timeouts and sizes of the transfers to USB DACs, for the devices open now and the ones opened later.
+	// Longer timeouts and smaller transfers help on flaky hubs and long cables, at the cost of latency when a transfer fails.
+	// Pass NULL to restore the defaults.
+	void SetUsbTransferOptions(const HeliosUsbTransferOptions* options);
+
 private:
 
 	// Base class for individual DAC, for internal use
@@ -319,6 +371,7 @@ private:
 		virtual int Close() = 0;
 		virtual int EraseFirmware() = 0;
 		virtual bool GetDidSendFrameRecently() = 0;
+		virtual void SetUsbTransferOptions(const HeliosUsbTransferOptions& options) {}
 		bool GetIsClosed() { return closed; }
 
 	protected:
@@ -331,7 +384,7 @@ private:
 	{
 	public:
 
-		HeliosDacUsbDevice(libusb_device_handle*);
+		HeliosDacUsbDevice(libusb_device_handle*, const HeliosUsbTransferOptions& options);
 		~HeliosDacUsbDevice();
 
 		int SendFrame(unsigned int pps, std::uint8_t flags, HeliosPoint* points, unsigned int numOfPoints);
@@ -348,6 +401,7 @@ private:
 		int Close();
 		int EraseFirmware();
 		bool GetDidSendFrameRecently();
+		void SetUsbTransferOptions(const HeliosUsbTransferOptions& options);
 
 		libusb_device_handle* GetLibusbHandle();
 
@@ -357,6 +411,9 @@ private:
 		int DoFrame();
 		void BackgroundFrameHandler();
 		int SendControl(std::uint8_t* buffer, unsigned int bufferSize);
+		int SendBulk(unsigned int transferSize, unsigned int timeout);
+		int SendBulkAsync(unsigned int transferSize, unsigned int numTransfers, unsigned int timeout);
+		unsigned int ControlTimeout(unsigned int defaultTimeout);
 
 		unsigned int GetMaxSampleRate() { return HELIOS_MAX_PPS; } // TODO read exact capabilities from DAC
 		unsigned int GetMinSampleRate() { return HELIOS_MIN_PPS; } // TODO read exact capabilities from DAC
@@ -378,6 +435,8 @@ private:
 		int errorLimitCountdown = errorLimitResetValue;
 		bool threadingHasBeenUsed = false;
 		uint64_t lastSendTime = 0;
+		HeliosUsbTransferOptions transferOptions;
+		std::mutex optionsLock;
 	};
 
 	// Class for network (IDN) connected DACs such as HeliosPRO (but also work with other DACs supporting IDN), for internal use
@@ -395,6 +454,7 @@ private:
 		int GetSupportsHigherResolutions() { return 1; }
 		int GetIsUsb() { return 0; }
 		int GetUnitId(uint8_t* idArray);
+		bool GetIsService(std::uint32_t address, int serviceId) { return context->serverSockAddr.sin_addr.s_addr == address && context->serviceId == serviceId; }
 		int GetFirmwareVersion();
 		int GetName(char* name);
 		int SetName(char* name);
@@ -435,6 +495,7 @@ private:
 
 	std::vector<std::unique_ptr<HeliosDacDevice>> deviceList;
 	std::mutex threadLock;
+	HeliosUsbTransferOptions usbTransferOptions = {};
 	bool inited = false;
 	bool idnInited = false;
 	bool usbInited = false;
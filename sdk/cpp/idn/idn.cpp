@@ -55,6 +55,9 @@ static char int2Hex(unsigned i)
 }
 
 
+std::atomic<IdnSendHook> idnSendHook(NULL);
+
+
 static int idnSend(void* context, IDNHDR_PACKET* packetHdr, unsigned packetLen)
 {
 	IDNCONTEXT* ctx = (IDNCONTEXT*)context;
@@ -65,6 +68,10 @@ static int idnSend(void* context, IDNHDR_PACKET* packetHdr, unsigned packetLen)
 		return -1;
 	}
 
+	IdnSendHook hook = idnSendHook.load();
+	if (hook != NULL)
+		hook(&ctx->serverSockAddr, (const uint8_t*)packetHdr, packetLen);
+
 	return 0;
 }
 
@@ -12,6 +12,7 @@
 #include "idn-hello.h"
 #include <vector>
 #include <string>
+#include <atomic>
 
 
 #ifndef IDN_H
@@ -138,6 +139,11 @@ typedef struct
 } IDNCONTEXT;
 
 
+// Called with every packet sent to an IDN server, if set. Used for wire capture.
+// Atomic since it is set while the send threads are running.
+typedef void (*IdnSendHook)(const struct sockaddr_in* serverSockAddr, const uint8_t* packet, unsigned packetLen);
+extern std::atomic<IdnSendHook> idnSendHook;
+
 void logError(const char* fmt, ...);
 void logInfo(const char* fmt, ...);
 int idnOpenFrameGeneric(IDNCONTEXT* context, uint16_t* channelDescriptors, size_t numChannelDescriptors, bool forceNewConfig);
@@ -0,0 +1,188 @@
+package helios
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Accessory ports are the four user channels of PointExt, which some DACs
+// wire to TTL or analog outputs for fog machines, shutters or effects. A
+// device's accessories name its ports and say what to send on them, and
+// WriteFrameExtended fills those ports on every point, so content never
+// has to carry them.
+
+// Accessory drives one user port of a device.
+type Accessory struct {
+	Name string `json:"name"`
+
+	// Port is the user channel driven, 1 to 4.
+	Port int `json:"port"`
+
+	// Value is sent on every point while Envelope is empty.
+	Value uint16 `json:"value"`
+
+	// Envelope, if set, varies the value along each frame, for example to
+	// pulse a TTL output at the start of every frame.
+	Envelope []EnvelopeStep `json:"envelope,omitempty"`
+
+	// Ramp interpolates linearly between envelope steps instead of holding
+	// each until the next, for analog outputs.
+	Ramp bool `json:"ramp,omitempty"`
+}
+
+// EnvelopeStep sets a port to Value from position Pos along the frame, 0
+// at the first point and 1 at the last. Before the first step the first
+// step's value applies.
+type EnvelopeStep struct {
+	Pos   float64 `json:"pos"`
+	Value uint16  `json:"value"`
+}
+
+// Validate checks that the port is between 1 and 4 and the envelope's
+// positions are in order within 0 - 1.
+func (a Accessory) Validate() error {
+	if a.Port < 1 || a.Port > 4 {
+		return fmt.Errorf("helios: accessory %q: port %d is not 1 - 4", a.Name, a.Port)
+	}
+	for i, s := range a.Envelope {
+		if s.Pos < 0 || s.Pos > 1 || i > 0 && s.Pos < a.Envelope[i-1].Pos {
+			return fmt.Errorf("helios: accessory %q: envelope step %d at %v is out of order", a.Name, i, s.Pos)
+		}
+	}
+	return nil
+}
+
+// at returns the value at position pos along the frame.
+func (a Accessory) at(pos float64) uint16 {
+	env := a.Envelope
+	if len(env) == 0 {
+		return a.Value
+	}
+	i, _ := slices.BinarySearchFunc(env, pos, func(s EnvelopeStep, pos float64) int {
+		if s.Pos <= pos {
+			return -1
+		}
+		return 1
+	})
+	switch {
+	case i == 0:
+		return env[0].Value
+	case i == len(env) || !a.Ramp:
+		return env[i-1].Value
+	}
+	prev, next := env[i-1], env[i]
+	f := (pos - prev.Pos) / (next.Pos - prev.Pos)
+	return uint16(float64(prev.Value) + (float64(next.Value)-float64(prev.Value))*f + 0.5)
+}
+
+// SetAccessories replaces the accessories of one device. Each port and
+// name may be used once; nil removes them all. They apply from the next
+// extended frame written.
+func (d *DAC) SetAccessories(deviceIndex int, accessories []Accessory) error {
+	var ports [5]bool
+	names := make(map[string]bool)
+	for _, a := range accessories {
+		if err := a.Validate(); err != nil {
+			return err
+		}
+		if ports[a.Port] || names[a.Name] {
+			return fmt.Errorf("helios: accessory %q: port %d or name used twice", a.Name, a.Port)
+		}
+		ports[a.Port], names[a.Name] = true, true
+	}
+	d.levels.mu.Lock()
+	defer d.levels.mu.Unlock()
+	if len(accessories) == 0 {
+		delete(d.levels.accessories, deviceIndex)
+		return nil
+	}
+	if d.levels.accessories == nil {
+		d.levels.accessories = make(map[int][]Accessory)
+	}
+	d.levels.accessories[deviceIndex] = cloneAccessories(accessories)
+	return nil
+}
+
+// Accessories returns a copy of the accessories of one device.
+func (d *DAC) Accessories(deviceIndex int) []Accessory {
+	return cloneAccessories(d.levels.deviceAccessories(deviceIndex))
+}
+
+// SetAccessoryValue sends a static value on the named accessory of a
+// device, replacing its envelope.
+func (d *DAC) SetAccessoryValue(deviceIndex int, name string, value uint16) error {
+	return d.updateAccessory(deviceIndex, name, func(a *Accessory) {
+		a.Value, a.Envelope = value, nil
+	})
+}
+
+// SetAccessoryEnvelope varies the named accessory of a device along every
+// frame; an empty envelope returns it to its static value.
+func (d *DAC) SetAccessoryEnvelope(deviceIndex int, name string, envelope []EnvelopeStep, ramp bool) error {
+	return d.updateAccessory(deviceIndex, name, func(a *Accessory) {
+		a.Envelope, a.Ramp = slices.Clone(envelope), ramp
+	})
+}
+
+func (d *DAC) updateAccessory(deviceIndex int, name string, update func(*Accessory)) error {
+	d.levels.mu.Lock()
+	defer d.levels.mu.Unlock()
+	accessories := d.levels.accessories[deviceIndex]
+	i := slices.IndexFunc(accessories, func(a Accessory) bool { return a.Name == name })
+	if i < 0 {
+		return fmt.Errorf("helios: device %d has no accessory %q", deviceIndex, name)
+	}
+	// Writers may hold the current slice, so it is replaced, not changed.
+	accessories = cloneAccessories(accessories)
+	update(&accessories[i])
+	if err := accessories[i].Validate(); err != nil {
+		return err
+	}
+	d.levels.accessories[deviceIndex] = accessories
+	return nil
+}
+
+// deviceAccessories returns the accessories of a device. The slice is
+// replaced, never modified, so it can be used after the lock is released.
+func (l *levels) deviceAccessories(deviceIndex int) []Accessory {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.accessories[deviceIndex]
+}
+
+func cloneAccessories(accessories []Accessory) []Accessory {
+	if accessories == nil {
+		return nil
+	}
+	out := slices.Clone(accessories)
+	for i := range out {
+		out[i].Envelope = slices.Clone(out[i].Envelope)
+	}
+	return out
+}
+
+// fillAccessories returns points with the accessory ports set, copying
+// them only if there are accessories.
+func fillAccessories(points []PointExt, accessories []Accessory) []PointExt {
+	if len(accessories) == 0 {
+		return points
+	}
+	out := slices.Clone(points)
+	last := float64(max(len(out)-1, 1))
+	for _, a := range accessories {
+		for i := range out {
+			v := a.at(float64(i) / last)
+			switch a.Port {
+			case 1:
+				out[i].User1 = v
+			case 2:
+				out[i].User2 = v
+			case 3:
+				out[i].User3 = v
+			case 4:
+				out[i].User4 = v
+			}
+		}
+	}
+	return out
+}
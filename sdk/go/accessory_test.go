@@ -0,0 +1,64 @@
+package helios
+
+import "testing"
+
+func TestFillAccessories(t *testing.T) {
+	d := &DAC{levels: newLevels()}
+	err := d.SetAccessories(0, []Accessory{
+		{Name: "fog", Port: 1, Value: 0xFFFF},
+		{Name: "sync", Port: 3, Envelope: []EnvelopeStep{{Pos: 0, Value: 0xFFFF}, {Pos: 0.25, Value: 0}}},
+		{Name: "dimmer", Port: 4, Ramp: true, Envelope: []EnvelopeStep{{Pos: 0, Value: 0}, {Pos: 1, Value: 1000}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	points := make([]PointExt, 5)
+	points[0].User2 = 7
+	out := fillAccessories(points, d.levels.deviceAccessories(0))
+	for i, p := range out {
+		sync := uint16(0)
+		if i < 1 {
+			sync = 0xFFFF
+		}
+		if p.User1 != 0xFFFF || p.User3 != sync || p.User4 != uint16(i*250) {
+			t.Errorf("point %d = %+v", i, p)
+		}
+	}
+	if out[0].User2 != 7 || points[0].User1 != 0 {
+		t.Fatal("undriven port changed or input modified")
+	}
+
+	if err := d.SetAccessoryValue(0, "sync", 5); err != nil {
+		t.Fatal(err)
+	}
+	if a := d.Accessories(0)[1]; a.Value != 5 || a.Envelope != nil {
+		t.Fatalf("sync after SetAccessoryValue = %+v", a)
+	}
+	if err := d.SetAccessoryEnvelope(0, "fog", []EnvelopeStep{{Pos: 2}}, false); err == nil {
+		t.Fatal("envelope beyond the frame accepted")
+	}
+	if err := d.SetAccessoryValue(0, "haze", 1); err == nil {
+		t.Fatal("unknown accessory accepted")
+	}
+	if err := d.SetAccessoryValue(1, "fog", 1); err == nil {
+		t.Fatal("accessory of another device accepted")
+	}
+}
+
+func TestSetAccessoriesRejects(t *testing.T) {
+	d := &DAC{levels: newLevels()}
+	for _, acc := range [][]Accessory{
+		{{Name: "a", Port: 0}},
+		{{Name: "a", Port: 5}},
+		{{Name: "a", Port: 1}, {Name: "b", Port: 1}},
+		{{Name: "a", Port: 1}, {Name: "a", Port: 2}},
+		{{Name: "a", Port: 1, Envelope: []EnvelopeStep{{Pos: 0.5}, {Pos: 0.25}}}},
+	} {
+		if err := d.SetAccessories(0, acc); err == nil {
+			t.Errorf("SetAccessories(%+v) accepted", acc)
+		}
+	}
+	if d.Accessories(0) != nil {
+		t.Fatal("rejected accessories were stored")
+	}
+}
@@ -0,0 +1,35 @@
+package helios
+
+// AdaptFrame fits a frame to limits instead of letting the device reject it.
+// A frame with too many points keeps every k-th point and plays at pps/k, so
+// it lasts about as long as the original, the same subsampling the C++ SDK
+// applies to USB devices. The rate is then clamped to the device range. The
+// caller's slice is never modified.
+func AdaptFrame(points []Point, pps int, limits FrameLimits) ([]Point, int) {
+	return adaptFrame(points, pps, limits)
+}
+
+func adaptFrame[P any](points []P, pps int, limits FrameLimits) ([]P, int) {
+	if n := len(points); limits.MaxPoints > 0 && n > limits.MaxPoints {
+		k := (n + limits.MaxPoints - 1) / limits.MaxPoints
+		out := make([]P, 0, (n+k-1)/k)
+		for i := 0; i < n; i += k {
+			out = append(out, points[i])
+		}
+		points, pps = out, pps/k
+	}
+	return points, min(max(pps, limits.MinPPS), limits.MaxPPS)
+}
+
+// SetAdaptFrames sets whether frames written with the WriteFrame methods are
+// fitted to the device with AdaptFrame before validation, so code written
+// for one DAC model keeps working on another with lower limits. The default
+// is off.
+func (d *DAC) SetAdaptFrames(on bool) {
+	d.adapt.Store(on)
+}
+
+// AdaptFrames reports whether SetAdaptFrames is on.
+func (d *DAC) AdaptFrames() bool {
+	return d.adapt.Load()
+}
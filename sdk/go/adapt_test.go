@@ -0,0 +1,29 @@
+package helios
+
+import "testing"
+
+func TestAdaptFrame(t *testing.T) {
+	frame := make([]Point, 10000)
+	for i := range frame {
+		frame[i].X = uint16(i % 4096)
+	}
+
+	got, pps := AdaptFrame(frame, 60000, LimitsUsb)
+	// 10000 points over 4095 keeps every third point at a third of the rate.
+	if len(got) != 3334 || pps != 20000 || got[1].X != 3 {
+		t.Fatalf("oversized: %d points at %d pps", len(got), pps)
+	}
+	if _, err := ValidateFrame(got, pps, LimitsUsb, ValidateStrict); err != nil {
+		t.Fatalf("adapted frame fails validation: %v", err)
+	}
+
+	if got, pps := AdaptFrame(frame[:100], 90000, LimitsUsb); len(got) != 100 || &got[0] != &frame[0] || pps != LimitsUsb.MaxPPS {
+		t.Fatalf("fast: %d points at %d pps", len(got), pps)
+	}
+	if _, pps := AdaptFrame(frame[:100], 1, LimitsNetwork); pps != LimitsNetwork.MinPPS {
+		t.Fatalf("slow: %d pps", pps)
+	}
+	if got, pps := AdaptFrame(frame, 60000, LimitsNetwork); len(got) != 5000 || pps != 30000 {
+		t.Fatalf("network: %d points at %d pps", len(got), pps)
+	}
+}
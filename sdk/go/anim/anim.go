@@ -0,0 +1,189 @@
+// Package anim runs animations on a fixed logical timestep, the way game
+// engines do, so they play out the same however often and however
+// irregularly frames are rendered.
+//
+// A Runtime accumulates the time passed to it and advances its Sim in
+// whole steps of Step, carrying the remainder over to the next frame. The
+// Runtime is a scene.Layer, so a stream.Streamer renders it at the pace of
+// the output: a frame falling between two steps blends the frames of the
+// last two states by how far it falls past the older one, so motion stays
+// smooth a step behind, and a render that falls behind catches up with a
+// bounded number of steps rather than drifting.
+//
+//	rt := anim.New(anim.FromLayer(&generators.Lissajous{}))
+//	s := stream.New(out, rt, nil)
+package anim
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/scene"
+)
+
+// DefaultStep is the logical timestep of a Runtime whose Step is zero.
+const DefaultStep = time.Second / 120
+
+// DefaultMaxSteps is the most steps a Runtime whose MaxSteps is zero takes
+// for one frame.
+const DefaultMaxSteps = 30
+
+// Sim is animation state advanced in fixed steps.
+type Sim interface {
+	// Step advances the state by dt.
+	Step(dt time.Duration)
+
+	// Points draws the current state in at most budget points.
+	Points(budget int) []helios.Point
+}
+
+// FromLayer returns a Sim drawing l at its logical time, for stateless
+// generators: l is drawn at whole steps only, so it animates from the
+// Runtime's time rather than the output's.
+func FromLayer(l scene.Layer) Sim {
+	return &layerSim{layer: l}
+}
+
+type layerSim struct {
+	layer scene.Layer
+	now   time.Duration
+}
+
+func (s *layerSim) Step(dt time.Duration) { s.now += dt }
+
+func (s *layerSim) Points(budget int) []helios.Point { return s.layer.Points(s.now, budget) }
+
+// Runtime drives a Sim on a fixed timestep. Its methods are safe for
+// concurrent use.
+type Runtime struct {
+	// Step is the logical timestep. Zero means DefaultStep.
+	Step time.Duration
+
+	// MaxSteps bounds the steps taken for one frame. Time beyond it is
+	// dropped, so a stalled render slows the animation down instead of
+	// spending ever longer catching up. Zero means DefaultMaxSteps.
+	MaxSteps int
+
+	// NoBlend draws the latest state as it is, instead of blending it with
+	// the one before by how far the frame falls between them.
+	NoBlend bool
+
+	sim Sim
+
+	mu      sync.Mutex
+	started bool
+	last    time.Duration // the frame time last passed to Points
+	acc     time.Duration // time not yet stepped
+	steps   int64
+	dropped time.Duration
+	budget  int            // the budget prev and cur were drawn with
+	prev    []helios.Point // the state before the latest step
+	cur     []helios.Point // the latest state
+}
+
+// New creates a Runtime driving sim from logical time zero.
+func New(sim Sim) *Runtime {
+	return &Runtime{sim: sim}
+}
+
+func (r *Runtime) step() time.Duration {
+	if r.Step > 0 {
+		return r.Step
+	}
+	return DefaultStep
+}
+
+func (r *Runtime) maxSteps() int {
+	if r.MaxSteps > 0 {
+		return r.MaxSteps
+	}
+	return DefaultMaxSteps
+}
+
+// Points advances the Sim by the time since the previous call and draws
+// it. The first call, and a t earlier than the previous one, as after a
+// seek, advance nothing.
+func (r *Runtime) Points(t time.Duration, budget int) []helios.Point {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.started && t > r.last {
+		r.acc += t - r.last
+	}
+	r.started, r.last = true, t
+
+	step := r.step()
+	n := int(r.acc / step)
+	if most := r.maxSteps(); n > most {
+		r.dropped += r.acc - time.Duration(most)*step
+		r.acc, n = 0, most
+	} else {
+		r.acc -= time.Duration(n) * step
+	}
+	switch {
+	case n > 0:
+		for range n - 1 {
+			r.sim.Step(step)
+		}
+		if !r.NoBlend {
+			r.prev = r.sim.Points(budget)
+		}
+		r.sim.Step(step)
+		r.steps += int64(n)
+		r.cur, r.budget = r.sim.Points(budget), budget
+	case r.cur == nil || budget != r.budget:
+		r.prev, r.cur, r.budget = nil, r.sim.Points(budget), budget
+	}
+	if r.NoBlend || len(r.prev) != len(r.cur) {
+		return append([]helios.Point(nil), r.cur...)
+	}
+	return blend(r.prev, r.cur, float64(r.acc)/float64(step))
+}
+
+// Time returns the logical time, the number of steps taken times Step.
+func (r *Runtime) Time() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return time.Duration(r.steps) * r.step()
+}
+
+// Steps returns the number of steps taken.
+func (r *Runtime) Steps() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.steps
+}
+
+// Dropped returns the time dropped by frames that would have taken more
+// than MaxSteps steps: how far the logical time lags the frame times.
+func (r *Runtime) Dropped() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.dropped
+}
+
+// blend returns the points alpha of the way from a to b, which have the
+// same length. Positions and colors are interpolated; a point is lit if it
+// is lit in b.
+func blend(a, b []helios.Point, alpha float64) []helios.Point {
+	out := make([]helios.Point, len(b))
+	for i, q := range b {
+		p := a[i]
+		out[i] = helios.Point{
+			X: helios.ClampToCoord(lerp(p.X, q.X, alpha)),
+			Y: helios.ClampToCoord(lerp(p.Y, q.Y, alpha)),
+		}
+		if q.I == 0 && q.R == 0 && q.G == 0 && q.B == 0 {
+			continue
+		}
+		out[i].R = uint8(lerp(p.R, q.R, alpha) + 0.5)
+		out[i].G = uint8(lerp(p.G, q.G, alpha) + 0.5)
+		out[i].B = uint8(lerp(p.B, q.B, alpha) + 0.5)
+		out[i].I = uint8(lerp(p.I, q.I, alpha) + 0.5)
+	}
+	return out
+}
+
+func lerp[T uint8 | uint16](a, b T, alpha float64) float64 {
+	return float64(a) + (float64(b)-float64(a))*alpha
+}
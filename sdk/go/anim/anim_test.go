@@ -0,0 +1,96 @@
+package anim
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/scene"
+)
+
+// walker moves a dot right one unit per millisecond.
+type walker struct{ x float64 }
+
+func (w *walker) Step(dt time.Duration) { w.x += float64(dt) / float64(time.Millisecond) }
+
+func (w *walker) Points(int) []helios.Point {
+	return []helios.Point{{X: helios.ClampToCoord(w.x), I: 255}}
+}
+
+func TestDeterministic(t *testing.T) {
+	// Steady and irregular frame times reach the same state.
+	steady, ragged := &walker{}, &walker{}
+	a, b := New(steady), New(ragged)
+	a.Step, b.Step = 10*time.Millisecond, 10*time.Millisecond
+	b.MaxSteps = 100
+	for ms := 0; ms <= 1000; ms += 16 {
+		a.Points(time.Duration(ms)*time.Millisecond, 1)
+	}
+	for _, ms := range []int{0, 3, 250, 251, 700, 990, 992} {
+		b.Points(time.Duration(ms)*time.Millisecond, 1)
+	}
+	if a.Steps() != 99 || b.Steps() != 99 || steady.x != ragged.x {
+		t.Errorf("steps %d and %d, states %v and %v", a.Steps(), b.Steps(), steady.x, ragged.x)
+	}
+	if got := a.Time(); got != 990*time.Millisecond {
+		t.Errorf("Time = %v", got)
+	}
+}
+
+func TestBlend(t *testing.T) {
+	r := New(&walker{})
+	r.Step = 10 * time.Millisecond
+	r.Points(0, 1)
+	// One step taken with half a step left over: halfway between x=0 and
+	// x=10.
+	if got := r.Points(15*time.Millisecond, 1); got[0].X != 5 || got[0].I != 255 {
+		t.Errorf("blended frame = %+v", got[0])
+	}
+	if got := r.Points(18*time.Millisecond, 1); got[0].X != 8 {
+		t.Errorf("blended frame = %+v", got[0])
+	}
+	r.NoBlend = true
+	if got := r.Points(19*time.Millisecond, 1); got[0].X != 10 {
+		t.Errorf("unblended frame = %+v", got[0])
+	}
+}
+
+func TestMaxSteps(t *testing.T) {
+	w := &walker{}
+	r := New(w)
+	r.Step, r.MaxSteps = time.Millisecond, 5
+	r.Points(0, 1)
+	r.Points(time.Second, 1)
+	if r.Steps() != 5 || r.Dropped() != 995*time.Millisecond {
+		t.Errorf("steps %d, dropped %v", r.Steps(), r.Dropped())
+	}
+
+	// Going back in time advances nothing.
+	r.Points(500*time.Millisecond, 1)
+	r.Points(502*time.Millisecond, 1)
+	if r.Steps() != 7 {
+		t.Errorf("steps after seeking back = %d", r.Steps())
+	}
+}
+
+func TestFromLayer(t *testing.T) {
+	var drawn []time.Duration
+	l := scene.LayerFunc(func(t time.Duration, budget int) []helios.Point {
+		drawn = append(drawn, t)
+		return make([]helios.Point, budget)
+	})
+	r := New(FromLayer(l))
+	r.Step = 10 * time.Millisecond
+	r.Points(time.Hour, 2)
+	r.Points(time.Hour+25*time.Millisecond, 2)
+	// The layer is drawn at logical times, before and after the last step.
+	want := []time.Duration{0, 10 * time.Millisecond, 20 * time.Millisecond}
+	if len(drawn) != len(want) {
+		t.Fatalf("drawn at %v, want %v", drawn, want)
+	}
+	for i := range want {
+		if drawn[i] != want[i] {
+			t.Errorf("drawn at %v, want %v", drawn, want)
+		}
+	}
+}
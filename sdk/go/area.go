@@ -0,0 +1,101 @@
+package helios
+
+import (
+	"errors"
+	"math"
+)
+
+// Area relates device coordinates to physical units on a flat surface
+// square to the projector's center beam. The galvos turn in proportion to
+// the coordinates, so a coordinate is an angle: the full range sweeps the
+// optical scan angle, and a position on the surface lies at the distance
+// times the tangent of its angle. Lengths are in millimeters and angles in
+// degrees; the pincushion distortion of a two-mirror scanner is ignored.
+type Area struct {
+	// Distance is the throw distance from the scanner to the surface.
+	Distance float64 `json:"distance_mm"`
+
+	// ScanAngleX and ScanAngleY are the full optical angles swept by the
+	// range of X and Y, usually the scanner's rated angle or the angle the
+	// projector's size setting leaves.
+	ScanAngleX float64 `json:"scan_angle_x_deg"`
+	ScanAngleY float64 `json:"scan_angle_y_deg"`
+}
+
+// AreaFromThrow returns the Area of a projector throwing at distance with
+// the same scan angle on both axes.
+func AreaFromThrow(distance, angle float64) Area {
+	return Area{Distance: distance, ScanAngleX: angle, ScanAngleY: angle}
+}
+
+// Validate checks that the distance is positive and the scan angles are
+// between 0 and 180 degrees, exclusive.
+func (a Area) Validate() error {
+	if !(a.Distance > 0) || math.IsInf(a.Distance, 0) {
+		return errors.New("helios: area distance must be positive")
+	}
+	for _, angle := range []float64{a.ScanAngleX, a.ScanAngleY} {
+		if !(angle > 0 && angle < 180) {
+			return errors.New("helios: area scan angles must be between 0 and 180 degrees")
+		}
+	}
+	return nil
+}
+
+// Size returns the width and height of the area on the surface.
+func (a Area) Size() (width, height float64) {
+	return 2 * a.Distance * tanDeg(a.ScanAngleX/2), 2 * a.Distance * tanDeg(a.ScanAngleY/2)
+}
+
+// Normalize converts a point in millimeters from the center of the area,
+// X to the right and Y up, to normalized units. Colors are kept. Points
+// beyond the edges lie outside -1 to 1 and are clamped on conversion.
+func (a Area) Normalize(p PointF) PointF {
+	p.X = a.normalize(p.X, a.ScanAngleX)
+	p.Y = a.normalize(p.Y, a.ScanAngleY)
+	return p
+}
+
+// Physical converts a point in normalized units to millimeters from the
+// center of the area. It is the inverse of Normalize.
+func (a Area) Physical(p PointF) PointF {
+	p.X = a.Distance * tanDeg(p.X*a.ScanAngleX/2)
+	p.Y = a.Distance * tanDeg(p.Y*a.ScanAngleY/2)
+	return p
+}
+
+// ToDevice converts a point in millimeters from the center of the area to
+// the standard point structure. Use Normalize and PointF.HighRes for the
+// 16-bit coordinates.
+func (a Area) ToDevice(p PointF) Point {
+	return a.Normalize(p).Point()
+}
+
+// FromDevice converts a standard point to millimeters from the center of
+// the area.
+func (a Area) FromDevice(p Point) PointF {
+	return a.Physical(p.PointF())
+}
+
+// Units returns the number of standard coordinate units a length spans at
+// the center of the area along X, such as the radius of a small shape.
+// Toward the edges the same length spans fewer units.
+func (a Area) Units(length float64) float64 {
+	return a.normalize(length, a.ScanAngleX) * (maxCoord + 1) / 2
+}
+
+// DegreesToUnits returns the number of standard coordinate units the beam
+// sweeps across an optical angle along X.
+func (a Area) DegreesToUnits(angle float64) float64 {
+	return angle / a.ScanAngleX * (maxCoord + 1)
+}
+
+// normalize maps a length from the center to normalized units along an
+// axis sweeping angle.
+func (a Area) normalize(v, angle float64) float64 {
+	return math.Atan2(v, a.Distance) * 180 / math.Pi / (angle / 2)
+}
+
+func tanDeg(deg float64) float64 {
+	return math.Tan(deg * math.Pi / 180)
+}
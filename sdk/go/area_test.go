@@ -0,0 +1,97 @@
+package helios
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAreaSize(t *testing.T) {
+	// 90 degrees at 1m sweeps 2m.
+	w, h := Area{Distance: 1000, ScanAngleX: 90, ScanAngleY: 60}.Size()
+	if math.Abs(w-2000) > 1e-9 || math.Abs(h-2*1000/math.Sqrt(3)) > 1e-9 {
+		t.Errorf("Size = %v x %v", w, h)
+	}
+}
+
+func TestAreaRoundTrip(t *testing.T) {
+	a := AreaFromThrow(3000, 40)
+	w, _ := a.Size()
+	tests := []PointF{
+		{X: 0, Y: 0},
+		{X: w / 2, Y: -w / 2},
+		{X: 100, Y: 250, R: 1, I: 0.5},
+	}
+	for _, p := range tests {
+		n := a.Normalize(p)
+		if n.R != p.R || n.I != p.I {
+			t.Errorf("Normalize(%v) changed the colors: %v", p, n)
+		}
+		back := a.Physical(n)
+		if math.Abs(back.X-p.X) > 1e-9 || math.Abs(back.Y-p.Y) > 1e-9 {
+			t.Errorf("Physical(Normalize(%v)) = %v", p, back)
+		}
+	}
+	// The edges of the area are the edges of the field.
+	if n := a.Normalize(PointF{X: w / 2, Y: -w / 2}); math.Abs(n.X-1) > 1e-12 || math.Abs(n.Y+1) > 1e-12 {
+		t.Errorf("edge normalized to %v", n)
+	}
+}
+
+func TestAreaToDevice(t *testing.T) {
+	a := AreaFromThrow(2000, 30)
+	w, _ := a.Size()
+	tests := []struct {
+		in   PointF
+		want Point
+	}{
+		{PointF{}, Point{X: 2048, Y: 2048}},
+		{PointF{X: w / 2, Y: w / 2, G: 1}, Point{X: 4095, Y: 4095, G: 255}},
+		{PointF{X: -w / 2}, Point{X: 0, Y: 2048}},
+		// Beyond the edge clamps.
+		{PointF{X: 10 * w}, Point{X: 4095, Y: 2048}},
+	}
+	for _, tt := range tests {
+		if got := a.ToDevice(tt.in); got != tt.want {
+			t.Errorf("ToDevice(%v) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+	p := a.FromDevice(Point{X: 4095, Y: 0})
+	if math.Abs(p.X-w/2) > 1e-9 || math.Abs(p.Y+w/2) > 1e-9 {
+		t.Errorf("FromDevice(corner) = %v, want ±%v", p, w/2)
+	}
+}
+
+func TestAreaUnits(t *testing.T) {
+	a := AreaFromThrow(1000, 40)
+	// At the center a small length spans its share of the width, in
+	// angle: 30mm at 1m is atan(0.03) of 40 degrees.
+	want := math.Atan(0.03) * 180 / math.Pi / 20 * 2048
+	if got := a.Units(30); math.Abs(got-want) > 1e-9 {
+		t.Errorf("Units(30) = %v, want %v", got, want)
+	}
+	if got := a.DegreesToUnits(10); got != 1024 {
+		t.Errorf("DegreesToUnits(10) = %v, want 1024", got)
+	}
+}
+
+func TestAreaValidate(t *testing.T) {
+	tests := []struct {
+		a  Area
+		ok bool
+	}{
+		{AreaFromThrow(1000, 40), true},
+		{Area{Distance: 1000, ScanAngleX: 40, ScanAngleY: 20}, true},
+		{AreaFromThrow(0, 40), false},
+		{AreaFromThrow(-1, 40), false},
+		{AreaFromThrow(math.Inf(1), 40), false},
+		{AreaFromThrow(math.NaN(), 40), false},
+		{AreaFromThrow(1000, 0), false},
+		{AreaFromThrow(1000, 180), false},
+		{Area{Distance: 1000, ScanAngleX: 40}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.a.Validate(); (err == nil) != tt.ok {
+			t.Errorf("%+v.Validate() = %v", tt.a, err)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package artnet
+
+import "github.com/Grix/helios_dac/sdk/go/scene"
+
+// ChannelMap assigns show parameters to DMX channels of one universe.
+// Channels are 1-based DMX addresses as shown on lighting desks; zero leaves
+// a parameter unmapped. It is intended to be loaded from a config file.
+type ChannelMap struct {
+	// Universe is the 15-bit Art-Net port address (net, sub-net, universe).
+	Universe int `json:"universe"`
+
+	Dimmer  int `json:"dimmer"`
+	Red     int `json:"red"`
+	Green   int `json:"green"`
+	Blue    int `json:"blue"`
+	Pattern int `json:"pattern"`
+	OffsetX int `json:"offset_x"`
+	OffsetY int `json:"offset_y"`
+	Size    int `json:"size"`
+
+	// Duck is a channel that ducks the output while at 50% or above, for
+	// lighting desks that cue pyro or camera moments. It is not part of
+	// DefaultChannelMap.
+	Duck int `json:"duck,omitempty"`
+}
+
+// DefaultChannelMap is a common 8-channel laser fixture layout starting at address 1.
+var DefaultChannelMap = ChannelMap{
+	Dimmer:  1,
+	Red:     2,
+	Green:   3,
+	Blue:    4,
+	Pattern: 5,
+	OffsetX: 6,
+	OffsetY: 7,
+	Size:    8,
+}
+
+// State is the decoded value of every mapped parameter. Unmapped
+// parameters keep neutral values (full dimmer and color, no offset, full size).
+type State struct {
+	// Dimmer, Red, Green, Blue and Size are in the range 0.0 - 1.0.
+	Dimmer, Red, Green, Blue float64
+	Size                     float64
+
+	// OffsetX and OffsetY are in the range -1.0 - 1.0, centered at DMX value 128.
+	OffsetX, OffsetY float64
+
+	// Pattern is the raw 0 - 255 value of the pattern select channel.
+	Pattern int
+
+	// Duck is set while the duck channel is at 128 or above. Pass it to
+	// helios.DAC.SetDucked from the receiver's OnChange.
+	Duck bool
+}
+
+// Decode reads the mapped parameters from a universe's channel data.
+func (m ChannelMap) Decode(dmx []byte) State {
+	unit := func(ch int) float64 {
+		if ch <= 0 || ch > len(dmx) {
+			return 1
+		}
+		return float64(dmx[ch-1]) / 255
+	}
+	centered := func(ch int) float64 {
+		if ch <= 0 || ch > len(dmx) {
+			return 0
+		}
+		return (float64(dmx[ch-1]) - 128) / 127
+	}
+	s := State{
+		Dimmer:  unit(m.Dimmer),
+		Red:     unit(m.Red),
+		Green:   unit(m.Green),
+		Blue:    unit(m.Blue),
+		Size:    unit(m.Size),
+		OffsetX: max(-1, centered(m.OffsetX)),
+		OffsetY: max(-1, centered(m.OffsetY)),
+	}
+	if m.Pattern > 0 && m.Pattern <= len(dmx) {
+		s.Pattern = int(dmx[m.Pattern-1])
+	}
+	if m.Duck > 0 && m.Duck <= len(dmx) {
+		s.Duck = dmx[m.Duck-1] >= 128
+	}
+	return s
+}
+
+// Color returns the dimmer and color channels as a scene color modulation.
+func (s State) Color() scene.ColorMod {
+	return scene.ColorMod{
+		R: s.Red * s.Dimmer,
+		G: s.Green * s.Dimmer,
+		B: s.Blue * s.Dimmer,
+		I: s.Dimmer,
+	}
+}
+
+// Transform returns the size and offset channels as a scene transform that
+// scales around the center of the projection field and then moves content
+// by up to half the field in each direction.
+func (s State) Transform() scene.Transform {
+	const center = 2048
+	return scene.Scale(s.Size, s.Size, center, center).
+		Then(scene.Translate(s.OffsetX*center, s.OffsetY*center))
+}
@@ -0,0 +1,149 @@
+// Package artnet bridges Art-Net DMX input from lighting desks to show
+// parameters.
+//
+// A Receiver listens for ArtDmx packets on one universe and decodes the
+// channels through a ChannelMap into a State (master dimmer, color, pattern
+// select, position and size). The State converts directly to scene color
+// modulation and transforms.
+package artnet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"net"
+	"sync"
+)
+
+// Port is the standard Art-Net UDP port.
+const Port = 6454
+
+const opDmx = 0x5000
+
+var header = []byte("Art-Net\x00")
+
+// ErrNotDmx is returned by ParseDmx for packets that are not ArtDmx.
+var ErrNotDmx = errors.New("artnet: not an ArtDmx packet")
+
+// Dmx is a decoded ArtDmx packet.
+type Dmx struct {
+	Sequence uint8
+	// Universe is the 15-bit port address.
+	Universe int
+	Data     []byte
+}
+
+// ParseDmx decodes an ArtDmx packet.
+func ParseDmx(b []byte) (*Dmx, error) {
+	if len(b) < 18 || !bytes.Equal(b[:8], header) || binary.LittleEndian.Uint16(b[8:]) != opDmx {
+		return nil, ErrNotDmx
+	}
+	length := int(binary.BigEndian.Uint16(b[16:]))
+	if length > 512 || 18+length > len(b) {
+		return nil, ErrNotDmx
+	}
+	return &Dmx{
+		Sequence: b[12],
+		Universe: int(b[15]&0x7f)<<8 | int(b[14]),
+		Data:     b[18 : 18+length],
+	}, nil
+}
+
+// Receiver listens for DMX on the universe of its channel map.
+type Receiver struct {
+	// OnChange, if set, is called from the receiving goroutine whenever a
+	// packet changes the decoded state.
+	OnChange func(State)
+
+	mu    sync.Mutex
+	cmap  ChannelMap
+	dmx   [512]byte
+	state State
+	conn  net.PacketConn
+}
+
+// NewReceiver creates a receiver decoding DMX with m. Until the first
+// packet arrives the state is all channels at zero.
+func NewReceiver(m ChannelMap) *Receiver {
+	r := &Receiver{cmap: m}
+	r.state = m.Decode(r.dmx[:])
+	return r
+}
+
+// State returns the most recently decoded state.
+func (r *Receiver) State() State {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.state
+}
+
+// SetChannelMap replaces the channel map and re-decodes the last received data.
+func (r *Receiver) SetChannelMap(m ChannelMap) {
+	r.mu.Lock()
+	r.cmap = m
+	r.state = m.Decode(r.dmx[:])
+	r.mu.Unlock()
+}
+
+// Handle processes one received packet. Packets for other universes and
+// non-DMX Art-Net traffic are ignored.
+func (r *Receiver) Handle(packet []byte) {
+	d, err := ParseDmx(packet)
+	if err != nil {
+		return
+	}
+	r.mu.Lock()
+	if d.Universe != r.cmap.Universe {
+		r.mu.Unlock()
+		return
+	}
+	copy(r.dmx[:], d.Data)
+	prev := r.state
+	r.state = r.cmap.Decode(r.dmx[:])
+	changed := r.state != prev
+	state := r.state
+	r.mu.Unlock()
+
+	if changed && r.OnChange != nil {
+		r.OnChange(state)
+	}
+}
+
+// ListenAndServe listens on the UDP address addr (usually ":6454") and
+// serves until Close is called.
+func (r *Receiver) ListenAndServe(addr string) error {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return err
+	}
+	return r.Serve(conn)
+}
+
+// Serve reads packets from conn until Close is called. It returns nil after Close.
+func (r *Receiver) Serve(conn net.PacketConn) error {
+	r.mu.Lock()
+	r.conn = conn
+	r.mu.Unlock()
+
+	buf := make([]byte, 1024)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		r.Handle(buf[:n])
+	}
+}
+
+// Close stops the receiver.
+func (r *Receiver) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.conn == nil {
+		return nil
+	}
+	return r.conn.Close()
+}
@@ -0,0 +1,73 @@
+package artnet
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+func dmxPacket(universe int, data []byte) []byte {
+	b := append([]byte(nil), header...)
+	b = binary.LittleEndian.AppendUint16(b, opDmx)
+	b = append(b, 0, 14, 1, 0, byte(universe), byte(universe>>8))
+	b = binary.BigEndian.AppendUint16(b, uint16(len(data)))
+	return append(b, data...)
+}
+
+func TestReceiverDecodes(t *testing.T) {
+	m := DefaultChannelMap
+	m.Universe = 0x102
+	r := NewReceiver(m)
+
+	var got []State
+	r.OnChange = func(s State) { got = append(got, s) }
+
+	data := []byte{255, 255, 0, 51, 7, 255, 128, 0}
+	r.Handle(dmxPacket(0x102, data))
+	r.Handle(dmxPacket(0x102, data)) // unchanged, no callback
+	r.Handle(dmxPacket(0x001, []byte{0}))
+
+	if len(got) != 1 {
+		t.Fatalf("OnChange called %d times, want 1", len(got))
+	}
+	s := r.State()
+	if s.Dimmer != 1 || s.Red != 1 || s.Green != 0 || math.Abs(s.Blue-0.2) > 1e-9 {
+		t.Fatalf("colors = %+v", s)
+	}
+	if s.Pattern != 7 || s.OffsetX != 1 || s.OffsetY != 0 || s.Size != 0 {
+		t.Fatalf("pattern/position = %+v", s)
+	}
+}
+
+func TestDecodeDuck(t *testing.T) {
+	m := DefaultChannelMap
+	if m.Decode([]byte{0, 0, 0, 0, 0, 0, 0, 0, 255}).Duck {
+		t.Fatal("unmapped duck channel ducked")
+	}
+	m.Duck = 9
+	data := make([]byte, 9)
+	if data[8] = 127; m.Decode(data).Duck {
+		t.Fatal("ducked below 50%")
+	}
+	if data[8] = 128; !m.Decode(data).Duck {
+		t.Fatal("not ducked at 50%")
+	}
+}
+
+func TestStateTransform(t *testing.T) {
+	s := State{Size: 0.5, OffsetX: 0.5}
+	x, y := s.Transform().Apply(4096, 2048)
+	if x != 3072+1024 || y != 2048 {
+		t.Fatalf("got (%v, %v)", x, y)
+	}
+}
+
+func TestParseDmxRejects(t *testing.T) {
+	if _, err := ParseDmx([]byte("Art-Net\x00\x00\x20")); err != ErrNotDmx {
+		t.Fatalf("err = %v, want ErrNotDmx", err)
+	}
+	short := dmxPacket(0, make([]byte, 10))[:20]
+	if _, err := ParseDmx(short); err != ErrNotDmx {
+		t.Fatalf("truncated packet err = %v", err)
+	}
+}
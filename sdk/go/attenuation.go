@@ -0,0 +1,208 @@
+package helios
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// An attenuation map dims regions of the projection area, for example over
+// reflective surfaces or near walkways. It is applied to every frame
+// written to a device, after the intensity levels, so no content can
+// exceed it.
+
+// Vertex is a corner of an AttenuationZone, in device coordinates
+// (0 - 4095).
+type Vertex struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// AttenuationZone scales the color of points inside a polygon.
+type AttenuationZone struct {
+	Name string `json:"name,omitempty"`
+
+	// Level is the intensity inside the zone (0.0 - 1.0).
+	Level float64 `json:"level"`
+
+	// Polygon is the zone's outline, at least three vertices; it is closed
+	// implicitly. Points inside follow the even-odd rule.
+	Polygon []Vertex `json:"polygon"`
+}
+
+// AttenuationGrid scales the color of points by the cell of a grid over the
+// whole coordinate range they fall in.
+type AttenuationGrid struct {
+	Cols int `json:"cols"`
+	Rows int `json:"rows"`
+
+	// Levels holds Cols*Rows intensities (0.0 - 1.0), row by row from
+	// Y = 0.
+	Levels []float64 `json:"levels"`
+}
+
+// AttenuationMap is a set of dimmed regions. Where regions overlap, the
+// lowest level applies.
+type AttenuationMap struct {
+	Zones []AttenuationZone `json:"zones,omitempty"`
+	Grid  *AttenuationGrid  `json:"grid,omitempty"`
+}
+
+// Validate checks that levels are between 0 and 1, polygons have at least
+// three vertices and the grid has a level for every cell.
+func (m *AttenuationMap) Validate() error {
+	for i, z := range m.Zones {
+		if len(z.Polygon) < 3 {
+			return fmt.Errorf("helios: attenuation zone %d has %d vertices, need 3", i, len(z.Polygon))
+		}
+		if !(z.Level >= 0 && z.Level <= 1) {
+			return fmt.Errorf("helios: attenuation zone %d level %v is not between 0 and 1", i, z.Level)
+		}
+	}
+	if g := m.Grid; g != nil {
+		if g.Cols <= 0 || g.Rows <= 0 || len(g.Levels) != g.Cols*g.Rows {
+			return errors.New("helios: attenuation grid needs cols*rows levels")
+		}
+		for _, v := range g.Levels {
+			if !(v >= 0 && v <= 1) {
+				return fmt.Errorf("helios: attenuation grid level %v is not between 0 and 1", v)
+			}
+		}
+	}
+	return nil
+}
+
+// Level returns the intensity at a device coordinate.
+func (m *AttenuationMap) Level(x, y uint16) float64 {
+	level := 1.0
+	if g := m.Grid; g != nil {
+		col := min(int(x)*g.Cols/(maxCoord+1), g.Cols-1)
+		row := min(int(y)*g.Rows/(maxCoord+1), g.Rows-1)
+		level = g.Levels[row*g.Cols+col]
+	}
+	for _, z := range m.Zones {
+		if z.Level < level && inPolygon(z.Polygon, float64(x), float64(y)) {
+			level = z.Level
+		}
+	}
+	return level
+}
+
+// inPolygon reports whether (x, y) is inside poly by the even-odd rule.
+func inPolygon(poly []Vertex, x, y float64) bool {
+	in := false
+	for i, j := 0, len(poly)-1; i < len(poly); j, i = i, i+1 {
+		a, b := poly[i], poly[j]
+		if (a.Y > y) != (b.Y > y) && x < a.X+(y-a.Y)*(b.X-a.X)/(b.Y-a.Y) {
+			in = !in
+		}
+	}
+	return in
+}
+
+// Apply returns a copy of points dimmed by the map, so the map can mask the
+// output of DACs other than Helios, such as through output.Map. A nil map
+// returns points unchanged.
+func (m *AttenuationMap) Apply(points []Point) []Point {
+	return attenuatePoints(points, m)
+}
+
+func (m *AttenuationMap) clone() *AttenuationMap {
+	c := &AttenuationMap{Zones: slices.Clone(m.Zones)}
+	for i := range c.Zones {
+		c.Zones[i].Polygon = slices.Clone(c.Zones[i].Polygon)
+	}
+	if m.Grid != nil {
+		g := *m.Grid
+		g.Levels = slices.Clone(g.Levels)
+		c.Grid = &g
+	}
+	return c
+}
+
+// SetAttenuationMap sets the attenuation map of one device, taking effect
+// from the next frame written. A nil map removes it. The map is copied.
+func (d *DAC) SetAttenuationMap(deviceIndex int, m *AttenuationMap) error {
+	if m != nil {
+		if err := m.Validate(); err != nil {
+			return err
+		}
+		m = m.clone()
+	}
+	d.levels.mu.Lock()
+	defer d.levels.mu.Unlock()
+	if m == nil {
+		delete(d.levels.attenuation, deviceIndex)
+		return nil
+	}
+	if d.levels.attenuation == nil {
+		d.levels.attenuation = make(map[int]*AttenuationMap)
+	}
+	d.levels.attenuation[deviceIndex] = m
+	return nil
+}
+
+// AttenuationMap returns a copy of the attenuation map of one device, or
+// nil if it has none.
+func (d *DAC) AttenuationMap(deviceIndex int) *AttenuationMap {
+	m := d.levels.attenuationMap(deviceIndex)
+	if m == nil {
+		return nil
+	}
+	return m.clone()
+}
+
+// attenuationMap returns the map of a device. Maps are replaced, never
+// modified, so the result can be used without the lock.
+func (l *levels) attenuationMap(deviceIndex int) *AttenuationMap {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.attenuation[deviceIndex]
+}
+
+// The attenuate functions return points unchanged without a map, and an
+// attenuated copy otherwise; the caller's slice is never modified. Maps are
+// in 12-bit device coordinates, so the 16-bit coordinates of high-resolution
+// and extended points are narrowed to look them up.
+
+func attenuatePoints(points []Point, m *AttenuationMap) []Point {
+	if m == nil {
+		return points
+	}
+	out := make([]Point, len(points))
+	for i, p := range points {
+		if s := m.Level(p.X, p.Y); s < 1 {
+			p.R, p.G, p.B, p.I = ScaleColor(p.R, s), ScaleColor(p.G, s), ScaleColor(p.B, s), ScaleColor(p.I, s)
+		}
+		out[i] = p
+	}
+	return out
+}
+
+func attenuatePointsHighRes(points []PointHighRes, m *AttenuationMap) []PointHighRes {
+	if m == nil {
+		return points
+	}
+	out := make([]PointHighRes, len(points))
+	for i, p := range points {
+		if s := m.Level(narrowCoord(p.X), narrowCoord(p.Y)); s < 1 {
+			p.R, p.G, p.B = ScaleColor16(p.R, s), ScaleColor16(p.G, s), ScaleColor16(p.B, s)
+		}
+		out[i] = p
+	}
+	return out
+}
+
+func attenuatePointsExt(points []PointExt, m *AttenuationMap) []PointExt {
+	if m == nil {
+		return points
+	}
+	out := make([]PointExt, len(points))
+	for i, p := range points {
+		if s := m.Level(narrowCoord(p.X), narrowCoord(p.Y)); s < 1 {
+			p.R, p.G, p.B, p.I = ScaleColor16(p.R, s), ScaleColor16(p.G, s), ScaleColor16(p.B, s), ScaleColor16(p.I, s)
+		}
+		out[i] = p
+	}
+	return out
+}
@@ -0,0 +1,75 @@
+package helios
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAttenuationMapLevel(t *testing.T) {
+	m := &AttenuationMap{
+		Zones: []AttenuationZone{
+			{Name: "mirror", Level: 0.2, Polygon: []Vertex{{0, 0}, {1000, 0}, {1000, 1000}, {0, 1000}}},
+			{Name: "walkway", Level: 0.5, Polygon: []Vertex{{500, 500}, {3000, 500}, {500, 3000}}},
+		},
+		Grid: &AttenuationGrid{Cols: 2, Rows: 1, Levels: []float64{1, 0.8}},
+	}
+	if err := m.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		x, y uint16
+		want float64
+	}{
+		{100, 100, 0.2},   // mirror, and the walkway does not lower it further
+		{800, 800, 0.2},   // both zones overlap: the lowest wins
+		{1500, 600, 0.5},  // walkway only
+		{2900, 2900, 0.8}, // outside the triangle, right half of the grid
+		{100, 4000, 1},
+	} {
+		if got := m.Level(tt.x, tt.y); got != tt.want {
+			t.Errorf("Level(%d, %d) = %v, want %v", tt.x, tt.y, got, tt.want)
+		}
+	}
+
+	out := m.Apply([]Point{{X: 100, Y: 100, R: 255, I: 255}, {X: 100, Y: 4000, G: 200}})
+	if out[0] != (Point{X: 100, Y: 100, R: 51, I: 51}) || out[1].G != 200 {
+		t.Fatalf("attenuated = %+v", out)
+	}
+}
+
+func TestAttenuationMapValidate(t *testing.T) {
+	for _, tt := range []struct {
+		m    AttenuationMap
+		want string
+	}{
+		{AttenuationMap{Zones: []AttenuationZone{{Level: 0.5, Polygon: []Vertex{{0, 0}, {1, 1}}}}}, "vertices"},
+		{AttenuationMap{Zones: []AttenuationZone{{Level: 2, Polygon: []Vertex{{0, 0}, {1, 1}, {0, 1}}}}}, "level 2"},
+		{AttenuationMap{Grid: &AttenuationGrid{Cols: 2, Rows: 2, Levels: []float64{1}}}, "cols*rows"},
+	} {
+		if err := tt.m.Validate(); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Validate(%+v) = %v, want %q", tt.m, err, tt.want)
+		}
+	}
+}
+
+func TestSetAttenuationMap(t *testing.T) {
+	d := &DAC{levels: newLevels()}
+	m := &AttenuationMap{Grid: &AttenuationGrid{Cols: 1, Rows: 1, Levels: []float64{0.5}}}
+	if err := d.SetAttenuationMap(1, m); err != nil {
+		t.Fatal(err)
+	}
+	m.Grid.Levels[0] = 0
+	if got := d.AttenuationMap(1).Level(0, 0); got != 0.5 {
+		t.Fatalf("stored map changed with the caller's: level %v", got)
+	}
+	if d.AttenuationMap(0) != nil {
+		t.Fatal("map applies to another device")
+	}
+	if err := d.SetAttenuationMap(1, &AttenuationMap{Grid: &AttenuationGrid{}}); err == nil {
+		t.Fatal("invalid map accepted")
+	}
+	d.SetAttenuationMap(1, nil)
+	if d.AttenuationMap(1) != nil {
+		t.Fatal("map not removed")
+	}
+}
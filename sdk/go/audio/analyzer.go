@@ -0,0 +1,208 @@
+// Package audio turns an audio stream into control values for laser content.
+//
+// An Analyzer consumes mono samples (or 16-bit PCM from any io.Reader via
+// Run), computes the spectrum of each window with an FFT and exposes the
+// energy of a set of frequency bands together with a simple beat detector.
+// Frame generators read the values from their own goroutine.
+//
+// The package does not capture audio itself. Pipe PCM from a capture tool
+// instead, for example:
+//
+//	arecord -f S16_LE -r 44100 -c 1 | ./my_laser_app
+package audio
+
+import (
+	"math"
+	"math/cmplx"
+	"sync"
+)
+
+// Band is a frequency range in Hz.
+type Band struct {
+	Name      string
+	Low, High float64
+}
+
+// DefaultBands splits the spectrum into bass, mid and treble.
+var DefaultBands = []Band{
+	{Name: "bass", Low: 20, High: 250},
+	{Name: "mid", Low: 250, High: 4000},
+	{Name: "treble", Low: 4000, High: 16000},
+}
+
+// DefaultWindowSize is the number of samples analyzed at a time, about 23ms at 44.1kHz.
+const DefaultWindowSize = 1024
+
+const (
+	// historyWindows is the number of past windows the beat detector
+	// averages over, about one second at the default window size.
+	historyWindows = 43
+	// peakDecay is the per-window decay of the auto-gain peak.
+	peakDecay = 0.995
+)
+
+// Analyzer computes band energies and detects beats. It is safe to read
+// values from other goroutines while Process or Run is feeding it.
+type Analyzer struct {
+	// BeatBand is the index of the band used for beat detection.
+	BeatBand int
+
+	// BeatSensitivity is how far above its recent average the beat band's
+	// energy must rise to count as a beat. Zero means 1.4.
+	BeatSensitivity float64
+
+	// OnBeat, if set, is called from the analysis goroutine on every beat.
+	OnBeat func()
+
+	sampleRate int
+	size       int
+	bands      []Band
+	window     []float64
+	buf        []float64
+	spectrum   []complex128
+
+	mu       sync.Mutex
+	energies []float64
+	levels   []float64
+	peaks    []float64
+	history  []float64
+	beats    uint64
+}
+
+// NewAnalyzer creates an analyzer for audio at sampleRate. size is the
+// analysis window length and is rounded up to a power of two; zero means
+// DefaultWindowSize. A nil bands uses DefaultBands.
+func NewAnalyzer(sampleRate, size int, bands []Band) *Analyzer {
+	if size <= 0 {
+		size = DefaultWindowSize
+	}
+	size = 1 << uint(math.Ceil(math.Log2(float64(size))))
+	if bands == nil {
+		bands = DefaultBands
+	}
+	return &Analyzer{
+		sampleRate: sampleRate,
+		size:       size,
+		bands:      bands,
+		window:     hann(size),
+		buf:        make([]float64, 0, size),
+		spectrum:   make([]complex128, size),
+		energies:   make([]float64, len(bands)),
+		levels:     make([]float64, len(bands)),
+		peaks:      make([]float64, len(bands)),
+	}
+}
+
+// Process feeds mono samples in the range -1.0 - 1.0 to the analyzer.
+// Values are updated every time a full window has been collected.
+func (a *Analyzer) Process(samples []float64) {
+	for _, s := range samples {
+		a.buf = append(a.buf, s)
+		if len(a.buf) == a.size {
+			a.analyze()
+			a.buf = a.buf[:0]
+		}
+	}
+}
+
+func (a *Analyzer) analyze() {
+	for i, s := range a.buf {
+		a.spectrum[i] = complex(s*a.window[i], 0)
+	}
+	fft(a.spectrum)
+
+	binHz := float64(a.sampleRate) / float64(a.size)
+	energies := make([]float64, len(a.bands))
+	for b, band := range a.bands {
+		lo := max(1, int(band.Low/binHz))
+		hi := min(a.size/2, int(math.Ceil(band.High/binHz)))
+		sum := 0.0
+		for k := lo; k < hi; k++ {
+			sum += cmplx.Abs(a.spectrum[k])
+		}
+		if hi > lo {
+			energies[b] = sum / float64(hi-lo)
+		}
+	}
+
+	a.mu.Lock()
+	copy(a.energies, energies)
+	for b, e := range energies {
+		a.peaks[b] = max(e, a.peaks[b]*peakDecay)
+		if a.peaks[b] > 0 {
+			a.levels[b] = e / a.peaks[b]
+		}
+	}
+	beat := a.detectBeatLocked()
+	a.mu.Unlock()
+
+	if beat && a.OnBeat != nil {
+		a.OnBeat()
+	}
+}
+
+func (a *Analyzer) detectBeatLocked() bool {
+	if a.BeatBand < 0 || a.BeatBand >= len(a.energies) {
+		return false
+	}
+	e := a.energies[a.BeatBand]
+	beat := false
+	if len(a.history) == historyWindows {
+		avg := 0.0
+		for _, h := range a.history {
+			avg += h
+		}
+		avg /= historyWindows
+		sensitivity := a.BeatSensitivity
+		if sensitivity == 0 {
+			sensitivity = 1.4
+		}
+		beat = avg > 0 && e > sensitivity*avg
+		a.history = a.history[1:]
+	}
+	a.history = append(a.history, e)
+	if beat {
+		a.beats++
+	}
+	return beat
+}
+
+// Energy returns the raw spectral energy of the named band.
+func (a *Analyzer) Energy(name string) float64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for i, b := range a.bands {
+		if b.Name == name {
+			return a.energies[i]
+		}
+	}
+	return 0
+}
+
+// Level returns the energy of the named band normalized against its recent
+// peak, in the range 0.0 - 1.0. This is usually the value to drive content with.
+func (a *Analyzer) Level(name string) float64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for i, b := range a.bands {
+		if b.Name == name {
+			return a.levels[i]
+		}
+	}
+	return 0
+}
+
+// Levels returns the normalized level of every band, in band order.
+func (a *Analyzer) Levels() []float64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]float64(nil), a.levels...)
+}
+
+// Beats returns the number of beats detected so far. Generators can compare
+// it against the value seen on the previous frame to react to new beats.
+func (a *Analyzer) Beats() uint64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.beats
+}
@@ -0,0 +1,104 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+func sine(freq float64, sampleRate, n int, amp float64) []float64 {
+	s := make([]float64, n)
+	for i := range s {
+		s[i] = amp * math.Sin(2*math.Pi*freq*float64(i)/float64(sampleRate))
+	}
+	return s
+}
+
+func TestAnalyzerBands(t *testing.T) {
+	a := NewAnalyzer(44100, 0, nil)
+	a.Process(sine(100, 44100, 4096, 0.8))
+
+	if bass, treble := a.Energy("bass"), a.Energy("treble"); bass < 100*treble {
+		t.Fatalf("bass energy %v should dominate treble %v for a 100Hz tone", bass, treble)
+	}
+	if level := a.Level("bass"); level <= 0 || level > 1 {
+		t.Fatalf("bass level = %v, want (0, 1]", level)
+	}
+}
+
+func TestAnalyzerBeats(t *testing.T) {
+	a := NewAnalyzer(44100, 1024, nil)
+	called := 0
+	a.OnBeat = func() { called++ }
+
+	// A second of quiet bass, then a loud kick.
+	a.Process(sine(60, 44100, 1024*historyWindows, 0.05))
+	a.Process(sine(60, 44100, 1024, 0.9))
+
+	if a.Beats() != 1 || called != 1 {
+		t.Fatalf("beats = %d (callback %d), want 1", a.Beats(), called)
+	}
+}
+
+func TestAnalyzerRunPCM(t *testing.T) {
+	var buf bytes.Buffer
+	for _, s := range sine(100, 44100, 2048, 0.5) {
+		v := int16(s * 32767)
+		// Stereo: identical left and right channels.
+		binary.Write(&buf, binary.LittleEndian, [2]int16{v, v})
+	}
+
+	a := NewAnalyzer(44100, 1024, nil)
+	if err := a.Run(&buf, Format{SampleRate: 44100, Channels: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if a.Energy("bass") == 0 {
+		t.Fatal("no bass energy after reading PCM")
+	}
+}
+
+func TestAnalyzerRunSampleRate(t *testing.T) {
+	var buf bytes.Buffer
+	for _, s := range sine(1000, 44100, 2048, 0.5) {
+		binary.Write(&buf, binary.LittleEndian, int16(s*32767))
+	}
+
+	// The format's rate wins over the analyzer's, which would put the
+	// 1kHz tone in the bass band.
+	a := NewAnalyzer(8000, 1024, nil)
+	if err := a.Run(&buf, Format{SampleRate: 44100, Channels: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if mid, bass := a.Energy("mid"), a.Energy("bass"); mid < 10*bass {
+		t.Fatalf("mid energy %v should dominate bass %v for a 1kHz tone", mid, bass)
+	}
+}
+
+func TestHannSingleSample(t *testing.T) {
+	if w := hann(1); len(w) != 1 || w[0] != 1 {
+		t.Fatalf("hann(1) = %v", w)
+	}
+	a := NewAnalyzer(44100, 1, nil)
+	a.Process([]float64{0.5})
+	if e := a.Energy("bass"); math.IsNaN(e) {
+		t.Fatal("one-sample window gave NaN energy")
+	}
+}
+
+func TestFFT(t *testing.T) {
+	x := make([]complex128, 8)
+	for i := range x {
+		x[i] = complex(math.Cos(2*math.Pi*float64(i)/8), 0)
+	}
+	fft(x)
+	for k, v := range x {
+		want := 0.0
+		if k == 1 || k == 7 {
+			want = 4
+		}
+		if math.Abs(real(v)-want) > 1e-9 || math.Abs(imag(v)) > 1e-9 {
+			t.Fatalf("bin %d = %v, want %v", k, v, want)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package audio
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// Format describes interleaved signed 16-bit little-endian PCM, the default
+// output of tools such as arecord, parec and ffmpeg's s16le format.
+type Format struct {
+	SampleRate int
+	Channels   int
+}
+
+// DefaultFormat is 44.1kHz mono.
+var DefaultFormat = Format{SampleRate: 44100, Channels: 1}
+
+// Run reads PCM in format f from r and feeds it to the analyzer until r
+// returns an error. Channels are mixed down to mono, and the bands are
+// measured at f.SampleRate, replacing the rate the analyzer was created
+// with; zero keeps it. Run returns nil when r reaches EOF.
+func (a *Analyzer) Run(r io.Reader, f Format) error {
+	if f.Channels < 1 {
+		f.Channels = 1
+	}
+	if f.SampleRate > 0 {
+		a.sampleRate = f.SampleRate
+	}
+	br := bufio.NewReader(r)
+	frame := make([]byte, 2*f.Channels)
+	samples := make([]float64, 0, a.size)
+	for {
+		if _, err := io.ReadFull(br, frame); err != nil {
+			if len(samples) > 0 {
+				a.Process(samples)
+			}
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return nil
+			}
+			return err
+		}
+		sum := 0.0
+		for c := 0; c < f.Channels; c++ {
+			sum += float64(int16(binary.LittleEndian.Uint16(frame[2*c:]))) / 32768
+		}
+		samples = append(samples, sum/float64(f.Channels))
+		if len(samples) == cap(samples) {
+			a.Process(samples)
+			samples = samples[:0]
+		}
+	}
+}
@@ -0,0 +1,178 @@
+// Package bitmap traces the outlines of simple bitmaps, such as logos and
+// line art, into laser paths.
+//
+// Pixels are split into ink and background by a brightness threshold, and
+// the boundary of every ink region, including the edges of holes, becomes
+// a closed path. The pixel staircase of each outline is simplified into
+// straight segments, and the paths are ordered and started so the blanked
+// jumps between them are short. The result plugs into svg.Frame:
+//
+//	paths, _ := bitmap.Read(f, bitmap.Options{})
+//	frame := svg.Frame(paths, 0.01, 8)
+package bitmap
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // register decoders for Read
+	_ "image/png"
+	"io"
+	"math"
+
+	"github.com/Grix/helios_dac/sdk/go/svg"
+)
+
+// Options configures tracing. The zero value traces dark ink on a light or
+// transparent background in white.
+type Options struct {
+	// Threshold is the brightness (0 to 1) below which an opaque pixel is
+	// ink. Zero means 0.5.
+	Threshold float64
+
+	// Invert traces pixels above the threshold instead, for light ink on a
+	// dark background.
+	Invert bool
+
+	// Tolerance is how far, in pixels, a simplified outline may stray from
+	// the pixel boundary. Zero means 1, which turns staircases into
+	// diagonals; larger values give fewer segments.
+	Tolerance float64
+
+	// MinPerimeter drops outlines shorter than this many pixels, removing
+	// specks and scanning noise. Zero means 8; negative keeps all.
+	MinPerimeter int
+
+	// Color is the color of the paths. Nil means white, unless
+	// SampleColors is set.
+	Color color.Color
+
+	// SampleColors colors each path with the average color of the ink
+	// pixels along it, for multicolored logos.
+	SampleColors bool
+}
+
+func (o Options) threshold() float64 {
+	if o.Threshold > 0 {
+		return o.Threshold
+	}
+	return 0.5
+}
+
+func (o Options) tolerance() float64 {
+	if o.Tolerance > 0 {
+		return o.Tolerance
+	}
+	return 1
+}
+
+func (o Options) minPerimeter() int {
+	if o.MinPerimeter == 0 {
+		return 8
+	}
+	return o.MinPerimeter
+}
+
+// Read decodes a PNG or GIF image and traces it. Only the first frame of an
+// animated GIF is read.
+func Read(r io.Reader, opt Options) ([]svg.Path, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("bitmap: %w", err)
+	}
+	return Trace(img, opt)
+}
+
+// Trace returns the outlines of the ink in img, scaled to fit the
+// projection area (-1 to 1) with their aspect ratio kept, centered and with
+// the top of the image at the top of the projection.
+func Trace(img image.Image, opt Options) ([]svg.Path, error) {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	ink := make([]bool, w*h)
+	for y := range h {
+		for x := range w {
+			ink[y*w+x] = isInk(img.At(b.Min.X+x, b.Min.Y+y), opt)
+		}
+	}
+	loops := contours(ink, w, h)
+
+	var paths []svg.Path
+	scale := 2 / float64(max(w, h, 1))
+	for _, l := range loops {
+		if len(l.vertices) < opt.minPerimeter() {
+			continue
+		}
+		p := svg.Path{R: 1, G: 1, B: 1}
+		if opt.SampleColors {
+			p.R, p.G, p.B = averageColor(img, b.Min, l.pixels, w)
+		} else if opt.Color != nil {
+			p.R, p.G, p.B = rgb(opt.Color)
+		}
+		for _, v := range simplify(l.vertices, opt.tolerance()) {
+			p.Points = append(p.Points, [2]float64{
+				(float64(v[0]) - float64(w)/2) * scale,
+				-(float64(v[1]) - float64(h)/2) * scale,
+			})
+		}
+		paths = append(paths, p)
+	}
+	if len(paths) == 0 {
+		return nil, errors.New("bitmap: no outlines found")
+	}
+	return order(paths), nil
+}
+
+func isInk(c color.Color, opt Options) bool {
+	r, g, b, a := c.RGBA()
+	if a < 0x8000 {
+		return false
+	}
+	// Luma of the unpremultiplied color.
+	lum := (0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)) / float64(a)
+	return (lum < opt.threshold()) != opt.Invert
+}
+
+// rgb returns the unpremultiplied color components (0 to 1).
+func rgb(c color.Color) (r, g, b float64) {
+	cr, cg, cb, ca := c.RGBA()
+	if ca == 0 {
+		return 0, 0, 0
+	}
+	return float64(cr) / float64(ca), float64(cg) / float64(ca), float64(cb) / float64(ca)
+}
+
+func averageColor(img image.Image, min image.Point, pixels []int, w int) (r, g, b float64) {
+	for _, i := range pixels {
+		pr, pg, pb := rgb(img.At(min.X+i%w, min.Y+i/w))
+		r, g, b = r+pr, g+pg, b+pb
+	}
+	n := float64(len(pixels))
+	return r / n, g / n, b / n
+}
+
+// order sorts closed paths so each starts at its point nearest to where the
+// previous one ended, visiting the nearest remaining path next.
+func order(paths []svg.Path) []svg.Path {
+	out := make([]svg.Path, 0, len(paths))
+	left := append([]svg.Path(nil), paths...)
+	pos := left[0].Points[0]
+	for len(left) > 0 {
+		best, bestAt, bestDist := 0, 0, math.Inf(1)
+		for i, p := range left {
+			for j, pt := range p.Points {
+				if d := math.Hypot(pt[0]-pos[0], pt[1]-pos[1]); d < bestDist {
+					best, bestAt, bestDist = i, j, d
+				}
+			}
+		}
+		p := left[best]
+		left = append(left[:best], left[best+1:]...)
+		pts := append(append([][2]float64(nil), p.Points[bestAt:]...), p.Points[:bestAt]...)
+		p.Points = append(pts, pts[0]) // close the outline
+		out = append(out, p)
+		pos = pts[0]
+	}
+	return out
+}
@@ -0,0 +1,150 @@
+package bitmap
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"testing"
+
+	"github.com/Grix/helios_dac/sdk/go/svg"
+)
+
+// canvas returns a white w*h image with the given rectangles filled.
+func canvas(w, h int, c color.Color, rects ...image.Rectangle) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	for _, r := range rects {
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				img.Set(x, y, c)
+			}
+		}
+	}
+	return img
+}
+
+func near(a, b [2]float64) bool {
+	return math.Abs(a[0]-b[0]) < 1e-9 && math.Abs(a[1]-b[1]) < 1e-9
+}
+
+func TestTraceSquare(t *testing.T) {
+	img := canvas(10, 10, color.Black, image.Rect(3, 3, 7, 7))
+	paths, err := Trace(img, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 1 {
+		t.Fatalf("got %d paths, want 1", len(paths))
+	}
+	p := paths[0]
+	want := [][2]float64{{-0.4, 0.4}, {0.4, 0.4}, {0.4, -0.4}, {-0.4, -0.4}, {-0.4, 0.4}}
+	if len(p.Points) != len(want) {
+		t.Fatalf("square traced as %v", p.Points)
+	}
+	for i := range want {
+		if !near(p.Points[i], want[i]) {
+			t.Fatalf("square traced as %v, want %v", p.Points, want)
+		}
+	}
+	if p.R != 1 || p.G != 1 || p.B != 1 {
+		t.Fatalf("default color = %v %v %v, want white", p.R, p.G, p.B)
+	}
+}
+
+func TestTraceHoleAndSpecks(t *testing.T) {
+	img := canvas(20, 20, color.Black, image.Rect(2, 2, 12, 12))
+	for y := 5; y < 9; y++ {
+		for x := 5; x < 9; x++ {
+			img.Set(x, y, color.White)
+		}
+	}
+	img.Set(16, 16, color.Black) // a speck
+	paths, err := Trace(img, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 2 {
+		t.Fatalf("got %d paths, want the outline and the hole", len(paths))
+	}
+	if paths, _ := Trace(img, Options{MinPerimeter: -1}); len(paths) != 3 {
+		t.Fatalf("got %d paths keeping specks, want 3", len(paths))
+	}
+}
+
+func TestTraceDiagonalPixels(t *testing.T) {
+	img := canvas(4, 4, color.Black, image.Rect(1, 1, 2, 2), image.Rect(2, 2, 3, 3))
+	paths, err := Trace(img, Options{MinPerimeter: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 2 {
+		t.Fatalf("got %d paths, want the pixels outlined separately", len(paths))
+	}
+}
+
+func TestTraceSimplifiesStaircase(t *testing.T) {
+	// A right triangle whose hypotenuse is a staircase of 16 steps.
+	img := canvas(20, 20, color.White)
+	for y := 2; y < 18; y++ {
+		for x := 2; x <= y; x++ {
+			img.Set(x, y, color.Black)
+		}
+	}
+	paths, err := Trace(img, Options{Tolerance: 1.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(paths[0].Points); n > 6 {
+		t.Fatalf("triangle traced with %d points: %v", n, paths[0].Points)
+	}
+}
+
+func TestOrderStartsNearPreviousEnd(t *testing.T) {
+	paths := []svg.Path{
+		{Points: [][2]float64{{-1, 0}, {-0.5, 0}, {-0.5, 0.5}}},
+		{Points: [][2]float64{{0.9, 0}, {0.1, 0}, {0.1, 0.5}}},
+		{Points: [][2]float64{{-0.4, 0}, {-0.3, 0}, {-0.3, 0.5}}},
+	}
+	got := order(paths)
+	if !near(got[1].Points[0], [2]float64{-0.4, 0}) || !near(got[2].Points[0], [2]float64{0.1, 0}) {
+		t.Fatalf("order = %v", got)
+	}
+	for _, p := range got {
+		if !near(p.Points[0], p.Points[len(p.Points)-1]) {
+			t.Fatalf("path %v is not closed", p.Points)
+		}
+	}
+}
+
+func TestReadPNGInvertedColors(t *testing.T) {
+	img := canvas(10, 10, color.RGBA{R: 255, A: 255}, image.Rect(3, 3, 7, 7))
+	dark := color.RGBA{A: 255}
+	for y := range 10 {
+		for x := range 10 {
+			if img.RGBAAt(x, y).G == 255 {
+				img.Set(x, y, dark)
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	paths, err := Read(&buf, Options{Invert: true, Threshold: 0.1, SampleColors: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 1 || paths[0].R != 1 || paths[0].G != 0 {
+		t.Fatalf("paths = %+v, want one red outline", paths)
+	}
+	if _, err := Read(bytes.NewReader([]byte("not an image")), Options{}); err == nil {
+		t.Fatal("Read accepted garbage")
+	}
+	if _, err := Trace(canvas(4, 4, color.Black), Options{}); err == nil {
+		t.Fatal("Trace of a blank image succeeded")
+	}
+}
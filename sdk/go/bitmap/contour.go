@@ -0,0 +1,150 @@
+package bitmap
+
+import "math"
+
+// loop is a closed outline along pixel edges: its corner vertices in order,
+// and the ink pixel along each edge.
+type loop struct {
+	vertices [][2]int
+	pixels   []int
+}
+
+type edge struct {
+	from, to [2]int
+	pixel    int
+}
+
+func (e edge) dir() [2]int {
+	return [2]int{e.to[0] - e.from[0], e.to[1] - e.from[1]}
+}
+
+// contours returns the outlines of the ink regions of a w*h grid. Every
+// pixel edge between ink and background becomes a directed edge running
+// clockwise around the ink, so outer outlines run clockwise and holes
+// counterclockwise (with y down), and chaining the edges yields closed
+// loops. Where two ink pixels touch only at a corner, the loops turn away
+// from each other, so the pixels are outlined separately.
+func contours(ink []bool, w, h int) []loop {
+	at := func(x, y int) bool { return x >= 0 && y >= 0 && x < w && y < h && ink[y*w+x] }
+	var edges []edge
+	for y := range h {
+		for x := range w {
+			if !ink[y*w+x] {
+				continue
+			}
+			i := y*w + x
+			if !at(x, y-1) {
+				edges = append(edges, edge{[2]int{x, y}, [2]int{x + 1, y}, i})
+			}
+			if !at(x+1, y) {
+				edges = append(edges, edge{[2]int{x + 1, y}, [2]int{x + 1, y + 1}, i})
+			}
+			if !at(x, y+1) {
+				edges = append(edges, edge{[2]int{x + 1, y + 1}, [2]int{x, y + 1}, i})
+			}
+			if !at(x-1, y) {
+				edges = append(edges, edge{[2]int{x, y + 1}, [2]int{x, y}, i})
+			}
+		}
+	}
+	out := make(map[[2]int][]int, len(edges))
+	for i, e := range edges {
+		out[e.from] = append(out[e.from], i)
+	}
+
+	used := make([]bool, len(edges))
+	var loops []loop
+	for first := range edges {
+		if used[first] {
+			continue
+		}
+		var l loop
+		for e := first; ; {
+			used[e] = true
+			l.vertices = append(l.vertices, edges[e].from)
+			l.pixels = append(l.pixels, edges[e].pixel)
+			if edges[e].to == edges[first].from {
+				break
+			}
+			e = next(edges, out[edges[e].to], used, edges[e].dir())
+		}
+		loops = append(loops, l)
+	}
+	return loops
+}
+
+// next picks the unused edge among candidates turning most to the right of
+// dir. Every vertex has as many unused edges leaving it as entering it, so
+// one is left.
+func next(edges []edge, candidates []int, used []bool, dir [2]int) int {
+	best, bestTurn := -1, math.MinInt
+	for _, c := range candidates {
+		if used[c] {
+			continue
+		}
+		d := edges[c].dir()
+		turn := dir[0]*d[1] - dir[1]*d[0] // right is positive with y down
+		if turn > bestTurn {
+			best, bestTurn = c, turn
+		}
+	}
+	return best
+}
+
+// simplify reduces a closed outline to the vertices needed to stay within
+// tolerance of it (Douglas-Peucker), dropping the vertices along straight
+// runs and turning staircases into diagonals.
+func simplify(vertices [][2]int, tolerance float64) [][2]int {
+	if len(vertices) < 3 {
+		return vertices
+	}
+	// Split the loop at the vertex farthest from the first.
+	far, farDist := 0, -1.0
+	for i, v := range vertices {
+		if d := math.Hypot(float64(v[0]-vertices[0][0]), float64(v[1]-vertices[0][1])); d > farDist {
+			far, farDist = i, d
+		}
+	}
+	keep := make([]bool, len(vertices)+1)
+	keep[0], keep[far], keep[len(vertices)] = true, true, true
+	closed := append(vertices[:len(vertices):len(vertices)], vertices[0])
+	douglasPeucker(closed, 0, far, tolerance, keep)
+	douglasPeucker(closed, far, len(vertices), tolerance, keep)
+	var out [][2]int
+	for i, v := range vertices {
+		if keep[i] {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func douglasPeucker(pts [][2]int, a, b int, tolerance float64, keep []bool) {
+	if b-a < 2 {
+		return
+	}
+	worst, worstDist := -1, tolerance
+	for i := a + 1; i < b; i++ {
+		if d := segmentDistance(pts[i], pts[a], pts[b]); d > worstDist {
+			worst, worstDist = i, d
+		}
+	}
+	if worst < 0 {
+		return
+	}
+	keep[worst] = true
+	douglasPeucker(pts, a, worst, tolerance, keep)
+	douglasPeucker(pts, worst, b, tolerance, keep)
+}
+
+// segmentDistance returns the distance from p to the segment ab.
+func segmentDistance(p, a, b [2]int) float64 {
+	px, py := float64(p[0]-a[0]), float64(p[1]-a[1])
+	dx, dy := float64(b[0]-a[0]), float64(b[1]-a[1])
+	l2 := dx*dx + dy*dy
+	if l2 == 0 {
+		return math.Hypot(px, py)
+	}
+	t := max(0, min(1, (px*dx+py*dy)/l2))
+	return math.Hypot(px-t*dx, py-t*dy)
+}
@@ -0,0 +1,50 @@
+package helios
+
+// blankPoints is the size of the frames WriteBlank writes: short enough to
+// take effect at once, long enough for every device to accept.
+const blankPoints = 20
+
+// blankFrame returns a frame of blanked points at the center of the scan
+// field.
+func blankFrame() []Point {
+	blank := make([]Point, blankPoints)
+	for i := range blank {
+		blank[i] = Point{X: 0x800, Y: 0x800}
+	}
+	return blank
+}
+
+// WriteBlank interrupts the frame on the device with a short blanked frame
+// at pps, leaving the beam off at the center of the scan field until the
+// next frame is written. Unlike Stop it returns as soon as the frame is
+// sent.
+func (d *DAC) WriteBlank(deviceIndex int, pps int) int {
+	if !d.gate.enter() {
+		return int(ErrDeviceClosed)
+	}
+	defer d.gate.leave()
+	d.watchdog.touch(deviceIndex)
+	return d.writeFrame(deviceIndex, pps, FlagStartImmediately|FlagSingleMode, blankFrame(), FrameMeta{})
+}
+
+// SetBlankOnEmpty sets whether writing a frame without points blanks the
+// device, as if a short blanked frame had been written with the same
+// flags. The default is off: empty writes do nothing and return 0, leaving
+// a looping frame on the device.
+func (d *DAC) SetBlankOnEmpty(on bool) {
+	d.blankEmpty.Store(on)
+}
+
+// BlankOnEmpty reports whether SetBlankOnEmpty is on.
+func (d *DAC) BlankOnEmpty() bool {
+	return d.blankEmpty.Load()
+}
+
+// writeEmpty handles a write without points. The caller must not hold the
+// device lock.
+func (d *DAC) writeEmpty(deviceIndex int, pps int, flags int, meta FrameMeta) int {
+	if !d.BlankOnEmpty() {
+		return 0
+	}
+	return d.writeFrame(deviceIndex, pps, flags, blankFrame(), meta)
+}
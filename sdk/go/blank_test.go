@@ -0,0 +1,30 @@
+package helios
+
+import "testing"
+
+func TestBlankFrame(t *testing.T) {
+	frame := blankFrame()
+	if len(frame) != blankPoints {
+		t.Fatalf("blank frame has %d points, want %d", len(frame), blankPoints)
+	}
+	for i, p := range frame {
+		if p != (Point{X: 0x800, Y: 0x800}) {
+			t.Fatalf("point %d = %+v, want blanked at the center", i, p)
+		}
+	}
+}
+
+func TestBlankOnEmpty(t *testing.T) {
+	d := &DAC{levels: newLevels()}
+	if d.BlankOnEmpty() {
+		t.Fatal("BlankOnEmpty is on by default")
+	}
+	// Empty writes are ignored without reaching the device.
+	if r := d.writeFrame(0, 30000, FlagsDefault, nil, FrameMeta{}); r != 0 {
+		t.Fatalf("empty write = %d, want 0", r)
+	}
+	d.SetBlankOnEmpty(true)
+	if !d.BlankOnEmpty() {
+		t.Fatal("SetBlankOnEmpty(true) did not turn it on")
+	}
+}
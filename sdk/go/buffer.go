@@ -0,0 +1,48 @@
+package helios
+
+import "time"
+
+// DeviceBuffer describes the output written to a device that it has not
+// scanned yet.
+type DeviceBuffer struct {
+	// Frames is the number of frames waiting behind the one scanning. The
+	// Helios firmware holds at most one, and reports only whether it does.
+	Frames int
+
+	// Remaining is how long the device takes to scan the frames written
+	// to it, the rest of the scanning frame included. The firmware does
+	// not report its progress, so Remaining is estimated from when each
+	// frame was written and its playback time.
+	Remaining time.Duration
+
+	// Points is the number of points scanned in Remaining at the point
+	// rate of the last frame written.
+	Points int
+}
+
+// Buffer returns the output queued on a device. Applications choosing how
+// far ahead to render use it to tell how soon the device runs out of
+// frames. It returns the device's error when the device is not working.
+func (d *DAC) Buffer(deviceIndex int) (DeviceBuffer, error) {
+	s := d.Status(deviceIndex)
+	if !s.Healthy() {
+		return DeviceBuffer{}, s.Err
+	}
+	return d.stats.buffer(deviceIndex, !s.Ready()), nil
+}
+
+// buffer returns the estimated buffer of a device, busy when the device
+// reports a frame waiting.
+func (s *stats) buffer(deviceIndex int, busy bool) DeviceBuffer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var b DeviceBuffer
+	if busy {
+		b.Frames = 1
+	}
+	if c, ok := s.devices[deviceIndex]; ok && c.PPS > 0 {
+		b.Remaining = max(c.end.Sub(s.clock()), 0)
+		b.Points = int(b.Remaining * time.Duration(c.PPS) / time.Second)
+	}
+	return b
+}
@@ -0,0 +1,40 @@
+package helios
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBufferEstimate(t *testing.T) {
+	now := time.Unix(0, 0)
+	s := stats{now: func() time.Time { return now }}
+
+	if got := s.buffer(0, false); got != (DeviceBuffer{}) {
+		t.Fatalf("buffer before any write = %+v", got)
+	}
+	// Two 100ms frames written together scan one after the other.
+	s.record(0, 3000, 30000, 1)
+	s.record(0, 3000, 30000, 1)
+	now = now.Add(50 * time.Millisecond)
+	want := DeviceBuffer{Frames: 1, Remaining: 150 * time.Millisecond, Points: 4500}
+	if got := s.buffer(0, true); got != want {
+		t.Fatalf("buffer = %+v, want %+v", got, want)
+	}
+
+	// A frame written after the device ran out starts when it is written.
+	now = now.Add(time.Second)
+	if got := s.buffer(0, false); got.Remaining != 0 || got.Points != 0 {
+		t.Fatalf("buffer after running out = %+v", got)
+	}
+	s.record(0, 300, 30000, 1)
+	if got := s.buffer(0, false); got.Remaining != 10*time.Millisecond {
+		t.Fatalf("remaining = %v, want 10ms", got.Remaining)
+	}
+
+	// Failed writes queue nothing, and stopping clears the queue.
+	s.record(0, 0, 30000, int(ErrPpsTooHigh))
+	s.stop(0)
+	if got := s.buffer(0, false); got.Remaining != 0 {
+		t.Fatalf("remaining after stop = %v", got.Remaining)
+	}
+}
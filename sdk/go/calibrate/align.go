@@ -0,0 +1,143 @@
+package calibrate
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/output"
+	"github.com/Grix/helios_dac/sdk/go/scene"
+)
+
+// center is the middle of the device coordinate range, and also half of
+// it.
+const center = 0xFFF / 2.0
+
+// Correction is an output correction: points are scaled about the center,
+// rotated about it and then offset. The zero Correction leaves points
+// unchanged.
+type Correction struct {
+	// ScaleX and ScaleY scale the output; zero means 1.
+	ScaleX float64 `json:"scale_x,omitempty"`
+	ScaleY float64 `json:"scale_y,omitempty"`
+
+	// Rotation is in degrees, counter-clockwise.
+	Rotation float64 `json:"rotation,omitempty"`
+
+	// OffsetX and OffsetY move the output, in normalized units where 1 is
+	// half the coordinate range.
+	OffsetX float64 `json:"offset_x,omitempty"`
+	OffsetY float64 `json:"offset_y,omitempty"`
+}
+
+func orOne(v float64) float64 {
+	if v == 0 {
+		return 1
+	}
+	return v
+}
+
+// Transform returns the correction as a transform of device coordinates.
+func (c Correction) Transform() scene.Transform {
+	return scene.Scale(orOne(c.ScaleX), orOne(c.ScaleY), center, center).
+		Then(scene.Rotate(c.Rotation*math.Pi/180, center, center)).
+		Then(scene.Translate(c.OffsetX*center, c.OffsetY*center))
+}
+
+// Apply returns a copy of points with the correction applied. Points moved
+// outside the coordinate range are clamped to its edge.
+func (c Correction) Apply(points []helios.Point) []helios.Point {
+	tr := c.Transform()
+	out := make([]helios.Point, len(points))
+	for i, p := range points {
+		x, y := tr.Apply(float64(p.X), float64(p.Y))
+		out[i] = p
+		out[i].X, out[i].Y = helios.ClampToCoord(x), helios.ClampToCoord(y)
+	}
+	return out
+}
+
+// Wrap returns an output applying the correction to every frame written
+// to out.
+func (c Correction) Wrap(out output.Output) output.Output {
+	return &corrected{Output: out, c: c}
+}
+
+type corrected struct {
+	output.Output
+	c Correction
+}
+
+func (o *corrected) WriteFrame(pps int, points []helios.Point) error {
+	return o.Output.WriteFrame(pps, o.c.Apply(points))
+}
+
+func (o *corrected) Unwrap() output.Output { return o.Output }
+
+// Aligner shows a calibration pattern through a Correction that can be
+// changed while it plays. It is safe for concurrent use.
+type Aligner struct {
+	mu      sync.Mutex
+	pattern Pattern
+	c       Correction
+}
+
+// NewAligner returns an aligner showing Grid through c, typically the
+// device's current correction.
+func NewAligner(c Correction) *Aligner {
+	return &Aligner{c: c}
+}
+
+// Points draws the pattern through the current correction. It implements
+// scene.Layer, so the aligner plays like any other content.
+func (a *Aligner) Points(t time.Duration, budget int) []helios.Point {
+	a.mu.Lock()
+	p, c := a.pattern, a.c
+	a.mu.Unlock()
+	return c.Apply(p.Points(t, budget))
+}
+
+// Pattern returns the pattern shown.
+func (a *Aligner) Pattern() Pattern {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.pattern
+}
+
+// SetPattern changes the pattern shown.
+func (a *Aligner) SetPattern(p Pattern) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.pattern = p
+}
+
+// Correction returns the current correction, ready to be saved and used
+// as the device's output correction.
+func (a *Aligner) Correction() Correction {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.c
+}
+
+// SetCorrection replaces the correction.
+func (a *Aligner) SetCorrection(c Correction) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.c = c
+}
+
+// Nudge adds each field of d to the correction and returns the result.
+// Unset scales count as 1, so a ScaleX of 0.01 grows the output by 1%.
+func (a *Aligner) Nudge(d Correction) Correction {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.c = Correction{
+		ScaleX:   orOne(a.c.ScaleX) + d.ScaleX,
+		ScaleY:   orOne(a.c.ScaleY) + d.ScaleY,
+		Rotation: a.c.Rotation + d.Rotation,
+		OffsetX:  a.c.OffsetX + d.OffsetX,
+		OffsetY:  a.c.OffsetY + d.OffsetY,
+	}
+	return a.c
+}
@@ -0,0 +1,150 @@
+// Package calibrate draws calibration content and aligns a projector's
+// output while it is shown.
+//
+// Each Pattern is a scene.Layer: a grid, a crosshair, colored corner
+// markers or a test pattern. An Aligner plays a pattern through a
+// Correction that the caller nudges, locally or over HTTP, until the
+// projection lines up with the venue; the finished Correction is saved as
+// JSON and applied to the device's output with Correction.Wrap or
+// Correction.Apply.
+//
+// A LatencyTest measures how long frames take from being written to being
+// scanned, for shows synchronized to audio.
+package calibrate
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/svg"
+)
+
+// Pattern is a kind of calibration content.
+type Pattern int
+
+const (
+	// Grid draws the outline of the projection area divided into 4×4
+	// cells.
+	Grid Pattern = iota
+
+	// Crosshair draws horizontal and vertical lines through the center,
+	// with a small square marking it.
+	Crosshair
+
+	// Corners draws an L-shaped marker in each corner: red top left, green
+	// top right, blue bottom right and white bottom left, so mirrored or
+	// rotated output is recognized at a glance.
+	Corners
+
+	// TestPattern draws the outline, an inscribed circle, the diagonals
+	// and a centered cross, in the spirit of the ILDA test pattern, to
+	// judge geometry and scanner tuning together.
+	TestPattern
+
+	numPatterns
+)
+
+var patternNames = [numPatterns]string{"grid", "crosshair", "corners", "test_pattern"}
+
+func (p Pattern) String() string {
+	if p >= 0 && p < numPatterns {
+		return patternNames[p]
+	}
+	return fmt.Sprintf("Pattern(%d)", int(p))
+}
+
+// ParsePattern returns the pattern with the given name, as returned by
+// String.
+func ParsePattern(name string) (Pattern, error) {
+	for i, n := range patternNames {
+		if n == name {
+			return Pattern(i), nil
+		}
+	}
+	return 0, fmt.Errorf("calibrate: unknown pattern %q", name)
+}
+
+// MarshalText encodes p as its name.
+func (p Pattern) MarshalText() ([]byte, error) {
+	if p < 0 || p >= numPatterns {
+		return nil, fmt.Errorf("calibrate: unknown pattern %d", int(p))
+	}
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText decodes a pattern name.
+func (p *Pattern) UnmarshalText(b []byte) error {
+	v, err := ParsePattern(string(b))
+	if err != nil {
+		return err
+	}
+	*p = v
+	return nil
+}
+
+// Paths returns the outlines of the pattern in normalized coordinates.
+func (p Pattern) Paths() []svg.Path {
+	white := func(pts ...[2]float64) svg.Path { return svg.Path{R: 1, G: 1, B: 1, Points: pts} }
+	outline := white([2]float64{-1, -1}, [2]float64{1, -1}, [2]float64{1, 1}, [2]float64{-1, 1}, [2]float64{-1, -1})
+	switch p {
+	case Grid:
+		paths := []svg.Path{outline}
+		for _, v := range []float64{-0.5, 0, 0.5} {
+			paths = append(paths, white([2]float64{-1, v}, [2]float64{1, v}), white([2]float64{v, -1}, [2]float64{v, 1}))
+		}
+		return paths
+	case Crosshair:
+		const m = 0.05
+		return []svg.Path{
+			white([2]float64{-1, 0}, [2]float64{1, 0}),
+			white([2]float64{0, -1}, [2]float64{0, 1}),
+			white([2]float64{-m, -m}, [2]float64{m, -m}, [2]float64{m, m}, [2]float64{-m, m}, [2]float64{-m, -m}),
+		}
+	case Corners:
+		const l = 0.2
+		corner := func(x, y float64, r, g, b float64) svg.Path {
+			return svg.Path{R: r, G: g, B: b, Points: [][2]float64{{x, y - math.Copysign(l, y)}, {x, y}, {x - math.Copysign(l, x), y}}}
+		}
+		return []svg.Path{corner(-1, 1, 1, 0, 0), corner(1, 1, 0, 1, 0), corner(1, -1, 0, 0, 1), corner(-1, -1, 1, 1, 1)}
+	case TestPattern:
+		const segments = 64
+		circle := svg.Path{R: 1, G: 1, B: 1}
+		for i := range segments + 1 {
+			s, c := math.Sincos(2 * math.Pi * float64(i) / segments)
+			circle.Points = append(circle.Points, [2]float64{c, s})
+		}
+		const m = 0.25
+		return []svg.Path{
+			outline, circle,
+			white([2]float64{-1, -1}, [2]float64{1, 1}),
+			white([2]float64{-1, 1}, [2]float64{1, -1}),
+			white([2]float64{-m, 0}, [2]float64{m, 0}),
+			white([2]float64{0, -m}, [2]float64{0, m}),
+		}
+	}
+	return nil
+}
+
+// blankPoints is the number of blanked points at each end of the jumps
+// between outlines.
+const blankPoints = 4
+
+// Points draws the pattern with at most budget points, or as few as its
+// outlines need if budget is smaller. It implements scene.Layer; the
+// pattern does not change over time.
+func (p Pattern) Points(_ time.Duration, budget int) []helios.Point {
+	paths := p.Paths()
+	length, fixed := 0.0, 0
+	for _, path := range paths {
+		fixed += 1 + 2*blankPoints
+		for i := 1; i < len(path.Points); i++ {
+			a, b := path.Points[i-1], path.Points[i]
+			length += math.Hypot(b[0]-a[0], b[1]-a[1])
+			fixed++ // svg.Frame rounds each segment up
+		}
+	}
+	spacing := length / float64(max(budget-fixed, 1))
+	return svg.Frame(paths, spacing, blankPoints)
+}
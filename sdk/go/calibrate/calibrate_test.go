@@ -0,0 +1,103 @@
+package calibrate
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+func TestPatternsFitBudget(t *testing.T) {
+	for p := range numPatterns {
+		for _, budget := range []int{300, 1000, 4000} {
+			frame := p.Points(0, budget)
+			if len(frame) > budget || len(frame) < budget*3/4 {
+				t.Errorf("%v: %d points for a budget of %d", p, len(frame), budget)
+			}
+		}
+		if q, err := ParsePattern(p.String()); q != p || err != nil {
+			t.Errorf("ParsePattern(%q) = %v, %v", p, q, err)
+		}
+	}
+	if _, err := ParsePattern("spiral"); err == nil {
+		t.Error("ParsePattern accepted an unknown name")
+	}
+}
+
+func TestCornersIdentifyOrientation(t *testing.T) {
+	want := map[[2]uint16][3]uint8{
+		{0, 0xFFF}:     {255, 0, 0},
+		{0xFFF, 0xFFF}: {0, 255, 0},
+		{0xFFF, 0}:     {0, 0, 255},
+		{0, 0}:         {255, 255, 255},
+	}
+	for _, p := range Corners.Points(0, 500) {
+		c, ok := want[[2]uint16{p.X, p.Y}]
+		if !ok || p.R == 0 && p.G == 0 && p.B == 0 {
+			continue
+		}
+		if got := [3]uint8{p.R, p.G, p.B}; got != c {
+			t.Errorf("corner (%d, %d) is %v, want %v", p.X, p.Y, got, c)
+		}
+		delete(want, [2]uint16{p.X, p.Y})
+	}
+	if len(want) > 0 {
+		t.Errorf("corners not drawn: %v", want)
+	}
+}
+
+func TestCorrection(t *testing.T) {
+	points := []helios.Point{{X: 0x800, Y: 0x800, R: 9}, {X: 0xC00, Y: 0x800}}
+	if got := (Correction{}).Apply(points); got[0] != points[0] || got[1] != points[1] {
+		t.Fatalf("zero correction moved points: %v", got)
+	}
+	got := Correction{ScaleX: 0.5, Rotation: 90, OffsetY: -0.5}.Apply(points)
+	// (0xC00, 0x800) is 1024.5 right of center: halved and rotated to 512
+	// above it, then moved down by a quarter of the range.
+	if got[1].X < 0x7FF || got[1].X > 0x800 || got[1].Y != 0x600 || got[0].R != 9 {
+		t.Fatalf("corrected points = %v", got)
+	}
+	if got := (Correction{OffsetX: 2}).Apply(points); got[0].X != 0xFFF {
+		t.Fatalf("offset point not clamped: %v", got[0])
+	}
+}
+
+func TestAlignerHandler(t *testing.T) {
+	a := NewAligner(Correction{})
+	h := a.Handler()
+	do := func(method, path, body string) (State, int) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		var s State
+		json.NewDecoder(rec.Body).Decode(&s)
+		return s, rec.Code
+	}
+	if s, _ := do(http.MethodPut, "/alignment/pattern", `{"pattern":"crosshair"}`); s.Pattern != Crosshair {
+		t.Fatalf("set pattern: %+v", s)
+	}
+	if _, code := do(http.MethodPut, "/alignment/pattern", `{"pattern":"spiral"}`); code != http.StatusBadRequest {
+		t.Fatalf("unknown pattern: %d, want 400", code)
+	}
+	do(http.MethodPatch, "/alignment/correction", `{"scale_x":-0.1,"rotation":2}`)
+	s, _ := do(http.MethodPatch, "/alignment/correction", `{"rotation":1,"offset_x":0.05}`)
+	want := Correction{ScaleX: 0.9, ScaleY: 1, Rotation: 3, OffsetX: 0.05}
+	if s.Correction != want {
+		t.Fatalf("nudged correction = %+v, want %+v", s.Correction, want)
+	}
+	if s, _ := do(http.MethodGet, "/alignment", ""); s.Correction != a.Correction() || s.Pattern != Crosshair {
+		t.Fatalf("state = %+v", s)
+	}
+
+	// The live pattern shows the correction.
+	frame, plain := a.Points(0, 500), Crosshair.Points(0, 500)
+	if want := want.Apply(plain); len(frame) != len(want) || frame[10] != want[10] {
+		t.Fatal("aligner output does not match the corrected pattern")
+	}
+
+	if s, _ := do(http.MethodPut, "/alignment/correction", `{}`); s.Correction != (Correction{}) {
+		t.Fatalf("reset correction = %+v", s.Correction)
+	}
+}
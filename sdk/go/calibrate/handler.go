@@ -0,0 +1,69 @@
+package calibrate
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// State is the state of an Aligner, as served by its Handler.
+type State struct {
+	Pattern    Pattern    `json:"pattern"`
+	Correction Correction `json:"correction"`
+}
+
+// Handler returns an HTTP API for aligning while the pattern plays:
+//
+//	GET   /alignment             the State, as JSON
+//	PUT   /alignment/pattern     select the pattern named by {"pattern": name}
+//	PUT   /alignment/correction  replace the correction with the JSON body
+//	PATCH /alignment/correction  nudge the correction by the JSON body
+//
+// Every endpoint responds with the State, whose correction is what to
+// export once the projection lines up.
+func (a *Aligner) Handler() http.Handler {
+	mux := http.NewServeMux()
+	state := func(w http.ResponseWriter) {
+		a.mu.Lock()
+		s := State{Pattern: a.pattern, Correction: a.c}
+		a.mu.Unlock()
+		writeJSON(w, s)
+	}
+	mux.HandleFunc("GET /alignment", func(w http.ResponseWriter, r *http.Request) {
+		state(w)
+	})
+	mux.HandleFunc("PUT /alignment/pattern", func(w http.ResponseWriter, r *http.Request) {
+		var v struct {
+			Pattern Pattern `json:"pattern"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		a.SetPattern(v.Pattern)
+		state(w)
+	})
+	mux.HandleFunc("PUT /alignment/correction", func(w http.ResponseWriter, r *http.Request) {
+		var c Correction
+		if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		a.SetCorrection(c)
+		state(w)
+	})
+	mux.HandleFunc("PATCH /alignment/correction", func(w http.ResponseWriter, r *http.Request) {
+		var d Correction
+		if err := json.NewDecoder(r.Body).Decode(&d); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		a.Nudge(d)
+		state(w)
+	})
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
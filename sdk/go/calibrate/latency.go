@@ -0,0 +1,187 @@
+package calibrate
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/output"
+)
+
+// Defaults of a LatencyTest's zero fields.
+const (
+	DefaultLatencySamples = 10
+	DefaultLatencyPPS     = 20000
+	DefaultLatencyPoints  = 200
+	DefaultLatencyTimeout = time.Second
+)
+
+// latencyPoll is how often a LatencyTest polls the output's readiness.
+const latencyPoll = 100 * time.Microsecond
+
+// Sensor detects the laser, such as a photodiode watching the projection.
+// The Helios SDK cannot read the user ports, so the sensor is read by the
+// caller: through a microcontroller, a sound card input or a camera.
+type Sensor interface {
+	// Wait blocks until the sensor next sees light and returns when it
+	// did.
+	Wait(ctx context.Context) (time.Time, error)
+}
+
+// LatencyTest measures the time from writing a frame to the output to the
+// device scanning it, so audio-synced shows can send their frames that
+// much early. Audio and video latencies differ between setups; measure
+// each one with the content's point rate.
+//
+// Without a Sensor the test writes two frames of blanked points to the
+// idle output, and times how long the output takes to be ready again: the
+// first frame's scan time plus the latency. This covers the USB or network
+// link and the device's buffering, but not the scanners. With a Sensor the
+// first frame is lit, and the latency is the time until the sensor sees it.
+type LatencyTest struct {
+	// Samples is the number of measurements. Zero means
+	// DefaultLatencySamples.
+	Samples int
+
+	// PPS and Points are the scan rate and size of the frames written.
+	// Zero means DefaultLatencyPPS and DefaultLatencyPoints.
+	PPS    int
+	Points int
+
+	// Sensor, if set, times when the light is seen instead of when the
+	// output becomes ready.
+	Sensor Sensor
+
+	// Timeout bounds each measurement. Zero means DefaultLatencyTimeout.
+	Timeout time.Duration
+}
+
+// Latency is the result of a LatencyTest.
+type Latency struct {
+	// Samples are the measurements, in order.
+	Samples []time.Duration
+
+	// Mean is the average of Samples and Jitter their standard deviation.
+	Mean   time.Duration
+	Jitter time.Duration
+
+	Min, Max time.Duration
+}
+
+// ErrLatencyTimeout is returned when a measurement takes longer than the
+// test's Timeout.
+var ErrLatencyTimeout = errors.New("calibrate: latency measurement timed out")
+
+// Run measures the latency of out. The output is stopped afterwards.
+func (lt LatencyTest) Run(ctx context.Context, out output.Output) (Latency, error) {
+	defer out.Stop()
+	var samples []time.Duration
+	for range orDefault(lt.Samples, DefaultLatencySamples) {
+		d, err := lt.measure(ctx, out)
+		if err != nil {
+			return Latency{}, err
+		}
+		samples = append(samples, d)
+	}
+	return summarize(samples), nil
+}
+
+// measure takes one sample, starting once the output has scanned
+// everything written before.
+func (lt LatencyTest) measure(ctx context.Context, out output.Output) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, orDefault(lt.Timeout, DefaultLatencyTimeout))
+	defer cancel()
+	pps, n := orDefault(lt.PPS, DefaultLatencyPPS), orDefault(lt.Points, DefaultLatencyPoints)
+	scan := time.Duration(n) * time.Second / time.Duration(pps)
+
+	if err := waitReady(ctx, out); err != nil {
+		return 0, err
+	}
+	// Ready only tells that the last frame started; let it finish.
+	if err := sleep(ctx, scan); err != nil {
+		return 0, err
+	}
+	frame := make([]helios.Point, n)
+	for i := range frame {
+		frame[i] = helios.Point{X: 0x800, Y: 0x800}
+	}
+	if lt.Sensor != nil {
+		for i := range frame {
+			frame[i].R, frame[i].G, frame[i].B, frame[i].I = 255, 255, 255, 255
+		}
+	}
+
+	written := time.Now()
+	if err := out.WriteFrame(pps, frame); err != nil {
+		return 0, err
+	}
+	if lt.Sensor != nil {
+		seen, err := lt.Sensor.Wait(ctx)
+		if err != nil {
+			return 0, err
+		}
+		return seen.Sub(written), out.Stop()
+	}
+	// The second frame waits until the first is scanned, so the output is
+	// ready again once the first frame has reached the device and scanned.
+	if err := out.WriteFrame(pps, frame[:1+len(frame)/10]); err != nil {
+		return 0, err
+	}
+	if err := waitReady(ctx, out); err != nil {
+		return 0, err
+	}
+	return max(time.Since(written)-scan, 0), nil
+}
+
+func waitReady(ctx context.Context, out output.Output) error {
+	for {
+		ready, err := out.Ready()
+		if err != nil || ready {
+			return err
+		}
+		if err := sleep(ctx, latencyPoll); err != nil {
+			return err
+		}
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return ErrLatencyTimeout
+		}
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+func orDefault[T int | time.Duration](v, def T) T {
+	if v > 0 {
+		return v
+	}
+	return def
+}
+
+// summarize computes the statistics of samples.
+func summarize(samples []time.Duration) Latency {
+	l := Latency{Samples: samples, Min: samples[0], Max: samples[0]}
+	var sum float64
+	for _, d := range samples {
+		sum += float64(d)
+		l.Min, l.Max = min(l.Min, d), max(l.Max, d)
+	}
+	mean := sum / float64(len(samples))
+	var sq float64
+	for _, d := range samples {
+		sq += (float64(d) - mean) * (float64(d) - mean)
+	}
+	l.Mean = time.Duration(mean)
+	l.Jitter = time.Duration(math.Sqrt(sq / float64(len(samples))))
+	return l
+}
@@ -0,0 +1,95 @@
+package calibrate
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+// slowOutput models a double-buffered device reached after a fixed delay:
+// each frame starts scanning latency after it is written, or when the
+// frame before it ends, and the output is ready while no frame waits.
+type slowOutput struct {
+	latency time.Duration
+
+	mu     sync.Mutex
+	starts []time.Time
+	end    time.Time
+	lit    chan time.Time // receives when lit frames start
+}
+
+func (o *slowOutput) Ready() (bool, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.starts) == 0 || !o.starts[len(o.starts)-1].After(time.Now()), nil
+}
+
+func (o *slowOutput) WriteFrame(pps int, points []helios.Point) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	start := time.Now().Add(o.latency)
+	if o.end.After(start) {
+		start = o.end
+	}
+	o.starts = append(o.starts, start)
+	o.end = start.Add(time.Duration(len(points)) * time.Second / time.Duration(pps))
+	if o.lit != nil && points[0].I > 0 {
+		o.lit <- start
+	}
+	return nil
+}
+
+func (o *slowOutput) Stop() error  { return nil }
+func (o *slowOutput) Close() error { return nil }
+
+// sensor sees the light when the output starts a lit frame.
+type sensor struct{ lit chan time.Time }
+
+func (s sensor) Wait(ctx context.Context) (time.Time, error) {
+	select {
+	case <-ctx.Done():
+		return time.Time{}, ctx.Err()
+	case t := <-s.lit:
+		time.Sleep(time.Until(t))
+		return t, nil
+	}
+}
+
+func TestLatency(t *testing.T) {
+	lit := make(chan time.Time, 1)
+	for _, lt := range []LatencyTest{
+		{Samples: 5},
+		{Samples: 5, Sensor: sensor{lit}},
+	} {
+		out := &slowOutput{latency: 5 * time.Millisecond, lit: lit}
+		l, err := lt.Run(context.Background(), out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(l.Samples) != 5 || l.Min > l.Mean || l.Mean > l.Max {
+			t.Fatalf("result %+v", l)
+		}
+		// Polling only adds to the measurement.
+		if l.Min < 5*time.Millisecond || l.Mean > 8*time.Millisecond {
+			t.Errorf("sensor %v: mean %v, min %v, want about 5ms", lt.Sensor != nil, l.Mean, l.Min)
+		}
+	}
+}
+
+func TestLatencyTimeout(t *testing.T) {
+	out := &slowOutput{latency: time.Hour}
+	lt := LatencyTest{Timeout: 20 * time.Millisecond}
+	if _, err := lt.Run(context.Background(), out); err != ErrLatencyTimeout {
+		t.Fatalf("Run = %v, want a timeout", err)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	l := summarize([]time.Duration{2, 4, 4, 4, 5, 5, 7, 9})
+	if l.Mean != 5 || l.Jitter != 2 || l.Min != 2 || l.Max != 9 {
+		t.Fatalf("summarize = %+v", l)
+	}
+}
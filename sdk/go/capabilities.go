@@ -0,0 +1,88 @@
+package helios
+
+import "fmt"
+
+// Firmware is a firmware version split into its parts. USB devices report
+// a single number, which is kept in Major; network devices report
+// major*10000 + minor*100 + patch.
+type Firmware struct {
+	Major, Minor, Patch int
+}
+
+// ParseFirmware splits a version returned by GetFirmwareVersion.
+func ParseFirmware(version int, isUsb bool) Firmware {
+	if isUsb || version < 0 {
+		return Firmware{Major: version}
+	}
+	return Firmware{Major: version / 10000, Minor: version / 100 % 100, Patch: version % 100}
+}
+
+func (f Firmware) String() string {
+	if f.Minor == 0 && f.Patch == 0 {
+		return fmt.Sprintf("v%d", f.Major)
+	}
+	return fmt.Sprintf("v%d.%d.%d", f.Major, f.Minor, f.Patch)
+}
+
+// Capabilities describes what a device supports, so applications can pick
+// a point format and features without comparing firmware numbers.
+type Capabilities struct {
+	IsUsb bool
+
+	// FirmwareVersion is the version as returned by GetFirmwareVersion,
+	// and Firmware the same split into its parts.
+	FirmwareVersion int
+	Firmware        Firmware
+
+	// HighResPoints reports that WriteFrameHighResolution sends 16-bit
+	// color and position to the device. Otherwise frames are converted to
+	// the standard format first.
+	HighResPoints bool
+
+	// ExtendedPoints reports that WriteFrameExtended sends the intensity
+	// and user channels of PointExt to the device, and UserPorts is the
+	// number of user channels it carries.
+	ExtendedPoints bool
+	UserPorts      int
+
+	// Shutter reports that SetShutter controls a shutter. Network devices
+	// open and close theirs automatically.
+	Shutter bool
+
+	// Limits are the frame size and point rates the device accepts.
+	Limits FrameLimits
+}
+
+// Meets reports whether the device's firmware meets r.
+func (c Capabilities) Meets(r Requirement) bool {
+	return len(checkFirmware(DeviceInfo{IsUsb: c.IsUsb, FirmwareVersion: c.FirmwareVersion}, []Requirement{r})) == 0
+}
+
+// capabilitiesOf derives the capabilities of a device the way the C++ SDK
+// treats it: USB devices take standard points and convert the others,
+// network devices take every format.
+func capabilitiesOf(isUsb bool, firmware int, highRes bool) Capabilities {
+	c := Capabilities{
+		IsUsb:           isUsb,
+		FirmwareVersion: firmware,
+		Firmware:        ParseFirmware(firmware, isUsb),
+		HighResPoints:   highRes,
+		Shutter:         isUsb,
+		Limits:          LimitsNetwork,
+	}
+	if isUsb {
+		c.Limits = LimitsUsb
+	} else {
+		c.ExtendedPoints, c.UserPorts = true, 4
+	}
+	return c
+}
+
+// Capabilities queries what a device supports.
+func (d *DAC) Capabilities(deviceIndex int) Capabilities {
+	return capabilitiesOf(
+		d.GetIsUsb(deviceIndex),
+		d.GetFirmwareVersion(deviceIndex),
+		d.GetSupportsHigherResolutions(deviceIndex) == 1,
+	)
+}
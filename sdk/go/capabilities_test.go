@@ -0,0 +1,22 @@
+package helios
+
+import "testing"
+
+func TestCapabilities(t *testing.T) {
+	usb := capabilitiesOf(true, 6, false)
+	if usb.Limits != LimitsUsb || usb.ExtendedPoints || usb.UserPorts != 0 || !usb.Shutter || usb.Firmware.String() != "v6" {
+		t.Fatalf("usb capabilities = %+v", usb)
+	}
+	network := capabilitiesOf(false, 10002, true)
+	if network.Limits != LimitsNetwork || !network.HighResPoints || network.UserPorts != 4 || network.Shutter {
+		t.Fatalf("network capabilities = %+v", network)
+	}
+	if f := network.Firmware; f != (Firmware{1, 0, 2}) || f.String() != "v1.0.2" {
+		t.Fatalf("network firmware = %+v (%v)", f, f)
+	}
+
+	r := Requirement{Feature: "extended", MinFirmwareUsb: 7, MinFirmwareNetwork: 10000}
+	if usb.Meets(r) || !network.Meets(r) {
+		t.Fatalf("Meets = %v, %v; want false, true", usb.Meets(r), network.Meets(r))
+	}
+}
@@ -0,0 +1,229 @@
+package color
+
+import (
+	"math"
+	"slices"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+// RGB is a color with channels from 0 to 1.
+type RGB struct {
+	R float64 `json:"r"`
+	G float64 `json:"g"`
+	B float64 `json:"b"`
+}
+
+// HSV converts hue, saturation and value to RGB. Hue is in turns of the
+// color wheel (0 is red, 1/3 green, 2/3 blue) and wraps; saturation and
+// value are clamped to 0 - 1.
+func HSV(h, s, v float64) RGB {
+	s, v = clamp01(s), clamp01(v)
+	h = math.Mod(h, 1)
+	if h < 0 {
+		h++
+	}
+	h *= 6
+	channel := func(n float64) float64 {
+		k := math.Mod(n+h, 6)
+		return v - v*s*max(0, min(k, 4-k, 1))
+	}
+	return RGB{channel(5), channel(3), channel(1)}
+}
+
+// HSV returns the hue (in turns), saturation and value of c. Grays have hue
+// 0.
+func (c RGB) HSV() (h, s, v float64) {
+	hi, lo := max(c.R, c.G, c.B), min(c.R, c.G, c.B)
+	chroma := hi - lo
+	if hi > 0 {
+		s = chroma / hi
+	}
+	switch {
+	case chroma == 0:
+		h = 0
+	case hi == c.R:
+		h = math.Mod((c.G-c.B)/chroma+6, 6)
+	case hi == c.G:
+		h = (c.B-c.R)/chroma + 2
+	default:
+		h = (c.R-c.G)/chroma + 4
+	}
+	return h / 6, s, hi
+}
+
+// HSV8 converts hue (in turns), saturation and value to 8-bit channels.
+func HSV8(h, s, v float64) (r, g, b uint8) {
+	c := HSV(h, s, v)
+	return level8(c.R), level8(c.G), level8(c.B)
+}
+
+// ToHSV8 returns the hue (in turns), saturation and value of 8-bit channels.
+func ToHSV8(r, g, b uint8) (h, s, v float64) {
+	return RGB{float64(r) / 0xFF, float64(g) / 0xFF, float64(b) / 0xFF}.HSV()
+}
+
+// HSV16 converts hue (in turns), saturation and value to 16-bit channels,
+// as used by helios.PointHighRes and helios.PointExt.
+func HSV16(h, s, v float64) (r, g, b uint16) {
+	c := HSV(h, s, v)
+	return level16(c.R), level16(c.G), level16(c.B)
+}
+
+// ToHSV16 returns the hue (in turns), saturation and value of 16-bit
+// channels.
+func ToHSV16(r, g, b uint16) (h, s, v float64) {
+	return RGB{float64(r) / 0xFFFF, float64(g) / 0xFFFF, float64(b) / 0xFFFF}.HSV()
+}
+
+func clamp01(v float64) float64 {
+	if math.IsNaN(v) {
+		return 0
+	}
+	return max(0, min(v, 1))
+}
+
+func level8(v float64) uint8 {
+	return helios.ScaleColor(0xFF, clamp01(v))
+}
+
+func level16(v float64) uint16 {
+	return helios.ScaleColor16(0xFFFF, clamp01(v))
+}
+
+// Stop is a color at a position, from 0 to 1, along a Gradient.
+type Stop struct {
+	Pos   float64 `json:"pos"`
+	Color RGB     `json:"color"`
+}
+
+// Gradient blends linearly between stops, which must be sorted by position.
+// Before the first stop and after the last the end colors hold.
+type Gradient []Stop
+
+// Even returns a gradient with colors spaced evenly from 0 to 1.
+func Even(colors ...RGB) Gradient {
+	g := make(Gradient, len(colors))
+	for i, c := range colors {
+		g[i] = Stop{Pos: float64(i) / float64(max(len(colors)-1, 1)), Color: c}
+	}
+	return g
+}
+
+// At returns the color at t.
+func (g Gradient) At(t float64) RGB {
+	if len(g) == 0 {
+		return RGB{}
+	}
+	i, _ := slices.BinarySearchFunc(g, t, func(s Stop, t float64) int {
+		if s.Pos < t {
+			return -1
+		}
+		if s.Pos > t {
+			return 1
+		}
+		return 0
+	})
+	if i == 0 {
+		return g[0].Color
+	}
+	if i == len(g) {
+		return g[len(g)-1].Color
+	}
+	a, b := g[i-1], g[i]
+	f := 0.0
+	if b.Pos > a.Pos {
+		f = (t - a.Pos) / (b.Pos - a.Pos)
+	}
+	return RGB{
+		a.Color.R + (b.Color.R-a.Color.R)*f,
+		a.Color.G + (b.Color.G-a.Color.G)*f,
+		a.Color.B + (b.Color.B-a.Color.B)*f,
+	}
+}
+
+// Ramp returns a copy of frame with the lit points colored by g according
+// to how far along the path they are, so the gradient runs from the first
+// lit point to the last. Blanked travel counts towards the distance, so
+// separate shapes continue the ramp where the previous one stopped. The
+// brightness of each point is kept by scaling the gradient color with the
+// point's largest channel.
+func Ramp(frame []helios.Point, g Gradient) []helios.Point {
+	out := slices.Clone(frame)
+	cum := make([]float64, len(frame))
+	first, last := -1, -1
+	for i, p := range frame {
+		if i > 0 {
+			q := frame[i-1]
+			cum[i] = cum[i-1] + math.Hypot(float64(p.X)-float64(q.X), float64(p.Y)-float64(q.Y))
+		}
+		if isLit(p) {
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+	}
+	if first < 0 {
+		return out
+	}
+	span := cum[last] - cum[first]
+	for i := first; i <= last; i++ {
+		p := frame[i]
+		if !isLit(p) {
+			continue
+		}
+		t := 0.0
+		if span > 0 {
+			t = (cum[i] - cum[first]) / span
+		}
+		c, level := g.At(t), float64(max(p.R, p.G, p.B))/0xFF
+		out[i].R, out[i].G, out[i].B = level8(c.R*level), level8(c.G*level), level8(c.B*level)
+	}
+	return out
+}
+
+func isLit(p helios.Point) bool {
+	return p.R != 0 || p.G != 0 || p.B != 0
+}
+
+// Palettes are named gradients for dynamic content.
+var Palettes = map[string]Gradient{
+	"rainbow": Even(RGB{1, 0, 0}, RGB{1, 1, 0}, RGB{0, 1, 0}, RGB{0, 1, 1}, RGB{0, 0, 1}, RGB{1, 0, 1}, RGB{1, 0, 0}),
+	"fire":    Even(RGB{1, 0, 0}, RGB{1, 0.35, 0}, RGB{1, 0.8, 0}),
+	"ice":     Even(RGB{0, 0.2, 1}, RGB{0, 0.8, 1}, RGB{0.8, 1, 1}),
+	"ocean":   Even(RGB{0, 0, 1}, RGB{0, 0.6, 0.8}, RGB{0, 1, 0.5}),
+	"sunset":  Even(RGB{1, 0.5, 0}, RGB{1, 0, 0.3}, RGB{0.5, 0, 1}),
+	"forest":  Even(RGB{0, 0.4, 0}, RGB{0.3, 1, 0}, RGB{0.8, 1, 0.2}),
+	"neon":    Even(RGB{1, 0, 1}, RGB{0, 1, 1}, RGB{0.6, 1, 0}),
+}
+
+// Dither keeps dim colors visible on diodes that do not light below a
+// threshold. Each channel value below the channel's threshold (but above
+// zero) is replaced along the path by a mix of zero and the threshold
+// whose average matches the requested value, using error diffusion so the
+// pattern is as fine as the point spacing allows. Values at or above the
+// threshold, and channels with a zero threshold, are kept. The caller's
+// slice is never modified.
+func Dither(frame []helios.Point, threshold [3]uint8) []helios.Point {
+	out := slices.Clone(frame)
+	var carry [3]float64
+	for i := range out {
+		chans := [3]*uint8{&out[i].R, &out[i].G, &out[i].B}
+		for c, v := range chans {
+			th := threshold[c]
+			if th == 0 || *v == 0 || *v >= th {
+				carry[c] = 0
+				continue
+			}
+			want := float64(*v) + carry[c]
+			if want >= float64(th)/2 {
+				*v = th
+			} else {
+				*v = 0
+			}
+			carry[c] = want - float64(*v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,112 @@
+package color
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+func near(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+func TestHSVRoundTrip(t *testing.T) {
+	tests := []struct {
+		h, s, v float64
+		want    RGB
+	}{
+		{0, 1, 1, RGB{1, 0, 0}},
+		{1.0 / 3, 1, 1, RGB{0, 1, 0}},
+		{2.0 / 3, 1, 1, RGB{0, 0, 1}},
+		{1.0 / 6, 1, 0.5, RGB{0.5, 0.5, 0}},
+		{-1.0 / 3, 1, 1, RGB{0, 0, 1}}, // hue wraps
+		{0.4, 0, 0.25, RGB{0.25, 0.25, 0.25}},
+	}
+	for _, tt := range tests {
+		got := HSV(tt.h, tt.s, tt.v)
+		if !near(got.R, tt.want.R) || !near(got.G, tt.want.G) || !near(got.B, tt.want.B) {
+			t.Errorf("HSV(%v, %v, %v) = %+v, want %+v", tt.h, tt.s, tt.v, got, tt.want)
+		}
+	}
+
+	h, s, v := RGB{0.2, 0.8, 0.5}.HSV()
+	if back := HSV(h, s, v); !near(back.R, 0.2) || !near(back.G, 0.8) || !near(back.B, 0.5) {
+		t.Fatalf("round trip gave %+v", back)
+	}
+
+	if r, g, b := HSV8(ToHSV8(10, 200, 99)); r != 10 || g != 200 || b != 99 {
+		t.Fatalf("8-bit round trip gave %d %d %d", r, g, b)
+	}
+	if r, g, b := HSV16(ToHSV16(1000, 60000, 30000)); r != 1000 || g != 60000 || b != 30000 {
+		t.Fatalf("16-bit round trip gave %d %d %d", r, g, b)
+	}
+}
+
+func TestGradient(t *testing.T) {
+	g := Gradient{{0.25, RGB{1, 0, 0}}, {0.75, RGB{0, 0, 1}}}
+	for _, tt := range []struct {
+		t    float64
+		want RGB
+	}{
+		{0, RGB{1, 0, 0}},
+		{0.5, RGB{0.5, 0, 0.5}},
+		{0.75, RGB{0, 0, 1}},
+		{2, RGB{0, 0, 1}},
+	} {
+		if got := g.At(tt.t); got != tt.want {
+			t.Errorf("At(%v) = %+v, want %+v", tt.t, got, tt.want)
+		}
+	}
+	for name, p := range Palettes {
+		if len(p) < 2 || p[0].Pos != 0 || p[len(p)-1].Pos != 1 {
+			t.Errorf("palette %s = %+v", name, p)
+		}
+	}
+}
+
+func TestRamp(t *testing.T) {
+	frame := []helios.Point{
+		{X: 0},
+		{X: 0, R: 255, I: 255},
+		{X: 100, R: 255, I: 255},
+		{X: 200, G: 128, I: 255}, // half brightness
+		{X: 300},
+	}
+	out := Ramp(frame, Even(RGB{1, 0, 0}, RGB{0, 0, 1}))
+	if out[1].R != 255 || out[1].B != 0 {
+		t.Fatalf("start = %+v", out[1])
+	}
+	if out[2].R != 128 || out[2].B != 128 {
+		t.Fatalf("middle = %+v", out[2])
+	}
+	if out[3].R != 0 || out[3].G != 0 || out[3].B != 128 {
+		t.Fatalf("end = %+v", out[3])
+	}
+	if out[0] != frame[0] || out[4] != frame[4] || frame[2].B != 0 {
+		t.Fatal("blanked points changed or caller's frame modified")
+	}
+}
+
+func TestDither(t *testing.T) {
+	frame := make([]helios.Point, 100)
+	for i := range frame {
+		frame[i] = helios.Point{R: 10, G: 200, B: 10, I: 255}
+	}
+	out := Dither(frame, [3]uint8{40, 40, 0})
+	sum := 0
+	for _, p := range out {
+		if p.R != 0 && p.R != 40 {
+			t.Fatalf("R = %d, want 0 or the threshold", p.R)
+		}
+		if p.G != 200 || p.B != 10 {
+			t.Fatalf("channel above threshold or without one changed: %+v", p)
+		}
+		sum += int(p.R)
+	}
+	// The average matches the requested level.
+	if sum != 1000 {
+		t.Fatalf("R sums to %d, want 1000", sum)
+	}
+	if frame[0].R != 10 {
+		t.Fatal("caller's frame modified")
+	}
+}
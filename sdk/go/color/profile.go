@@ -0,0 +1,166 @@
+// Package color provides color correction and color utilities for laser
+// output.
+//
+// A Profile describes how content colors map to a projector's diodes
+// (per-channel gain, gamma and turn-on threshold, plus overall brightness).
+// A Switch holds the active Profile of one device and lets control code
+// replace it at any time; the change takes effect atomically at the next
+// frame, so a running pipeline can move between e.g. "daylight" and "night"
+// profiles without restarting.
+//
+// For dynamic content the package also provides HSV conversion for 8- and
+// 16-bit channels, gradients and named palettes that can be ramped along a
+// path, and dithering for dim colors below a diode's turn-on threshold.
+package color
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+// Channel corrects one color channel. The zero Channel passes values through.
+type Channel struct {
+	// Gain scales the channel (0.0 - 1.0). Nil means 1; zero switches the
+	// channel off.
+	Gain *float64 `json:"gain,omitempty"`
+
+	// Gamma is applied to the normalized value before gain. Zero means 1 (linear).
+	Gamma float64 `json:"gamma"`
+
+	// Threshold is the lowest output value at which the diode emits light.
+	// Non-zero inputs are mapped into Threshold - 255 so dim colors stay visible.
+	Threshold uint8 `json:"threshold"`
+}
+
+// Profile is a complete color correction for a projector. A Profile must not
+// be modified after it has been used to Apply frames; create a new Profile
+// and Set it on the Switch instead.
+type Profile struct {
+	Name string `json:"name"`
+
+	// Brightness scales all channels, including intensity (0.0 - 1.0).
+	// Nil means 1; zero blanks every point.
+	Brightness *float64 `json:"brightness,omitempty"`
+
+	Red   Channel `json:"red"`
+	Green Channel `json:"green"`
+	Blue  Channel `json:"blue"`
+
+	once sync.Once
+	lut  [4][256]uint8
+}
+
+// Apply returns a color-corrected copy of frame.
+func (p *Profile) Apply(frame []helios.Point) []helios.Point {
+	p.once.Do(p.build)
+	out := make([]helios.Point, len(frame))
+	for i, pt := range frame {
+		pt.R = p.lut[0][pt.R]
+		pt.G = p.lut[1][pt.G]
+		pt.B = p.lut[2][pt.B]
+		pt.I = p.lut[3][pt.I]
+		out[i] = pt
+	}
+	return out
+}
+
+// WithBrightness returns a copy of p with its brightness multiplied by
+// level (0.0 - 1.0). A nil p is treated as an uncorrected profile.
+func (p *Profile) WithBrightness(level float64) *Profile {
+	if p == nil {
+		p = &Profile{}
+	}
+	brightness := max(0, orOne(p.Brightness)*level)
+	return &Profile{
+		Name:       p.Name,
+		Brightness: &brightness,
+		Red:        p.Red,
+		Green:      p.Green,
+		Blue:       p.Blue,
+	}
+}
+
+func (p *Profile) build() {
+	brightness := orOne(p.Brightness)
+	for i, ch := range []Channel{p.Red, p.Green, p.Blue, {}} {
+		gain, gamma := orOne(ch.Gain)*brightness, ch.Gamma
+		if gamma == 0 {
+			gamma = 1
+		}
+		if gain <= 0 {
+			continue // off, even above the threshold
+		}
+		for v := 1; v < 256; v++ {
+			out := math.Pow(float64(v)/255, gamma) * gain * 255
+			if ch.Threshold > 0 {
+				out = float64(ch.Threshold) + out*(255-float64(ch.Threshold))/255
+			}
+			p.lut[i][v] = uint8(math.Round(math.Min(out, 255)))
+		}
+	}
+}
+
+func orOne(v *float64) float64 {
+	if v == nil {
+		return 1
+	}
+	return *v
+}
+
+// Switch holds the active profile of a device. It is safe for concurrent use.
+type Switch struct {
+	active atomic.Pointer[Profile]
+
+	mu    sync.Mutex
+	named map[string]*Profile
+}
+
+// NewSwitch creates a switch with p active. A nil p applies no correction.
+func NewSwitch(p *Profile) *Switch {
+	s := &Switch{named: make(map[string]*Profile)}
+	s.active.Store(p)
+	return s
+}
+
+// Set makes p the active profile from the next call to Apply.
+func (s *Switch) Set(p *Profile) {
+	s.active.Store(p)
+}
+
+// Active returns the active profile.
+func (s *Switch) Active() *Profile {
+	return s.active.Load()
+}
+
+// Register makes p selectable by name with Select.
+func (s *Switch) Register(p *Profile) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.named[p.Name] = p
+}
+
+// Select activates the registered profile with the given name.
+func (s *Switch) Select(name string) error {
+	s.mu.Lock()
+	p, ok := s.named[name]
+	s.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("color: no profile named %q", name)
+	}
+	s.Set(p)
+	return nil
+}
+
+// Apply corrects frame with the active profile. The profile is read once, so
+// a concurrent Set never splits a frame between two profiles.
+func (s *Switch) Apply(frame []helios.Point) []helios.Point {
+	p := s.active.Load()
+	if p == nil {
+		return frame
+	}
+	return p.Apply(frame)
+}
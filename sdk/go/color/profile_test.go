@@ -0,0 +1,69 @@
+package color
+
+import (
+	"testing"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+func TestProfileApply(t *testing.T) {
+	p := &Profile{
+		Brightness: value(0.5),
+		Red:        Channel{Gamma: 2},
+		Blue:       Channel{Threshold: 55},
+	}
+	frame := []helios.Point{{R: 255, G: 128, B: 0, I: 255}, {R: 128, B: 255}}
+	out := p.Apply(frame)
+
+	if out[0].R != 128 || out[0].G != 64 || out[0].B != 0 || out[0].I != 128 {
+		t.Fatalf("point 0 = %+v", out[0])
+	}
+	// 128/255 squared and halved; blue at full scale is 55 + 127.5*200/255.
+	if out[1].R != 32 || out[1].B != 155 {
+		t.Fatalf("point 1 = %+v", out[1])
+	}
+	if frame[0].R != 255 {
+		t.Fatal("Apply modified its input")
+	}
+}
+
+func value(v float64) *float64 { return &v }
+
+func TestProfileChannelOff(t *testing.T) {
+	p := &Profile{Green: Channel{Gain: value(0), Threshold: 40}}
+	out := p.Apply([]helios.Point{{R: 200, G: 200, B: 200, I: 255}})
+	if out[0].R != 200 || out[0].G != 0 || out[0].B != 200 || out[0].I != 255 {
+		t.Fatalf("zero green gain: %+v", out[0])
+	}
+
+	dark := p.WithBrightness(0)
+	if out := dark.Apply([]helios.Point{{R: 200, B: 1, I: 255}}); out[0] != (helios.Point{}) {
+		t.Fatalf("zero brightness: %+v", out[0])
+	}
+	if *dark.Green.Gain != 0 {
+		t.Fatal("WithBrightness lost the channel gain")
+	}
+}
+
+func TestSwitchSelect(t *testing.T) {
+	night := &Profile{Name: "night", Brightness: value(0.2)}
+	s := NewSwitch(nil)
+	s.Register(night)
+
+	frame := []helios.Point{{G: 250}}
+	if out := s.Apply(frame); out[0].G != 250 {
+		t.Fatalf("nil profile changed color: %+v", out[0])
+	}
+	if err := s.Select("night"); err != nil {
+		t.Fatal(err)
+	}
+	if out := s.Apply(frame); out[0].G != 50 {
+		t.Fatalf("night profile G = %d, want 50", out[0].G)
+	}
+	if err := s.Select("missing"); err == nil {
+		t.Fatal("Select of unknown profile succeeded")
+	}
+	if s.Active() != night {
+		t.Fatal("failed Select changed the active profile")
+	}
+}
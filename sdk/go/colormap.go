@@ -0,0 +1,220 @@
+package helios
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// A color map rewires the color channels of every frame written to a
+// device, so content authored in RGB renders on hardware whose channels do
+// not match it: a single-color projector driven by one channel, or one with
+// its green diode wired to the intensity pin. It is the last stage applied
+// to a frame, after the margin, as it describes the projector rather than
+// the content.
+
+// Channel is the source of one output channel of a ColorMap.
+type Channel int
+
+const (
+	ChannelSame Channel = iota // the channel itself, unchanged
+	ChannelR                   // the red channel
+	ChannelG                   // the green channel
+	ChannelB                   // the blue channel
+	ChannelI                   // the intensity channel
+	ChannelOff                 // always zero
+	ChannelMax                 // the brightest of red, green and blue
+	ChannelLuma                // the perceived brightness of red, green and blue
+	numChannels
+)
+
+var channelNames = [numChannels]string{"same", "r", "g", "b", "i", "off", "max", "luma"}
+
+func (c Channel) String() string {
+	if c < 0 || c >= numChannels {
+		return fmt.Sprintf("Channel(%d)", int(c))
+	}
+	return channelNames[c]
+}
+
+// ParseChannel returns the channel with the given name, as returned by
+// String. The empty name is ChannelSame.
+func ParseChannel(name string) (Channel, error) {
+	if name == "" {
+		return ChannelSame, nil
+	}
+	for i, n := range channelNames {
+		if n == name {
+			return Channel(i), nil
+		}
+	}
+	return 0, fmt.Errorf("helios: unknown color channel %q", name)
+}
+
+// MarshalText encodes c as its name.
+func (c Channel) MarshalText() ([]byte, error) {
+	if c < 0 || c >= numChannels {
+		return nil, fmt.Errorf("helios: unknown color channel %d", int(c))
+	}
+	return []byte(c.String()), nil
+}
+
+// UnmarshalText decodes a channel name.
+func (c *Channel) UnmarshalText(b []byte) error {
+	v, err := ParseChannel(string(b))
+	if err != nil {
+		return err
+	}
+	*c = v
+	return nil
+}
+
+// ColorMap selects the source of each color channel sent to a device. The
+// zero ColorMap leaves the colors as they are. PointHighRes carries no
+// intensity, so for it ChannelI reads as the brightest color and the I
+// field of the map is ignored.
+type ColorMap struct {
+	R Channel `json:"r,omitempty"`
+	G Channel `json:"g,omitempty"`
+	B Channel `json:"b,omitempty"`
+	I Channel `json:"i,omitempty"`
+}
+
+// MonochromeColorMap returns the map of a single-color projector whose
+// laser is driven by channel pin: the pin takes the brightest color of each
+// point, so any colored content shows, and the other color channels are
+// off. Intensity is kept unless pin is ChannelI.
+func MonochromeColorMap(pin Channel) ColorMap {
+	m := ColorMap{R: ChannelOff, G: ChannelOff, B: ChannelOff}
+	switch pin {
+	case ChannelR:
+		m.R = ChannelMax
+	case ChannelG:
+		m.G = ChannelMax
+	case ChannelB:
+		m.B = ChannelMax
+	case ChannelI:
+		m.I = ChannelMax
+	}
+	return m
+}
+
+// Validate checks that every channel is known.
+func (m ColorMap) Validate() error {
+	for _, c := range []Channel{m.R, m.G, m.B, m.I} {
+		if c < 0 || c >= numChannels {
+			return errors.New("helios: unknown color channel in color map")
+		}
+	}
+	return nil
+}
+
+// identity reports whether m leaves every channel as it is.
+func (m ColorMap) identity() bool {
+	return (m.R == ChannelSame || m.R == ChannelR) &&
+		(m.G == ChannelSame || m.G == ChannelG) &&
+		(m.B == ChannelSame || m.B == ChannelB) &&
+		(m.I == ChannelSame || m.I == ChannelI)
+}
+
+// SetColorMap sets the color map of one device, taking effect from the
+// next frame written.
+func (d *DAC) SetColorMap(deviceIndex int, m ColorMap) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+	d.levels.mu.Lock()
+	defer d.levels.mu.Unlock()
+	if m.identity() {
+		delete(d.levels.colorMaps, deviceIndex)
+		return nil
+	}
+	if d.levels.colorMaps == nil {
+		d.levels.colorMaps = make(map[int]ColorMap)
+	}
+	d.levels.colorMaps[deviceIndex] = m
+	return nil
+}
+
+// ColorMap returns the color map of one device.
+func (d *DAC) ColorMap(deviceIndex int) ColorMap {
+	return d.levels.colorMap(deviceIndex)
+}
+
+func (l *levels) colorMap(deviceIndex int) ColorMap {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.colorMaps[deviceIndex]
+}
+
+// Apply returns a copy of points with the channels remapped, for frames
+// bound for outputs other than a DAC device, or points itself if m leaves
+// the colors as they are.
+func (m ColorMap) Apply(points []Point) []Point {
+	return colorMapPoints(points, m)
+}
+
+// pick returns the value of channel c of a point with colors r, g, b and
+// intensity i, for the output channel whose own value is own.
+func pick[T uint8 | uint16](c Channel, own, r, g, b, i T) T {
+	switch c {
+	case ChannelR:
+		return r
+	case ChannelG:
+		return g
+	case ChannelB:
+		return b
+	case ChannelI:
+		return i
+	case ChannelOff:
+		return 0
+	case ChannelMax:
+		return max(r, g, b)
+	case ChannelLuma:
+		return T(math.Round(0.2126*float64(r) + 0.7152*float64(g) + 0.0722*float64(b)))
+	}
+	return own
+}
+
+// The color map functions return points unchanged with an identity map,
+// and a remapped copy otherwise; the caller's slice is never modified.
+
+func colorMapPoints(points []Point, m ColorMap) []Point {
+	if m.identity() {
+		return points
+	}
+	out := make([]Point, len(points))
+	for k, p := range points {
+		r, g, b, i := p.R, p.G, p.B, p.I
+		p.R, p.G, p.B, p.I = pick(m.R, r, r, g, b, i), pick(m.G, g, r, g, b, i), pick(m.B, b, r, g, b, i), pick(m.I, i, r, g, b, i)
+		out[k] = p
+	}
+	return out
+}
+
+func colorMapPointsHighRes(points []PointHighRes, m ColorMap) []PointHighRes {
+	if m.identity() {
+		return points
+	}
+	out := make([]PointHighRes, len(points))
+	for k, p := range points {
+		r, g, b := p.R, p.G, p.B
+		i := max(r, g, b)
+		p.R, p.G, p.B = pick(m.R, r, r, g, b, i), pick(m.G, g, r, g, b, i), pick(m.B, b, r, g, b, i)
+		out[k] = p
+	}
+	return out
+}
+
+func colorMapPointsExt(points []PointExt, m ColorMap) []PointExt {
+	if m.identity() {
+		return points
+	}
+	out := make([]PointExt, len(points))
+	for k, p := range points {
+		r, g, b, i := p.R, p.G, p.B, p.I
+		p.R, p.G, p.B, p.I = pick(m.R, r, r, g, b, i), pick(m.G, g, r, g, b, i), pick(m.B, b, r, g, b, i), pick(m.I, i, r, g, b, i)
+		out[k] = p
+	}
+	return out
+}
@@ -0,0 +1,86 @@
+package helios
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestColorMapPoints(t *testing.T) {
+	frame := []Point{{X: 1, Y: 2, R: 10, G: 200, B: 30, I: 255}, {R: 0, G: 0, B: 0, I: 0}}
+
+	// Green wired to the intensity pin.
+	got := colorMapPoints(frame, ColorMap{G: ChannelOff, I: ChannelG})
+	if got[0] != (Point{X: 1, Y: 2, R: 10, B: 30, I: 200}) || got[1] != (Point{}) {
+		t.Fatalf("remapped = %+v", got)
+	}
+	if frame[0].G != 200 {
+		t.Fatal("color map modified the caller's frame")
+	}
+
+	// A blue single-color projector shows any color at its brightness.
+	got = colorMapPoints(frame, MonochromeColorMap(ChannelB))
+	if got[0] != (Point{X: 1, Y: 2, B: 200, I: 255}) {
+		t.Fatalf("monochrome = %+v", got[0])
+	}
+
+	got = colorMapPoints(frame, ColorMap{R: ChannelLuma, G: ChannelR, B: ChannelI})
+	if got[0] != (Point{X: 1, Y: 2, R: 147, G: 10, B: 255, I: 255}) {
+		t.Fatalf("luma = %+v", got[0])
+	}
+
+	same := ColorMap{R: ChannelR, G: ChannelSame, I: ChannelI}
+	if got := colorMapPoints(frame, same); &got[0] != &frame[0] {
+		t.Fatal("identity map copied the frame")
+	}
+}
+
+func TestColorMapHighResAndExt(t *testing.T) {
+	m := ColorMap{R: ChannelOff, G: ChannelI, B: ChannelMax, I: ChannelOff}
+	hr := colorMapPointsHighRes([]PointHighRes{{X: 5, R: 100, G: 0xFFFF, B: 7}}, m)
+	// Without intensity, ChannelI reads as the brightest color.
+	if hr[0] != (PointHighRes{X: 5, G: 0xFFFF, B: 0xFFFF}) {
+		t.Fatalf("high-res = %+v", hr[0])
+	}
+	ext := colorMapPointsExt([]PointExt{{R: 1, G: 2, B: 3, I: 4, User2: 9}}, m)
+	if ext[0] != (PointExt{G: 4, B: 3, User2: 9}) {
+		t.Fatalf("ext = %+v", ext[0])
+	}
+}
+
+func TestSetColorMap(t *testing.T) {
+	d := &DAC{levels: newLevels()}
+	if err := d.SetColorMap(0, ColorMap{R: Channel(99)}); err == nil {
+		t.Error("SetColorMap accepted an unknown channel")
+	}
+	m := MonochromeColorMap(ChannelG)
+	if err := d.SetColorMap(1, m); err != nil {
+		t.Fatal(err)
+	}
+	if d.ColorMap(1) != m || d.ColorMap(0) != (ColorMap{}) {
+		t.Fatalf("color maps = %+v %+v", d.ColorMap(1), d.ColorMap(0))
+	}
+	d.SetColorMap(1, ColorMap{B: ChannelB})
+	if _, ok := d.levels.colorMaps[1]; ok {
+		t.Fatal("identity map kept")
+	}
+}
+
+func TestColorMapJSON(t *testing.T) {
+	var m ColorMap
+	if err := json.Unmarshal([]byte(`{"r":"off","i":"luma","b":""}`), &m); err != nil {
+		t.Fatal(err)
+	}
+	if m != (ColorMap{R: ChannelOff, I: ChannelLuma}) {
+		t.Fatalf("decoded %+v", m)
+	}
+	b, err := json.Marshal(MonochromeColorMap(ChannelI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"r":"off","g":"off","b":"off","i":"max"}` {
+		t.Fatalf("encoded %s", b)
+	}
+	if err := json.Unmarshal([]byte(`{"g":"purple"}`), &m); err == nil {
+		t.Fatal("decoded an unknown channel")
+	}
+}
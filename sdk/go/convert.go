@@ -0,0 +1,107 @@
+package helios
+
+import "math"
+
+// Saturating conversions from float64 to point fields. A plain uint16 or
+// uint8 conversion of an out-of-range value wraps around, which jerks the
+// beam to the opposite edge of the field or flips a dim color to full
+// brightness; these clamp instead.
+
+// ClampToCoord rounds v, in device units, to the nearest Point coordinate,
+// clamping to 0 - 0xFFF. NaN maps to the center.
+func ClampToCoord(v float64) uint16 {
+	if math.IsNaN(v) {
+		return (maxCoord + 1) / 2
+	}
+	return uint16(math.Round(max(0, min(v, maxCoord))))
+}
+
+// ClampToCoordHighRes is ClampToCoord for the 16-bit coordinates of
+// PointHighRes and PointExt.
+func ClampToCoordHighRes(v float64) uint16 {
+	if math.IsNaN(v) {
+		return 0x8000
+	}
+	return uint16(math.Round(max(0, min(v, 0xFFFF))))
+}
+
+// ScaleColor multiplies an 8-bit color or intensity value by s, rounding
+// and saturating at 0 and 0xFF. NaN scales to 0.
+func ScaleColor(v uint8, s float64) uint8 {
+	x := float64(v) * s
+	if math.IsNaN(x) {
+		return 0
+	}
+	return uint8(math.Round(max(0, min(x, 0xFF))))
+}
+
+// ScaleColor16 is ScaleColor for 16-bit color values.
+func ScaleColor16(v uint16, s float64) uint16 {
+	x := float64(v) * s
+	if math.IsNaN(x) {
+		return 0
+	}
+	return uint16(math.Round(max(0, min(x, 0xFFFF))))
+}
+
+// Conversions between the point structures. Colors widen from 8 to 16 bits
+// by multiplying by 257, so 0xFF becomes 0xFFFF, and narrow by dropping the
+// low byte as the C++ SDK does when it sends high-resolution frames to
+// standard devices; widening and then narrowing returns the original
+// value. Coordinates widen from 12 to 16 bits by repeating the top bits in
+// the low ones, so 0xFFF becomes 0xFFFF, and narrow by dropping the low 4
+// bits. Narrowing is lossy, as are the conversions that drop a field:
+// PointHighRes has no intensity, and only PointExt carries the user fields.
+// Converting to PointHighRes keeps the colors as they are, since intensity
+// is often left unset when colors are used.
+
+func widenCoord(v uint16) uint16  { return v<<4 | v>>8 }
+func widenColor(v uint8) uint16   { return uint16(v) * 257 }
+func narrowCoord(v uint16) uint16 { return v >> 4 }
+func narrowColor(v uint16) uint8  { return uint8(v >> 8) }
+
+// HighRes converts p to the high-resolution point structure, dropping the
+// intensity.
+func (p Point) HighRes() PointHighRes {
+	return PointHighRes{
+		X: widenCoord(p.X), Y: widenCoord(p.Y),
+		R: widenColor(p.R), G: widenColor(p.G), B: widenColor(p.B),
+	}
+}
+
+// Ext converts p to the extended point structure. It is lossless.
+func (p Point) Ext() PointExt {
+	return PointExt{
+		X: widenCoord(p.X), Y: widenCoord(p.Y),
+		R: widenColor(p.R), G: widenColor(p.G), B: widenColor(p.B), I: widenColor(p.I),
+	}
+}
+
+// Point converts p to the standard point structure, at full intensity.
+func (p PointHighRes) Point() Point {
+	return Point{
+		X: narrowCoord(p.X), Y: narrowCoord(p.Y),
+		R: narrowColor(p.R), G: narrowColor(p.G), B: narrowColor(p.B), I: 0xFF,
+	}
+}
+
+// Ext converts p to the extended point structure, at full intensity. It is
+// lossless.
+func (p PointHighRes) Ext() PointExt {
+	return PointExt{X: p.X, Y: p.Y, R: p.R, G: p.G, B: p.B, I: 0xFFFF}
+}
+
+// Point converts p to the standard point structure, dropping the user
+// fields.
+func (p PointExt) Point() Point {
+	return Point{
+		X: narrowCoord(p.X), Y: narrowCoord(p.Y),
+		R: narrowColor(p.R), G: narrowColor(p.G), B: narrowColor(p.B), I: narrowColor(p.I),
+	}
+}
+
+// HighRes converts p to the high-resolution point structure, dropping the
+// intensity and user fields.
+func (p PointExt) HighRes() PointHighRes {
+	return PointHighRes{X: p.X, Y: p.Y, R: p.R, G: p.G, B: p.B}
+}
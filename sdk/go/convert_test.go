@@ -0,0 +1,95 @@
+package helios
+
+import (
+	"math"
+	"testing"
+)
+
+func TestClampToCoord(t *testing.T) {
+	tests := []struct {
+		in   float64
+		want uint16
+	}{
+		{0, 0},
+		{0.49, 0},
+		{0.5, 1},
+		{2047.5, 2048},
+		{4094.6, 4095},
+		{4095, 4095},
+		{4095.4, 4095},
+		{4096, 4095},
+		{1e12, 4095},
+		{-0.4, 0},
+		{-1, 0},
+		{-1e12, 0},
+		{math.Inf(1), 4095},
+		{math.Inf(-1), 0},
+		// A degenerate transform must not send the beam anywhere undefined.
+		{math.NaN(), 2048},
+	}
+	for _, tt := range tests {
+		if got := ClampToCoord(tt.in); got != tt.want {
+			t.Errorf("ClampToCoord(%v) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestScaleColor(t *testing.T) {
+	tests := []struct {
+		v    uint8
+		f    float64
+		want uint8
+	}{
+		{255, 1, 255},
+		{255, 0, 0},
+		{255, 0.5, 128},
+		{100, 0.5, 50},
+		{1, 0.5, 1},
+		{200, 2, 255},
+		{200, -1, 0},
+		{200, math.NaN(), 0},
+	}
+	for _, tt := range tests {
+		if got := ScaleColor(tt.v, tt.f); got != tt.want {
+			t.Errorf("ScaleColor(%d, %v) = %d, want %d", tt.v, tt.f, got, tt.want)
+		}
+	}
+}
+
+func TestScaleColor16AndHighRes(t *testing.T) {
+	if got := ScaleColor16(0xFFFF, 0.5); got != 0x8000 {
+		t.Errorf("ScaleColor16(0xFFFF, 0.5) = %#x", got)
+	}
+	if got := ScaleColor16(0x8000, 3); got != 0xFFFF {
+		t.Errorf("ScaleColor16(0x8000, 3) = %#x", got)
+	}
+	if got := ClampToCoordHighRes(70000); got != 0xFFFF {
+		t.Errorf("ClampToCoordHighRes(70000) = %#x", got)
+	}
+	if got := ClampToCoordHighRes(math.NaN()); got != 0x8000 {
+		t.Errorf("ClampToCoordHighRes(NaN) = %#x", got)
+	}
+}
+
+func TestPointConversions(t *testing.T) {
+	for _, p := range []Point{{}, {X: 0xFFF, Y: 0x800, R: 0xFF, G: 0x80, B: 1, I: 0xFF}, {X: 1, Y: 0xFFE, I: 7}} {
+		ext := p.Ext()
+		if back := ext.Point(); back != p {
+			t.Errorf("%+v through PointExt = %+v", p, back)
+		}
+		hr := p.HighRes()
+		if back := hr.Point(); back.X != p.X || back.Y != p.Y || back.R != p.R || back.G != p.G || back.B != p.B {
+			t.Errorf("%+v through PointHighRes = %+v", p, back)
+		}
+	}
+	full := Point{X: 0xFFF, Y: 0xFFF, R: 0xFF, G: 0xFF, B: 0xFF, I: 0xFF}.Ext()
+	if full != (PointExt{X: 0xFFFF, Y: 0xFFFF, R: 0xFFFF, G: 0xFFFF, B: 0xFFFF, I: 0xFFFF}) {
+		t.Fatalf("full scale point widened to %+v", full)
+	}
+	if p := (PointHighRes{X: 0x1234, R: 0xABCD}).Point(); p.X != 0x123 || p.R != 0xAB || p.I != 0xFF {
+		t.Fatalf("narrowed point = %+v", p)
+	}
+	if p := (PointExt{X: 9, R: 5, I: 6, User1: 7}).HighRes(); p != (PointHighRes{X: 9, R: 5}) {
+		t.Fatalf("extended point as high resolution = %+v", p)
+	}
+}
@@ -0,0 +1,88 @@
+package helios
+
+import "fmt"
+
+// Requirement names a feature and the minimum firmware version it needs.
+// USB and network devices report firmware versions on different scales
+// (network devices encode major*10000 + minor*100 + patch), so each transport
+// has its own minimum. A zero minimum means the feature has no requirement on
+// that transport.
+type Requirement struct {
+	Feature            string
+	MinFirmwareUsb     int
+	MinFirmwareNetwork int
+}
+
+// FirmwareTooOld reports that a device's firmware is older than a feature requires.
+type FirmwareTooOld struct {
+	Feature  string
+	Have     int
+	Required int
+}
+
+func (e *FirmwareTooOld) Error() string {
+	return fmt.Sprintf("helios: %s requires firmware %d or newer, device has %d", e.Feature, e.Required, e.Have)
+}
+
+// DeviceInfo is a snapshot of the properties of an opened device.
+type DeviceInfo struct {
+	Index                     int
+	Name                      string
+	FirmwareVersion           int
+	IsUsb                     bool
+	SupportsHigherResolutions bool
+
+	// FirmwareTooOld lists the requirements passed to DeviceInfo or Devices
+	// that this device's firmware does not meet.
+	FirmwareTooOld []FirmwareTooOld
+}
+
+// Err returns the first unmet firmware requirement, or nil if all were met.
+func (i DeviceInfo) Err() error {
+	if len(i.FirmwareTooOld) == 0 {
+		return nil
+	}
+	return &i.FirmwareTooOld[0]
+}
+
+// DeviceInfo queries the properties of a device and checks them against reqs.
+func (d *DAC) DeviceInfo(deviceIndex int, reqs ...Requirement) DeviceInfo {
+	info := DeviceInfo{
+		Index:                     deviceIndex,
+		Name:                      d.GetName(deviceIndex),
+		FirmwareVersion:           d.GetFirmwareVersion(deviceIndex),
+		IsUsb:                     d.GetIsUsb(deviceIndex),
+		SupportsHigherResolutions: d.GetSupportsHigherResolutions(deviceIndex) == 1,
+	}
+	info.FirmwareTooOld = checkFirmware(info, reqs)
+	return info
+}
+
+// Devices returns the DeviceInfo of every device found by the last scan.
+// It is intended as a preflight step after OpenDevices, so unsupported
+// features are reported up front instead of failing at write time.
+func (d *DAC) Devices(reqs ...Requirement) []DeviceInfo {
+	infos := make([]DeviceInfo, d.NumDevices())
+	for i := range infos {
+		infos[i] = d.DeviceInfo(i, reqs...)
+	}
+	return infos
+}
+
+func checkFirmware(info DeviceInfo, reqs []Requirement) []FirmwareTooOld {
+	var failed []FirmwareTooOld
+	for _, r := range reqs {
+		required := r.MinFirmwareNetwork
+		if info.IsUsb {
+			required = r.MinFirmwareUsb
+		}
+		if required > 0 && info.FirmwareVersion < required {
+			failed = append(failed, FirmwareTooOld{
+				Feature:  r.Feature,
+				Have:     info.FirmwareVersion,
+				Required: required,
+			})
+		}
+	}
+	return failed
+}
@@ -0,0 +1,26 @@
+package helios
+
+import "testing"
+
+func TestCheckFirmware(t *testing.T) {
+	reqs := []Requirement{
+		{Feature: "shutter", MinFirmwareUsb: 5},
+		{Feature: "extended", MinFirmwareUsb: 7, MinFirmwareNetwork: 10200},
+	}
+
+	usb := DeviceInfo{IsUsb: true, FirmwareVersion: 6}
+	failed := checkFirmware(usb, reqs)
+	if len(failed) != 1 || failed[0].Feature != "extended" || failed[0].Required != 7 || failed[0].Have != 6 {
+		t.Fatalf("unexpected result for usb device: %+v", failed)
+	}
+
+	network := DeviceInfo{IsUsb: false, FirmwareVersion: 10300}
+	if failed := checkFirmware(network, reqs); len(failed) != 0 {
+		t.Fatalf("network device should meet all requirements, got %+v", failed)
+	}
+
+	usb.FirmwareTooOld = failed
+	if err := usb.Err(); err == nil {
+		t.Fatal("expected an error for unmet requirement")
+	}
+}
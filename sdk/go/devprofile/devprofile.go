@@ -0,0 +1,234 @@
+// Package devprofile saves the setup of each projector, so an installation
+// is calibrated once instead of on every run.
+//
+// A Profile bundles the output correction, color correction, galvo profile,
+// safety zones, channel wiring, slew limit and intensity of one device.
+// Profiles are keyed by device name: the Helios SDK exposes no serial
+// number, but each device's name is stored on the device and defaults to
+// one derived from its serial, so it follows the projector across USB ports
+// and IP addresses.
+//
+//	profiles, err := devprofile.Load(ctx, dir, "profiles.json")
+//	profiles.AutoApply(dac, nil)
+//	dac.OpenDevices()
+//	out := profiles.Output(dac, 0)
+//
+// AutoApply restores the settings the DAC applies itself, intensity, safety
+// zones, channel wiring and slew limit, as devices are opened; Output
+// applies the corrections to frames written through an output.Output.
+package devprofile
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Grix/helios_dac/sdk/go/calibrate"
+	"github.com/Grix/helios_dac/sdk/go/color"
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/motion"
+	"github.com/Grix/helios_dac/sdk/go/output"
+	"github.com/Grix/helios_dac/sdk/go/store"
+)
+
+// Profile is the setup of one projector. The zero Profile leaves a device
+// as it is.
+type Profile struct {
+	// Correction aligns the output with the venue.
+	Correction calibrate.Correction `json:"correction"`
+
+	// Color corrects colors for the projector's diodes. Nil leaves them
+	// unchanged.
+	Color *color.Profile `json:"color,omitempty"`
+
+	// Galvo describes the projector's scanners, for content built for it.
+	// Nil means motion.DefaultProfile.
+	Galvo *motion.GalvoProfile `json:"galvo,omitempty"`
+
+	// Attenuation dims the safety zones of the projection area.
+	Attenuation *helios.AttenuationMap `json:"attenuation,omitempty"`
+
+	// Channels remaps the color channels for single-color or miswired
+	// projectors. The zero ColorMap leaves them as they are.
+	Channels helios.ColorMap `json:"channels,omitzero"`
+
+	// SlewLimit is the largest step the scanners are commanded between
+	// consecutive points, in device coordinates; see
+	// helios.DAC.SetSlewLimit. Zero means no limit.
+	SlewLimit float64 `json:"slew_limit,omitempty"`
+
+	// Intensity is the device intensity (0.0 - 1.0). Zero means 1.
+	Intensity float64 `json:"intensity,omitempty"`
+}
+
+// Validate checks the attenuation map, color map and slew limit.
+func (p *Profile) Validate() error {
+	if p.Attenuation != nil {
+		if err := p.Attenuation.Validate(); err != nil {
+			return err
+		}
+	}
+	if !(p.SlewLimit >= 0) {
+		return fmt.Errorf("devprofile: slew limit %v is negative", p.SlewLimit)
+	}
+	return p.Channels.Validate()
+}
+
+// GalvoProfile returns the scanner profile to build content with.
+func (p *Profile) GalvoProfile() motion.GalvoProfile {
+	if p.Galvo != nil {
+		return *p.Galvo
+	}
+	return motion.DefaultProfile
+}
+
+// Apply sets the intensity, attenuation map, color map and slew limit of a
+// device of dac.
+func (p *Profile) Apply(dac *helios.DAC, deviceIndex int) error {
+	intensity := p.Intensity
+	if intensity == 0 {
+		intensity = 1
+	}
+	dac.SetDeviceIntensity(deviceIndex, intensity)
+	if err := dac.SetAttenuationMap(deviceIndex, p.Attenuation); err != nil {
+		return err
+	}
+	if err := dac.SetColorMap(deviceIndex, p.Channels); err != nil {
+		return err
+	}
+	return dac.SetSlewLimit(deviceIndex, p.SlewLimit)
+}
+
+// Wrap returns an output applying the output correction and then the
+// color correction to every frame written to out.
+func (p *Profile) Wrap(out output.Output) output.Output {
+	return &corrected{Output: out, p: p}
+}
+
+type corrected struct {
+	output.Output
+	p *Profile
+}
+
+func (o *corrected) WriteFrame(pps int, points []helios.Point) error {
+	points = o.p.Correction.Apply(points)
+	if o.p.Color != nil {
+		points = o.p.Color.Apply(points)
+	}
+	return o.Output.WriteFrame(pps, points)
+}
+
+func (o *corrected) Unwrap() output.Output { return o.Output }
+
+// Profiles holds profiles by device name. It must not be modified while
+// AutoApply is in effect.
+type Profiles map[string]*Profile
+
+// Lookup returns the profile of a device of dac, or nil if it has none.
+func (ps Profiles) Lookup(dac *helios.DAC, deviceIndex int) *Profile {
+	return ps[dac.GetName(deviceIndex)]
+}
+
+// Apply applies the profile of a device of dac, if it has one.
+func (ps Profiles) Apply(dac *helios.DAC, deviceIndex int) error {
+	if p := ps.Lookup(dac, deviceIndex); p != nil {
+		return p.Apply(dac, deviceIndex)
+	}
+	return nil
+}
+
+// AutoApply applies the profile of every device dac opens, on each
+// OpenDevices or ReScanDevices call from now on, replacing the DAC's open
+// hook. onError, if not nil, is called with the errors.
+func (ps Profiles) AutoApply(dac *helios.DAC, onError func(deviceIndex int, err error)) {
+	dac.SetOpenHook(func(i int) {
+		if err := ps.Apply(dac, i); err != nil && onError != nil {
+			onError(i, err)
+		}
+	})
+}
+
+// Output returns an output for a device of dac, with the device's profile
+// applied to its frames if it has one.
+func (ps Profiles) Output(dac *helios.DAC, deviceIndex int) output.Output {
+	out := output.NewDevice(dac, deviceIndex)
+	if p := ps.Lookup(dac, deviceIndex); p != nil {
+		return p.Wrap(out)
+	}
+	return out
+}
+
+// profilesSchema versions saved profiles. Version 1 wrote a color gain or
+// brightness of zero for full scale; version 2 leaves it out, as zero
+// switches the channel off.
+var profilesSchema = store.Schema{
+	Name:    "device profiles",
+	Version: 2,
+	Migrations: map[int]store.Migration{
+		1: func(doc any) (any, error) {
+			root, ok := doc.(map[string]any)
+			if !ok {
+				return nil, errors.New("expected an object")
+			}
+			profiles, _ := root["profiles"].(map[string]any)
+			for _, p := range profiles {
+				p, _ := p.(map[string]any)
+				c, _ := p["color"].(map[string]any)
+				dropZero(c, "brightness")
+				for _, name := range []string{"red", "green", "blue"} {
+					ch, _ := c[name].(map[string]any)
+					dropZero(ch, "gain")
+				}
+			}
+			return doc, nil
+		},
+	},
+}
+
+// dropZero deletes the member key of obj if it is the number zero.
+func dropZero(obj map[string]any, key string) {
+	if n, ok := obj[key].(json.Number); ok {
+		if v, err := n.Float64(); err == nil && v == 0 {
+			delete(obj, key)
+		}
+	}
+}
+
+// savedProfiles is the saved form of Profiles.
+type savedProfiles struct {
+	Profiles Profiles `json:"profiles"`
+}
+
+// Save writes the profiles to key in s as versioned JSON.
+func (ps Profiles) Save(ctx context.Context, s store.Store, key string) error {
+	data, err := profilesSchema.Encode(savedProfiles{Profiles: ps})
+	if err != nil {
+		return err
+	}
+	return s.Put(ctx, key, data)
+}
+
+// Load reads profiles written to key in s by Save, and validates them.
+func Load(ctx context.Context, s store.Store, key string) (Profiles, error) {
+	data, err := s.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	var saved savedProfiles
+	if err := profilesSchema.Decode(data, &saved); err != nil {
+		return nil, fmt.Errorf("devprofile: %s: %w", key, err)
+	}
+	for name, p := range saved.Profiles {
+		if p == nil {
+			return nil, fmt.Errorf("devprofile: %s: device %q has no profile", key, name)
+		}
+		if err := p.Validate(); err != nil {
+			return nil, fmt.Errorf("devprofile: %s: device %q: %w", key, name, err)
+		}
+	}
+	if saved.Profiles == nil {
+		saved.Profiles = Profiles{}
+	}
+	return saved.Profiles, nil
+}
@@ -0,0 +1,143 @@
+package devprofile
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/calibrate"
+	"github.com/Grix/helios_dac/sdk/go/color"
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/motion"
+	"github.com/Grix/helios_dac/sdk/go/store"
+)
+
+type recorder struct{ frame []helios.Point }
+
+func (r *recorder) Ready() (bool, error) { return true, nil }
+func (r *recorder) WriteFrame(_ int, points []helios.Point) error {
+	r.frame = points
+	return nil
+}
+func (r *recorder) Stop() error  { return nil }
+func (r *recorder) Close() error { return nil }
+
+func value(v float64) *float64 { return &v }
+
+func testProfiles() Profiles {
+	return Profiles{
+		"Helios 1234": {
+			Correction: calibrate.Correction{OffsetX: 0.5},
+			Color:      &color.Profile{Name: "stage", Red: color.Channel{Gain: value(0.5)}},
+			Galvo:      &motion.GalvoProfile{SmallStep: 200 * time.Microsecond, LargeStep: 800 * time.Microsecond},
+			Attenuation: &helios.AttenuationMap{Zones: []helios.AttenuationZone{
+				{Name: "audience", Level: 0, Polygon: []helios.Vertex{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 0, Y: 1}}},
+			}},
+			Channels:  helios.MonochromeColorMap(helios.ChannelB),
+			SlewLimit: 400,
+			Intensity: 0.8,
+		},
+		"Helios 5678": {},
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	ctx := context.Background()
+	s := store.NewMemory()
+	if err := testProfiles().Save(ctx, s, "profiles.json"); err != nil {
+		t.Fatal(err)
+	}
+	ps, err := Load(ctx, s, "profiles.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := ps["Helios 1234"]
+	if len(ps) != 2 || p == nil || p.Correction.OffsetX != 0.5 || p.Intensity != 0.8 {
+		t.Fatalf("loaded %+v", ps)
+	}
+	if p.Color == nil || *p.Color.Red.Gain != 0.5 || p.Attenuation == nil || p.Attenuation.Zones[0].Name != "audience" || p.Channels.B != helios.ChannelMax || p.SlewLimit != 400 {
+		t.Fatalf("loaded %+v", p)
+	}
+	if g := p.GalvoProfile(); g.SmallStep != 200*time.Microsecond || g.LargeStep != 800*time.Microsecond {
+		t.Fatalf("galvo = %+v", g)
+	}
+	if g := ps["Helios 5678"].GalvoProfile(); g.LargeStep != motion.DefaultProfile.LargeStep {
+		t.Fatalf("default galvo = %+v", g)
+	}
+}
+
+func TestLoadVersion1(t *testing.T) {
+	ctx := context.Background()
+	s := store.NewMemory()
+	// Version 1 saved unset gains and brightness as zero.
+	s.Put(ctx, "old.json", []byte(`{"version": 1, "profiles": {"x": {"color": {"name": "stage", "brightness": 0, "red": {"gain": 0.5}, "green": {"gain": 0, "gamma": 2}, "blue": {"gain": 0}}}}}`))
+	ps, err := Load(ctx, s, "old.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := ps["x"].Color
+	if c.Brightness != nil || *c.Red.Gain != 0.5 || c.Green.Gain != nil || c.Green.Gamma != 2 || c.Blue.Gain != nil {
+		t.Fatalf("loaded %+v", c)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	ctx := context.Background()
+	s := store.NewMemory()
+	s.Put(ctx, "bad", []byte(`{"version": 1, "profiles": {"x": {"attenuation": {"zones": [{"level": 2, "polygon": []}]}}}}`))
+	if _, err := Load(ctx, s, "bad"); err == nil || !strings.Contains(err.Error(), `device "x"`) {
+		t.Fatalf("err = %v", err)
+	}
+	s.Put(ctx, "unknown", []byte(`{"version": 1, "profiles": {"x": {"gain": 1}}}`))
+	if _, err := Load(ctx, s, "unknown"); err == nil {
+		t.Fatal("unknown member accepted")
+	}
+	s.Put(ctx, "slew", []byte(`{"version": 1, "profiles": {"x": {"slew_limit": -1}}}`))
+	if _, err := Load(ctx, s, "slew"); err == nil {
+		t.Fatal("negative slew limit accepted")
+	}
+}
+
+func TestWrap(t *testing.T) {
+	p := testProfiles()["Helios 1234"]
+	rec := &recorder{}
+	in := []helios.Point{{X: 2047, Y: 2047, R: 200, I: 255}}
+	if err := p.Wrap(rec).WriteFrame(30000, in); err != nil {
+		t.Fatal(err)
+	}
+	got := rec.frame[0]
+	if got.X <= in[0].X || got.Y != in[0].Y || got.R != 100 {
+		t.Fatalf("got %+v", got)
+	}
+	if in[0].X != 2047 {
+		t.Fatal("Wrap modified the caller's frame")
+	}
+}
+
+func TestApply(t *testing.T) {
+	dac := helios.NewDAC()
+	defer dac.Close()
+	p := testProfiles()["Helios 1234"]
+	if err := p.Apply(dac, 0); err != nil {
+		t.Fatal(err)
+	}
+	if got := dac.DeviceIntensity(0); got != 0.8 {
+		t.Fatalf("intensity = %v", got)
+	}
+	if m := dac.AttenuationMap(0); m == nil || len(m.Zones) != 1 {
+		t.Fatalf("attenuation = %+v", m)
+	}
+	if m := dac.ColorMap(0); m != helios.MonochromeColorMap(helios.ChannelB) {
+		t.Fatalf("color map = %+v", m)
+	}
+	if got := dac.SlewLimit(0); got != 400 {
+		t.Fatalf("slew limit = %v", got)
+	}
+	if err := (&Profile{}).Apply(dac, 0); err != nil {
+		t.Fatal(err)
+	}
+	if dac.DeviceIntensity(0) != 1 || dac.AttenuationMap(0) != nil || dac.ColorMap(0) != (helios.ColorMap{}) || dac.SlewLimit(0) != 0 {
+		t.Fatal("zero profile did not reset the device")
+	}
+}
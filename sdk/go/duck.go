@@ -0,0 +1,123 @@
+package helios
+
+import "time"
+
+// Ducking momentarily lowers the intensity of every device when an external
+// trigger asks the lasers to yield, for example to pyrotechnics or a camera
+// flash. It attacks fast and releases over a configurable time, on top of
+// the master and device intensities.
+
+// DuckSettings shapes the ducking envelope.
+type DuckSettings struct {
+	// Level is the intensity while ducked (0.0 - 1.0).
+	Level float64
+
+	// Attack is how long the intensity takes to fall to Level. Zero ducks
+	// from the next frame written.
+	Attack time.Duration
+
+	// Release is how long the intensity takes to recover once the duck
+	// ends.
+	Release time.Duration
+}
+
+// DefaultDuckSettings blank immediately and recover over half a second.
+var DefaultDuckSettings = DuckSettings{Release: 500 * time.Millisecond}
+
+// duck is the ducking state, guarded by levels.mu.
+type duck struct {
+	settings DuckSettings
+	active   bool
+	onset    time.Time
+	end      time.Time // zero while held
+}
+
+// ramp returns the progress, 0 to 1, of a ramp of length d after elapsed.
+func ramp(elapsed, d time.Duration) float64 {
+	if d <= 0 || elapsed >= d {
+		return 1
+	}
+	return max(0, float64(elapsed)/float64(d))
+}
+
+// factor returns the intensity multiplier at now.
+func (k *duck) factor(now time.Time) float64 {
+	if !k.active {
+		return 1
+	}
+	depth := func(t time.Time) float64 {
+		return (1 - k.settings.Level) * ramp(t.Sub(k.onset), k.settings.Attack)
+	}
+	if k.end.IsZero() || now.Before(k.end) {
+		return 1 - depth(now)
+	}
+	p := ramp(now.Sub(k.end), k.settings.Release)
+	if p >= 1 {
+		k.active = false
+	}
+	return 1 - depth(k.end)*(1-p)
+}
+
+// start begins a duck at now, ending at end (zero to hold).
+func (k *duck) start(now, end time.Time) {
+	if !k.active || !k.end.IsZero() && now.After(k.end) {
+		// Restart the attack, from where a release in progress has got to.
+		f := k.factor(now)
+		k.onset = now
+		if full := 1 - k.settings.Level; full > 0 && f < 1 {
+			k.onset = now.Add(-time.Duration((1 - f) / full * float64(k.settings.Attack)))
+		}
+	}
+	k.active, k.end = true, end
+}
+
+// SetDuckSettings sets the ducking envelope. It applies to ducks in
+// progress from the next frame written.
+func (d *DAC) SetDuckSettings(s DuckSettings) {
+	d.levels.mu.Lock()
+	defer d.levels.mu.Unlock()
+	s.Level = clampLevel(s.Level)
+	d.levels.duck.settings = s
+}
+
+// DuckSettings returns the ducking envelope.
+func (d *DAC) DuckSettings() DuckSettings {
+	d.levels.mu.Lock()
+	defer d.levels.mu.Unlock()
+	return d.levels.duck.settings
+}
+
+// SetDucked ducks every device while on is true, as for a trigger that is
+// held, such as a DMX channel. Turning it off starts the release.
+func (d *DAC) SetDucked(on bool) {
+	d.levels.mu.Lock()
+	defer d.levels.mu.Unlock()
+	now := d.levels.now()
+	switch {
+	case on:
+		d.levels.duck.start(now, time.Time{})
+	case d.levels.duck.active && d.levels.duck.end.IsZero():
+		d.levels.duck.end = now
+	}
+}
+
+// Duck ducks every device for hold, then releases, as for a momentary
+// trigger such as a camera flash cue. A longer duck already in progress is
+// not shortened.
+func (d *DAC) Duck(hold time.Duration) {
+	d.levels.mu.Lock()
+	defer d.levels.mu.Unlock()
+	now := d.levels.now()
+	end := now.Add(hold)
+	if k := &d.levels.duck; k.active && (k.end.IsZero() || k.end.After(end)) {
+		return
+	}
+	d.levels.duck.start(now, end)
+}
+
+// Ducking returns the current ducking multiplier, 1 when not ducked.
+func (d *DAC) Ducking() float64 {
+	d.levels.mu.Lock()
+	defer d.levels.mu.Unlock()
+	return d.levels.duck.factor(d.levels.now())
+}
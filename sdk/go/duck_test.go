@@ -0,0 +1,70 @@
+package helios
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDuckEnvelope(t *testing.T) {
+	d := &DAC{levels: newLevels()}
+	now := time.Unix(0, 0)
+	d.levels.now = func() time.Time { return now }
+	d.SetDuckSettings(DuckSettings{Level: 0.2, Attack: 10 * time.Millisecond, Release: 100 * time.Millisecond})
+
+	if d.Ducking() != 1 {
+		t.Fatal("ducked before any trigger")
+	}
+	d.SetDucked(true)
+	now = now.Add(5 * time.Millisecond)
+	if got := d.Ducking(); !near(got, 0.6) {
+		t.Fatalf("half way through the attack: %v, want 0.6", got)
+	}
+	now = now.Add(time.Second)
+	if got := d.levels.scale(0); !near(got, 0.2) {
+		t.Fatalf("held: scale %v, want 0.2", got)
+	}
+
+	d.SetDucked(false)
+	now = now.Add(50 * time.Millisecond)
+	if got := d.Ducking(); !near(got, 0.6) {
+		t.Fatalf("half way through the release: %v, want 0.6", got)
+	}
+
+	// Ducking again during the release attacks from where it got to.
+	d.SetDucked(true)
+	now = now.Add(2500 * time.Microsecond)
+	if got := d.Ducking(); !near(got, 0.4) {
+		t.Fatalf("re-attack: %v, want 0.4", got)
+	}
+	d.SetDucked(false)
+	now = now.Add(time.Second)
+	if d.Ducking() != 1 || d.levels.duck.active {
+		t.Fatal("not recovered after the release")
+	}
+}
+
+func TestDuckMomentary(t *testing.T) {
+	d := &DAC{levels: newLevels()}
+	now := time.Unix(0, 0)
+	d.levels.now = func() time.Time { return now }
+	d.SetMasterIntensity(0.5)
+
+	d.Duck(time.Second)
+	if got := d.levels.scale(0); got != 0 {
+		t.Fatalf("default duck scale %v, want 0", got)
+	}
+	d.Duck(10 * time.Millisecond) // does not shorten the duck
+	now = now.Add(500 * time.Millisecond)
+	if d.Ducking() != 0 {
+		t.Fatal("shorter trigger cut the duck short")
+	}
+	now = now.Add(750 * time.Millisecond) // half the default release
+	if got := d.levels.scale(0); !near(got, 0.25) {
+		t.Fatalf("releasing: scale %v, want 0.25", got)
+	}
+}
+
+func near(a, b float64) bool {
+	d := a - b
+	return d < 1e-9 && d > -1e-9
+}
@@ -0,0 +1,140 @@
+// Package ease provides easing curves shared by the motion model,
+// animations and transitions.
+//
+// An easing curve maps progress t in the range 0.0 - 1.0 to an eased
+// progress value. All curves in this package return 0 at t=0 and 1 at t=1;
+// some (Back, Elastic) overshoot in between.
+package ease
+
+import "math"
+
+// Func is an easing curve.
+type Func func(t float64) float64
+
+// Apply evaluates f at t, clamping t to 0.0 - 1.0. A nil Func is linear.
+func (f Func) Apply(t float64) float64 {
+	t = clamp01(t)
+	if f == nil {
+		return t
+	}
+	return f(t)
+}
+
+// Linear does not ease.
+func Linear(t float64) float64 { return t }
+
+// SmoothStep is the cubic Hermite S-curve 3t² - 2t³. It has zero velocity
+// at both ends and is the default for galvo travel.
+func SmoothStep(t float64) float64 { return t * t * (3 - 2*t) }
+
+// SmootherStep is Perlin's quintic S-curve, with zero velocity and
+// acceleration at both ends.
+func SmootherStep(t float64) float64 { return t * t * t * (t*(6*t-15) + 10) }
+
+// InQuad accelerates from zero velocity.
+func InQuad(t float64) float64 { return t * t }
+
+// OutQuad decelerates to zero velocity.
+func OutQuad(t float64) float64 { return t * (2 - t) }
+
+// InOutQuad accelerates until halfway, then decelerates.
+func InOutQuad(t float64) float64 { return InOut(InQuad)(t) }
+
+// InCubic accelerates from zero velocity.
+func InCubic(t float64) float64 { return t * t * t }
+
+// OutCubic decelerates to zero velocity.
+func OutCubic(t float64) float64 { return Out(InCubic)(t) }
+
+// InOutCubic accelerates until halfway, then decelerates.
+func InOutCubic(t float64) float64 { return InOut(InCubic)(t) }
+
+// InExpo accelerates exponentially.
+func InExpo(t float64) float64 {
+	if t == 0 {
+		return 0
+	}
+	return math.Pow(2, 10*(t-1))
+}
+
+// OutExpo decelerates exponentially.
+func OutExpo(t float64) float64 { return Out(InExpo)(t) }
+
+// InOutExpo accelerates then decelerates exponentially.
+func InOutExpo(t float64) float64 { return InOut(InExpo)(t) }
+
+// OutBack overshoots the target slightly before settling on it.
+func OutBack(t float64) float64 {
+	const c1 = 1.70158
+	const c3 = c1 + 1
+	u := t - 1
+	return 1 + c3*u*u*u + c1*u*u
+}
+
+// OutElastic overshoots and oscillates around the target like a spring.
+func OutElastic(t float64) float64 {
+	if t == 0 || t == 1 {
+		return t
+	}
+	return math.Pow(2, -10*t)*math.Sin((t*10-0.75)*(2*math.Pi/3)) + 1
+}
+
+// InElastic is OutElastic played in reverse.
+func InElastic(t float64) float64 { return Out(OutElastic)(t) }
+
+// Out returns the mirror image of an ease-in curve, turning it into an
+// ease-out curve and vice versa.
+func Out(f Func) Func {
+	return func(t float64) float64 { return 1 - f(1-t) }
+}
+
+// InOut combines an ease-in curve with its mirror image: f for the first
+// half and Out(f) for the second.
+func InOut(f Func) Func {
+	return func(t float64) float64 {
+		if t < 0.5 {
+			return f(2*t) / 2
+		}
+		return 1 - f(2-2*t)/2
+	}
+}
+
+// CubicBezier returns a curve defined by a cubic Bézier with end points
+// (0,0) and (1,1) and control points (x1,y1) and (x2,y2), the same
+// parameterization as CSS cubic-bezier(). x1 and x2 are clamped to 0.0 - 1.0
+// so the curve stays a function of t.
+func CubicBezier(x1, y1, x2, y2 float64) Func {
+	x1, x2 = clamp01(x1), clamp01(x2)
+	bez := func(s, p1, p2 float64) float64 {
+		u := 1 - s
+		return 3*u*u*s*p1 + 3*u*s*s*p2 + s*s*s
+	}
+	return func(t float64) float64 {
+		// Solve x(s) = t for s by bisection; x is monotonic on [0,1].
+		lo, hi := 0.0, 1.0
+		for i := 0; i < 40; i++ {
+			mid := (lo + hi) / 2
+			if bez(mid, x1, x2) < t {
+				lo = mid
+			} else {
+				hi = mid
+			}
+		}
+		return bez((lo+hi)/2, y1, y2)
+	}
+}
+
+// Lerp interpolates between a and b at eased progress f(t).
+func Lerp(f Func, a, b, t float64) float64 {
+	return a + (b-a)*f.Apply(t)
+}
+
+func clamp01(t float64) float64 {
+	if t < 0 {
+		return 0
+	}
+	if t > 1 {
+		return 1
+	}
+	return t
+}
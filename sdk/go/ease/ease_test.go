@@ -0,0 +1,65 @@
+package ease
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEndpoints(t *testing.T) {
+	curves := map[string]Func{
+		"Linear":       Linear,
+		"SmoothStep":   SmoothStep,
+		"SmootherStep": SmootherStep,
+		"InQuad":       InQuad,
+		"OutQuad":      OutQuad,
+		"InOutQuad":    InOutQuad,
+		"InCubic":      InCubic,
+		"OutCubic":     OutCubic,
+		"InOutCubic":   InOutCubic,
+		"InExpo":       InExpo,
+		"OutExpo":      OutExpo,
+		"InOutExpo":    InOutExpo,
+		"OutBack":      OutBack,
+		"InElastic":    InElastic,
+		"OutElastic":   OutElastic,
+		"CubicBezier":  CubicBezier(0.25, 0.1, 0.25, 1),
+	}
+	for name, f := range curves {
+		if v := f(0); math.Abs(v) > 1e-3 {
+			t.Errorf("%s(0) = %v, want 0", name, v)
+		}
+		if v := f(1); math.Abs(v-1) > 1e-3 {
+			t.Errorf("%s(1) = %v, want 1", name, v)
+		}
+	}
+}
+
+func TestSymmetry(t *testing.T) {
+	for _, x := range []float64{0.1, 0.3, 0.5} {
+		if a, b := InOutCubic(x), 1-InOutCubic(1-x); math.Abs(a-b) > 1e-12 {
+			t.Errorf("InOutCubic not symmetric at %v: %v vs %v", x, a, b)
+		}
+	}
+	if v := SmoothStep(0.5); v != 0.5 {
+		t.Errorf("SmoothStep(0.5) = %v, want 0.5", v)
+	}
+}
+
+func TestCubicBezierLinear(t *testing.T) {
+	f := CubicBezier(1.0/3, 1.0/3, 2.0/3, 2.0/3)
+	for _, x := range []float64{0.2, 0.5, 0.9} {
+		if v := f(x); math.Abs(v-x) > 1e-6 {
+			t.Errorf("linear bezier(%v) = %v", x, v)
+		}
+	}
+}
+
+func TestApplyAndLerp(t *testing.T) {
+	var f Func
+	if v := f.Apply(2); v != 1 {
+		t.Errorf("nil Func Apply(2) = %v, want 1", v)
+	}
+	if v := Lerp(InQuad, 10, 20, 0.5); v != 12.5 {
+		t.Errorf("Lerp = %v, want 12.5", v)
+	}
+}
@@ -0,0 +1,217 @@
+// Package effects provides post-processing effects for laser frames:
+// strobes, color cycles, chases, wave distortion, zoom and spin
+// animations and kaleidoscopes.
+//
+// Every effect is a function of the frame and the show time, so it animates
+// the same way however often frames are rendered. Effects chain with Chain
+// and wrap a scene.Layer with Layer. Their fields carry param tags, so
+// param.Of exposes them to control surfaces.
+package effects
+
+import (
+	"math"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/color"
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/scene"
+)
+
+// Effect transforms a frame at show time t. Implementations return a new
+// slice and leave frame unchanged.
+type Effect interface {
+	Apply(t time.Duration, frame []helios.Point) []helios.Point
+}
+
+// Func adapts an ordinary function to the Effect interface.
+type Func func(t time.Duration, frame []helios.Point) []helios.Point
+
+// Apply calls f(t, frame).
+func (f Func) Apply(t time.Duration, frame []helios.Point) []helios.Point {
+	return f(t, frame)
+}
+
+// Chain applies effects in order.
+type Chain []Effect
+
+// Apply applies every effect of the chain to the output of the previous one.
+func (c Chain) Apply(t time.Duration, frame []helios.Point) []helios.Point {
+	for _, e := range c {
+		frame = e.Apply(t, frame)
+	}
+	return frame
+}
+
+// Layer returns a layer drawing l with e applied. If e is a Budgeter, l is
+// drawn with the budget it returns.
+func Layer(l scene.Layer, e Effect) scene.Layer {
+	return scene.LayerFunc(func(t time.Duration, budget int) []helios.Point {
+		return e.Apply(t, l.Points(t, InputBudget(e, budget)))
+	})
+}
+
+// center is the middle of the device coordinate range.
+const center = 2048
+
+// phase returns the fractional part of t * rate, the position within the
+// current cycle of something repeating rate times per second.
+func phase(t time.Duration, rate float64) float64 {
+	_, f := math.Modf(t.Seconds() * rate)
+	if f < 0 {
+		f++
+	}
+	return f
+}
+
+// blank returns p with its beam off.
+func blank(p helios.Point) helios.Point {
+	return helios.Point{X: p.X, Y: p.Y}
+}
+
+// Strobe gates the beam on and off.
+type Strobe struct {
+	// Rate is the number of flashes per second. Zero leaves the beam on.
+	Rate float64 `param:"rate,min=0,max=30,default=10"`
+
+	// Duty is the fraction of each flash the beam is on.
+	Duty float64 `param:"duty,min=0,max=1,default=0.5"`
+}
+
+// Apply blanks the frame while the strobe is off.
+func (s *Strobe) Apply(t time.Duration, frame []helios.Point) []helios.Point {
+	out := append([]helios.Point(nil), frame...)
+	if s.Rate <= 0 || phase(t, s.Rate) < s.Duty {
+		return out
+	}
+	for i, p := range out {
+		out[i] = blank(p)
+	}
+	return out
+}
+
+// HueCycle rotates the hue of lit points, keeping their saturation and
+// brightness.
+type HueCycle struct {
+	// Speed is in turns of the color wheel per second.
+	Speed float64 `param:"speed,min=-2,max=2,default=0.25"`
+
+	// Spread is the number of turns of the color wheel along the frame, so
+	// a nonzero spread draws a rainbow along the path.
+	Spread float64 `param:"spread,min=0,max=4"`
+}
+
+// Apply rotates the hue of every lit point.
+func (h *HueCycle) Apply(t time.Duration, frame []helios.Point) []helios.Point {
+	out := make([]helios.Point, len(frame))
+	base := phase(t, h.Speed)
+	for i, p := range frame {
+		out[i] = p
+		if p.R == 0 && p.G == 0 && p.B == 0 {
+			continue
+		}
+		shift := base + h.Spread*float64(i)/float64(len(frame))
+		out[i].R, out[i].G, out[i].B = rotateHue(p.R, p.G, p.B, shift)
+	}
+	return out
+}
+
+// rotateHue rotates a color by turns of the color wheel.
+func rotateHue(r, g, b uint8, turns float64) (uint8, uint8, uint8) {
+	if r == g && g == b {
+		return r, g, b
+	}
+	h, s, v := color.ToHSV8(r, g, b)
+	return color.HSV8(h+turns, s, v)
+}
+
+// Chase lights moving sections of the path and blanks the rest.
+type Chase struct {
+	// Speed is in passes along the path per second.
+	Speed float64 `param:"speed,min=-4,max=4,default=0.5"`
+
+	// Count is the number of lit sections.
+	Count int `param:"count,min=1,max=16,default=1"`
+
+	// Width is the fraction of each section's share of the path that is lit.
+	Width float64 `param:"width,min=0,max=1,default=0.25"`
+}
+
+// Apply blanks the points outside the lit sections.
+func (c *Chase) Apply(t time.Duration, frame []helios.Point) []helios.Point {
+	out := make([]helios.Point, len(frame))
+	head := phase(t, c.Speed)
+	count := float64(max(c.Count, 1))
+	for i, p := range frame {
+		pos := float64(i) / float64(len(frame))
+		if _, f := math.Modf((pos - head + 1) * count); f < c.Width {
+			out[i] = p
+		} else {
+			out[i] = blank(p)
+		}
+	}
+	return out
+}
+
+// Wave displaces points vertically by a sine wave traveling horizontally.
+type Wave struct {
+	// Amplitude is the largest displacement, in device units.
+	Amplitude float64 `param:"amplitude,min=0,max=1024,default=200"`
+
+	// Cycles is the number of wave periods across the full width.
+	Cycles float64 `param:"cycles,min=0,max=16,default=2"`
+
+	// Speed is in periods per second.
+	Speed float64 `param:"speed,min=-8,max=8,default=1"`
+}
+
+// Apply displaces every point, clamping to the device range.
+func (w *Wave) Apply(t time.Duration, frame []helios.Point) []helios.Point {
+	out := make([]helios.Point, len(frame))
+	shift := t.Seconds() * w.Speed
+	for i, p := range frame {
+		out[i] = p
+		d := w.Amplitude * math.Sin(2*math.Pi*(float64(p.X)/4096*w.Cycles-shift))
+		out[i].Y = helios.ClampToCoord(float64(p.Y) + d)
+	}
+	return out
+}
+
+// Zoom pulses the size of the frame around the center.
+type Zoom struct {
+	// Min and Max are the scale factors at the ends of a pulse.
+	Min float64 `param:"min,min=0,max=2,default=0.5"`
+	Max float64 `param:"max,min=0,max=2,default=1"`
+
+	// Rate is the number of pulses per second. Zero holds the frame at Max.
+	Rate float64 `param:"rate,min=0,max=10,default=0.5"`
+}
+
+// Apply scales the frame, easing in and out of each pulse.
+func (z *Zoom) Apply(t time.Duration, frame []helios.Point) []helios.Point {
+	s := z.Max
+	if z.Rate > 0 {
+		s = z.Min + (z.Max-z.Min)*(0.5+0.5*math.Cos(2*math.Pi*phase(t, z.Rate)))
+	}
+	return transform(frame, scene.Scale(s, s, center, center))
+}
+
+// Spin rotates the frame around the center.
+type Spin struct {
+	// Speed is in turns per second; positive is counterclockwise.
+	Speed float64 `param:"speed,min=-4,max=4,default=0.25"`
+}
+
+// Apply rotates the frame.
+func (s *Spin) Apply(t time.Duration, frame []helios.Point) []helios.Point {
+	return transform(frame, scene.Rotate(2*math.Pi*phase(t, s.Speed), center, center))
+}
+
+func transform(frame []helios.Point, tr scene.Transform) []helios.Point {
+	out := make([]helios.Point, len(frame))
+	for i, p := range frame {
+		x, y := tr.Apply(float64(p.X), float64(p.Y))
+		out[i] = p
+		out[i].X, out[i].Y = helios.ClampToCoord(x), helios.ClampToCoord(y)
+	}
+	return out
+}
@@ -0,0 +1,104 @@
+package effects
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/param"
+	"github.com/Grix/helios_dac/sdk/go/scene"
+)
+
+var red = helios.Point{X: 4095, Y: 2048, R: 255, I: 255}
+
+func TestStrobe(t *testing.T) {
+	s := &Strobe{Rate: 10, Duty: 0.5}
+	frame := []helios.Point{red}
+	if got := s.Apply(20*time.Millisecond, frame); got[0] != red {
+		t.Fatalf("on phase = %+v", got[0])
+	}
+	if got := s.Apply(70*time.Millisecond, frame); got[0] != (helios.Point{X: 4095, Y: 2048}) {
+		t.Fatalf("off phase = %+v", got[0])
+	}
+	if frame[0] != red {
+		t.Fatal("Apply modified the frame")
+	}
+}
+
+func TestRotateHue(t *testing.T) {
+	tests := []struct {
+		r, g, b    uint8
+		turns      float64
+		wr, wg, wb uint8
+	}{
+		{255, 0, 0, 1.0 / 3, 0, 255, 0},
+		{255, 0, 0, 2.0 / 3, 0, 0, 255},
+		{255, 0, 0, -1.0 / 3, 0, 0, 255},
+		{255, 0, 0, 1, 255, 0, 0},
+		{255, 0, 0, 1.0 / 6, 255, 255, 0},
+		{200, 100, 100, 0.5, 100, 200, 200},
+		{80, 80, 80, 0.3, 80, 80, 80},
+	}
+	for _, tt := range tests {
+		r, g, b := rotateHue(tt.r, tt.g, tt.b, tt.turns)
+		if r != tt.wr || g != tt.wg || b != tt.wb {
+			t.Errorf("rotateHue(%d, %d, %d, %v) = %d, %d, %d, want %d, %d, %d", tt.r, tt.g, tt.b, tt.turns, r, g, b, tt.wr, tt.wg, tt.wb)
+		}
+	}
+}
+
+func TestChase(t *testing.T) {
+	frame := make([]helios.Point, 8)
+	for i := range frame {
+		frame[i] = red
+	}
+	c := &Chase{Speed: 1, Count: 1, Width: 0.25}
+	lit := func(got []helios.Point) (n []int) {
+		for i, p := range got {
+			if p.I != 0 {
+				n = append(n, i)
+			}
+		}
+		return n
+	}
+	if n := lit(c.Apply(0, frame)); len(n) != 2 || n[0] != 0 {
+		t.Fatalf("t=0: lit %v", n)
+	}
+	if n := lit(c.Apply(500*time.Millisecond, frame)); len(n) != 2 || n[0] != 4 {
+		t.Fatalf("t=0.5s: lit %v", n)
+	}
+}
+
+func TestWaveZoomSpin(t *testing.T) {
+	frame := []helios.Point{red}
+	w := &Wave{Amplitude: 100, Cycles: 1, Speed: 0.25}
+	// At one second the wave has moved a quarter period: sin(2π(1 - 0.25)) = -1.
+	if got := w.Apply(time.Second, frame); got[0].Y != 1948 || got[0].X != 4095 {
+		t.Fatalf("wave = %+v", got[0])
+	}
+	z := &Zoom{Min: 0.5, Max: 1, Rate: 1}
+	if got := z.Apply(500*time.Millisecond, frame); got[0].X != 3072 {
+		t.Fatalf("zoom = %+v", got[0])
+	}
+	s := &Spin{Speed: 0.25}
+	if got := s.Apply(time.Second, frame); got[0].X != 2048 || got[0].Y != 4095 {
+		t.Fatalf("spin = %+v", got[0])
+	}
+}
+
+func TestChainLayer(t *testing.T) {
+	src := scene.LayerFunc(func(time.Duration, int) []helios.Point { return []helios.Point{red} })
+	l := Layer(src, Chain{&HueCycle{Speed: 1.0 / 3}, &Spin{Speed: 0.5}})
+	got := l.Points(time.Second, 10)
+	if got[0].R != 0 || got[0].G != 255 || got[0].X != 1 {
+		t.Fatalf("chained = %+v", got[0])
+	}
+}
+
+func TestEffectsHaveParams(t *testing.T) {
+	for _, e := range []Effect{&Strobe{}, &HueCycle{}, &Chase{}, &Wave{}, &Zoom{}, &Spin{}} {
+		if s, err := param.Of(e); err != nil || len(s.Params()) == 0 {
+			t.Errorf("param.Of(%T): %v", e, err)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package effects
+
+import (
+	"math"
+	"slices"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/motion"
+	"github.com/Grix/helios_dac/sdk/go/scene"
+)
+
+// DefaultKaleidoscopePPS is the scan rate a Kaleidoscope whose PPS is zero
+// plans its travel for.
+const DefaultKaleidoscopePPS = 30000
+
+// Budgeter is implemented by effects that draw more points than they are
+// given. Budget returns the budget of the input that keeps the output
+// within budget. Layer and the pipeline package use it to size the input.
+type Budgeter interface {
+	Budget(budget int) int
+}
+
+// InputBudget returns the budget of the input of e for an output budget.
+func InputBudget(e Effect, budget int) int {
+	if b, ok := e.(Budgeter); ok {
+		return b.Budget(budget)
+	}
+	return budget
+}
+
+// Kaleidoscope replicates the frame around the center with rotational
+// symmetry, optionally mirroring every other copy. The copies are drawn one
+// after the other with blanked travel between them, each in the direction
+// that starts nearest to where the previous one ended.
+type Kaleidoscope struct {
+	// Copies is the number of copies, evenly spaced around the center.
+	Copies int `param:"copies,min=1,max=16,default=6"`
+
+	// Mirror reflects every other copy, so neighbors are mirror images.
+	Mirror bool `param:"mirror"`
+
+	// Speed is in turns of the whole pattern per second; positive is
+	// counterclockwise.
+	Speed float64 `param:"speed,min=-4,max=4"`
+
+	// PPS is the scan rate the travel between copies is planned for. Zero
+	// means DefaultKaleidoscopePPS.
+	PPS int `param:"pps,min=0,max=100000"`
+}
+
+func (k *Kaleidoscope) copies() int { return max(k.Copies, 1) }
+
+func (k *Kaleidoscope) pps() int {
+	if k.PPS <= 0 {
+		return DefaultKaleidoscopePPS
+	}
+	return k.PPS
+}
+
+// Budget divides the budget evenly between the copies, after reserving
+// the travel of a half-scale jump between each pair of them.
+func (k *Kaleidoscope) Budget(budget int) int {
+	n := k.copies()
+	travel := (n - 1) * motion.DefaultProfile.TravelPoints(center, k.pps())
+	return max(0, budget-travel) / n
+}
+
+// Apply draws the copies.
+func (k *Kaleidoscope) Apply(t time.Duration, frame []helios.Point) []helios.Point {
+	if len(frame) == 0 {
+		return nil
+	}
+	n := k.copies()
+	spin := 2 * math.Pi * phase(t, k.Speed)
+	mirror := scene.Scale(1, -1, center, center)
+	var out []helios.Point
+	for i := range n {
+		tr := scene.Rotate(spin+2*math.Pi*float64(i)/float64(n), center, center)
+		if k.Mirror && i%2 == 1 {
+			tr = mirror.Then(tr)
+		}
+		c := transform(frame, tr)
+		if len(out) > 0 {
+			last := out[len(out)-1]
+			if dist(last, c[len(c)-1]) < dist(last, c[0]) {
+				slices.Reverse(c)
+			}
+			if last.X != c[0].X || last.Y != c[0].Y {
+				out = append(out, motion.DefaultProfile.Travel(last, c[0], k.pps())...)
+			}
+		}
+		out = append(out, c...)
+	}
+	return out
+}
+
+func dist(a, b helios.Point) float64 {
+	return math.Hypot(float64(a.X)-float64(b.X), float64(a.Y)-float64(b.Y))
+}
@@ -0,0 +1,86 @@
+package effects
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/scene"
+)
+
+func TestKaleidoscope(t *testing.T) {
+	// A lit chord from the top of the range to the right.
+	frame := []helios.Point{{X: 2048, Y: 4095, G: 255, I: 255}, red}
+	k := &Kaleidoscope{Copies: 4}
+	got := k.Apply(0, frame)
+	var lit []helios.Point
+	for _, p := range got {
+		if p.I != 0 {
+			lit = append(lit, p)
+		}
+	}
+	// Each copy after the first is drawn from the end nearest to where the
+	// previous one ended, closing a diamond.
+	want := []helios.Point{
+		{X: 2048, Y: 4095}, {X: 4095, Y: 2048},
+		{X: 2048, Y: 4095}, {X: 1, Y: 2048},
+		{X: 1, Y: 2048}, {X: 2048, Y: 1},
+		{X: 2048, Y: 1}, {X: 4095, Y: 2048},
+	}
+	if len(lit) != len(want) {
+		t.Fatalf("lit %d points, want %d", len(lit), len(want))
+	}
+	for i, w := range want {
+		if lit[i].X != w.X || lit[i].Y != w.Y {
+			t.Errorf("lit point %d = (%d, %d), want (%d, %d)", i, lit[i].X, lit[i].Y, w.X, w.Y)
+		}
+	}
+	if len(got) <= len(lit) {
+		t.Error("no travel between copies")
+	}
+	if frame[1] != red {
+		t.Fatal("Apply modified the frame")
+	}
+	if got := k.Apply(0, nil); len(got) != 0 {
+		t.Errorf("empty frame gave %d points", len(got))
+	}
+}
+
+func TestKaleidoscopeMirror(t *testing.T) {
+	// A stroke above the horizontal axis: mirrored copies fall below it.
+	frame := []helios.Point{{X: 3000, Y: 2548, R: 255, I: 255}}
+	k := &Kaleidoscope{Copies: 2, Mirror: true}
+	got := k.Apply(0, frame)
+	if first, last := got[0], got[len(got)-1]; first.X != 3000 || first.Y != 2548 || last.X != 1096 || last.Y != 2548 {
+		t.Errorf("copies at %+v and %+v", first, last)
+	}
+	k.Mirror = false
+	if last := k.Apply(0, frame); last[len(last)-1].Y != 1548 {
+		t.Errorf("unmirrored copy at %+v", last[len(last)-1])
+	}
+}
+
+func TestKaleidoscopeBudget(t *testing.T) {
+	k := &Kaleidoscope{Copies: 3}
+	in := k.Budget(1000)
+	if in <= 0 || in > 1000/3 {
+		t.Fatalf("Budget(1000) = %d", in)
+	}
+	if got := (&Kaleidoscope{}).Budget(1000); got != 1000 {
+		t.Errorf("single copy Budget(1000) = %d", got)
+	}
+	if got := InputBudget(&Spin{}, 1000); got != 1000 {
+		t.Errorf("InputBudget(Spin) = %d", got)
+	}
+
+	// Layer draws its layer with the divided budget.
+	var drawn int
+	l := scene.LayerFunc(func(_ time.Duration, budget int) []helios.Point {
+		drawn = budget
+		return nil
+	})
+	Layer(l, k).Points(0, 1000)
+	if drawn != in {
+		t.Errorf("layer drawn with budget %d, want %d", drawn, in)
+	}
+}
@@ -0,0 +1,83 @@
+package helios
+
+import "fmt"
+
+// Error is a negative result code returned by the DAC methods.
+type Error int
+
+// Result codes, mirroring the HELIOS_ERROR_* definitions of the C++ SDK.
+const (
+	Success = 1
+
+	ErrNotInitialized   Error = -1
+	ErrInvalidDevNum    Error = -2
+	ErrNullPoints       Error = -3
+	ErrTooManyPoints    Error = -4
+	ErrPpsTooHigh       Error = -5
+	ErrPpsTooLow        Error = -6
+	ErrFrameTooSmall    Error = -7
+	ErrDeviceClosed     Error = -1000
+	ErrDeviceFrameReady Error = -1001
+	ErrSendControl      Error = -1002
+	ErrDeviceResult     Error = -1003
+	ErrNullBuffer       Error = -1004
+	ErrSignalTooLong    Error = -1005
+	ErrNotSupported     Error = -1006
+	ErrNetwork          Error = -1007
+
+	// ErrLibusbBase is added to libusb error codes (which are negative).
+	ErrLibusbBase Error = -5000
+
+	// ErrCoordinateRange is returned by the Go bindings, not the C++ SDK,
+	// for frames rejected by ValidateStrict.
+	ErrCoordinateRange Error = -6000
+
+	// ErrTimeout is returned by the Go bindings, not the C++ SDK, by the
+	// WithTimeout variants of calls the device did not finish in time, and
+	// by split frame writes to a device that stopped becoming ready.
+	ErrTimeout Error = -6001
+
+	// ErrNoBackend is returned by the Go bindings, not the C++ SDK, by
+	// every device call in builds with the helios_stub tag.
+	ErrNoBackend Error = -6002
+)
+
+var errorText = map[Error]string{
+	ErrNotInitialized:   "not initialized",
+	ErrInvalidDevNum:    "invalid device number",
+	ErrNullPoints:       "no points",
+	ErrTooManyPoints:    "too many points",
+	ErrPpsTooHigh:       "pps too high",
+	ErrPpsTooLow:        "pps too low",
+	ErrFrameTooSmall:    "frame too small",
+	ErrDeviceClosed:     "device closed",
+	ErrDeviceFrameReady: "previous frame not yet sent",
+	ErrSendControl:      "sending control packet failed",
+	ErrDeviceResult:     "invalid device response",
+	ErrNullBuffer:       "no buffer",
+	ErrSignalTooLong:    "signal too long",
+	ErrNotSupported:     "not supported by device",
+	ErrNetwork:          "network error",
+	ErrCoordinateRange:  "coordinate out of range",
+	ErrTimeout:          "timed out",
+	ErrNoBackend:        "built without a device backend",
+}
+
+func (e Error) Error() string {
+	if s, ok := errorText[e]; ok {
+		return "helios: " + s
+	}
+	if e <= ErrLibusbBase && e > ErrLibusbBase-100 {
+		return fmt.Sprintf("helios: libusb error %d", int(e-ErrLibusbBase))
+	}
+	return fmt.Sprintf("helios: error %d", int(e))
+}
+
+// ErrorFromCode converts a result code returned by a DAC method to an error.
+// Non-negative codes are not errors and yield nil.
+func ErrorFromCode(code int) error {
+	if code >= 0 {
+		return nil
+	}
+	return Error(code)
+}
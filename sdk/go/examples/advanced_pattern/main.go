@@ -12,7 +12,6 @@ package main
 
 import (
 	"fmt"
-	"math"
 	"time"
 
 	"github.com/Grix/helios_dac/sdk/go/helios"
@@ -43,8 +42,8 @@ func GenerateLine(start, end helios.Point, duration time.Duration, on bool) []he
 		y := float64(start.Y) + t*(float64(end.Y)-float64(start.Y))
 
 		p := helios.Point{
-			X: uint16(math.Round(x)),
-			Y: uint16(math.Round(y)),
+			X: helios.ClampToCoord(x),
+			Y: helios.ClampToCoord(y),
 		}
 
 		if on {
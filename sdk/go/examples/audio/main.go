@@ -0,0 +1,88 @@
+// Example: Audio Reactive
+//
+// This example drives a laser pattern from live audio. It reads 16-bit mono
+// PCM from stdin, so any capture tool can feed it, for example:
+//
+//	arecord -f S16_LE -r 44100 -c 1 | bazel run //sdk/go/examples/audio
+//
+// Concepts shown:
+// - Analysis: Using the audio package to extract band levels and beats.
+// - Modulation: Scaling the amplitude of a sine wave by the bass level.
+// - Reacting to beats: Switching color every time a beat is detected.
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/audio"
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+const (
+	PPS          = 30000
+	FrameRate    = 30
+	Center       = 2048
+	MaxAmplitude = 1500
+)
+
+var colors = []helios.Point{
+	{R: 255, I: 255},
+	{G: 255, I: 255},
+	{B: 255, I: 255},
+	{R: 255, G: 255, I: 255},
+}
+
+func main() {
+	analyzer := audio.NewAnalyzer(audio.DefaultFormat.SampleRate, 0, nil)
+	go func() {
+		if err := analyzer.Run(os.Stdin, audio.DefaultFormat); err != nil {
+			fmt.Println("Audio input error:", err)
+		}
+		fmt.Println("Audio input ended.")
+	}()
+
+	dac := helios.NewDAC()
+	defer dac.Close()
+
+	if dac.OpenDevices() == 0 {
+		fmt.Println("No devices found. Exiting.")
+		return
+	}
+
+	fmt.Println("Outputting audio reactive pattern... (Ctrl+C to stop)")
+
+	numPoints := PPS / FrameRate
+	frame := make([]helios.Point, numPoints)
+	colorIdx := 0
+	lastBeats := uint64(0)
+	start := time.Now()
+
+	for {
+		if beats := analyzer.Beats(); beats != lastBeats {
+			lastBeats = beats
+			colorIdx = (colorIdx + 1) % len(colors)
+		}
+
+		// Horizontal sine wave whose height follows the bass.
+		amplitude := MaxAmplitude * analyzer.Level("bass")
+		phase := time.Since(start).Seconds() * 4
+		color := colors[colorIdx]
+		for i := range frame {
+			t := float64(i) / float64(numPoints-1)
+			x := Center - MaxAmplitude + 2*MaxAmplitude*t
+			y := Center + amplitude*math.Sin(4*math.Pi*t+phase)
+			p := color
+			p.X, p.Y = helios.ClampToCoord(x), helios.ClampToCoord(y)
+			frame[i] = p
+		}
+
+		if dac.Status(0).Ready() {
+			dac.WriteFrame(0, PPS, 0, frame)
+		} else {
+			time.Sleep(1 * time.Millisecond)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+// Example: Bridge
+//
+// This example turns the Go SDK into an output daemon for programs written
+// in other languages. They write frames in the pipe package's wire format
+// to stdin, a UNIX socket or UDP, and the bridge paces them to the DACs and
+// blanks any device whose program exits or stalls:
+//
+//	python3 render.py | bazel run //sdk/go/examples/bridge
+//	bazel run //sdk/go/examples/bridge -- -unix /tmp/helios.sock
+//	bazel run //sdk/go/examples/bridge -- -udp :7302
+//
+// Concepts shown:
+// - Device management: Submitting frames through a DeviceManager, which writes each when its device is ready.
+// - Safety: Blanking devices when their input ends or goes quiet.
+// - Shutdown: Blanking and closing every device on Ctrl+C.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/pipe"
+)
+
+func main() {
+	var unixPath, udpAddr string
+	var timeout time.Duration
+	flag.StringVar(&unixPath, "unix", "", "Serve a UNIX socket at this path instead of stdin")
+	flag.StringVar(&udpAddr, "udp", "", "Serve UDP datagrams on this address instead of stdin")
+	flag.DurationVar(&timeout, "timeout", pipe.DefaultTimeout, "Blank a device after this long without a frame")
+	flag.Parse()
+
+	dac := helios.NewDAC()
+	defer dac.Shutdown(context.Background())
+
+	n := dac.OpenDevices()
+	if n == 0 {
+		fmt.Fprintln(os.Stderr, "No devices found. Exiting.")
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Bridging %d devices.\n", n)
+
+	manager := helios.NewDeviceManager(dac)
+	defer manager.Close()
+
+	bridge := pipe.NewBridge(manager)
+	bridge.Timeout = timeout
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt)
+	go func() {
+		<-stop
+		bridge.Close()
+		os.Stdin.Close()
+	}()
+
+	var err error
+	switch {
+	case unixPath != "":
+		os.Remove(unixPath)
+		err = bridge.ListenAndServe("unix", unixPath)
+	case udpAddr != "":
+		err = bridge.ListenAndServePacket(udpAddr)
+	default:
+		err = bridge.Serve(os.Stdin)
+	}
+	if err != nil {
+		log.Print(err)
+	}
+}
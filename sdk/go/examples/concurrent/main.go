@@ -7,7 +7,8 @@
 // - Concurrency: Using Go channels to pipe frames from generator to writer.
 // - Double Buffering: Using a buffered channel to decouple generation frame rate from output.
 // - Performance: Using runtime.LockOSThread() to reduce OS scheduler jitter on the output loop.
-// - Dynamic Generation: Calculating frames on-the-fly based on wall-clock time.
+// - Dynamic Generation: Calculating frames on-the-fly from a show.Transport clock.
+// - Fixed Timestep: Stepping the animation with an anim.Runtime, decoupled from frame pacing.
 package main
 
 import (
@@ -20,7 +21,9 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/Grix/helios_dac/sdk/go/anim"
 	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/show"
 )
 
 const (
@@ -31,12 +34,56 @@ const (
 	FrameRate = 30   // Target FPS for generation
 )
 
-// generateFrames continually calculates new frames based on time and sends them to the channel.
+// scanner is the animation state: a vertical line that scans left and
+// right. It advances in fixed steps, so its motion does not depend on when
+// the generator happens to wake up.
+type scanner struct {
+	angle float64 // radians along the sine wave
+}
+
+func (s *scanner) Step(dt time.Duration) {
+	s.angle += dt.Seconds() * ScanSpeed
+}
+
+func (s *scanner) Points(numPoints int) []helios.Point {
+	// Pattern: A vertical line that scans left and right (sine wave)
+	lineX := float64(Center) + math.Sin(s.angle)*float64(ScanRange)
+
+	// Line dimensions (Vertical)
+	yTop := float64(Center + ScanRange)
+	yBottom := float64(Center - ScanRange)
+
+	frame := make([]helios.Point, numPoints)
+	for i := 0; i < numPoints; i++ {
+		progress := float64(i) / float64(numPoints-1)
+
+		// Y interpolates from Bottom to Top
+		y := yBottom + (yTop-yBottom)*progress
+
+		frame[i] = helios.Point{
+			X: helios.ClampToCoord(lineX),
+			Y: helios.ClampToCoord(y),
+			R: 0,
+			G: 255, // Green Line
+			B: 0,
+			I: 255,
+		}
+	}
+	return frame
+}
+
+// generateFrames continually renders new frames and sends them to the channel.
 func generateFrames(ctx context.Context, framesChan chan<- []helios.Point) {
 	ticker := time.NewTicker(time.Second / FrameRate)
 	defer ticker.Stop()
 
-	startTime := time.Now()
+	// Animate from a transport rather than the wall clock, so the pattern
+	// can be paused, slowed down or scrubbed without touching the math.
+	clock := show.NewTransport(nil)
+
+	// The runtime steps the scanner on a fixed timestep and blends between
+	// steps, so a late tick or a slow render neither stutters nor drifts.
+	animation := anim.New(&scanner{})
 
 	fmt.Println("Generator: Started")
 
@@ -45,41 +92,9 @@ func generateFrames(ctx context.Context, framesChan chan<- []helios.Point) {
 		case <-ctx.Done():
 			fmt.Println("Generator: Stopping")
 			return
-		case t := <-ticker.C:
-			// Calculate animation state based on elapsed time
-			elapsed := t.Sub(startTime).Seconds()
-
-			// Pattern: A vertical line that scans left and right (sine wave)
-			xOffset := math.Sin(elapsed*ScanSpeed) * float64(ScanRange)
-			lineX := float64(Center) + xOffset
-
-			// Line dimensions (Vertical)
-			yTop := float64(Center + ScanRange)
-			yBottom := float64(Center - ScanRange)
-
-			// Simple line generation (fixed point count)
+		case <-ticker.C:
 			// Frame duration ~ 1/30s = 33ms. @ 30k PPS ~ 1000 points.
-			numPoints := PPS / FrameRate
-			frame := make([]helios.Point, numPoints)
-
-			for i := 0; i < numPoints; i++ {
-				progress := float64(i) / float64(numPoints-1)
-
-				// X is constant for the whole frame (vertical line)
-				x := lineX
-
-				// Y interpolates from Bottom to Top
-				y := yBottom + (yTop-yBottom)*progress
-
-				frame[i] = helios.Point{
-					X: uint16(x),
-					Y: uint16(y),
-					R: 0,
-					G: 255, // Green Line
-					B: 0,
-					I: 255,
-				}
-			}
+			frame := animation.Points(clock.Now(), PPS/FrameRate)
 
 			// Non-blocking send if possible, otherwise block until writer is ready.
 			// Ideally the buffer size prevents blocking unless writer falls behind.
@@ -120,11 +135,7 @@ func outputLoop(ctx context.Context, dac *helios.DAC, framesChan <-chan []helios
 		select {
 		case <-ctx.Done():
 			fmt.Println("Writer: Stopping")
-			// Stop output before exiting
-			// Note: dac.Stop() stops the stream.
-			// Iterate all devices to stop them.
-			// (Assuming 1 device for simplicity, or we can scan again)
-			// Ideally we just exit, and the defer Close() in main handles cleanup.
+			// main blanks and stops the devices with dac.Shutdown.
 			return
 		default:
 		}
@@ -147,8 +158,7 @@ func outputLoop(ctx context.Context, dac *helios.DAC, framesChan <-chan []helios
 		// For this example, we'll blindly write to index 0 if it's ready.
 		// (Assuming at least one device exists)
 
-		status := dac.GetStatus(0)
-		if status == 1 {
+		if dac.Status(0).Ready() {
 			dac.WriteFrame(0, PPS, 0, currentFrame)
 		} else {
 			// If not ready, sleep briefly to yield CPU
@@ -192,10 +202,12 @@ func main() {
 	// Run on main thread to avoid libusb threading issues on some platforms
 	outputLoop(ctx, dac, framesChan)
 
-	// Give slight time for cleanup printouts
-	time.Sleep(100 * time.Millisecond)
-
-	dac.Stop(0)
-	dac.CloseDevices()
+	// Blank, stop and close all devices, waiting at most a second for
+	// writes still in flight.
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), time.Second)
+	defer cancelShutdown()
+	if err := dac.Shutdown(shutdownCtx); err != nil {
+		fmt.Println("Shutdown:", err)
+	}
 	fmt.Println("Devices closed. Bye!")
 }
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
@@ -10,6 +11,7 @@ import (
 	"time"
 
 	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/motion"
 )
 
 const (
@@ -18,22 +20,35 @@ const (
 	galvoFullScale = 1 << galvoBitDepth // 4096
 	galvoMaxCoord  = galvoFullScale - 1 // 4095
 
-	// Hardware specs for dynamic latency calculation based on galvanometer physics.
-	stepResponseSmallAngle = 250 * time.Microsecond  // 0.1° step
-	stepResponseLargeAngle = 1000 * time.Microsecond // 40° optical step
-
 	// Default PPS (Points Per Second).
 	defaultPPS = 50000
 )
 
+// galvoProfile holds the hardware specs for dynamic latency calculation based
+// on galvanometer physics: 250µs for a 0.1° step, 1000µs for a 40° optical
+// step and a 150µs settle before the laser is enabled.
+var galvoProfile = motion.DefaultProfile
+
 func main() {
 	var x, y, radius, pps int
+	var radiusMM, distance, angle float64
 	flag.IntVar(&x, "x", 2048, "X coordinate of the dot center (0-4095)")
 	flag.IntVar(&y, "y", 2048, "Y coordinate of the dot center (0-4095)")
-	flag.IntVar(&radius, "radius", 84, "Radius of the dot in galvo units (approx 30mm)")
+	flag.IntVar(&radius, "radius", 84, "Radius of the dot in galvo units")
+	flag.Float64Var(&radiusMM, "radius-mm", 0, "Radius of the dot in millimeters on the surface, overriding -radius; needs -distance and -angle")
+	flag.Float64Var(&distance, "distance", 0, "Throw distance to the surface in millimeters")
+	flag.Float64Var(&angle, "angle", 0, "Full optical scan angle of the projector in degrees")
 	flag.IntVar(&pps, "pps", defaultPPS, "Points per second")
 	flag.Parse()
 
+	if radiusMM > 0 {
+		area := helios.AreaFromThrow(distance, angle)
+		if err := area.Validate(); err != nil {
+			log.Fatal(err)
+		}
+		radius = int(math.Round(area.Units(radiusMM)))
+	}
+
 	if x < 0 || x > galvoMaxCoord || y < 0 || y > galvoMaxCoord {
 		log.Fatalf("Coordinates out of bounds: (%d, %d). Must be 0-%d", x, y, galvoMaxCoord)
 	}
@@ -41,13 +56,10 @@ func main() {
 	fmt.Printf("Generating simple circle at (%d, %d) radius %d...\n", x, y, radius)
 
 	// Target 15ms frame time to fit within shorter exposure times of a 30fps camera.
-	targetFrameTime := 15 * time.Millisecond
-	pointBudget := int(targetFrameTime.Seconds() * float64(pps))
-
-	// Allocate budget. We need to reserve some for flyback.
 	// Flyback from radius to center is small distance (radius).
 	// Let's reserve 20% for flyback and safety.
-	featureBudget := int(float64(pointBudget) * 0.8)
+	governor := motion.Governor{FPS: 1 / (15 * time.Millisecond).Seconds(), PPS: pps, Reserve: 0.2}
+	featureBudget := governor.Budget()
 
 	// 1. Generate Feature (Center -> Ring Start -> Ring)
 	points := getFeaturePoints(float64(x), float64(y), radius, featureBudget, pps)
@@ -58,7 +70,7 @@ func main() {
 		lastPt := points[len(points)-1]
 		// Determine where we ended.
 		// Construct travel points from Last Point -> Center (x, y)
-		flyback := getTravelPoints(float64(lastPt.X), float64(lastPt.Y), float64(x), float64(y), pps)
+		flyback := galvoProfile.Travel(lastPt, helios.Point{X: uint16(x), Y: uint16(y)}, pps)
 		points = append(points, flyback...)
 	}
 
@@ -94,12 +106,12 @@ func main() {
 
 	fmt.Println("Projecting dot... Press Ctrl-C to stop.")
 
-	// Playback Rate Limiting
-	// Enforce min replay interval to avoid buffer underruns/partial frames
-	minReplayInterval := time.Duration(float64(frameDuration) * 0.9)
-	lastWriteTime := time.Time{}
+	// The frame never changes, so let the DAC loop it: with SkipRepeats the
+	// manager writes it once and drops the identical frames submitted after.
+	manager := helios.NewDeviceManager(dac)
+	manager.SkipRepeats = true
 
-	ticker := time.NewTicker(1 * time.Millisecond)
+	ticker := time.NewTicker(frameDuration)
 	defer ticker.Stop()
 
 	running := true
@@ -108,75 +120,14 @@ func main() {
 		case <-stop:
 			running = false
 		case <-ticker.C:
-			if time.Since(lastWriteTime) < minReplayInterval {
-				continue
-			}
-
-			// Poll all devices
-			anyWritten := false
 			for i := 0; i < numDevices; i++ {
-				if dac.GetStatus(i) == 1 {
-					dac.WriteFrame(i, pps, 0, points)
-					anyWritten = true
-				}
-			}
-			if anyWritten {
-				lastWriteTime = time.Now()
+				manager.SubmitFrame(i, pps, points)
 			}
 		}
 	}
 
-	for i := 0; i < numDevices; i++ {
-		dac.Stop(i)
-	}
-	dac.CloseDevices()
-}
-
-// getTravelPoints generates blanking points to move the laser from start to end coordinates.
-func getTravelPoints(startX, startY, endX, endY float64, pps int) []helios.Point {
-	// Calculate Euclidean distance for the jump
-	dist := math.Hypot(endX-startX, endY-startY)
-
-	ratio := dist / float64(galvoFullScale)
-	if ratio > 1.0 {
-		ratio = 1.0
-	}
-
-	// Dynamic Latency: Interpolate settling time between small (300µs) and large (1000µs) moves.
-	reqTime := stepResponseSmallAngle + time.Duration(float64(stepResponseLargeAngle-stepResponseSmallAngle)*ratio)
-
-	travelPoints := int(math.Ceil(reqTime.Seconds() * float64(pps)))
-	if travelPoints < 1 {
-		travelPoints = 1
-	}
-
-	var points []helios.Point
-	for k := 1; k <= travelPoints; k++ {
-		t := float64(k) / float64(travelPoints)
-		// SmoothStep (S-curve) interpolation to minimize mechanical shock/jerk.
-		alpha := t * t * (3.0 - 2.0*t)
-
-		ix := startX + (endX-startX)*alpha
-		iy := startY + (endY-startY)*alpha
-		points = append(points, helios.Point{
-			X: uint16(ix), Y: uint16(iy), R: 0, G: 0, B: 0, I: 0,
-		})
-	}
-
-	// Settling Dwell: 150µs dead time to ensure absolute stability before laser enable.
-	settleTime := 150 * time.Microsecond
-	settlePoints := int(math.Ceil(settleTime.Seconds() * float64(pps)))
-	if settlePoints < 1 {
-		settlePoints = 1
-	}
-
-	for k := 0; k < settlePoints; k++ {
-		points = append(points, helios.Point{
-			X: uint16(endX), Y: uint16(endY), R: 0, G: 0, B: 0, I: 0,
-		})
-	}
-
-	return points
+	manager.Close()
+	dac.Shutdown(context.Background())
 }
 
 // getFeaturePoints generates the visible ring pattern.
@@ -191,8 +142,8 @@ func getFeaturePoints(cx, cy float64, dotRadius int, pointBudget int, pps int) [
 
 	// 1. Move from Center (blanked) to Ring Start (Angle 0).
 	// We assume the laser is historically at Center (cx, cy).
-	ringStart := helios.Point{X: uint16(cx + float64(dotRadius)), Y: uint16(cy), R: 0, G: 0, B: 0, I: 0}
-	travel := getTravelPoints(cx, cy, float64(ringStart.X), float64(ringStart.Y), pps)
+	ringStart := helios.Point{X: helios.ClampToCoord(cx + float64(dotRadius)), Y: helios.ClampToCoord(cy), R: 0, G: 0, B: 0, I: 0}
+	travel := galvoProfile.Travel(helios.Point{X: helios.ClampToCoord(cx), Y: helios.ClampToCoord(cy)}, ringStart, pps)
 	points = append(points, travel...)
 
 	// 2. Draw Ring
@@ -207,10 +158,12 @@ func getFeaturePoints(cx, cy float64, dotRadius int, pointBudget int, pps int) [
 
 		theta := 2.0 * math.Pi * t
 
-		px := uint16(cx + float64(dotRadius)*math.Cos(theta))
-		py := uint16(cy + float64(dotRadius)*math.Sin(theta))
+		// Work in normalized units, so a ring near the edge clamps instead
+		// of wrapping around to the opposite side.
+		px := (cx+float64(dotRadius)*math.Cos(theta))/galvoMaxCoord*2 - 1
+		py := (cy+float64(dotRadius)*math.Sin(theta))/galvoMaxCoord*2 - 1
 
-		points = append(points, helios.Point{X: px, Y: py, R: 255, G: 255, B: 255, I: 255})
+		points = append(points, helios.PointF{X: px, Y: py, R: 1, G: 1, B: 1, I: 1}.Point())
 	}
 
 	return points
@@ -0,0 +1,118 @@
+// Example: Follow
+//
+// This example points the laser at a tracked object. A tracker, such as a
+// camera pipeline or a motion capture system, sends the object's position
+// over UDP, and a small circle follows it with as little delay as the
+// scanners allow:
+//
+//	bazel run //sdk/go/examples/follow -- -udp :7303
+//	echo "0.25 -0.5" | nc -u -w0 localhost 7303
+//
+// Each datagram holds "x y", from -1 to 1 across the projection area with
+// y pointing up. The output is blanked when the feed goes quiet.
+//
+// Concepts shown:
+// - Subframes: Replacing only the cursor segment of the frame on each update.
+// - Low Latency: Rendering just before the output runs out, with frames as short as the content.
+// - Safety: Pausing the stream, which blanks the output, when the tracker stops sending.
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/output"
+	"github.com/Grix/helios_dac/sdk/go/stream"
+)
+
+const (
+	PPS          = 30000
+	Center       = 2048
+	CursorPoints = 24
+)
+
+// cursor returns a red circle of radius r around (x, y).
+func cursor(x, y, r float64) []helios.Point {
+	points := make([]helios.Point, CursorPoints+1)
+	for i := range points {
+		a := 2 * math.Pi * float64(i) / CursorPoints
+		points[i] = helios.Point{
+			X: helios.ClampToCoord(x + r*math.Cos(a)),
+			Y: helios.ClampToCoord(y + r*math.Sin(a)),
+			R: 255,
+			I: 255,
+		}
+	}
+	return points
+}
+
+func main() {
+	var udpAddr string
+	var radius float64
+	var timeout time.Duration
+	flag.StringVar(&udpAddr, "udp", ":7303", "Receive positions on this UDP address")
+	flag.Float64Var(&radius, "radius", 60, "Radius of the cursor in galvo units")
+	flag.DurationVar(&timeout, "timeout", 250*time.Millisecond, "Blank the output after this long without a position")
+	flag.Parse()
+
+	conn, err := net.ListenPacket("udp", udpAddr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer conn.Close()
+
+	dac := helios.NewDAC()
+	defer dac.Shutdown(context.Background())
+	if dac.OpenDevices() == 0 {
+		fmt.Fprintln(os.Stderr, "No devices found. Exiting.")
+		return
+	}
+
+	frame := stream.NewSubframes()
+	frame.PPS = PPS
+	s := stream.New(output.NewDevice(dac, 0), frame, nil)
+	s.PPS = PPS
+	s.Buffering = stream.BufferLowLatency
+	s.Pause() // until the first position arrives
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+	go s.Run(ctx)
+
+	fmt.Printf("Following positions received on %s.\n", conn.LocalAddr())
+	buf := make([]byte, 512)
+	for {
+		conn.SetReadDeadline(time.Now().Add(timeout))
+		n, _, err := conn.ReadFrom(buf)
+		if ne, ok := err.(net.Error); ok && ne.Timeout() {
+			s.Pause()
+			continue
+		}
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Print(err)
+			}
+			return
+		}
+		var x, y float64
+		if _, err := fmt.Sscan(string(bytes.TrimSpace(buf[:n])), &x, &y); err != nil {
+			log.Printf("bad position %q: %v", buf[:n], err)
+			continue
+		}
+		frame.Set("cursor", cursor(Center+x*Center, Center+y*Center, radius))
+		s.Resume()
+	}
+}
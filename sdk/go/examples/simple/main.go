@@ -79,12 +79,12 @@ func main() {
 			// Poll status
 			attempts := 0
 			for attempts < 1024 {
-				status := dac.GetStatus(j)
-				if status == 1 {
+				status := dac.Status(j)
+				if status.Ready() {
 					dac.WriteFrameHighResolution(j, pointsPerSecond, 0, frames[frameIdx%numFramesInLoop])
 					break
-				} else if status < 0 {
-					fmt.Printf("Error polling device %d: %d\n", j, status)
+				} else if !status.Healthy() {
+					fmt.Printf("Error polling device %d: %v\n", j, status)
 					break
 				}
 				attempts++
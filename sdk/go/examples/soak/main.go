@@ -0,0 +1,84 @@
+// Example: Soak Test
+//
+// This example plays stress content on the first device for hours,
+// unattended, and reports how every kind of frame fared. Run it with a seed
+// to reproduce a failure seen in an earlier run:
+//
+//	bazel run //sdk/go/examples/soak -- -duration 8h -seed 42
+//
+// Concepts shown:
+// - Stress content: Using the stress package to cycle pathological frames.
+// - Frame splitting: Frames larger than the device accepts are written in chunks.
+// - Error accounting: Counting write results per pattern with ErrorFromCode.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/stress"
+)
+
+func main() {
+	var duration, period, report time.Duration
+	var seed uint64
+	flag.DurationVar(&duration, "duration", time.Hour, "How long to run")
+	flag.DurationVar(&period, "period", stress.DefaultPeriod, "How long each pattern runs")
+	flag.DurationVar(&report, "report", time.Minute, "How often to print statistics")
+	flag.Uint64Var(&seed, "seed", uint64(time.Now().UnixNano()), "Content seed")
+	flag.Parse()
+
+	dac := helios.NewDAC()
+	defer dac.Close()
+
+	if dac.OpenDevices() == 0 {
+		fmt.Println("No devices found. Exiting.")
+		return
+	}
+
+	gen := &stress.Generator{Seed: seed, Period: period, Limits: dac.FrameLimits(0)}
+	fmt.Printf("Soaking %s (%+v) for %v with seed %d... (Ctrl+C to stop)\n", dac.GetName(0), gen.Limits, duration, seed)
+
+	type stats struct{ frames, failures int }
+	counts := make(map[stress.Pattern]*stats)
+	start := time.Now()
+	lastReport := start
+	for time.Since(start) < duration {
+		if status := dac.Status(0); !status.Ready() {
+			if !status.Healthy() {
+				fmt.Printf("%v: status %v\n", time.Since(start).Round(time.Second), status)
+			}
+			time.Sleep(time.Millisecond)
+			continue
+		}
+
+		at := time.Since(start)
+		f := gen.Frame(at)
+		s := counts[f.Pattern]
+		if s == nil {
+			s = new(stats)
+			counts[f.Pattern] = s
+		}
+		s.frames++
+		if r := dac.WriteFrame(0, f.PPS, helios.FlagsDefault, f.Points); r < 0 {
+			s.failures++
+			fmt.Printf("%v: %v frame of %d points at %d pps (t=%d): %v\n", at.Round(time.Second), f.Pattern, len(f.Points), f.PPS, at, helios.ErrorFromCode(r))
+		}
+
+		if time.Since(lastReport) >= report {
+			lastReport = time.Now()
+			fmt.Printf("%v elapsed:", time.Since(start).Round(time.Second))
+			for p := stress.Sweep; p <= stress.Tiny; p++ {
+				if s := counts[p]; s != nil {
+					fmt.Printf(" %v %d/%d failed", p, s.failures, s.frames)
+				}
+			}
+			fmt.Println()
+		}
+	}
+
+	dac.Stop(0)
+	dac.CloseDevices()
+}
@@ -0,0 +1,150 @@
+package helios
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Stage describes one step of the WriteFrame pipeline as applied to a
+// frame.
+type Stage struct {
+	// Name is "adapt", "slew", "split", "validate", "intensity",
+	// "attenuation", "margin" or "colormap".
+	Name string `json:"name"`
+
+	// Ran reports whether the stage is enabled and changed or checked the
+	// frame.
+	Ran bool `json:"ran"`
+
+	// Params describes the settings the stage used.
+	Params string `json:"params"`
+
+	// PointsIn and PointsOut are the frame size before and after the
+	// stage.
+	PointsIn  int `json:"points_in"`
+	PointsOut int `json:"points_out"`
+
+	// Duration is the time the stage took.
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// Explanation reports what WriteFrame would do with a frame.
+type Explanation struct {
+	Stages []Stage `json:"stages"`
+
+	// Points and PPS describe the frame as it would be sent, and Writes the
+	// number of chunks it would be sent in.
+	Points int `json:"points"`
+	PPS    int `json:"pps"`
+	Writes int `json:"writes"`
+
+	// Latency is the time the pipeline took, and Playback the time the
+	// device takes to scan the frame once.
+	Latency  time.Duration `json:"latency_ns"`
+	Playback time.Duration `json:"playback_ns"`
+
+	// Err is the validation error the frame would be rejected with.
+	Err error `json:"-"`
+}
+
+// ExplainFrame runs a frame through the same pipeline as WriteFrame, with
+// the current settings and the device's limits, without sending it, and
+// reports each stage. Use it to find out why the device receives a
+// different frame than was written, or why a write fails.
+func (d *DAC) ExplainFrame(deviceIndex int, pps int, points []Point) *Explanation {
+	defer d.lockDevice(deviceIndex)()
+	ex := new(Explanation)
+	d.prepareFrame(points, pps, d.frameLimits(deviceIndex), d.levels.peek(deviceIndex), d.levels.attenuationMap(deviceIndex), d.levels.margin(deviceIndex), d.levels.colorMap(deviceIndex), d.levels.slewLimit(deviceIndex), ex)
+	return ex
+}
+
+// prepareFrame applies the WriteFrame pipeline, recording each stage in ex
+// if it is not nil. It returns the frame to send, its rate and the chunk
+// size to write it in.
+func (d *DAC) prepareFrame(points []Point, pps int, limits FrameLimits, scale float64, att *AttenuationMap, margin Margin, colors ColorMap, slew float64, ex *Explanation) ([]Point, int, int, error) {
+	begin := time.Now()
+	stage := func(name string, ran bool, params string, in int, start time.Time) {
+		if ex != nil {
+			ex.Stages = append(ex.Stages, Stage{Name: name, Ran: ran, Params: params, PointsIn: in, PointsOut: len(points), Duration: time.Since(start)})
+		}
+	}
+
+	start, in, inPPS := time.Now(), len(points), pps
+	adapt := d.AdaptFrames()
+	if adapt {
+		points, pps = adaptFrame(points, pps, limits)
+	}
+	stage("adapt", adapt && (len(points) != in || pps != inPPS),
+		fmt.Sprintf("enabled=%t max_points=%d pps=%d-%d, %d pps -> %d pps", adapt, limits.MaxPoints, limits.MinPPS, limits.MaxPPS, inPPS, pps), in, start)
+
+	start, in = time.Now(), len(points)
+	points = slewPoints(points, slew)
+	stage("slew", len(points) != in, fmt.Sprintf("limit=%g", slew), in, start)
+
+	start, in = time.Now(), len(points)
+	limits, chunk := d.splitLimits(limits, len(points))
+	stage("split", chunk < len(points),
+		fmt.Sprintf("enabled=%t chunk=%d", d.SplitFrames(), chunk), in, start)
+
+	var err error
+	start = time.Now()
+	v := d.Validation()
+	if v != ValidateOff {
+		points, err = ValidateFrame(points, pps, limits, v)
+	}
+	stage("validate", v != ValidateOff, fmt.Sprintf("mode=%s", v), in, start)
+
+	if err == nil {
+		start = time.Now()
+		points = scalePoints(points, scale)
+		stage("intensity", scale < 1, fmt.Sprintf("scale=%.3g", scale), in, start)
+
+		start = time.Now()
+		points = attenuatePoints(points, att)
+		zones := 0
+		if att != nil {
+			zones = len(att.Zones)
+		}
+		stage("attenuation", att != nil, fmt.Sprintf("zones=%d grid=%t", zones, att != nil && att.Grid != nil), in, start)
+
+		start = time.Now()
+		points = marginPoints(points, margin)
+		stage("margin", !margin.zero(), fmt.Sprintf("left=%g right=%g bottom=%g top=%g compress=%t", margin.Left, margin.Right, margin.Bottom, margin.Top, margin.Compress), in, start)
+
+		start = time.Now()
+		points = colorMapPoints(points, colors)
+		stage("colormap", !colors.identity(), fmt.Sprintf("r=%s g=%s b=%s i=%s", colors.R, colors.G, colors.B, colors.I), in, start)
+	}
+
+	if ex != nil {
+		ex.Points, ex.PPS, ex.Err = len(points), pps, err
+		if err == nil && len(points) > 0 {
+			ex.Writes = (len(points) + max(chunk, 1) - 1) / max(chunk, 1)
+		}
+		if pps > 0 {
+			ex.Playback = time.Duration(len(points)) * time.Second / time.Duration(pps)
+		}
+		ex.Latency = time.Since(begin)
+	}
+	return points, pps, chunk, err
+}
+
+// String formats the explanation as a table, one stage per line.
+func (e *Explanation) String() string {
+	var b strings.Builder
+	for _, s := range e.Stages {
+		mark := "-"
+		if s.Ran {
+			mark = "*"
+		}
+		fmt.Fprintf(&b, "%s %-9s %5d -> %-5d %9v  %s\n", mark, s.Name, s.PointsIn, s.PointsOut, s.Duration, s.Params)
+	}
+	if e.Err != nil {
+		fmt.Fprintf(&b, "rejected: %v\n", e.Err)
+	} else {
+		fmt.Fprintf(&b, "sends %d points at %d pps in %d writes, plays in %v\n", e.Points, e.PPS, e.Writes, e.Playback)
+	}
+	fmt.Fprintf(&b, "pipeline latency %v\n", e.Latency)
+	return b.String()
+}
@@ -0,0 +1,85 @@
+package helios
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPrepareFrameExplains(t *testing.T) {
+	d := &DAC{}
+	d.SetAdaptFrames(true)
+	d.SetValidation(ValidateClamp)
+	limits := FrameLimits{MaxPoints: 100, MinPPS: 7, MaxPPS: 30000}
+	points := make([]Point, 250)
+	points[3].X = 5000
+
+	ex := new(Explanation)
+	out, pps, chunk, err := d.prepareFrame(points, 30000, limits, 0.5, nil, Margin{}, ColorMap{}, 0, ex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Adapting keeps every third point, which already fits.
+	if len(out) != 84 || pps != 10000 || chunk != 84 {
+		t.Fatalf("prepared %d points at %d pps in chunks of %d", len(out), pps, chunk)
+	}
+	var names []string
+	for _, s := range ex.Stages {
+		names = append(names, s.Name)
+	}
+	if got := strings.Join(names, " "); got != "adapt slew split validate intensity attenuation margin colormap" {
+		t.Fatalf("stages = %s", got)
+	}
+	if a := ex.Stages[0]; !a.Ran || a.PointsIn != 250 || a.PointsOut != 84 {
+		t.Fatalf("adapt = %+v", a)
+	}
+	if ex.Stages[1].Ran || ex.Stages[2].Ran || !ex.Stages[3].Ran || !ex.Stages[4].Ran {
+		t.Fatalf("stages = %+v", ex.Stages)
+	}
+	if ex.Points != 84 || ex.PPS != 10000 || ex.Writes != 1 || ex.Playback != 8400000 {
+		t.Fatalf("explanation = %+v", ex)
+	}
+	if s := ex.String(); !strings.Contains(s, "* adapt") || !strings.Contains(s, "- split") {
+		t.Fatalf("String() = %q", s)
+	}
+}
+
+func TestPrepareFrameExplainsSplitAndRejection(t *testing.T) {
+	d := &DAC{}
+	d.SetValidation(ValidateStrict)
+	limits := FrameLimits{MaxPoints: 100, MinPPS: 7, MaxPPS: 30000}
+	points := make([]Point, 250)
+
+	ex := new(Explanation)
+	if _, _, chunk, err := d.prepareFrame(points, 30000, limits, 1, nil, Margin{}, ColorMap{}, 0, ex); err != nil || chunk != 100 {
+		t.Fatalf("chunk = %d, err = %v", chunk, err)
+	}
+	if !ex.Stages[2].Ran || ex.Writes != 3 || ex.Stages[4].Ran {
+		t.Fatalf("explanation = %+v", ex)
+	}
+
+	points[7].Y = 4096
+	ex = new(Explanation)
+	if _, _, _, err := d.prepareFrame(points, 30000, limits, 1, nil, Margin{}, ColorMap{}, 0, ex); !errors.Is(err, ErrCoordinateRange) {
+		t.Fatalf("err = %v", err)
+	}
+	if len(ex.Stages) != 4 || ex.Writes != 0 || !strings.Contains(ex.String(), "rejected") {
+		t.Fatalf("explanation = %+v", ex)
+	}
+}
+
+func TestPrepareFrameExplainsSlew(t *testing.T) {
+	d := &DAC{}
+	limits := FrameLimits{MaxPoints: 100, MinPPS: 7, MaxPPS: 30000}
+	points := []Point{{X: 0}, {X: 4000}}
+
+	// The jump out and the jump back are each broken into four steps.
+	ex := new(Explanation)
+	out, _, _, err := d.prepareFrame(points, 30000, limits, 1, nil, Margin{}, ColorMap{}, 1000, ex)
+	if err != nil || len(out) != 8 {
+		t.Fatalf("prepared %d points, err = %v", len(out), err)
+	}
+	if s := ex.Stages[1]; s.Name != "slew" || !s.Ran || s.PointsIn != 2 || s.PointsOut != 8 {
+		t.Fatalf("slew = %+v", s)
+	}
+}
@@ -0,0 +1,203 @@
+// Package failover runs two hosts as a primary/backup pair.
+//
+// The primary sends heartbeats over UDP with SendHeartbeats. The backup runs
+// a Monitor, which takes over when no heartbeat has arrived within its
+// timeout: Active turns true and OnTakeover is called, e.g. to flip a USB
+// switch to the backup host. A Standby output forwards frames only while its
+// monitor is active, so a backup connected to the same network DAC stays
+// silent until the primary dies.
+package failover
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/output"
+)
+
+// DefaultPort is the UDP port conventionally used for heartbeats.
+const DefaultPort = 7270
+
+var magic = []byte("HLHB")
+
+// SendHeartbeats writes a heartbeat to conn every interval until ctx is
+// done. Send errors are retried at the next interval, since the backup may
+// be temporarily unreachable; SendHeartbeats returns ctx.Err().
+func SendHeartbeats(ctx context.Context, conn net.Conn, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var seq uint32
+	for {
+		seq++
+		conn.Write(binary.BigEndian.AppendUint32(append([]byte(nil), magic...), seq))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Monitor watches a primary's heartbeats on the backup host.
+type Monitor struct {
+	// Timeout is how long the primary may stay silent before the backup
+	// takes over.
+	Timeout time.Duration
+
+	// Failback hands control back when the primary's heartbeats resume.
+	// Without it the backup stays active once it has taken over, which
+	// avoids flapping on an unreliable link.
+	Failback bool
+
+	// OnTakeover and OnStandby, if set, are called when the monitor becomes
+	// active and when it hands back to the primary.
+	OnTakeover func()
+	OnStandby  func()
+
+	// ErrorLog receives read errors. Nil means the log package's standard
+	// logger.
+	ErrorLog *log.Logger
+
+	mu     sync.Mutex
+	last   time.Time
+	active bool
+	conn   net.PacketConn
+}
+
+// NewMonitor creates a monitor in standby. The primary counts as alive
+// until timeout has passed without a heartbeat.
+func NewMonitor(timeout time.Duration) *Monitor {
+	return &Monitor{Timeout: timeout, last: time.Now()}
+}
+
+// Active reports whether the backup is in control.
+func (m *Monitor) Active() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.active
+}
+
+// Beat records a heartbeat from the primary.
+func (m *Monitor) Beat() {
+	m.mu.Lock()
+	m.last = time.Now()
+	handBack := m.active && m.Failback
+	if handBack {
+		m.active = false
+	}
+	m.mu.Unlock()
+	if handBack && m.OnStandby != nil {
+		m.OnStandby()
+	}
+}
+
+// Check takes over if the primary has been silent for longer than Timeout.
+// Serve calls it periodically.
+func (m *Monitor) Check() {
+	m.mu.Lock()
+	takeover := !m.active && time.Since(m.last) > m.Timeout
+	if takeover {
+		m.active = true
+	}
+	m.mu.Unlock()
+	if takeover && m.OnTakeover != nil {
+		m.OnTakeover()
+	}
+}
+
+// ListenAndServe listens for heartbeats on the UDP address addr and serves
+// until Close is called.
+func (m *Monitor) ListenAndServe(addr string) error {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return err
+	}
+	return m.Serve(conn)
+}
+
+// Serve reads heartbeats from conn and checks for timeouts until Close is
+// called. It returns nil after Close.
+func (m *Monitor) Serve(conn net.PacketConn) error {
+	m.mu.Lock()
+	m.conn = conn
+	m.mu.Unlock()
+
+	buf := make([]byte, 64)
+	for {
+		conn.SetReadDeadline(time.Now().Add(m.Timeout / 4))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			var ne net.Error
+			switch {
+			case errors.Is(err, net.ErrClosed):
+				return nil
+			case errors.As(err, &ne) && ne.Timeout():
+			default:
+				m.logf("failover: %v", err)
+			}
+		} else if n >= len(magic) && bytes.Equal(buf[:len(magic)], magic) {
+			m.Beat()
+		}
+		m.Check()
+	}
+}
+
+// Close stops serving.
+func (m *Monitor) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.conn == nil {
+		return nil
+	}
+	return m.conn.Close()
+}
+
+func (m *Monitor) logf(format string, args ...any) {
+	if m.ErrorLog != nil {
+		m.ErrorLog.Printf(format, args...)
+	} else {
+		log.Printf(format, args...)
+	}
+}
+
+// Standby wraps the backup's output. Frames are discarded while the monitor
+// is in standby, and the output is stopped when control returns to the
+// primary.
+type Standby struct {
+	output.Output
+	Monitor *Monitor
+
+	mu      sync.Mutex
+	writing bool
+}
+
+// Ready reports whether the wrapped output is ready. In standby it is
+// always ready, so the frame loop keeps its pace.
+func (s *Standby) Ready() (bool, error) {
+	if !s.Monitor.Active() {
+		return true, nil
+	}
+	return s.Output.Ready()
+}
+
+// WriteFrame forwards the frame while the monitor is active.
+func (s *Standby) WriteFrame(pps int, points []helios.Point) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.Monitor.Active() {
+		if s.writing {
+			s.writing = false
+			return s.Output.Stop()
+		}
+		return nil
+	}
+	s.writing = true
+	return s.Output.WriteFrame(pps, points)
+}
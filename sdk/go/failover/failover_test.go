@@ -0,0 +1,76 @@
+package failover
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+type countingOutput struct{ frames, stops int }
+
+func (o *countingOutput) Ready() (bool, error)                 { return true, nil }
+func (o *countingOutput) WriteFrame(int, []helios.Point) error { o.frames++; return nil }
+func (o *countingOutput) Stop() error                          { o.stops++; return nil }
+func (o *countingOutput) Close() error                         { return nil }
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestTakeoverAndFailback(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewMonitor(100 * time.Millisecond)
+	m.Failback = true
+	takeovers := make(chan struct{}, 1)
+	m.OnTakeover = func() { takeovers <- struct{}{} }
+	done := make(chan error)
+	go func() { done <- m.Serve(conn) }()
+
+	primary, err := net.Dial("udp", conn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer primary.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	go SendHeartbeats(ctx, primary, 10*time.Millisecond)
+
+	out := &countingOutput{}
+	standby := &Standby{Output: out, Monitor: m}
+	time.Sleep(200 * time.Millisecond)
+	standby.WriteFrame(30000, make([]helios.Point, 10))
+	if m.Active() || out.frames != 0 {
+		t.Fatal("backup took over while the primary was alive")
+	}
+
+	cancel()
+	<-takeovers
+	standby.WriteFrame(30000, make([]helios.Point, 10))
+	if out.frames != 1 {
+		t.Fatal("backup did not forward frames after takeover")
+	}
+
+	go SendHeartbeats(context.Background(), primary, 10*time.Millisecond)
+	waitFor(t, "failback", func() bool { return !m.Active() })
+	standby.WriteFrame(30000, make([]helios.Point, 10))
+	if out.frames != 1 || out.stops != 1 {
+		t.Fatalf("after failback frames = %d, stops = %d", out.frames, out.stops)
+	}
+
+	m.Close()
+	if err := <-done; err != nil {
+		t.Fatalf("Serve returned %v after Close", err)
+	}
+}
@@ -0,0 +1,107 @@
+package helios
+
+// Frame holds a frame in one of the point formats, so content can be
+// written to any device without knowing which format the device takes. Set
+// one of Points, HighRes and Ext; if several are set, the first is used.
+type Frame struct {
+	Points  []Point
+	HighRes []PointHighRes
+	Ext     []PointExt
+}
+
+// Len returns the number of points in the frame.
+func (f Frame) Len() int {
+	switch {
+	case f.Points != nil:
+		return len(f.Points)
+	case f.HighRes != nil:
+		return len(f.HighRes)
+	}
+	return len(f.Ext)
+}
+
+// convertPoints converts every point of a frame with conv.
+func convertPoints[P, Q any](points []P, conv func(P) Q) []Q {
+	out := make([]Q, len(points))
+	for i, p := range points {
+		out[i] = conv(p)
+	}
+	return out
+}
+
+// ToPoints returns the frame as standard points, converting if needed.
+func (f Frame) ToPoints() []Point {
+	switch {
+	case f.Points != nil:
+		return f.Points
+	case f.HighRes != nil:
+		return convertPoints(f.HighRes, PointHighRes.Point)
+	}
+	return convertPoints(f.Ext, PointExt.Point)
+}
+
+// ToHighRes returns the frame as high-resolution points, converting if
+// needed.
+func (f Frame) ToHighRes() []PointHighRes {
+	switch {
+	case f.Points != nil:
+		return convertPoints(f.Points, Point.HighRes)
+	case f.HighRes != nil:
+		return f.HighRes
+	}
+	return convertPoints(f.Ext, PointExt.HighRes)
+}
+
+// ToExt returns the frame as extended points, converting if needed.
+func (f Frame) ToExt() []PointExt {
+	switch {
+	case f.Points != nil:
+		return convertPoints(f.Points, Point.Ext)
+	case f.HighRes != nil:
+		return convertPoints(f.HighRes, PointHighRes.Ext)
+	}
+	return f.Ext
+}
+
+type pointFormat int
+
+const (
+	formatStandard pointFormat = iota
+	formatHighRes
+	formatExt
+)
+
+// format picks the format to write f in to a device taking high-resolution
+// and extended points as given: the frame's own if the device takes it,
+// otherwise the richest the device takes. Standard frames are always
+// written as they are, since widening gains nothing.
+func (f Frame) format(highRes, ext bool) pointFormat {
+	switch {
+	case f.Points != nil:
+		return formatStandard
+	case f.HighRes != nil && highRes:
+		return formatHighRes
+	case f.HighRes != nil:
+		return formatStandard
+	case ext:
+		return formatExt
+	case highRes:
+		return formatHighRes
+	}
+	return formatStandard
+}
+
+// WriteFrameAny writes a frame in any format to a device, with
+// WriteFrame, WriteFrameHighResolution or WriteFrameExtended depending on
+// what the device takes, converting the points if it does not take the
+// frame's format.
+func (d *DAC) WriteFrameAny(deviceIndex int, pps int, flags int, f Frame) int {
+	c := capabilitiesOf(d.GetIsUsb(deviceIndex), 0, d.GetSupportsHigherResolutions(deviceIndex) == 1)
+	switch f.format(c.HighResPoints, c.ExtendedPoints) {
+	case formatExt:
+		return d.WriteFrameExtended(deviceIndex, pps, flags, f.ToExt())
+	case formatHighRes:
+		return d.WriteFrameHighResolution(deviceIndex, pps, flags, f.ToHighRes())
+	}
+	return d.WriteFrame(deviceIndex, pps, flags, f.ToPoints())
+}
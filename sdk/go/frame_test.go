@@ -0,0 +1,31 @@
+package helios
+
+import "testing"
+
+func TestFrameFormat(t *testing.T) {
+	std := Frame{Points: []Point{{X: 1}}}
+	hr := Frame{HighRes: []PointHighRes{{X: 0x10}}}
+	ext := Frame{Ext: []PointExt{{X: 0x10, User1: 3}}}
+	for _, tc := range []struct {
+		f            Frame
+		highRes, ext bool
+		want         pointFormat
+	}{
+		{std, true, true, formatStandard},
+		{hr, true, true, formatHighRes},
+		{hr, false, false, formatStandard},
+		{ext, true, true, formatExt},
+		{ext, true, false, formatHighRes},
+		{ext, false, false, formatStandard},
+	} {
+		if got := tc.f.format(tc.highRes, tc.ext); got != tc.want {
+			t.Errorf("%+v on highRes=%v ext=%v: format %d, want %d", tc.f, tc.highRes, tc.ext, got, tc.want)
+		}
+	}
+	if p := ext.ToPoints(); len(p) != 1 || p[0].X != 1 || ext.Len() != 1 {
+		t.Fatalf("extended frame as standard points = %+v", p)
+	}
+	if p := std.ToExt(); p[0].X != 0x10 {
+		t.Fatalf("standard frame as extended points = %+v", p)
+	}
+}
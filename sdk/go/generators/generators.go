@@ -0,0 +1,188 @@
+// Package generators draws parametric abstract patterns: Lissajous
+// figures, spirographs, harmonographs and rose curves.
+//
+// Every generator is a scene.Layer. Its curve is evaluated densely, then
+// respaced with motion.Resample so the beam scans at constant speed, and
+// animated by show time so it moves smoothly at any frame rate. Fields
+// carry param tags, so param.Of exposes them to control surfaces.
+package generators
+
+import (
+	"math"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/motion"
+)
+
+// Stroke is the size and color a pattern is drawn with. The zero Stroke
+// draws nothing; start from DefaultStroke.
+type Stroke struct {
+	// Size scales the pattern; 1 fills the projection.
+	Size float64 `param:"size,min=0,max=1,default=0.8"`
+
+	// R, G, B is the color (0 to 1).
+	R float64 `param:"r,min=0,max=1,default=1"`
+	G float64 `param:"g,min=0,max=1,default=1"`
+	B float64 `param:"b,min=0,max=1,default=1"`
+}
+
+// DefaultStroke draws white at 80% size.
+var DefaultStroke = Stroke{Size: 0.8, R: 1, G: 1, B: 1}
+
+// oversample is how many curve samples are taken per output point before
+// resampling.
+const oversample = 4
+
+// minSamples keeps small budgets from flattening curves into polygons
+// before resampling.
+const minSamples = 512
+
+// trace samples curve at s in [0, 1] and respaces the samples into budget
+// points.
+func trace(st Stroke, budget int, curve func(s float64) (x, y float64)) []helios.Point {
+	if budget <= 0 {
+		return nil
+	}
+	n := max(budget*oversample, minSamples)
+	dense := make([]helios.Point, n+1)
+	for i := range dense {
+		x, y := curve(float64(i) / float64(n))
+		dense[i] = helios.PointF{X: x * st.Size, Y: y * st.Size, R: st.R, G: st.G, B: st.B, I: 1}.Point()
+	}
+	return motion.Resample(dense, budget)
+}
+
+// turns returns the phase in radians of something turning rate times per
+// second.
+func turns(t time.Duration, rate float64) float64 {
+	return 2 * math.Pi * rate * t.Seconds()
+}
+
+// Lissajous draws x = sin(A·θ + φ), y = sin(B·θ), with the phase φ
+// drifting over time so the figure appears to rotate in depth.
+type Lissajous struct {
+	Stroke Stroke `param:"stroke"`
+
+	A int `param:"a,min=1,max=16,default=3"`
+	B int `param:"b,min=1,max=16,default=2"`
+
+	// Speed is the phase drift in turns per second.
+	Speed float64 `param:"speed,min=-2,max=2,default=0.1"`
+}
+
+// Points draws the figure at time t.
+func (l *Lissajous) Points(t time.Duration, budget int) []helios.Point {
+	phase := turns(t, l.Speed)
+	return trace(l.Stroke, budget, func(s float64) (float64, float64) {
+		th := 2 * math.Pi * s
+		return math.Sin(float64(l.A)*th + phase), math.Sin(float64(l.B) * th)
+	})
+}
+
+// Spirograph draws a hypotrochoid: the path of a pen at distance Pen from
+// the center of a wheel of radius Wheel rolling inside a ring of radius
+// Ring. The figure rotates over time.
+type Spirograph struct {
+	Stroke Stroke `param:"stroke"`
+
+	Ring  int     `param:"ring,min=1,max=96,default=60"`
+	Wheel int     `param:"wheel,min=1,max=96,default=23"`
+	Pen   float64 `param:"pen,min=0,max=96,default=20"`
+
+	// Speed is the rotation in turns per second.
+	Speed float64 `param:"speed,min=-2,max=2,default=0.05"`
+}
+
+// Points draws the figure at time t.
+func (sp *Spirograph) Points(t time.Duration, budget int) []helios.Point {
+	ring, wheel := float64(sp.Ring), float64(max(sp.Wheel, 1))
+	// The pen returns to its start after the wheel has gone round the ring
+	// wheel/gcd(ring, wheel) times.
+	laps := float64(sp.Wheel / gcd(sp.Ring, max(sp.Wheel, 1)))
+	scale := math.Abs(ring-wheel) + sp.Pen
+	if scale == 0 {
+		scale = 1
+	}
+	rot := turns(t, sp.Speed)
+	return trace(sp.Stroke, budget, func(s float64) (float64, float64) {
+		th := 2 * math.Pi * laps * s
+		x := (ring-wheel)*math.Cos(th) + sp.Pen*math.Cos((ring-wheel)/wheel*th)
+		y := (ring-wheel)*math.Sin(th) - sp.Pen*math.Sin((ring-wheel)/wheel*th)
+		return rotate(x/scale, y/scale, rot)
+	})
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return max(a, 1)
+}
+
+func rotate(x, y, angle float64) (float64, float64) {
+	s, c := math.Sincos(angle)
+	return x*c - y*s, x*s + y*c
+}
+
+// Harmonograph draws the trace of two damped pendulums per axis, which
+// spirals in towards the center. The second pendulum's phase drifts over
+// time.
+type Harmonograph struct {
+	Stroke Stroke `param:"stroke"`
+
+	// F1 and F2 are the pendulum frequencies; nearly whole ratios give the
+	// classic slowly precessing figures.
+	F1 float64 `param:"f1,min=0.5,max=8,default=2"`
+	F2 float64 `param:"f2,min=0.5,max=8,default=3.01"`
+
+	// Damping is how much the swing decays over the trace.
+	Damping float64 `param:"damping,min=0,max=10,default=3"`
+
+	// Cycles is the length of the trace in periods of the first pendulum.
+	Cycles float64 `param:"cycles,min=1,max=64,default=20"`
+
+	// Speed is the phase drift in turns per second.
+	Speed float64 `param:"speed,min=-2,max=2,default=0.05"`
+}
+
+// Points draws the figure at time t. It is an open path, starting at the
+// edge and ending near the center.
+func (h *Harmonograph) Points(t time.Duration, budget int) []helios.Point {
+	phase := turns(t, h.Speed)
+	return trace(h.Stroke, budget, func(s float64) (float64, float64) {
+		tau := 2 * math.Pi * h.Cycles * s
+		decay := math.Exp(-h.Damping * s)
+		x := math.Sin(h.F1*tau) + math.Sin(h.F2*tau+phase)
+		y := math.Sin(h.F1*tau+math.Pi/2) + math.Sin(h.F2*tau)
+		return x * decay / 2, y * decay / 2
+	})
+}
+
+// Rose draws the rose curve r = cos(N/D·θ), rotating over time.
+type Rose struct {
+	Stroke Stroke `param:"stroke"`
+
+	N int `param:"n,min=1,max=16,default=5"`
+	D int `param:"d,min=1,max=16,default=4"`
+
+	// Speed is the rotation in turns per second.
+	Speed float64 `param:"speed,min=-2,max=2,default=0.05"`
+}
+
+// Points draws the figure at time t.
+func (r *Rose) Points(t time.Duration, budget int) []helios.Point {
+	g := gcd(r.N, max(r.D, 1))
+	n, d := float64(r.N/g), float64(max(r.D, 1)/g)
+	// The curve closes after d·π when n·d is odd, 2d·π otherwise.
+	span := 2 * math.Pi * d
+	if int(n)*int(d)%2 == 1 {
+		span = math.Pi * d
+	}
+	rot := turns(t, r.Speed)
+	return trace(r.Stroke, budget, func(s float64) (float64, float64) {
+		th := span * s
+		rad := math.Cos(n / d * th)
+		return rotate(rad*math.Cos(th), rad*math.Sin(th), rot)
+	})
+}
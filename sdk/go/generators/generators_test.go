@@ -0,0 +1,85 @@
+package generators
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/param"
+	"github.com/Grix/helios_dac/sdk/go/scene"
+)
+
+func dist(a, b helios.Point) float64 {
+	return math.Hypot(float64(a.X)-float64(b.X), float64(a.Y)-float64(b.Y))
+}
+
+func TestLissajousCircle(t *testing.T) {
+	// A 1:1 figure a quarter turn out of phase is a circle.
+	l := &Lissajous{Stroke: DefaultStroke, A: 1, B: 1, Speed: 0.25}
+	frame := l.Points(time.Second, 200)
+	if len(frame) != 200 {
+		t.Fatalf("got %d points", len(frame))
+	}
+	r := 0.8 * 2047.5
+	for i, p := range frame {
+		if d := math.Hypot(float64(p.X)-2047.5, float64(p.Y)-2047.5); math.Abs(d-r) > 3 {
+			t.Fatalf("point %d is %v from the center, want %v", i, d, r)
+		}
+	}
+	// Resampling spaces the points evenly.
+	step := 2 * math.Pi * r / 199
+	for i := 1; i < len(frame); i++ {
+		if d := dist(frame[i-1], frame[i]); math.Abs(d-step) > 2 {
+			t.Fatalf("step %d is %v, want %v", i, d, step)
+		}
+	}
+}
+
+func TestClosedFigures(t *testing.T) {
+	for _, l := range []scene.Layer{
+		&Lissajous{Stroke: DefaultStroke, A: 3, B: 2, Speed: 0.1},
+		&Spirograph{Stroke: DefaultStroke, Ring: 60, Wheel: 23, Pen: 20},
+		&Spirograph{Stroke: DefaultStroke, Ring: 5, Wheel: 3, Pen: 2},
+		&Rose{Stroke: DefaultStroke, N: 5, D: 4},
+		&Rose{Stroke: DefaultStroke, N: 3, D: 1},
+	} {
+		frame := l.Points(1234*time.Millisecond, 500)
+		if len(frame) != 500 {
+			t.Errorf("%T: %d points", l, len(frame))
+			continue
+		}
+		if d := dist(frame[0], frame[len(frame)-1]); d > 2 {
+			t.Errorf("%+v does not close: ends %v apart", l, d)
+		}
+		for _, p := range frame {
+			if p.R != 255 || p.I != 255 {
+				t.Fatalf("%T: unlit point %+v", l, p)
+			}
+		}
+	}
+}
+
+func TestHarmonographDecays(t *testing.T) {
+	h := &Harmonograph{Stroke: DefaultStroke, F1: 2, F2: 3.01, Damping: 3, Cycles: 20}
+	frame := h.Points(0, 300)
+	center := helios.Point{X: 2048, Y: 2048}
+	if first, last := dist(frame[0], center), dist(frame[len(frame)-1], center); last >= first/4 {
+		t.Fatalf("trace does not spiral in: %v then %v from the center", first, last)
+	}
+}
+
+func TestGeneratorsHaveParams(t *testing.T) {
+	for _, g := range []any{&Lissajous{}, &Spirograph{}, &Harmonograph{}, &Rose{}} {
+		s, err := param.Of(g)
+		if err != nil {
+			t.Fatalf("param.Of(%T): %v", g, err)
+		}
+		if s.Lookup("stroke/size") == nil {
+			t.Errorf("%T has no stroke/size parameter", g)
+		}
+	}
+	if l := (&Lissajous{}).Points(0, 0); l != nil {
+		t.Fatal("zero budget drew points")
+	}
+}
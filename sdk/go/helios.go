@@ -1,18 +1,31 @@
 package helios
 
-/*
-#include "wrapper.h"
-#include <stdlib.h>
-*/
-import "C"
-
 import (
-	"unsafe"
+	"errors"
+	"net/netip"
+	"sync/atomic"
 )
 
-// HeliosDac is a wrapper around the C++ HeliosDac class.
+// DAC is a wrapper around the C++ HeliosDac class.
+//
+// A DAC is safe for concurrent use. Calls for the same device are
+// serialized, calls for different devices run in parallel, and scanning or
+// closing devices waits for all device calls in progress.
 type DAC struct {
-	handle C.HeliosDacHandle
+	lib        library
+	numDevices int
+	levels     levels
+	watchdog   watchdog
+	gate       writeGate
+	locks      deviceLocks
+	validation atomic.Int32
+	adapt      atomic.Bool
+	noSplit    atomic.Bool
+	blankEmpty atomic.Bool
+	usb        atomic.Pointer[USBOptions]
+	openHook   atomic.Pointer[func(deviceIndex int)]
+	writeHook  atomic.Pointer[func(deviceIndex int, info WriteInfo)]
+	stats      stats
 }
 
 // Point corresponds to the standard point structure (8-bit colors, 12-bit XY).
@@ -24,8 +37,8 @@ type Point struct {
 	R, G, B, I uint8
 }
 
-// PointHighRes corresponds to the high-resolution point structure (16-bit colors, 12-bit XY).
-// X, Y: 12-bit coordinates (Range: 0 - 4095).
+// PointHighRes corresponds to the high-resolution point structure (16-bit colors, 16-bit XY).
+// X, Y: 16-bit coordinates (Range: 0 - 65535).
 // R, G, B: 16-bit color components (Range: 0 - 65535).
 type PointHighRes struct {
 	X, Y    uint16
@@ -33,7 +46,7 @@ type PointHighRes struct {
 }
 
 // PointExt corresponds to the extended point structure (all fields 16-bit).
-// X, Y: 12-bit coordinates (Range: 0 - 4095).
+// X, Y: 16-bit coordinates (Range: 0 - 65535).
 // R, G, B, I: 16-bit color/intensity components (Range: 0 - 65535).
 // User1-4: 16-bit user defined values for accessory ports (Range: 0 - 65535).
 type PointExt struct {
@@ -45,160 +58,348 @@ type PointExt struct {
 // New creates a new HeliosDac instance.
 func NewDAC() *DAC {
 	return &DAC{
-		handle: C.HeliosDac_New(),
+		lib:    newLibrary(),
+		levels: newLevels(),
 	}
 }
 
-// Close releases the underlying C++ instance.
+// Close releases the underlying C++ instance. Frame writes after Close
+// return ErrDeviceClosed.
 func (d *DAC) Close() {
-	if d.handle != nil {
-		C.HeliosDac_Delete(d.handle)
-		d.handle = nil
-	}
+	// The watchdog may be waiting for a device, so it must be done before
+	// the instance is released.
+	<-d.watchdog.stop()
+	d.gate.close()
+	defer d.lockAll()()
+	d.lib.close()
 }
 
 // OpenDevices scans for and opens connected devices.
 // Returns the number of devices found.
 func (d *DAC) OpenDevices() int {
-	return int(C.HeliosDac_OpenDevices(d.handle))
+	return d.scan(d.lib.openDevices)
 }
 
 // OpenDevicesOnlyUsb scans for and opens only USB devices.
 func (d *DAC) OpenDevicesOnlyUsb() int {
-	return int(C.HeliosDac_OpenDevicesOnlyUsb(d.handle))
+	return d.scan(d.lib.openDevicesOnlyUsb)
 }
 
 // OpenDevicesOnlyNetwork scans for and opens only network devices.
 func (d *DAC) OpenDevicesOnlyNetwork() int {
-	return int(C.HeliosDac_OpenDevicesOnlyNetwork(d.handle))
+	return d.scan(d.lib.openDevicesOnlyNetwork)
 }
 
 // ReScanDevices scans for new devices (preserves existing connections).
 func (d *DAC) ReScanDevices() int {
-	return int(C.HeliosDac_ReScanDevices(d.handle))
+	return d.scan(d.lib.reScanDevices)
 }
 
 // ReScanDevicesOnlyUsb scans for new USB devices.
 func (d *DAC) ReScanDevicesOnlyUsb() int {
-	return int(C.HeliosDac_ReScanDevicesOnlyUsb(d.handle))
+	return d.scan(d.lib.reScanDevicesOnlyUsb)
 }
 
 // ReScanDevicesOnlyNetwork scans for new network devices.
 func (d *DAC) ReScanDevicesOnlyNetwork() int {
-	return int(C.HeliosDac_ReScanDevicesOnlyNetwork(d.handle))
+	return d.scan(d.lib.reScanDevicesOnlyNetwork)
+}
+
+// NetworkDevice identifies one service of an IDN network DAC, such as one
+// found by the x/idn package, to open with OpenNetworkDevice.
+type NetworkDevice struct {
+	// Addr is the device's IPv4 address.
+	Addr netip.Addr
+
+	// ServiceID is the IDN service to stream to.
+	ServiceID uint8
+
+	// Name is the name GetName returns. Empty uses the address.
+	Name string
+
+	// UnitID is the device's IDN unit ID, which recognizes it when
+	// rescanning. Zero if unknown.
+	UnitID [16]byte
+}
+
+// OpenNetworkDevice opens one network device without scanning, keeping
+// the devices already open, and returns its device index. The address is
+// not checked to answer as a device. If the service is already open, its
+// index is returned and the open hook is not called. Builds with the
+// helios_purego tag return ErrNotSupported.
+func (d *DAC) OpenNetworkDevice(dev NetworkDevice) (int, error) {
+	if !dev.Addr.Is4() {
+		return -1, errors.New("helios: network devices need an IPv4 address")
+	}
+	var unitID *[16]byte
+	if dev.UnitID != ([16]byte{}) {
+		unitID = &dev.UnitID
+	}
+
+	unlock := d.lockAll()
+	known := d.numDevices
+	i := d.lib.openNetworkDevice(dev.Addr.String(), dev.Name, dev.ServiceID, unitID)
+	if i >= known {
+		d.numDevices = i + 1
+	}
+	unlock()
+	if i < 0 {
+		return -1, Error(i)
+	}
+	if hook := d.openHook.Load(); hook != nil && i >= known {
+		(*hook)(i)
+	}
+	return i, nil
 }
 
 // CloseDevices closes all opened devices.
 func (d *DAC) CloseDevices() {
-	C.HeliosDac_CloseDevices(d.handle)
+	defer d.lockAll()()
+	d.lib.closeDevices()
+	d.numDevices = 0
+}
+
+// NumDevices returns the number of devices found by the last scan.
+func (d *DAC) NumDevices() int {
+	d.locks.scan.RLock()
+	defer d.locks.scan.RUnlock()
+	return d.numDevices
+}
+
+// scan runs a scan holding all device locks, and then calls the open hook
+// for the devices found.
+func (d *DAC) scan(f func() int) int {
+	unlock := d.lockAll()
+	n := d.setNumDevices(f())
+	unlock()
+	if hook := d.openHook.Load(); hook != nil {
+		for i := range max(n, 0) {
+			(*hook)(i)
+		}
+	}
+	return n
+}
+
+func (d *DAC) setNumDevices(n int) int {
+	if n < 0 {
+		d.numDevices = 0
+	} else {
+		d.numDevices = n
+	}
+	return n
 }
 
 // GetStatus returns the status of the device.
 // 1 means ready for next frame.
 func (d *DAC) GetStatus(deviceIndex int) int {
-	return int(C.HeliosDac_GetStatus(d.handle, C.int(deviceIndex)))
+	defer d.lockDevice(deviceIndex)()
+	return d.status(deviceIndex)
 }
 
+// status is GetStatus for callers holding the device lock.
+func (d *DAC) status(deviceIndex int) int {
+	return d.lib.getStatus(deviceIndex)
+}
+
+// Flags for the WriteFrame methods, mirroring HELIOS_FLAGS_* of the C++ SDK.
+const (
+	FlagStartImmediately = 1 // Interrupt the current frame instead of waiting for it to finish.
+	FlagSingleMode       = 2 // Play the frame once instead of repeating it.
+	FlagDontBlock        = 4 // Return immediately instead of waiting for the transfer.
+
+	FlagsDefault = FlagSingleMode
+)
+
 // WriteFrame sends a standard frame (8-bit colors, 12-bit XY) to the device.
 func (d *DAC) WriteFrame(deviceIndex int, pps int, flags int, points []Point) int {
+	if !d.gate.enter() {
+		return int(ErrDeviceClosed)
+	}
+	defer d.gate.leave()
+	d.watchdog.touch(deviceIndex)
+	return d.writeFrame(deviceIndex, pps, flags, points, FrameMeta{})
+}
+
+func (d *DAC) writeFrame(deviceIndex int, pps int, flags int, points []Point, meta FrameMeta) int {
+	result, _ := d.writeFrameChecked(deviceIndex, pps, flags, points, meta)
+	return result
+}
+
+// writeFrameChecked is writeFrame also returning the *FrameError of a
+// frame rejected by validation.
+func (d *DAC) writeFrameChecked(deviceIndex int, pps int, flags int, points []Point, meta FrameMeta) (int, *FrameError) {
 	if len(points) == 0 {
-		return 0
+		return d.writeEmpty(deviceIndex, pps, flags, meta), nil
 	}
-	return int(C.HeliosDac_WriteFrame(
-		d.handle,
-		C.int(deviceIndex),
-		C.int(pps),
-		C.int(flags),
-		(*C.WrapperHeliosPoint)(unsafe.Pointer(&points[0])),
-		C.int(len(points)),
-	))
+	unlock := d.lockDevice(deviceIndex)
+	n, pps, result, ferr := d.writeFrameLocked(deviceIndex, pps, flags, points)
+	unlock()
+	d.recordWrite(deviceIndex, n, pps, result, meta)
+	return result, ferr
+}
+
+// writeFrameLocked is writeFrame for callers holding the device lock. It
+// returns the number of points written, the rate they were written at, the
+// result and, for a frame rejected by validation, why.
+func (d *DAC) writeFrameLocked(deviceIndex int, pps int, flags int, points []Point) (int, int, int, *FrameError) {
+	points, pps, chunk, err := d.prepareFrame(points, pps, d.frameLimits(deviceIndex), d.levels.scale(deviceIndex), d.levels.attenuationMap(deviceIndex), d.levels.margin(deviceIndex), d.levels.colorMap(deviceIndex), d.levels.slewLimit(deviceIndex), nil)
+	if err != nil {
+		ferr := err.(*FrameError)
+		return 0, pps, int(ferr.Code), ferr
+	}
+	result := writeSplit(points, chunk, pps, flags, func() int { return d.status(deviceIndex) }, func(points []Point, flags int) int {
+		return d.lib.writeFrame(deviceIndex, pps, flags, points)
+	})
+	return len(points), pps, result, nil
 }
 
 // WriteFrameHighResolution sends a high-resolution frame to the device.
 // Uses 16-bit XY and RGB. Intensity is ignored.
 func (d *DAC) WriteFrameHighResolution(deviceIndex int, pps int, flags int, points []PointHighRes) int {
+	if !d.gate.enter() {
+		return int(ErrDeviceClosed)
+	}
+	defer d.gate.leave()
+	d.watchdog.touch(deviceIndex)
 	if len(points) == 0 {
-		return 0
+		return d.writeEmpty(deviceIndex, pps, flags, FrameMeta{})
+	}
+	unlock := d.lockDevice(deviceIndex)
+	n, pps, result := d.writeFrameHighResolutionLocked(deviceIndex, pps, flags, points)
+	unlock()
+	d.recordWrite(deviceIndex, n, pps, result, FrameMeta{})
+	return result
+}
+
+// writeFrameHighResolutionLocked is WriteFrameHighResolution for callers
+// holding the device lock, returning as writeFrameLocked does.
+func (d *DAC) writeFrameHighResolutionLocked(deviceIndex int, pps int, flags int, points []PointHighRes) (int, int, int) {
+	limits := d.frameLimits(deviceIndex)
+	if d.AdaptFrames() {
+		points, pps = adaptFrame(points, pps, limits)
+	}
+	points = slewPointsHighRes(points, d.levels.slewLimit(deviceIndex))
+	limits, chunk := d.splitLimits(limits, len(points))
+	if d.Validation() != ValidateOff {
+		if err := checkFrame(len(points), pps, limits); err != nil {
+			return 0, pps, int(err.(*FrameError).Code)
+		}
 	}
-	return int(C.HeliosDac_WriteFrameHighResolution(
-		d.handle,
-		C.int(deviceIndex),
-		C.int(pps),
-		C.int(flags),
-		(*C.WrapperHeliosPointHighRes)(unsafe.Pointer(&points[0])),
-		C.int(len(points)),
-	))
+	points = scalePointsHighRes(points, d.levels.scale(deviceIndex))
+	points = attenuatePointsHighRes(points, d.levels.attenuationMap(deviceIndex))
+	points = marginPointsHighRes(points, d.levels.margin(deviceIndex))
+	points = colorMapPointsHighRes(points, d.levels.colorMap(deviceIndex))
+	result := writeSplit(points, chunk, pps, flags, func() int { return d.status(deviceIndex) }, func(points []PointHighRes, flags int) int {
+		return d.lib.writeFrameHighResolution(deviceIndex, pps, flags, points)
+	})
+	return len(points), pps, result
 }
 
 // WriteFrameExtended sends an extended frame to the device.
-// Uses all fields including Intensity and User fields.
+// Uses all fields including Intensity and User fields. User ports driven by
+// the device's accessories are filled in; see SetAccessories.
 func (d *DAC) WriteFrameExtended(deviceIndex int, pps int, flags int, points []PointExt) int {
+	if !d.gate.enter() {
+		return int(ErrDeviceClosed)
+	}
+	defer d.gate.leave()
+	d.watchdog.touch(deviceIndex)
 	if len(points) == 0 {
-		return 0
+		return d.writeEmpty(deviceIndex, pps, flags, FrameMeta{})
+	}
+	unlock := d.lockDevice(deviceIndex)
+	n, pps, result := d.writeFrameExtendedLocked(deviceIndex, pps, flags, points)
+	unlock()
+	d.recordWrite(deviceIndex, n, pps, result, FrameMeta{})
+	return result
+}
+
+// writeFrameExtendedLocked is WriteFrameExtended for callers holding the
+// device lock, returning as writeFrameLocked does.
+func (d *DAC) writeFrameExtendedLocked(deviceIndex int, pps int, flags int, points []PointExt) (int, int, int) {
+	limits := d.frameLimits(deviceIndex)
+	if d.AdaptFrames() {
+		points, pps = adaptFrame(points, pps, limits)
+	}
+	points = slewPointsExt(points, d.levels.slewLimit(deviceIndex))
+	limits, chunk := d.splitLimits(limits, len(points))
+	if d.Validation() != ValidateOff {
+		if err := checkFrame(len(points), pps, limits); err != nil {
+			return 0, pps, int(err.(*FrameError).Code)
+		}
 	}
-	return int(C.HeliosDac_WriteFrameExtended(
-		d.handle,
-		C.int(deviceIndex),
-		C.int(pps),
-		C.int(flags),
-		(*C.WrapperHeliosPointExt)(unsafe.Pointer(&points[0])),
-		C.int(len(points)),
-	))
+	points = scalePointsExt(points, d.levels.scale(deviceIndex))
+	points = attenuatePointsExt(points, d.levels.attenuationMap(deviceIndex))
+	points = marginPointsExt(points, d.levels.margin(deviceIndex))
+	points = colorMapPointsExt(points, d.levels.colorMap(deviceIndex))
+	points = fillAccessories(points, d.levels.deviceAccessories(deviceIndex))
+	result := writeSplit(points, chunk, pps, flags, func() int { return d.status(deviceIndex) }, func(points []PointExt, flags int) int {
+		return d.lib.writeFrameExtended(deviceIndex, pps, flags, points)
+	})
+	return len(points), pps, result
 }
 
 // GetName retrieves the name of the device.
 func (d *DAC) GetName(deviceIndex int) string {
-	buf := make([]byte, 32)
-	C.HeliosDac_GetName(d.handle, C.int(deviceIndex), (*C.char)(unsafe.Pointer(&buf[0])), C.int(len(buf)))
-	return C.GoString((*C.char)(unsafe.Pointer(&buf[0])))
+	defer d.lockDevice(deviceIndex)()
+	return d.lib.getName(deviceIndex)
 }
 
 // GetFirmwareVersion retrieves the firmware version.
 func (d *DAC) GetFirmwareVersion(deviceIndex int) int {
-	return int(C.HeliosDac_GetFirmwareVersion(d.handle, C.int(deviceIndex)))
+	defer d.lockDevice(deviceIndex)()
+	return d.lib.getFirmwareVersion(deviceIndex)
 }
 
 // GetSupportsHigherResolutions checks if the device supports high resolution data.
 func (d *DAC) GetSupportsHigherResolutions(deviceIndex int) int {
-	return int(C.HeliosDac_GetSupportsHigherResolutions(d.handle, C.int(deviceIndex)))
+	defer d.lockDevice(deviceIndex)()
+	return d.lib.getSupportsHigherResolutions(deviceIndex)
 }
 
 // GetIsUsb checks if the device is connected via USB.
 func (d *DAC) GetIsUsb(deviceIndex int) bool {
-	return bool(C.HeliosDac_GetIsUsb(d.handle, C.int(deviceIndex)))
+	defer d.lockDevice(deviceIndex)()
+	return d.lib.getIsUsb(deviceIndex)
 }
 
 // GetIsClosed checks if the device is closed.
 func (d *DAC) GetIsClosed(deviceIndex int) bool {
-	return bool(C.HeliosDac_GetIsClosed(d.handle, C.int(deviceIndex)))
+	defer d.lockDevice(deviceIndex)()
+	return d.lib.getIsClosed(deviceIndex)
 }
 
 // SetName sets the name of the device.
 func (d *DAC) SetName(deviceIndex int, name string) int {
-	cName := C.CString(name)
-	defer C.free(unsafe.Pointer(cName))
-	return int(C.HeliosDac_SetName(d.handle, C.int(deviceIndex), cName))
+	defer d.lockDevice(deviceIndex)()
+	return d.lib.setName(deviceIndex, name)
 }
 
 // Stop stops output of DAC until new frame is written.
 // Blocks for 100ms.
 func (d *DAC) Stop(deviceIndex int) int {
-	return int(C.HeliosDac_Stop(d.handle, C.int(deviceIndex)))
+	defer d.lockDevice(deviceIndex)()
+	d.levels.restart(deviceIndex)
+	d.stats.stop(deviceIndex)
+	return d.lib.stop(deviceIndex)
 }
 
 // SetShutter sets the shutter level of the DAC.
 // true = open, false = closed.
 func (d *DAC) SetShutter(deviceIndex int, level bool) int {
-	return int(C.HeliosDac_SetShutter(d.handle, C.int(deviceIndex), C.bool(level)))
+	defer d.lockDevice(deviceIndex)()
+	return d.lib.setShutter(deviceIndex, level)
 }
 
 // EraseFirmware erases the firmware of the DAC.
 // Advanced use only.
 func (d *DAC) EraseFirmware(deviceIndex int) int {
-	return int(C.HeliosDac_EraseFirmware(d.handle, C.int(deviceIndex)))
+	defer d.lockDevice(deviceIndex)()
+	return d.lib.eraseFirmware(deviceIndex)
 }
 
 // SetLibusbDebugLogLevel sets the debug log level for libusb.
 func (d *DAC) SetLibusbDebugLogLevel(logLevel int) int {
-	return int(C.HeliosDac_SetLibusbDebugLogLevel(d.handle, C.int(logLevel)))
+	defer d.lockAll()()
+	return d.lib.setLibusbDebugLogLevel(logLevel)
 }
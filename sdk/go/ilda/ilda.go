@@ -0,0 +1,148 @@
+// Package ilda reads ILDA Image Data Transfer Format (.ild) files.
+//
+// Formats 0 and 1 (indexed color, 3D and 2D), 4 and 5 (true color, 3D and
+// 2D) and color palettes (format 2) are supported. Z coordinates are
+// dropped. Indexed frames use the most recent palette in the file, or the
+// ILDA default palette if the file has none.
+package ilda
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+// Frame is one frame of an ILDA file.
+type Frame struct {
+	Name    string
+	Company string
+	Points  []helios.Point
+}
+
+// statusBlanked is the blanking bit of a point record's status byte.
+const statusBlanked = 0x40
+
+// Read reads all frames of an ILDA file. Reading stops at the end-of-file
+// header (a header with no records) or at the end of r.
+func Read(r io.Reader) ([]Frame, error) {
+	palette := DefaultPalette
+	var frames []Frame
+	for {
+		var h header
+		if err := binary.Read(r, binary.BigEndian, &h); err != nil {
+			if errors.Is(err, io.EOF) {
+				return frames, nil
+			}
+			return frames, fmt.Errorf("ilda: header: %w", err)
+		}
+		if string(h.Magic[:]) != "ILDA" {
+			return frames, fmt.Errorf("ilda: bad magic %q", h.Magic[:])
+		}
+		if h.Records == 0 {
+			return frames, nil
+		}
+		if h.Format == 2 {
+			p, err := readPalette(r, int(h.Records))
+			if err != nil {
+				return frames, err
+			}
+			palette = p
+			continue
+		}
+		points, err := readPoints(r, h.Format, int(h.Records), palette)
+		if err != nil {
+			return frames, err
+		}
+		frames = append(frames, Frame{
+			Name:    trim(h.Name[:]),
+			Company: trim(h.Company[:]),
+			Points:  points,
+		})
+	}
+}
+
+type header struct {
+	Magic     [4]byte
+	_         [3]byte
+	Format    uint8
+	Name      [8]byte
+	Company   [8]byte
+	Records   uint16
+	Number    uint16
+	Total     uint16
+	Projector uint8
+	_         uint8
+}
+
+func readPalette(r io.Reader, n int) ([][3]uint8, error) {
+	p := make([][3]uint8, n)
+	if err := binary.Read(r, binary.BigEndian, p); err != nil {
+		return nil, fmt.Errorf("ilda: palette: %w", err)
+	}
+	return p, nil
+}
+
+func readPoints(r io.Reader, format uint8, n int, palette [][3]uint8) ([]helios.Point, error) {
+	var size int
+	switch format {
+	case 0:
+		size = 8
+	case 1:
+		size = 6
+	case 4:
+		size = 10
+	case 5:
+		size = 8
+	default:
+		return nil, fmt.Errorf("ilda: unsupported format %d", format)
+	}
+	buf := make([]byte, size*n)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, fmt.Errorf("ilda: format %d records: %w", format, err)
+	}
+	points := make([]helios.Point, n)
+	for i := range points {
+		rec := buf[i*size : (i+1)*size]
+		p := helios.Point{
+			X: coord(rec[0:2]),
+			Y: coord(rec[2:4]),
+		}
+		// The status byte follows X, Y and, for 3D formats, Z.
+		rec = rec[4:]
+		if format == 0 || format == 4 {
+			rec = rec[2:]
+		}
+		status := rec[0]
+		if status&statusBlanked == 0 {
+			if format == 0 || format == 1 {
+				if int(rec[1]) < len(palette) {
+					c := palette[rec[1]]
+					p.R, p.G, p.B = c[0], c[1], c[2]
+				}
+			} else {
+				// True color records are stored blue, green, red.
+				p.B, p.G, p.R = rec[1], rec[2], rec[3]
+			}
+			p.I = 255
+		}
+		points[i] = p
+	}
+	return points, nil
+}
+
+// coord converts a signed 16-bit ILDA coordinate to the 12-bit DAC range.
+func coord(b []byte) uint16 {
+	v := int(int16(binary.BigEndian.Uint16(b)))
+	return uint16((v + 32768) >> 4)
+}
+
+func trim(b []byte) string {
+	n := len(b)
+	for n > 0 && (b[n-1] == 0 || b[n-1] == ' ') {
+		n--
+	}
+	return string(b[:n])
+}
@@ -0,0 +1,63 @@
+package ilda
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+func section(buf *bytes.Buffer, format uint8, name string, records int, data ...any) {
+	h := header{Format: format, Records: uint16(records)}
+	copy(h.Magic[:], "ILDA")
+	copy(h.Name[:], name)
+	binary.Write(buf, binary.BigEndian, h)
+	for _, d := range data {
+		binary.Write(buf, binary.BigEndian, d)
+	}
+}
+
+func TestRead(t *testing.T) {
+	var buf bytes.Buffer
+	// Format 5: X, Y, status, B, G, R.
+	section(&buf, 5, "frame1", 2,
+		int16(-32768), int16(32767), uint8(0), uint8(10), uint8(20), uint8(30),
+		int16(0), int16(0), uint8(statusBlanked|0x80), uint8(255), uint8(255), uint8(255))
+	// Palette, then a format 1 frame indexing it: X, Y, status, index.
+	section(&buf, 2, "pal", 1, [3]uint8{1, 2, 3})
+	section(&buf, 1, "frame2", 1, int16(16), int16(-16), uint8(0x80), uint8(0))
+	section(&buf, 0, "", 0)
+	buf.WriteString("trailing garbage after the end header")
+
+	frames, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) != 2 || frames[0].Name != "frame1" {
+		t.Fatalf("frames = %+v", frames)
+	}
+	want := []helios.Point{
+		{X: 0, Y: 4095, R: 30, G: 20, B: 10, I: 255},
+		{X: 2048, Y: 2048},
+	}
+	for i, p := range want {
+		if frames[0].Points[i] != p {
+			t.Errorf("frame1 point %d = %+v, want %+v", i, frames[0].Points[i], p)
+		}
+	}
+	if p := frames[1].Points[0]; p != (helios.Point{X: 2049, Y: 2047, R: 1, G: 2, B: 3, I: 255}) {
+		t.Errorf("frame2 point = %+v", p)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	var buf bytes.Buffer
+	section(&buf, 5, "short", 3, int16(0), int16(0))
+	if _, err := Read(&buf); err == nil {
+		t.Error("truncated records accepted")
+	}
+	if _, err := Read(bytes.NewReader([]byte("NOPE0000000000000000000000000000"))); err == nil {
+		t.Error("bad magic accepted")
+	}
+}
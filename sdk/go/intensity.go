@@ -0,0 +1,187 @@
+package helios
+
+import (
+	"sync"
+	"time"
+)
+
+// levels holds the master intensity, blackout, attenuation and accessory
+// state applied to every frame written, independent of frame content.
+type levels struct {
+	mu             sync.Mutex
+	master         float64
+	blackout       bool
+	device         map[int]float64
+	deviceBlackout map[int]bool
+	duck           duck
+	attenuation    map[int]*AttenuationMap
+	margins        map[int]Margin
+	colorMaps      map[int]ColorMap
+	slewLimits     map[int]float64
+	accessories    map[int][]Accessory
+	softStart      time.Duration
+	started        map[int]time.Time // when each device's output last started
+	now            func() time.Time
+}
+
+func newLevels() levels {
+	return levels{
+		master:         1,
+		device:         make(map[int]float64),
+		deviceBlackout: make(map[int]bool),
+		started:        make(map[int]time.Time),
+		duck:           duck{settings: DefaultDuckSettings},
+		now:            time.Now,
+	}
+}
+
+// scale returns the color multiplier for a frame written to a device,
+// starting its soft start if its output is starting.
+func (l *levels) scale(deviceIndex int) float64 {
+	return l.level(deviceIndex, true)
+}
+
+// peek is scale for frames that are not written.
+func (l *levels) peek(deviceIndex int) float64 {
+	return l.level(deviceIndex, false)
+}
+
+func (l *levels) level(deviceIndex int, write bool) float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.blackout || l.deviceBlackout[deviceIndex] {
+		return 0
+	}
+	now := l.now()
+	s := l.master
+	if l.duck.active {
+		s *= l.duck.factor(now)
+	}
+	if v, ok := l.device[deviceIndex]; ok {
+		s *= v
+	}
+	start, ok := l.started[deviceIndex]
+	if !ok {
+		start = now
+		if write {
+			l.started[deviceIndex] = now
+		}
+	}
+	return s * ramp(now.Sub(start), l.softStart)
+}
+
+func clampLevel(level float64) float64 {
+	return max(0, min(level, 1))
+}
+
+// SetMasterIntensity scales the color of every frame written to any device
+// (0.0 - 1.0). It multiplies with the per-device intensity and takes effect
+// from the next frame written.
+func (d *DAC) SetMasterIntensity(level float64) {
+	d.levels.mu.Lock()
+	defer d.levels.mu.Unlock()
+	d.levels.master = clampLevel(level)
+}
+
+// MasterIntensity returns the global intensity.
+func (d *DAC) MasterIntensity() float64 {
+	d.levels.mu.Lock()
+	defer d.levels.mu.Unlock()
+	return d.levels.master
+}
+
+// SetDeviceIntensity scales the color of frames written to one device
+// (0.0 - 1.0).
+func (d *DAC) SetDeviceIntensity(deviceIndex int, level float64) {
+	d.levels.mu.Lock()
+	defer d.levels.mu.Unlock()
+	d.levels.device[deviceIndex] = clampLevel(level)
+}
+
+// DeviceIntensity returns the intensity of one device, not including the
+// master intensity.
+func (d *DAC) DeviceIntensity(deviceIndex int) float64 {
+	d.levels.mu.Lock()
+	defer d.levels.mu.Unlock()
+	if v, ok := d.levels.device[deviceIndex]; ok {
+		return v
+	}
+	return 1
+}
+
+// SetBlackout blanks (true) or restores (false) the output of every device.
+// Point positions are still written, so scanning continues while dark.
+// Unlike SetShutter it works on all firmware versions and takes effect from
+// the next frame written.
+func (d *DAC) SetBlackout(on bool) {
+	d.levels.mu.Lock()
+	defer d.levels.mu.Unlock()
+	d.levels.blackout = on
+	if on {
+		clear(d.levels.started)
+	}
+}
+
+// Blackout reports whether the global blackout is on.
+func (d *DAC) Blackout() bool {
+	d.levels.mu.Lock()
+	defer d.levels.mu.Unlock()
+	return d.levels.blackout
+}
+
+// SetDeviceBlackout blanks or restores the output of one device.
+func (d *DAC) SetDeviceBlackout(deviceIndex int, on bool) {
+	d.levels.mu.Lock()
+	defer d.levels.mu.Unlock()
+	d.levels.deviceBlackout[deviceIndex] = on
+	if on {
+		delete(d.levels.started, deviceIndex)
+	}
+}
+
+// DeviceBlackout reports whether one device is blacked out, not including
+// the global blackout.
+func (d *DAC) DeviceBlackout(deviceIndex int) bool {
+	d.levels.mu.Lock()
+	defer d.levels.mu.Unlock()
+	return d.levels.deviceBlackout[deviceIndex]
+}
+
+// The scale functions return points unchanged at full intensity, and a
+// scaled copy otherwise; the caller's slice is never modified.
+
+func scalePoints(points []Point, s float64) []Point {
+	if s >= 1 {
+		return points
+	}
+	out := make([]Point, len(points))
+	for i, p := range points {
+		p.R, p.G, p.B, p.I = ScaleColor(p.R, s), ScaleColor(p.G, s), ScaleColor(p.B, s), ScaleColor(p.I, s)
+		out[i] = p
+	}
+	return out
+}
+
+func scalePointsHighRes(points []PointHighRes, s float64) []PointHighRes {
+	if s >= 1 {
+		return points
+	}
+	out := make([]PointHighRes, len(points))
+	for i, p := range points {
+		p.R, p.G, p.B = ScaleColor16(p.R, s), ScaleColor16(p.G, s), ScaleColor16(p.B, s)
+		out[i] = p
+	}
+	return out
+}
+
+func scalePointsExt(points []PointExt, s float64) []PointExt {
+	if s >= 1 {
+		return points
+	}
+	out := make([]PointExt, len(points))
+	for i, p := range points {
+		p.R, p.G, p.B, p.I = ScaleColor16(p.R, s), ScaleColor16(p.G, s), ScaleColor16(p.B, s), ScaleColor16(p.I, s)
+		out[i] = p
+	}
+	return out
+}
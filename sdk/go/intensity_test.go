@@ -0,0 +1,36 @@
+package helios
+
+import "testing"
+
+func TestLevels(t *testing.T) {
+	d := &DAC{levels: newLevels()}
+	d.SetMasterIntensity(0.5)
+	d.SetDeviceIntensity(1, 0.5)
+
+	frame := []Point{{X: 100, R: 255, G: 100, I: 255}}
+	if got := scalePoints(frame, d.levels.scale(0)); got[0].R != 128 || got[0].G != 50 || got[0].X != 100 {
+		t.Fatalf("device 0 = %+v", got[0])
+	}
+	if got := scalePoints(frame, d.levels.scale(1)); got[0].R != 64 {
+		t.Fatalf("device 1 R = %d, want 64", got[0].R)
+	}
+	if frame[0].R != 255 {
+		t.Fatal("scaling modified the caller's frame")
+	}
+
+	d.SetDeviceBlackout(1, true)
+	if d.levels.scale(1) != 0 || d.levels.scale(0) != 0.5 {
+		t.Fatal("device blackout affected the wrong device")
+	}
+	d.SetBlackout(true)
+	ext := scalePointsExt([]PointExt{{R: 65535, User1: 7}}, d.levels.scale(0))
+	if ext[0].R != 0 || ext[0].User1 != 7 {
+		t.Fatalf("blackout ext point = %+v", ext[0])
+	}
+
+	d.SetBlackout(false)
+	d.SetMasterIntensity(2)
+	if d.MasterIntensity() != 1 {
+		t.Fatalf("master intensity not clamped: %v", d.MasterIntensity())
+	}
+}
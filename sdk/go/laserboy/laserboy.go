@@ -0,0 +1,257 @@
+// Package laserboy reads the frames of LaserBoy text files (.txt), the
+// plain-text interchange format LaserBoy and tools built on it export
+// ILDA content to, so frame libraries kept in it can be played without a
+// round trip through the editor.
+//
+// A file is a sequence of sections, each a header line followed by one
+// line per point or color; '#' starts a comment. Frame sections are read:
+//
+//	frame xy rgb short      # dimensions, color mode, coordinate range
+//	-32768  -32768  255 0 0
+//	 32767   32767  blank
+//
+// Frames are xy or xyz, with Z dropped. Colors are rgb (three values),
+// hex (0xRRGGBB) or palette (an index into the most recent palette
+// section, or the ILDA default palette). Coordinates are short, signed
+// 16-bit as in ILDA files, or unit, -1 to 1. A point is blanked by the
+// word blank in place of or after its color, or by a hex color of -1.
+// Palette sections, "palette rgb" or "palette hex", list one color per
+// line. Other sections, such as color tables, are skipped, as are header
+// words other than these.
+package laserboy
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/ilda"
+)
+
+// section is the format of the points of a frame section.
+type section struct {
+	dims   int    // 2 or 3
+	color  string // "rgb", "hex" or "palette"
+	unit   bool   // coordinates are -1 to 1
+	points []helios.Point
+}
+
+// Read reads the frames of a LaserBoy text file, in file order, as ILDA
+// frames without names.
+func Read(r io.Reader) ([]ilda.Frame, error) {
+	var frames []ilda.Frame
+	palette := ilda.DefaultPalette
+	var frame *section
+	var colors [][3]uint8 // the palette section being read
+	inPalette, hexPalette := false, false
+	endSection := func() {
+		if frame != nil {
+			frames = append(frames, ilda.Frame{Points: frame.points})
+			frame = nil
+		}
+		if inPalette {
+			if len(colors) > 0 {
+				palette = colors
+			}
+			colors, inPalette = nil, false
+		}
+	}
+
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		fields := strings.Fields(stripComment(s.Text()))
+		if len(fields) == 0 {
+			continue
+		}
+		if isNumber(fields[0]) {
+			switch {
+			case frame != nil:
+				p, err := frame.parsePoint(fields, palette)
+				if err != nil {
+					return frames, fmt.Errorf("laserboy: line %d: %w", n, err)
+				}
+				frame.points = append(frame.points, p)
+			case inPalette:
+				c, err := parsePaletteColor(fields, hexPalette)
+				if err != nil {
+					return frames, fmt.Errorf("laserboy: line %d: %w", n, err)
+				}
+				colors = append(colors, c)
+			}
+			continue
+		}
+		endSection()
+		switch strings.ToLower(fields[0]) {
+		case "frame":
+			f, err := parseFrameHeader(fields[1:])
+			if err != nil {
+				return frames, fmt.Errorf("laserboy: line %d: %w", n, err)
+			}
+			frame = f
+		case "palette":
+			inPalette = true
+			hexPalette = len(fields) > 1 && strings.EqualFold(fields[1], "hex")
+		}
+	}
+	if err := s.Err(); err != nil {
+		return frames, fmt.Errorf("laserboy: %w", err)
+	}
+	endSection()
+	return frames, nil
+}
+
+// Detect reports whether b looks like a LaserBoy text file: its first line
+// that is not blank or a comment starts a frame or palette section.
+func Detect(b []byte) bool {
+	for len(b) > 0 {
+		line := b
+		if i := bytes.IndexByte(b, '\n'); i >= 0 {
+			line, b = b[:i], b[i+1:]
+		} else {
+			b = nil
+		}
+		fields := strings.Fields(stripComment(string(line)))
+		if len(fields) == 0 {
+			continue
+		}
+		k := strings.ToLower(fields[0])
+		return k == "frame" || k == "palette"
+	}
+	return false
+}
+
+func parseFrameHeader(words []string) (*section, error) {
+	f := &section{dims: 2, color: "rgb"}
+	for _, w := range words {
+		switch w = strings.ToLower(w); w {
+		case "xy":
+			f.dims = 2
+		case "xyz":
+			f.dims = 3
+		case "rgb", "hex", "palette":
+			f.color = w
+		case "short":
+			f.unit = false
+		case "unit":
+			f.unit = true
+		case "table":
+			return nil, fmt.Errorf("color table frames are not supported")
+		}
+	}
+	return f, nil
+}
+
+func (f *section) parsePoint(fields []string, palette [][3]uint8) (helios.Point, error) {
+	if len(fields) < f.dims {
+		return helios.Point{}, fmt.Errorf("point has %d coordinates, want %d", len(fields), f.dims)
+	}
+	var p helios.Point
+	for i, v := range []*uint16{&p.X, &p.Y} {
+		c, err := f.coord(fields[i])
+		if err != nil {
+			return p, err
+		}
+		*v = c
+	}
+	color := fields[f.dims:]
+	if n := len(color); n > 0 && strings.EqualFold(color[n-1], "blank") {
+		return p, nil
+	}
+	switch f.color {
+	case "rgb":
+		if len(color) != 3 {
+			return p, fmt.Errorf("rgb point has %d color values", len(color))
+		}
+		for i, v := range []*uint8{&p.R, &p.G, &p.B} {
+			c, err := strconv.ParseUint(color[i], 10, 8)
+			if err != nil {
+				return p, fmt.Errorf("color %q: %w", color[i], err)
+			}
+			*v = uint8(c)
+		}
+	case "hex":
+		if len(color) != 1 {
+			return p, fmt.Errorf("hex point has %d color values", len(color))
+		}
+		if color[0] == "-1" {
+			return p, nil
+		}
+		c, err := parseHex(color[0])
+		if err != nil {
+			return p, err
+		}
+		p.R, p.G, p.B = c[0], c[1], c[2]
+	case "palette":
+		if len(color) != 1 {
+			return p, fmt.Errorf("palette point has %d color values", len(color))
+		}
+		i, err := strconv.Atoi(color[0])
+		if err != nil || i < 0 || i >= len(palette) {
+			return p, fmt.Errorf("palette index %q out of range", color[0])
+		}
+		c := palette[i]
+		p.R, p.G, p.B = c[0], c[1], c[2]
+	}
+	p.I = 0xFF
+	return p, nil
+}
+
+// coord converts a coordinate to the 12-bit DAC range.
+func (f *section) coord(s string) (uint16, error) {
+	if f.unit {
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, fmt.Errorf("coordinate %q: %w", s, err)
+		}
+		return helios.ClampToCoord((v + 1) / 2 * 0xFFF), nil
+	}
+	v, err := strconv.ParseInt(s, 10, 16)
+	if err != nil {
+		return 0, fmt.Errorf("coordinate %q: %w", s, err)
+	}
+	return uint16((v + 32768) >> 4), nil
+}
+
+func parsePaletteColor(fields []string, hex bool) ([3]uint8, error) {
+	if hex {
+		return parseHex(fields[0])
+	}
+	var c [3]uint8
+	if len(fields) < 3 {
+		return c, fmt.Errorf("palette color has %d values", len(fields))
+	}
+	for i := range c {
+		v, err := strconv.ParseUint(fields[i], 10, 8)
+		if err != nil {
+			return c, fmt.Errorf("color %q: %w", fields[i], err)
+		}
+		c[i] = uint8(v)
+	}
+	return c, nil
+}
+
+func parseHex(s string) ([3]uint8, error) {
+	v, err := strconv.ParseUint(strings.TrimPrefix(strings.ToLower(s), "0x"), 16, 24)
+	if err != nil {
+		return [3]uint8{}, fmt.Errorf("hex color %q: %w", s, err)
+	}
+	return [3]uint8{uint8(v >> 16), uint8(v >> 8), uint8(v)}, nil
+}
+
+func stripComment(line string) string {
+	if i := strings.IndexByte(line, '#'); i >= 0 {
+		return line[:i]
+	}
+	return line
+}
+
+// isNumber reports whether a field starts a data line rather than a
+// section header.
+func isNumber(s string) bool {
+	c := s[0]
+	return c >= '0' && c <= '9' || c == '-' || c == '+' || c == '.'
+}
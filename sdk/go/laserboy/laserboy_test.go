@@ -0,0 +1,96 @@
+package laserboy
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/ilda"
+)
+
+const file = `# exported by LaserBoy
+frame xy rgb short
+-32768 -32768 blank
+-32768 -32768 255 0 0
+ 32767  32767 0 255 0   # corner
+
+frame xyz hex unit
+-1 1 0.5 0xff8000
+ 0 0 0   -1
+ 1 -1 0  0x0000ff blank
+
+palette rgb
+10 20 30
+40 50 60
+
+frame xy palette
+0 0 1
+`
+
+func TestRead(t *testing.T) {
+	frames, err := Read(strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ilda.Frame{
+		{Points: []helios.Point{
+			{X: 0, Y: 0},
+			{X: 0, Y: 0, R: 255, I: 255},
+			{X: 4095, Y: 4095, G: 255, I: 255},
+		}},
+		{Points: []helios.Point{
+			{X: 0, Y: 4095, R: 0xff, G: 0x80, I: 255},
+			{X: 2048, Y: 2048},
+			{X: 4095, Y: 0},
+		}},
+		{Points: []helios.Point{
+			{X: 2048, Y: 2048, R: 40, G: 50, B: 60, I: 255},
+		}},
+	}
+	if !reflect.DeepEqual(frames, want) {
+		t.Errorf("Read =\n%+v\nwant\n%+v", frames, want)
+	}
+}
+
+func TestReadDefaultPalette(t *testing.T) {
+	frames, err := Read(strings.NewReader("frame palette\n0 0 1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := ilda.DefaultPalette[1]
+	if p := frames[0].Points[0]; p.R != c[0] || p.G != c[1] || p.B != c[2] {
+		t.Errorf("point = %+v, want default palette color %v", p, c)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	for _, in := range []string{
+		"frame xy rgb\n0\n",
+		"frame xy rgb\n0 0 255 0\n",
+		"frame xy rgb\n0 0 256 0 0\n",
+		"frame xy short\n40000 0 0 0 0\n",
+		"frame xy hex\n0 0 red\n",
+		"frame xy palette\n0 0 9999\n",
+		"frame xy table\n",
+		"palette rgb\n1 2\n",
+	} {
+		if _, err := Read(strings.NewReader(in)); err == nil {
+			t.Errorf("no error for %q", in)
+		}
+	}
+}
+
+func TestDetect(t *testing.T) {
+	for in, want := range map[string]bool{
+		file:                     true,
+		"\n  # x\nPALETTE hex\n": true,
+		"notes about the show\n": false,
+		"":                       false,
+		"# only a comment":       false,
+	} {
+		if got := Detect([]byte(in)); got != want {
+			t.Errorf("Detect(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
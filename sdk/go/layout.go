@@ -0,0 +1,171 @@
+package helios
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"unsafe"
+)
+
+// The point structures have the memory layout of the C SDK's HeliosPoint,
+// HeliosPointHighRes and HeliosPointExt: the fields in declaration order,
+// in native byte order, with no padding. Frames are passed to the SDK
+// without copying, so the layout is part of the API; the assertions below
+// fail to compile if it changes, and library_cgo.go checks it against the C
+// headers. PointBytes, PointsFromBytes and CastPoints use the guarantee to
+// convert whole frames without touching each point.
+
+// Sizes of the point structures in bytes.
+const (
+	PointSize        = 8
+	PointHighResSize = 10
+	PointExtSize     = 20
+)
+
+// Each assertion indexes a one-element array with the difference between
+// two constants, which fails to compile unless they are equal.
+var (
+	_ = [1]struct{}{}[unsafe.Sizeof(Point{})-PointSize]
+	_ = [1]struct{}{}[PointSize-unsafe.Sizeof(Point{})]
+	_ = [1]struct{}{}[unsafe.Offsetof(Point{}.R)-4]
+	_ = [1]struct{}{}[unsafe.Offsetof(Point{}.I)-7]
+	_ = [1]struct{}{}[unsafe.Alignof(Point{})-2]
+
+	_ = [1]struct{}{}[unsafe.Sizeof(PointHighRes{})-PointHighResSize]
+	_ = [1]struct{}{}[PointHighResSize-unsafe.Sizeof(PointHighRes{})]
+	_ = [1]struct{}{}[unsafe.Offsetof(PointHighRes{}.B)-8]
+	_ = [1]struct{}{}[unsafe.Alignof(PointHighRes{})-2]
+
+	_ = [1]struct{}{}[unsafe.Sizeof(PointExt{})-PointExtSize]
+	_ = [1]struct{}{}[PointExtSize-unsafe.Sizeof(PointExt{})]
+	_ = [1]struct{}{}[unsafe.Offsetof(PointExt{}.I)-10]
+	_ = [1]struct{}{}[unsafe.Offsetof(PointExt{}.User4)-18]
+	_ = [1]struct{}{}[unsafe.Alignof(PointExt{})-2]
+)
+
+// PointType is the set of point structures the SDK takes frames of.
+type PointType interface {
+	Point | PointHighRes | PointExt
+}
+
+// PointBytes returns the memory of points as bytes, in the layout of the C
+// point structures, without copying. The bytes alias points, so writing one
+// changes the other.
+func PointBytes[P PointType](points []P) []byte {
+	if len(points) == 0 {
+		return nil
+	}
+	return unsafe.Slice((*byte)(unsafe.Pointer(&points[0])), len(points)*int(unsafe.Sizeof(points[0])))
+}
+
+// PointsFromBytes returns b, holding point structures in the C layout and
+// native byte order, as points without copying; the points alias b. It
+// fails if the length of b is not a whole number of points or b is not
+// aligned to 2 bytes, as data sliced at an odd offset can be; copy such
+// data into a new slice first.
+func PointsFromBytes[P PointType](b []byte) ([]P, error) {
+	size := int(unsafe.Sizeof(*new(P)))
+	if len(b)%size != 0 {
+		return nil, fmt.Errorf("helios: %d bytes are not a whole number of %d-byte points", len(b), size)
+	}
+	if len(b) == 0 {
+		return nil, nil
+	}
+	if uintptr(unsafe.Pointer(&b[0]))%unsafe.Alignof(*new(P)) != 0 {
+		return nil, errors.New("helios: point bytes are not aligned")
+	}
+	return unsafe.Slice((*P)(unsafe.Pointer(&b[0])), len(b)/size), nil
+}
+
+// CastPoints returns s, a slice of an application's own point structure, as
+// points without copying; the points alias s. T must have the layout of P:
+// the same size, and integer fields of the same sizes at the same offsets,
+// possibly grouped in nested structures or arrays, such as
+//
+//	type vertex struct {
+//		Pos   [2]uint16
+//		Color [4]uint8
+//	}
+//
+// for Point. Field names do not matter. The layout of T is checked on the
+// first call and remembered, so later calls cost no more than a slice
+// header.
+func CastPoints[P PointType, T any](s []T) ([]P, error) {
+	if err := checkLayout(reflect.TypeFor[T](), reflect.TypeFor[P]()); err != nil {
+		return nil, err
+	}
+	if len(s) == 0 {
+		return nil, nil
+	}
+	return unsafe.Slice((*P)(unsafe.Pointer(&s[0])), len(s)), nil
+}
+
+type layoutPair struct{ from, to reflect.Type }
+
+// layouts caches the result of checking a pair of types as a *layoutResult.
+var layouts sync.Map
+
+type layoutResult struct{ err error }
+
+func checkLayout(from, to reflect.Type) error {
+	key := layoutPair{from, to}
+	if r, ok := layouts.Load(key); ok {
+		return r.(*layoutResult).err
+	}
+	err := compareLayout(from, to)
+	layouts.Store(key, &layoutResult{err})
+	return err
+}
+
+func compareLayout(from, to reflect.Type) error {
+	if from.Size() != to.Size() || from.Align() < to.Align() {
+		return fmt.Errorf("helios: %v (%d bytes, aligned to %d) does not have the layout of %v (%d bytes, aligned to %d)",
+			from, from.Size(), from.Align(), to, to.Size(), to.Align())
+	}
+	got, ok := scalars(from, 0, nil)
+	want, _ := scalars(to, 0, nil)
+	if !ok || len(got) != len(want) {
+		return fmt.Errorf("helios: %v does not have the fields of %v", from, to)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			return fmt.Errorf("helios: %v does not have the fields of %v: the field at offset %d is %d bytes",
+				from, to, got[i].offset, got[i].size)
+		}
+	}
+	return nil
+}
+
+// scalar is an integer field of a point structure.
+type scalar struct {
+	offset, size uintptr
+}
+
+// scalars appends the integer fields of t, at base, flattening structures
+// and arrays. It returns false if t holds anything but unsigned integers of
+// 8 or 16 bits, which a point structure cannot map onto.
+func scalars(t reflect.Type, base uintptr, out []scalar) ([]scalar, bool) {
+	switch t.Kind() {
+	case reflect.Uint8, reflect.Uint16:
+		return append(out, scalar{base, t.Size()}), true
+	case reflect.Struct:
+		for i := range t.NumField() {
+			f := t.Field(i)
+			var ok bool
+			if out, ok = scalars(f.Type, base+f.Offset, out); !ok {
+				return nil, false
+			}
+		}
+		return out, true
+	case reflect.Array:
+		for i := range t.Len() {
+			var ok bool
+			if out, ok = scalars(t.Elem(), base+uintptr(i)*t.Elem().Size(), out); !ok {
+				return nil, false
+			}
+		}
+		return out, true
+	}
+	return nil, false
+}
@@ -0,0 +1,142 @@
+package helios
+
+import (
+	"reflect"
+	"testing"
+	"unsafe"
+)
+
+// TestPointLayout checks every field against the C structures in wrapper.h,
+// so a field added, reordered or resized, or padding introduced, fails
+// here as well as at compile time.
+func TestPointLayout(t *testing.T) {
+	tests := []struct {
+		typ     reflect.Type
+		size    uintptr
+		offsets []uintptr
+	}{
+		{reflect.TypeFor[Point](), PointSize, []uintptr{0, 2, 4, 5, 6, 7}},
+		{reflect.TypeFor[PointHighRes](), PointHighResSize, []uintptr{0, 2, 4, 6, 8}},
+		{reflect.TypeFor[PointExt](), PointExtSize, []uintptr{0, 2, 4, 6, 8, 10, 12, 14, 16, 18}},
+	}
+	for _, tt := range tests {
+		if tt.typ.Size() != tt.size {
+			t.Errorf("%v is %d bytes, want %d", tt.typ, tt.typ.Size(), tt.size)
+		}
+		if tt.typ.NumField() != len(tt.offsets) {
+			t.Errorf("%v has %d fields, want %d", tt.typ, tt.typ.NumField(), len(tt.offsets))
+			continue
+		}
+		var sum uintptr
+		for i, want := range tt.offsets {
+			f := tt.typ.Field(i)
+			if f.Offset != want {
+				t.Errorf("%v.%s at offset %d, want %d", tt.typ, f.Name, f.Offset, want)
+			}
+			sum += f.Type.Size()
+		}
+		if sum != tt.typ.Size() {
+			t.Errorf("%v has %d bytes of padding", tt.typ, tt.typ.Size()-sum)
+		}
+	}
+}
+
+func TestPointBytes(t *testing.T) {
+	points := []Point{{X: 0x102, Y: 0x304, R: 5, G: 6, B: 7, I: 8}, {X: 9}}
+	b := PointBytes(points)
+	if len(b) != 2*PointSize {
+		t.Fatalf("len = %d, want %d", len(b), 2*PointSize)
+	}
+	if b[4] != 5 || b[7] != 8 {
+		t.Errorf("colors at % x, want 05 at 4 and 08 at 7", b[:8])
+	}
+
+	back, err := PointsFromBytes[Point](b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(back, points) {
+		t.Errorf("PointsFromBytes = %v, want %v", back, points)
+	}
+	// The conversions alias the memory rather than copying it.
+	back[1].G = 42
+	if points[1].G != 42 {
+		t.Error("PointsFromBytes copied the points")
+	}
+
+	if PointBytes[PointExt](nil) != nil {
+		t.Error("PointBytes(nil) is not nil")
+	}
+}
+
+func TestPointsFromBytesErrors(t *testing.T) {
+	if _, err := PointsFromBytes[PointHighRes](make([]byte, 15)); err == nil {
+		t.Error("no error for a partial point")
+	}
+	buf := make([]uint16, 11)
+	b := unsafe.Slice((*byte)(unsafe.Pointer(&buf[0])), 22)
+	if _, err := PointsFromBytes[PointHighRes](b[1:21]); err == nil {
+		t.Error("no error for unaligned bytes")
+	}
+	if p, err := PointsFromBytes[PointHighRes](b[2:22]); err != nil || len(p) != 2 {
+		t.Errorf("PointsFromBytes(aligned) = %d points, %v", len(p), err)
+	}
+}
+
+func TestCastPoints(t *testing.T) {
+	type vertex struct {
+		Pos   [2]uint16
+		Color [4]uint8
+	}
+	vs := []vertex{{[2]uint16{1, 2}, [4]uint8{3, 4, 5, 6}}}
+	points, err := CastPoints[Point](vs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Point{1, 2, 3, 4, 5, 6}); points[0] != want {
+		t.Errorf("CastPoints = %v, want %v", points[0], want)
+	}
+
+	type ext struct {
+		XY    [2]uint16
+		RGBI  [4]uint16
+		Ports [4]uint16
+	}
+	if _, err := CastPoints[PointExt]([]ext{{}}); err != nil {
+		t.Errorf("CastPoints[PointExt]: %v", err)
+	}
+	if p, err := CastPoints[PointExt, ext](nil); p != nil || err != nil {
+		t.Errorf("CastPoints(nil) = %v, %v", p, err)
+	}
+
+	bad := []struct {
+		name string
+		cast func() error
+	}{
+		{"wrong size", func() error {
+			_, err := CastPoints[Point]([]struct{ X, Y, Z uint16 }{{}})
+			return err
+		}},
+		{"same size, wrong fields", func() error {
+			_, err := CastPoints[Point]([]struct{ X, Y, R, G uint16 }{{}})
+			return err
+		}},
+		{"float fields", func() error {
+			_, err := CastPoints[Point]([]struct{ X, Y float32 }{{}})
+			return err
+		}},
+		{"point type", func() error {
+			_, err := CastPoints[PointHighRes]([]Point{{}})
+			return err
+		}},
+	}
+	for _, tt := range bad {
+		if err := tt.cast(); err == nil {
+			t.Errorf("%s: no error", tt.name)
+		}
+		// The cached result must be the same.
+		if err := tt.cast(); err == nil {
+			t.Errorf("%s: no error on the second call", tt.name)
+		}
+	}
+}
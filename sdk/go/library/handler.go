@@ -0,0 +1,139 @@
+package library
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// maxUpload limits the size of files imported over HTTP.
+const maxUpload = 64 << 20
+
+// Handler returns an HTTP API for the library:
+//
+//	GET    /assets?q=text&tag=t&format=f  search, as JSON
+//	POST   /assets?name=file.ild          import the request body
+//	GET    /assets/{id}                   metadata, as JSON
+//	DELETE /assets/{id}                   delete
+//	POST   /assets/{id}/tags?tag=t        add tags
+//	DELETE /assets/{id}/tags?tag=t        remove tags
+//	GET    /assets/{id}/thumbnail         the thumbnail
+//	GET    /assets/{id}/preview           the animated preview
+//	POST   /assets/{id}/render            regenerate thumbnail and preview
+func (l *Library) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /assets", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		assets, err := l.Search(r.Context(), Query{Text: q.Get("q"), Tags: q["tag"], Format: q.Get("format")})
+		if err != nil {
+			httpError(w, err)
+			return
+		}
+		if assets == nil {
+			assets = []Asset{}
+		}
+		writeJSON(w, http.StatusOK, assets)
+	})
+	mux.HandleFunc("POST /assets", func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxUpload))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		a, err := l.Import(r.Context(), r.URL.Query().Get("name"), data)
+		if err != nil {
+			httpError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, a)
+	})
+	mux.HandleFunc("GET /assets/{id}", l.withID(func(w http.ResponseWriter, r *http.Request, id int64) {
+		a, err := l.Get(r.Context(), id)
+		if err != nil {
+			httpError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, a)
+	}))
+	mux.HandleFunc("DELETE /assets/{id}", l.withID(func(w http.ResponseWriter, r *http.Request, id int64) {
+		if err := l.Delete(r.Context(), id); err != nil {
+			httpError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	mux.HandleFunc("POST /assets/{id}/tags", l.withID(func(w http.ResponseWriter, r *http.Request, id int64) {
+		if err := l.Tag(r.Context(), id, r.URL.Query()["tag"]...); err != nil {
+			httpError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	mux.HandleFunc("DELETE /assets/{id}/tags", l.withID(func(w http.ResponseWriter, r *http.Request, id int64) {
+		if err := l.Untag(r.Context(), id, r.URL.Query()["tag"]...); err != nil {
+			httpError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	mux.HandleFunc("GET /assets/{id}/thumbnail", l.withID(func(w http.ResponseWriter, r *http.Request, id int64) {
+		writeImage(w, r)(l.Thumbnail(r.Context(), id))
+	}))
+	mux.HandleFunc("GET /assets/{id}/preview", l.withID(func(w http.ResponseWriter, r *http.Request, id int64) {
+		writeImage(w, r)(l.Preview(r.Context(), id))
+	}))
+	mux.HandleFunc("POST /assets/{id}/render", l.withID(func(w http.ResponseWriter, r *http.Request, id int64) {
+		if err := l.Render(r.Context(), id); err != nil {
+			httpError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	return mux
+}
+
+func (l *Library) withID(h func(http.ResponseWriter, *http.Request, int64)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			http.Error(w, "invalid asset id", http.StatusBadRequest)
+			return
+		}
+		h(w, r, id)
+	}
+}
+
+// writeImage returns a function writing the result of an image getter.
+func writeImage(w http.ResponseWriter, r *http.Request) func([]byte, error) {
+	return func(image []byte, err error) {
+		if err != nil {
+			httpError(w, err)
+			return
+		}
+		if image == nil {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", http.DetectContentType(image))
+		w.Write(image)
+	}
+}
+
+func httpError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrInvalid):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
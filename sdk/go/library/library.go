@@ -0,0 +1,409 @@
+// Package library keeps a searchable catalog of laser content.
+//
+// Assets are imported from ILDA (.ild) and SVG (.svg) files. The library
+// stores the original file together with metadata measured at import (frame
+// and point counts, duration, blanking ratio), free-form tags and a
+// thumbnail and animated preview rendered at import, in three tables of a
+// database/sql database. The
+// statements use SQLite syntax; register a driver by importing it, then
+// open the database as usual:
+//
+//	db, _ := sql.Open("sqlite", "/var/lib/laser/library.db")
+//	lib, _ := library.Open(ctx, db)
+//	asset, _ := lib.Import(ctx, "logo.ild", data)
+//	lib.Tag(ctx, asset.ID, "logo", "intro")
+//	found, _ := lib.Search(ctx, library.Query{Tags: []string{"intro"}})
+package library
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/ilda"
+	"github.com/Grix/helios_dac/sdk/go/laserboy"
+	"github.com/Grix/helios_dac/sdk/go/svg"
+)
+
+var (
+	// ErrNotFound is returned for asset IDs that are not in the library.
+	ErrNotFound = errors.New("library: asset not found")
+
+	// ErrInvalid is returned by Import for files that cannot be decoded.
+	ErrInvalid = errors.New("library: invalid file")
+)
+
+// Asset formats.
+const (
+	FormatILDA     = "ilda"
+	FormatSVG      = "svg"
+	FormatLaserBoy = "laserboy"
+)
+
+// DefaultFrameRate is the playback rate used to compute asset durations
+// when Library.FrameRate is zero. ILDA files carry no frame rate.
+const DefaultFrameRate = 30
+
+// SVG drawings are traced with this point spacing (in normalized units) and
+// number of blanked points per jump.
+const (
+	svgSpacing = 1.0 / 64
+	svgBlank   = 8
+)
+
+// Asset describes one imported file.
+type Asset struct {
+	ID     int64     `json:"id"`
+	Name   string    `json:"name"`
+	Format string    `json:"format"`
+	Added  time.Time `json:"added"`
+	Tags   []string  `json:"tags"`
+	Stats  Stats     `json:"stats"`
+
+	// HasThumbnail and HasPreview report whether a thumbnail and an
+	// animated preview have been stored.
+	HasThumbnail bool `json:"has_thumbnail"`
+	HasPreview   bool `json:"has_preview"`
+}
+
+// Library is a content catalog stored in a database.
+type Library struct {
+	// FrameRate is the playback rate used for asset durations. Zero means
+	// DefaultFrameRate.
+	FrameRate float64
+
+	db *sql.DB
+}
+
+// Open returns the library stored in db, creating its tables (assets,
+// asset_tags and asset_previews) if needed.
+func Open(ctx context.Context, db *sql.DB) (*Library, error) {
+	for _, stmt := range []string{
+		`CREATE TABLE IF NOT EXISTS assets (
+			id INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			format TEXT NOT NULL,
+			added INTEGER NOT NULL,
+			frames INTEGER NOT NULL,
+			points INTEGER NOT NULL,
+			max_points INTEGER NOT NULL,
+			blanked REAL NOT NULL,
+			duration INTEGER NOT NULL,
+			data BLOB NOT NULL,
+			thumbnail BLOB)`,
+		`CREATE TABLE IF NOT EXISTS asset_tags (
+			asset INTEGER NOT NULL REFERENCES assets (id) ON DELETE CASCADE,
+			tag TEXT NOT NULL,
+			PRIMARY KEY (asset, tag))`,
+		`CREATE INDEX IF NOT EXISTS asset_tags_tag ON asset_tags (tag)`,
+		`CREATE TABLE IF NOT EXISTS asset_previews (
+			asset INTEGER PRIMARY KEY REFERENCES assets (id) ON DELETE CASCADE,
+			gif BLOB NOT NULL)`,
+	} {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return nil, err
+		}
+	}
+	return &Library{db: db}, nil
+}
+
+func (l *Library) frameRate() float64 {
+	if l.FrameRate <= 0 {
+		return DefaultFrameRate
+	}
+	return l.FrameRate
+}
+
+// Decode parses the contents of an ILDA, SVG or LaserBoy text file,
+// choosing the format by the name's extension. A .txt file is only taken
+// for LaserBoy text if its first section is a frame or palette.
+func Decode(name string, data []byte) (format string, frames [][]helios.Point, err error) {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".ild", ".ilda":
+		f, err := ilda.Read(bytes.NewReader(data))
+		if err != nil {
+			return "", nil, err
+		}
+		return framePoints(name, FormatILDA, f)
+	case ".txt":
+		if !laserboy.Detect(data) {
+			break
+		}
+		f, err := laserboy.Read(bytes.NewReader(data))
+		if err != nil {
+			return "", nil, err
+		}
+		return framePoints(name, FormatLaserBoy, f)
+	case ".svg":
+		paths, err := svg.Read(bytes.NewReader(data))
+		if err != nil {
+			return "", nil, err
+		}
+		return FormatSVG, [][]helios.Point{svg.Frame(paths, svgSpacing, svgBlank)}, nil
+	}
+	return "", nil, fmt.Errorf("library: unsupported file type %q", name)
+}
+
+// framePoints returns the points of frames decoded from a file, failing if
+// there are none.
+func framePoints(name, format string, f []ilda.Frame) (string, [][]helios.Point, error) {
+	var frames [][]helios.Point
+	for _, fr := range f {
+		frames = append(frames, fr.Points)
+	}
+	if len(frames) == 0 {
+		return "", nil, fmt.Errorf("library: %s has no frames", name)
+	}
+	return format, frames, nil
+}
+
+// Import decodes a file, measures it, renders its thumbnail and preview and
+// adds it to the library.
+func (l *Library) Import(ctx context.Context, name string, data []byte) (Asset, error) {
+	format, frames, err := Decode(name, data)
+	if err != nil {
+		return Asset{}, fmt.Errorf("%w: %w", ErrInvalid, err)
+	}
+	a := Asset{
+		Name:   path.Base(name),
+		Format: format,
+		Added:  time.Now().UTC().Truncate(time.Second),
+		Stats:  Measure(frames, l.frameRate()),
+	}
+	thumbnail, preview, err := renderImages(frames, l.frameRate())
+	if err != nil {
+		return Asset{}, err
+	}
+	a.HasThumbnail, a.HasPreview = true, preview != nil
+
+	// The asset and its preview are added together, so a failure leaves
+	// neither.
+	tx, err := l.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Asset{}, err
+	}
+	defer tx.Rollback()
+	res, err := tx.ExecContext(ctx, `INSERT INTO assets
+		(name, format, added, frames, points, max_points, blanked, duration, data, thumbnail)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		a.Name, a.Format, a.Added.Unix(), a.Stats.Frames, a.Stats.Points, a.Stats.MaxPoints,
+		a.Stats.Blanked, int64(a.Stats.Duration), data, thumbnail)
+	if err != nil {
+		return Asset{}, err
+	}
+	if a.ID, err = res.LastInsertId(); err != nil {
+		return Asset{}, err
+	}
+	if err := setPreview(ctx, tx, a.ID, preview); err != nil {
+		return Asset{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return Asset{}, err
+	}
+	return a, nil
+}
+
+// Frames decodes the frames of an asset.
+func (l *Library) Frames(ctx context.Context, id int64) ([][]helios.Point, error) {
+	var name string
+	var data []byte
+	err := l.db.QueryRowContext(ctx, "SELECT name, data FROM assets WHERE id = ?", id).Scan(&name, &data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	_, frames, err := Decode(name, data)
+	return frames, err
+}
+
+// Get returns the metadata of an asset.
+func (l *Library) Get(ctx context.Context, id int64) (Asset, error) {
+	assets, err := l.query(ctx, "WHERE id = ?", id)
+	if err != nil {
+		return Asset{}, err
+	}
+	if len(assets) == 0 {
+		return Asset{}, ErrNotFound
+	}
+	return assets[0], nil
+}
+
+// Delete removes an asset, its tags and its preview. Deleting a missing
+// asset is not an error.
+func (l *Library) Delete(ctx context.Context, id int64) error {
+	tx, err := l.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, table := range []string{"asset_tags", "asset_previews"} {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE asset = ?", id); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM assets WHERE id = ?", id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Tag adds tags to an asset. Tags are case-insensitive and stored in lower
+// case; adding a tag twice has no effect.
+func (l *Library) Tag(ctx context.Context, id int64, tags ...string) error {
+	if _, err := l.Get(ctx, id); err != nil {
+		return err
+	}
+	for _, t := range normalizeTags(tags) {
+		_, err := l.db.ExecContext(ctx, "INSERT INTO asset_tags (asset, tag) VALUES (?, ?) ON CONFLICT DO NOTHING", id, t)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Untag removes tags from an asset.
+func (l *Library) Untag(ctx context.Context, id int64, tags ...string) error {
+	for _, t := range normalizeTags(tags) {
+		if _, err := l.db.ExecContext(ctx, "DELETE FROM asset_tags WHERE asset = ? AND tag = ?", id, t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func normalizeTags(tags []string) []string {
+	var out []string
+	for _, t := range tags {
+		if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// SetThumbnail stores an image (typically PNG) for an asset.
+func (l *Library) SetThumbnail(ctx context.Context, id int64, image []byte) error {
+	res, err := l.db.ExecContext(ctx, "UPDATE assets SET thumbnail = ? WHERE id = ?", image, id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Thumbnail returns the stored thumbnail of an asset, or nil if it has none.
+func (l *Library) Thumbnail(ctx context.Context, id int64) ([]byte, error) {
+	var image []byte
+	err := l.db.QueryRowContext(ctx, "SELECT thumbnail FROM assets WHERE id = ?", id).Scan(&image)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return image, err
+}
+
+// Query selects assets. Zero fields match everything.
+type Query struct {
+	// Text matches assets whose name contains it, ignoring case.
+	Text string
+
+	// Tags matches assets carrying all of the tags.
+	Tags []string
+
+	// Format matches assets of one format.
+	Format string
+}
+
+// Search returns the assets matching q, ordered by name.
+func (l *Library) Search(ctx context.Context, q Query) ([]Asset, error) {
+	var where []string
+	var args []any
+	if q.Text != "" {
+		where = append(where, "lower(name) LIKE ? ESCAPE '\\'")
+		args = append(args, "%"+escapeLike(strings.ToLower(q.Text))+"%")
+	}
+	if q.Format != "" {
+		where = append(where, "format = ?")
+		args = append(args, q.Format)
+	}
+	for _, t := range normalizeTags(q.Tags) {
+		where = append(where, "id IN (SELECT asset FROM asset_tags WHERE tag = ?)")
+		args = append(args, t)
+	}
+	clause := ""
+	if len(where) > 0 {
+		clause = "WHERE " + strings.Join(where, " AND ")
+	}
+	return l.query(ctx, clause, args...)
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// query loads the assets selected by the where clause, with their tags,
+// ordered by name.
+func (l *Library) query(ctx context.Context, where string, args ...any) ([]Asset, error) {
+	rows, err := l.db.QueryContext(ctx, `SELECT id, name, format, added, frames, points, max_points,
+		blanked, duration, thumbnail IS NOT NULL, id IN (SELECT asset FROM asset_previews)
+		FROM assets `+where+" ORDER BY name, id", args...)
+	if err != nil {
+		return nil, err
+	}
+	var assets []Asset
+	for rows.Next() {
+		var a Asset
+		var added, duration int64
+		err := rows.Scan(&a.ID, &a.Name, &a.Format, &added, &a.Stats.Frames, &a.Stats.Points,
+			&a.Stats.MaxPoints, &a.Stats.Blanked, &duration, &a.HasThumbnail, &a.HasPreview)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		a.Added = time.Unix(added, 0).UTC()
+		a.Stats.Duration = time.Duration(duration)
+		assets = append(assets, a)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(assets) == 0 {
+		return nil, nil
+	}
+	byID := make(map[int64]*Asset, len(assets))
+	for i := range assets {
+		byID[assets[i].ID] = &assets[i]
+	}
+
+	rows, err = l.db.QueryContext(ctx, "SELECT asset, tag FROM asset_tags WHERE asset IN (SELECT id FROM assets "+where+")", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		var tag string
+		if err := rows.Scan(&id, &tag); err != nil {
+			return nil, err
+		}
+		if a := byID[id]; a != nil {
+			a.Tags = append(a.Tags, tag)
+		}
+	}
+	for _, a := range assets {
+		sort.Strings(a.Tags)
+	}
+	return assets, rows.Err()
+}
@@ -0,0 +1,420 @@
+package library
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+func TestMeasure(t *testing.T) {
+	frames := [][]helios.Point{
+		{{X: 1, R: 255, I: 255}, {X: 2}},
+		{{X: 1, G: 255, I: 255}, {X: 2, B: 255, I: 255}, {X: 3, R: 255}},
+	}
+	s := Measure(frames, 25)
+	want := Stats{Frames: 2, Points: 5, MaxPoints: 3, Blanked: 0.4, Duration: 80 * time.Millisecond}
+	if s != want {
+		t.Fatalf("Measure = %+v, want %+v", s, want)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	format, frames, err := Decode("shapes/box.SVG", []byte(`<svg viewBox="0 0 10 10"><rect width="10" height="10"/></svg>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format != FormatSVG || len(frames) != 1 || len(frames[0]) < 4*64 {
+		t.Fatalf("Decode = %s, %d frames", format, len(frames))
+	}
+	format, frames, err = Decode("beams.txt", []byte("frame xy rgb\n0 0 255 0 0\n\nframe xy rgb\n0 0 0 255 0\n"))
+	if err != nil || format != FormatLaserBoy || len(frames) != 2 {
+		t.Fatalf("Decode(LaserBoy) = %s, %d frames, %v", format, len(frames), err)
+	}
+	if _, _, err := Decode("notes.txt", nil); err == nil {
+		t.Fatal("Decode accepted an unsupported file type")
+	}
+	if _, _, err := Decode("empty.ild", nil); err == nil {
+		t.Fatal("Decode accepted an ILDA file without frames")
+	}
+}
+
+func TestHandlerRejectsBadInput(t *testing.T) {
+	h := (&Library{}).Handler()
+	for _, tt := range []struct {
+		method, target, body string
+	}{
+		{"GET", "/assets/abc", ""},
+		{"POST", "/assets?name=broken.svg", "<svg>"},
+		{"POST", "/assets?name=movie.mp4", "data"},
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s %s: status %d, want 400", tt.method, tt.target, rec.Code)
+		}
+	}
+}
+
+func TestRenderImages(t *testing.T) {
+	still := [][]helios.Point{{{X: 0, Y: 0}, {X: 4095, Y: 4095, R: 255, I: 255}}}
+	thumbnail, preview, err := renderImages(still, DefaultFrameRate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if http.DetectContentType(thumbnail) != "image/png" || preview != nil {
+		t.Fatalf("still: thumbnail %q, preview %d bytes", http.DetectContentType(thumbnail), len(preview))
+	}
+
+	_, preview, err = renderImages(append(still, still[0]), DefaultFrameRate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if http.DetectContentType(preview) != "image/gif" {
+		t.Fatalf("animation preview is %q", http.DetectContentType(preview))
+	}
+}
+
+// fakeDB is a minimal database/sql driver for the statements Library runs,
+// holding the tables in memory. Statements containing fail return an
+// error, and a rolled back transaction restores the tables.
+type fakeDB struct {
+	fail string
+
+	assets   map[int64][]driver.Value // name, format, added, frames, points, max_points, blanked, duration, data, thumbnail
+	tags     map[int64]map[string]bool
+	previews map[int64][]byte
+	nextID   int64
+	saved    *fakeDB // the tables at the start of the transaction
+}
+
+func newFakeDB() *fakeDB {
+	return &fakeDB{assets: map[int64][]driver.Value{}, tags: map[int64]map[string]bool{}, previews: map[int64][]byte{}}
+}
+
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) { return f, nil }
+func (f *fakeDB) Driver() driver.Driver                        { return f }
+func (f *fakeDB) Open(string) (driver.Conn, error)             { return f, nil }
+func (f *fakeDB) Close() error                                 { return nil }
+
+func (f *fakeDB) Prepare(query string) (driver.Stmt, error) {
+	return fakeStmt{f, strings.Join(strings.Fields(query), " ")}, nil
+}
+
+func (f *fakeDB) Begin() (driver.Tx, error) {
+	saved := &fakeDB{assets: maps.Clone(f.assets), tags: map[int64]map[string]bool{}, previews: maps.Clone(f.previews), nextID: f.nextID}
+	for id, t := range f.tags {
+		saved.tags[id] = maps.Clone(t)
+	}
+	f.saved = saved
+	return f, nil
+}
+
+func (f *fakeDB) Commit() error {
+	f.saved = nil
+	return nil
+}
+
+func (f *fakeDB) Rollback() error {
+	if s := f.saved; s != nil {
+		f.assets, f.tags, f.previews, f.nextID, f.saved = s.assets, s.tags, s.previews, s.nextID, nil
+	}
+	return nil
+}
+
+type fakeStmt struct {
+	f     *fakeDB
+	query string
+}
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	f, q := s.f, s.query
+	if f.fail != "" && strings.Contains(q, f.fail) {
+		return nil, errors.New("fake db: injected failure")
+	}
+	var n int64 = 1
+	switch {
+	case strings.HasPrefix(q, "CREATE "):
+	case strings.HasPrefix(q, "INSERT INTO assets "):
+		f.nextID++
+		f.assets[f.nextID] = slices.Clone(args)
+		return fakeResult{f.nextID, 1}, nil
+	case strings.HasPrefix(q, "INSERT INTO asset_tags "):
+		id := args[0].(int64)
+		if f.tags[id] == nil {
+			f.tags[id] = map[string]bool{}
+		}
+		f.tags[id][args[1].(string)] = true
+	case strings.HasPrefix(q, "INSERT INTO asset_previews "):
+		f.previews[args[0].(int64)] = args[1].([]byte)
+	case q == "DELETE FROM asset_tags WHERE asset = ? AND tag = ?":
+		delete(f.tags[args[0].(int64)], args[1].(string))
+	case q == "DELETE FROM asset_tags WHERE asset = ?":
+		delete(f.tags, args[0].(int64))
+	case q == "DELETE FROM asset_previews WHERE asset = ?":
+		delete(f.previews, args[0].(int64))
+	case q == "DELETE FROM assets WHERE id = ?":
+		delete(f.assets, args[0].(int64))
+	case q == "UPDATE assets SET thumbnail = ? WHERE id = ?":
+		if a := f.assets[args[1].(int64)]; a != nil {
+			a[9] = args[0]
+		} else {
+			n = 0
+		}
+	default:
+		return nil, fmt.Errorf("fake db: unexpected statement %q", q)
+	}
+	return fakeResult{0, n}, nil
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	f, q := s.f, s.query
+	rows := &fakeRows{}
+	switch {
+	case q == "SELECT name, data FROM assets WHERE id = ?":
+		if a := f.assets[args[0].(int64)]; a != nil {
+			rows.add(a[0], a[8])
+		}
+	case q == "SELECT thumbnail FROM assets WHERE id = ?":
+		if a := f.assets[args[0].(int64)]; a != nil {
+			rows.add(a[9])
+		}
+	case q == "SELECT gif FROM asset_previews WHERE asset = ?":
+		if p, ok := f.previews[args[0].(int64)]; ok {
+			rows.add(p)
+		}
+	case strings.HasPrefix(q, "SELECT id, name, "):
+		where := strings.TrimSuffix(q[strings.Index(q, "FROM assets")+len("FROM assets"):], " ORDER BY name, id")
+		for _, id := range f.match(where, args) {
+			a := f.assets[id]
+			_, preview := f.previews[id]
+			rows.add(id, a[0], a[1], a[2], a[3], a[4], a[5], a[6], a[7], a[9] != nil, preview)
+		}
+		slices.SortStableFunc(rows.values, func(a, b []driver.Value) int { return strings.Compare(a[1].(string), b[1].(string)) })
+	case strings.HasPrefix(q, "SELECT asset, tag FROM asset_tags WHERE asset IN (SELECT id FROM assets"):
+		where := strings.TrimSuffix(q[strings.Index(q, "FROM assets")+len("FROM assets"):], ")")
+		for _, id := range f.match(where, args) {
+			for _, t := range slices.Sorted(maps.Keys(f.tags[id])) {
+				rows.add(id, t)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("fake db: unexpected query %q", q)
+	}
+	return rows, nil
+}
+
+// match returns the IDs of the assets matching the conditions Search and
+// Get build, in order.
+func (f *fakeDB) match(where string, args []driver.Value) []int64 {
+	var conds []string
+	if where = strings.TrimSpace(where); where != "" {
+		conds = strings.Split(strings.TrimPrefix(where, "WHERE "), " AND ")
+	}
+	var ids []int64
+	for _, id := range slices.Sorted(maps.Keys(f.assets)) {
+		a, ok := f.assets[id], true
+		for i, c := range conds {
+			switch c {
+			case "id = ?":
+				ok = ok && id == args[i].(int64)
+			case "format = ?":
+				ok = ok && a[1] == args[i]
+			case `lower(name) LIKE ? ESCAPE '\'`:
+				ok = ok && likePattern(args[i].(string)).MatchString(strings.ToLower(a[0].(string)))
+			case "id IN (SELECT asset FROM asset_tags WHERE tag = ?)":
+				ok = ok && f.tags[id][args[i].(string)]
+			default:
+				panic("fake db: unexpected condition " + c)
+			}
+		}
+		if ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// likePattern converts a LIKE pattern escaped with a backslash to a regular
+// expression.
+func likePattern(like string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	for r := []rune(like); len(r) > 0; r = r[1:] {
+		switch {
+		case r[0] == '\\' && len(r) > 1:
+			r = r[1:]
+			b.WriteString(regexp.QuoteMeta(string(r[0])))
+		case r[0] == '%':
+			b.WriteString(".*")
+		case r[0] == '_':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r[0])))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+type fakeResult struct{ id, rows int64 }
+
+func (r fakeResult) LastInsertId() (int64, error) { return r.id, nil }
+func (r fakeResult) RowsAffected() (int64, error) { return r.rows, nil }
+
+type fakeRows struct {
+	values [][]driver.Value
+}
+
+func (r *fakeRows) add(values ...driver.Value) { r.values = append(r.values, values) }
+func (r *fakeRows) Close() error               { return nil }
+
+func (r *fakeRows) Columns() []string {
+	if len(r.values) == 0 {
+		return nil
+	}
+	return make([]string, len(r.values[0]))
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+const (
+	boxSVG   = `<svg viewBox="0 0 10 10"><rect width="10" height="10"/></svg>`
+	beamsTxt = "frame xy rgb\n0 0 255 0 0\n\nframe xy rgb\n0 0 0 255 0\n"
+)
+
+func openFake(t *testing.T, f *fakeDB) *Library {
+	t.Helper()
+	db := sql.OpenDB(f)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	l, err := Open(context.Background(), db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+func TestLibrary(t *testing.T) {
+	ctx := context.Background()
+	l := openFake(t, newFakeDB())
+
+	box, err := l.Import(ctx, "shapes/box_1.svg", []byte(boxSVG))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if box.Name != "box_1.svg" || box.Format != FormatSVG || !box.HasThumbnail || box.HasPreview {
+		t.Fatalf("imported %+v", box)
+	}
+	beams, err := l.Import(ctx, "beams.txt", []byte(beamsTxt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Import(ctx, "boxx1.svg", []byte(boxSVG)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := l.Tag(ctx, beams.ID, "Intro", " intro ", "beams"); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Tag(ctx, 99, "intro"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Tag of a missing asset: %v", err)
+	}
+	got, err := l.Get(ctx, beams.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got.Tags, []string{"beams", "intro"}) || got.Stats.Frames != 2 || !got.HasPreview || !got.Added.Equal(beams.Added) {
+		t.Fatalf("Get = %+v", got)
+	}
+
+	search := func(q Query) []string {
+		t.Helper()
+		assets, err := l.Search(ctx, q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, a := range assets {
+			names = append(names, a.Name)
+		}
+		return names
+	}
+	if names := search(Query{}); !slices.Equal(names, []string{"beams.txt", "box_1.svg", "boxx1.svg"}) {
+		t.Fatalf("Search all = %v", names)
+	}
+	if names := search(Query{Text: "BOX_"}); !slices.Equal(names, []string{"box_1.svg"}) {
+		t.Fatalf("Search text = %v", names)
+	}
+	if names := search(Query{Tags: []string{"INTRO", "beams"}, Format: FormatLaserBoy}); !slices.Equal(names, []string{"beams.txt"}) {
+		t.Fatalf("Search tags = %v", names)
+	}
+
+	if frames, err := l.Frames(ctx, beams.ID); err != nil || len(frames) != 2 {
+		t.Fatalf("Frames = %d, %v", len(frames), err)
+	}
+	if p, err := l.Preview(ctx, beams.ID); err != nil || http.DetectContentType(p) != "image/gif" {
+		t.Fatalf("Preview = %q, %v", http.DetectContentType(p), err)
+	}
+	if p, err := l.Preview(ctx, box.ID); err != nil || p != nil {
+		t.Fatalf("Preview of a still = %d bytes, %v", len(p), err)
+	}
+	if err := l.SetThumbnail(ctx, box.ID, []byte("png")); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := l.Thumbnail(ctx, box.ID); err != nil || string(b) != "png" {
+		t.Fatalf("Thumbnail = %q, %v", b, err)
+	}
+
+	if err := l.Untag(ctx, beams.ID, "beams"); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Delete(ctx, beams.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Get(ctx, beams.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get after Delete: %v", err)
+	}
+	if names := search(Query{Tags: []string{"intro"}}); len(names) != 0 {
+		t.Fatalf("tags of a deleted asset found: %v", names)
+	}
+	if err := l.Delete(ctx, beams.ID); err != nil {
+		t.Fatalf("second Delete: %v", err)
+	}
+}
+
+func TestImportFailsWhole(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeDB()
+	l := openFake(t, f)
+	f.fail = "asset_previews"
+	if _, err := l.Import(ctx, "beams.txt", []byte(beamsTxt)); err == nil {
+		t.Fatal("Import succeeded without storing its preview")
+	}
+	f.fail = ""
+	if assets, err := l.Search(ctx, Query{}); err != nil || len(assets) != 0 {
+		t.Fatalf("failed Import left %d assets, %v", len(assets), err)
+	}
+}
@@ -0,0 +1,46 @@
+package library
+
+import (
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+// Stats summarizes the frames of an asset.
+type Stats struct {
+	Frames int `json:"frames"`
+
+	// Points is the total number of points of all frames, and MaxPoints the
+	// number of points of the largest frame.
+	Points    int `json:"points"`
+	MaxPoints int `json:"max_points"`
+
+	// Blanked is the fraction of points with the laser off (0 - 1).
+	Blanked float64 `json:"blanked"`
+
+	// Duration is the playback time of all frames at the library's frame
+	// rate.
+	Duration time.Duration `json:"duration"`
+}
+
+// Measure computes the statistics of frames played at fps frames per second.
+func Measure(frames [][]helios.Point, fps float64) Stats {
+	s := Stats{Frames: len(frames)}
+	blanked := 0
+	for _, f := range frames {
+		s.Points += len(f)
+		s.MaxPoints = max(s.MaxPoints, len(f))
+		for _, p := range f {
+			if p.R == 0 && p.G == 0 && p.B == 0 || p.I == 0 {
+				blanked++
+			}
+		}
+	}
+	if s.Points > 0 {
+		s.Blanked = float64(blanked) / float64(s.Points)
+	}
+	if fps > 0 {
+		s.Duration = time.Duration(float64(len(frames)) / fps * float64(time.Second))
+	}
+	return s
+}
@@ -0,0 +1,85 @@
+package library
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/raster"
+)
+
+// Thumbnails are square PNGs of ThumbnailSize pixels showing the middle
+// frame of an asset. Animations also get a GIF preview of at most
+// PreviewFrames frames.
+const (
+	ThumbnailSize = 128
+	PreviewFrames = 48
+)
+
+// renderImages renders the thumbnail and, for animations, the preview of
+// frames played at fps.
+func renderImages(frames [][]helios.Point, fps float64) (thumbnail, preview []byte, err error) {
+	var buf bytes.Buffer
+	if err := raster.PNG(&buf, frames[len(frames)/2], ThumbnailSize); err != nil {
+		return nil, nil, err
+	}
+	thumbnail = bytes.Clone(buf.Bytes())
+	if len(frames) > 1 {
+		buf.Reset()
+		if err := raster.GIF(&buf, frames, ThumbnailSize, fps, PreviewFrames); err != nil {
+			return nil, nil, err
+		}
+		preview = buf.Bytes()
+	}
+	return thumbnail, preview, nil
+}
+
+// Render regenerates the thumbnail and preview of an asset, replacing any
+// stored with SetThumbnail.
+func (l *Library) Render(ctx context.Context, id int64) error {
+	frames, err := l.Frames(ctx, id)
+	if err != nil {
+		return err
+	}
+	thumbnail, preview, err := renderImages(frames, l.frameRate())
+	if err != nil {
+		return err
+	}
+	if err := l.SetThumbnail(ctx, id, thumbnail); err != nil {
+		return err
+	}
+	return setPreview(ctx, l.db, id, preview)
+}
+
+// execer is a *sql.DB or *sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// setPreview stores the preview of an asset, or deletes it if preview is
+// nil.
+func setPreview(ctx context.Context, db execer, id int64, preview []byte) error {
+	if preview == nil {
+		_, err := db.ExecContext(ctx, "DELETE FROM asset_previews WHERE asset = ?", id)
+		return err
+	}
+	_, err := db.ExecContext(ctx, "INSERT INTO asset_previews (asset, gif) VALUES (?, ?) "+
+		"ON CONFLICT (asset) DO UPDATE SET gif = excluded.gif", id, preview)
+	return err
+}
+
+// Preview returns the animated GIF preview of an asset, or nil if it has
+// none (single-frame assets).
+func (l *Library) Preview(ctx context.Context, id int64) ([]byte, error) {
+	var preview []byte
+	err := l.db.QueryRowContext(ctx, "SELECT gif FROM asset_previews WHERE asset = ?", id).Scan(&preview)
+	if errors.Is(err, sql.ErrNoRows) {
+		if _, err := l.Get(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	return preview, err
+}
@@ -0,0 +1,281 @@
+//go:build !helios_purego && !helios_stub
+
+package helios
+
+/*
+#include "wrapper.h"
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"errors"
+	"net/netip"
+	"time"
+	"unsafe"
+)
+
+// library is the C++ SDK, compiled from the bundled sources and called
+// through the C wrapper. Building with the helios_purego tag replaces it
+// with the prebuilt shared library, see LoadLibrary, and building with the
+// helios_stub tag with no SDK at all.
+type library struct {
+	handle C.HeliosDacHandle
+}
+
+// The point structures are passed to the wrapper as its C point structures
+// without copying, so they must match them field for field; see layout.go.
+var (
+	_ = [1]struct{}{}[unsafe.Sizeof(Point{})-C.sizeof_WrapperHeliosPoint]
+	_ = [1]struct{}{}[C.sizeof_WrapperHeliosPoint-unsafe.Sizeof(Point{})]
+	_ = [1]struct{}{}[unsafe.Offsetof(Point{}.R)-unsafe.Offsetof(C.WrapperHeliosPoint{}.r)]
+	_ = [1]struct{}{}[unsafe.Offsetof(Point{}.I)-unsafe.Offsetof(C.WrapperHeliosPoint{}.i)]
+
+	_ = [1]struct{}{}[unsafe.Sizeof(PointHighRes{})-C.sizeof_WrapperHeliosPointHighRes]
+	_ = [1]struct{}{}[C.sizeof_WrapperHeliosPointHighRes-unsafe.Sizeof(PointHighRes{})]
+	_ = [1]struct{}{}[unsafe.Offsetof(PointHighRes{}.B)-unsafe.Offsetof(C.WrapperHeliosPointHighRes{}.b)]
+
+	_ = [1]struct{}{}[unsafe.Sizeof(PointExt{})-C.sizeof_WrapperHeliosPointExt]
+	_ = [1]struct{}{}[C.sizeof_WrapperHeliosPointExt-unsafe.Sizeof(PointExt{})]
+	_ = [1]struct{}{}[unsafe.Offsetof(PointExt{}.I)-unsafe.Offsetof(C.WrapperHeliosPointExt{}.i)]
+	_ = [1]struct{}{}[unsafe.Offsetof(PointExt{}.User4)-unsafe.Offsetof(C.WrapperHeliosPointExt{}.user4)]
+)
+
+// Backend names the SDK the package calls: "cgo" for the bundled C++
+// sources, "purego" for the prebuilt shared library, or "stub" for none.
+const Backend = "cgo"
+
+// LoadLibrary loads the prebuilt HeliosLaserDAC shared library in builds
+// with the helios_purego tag. This build links the bundled C++ SDK, so it
+// always fails.
+func LoadLibrary(path string) error {
+	return errors.New("helios: LoadLibrary needs the helios_purego build tag")
+}
+
+func newLibrary() library {
+	return library{C.HeliosDac_New()}
+}
+
+// closedLibrary is returned by the calls of a DAC after Close, such as a
+// stop still queued by a Shutdown that timed out.
+const closedLibrary = int(ErrDeviceClosed)
+
+func (l *library) close() {
+	if l.handle != nil {
+		C.HeliosDac_Delete(l.handle)
+		l.handle = nil
+	}
+}
+
+func (l *library) closed() bool {
+	return l.handle == nil
+}
+
+func (l *library) openDevices() int {
+	if l.handle == nil {
+		return closedLibrary
+	}
+	return int(C.HeliosDac_OpenDevices(l.handle))
+}
+
+func (l *library) openDevicesOnlyUsb() int {
+	if l.handle == nil {
+		return closedLibrary
+	}
+	return int(C.HeliosDac_OpenDevicesOnlyUsb(l.handle))
+}
+
+func (l *library) openDevicesOnlyNetwork() int {
+	if l.handle == nil {
+		return closedLibrary
+	}
+	return int(C.HeliosDac_OpenDevicesOnlyNetwork(l.handle))
+}
+
+func (l *library) reScanDevices() int {
+	if l.handle == nil {
+		return closedLibrary
+	}
+	return int(C.HeliosDac_ReScanDevices(l.handle))
+}
+
+func (l *library) reScanDevicesOnlyUsb() int {
+	if l.handle == nil {
+		return closedLibrary
+	}
+	return int(C.HeliosDac_ReScanDevicesOnlyUsb(l.handle))
+}
+
+func (l *library) reScanDevicesOnlyNetwork() int {
+	if l.handle == nil {
+		return closedLibrary
+	}
+	return int(C.HeliosDac_ReScanDevicesOnlyNetwork(l.handle))
+}
+
+func (l *library) openNetworkDevice(addr, name string, serviceID uint8, unitID *[16]byte) int {
+	if l.handle == nil {
+		return closedLibrary
+	}
+	cAddr := C.CString(addr)
+	defer C.free(unsafe.Pointer(cAddr))
+	var cName *C.char
+	if name != "" {
+		cName = C.CString(name)
+		defer C.free(unsafe.Pointer(cName))
+	}
+	return int(C.HeliosDac_OpenNetworkDevice(l.handle, cAddr, C.int(serviceID), cName, (*C.uint8_t)(unsafe.Pointer(unitID))))
+}
+
+func (l *library) closeDevices() {
+	if l.handle == nil {
+		return
+	}
+	C.HeliosDac_CloseDevices(l.handle)
+}
+
+func (l *library) getStatus(deviceIndex int) int {
+	if l.handle == nil {
+		return closedLibrary
+	}
+	return int(C.HeliosDac_GetStatus(l.handle, C.int(deviceIndex)))
+}
+
+func (l *library) writeFrame(deviceIndex, pps, flags int, points []Point) int {
+	if l.handle == nil {
+		return closedLibrary
+	}
+	return int(C.HeliosDac_WriteFrame(l.handle, C.int(deviceIndex), C.int(pps), C.int(flags),
+		(*C.WrapperHeliosPoint)(unsafe.Pointer(&points[0])), C.int(len(points))))
+}
+
+func (l *library) writeFrameHighResolution(deviceIndex, pps, flags int, points []PointHighRes) int {
+	if l.handle == nil {
+		return closedLibrary
+	}
+	return int(C.HeliosDac_WriteFrameHighResolution(l.handle, C.int(deviceIndex), C.int(pps), C.int(flags),
+		(*C.WrapperHeliosPointHighRes)(unsafe.Pointer(&points[0])), C.int(len(points))))
+}
+
+func (l *library) writeFrameExtended(deviceIndex, pps, flags int, points []PointExt) int {
+	if l.handle == nil {
+		return closedLibrary
+	}
+	return int(C.HeliosDac_WriteFrameExtended(l.handle, C.int(deviceIndex), C.int(pps), C.int(flags),
+		(*C.WrapperHeliosPointExt)(unsafe.Pointer(&points[0])), C.int(len(points))))
+}
+
+func (l *library) getName(deviceIndex int) string {
+	if l.handle == nil {
+		return ""
+	}
+	buf := make([]byte, 32)
+	C.HeliosDac_GetName(l.handle, C.int(deviceIndex), (*C.char)(unsafe.Pointer(&buf[0])), C.int(len(buf)))
+	return C.GoString((*C.char)(unsafe.Pointer(&buf[0])))
+}
+
+func (l *library) setName(deviceIndex int, name string) int {
+	if l.handle == nil {
+		return closedLibrary
+	}
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+	return int(C.HeliosDac_SetName(l.handle, C.int(deviceIndex), cName))
+}
+
+func (l *library) getFirmwareVersion(deviceIndex int) int {
+	if l.handle == nil {
+		return closedLibrary
+	}
+	return int(C.HeliosDac_GetFirmwareVersion(l.handle, C.int(deviceIndex)))
+}
+
+func (l *library) getSupportsHigherResolutions(deviceIndex int) int {
+	if l.handle == nil {
+		return closedLibrary
+	}
+	return int(C.HeliosDac_GetSupportsHigherResolutions(l.handle, C.int(deviceIndex)))
+}
+
+func (l *library) getIsUsb(deviceIndex int) bool {
+	if l.handle == nil {
+		return false
+	}
+	return bool(C.HeliosDac_GetIsUsb(l.handle, C.int(deviceIndex)))
+}
+
+func (l *library) getIsClosed(deviceIndex int) bool {
+	if l.handle == nil {
+		return true
+	}
+	return bool(C.HeliosDac_GetIsClosed(l.handle, C.int(deviceIndex)))
+}
+
+func (l *library) stop(deviceIndex int) int {
+	if l.handle == nil {
+		return closedLibrary
+	}
+	return int(C.HeliosDac_Stop(l.handle, C.int(deviceIndex)))
+}
+
+func (l *library) setShutter(deviceIndex int, level bool) int {
+	if l.handle == nil {
+		return closedLibrary
+	}
+	return int(C.HeliosDac_SetShutter(l.handle, C.int(deviceIndex), C.bool(level)))
+}
+
+func (l *library) eraseFirmware(deviceIndex int) int {
+	if l.handle == nil {
+		return closedLibrary
+	}
+	return int(C.HeliosDac_EraseFirmware(l.handle, C.int(deviceIndex)))
+}
+
+func (l *library) setLibusbDebugLogLevel(logLevel int) int {
+	if l.handle == nil {
+		return closedLibrary
+	}
+	return int(C.HeliosDac_SetLibusbDebugLogLevel(l.handle, C.int(logLevel)))
+}
+
+func (l *library) setUsbTransferOptions(frameTimeout, controlTimeout, bulkTransferSize, asyncTransfers uint32) {
+	if l.handle == nil {
+		return
+	}
+	C.HeliosDac_SetUsbTransferOptions(l.handle, C.uint(frameTimeout), C.uint(controlTimeout), C.uint(bulkTransferSize), C.uint(asyncTransfers))
+}
+
+// setWireTap turns capturing by the C++ SDK on or off.
+func setWireTap(on bool) {
+	C.HeliosDac_SetWireTap(C.bool(on))
+}
+
+//export heliosGoWireRecord
+func heliosGoWireRecord(r *C.WrapperWireRecord) {
+	fn := wireTap.Load()
+	if fn == nil {
+		return
+	}
+	(*fn)(wireRecord(r, time.Now()))
+}
+
+func wireRecord(r *C.WrapperWireRecord, now time.Time) WireRecord {
+	rec := WireRecord{
+		Time:      now,
+		Sent:      r.direction == 0,
+		Transport: WireTransport(r.transport),
+		Endpoint:  int(r.endpoint),
+	}
+	if rec.Transport == WireUDP {
+		a := uint32(r.address)
+		rec.Addr = netip.AddrPortFrom(netip.AddrFrom4([4]byte{byte(a >> 24), byte(a >> 16), byte(a >> 8), byte(a)}), uint16(r.port))
+	}
+	if r.result != 0 {
+		rec.Err = ErrLibusbBase + Error(r.result)
+	}
+	if r.length > 0 {
+		rec.Data = unsafe.Slice((*byte)(unsafe.Pointer(r.data)), int(r.length))
+	}
+	return rec
+}
@@ -0,0 +1,330 @@
+//go:build helios_purego && !helios_stub
+
+package helios
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+
+	"github.com/ebitengine/purego"
+)
+
+// library is the prebuilt HeliosLaserDAC shared library, loaded at run
+// time with purego instead of compiling the bundled C++ sources, so no C
+// compiler is needed. The library keeps one device list for the process,
+// so all DACs share it. It cannot open single network devices, tune USB
+// transfers or capture wire traffic: OpenNetworkDevice fails with
+// ErrNotSupported, and SetUSBOptions and SetWireTap have no effect.
+type library struct {
+	fn   *libraryFuncs
+	done bool
+}
+
+// Backend names the SDK the package calls: "cgo" for the bundled C++
+// sources, "purego" for the prebuilt shared library, or "stub" for none.
+const Backend = "purego"
+
+// libraryFuncs are the functions exported by the shared library.
+type libraryFuncs struct {
+	OpenDevices                  func() int32
+	OpenDevicesOnlyUsb           func() int32
+	OpenDevicesOnlyNetwork       func() int32
+	ReScanDevices                func() int32
+	ReScanDevicesOnlyUsb         func() int32
+	ReScanDevicesOnlyNetwork     func() int32
+	CloseDevices                 func() int32
+	GetStatus                    func(dacNum uint32) int32
+	GetIsClosed                  func(dacNum uint32) int32
+	WriteFrame                   func(dacNum uint32, pps int32, flags uint8, points *Point, n int32) int32
+	WriteFrameHighResolution     func(dacNum uint32, pps int32, flags uint8, points *PointHighRes, n int32) int32
+	WriteFrameExtended           func(dacNum uint32, pps int32, flags uint8, points *PointExt, n int32) int32
+	SetShutter                   func(dacNum uint32, level bool) int32
+	GetName                      func(dacNum uint32, name *byte) int32
+	SetName                      func(dacNum uint32, name *byte) int32
+	Stop                         func(dacNum uint32) int32
+	GetIsUsb                     func(dacNum uint32) int32
+	GetFirmwareVersion           func(dacNum uint32) int32
+	GetSupportsHigherResolutions func(dacNum uint32) int32
+	SetLibusbDebugLogLevel       func(level int32) int32
+	EraseFirmware                func(dacNum uint32) int32
+}
+
+var loaded struct {
+	mu  sync.Mutex
+	fn  *libraryFuncs
+	err error
+}
+
+// defaultLibrary is the file name of the shared library, found on the
+// system's library search path.
+func defaultLibrary() string {
+	switch runtime.GOOS {
+	case "windows":
+		return "HeliosLaserDAC.dll"
+	case "darwin":
+		return "libHeliosLaserDAC.dylib"
+	}
+	return "libHeliosLaserDAC.so"
+}
+
+// LoadLibrary loads the prebuilt HeliosLaserDAC shared library from path,
+// or by its platform's file name from the library search path if path is
+// empty. DACs created before use the library loaded then. NewDAC loads the
+// library from the search path itself if LoadLibrary was not called; if
+// that fails, every call of the DAC returns ErrNotInitialized, and
+// LoadLibrary reports why.
+func LoadLibrary(path string) error {
+	loaded.mu.Lock()
+	defer loaded.mu.Unlock()
+	return load(path)
+}
+
+// load loads the library, holding loaded.mu.
+func load(path string) error {
+	if path == "" {
+		path = defaultLibrary()
+	}
+	fn, err := bind(path)
+	if err != nil {
+		loaded.err = fmt.Errorf("helios: loading %s: %w", path, err)
+		return loaded.err
+	}
+	loaded.fn, loaded.err = fn, nil
+	return nil
+}
+
+// bind opens the library and binds every function of libraryFuncs to the
+// export of the same name.
+func bind(path string) (fn *libraryFuncs, err error) {
+	h, err := openLibrary(path)
+	if err != nil {
+		return nil, err
+	}
+	fn = new(libraryFuncs)
+	var name string
+	defer func() {
+		// purego panics on missing exports.
+		if r := recover(); r != nil {
+			fn, err = nil, fmt.Errorf("binding %s: %v", name, r)
+		}
+	}()
+	bindings := []struct {
+		name string
+		fptr any
+	}{
+		{"OpenDevices", &fn.OpenDevices},
+		{"OpenDevicesOnlyUsb", &fn.OpenDevicesOnlyUsb},
+		{"OpenDevicesOnlyNetwork", &fn.OpenDevicesOnlyNetwork},
+		{"ReScanDevices", &fn.ReScanDevices},
+		{"ReScanDevicesOnlyUsb", &fn.ReScanDevicesOnlyUsb},
+		{"ReScanDevicesOnlyNetwork", &fn.ReScanDevicesOnlyNetwork},
+		{"CloseDevices", &fn.CloseDevices},
+		{"GetStatus", &fn.GetStatus},
+		{"GetIsClosed", &fn.GetIsClosed},
+		{"WriteFrame", &fn.WriteFrame},
+		{"WriteFrameHighResolution", &fn.WriteFrameHighResolution},
+		{"WriteFrameExtended", &fn.WriteFrameExtended},
+		{"SetShutter", &fn.SetShutter},
+		{"GetName", &fn.GetName},
+		{"SetName", &fn.SetName},
+		{"Stop", &fn.Stop},
+		{"GetIsUsb", &fn.GetIsUsb},
+		{"GetFirmwareVersion", &fn.GetFirmwareVersion},
+		{"GetSupportsHigherResolutions", &fn.GetSupportsHigherResolutions},
+		{"SetLibusbDebugLogLevel", &fn.SetLibusbDebugLogLevel},
+		{"EraseFirmware", &fn.EraseFirmware},
+	}
+	for _, b := range bindings {
+		name = b.name
+		purego.RegisterLibFunc(b.fptr, h, b.name)
+	}
+	return fn, nil
+}
+
+func newLibrary() library {
+	loaded.mu.Lock()
+	defer loaded.mu.Unlock()
+	if loaded.fn == nil && loaded.err == nil {
+		load("")
+	}
+	return library{fn: loaded.fn}
+}
+
+// notLoaded is returned by the calls of a DAC without the library.
+const notLoaded = int(ErrNotInitialized)
+
+func (l *library) close() {
+	if !l.done && l.fn != nil {
+		l.fn.CloseDevices()
+	}
+	l.done = true
+}
+
+func (l *library) closed() bool {
+	return l.done
+}
+
+func (l *library) openDevices() int {
+	if l.fn == nil {
+		return notLoaded
+	}
+	return int(l.fn.OpenDevices())
+}
+
+func (l *library) openDevicesOnlyUsb() int {
+	if l.fn == nil {
+		return notLoaded
+	}
+	return int(l.fn.OpenDevicesOnlyUsb())
+}
+
+func (l *library) openDevicesOnlyNetwork() int {
+	if l.fn == nil {
+		return notLoaded
+	}
+	return int(l.fn.OpenDevicesOnlyNetwork())
+}
+
+func (l *library) reScanDevices() int {
+	if l.fn == nil {
+		return notLoaded
+	}
+	return int(l.fn.ReScanDevices())
+}
+
+func (l *library) reScanDevicesOnlyUsb() int {
+	if l.fn == nil {
+		return notLoaded
+	}
+	return int(l.fn.ReScanDevicesOnlyUsb())
+}
+
+func (l *library) reScanDevicesOnlyNetwork() int {
+	if l.fn == nil {
+		return notLoaded
+	}
+	return int(l.fn.ReScanDevicesOnlyNetwork())
+}
+
+func (l *library) openNetworkDevice(addr, name string, serviceID uint8, unitID *[16]byte) int {
+	return int(ErrNotSupported)
+}
+
+func (l *library) closeDevices() {
+	if l.fn != nil {
+		l.fn.CloseDevices()
+	}
+}
+
+func (l *library) getStatus(deviceIndex int) int {
+	if l.fn == nil {
+		return notLoaded
+	}
+	return int(l.fn.GetStatus(uint32(deviceIndex)))
+}
+
+func (l *library) writeFrame(deviceIndex, pps, flags int, points []Point) int {
+	if l.fn == nil {
+		return notLoaded
+	}
+	return int(l.fn.WriteFrame(uint32(deviceIndex), int32(pps), uint8(flags), &points[0], int32(len(points))))
+}
+
+func (l *library) writeFrameHighResolution(deviceIndex, pps, flags int, points []PointHighRes) int {
+	if l.fn == nil {
+		return notLoaded
+	}
+	return int(l.fn.WriteFrameHighResolution(uint32(deviceIndex), int32(pps), uint8(flags), &points[0], int32(len(points))))
+}
+
+func (l *library) writeFrameExtended(deviceIndex, pps, flags int, points []PointExt) int {
+	if l.fn == nil {
+		return notLoaded
+	}
+	return int(l.fn.WriteFrameExtended(uint32(deviceIndex), int32(pps), uint8(flags), &points[0], int32(len(points))))
+}
+
+func (l *library) getName(deviceIndex int) string {
+	if l.fn == nil {
+		return ""
+	}
+	buf := make([]byte, 32)
+	if l.fn.GetName(uint32(deviceIndex), &buf[0]) < 0 {
+		return ""
+	}
+	return cString(buf)
+}
+
+func (l *library) setName(deviceIndex int, name string) int {
+	if l.fn == nil {
+		return notLoaded
+	}
+	buf := append([]byte(name), 0)
+	return int(l.fn.SetName(uint32(deviceIndex), &buf[0]))
+}
+
+func (l *library) getFirmwareVersion(deviceIndex int) int {
+	if l.fn == nil {
+		return notLoaded
+	}
+	return int(l.fn.GetFirmwareVersion(uint32(deviceIndex)))
+}
+
+func (l *library) getSupportsHigherResolutions(deviceIndex int) int {
+	if l.fn == nil {
+		return notLoaded
+	}
+	return int(l.fn.GetSupportsHigherResolutions(uint32(deviceIndex)))
+}
+
+func (l *library) getIsUsb(deviceIndex int) bool {
+	return l.fn != nil && l.fn.GetIsUsb(uint32(deviceIndex)) > 0
+}
+
+func (l *library) getIsClosed(deviceIndex int) bool {
+	return l.fn == nil || l.fn.GetIsClosed(uint32(deviceIndex)) != 0
+}
+
+func (l *library) stop(deviceIndex int) int {
+	if l.fn == nil {
+		return notLoaded
+	}
+	return int(l.fn.Stop(uint32(deviceIndex)))
+}
+
+func (l *library) setShutter(deviceIndex int, level bool) int {
+	if l.fn == nil {
+		return notLoaded
+	}
+	return int(l.fn.SetShutter(uint32(deviceIndex), level))
+}
+
+func (l *library) eraseFirmware(deviceIndex int) int {
+	if l.fn == nil {
+		return notLoaded
+	}
+	return int(l.fn.EraseFirmware(uint32(deviceIndex)))
+}
+
+func (l *library) setLibusbDebugLogLevel(logLevel int) int {
+	if l.fn == nil {
+		return notLoaded
+	}
+	return int(l.fn.SetLibusbDebugLogLevel(int32(logLevel)))
+}
+
+func (l *library) setUsbTransferOptions(frameTimeout, controlTimeout, bulkTransferSize, asyncTransfers uint32) {
+}
+
+func setWireTap(on bool) {}
+
+// cString returns the NUL-terminated string at the start of buf.
+func cString(buf []byte) string {
+	for i, b := range buf {
+		if b == 0 {
+			return string(buf[:i])
+		}
+	}
+	return string(buf)
+}
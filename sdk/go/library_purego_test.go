@@ -0,0 +1,27 @@
+//go:build helios_purego && !helios_stub
+
+package helios
+
+import "testing"
+
+func TestCString(t *testing.T) {
+	if s := cString([]byte("Helios 1\x00junk")); s != "Helios 1" {
+		t.Errorf("cString = %q", s)
+	}
+	if s := cString([]byte("unterminated")); s != "unterminated" {
+		t.Errorf("cString = %q", s)
+	}
+}
+
+func TestLibraryNotLoaded(t *testing.T) {
+	if err := LoadLibrary("/nonexistent/libHeliosLaserDAC.so"); err == nil {
+		t.Fatal("loading a missing library succeeded")
+	}
+	var l library
+	if n := l.openDevices(); n != int(ErrNotInitialized) {
+		t.Errorf("openDevices = %d, want ErrNotInitialized", n)
+	}
+	if !l.getIsClosed(0) {
+		t.Error("devices of an unloaded library are open")
+	}
+}
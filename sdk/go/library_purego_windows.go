@@ -0,0 +1,10 @@
+//go:build helios_purego && !helios_stub
+
+package helios
+
+import "syscall"
+
+func openLibrary(path string) (uintptr, error) {
+	h, err := syscall.LoadLibrary(path)
+	return uintptr(h), err
+}
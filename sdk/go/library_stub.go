@@ -0,0 +1,96 @@
+//go:build helios_stub
+
+package helios
+
+import "errors"
+
+// library is no SDK at all: building with the helios_stub tag compiles the
+// package without cgo, the C++ sources or libusb, for CI machines and for
+// programs that only use the frame processing and types. Every device call
+// fails with ErrNoBackend and no devices are ever found.
+type library struct {
+	done bool
+}
+
+// Backend names the SDK the package calls: "cgo" for the bundled C++
+// sources, "purego" for the prebuilt shared library, or "stub" for none.
+const Backend = "stub"
+
+// LoadLibrary loads the prebuilt HeliosLaserDAC shared library in builds
+// with the helios_purego tag. This build has no device backend, so it
+// always fails.
+func LoadLibrary(path string) error {
+	return errors.New("helios: LoadLibrary needs the helios_purego build tag")
+}
+
+func newLibrary() library {
+	return library{}
+}
+
+// noBackend is returned by every device call.
+const noBackend = int(ErrNoBackend)
+
+func (l *library) close() {
+	l.done = true
+}
+
+func (l *library) closed() bool {
+	return l.done
+}
+
+func (l *library) openDevices() int { return noBackend }
+
+func (l *library) openDevicesOnlyUsb() int { return noBackend }
+
+func (l *library) openDevicesOnlyNetwork() int { return noBackend }
+
+func (l *library) reScanDevices() int { return noBackend }
+
+func (l *library) reScanDevicesOnlyUsb() int { return noBackend }
+
+func (l *library) reScanDevicesOnlyNetwork() int { return noBackend }
+
+func (l *library) openNetworkDevice(addr, name string, serviceID uint8, unitID *[16]byte) int {
+	return noBackend
+}
+
+func (l *library) closeDevices() {}
+
+func (l *library) getStatus(deviceIndex int) int { return noBackend }
+
+func (l *library) writeFrame(deviceIndex, pps, flags int, points []Point) int {
+	return noBackend
+}
+
+func (l *library) writeFrameHighResolution(deviceIndex, pps, flags int, points []PointHighRes) int {
+	return noBackend
+}
+
+func (l *library) writeFrameExtended(deviceIndex, pps, flags int, points []PointExt) int {
+	return noBackend
+}
+
+func (l *library) getName(deviceIndex int) string { return "" }
+
+func (l *library) setName(deviceIndex int, name string) int { return noBackend }
+
+func (l *library) getFirmwareVersion(deviceIndex int) int { return noBackend }
+
+func (l *library) getSupportsHigherResolutions(deviceIndex int) int { return noBackend }
+
+func (l *library) getIsUsb(deviceIndex int) bool { return false }
+
+func (l *library) getIsClosed(deviceIndex int) bool { return true }
+
+func (l *library) stop(deviceIndex int) int { return noBackend }
+
+func (l *library) setShutter(deviceIndex int, level bool) int { return noBackend }
+
+func (l *library) eraseFirmware(deviceIndex int) int { return noBackend }
+
+func (l *library) setLibusbDebugLogLevel(logLevel int) int { return noBackend }
+
+func (l *library) setUsbTransferOptions(frameTimeout, controlTimeout, bulkTransferSize, asyncTransfers uint32) {
+}
+
+func setWireTap(on bool) {}
@@ -0,0 +1,28 @@
+//go:build helios_stub
+
+package helios
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestStubBackend(t *testing.T) {
+	if Backend != "stub" {
+		t.Fatalf("Backend = %q", Backend)
+	}
+	d := NewDAC()
+	defer d.Close()
+	if n := d.OpenDevices(); n != int(ErrNoBackend) {
+		t.Fatalf("OpenDevices = %d, want ErrNoBackend", n)
+	}
+	if d.NumDevices() != 0 {
+		t.Fatalf("NumDevices = %d", d.NumDevices())
+	}
+	if err := ErrorFromCode(d.GetStatus(0)); !errors.Is(err, ErrNoBackend) {
+		t.Fatalf("GetStatus error = %v", err)
+	}
+	if err := LoadLibrary("libHeliosLaserDAC.so"); err == nil {
+		t.Fatal("LoadLibrary succeeded")
+	}
+}
@@ -0,0 +1,41 @@
+package helios
+
+import "sync"
+
+// deviceLocks serializes calls into the C++ SDK. Calls for one device are
+// serialized by that device's mutex; scanning and closing, which replace
+// the device list, exclude all device calls.
+type deviceLocks struct {
+	scan sync.RWMutex
+
+	mu     sync.Mutex
+	device map[int]*sync.Mutex
+}
+
+// lockDevice locks one device and returns the function unlocking it.
+func (d *DAC) lockDevice(deviceIndex int) func() {
+	l := &d.locks
+	l.scan.RLock()
+	l.mu.Lock()
+	if l.device == nil {
+		l.device = make(map[int]*sync.Mutex)
+	}
+	m, ok := l.device[deviceIndex]
+	if !ok {
+		m = new(sync.Mutex)
+		l.device[deviceIndex] = m
+	}
+	l.mu.Unlock()
+	m.Lock()
+	return func() {
+		m.Unlock()
+		l.scan.RUnlock()
+	}
+}
+
+// lockAll waits for all device calls to finish and blocks new ones until
+// the returned function is called.
+func (d *DAC) lockAll() func() {
+	d.locks.scan.Lock()
+	return d.locks.scan.Unlock
+}
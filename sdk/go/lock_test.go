@@ -0,0 +1,31 @@
+package helios
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLockAllWaitsForDevices(t *testing.T) {
+	d := &DAC{}
+	unlock := d.lockDevice(0)
+
+	// A different device is not blocked.
+	d.lockDevice(1)()
+
+	scanned := make(chan struct{})
+	go func() {
+		d.lockAll()()
+		close(scanned)
+	}()
+	select {
+	case <-scanned:
+		t.Fatal("lockAll returned while a device call was in progress")
+	case <-time.After(20 * time.Millisecond):
+	}
+	unlock()
+	select {
+	case <-scanned:
+	case <-time.After(2 * time.Second):
+		t.Fatal("lockAll did not proceed after the device call finished")
+	}
+}
@@ -0,0 +1,59 @@
+package helios
+
+// A device either plays a frame once and then stops (FlagSingleMode) or
+// repeats it until the next frame replaces it. The firmware has no repeat
+// counter, so WriteFrameRepeat writes the passes back to back as one frame
+// and plays it once.
+
+// WriteFrameOnce writes a frame that plays once, after which the device
+// stops output without the caller timing a Stop call.
+func (d *DAC) WriteFrameOnce(deviceIndex int, pps int, points []Point) int {
+	return d.WriteFrame(deviceIndex, pps, FlagSingleMode, points)
+}
+
+// WriteFrameLoop writes a frame that repeats until the next frame written
+// to the device replaces it at the end of a pass, or Stop is called.
+func (d *DAC) WriteFrameLoop(deviceIndex int, pps int, points []Point) int {
+	return d.WriteFrame(deviceIndex, pps, 0, points)
+}
+
+// WriteFrameRepeat writes a frame that plays count times and then stops.
+// The passes are written as one frame, so unless SetSplitFrames is on,
+// count*len(points) must not exceed GetMaxFrameSize. It returns
+// ErrNullPoints for a count below 1.
+func (d *DAC) WriteFrameRepeat(deviceIndex int, pps int, points []Point, count int) int {
+	if count < 1 {
+		return int(ErrNullPoints)
+	}
+	return d.WriteFrame(deviceIndex, pps, FlagSingleMode, repeatPoints(points, count))
+}
+
+// repeatPoints returns points repeated count times, or points itself for a
+// count of 1.
+func repeatPoints(points []Point, count int) []Point {
+	if count == 1 {
+		return points
+	}
+	out := make([]Point, 0, len(points)*count)
+	for range count {
+		out = append(out, points...)
+	}
+	return out
+}
+
+// SetLoopMode sets whether frames submitted afterwards repeat until the next
+// one replaces them, rather than playing once (FlagsDefault). Looping keeps
+// the device scanning the last frame if the producer stalls; playing once
+// stops output instead. SkipRepeats always loops.
+func (m *DeviceManager) SetLoopMode(loop bool) {
+	if loop {
+		m.flags.Store(FlagsDefault &^ FlagSingleMode)
+	} else {
+		m.flags.Store(FlagsDefault)
+	}
+}
+
+// LoopMode reports whether frames are written looping; see SetLoopMode.
+func (m *DeviceManager) LoopMode() bool {
+	return m.flags.Load()&FlagSingleMode == 0
+}
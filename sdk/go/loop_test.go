@@ -0,0 +1,58 @@
+package helios
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRepeatPoints(t *testing.T) {
+	points := []Point{{X: 1}, {X: 2}}
+	if got := repeatPoints(points, 1); &got[0] != &points[0] {
+		t.Fatal("a single pass was copied")
+	}
+	got := repeatPoints(points, 3)
+	want := []Point{{X: 1}, {X: 2}, {X: 1}, {X: 2}, {X: 1}, {X: 2}}
+	if !slices.Equal(got, want) {
+		t.Fatalf("repeatPoints = %v, want %v", got, want)
+	}
+}
+
+func TestDeviceManagerLoopMode(t *testing.T) {
+	w := &fakeWriter{ready: true, frames: map[int][][]Point{}}
+	m := newDeviceManager(w, 1)
+	defer m.Close()
+
+	write := func() int {
+		t.Helper()
+		w.mu.Lock()
+		n := len(w.frames[0])
+		w.mu.Unlock()
+		if err := m.SubmitFrame(0, 30000, []Point{{X: 1}}); err != nil {
+			t.Fatal(err)
+		}
+		for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+			w.mu.Lock()
+			written, flags := len(w.frames[0]), w.flags
+			w.mu.Unlock()
+			if written > n {
+				return flags
+			}
+			if time.Now().After(deadline) {
+				t.Fatal("frame not written")
+			}
+		}
+	}
+
+	if m.LoopMode() || write() != FlagsDefault {
+		t.Fatal("frames do not play once by default")
+	}
+	m.SetLoopMode(true)
+	if !m.LoopMode() || write()&FlagSingleMode != 0 {
+		t.Fatal("frames written in single mode after SetLoopMode(true)")
+	}
+	m.SetLoopMode(false)
+	if m.LoopMode() || write()&FlagSingleMode == 0 {
+		t.Fatal("frames still loop after SetLoopMode(false)")
+	}
+}
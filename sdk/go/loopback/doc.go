@@ -0,0 +1,13 @@
+// Package loopback holds the SDK's end-to-end tests: known content, a
+// triangle, an ILDA file and an SVG drawing, is run through the optimizer
+// and output stages into the scanner simulator of motion.Response, and the
+// simulated point streams are compared with references stored in testdata,
+// within a small tolerance. A change anywhere along the way that alters
+// what the scanners would draw fails them.
+//
+// After an intended change, rewrite the references and review their diff:
+//
+//	go test ./loopback -update
+//
+// This package itself contains no code.
+package loopback
@@ -0,0 +1,171 @@
+package loopback
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/ilda"
+	"github.com/Grix/helios_dac/sdk/go/motion"
+	"github.com/Grix/helios_dac/sdk/go/scene"
+	"github.com/Grix/helios_dac/sdk/go/svg"
+	"github.com/Grix/helios_dac/sdk/go/vector"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+const (
+	pps    = 30000
+	budget = 1000
+
+	// tolerance is the largest difference allowed in any coordinate or
+	// color, absorbing floating-point differences between platforms.
+	tolerance = 2
+)
+
+// loop runs the paths through the pipeline: each is rendered as a layer,
+// the scheduler orders them and plans the travel between them, brightness
+// is compensated for scan speed, and the scanners are simulated following
+// the frame.
+func loop(paths []vector.Path) []helios.Point {
+	r := vector.NewRenderer(pps)
+	s := scene.NewScheduler(budget, pps)
+	for _, p := range paths {
+		frame := r.Render(p)
+		s.Add(&scene.Node{Layer: scene.LayerFunc(func(time.Duration, int) []helios.Point {
+			return frame
+		})})
+	}
+	// The second frame is planned with the travel of the first.
+	s.Frame(0)
+	frame := s.Frame(0)
+	frame = motion.Compensation{Floor: 0.2}.Apply(frame)
+	return motion.Response{Profile: motion.DefaultProfile}.Simulate(frame, pps)
+}
+
+func triangle() ([]vector.Path, error) {
+	return []vector.Path{{{
+		Points: [][2]float64{{0, 0.8}, {0.7, -0.6}, {-0.7, -0.6}, {0, 0.8}},
+		G:      1,
+	}}}, nil
+}
+
+func star() ([]vector.Path, error) {
+	f, err := os.Open(filepath.Join("testdata", "star.ild"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	frames, err := ilda.Read(f)
+	if err != nil {
+		return nil, err
+	}
+	return []vector.Path{vector.FromPoints(frames[0].Points, pps)}, nil
+}
+
+func drawing() ([]vector.Path, error) {
+	f, err := os.Open(filepath.Join("testdata", "drawing.svg"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	outlines, err := svg.Read(f)
+	if err != nil {
+		return nil, err
+	}
+	// Every outline is a layer of its own, for the scheduler to order.
+	var paths []vector.Path
+	for _, o := range outlines {
+		paths = append(paths, vector.FromSVG([]svg.Path{o}))
+	}
+	return paths, nil
+}
+
+func TestLoopback(t *testing.T) {
+	for name, load := range map[string]func() ([]vector.Path, error){
+		"triangle": triangle,
+		"star":     star,
+		"drawing":  drawing,
+	} {
+		t.Run(name, func(t *testing.T) {
+			paths, err := load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := loop(paths)
+			golden := filepath.Join("testdata", name+".golden")
+			if *update {
+				if err := os.WriteFile(golden, format(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			b, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v; run with -update to create it", err)
+			}
+			want, err := parse(b)
+			if err != nil {
+				t.Fatalf("%s: %v", golden, err)
+			}
+			compare(t, got, want)
+		})
+	}
+}
+
+// compare reports the points of got differing from want by more than the
+// tolerance, stopping after a few.
+func compare(t *testing.T, got, want []helios.Point) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%d points, want %d", len(got), len(want))
+	}
+	bad := 0
+	for i := range got {
+		if !near(got[i], want[i]) {
+			t.Errorf("point %d = %+v, want %+v", i, got[i], want[i])
+			if bad++; bad == 5 {
+				t.Fatal("too many differences")
+			}
+		}
+	}
+}
+
+func near(a, b helios.Point) bool {
+	diff := func(x, y int) bool { return x-y > tolerance || y-x > tolerance }
+	return !diff(int(a.X), int(b.X)) && !diff(int(a.Y), int(b.Y)) &&
+		!diff(int(a.R), int(b.R)) && !diff(int(a.G), int(b.G)) &&
+		!diff(int(a.B), int(b.B)) && !diff(int(a.I), int(b.I))
+}
+
+// format writes one point per line: x y r g b i.
+func format(points []helios.Point) []byte {
+	var buf bytes.Buffer
+	for _, p := range points {
+		fmt.Fprintf(&buf, "%d %d %d %d %d %d\n", p.X, p.Y, p.R, p.G, p.B, p.I)
+	}
+	return buf.Bytes()
+}
+
+func parse(b []byte) ([]helios.Point, error) {
+	var points []helios.Point
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for n := 1; s.Scan(); n++ {
+		if strings.TrimSpace(s.Text()) == "" {
+			continue
+		}
+		var p helios.Point
+		if _, err := fmt.Sscanf(s.Text(), "%d %d %d %d %d %d", &p.X, &p.Y, &p.R, &p.G, &p.B, &p.I); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		points = append(points, p)
+	}
+	return points, s.Err()
+}
@@ -0,0 +1,229 @@
+package helios
+
+import (
+	"runtime"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// deviceWriter is the part of *DAC driven by a DeviceManager.
+type deviceWriter interface {
+	GetStatus(deviceIndex int) int
+	WriteFrame(deviceIndex int, pps int, flags int, points []Point) int
+	WriteFrameMeta(deviceIndex int, pps int, flags int, points []Point, meta FrameMeta) int
+	ReScanDevices() int
+	touch(deviceIndex int)
+	dropFrame(deviceIndex int)
+}
+
+type managedFrame struct {
+	pps    int
+	points []Point
+	meta   FrameMeta
+}
+
+// DeviceManager runs one output goroutine per device. Each goroutine is
+// locked to its OS thread, waits for its device to become ready and writes
+// the most recently submitted frame, so callers only produce frames.
+type DeviceManager struct {
+	// OnError, if set, is called from a device's goroutine when a write
+	// fails, including writes that are retried.
+	OnError func(deviceIndex int, err error)
+
+	// Retry is how failing writes are retried and dropped devices
+	// reopened. It is DefaultRetryPolicy unless changed before frames are
+	// submitted; the zero value writes each frame once.
+	Retry RetryPolicy
+
+	// OnStatus, if set, is called from a device's goroutine when the
+	// status it polls changes. Alternating between StatusReady and
+	// StatusBusy, as a working device does every frame, is not reported,
+	// but the first healthy status after a failure is.
+	OnStatus func(deviceIndex int, s DeviceStatus)
+
+	// SkipRepeats, if set, writes frames in looping mode and drops frames
+	// identical to the one last written, so static content is sent once
+	// and then repeated by the DAC itself instead of crossing the USB bus
+	// again every frame. A new frame replaces the looping one when its
+	// current pass ends. Dropped frames still count as output for the
+	// DAC's watchdog. Set it before submitting frames.
+	SkipRepeats bool
+
+	// ScanFail configures the scan-fail interlock, which writes frames
+	// blanked while they would hold the lit beam still; see
+	// ScanFailSettings. It is DefaultScanFail unless changed before frames
+	// are submitted; the zero value disables it.
+	ScanFail ScanFailSettings
+
+	// OnScanFail, if set, is called from a device's goroutine when the
+	// interlock starts or stops blanking the device.
+	OnScanFail func(deviceIndex int, ev ScanFailEvent)
+
+	dac          deviceWriter
+	flags        atomic.Int32
+	pollInterval time.Duration
+	queues       []chan managedFrame
+	done         chan struct{}
+	wg           sync.WaitGroup
+}
+
+// DefaultPollInterval is how often a manager's goroutines poll a busy
+// device's status.
+const DefaultPollInterval = 500 * time.Microsecond
+
+// NewDeviceManager starts an output goroutine for each of the DAC's open
+// devices, writing with FlagsDefault unless SetLoopMode changes it. The
+// devices must not be opened again with OpenDevices while the manager runs;
+// ReScanDevices keeps the indices of open devices, and the manager calls it
+// to reopen dropped ones.
+func NewDeviceManager(dac *DAC) *DeviceManager {
+	return newDeviceManager(dac, dac.NumDevices())
+}
+
+func newDeviceManager(dac deviceWriter, numDevices int) *DeviceManager {
+	m := &DeviceManager{
+		dac:          dac,
+		pollInterval: DefaultPollInterval,
+		ScanFail:     DefaultScanFail,
+		Retry:        DefaultRetryPolicy,
+		queues:       make([]chan managedFrame, numDevices),
+		done:         make(chan struct{}),
+	}
+	m.flags.Store(FlagsDefault)
+	for i := range m.queues {
+		m.queues[i] = make(chan managedFrame, 1)
+		m.wg.Add(1)
+		go m.run(i)
+	}
+	return m
+}
+
+// SubmitFrame queues points for a device. It never blocks: a frame that has
+// not been written yet is replaced, so the device always receives the
+// latest content.
+func (m *DeviceManager) SubmitFrame(deviceIndex int, pps int, points []Point) error {
+	return m.SubmitFrameMeta(deviceIndex, pps, points, FrameMeta{})
+}
+
+// SubmitFrameMeta queues points for a device like SubmitFrame, with
+// metadata passed to the DAC's write hook when the frame is written.
+func (m *DeviceManager) SubmitFrameMeta(deviceIndex int, pps int, points []Point, meta FrameMeta) error {
+	if deviceIndex < 0 || deviceIndex >= len(m.queues) {
+		return ErrInvalidDevNum
+	}
+	if len(points) == 0 {
+		return ErrNullPoints
+	}
+	q := m.queues[deviceIndex]
+	f := managedFrame{pps, points, meta}
+	for {
+		select {
+		case q <- f:
+			return nil
+		default:
+		}
+		select {
+		case <-q: // drop the stale frame
+			m.dac.dropFrame(deviceIndex)
+		default:
+		}
+	}
+}
+
+// Close stops the output goroutines. Frames not yet written are dropped.
+func (m *DeviceManager) Close() {
+	close(m.done)
+	m.wg.Wait()
+}
+
+func (m *DeviceManager) run(deviceIndex int) {
+	defer m.wg.Done()
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	q := m.queues[deviceIndex]
+	var last managedFrame
+	var interlock scanFail
+	reported := DeviceStatus{Kind: StatusReady}
+	var rescanned time.Time
+	poll := func() bool {
+		s := StatusFromCode(m.dac.GetStatus(deviceIndex))
+		if s != reported && !(s.Healthy() && reported.Healthy()) {
+			reported = s
+			if m.OnStatus != nil {
+				m.OnStatus(deviceIndex, s)
+			}
+		}
+		if s.Kind == StatusClosed && m.Retry.Reopen > 0 && time.Since(rescanned) >= m.Retry.Reopen {
+			m.dac.ReScanDevices()
+			rescanned = time.Now()
+		}
+		return s.Ready()
+	}
+	for {
+		var f managedFrame
+		select {
+		case <-m.done:
+			return
+		case f = <-q:
+		}
+		if m.SkipRepeats && f.pps == last.pps && slices.Equal(f.points, last.points) {
+			m.dac.touch(deviceIndex)
+			continue
+		}
+		for !poll() {
+			select {
+			case <-m.done:
+				return
+			case newer := <-q:
+				m.dac.dropFrame(deviceIndex)
+				f = newer
+			case <-time.After(m.pollInterval):
+			}
+		}
+		flags := int(m.flags.Load())
+		if m.SkipRepeats {
+			flags &^= FlagSingleMode
+		}
+		points := f.points
+		if m.ScanFail.Duration > 0 {
+			ev, changed := interlock.check(m.ScanFail, points, f.pps, flags, time.Now())
+			if ev.Tripped {
+				points = blanked(points)
+			}
+			if changed && m.OnScanFail != nil {
+				m.OnScanFail(deviceIndex, ev)
+			}
+		}
+		if m.write(deviceIndex, f.pps, flags, points, f.meta) {
+			last = f
+		}
+	}
+}
+
+// write writes a frame, retrying as m.Retry allows, and reports whether it
+// succeeded.
+func (m *DeviceManager) write(deviceIndex, pps, flags int, points []Point, meta FrameMeta) bool {
+	for attempt := 1; ; attempt++ {
+		err := ErrorFromCode(m.dac.WriteFrameMeta(deviceIndex, pps, flags, points, meta))
+		if err == nil {
+			return true
+		}
+		if m.OnError != nil {
+			m.OnError(deviceIndex, err)
+		}
+		if attempt >= m.Retry.MaxAttempts || !IsTransient(err) {
+			if m.Retry.OnPermanentFailure != nil {
+				m.Retry.OnPermanentFailure(deviceIndex, err)
+			}
+			return false
+		}
+		select {
+		case <-m.done:
+			return false
+		case <-time.After(m.Retry.backoff(attempt)):
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package helios
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeWriter struct {
+	mu        sync.Mutex
+	ready     bool
+	err       Error   // returned by GetStatus if set
+	writeErrs []Error // returned by the next writes
+	rescans   int
+	frames    map[int][][]Point
+	metas     []FrameMeta
+	flags     int
+	touches   int
+	dropped   int
+}
+
+func (f *fakeWriter) GetStatus(int) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != 0 {
+		return int(f.err)
+	}
+	if f.ready {
+		return 1
+	}
+	return 0
+}
+
+func (f *fakeWriter) WriteFrame(i, pps, flags int, points []Point) int {
+	return f.WriteFrameMeta(i, pps, flags, points, FrameMeta{})
+}
+
+func (f *fakeWriter) WriteFrameMeta(i, pps, flags int, points []Point, meta FrameMeta) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.frames[i] = append(f.frames[i], points)
+	f.metas = append(f.metas, meta)
+	f.flags = flags
+	if len(f.writeErrs) > 0 {
+		err := f.writeErrs[0]
+		f.writeErrs = f.writeErrs[1:]
+		return int(err)
+	}
+	return 1
+}
+
+func (f *fakeWriter) ReScanDevices() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rescans++
+	if f.err == ErrDeviceClosed {
+		f.err = 0
+	}
+	return len(f.frames)
+}
+
+func (f *fakeWriter) touch(int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.touches++
+}
+
+func (f *fakeWriter) dropFrame(int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.dropped++
+}
+
+func (f *fakeWriter) written(i int) [][]Point {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.frames[i]
+}
+
+func TestDeviceManagerWritesLatestFrame(t *testing.T) {
+	w := &fakeWriter{frames: map[int][][]Point{}}
+	m := newDeviceManager(w, 2)
+	defer m.Close()
+
+	// While device 1 is busy, only the last submitted frame survives.
+	for x := uint16(1); x <= 3; x++ {
+		if err := m.SubmitFrame(1, 30000, []Point{{X: x}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.mu.Lock()
+	w.ready = true
+	w.mu.Unlock()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(w.written(1)) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("frame was not written")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if got := w.written(1); len(got) != 1 || got[0][0].X != 3 {
+		t.Fatalf("written = %v, want only the latest frame", got)
+	}
+	w.mu.Lock()
+	if w.dropped != 2 {
+		t.Errorf("dropped = %d, want 2", w.dropped)
+	}
+	w.mu.Unlock()
+	if len(w.written(0)) != 0 {
+		t.Fatal("frame written to the wrong device")
+	}
+	if err := m.SubmitFrame(2, 30000, []Point{{}}); err != ErrInvalidDevNum {
+		t.Fatalf("err = %v, want ErrInvalidDevNum", err)
+	}
+}
+
+func TestDeviceManagerSkipRepeats(t *testing.T) {
+	w := &fakeWriter{ready: true, frames: map[int][][]Point{}}
+	m := newDeviceManager(w, 1)
+	m.SkipRepeats = true
+	defer m.Close()
+
+	submit := func(points []Point) {
+		if err := m.SubmitFrame(0, 30000, points); err != nil {
+			t.Fatal(err)
+		}
+		// Let the device goroutine take each frame before the next.
+		time.Sleep(5 * time.Millisecond)
+	}
+	submit([]Point{{X: 1}})
+	submit([]Point{{X: 1}})
+	submit([]Point{{X: 1}})
+	submit([]Point{{X: 2}})
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.frames[0]) != 2 || w.frames[0][1][0].X != 2 {
+		t.Fatalf("written = %v, want the two distinct frames", w.frames[0])
+	}
+	if w.touches != 2 {
+		t.Fatalf("touches = %d, want 2 for the skipped frames", w.touches)
+	}
+	if w.flags&FlagSingleMode != 0 {
+		t.Fatal("frames written in single mode, so the DAC will not repeat them")
+	}
+}
+
+func TestDeviceManagerOnStatus(t *testing.T) {
+	w := &fakeWriter{err: ErrNetwork, frames: map[int][][]Point{}}
+	m := newDeviceManager(w, 1)
+	statuses := make(chan DeviceStatus, 10)
+	m.OnStatus = func(_ int, s DeviceStatus) { statuses <- s }
+	defer m.Close()
+
+	if err := m.SubmitFrame(0, 30000, []Point{{}}); err != nil {
+		t.Fatal(err)
+	}
+	next := func() DeviceStatus {
+		select {
+		case s := <-statuses:
+			return s
+		case <-time.After(2 * time.Second):
+			t.Fatal("no status reported")
+			return DeviceStatus{}
+		}
+	}
+	if s := next(); s.Kind != StatusReconnecting {
+		t.Fatalf("status = %v, want reconnecting", s)
+	}
+	w.mu.Lock()
+	w.err = 0
+	w.mu.Unlock()
+	if s := next(); s.Kind != StatusBusy {
+		t.Fatalf("status = %v, want busy", s)
+	}
+	w.mu.Lock()
+	w.ready = true
+	w.mu.Unlock()
+	deadline := time.Now().Add(2 * time.Second)
+	for len(w.written(0)) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("frame was not written")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case s := <-statuses:
+		t.Fatalf("status %v reported going from busy to ready", s)
+	default:
+	}
+}
+
+func TestDeviceManagerRetries(t *testing.T) {
+	w := &fakeWriter{ready: true, frames: map[int][][]Point{}}
+	w.writeErrs = []Error{ErrLibusbBase - 7, ErrLibusbBase - 9, ErrTooManyPoints, ErrLibusbBase - 1, ErrLibusbBase - 1, ErrLibusbBase - 1}
+	m := newDeviceManager(w, 1)
+	m.Retry.Backoff = time.Microsecond
+	failures := make(chan error, 10)
+	m.Retry.OnPermanentFailure = func(_ int, err error) { failures <- err }
+	defer m.Close()
+
+	wait := func(n int) {
+		deadline := time.Now().Add(2 * time.Second)
+		for len(w.written(0)) < n {
+			if time.Now().After(deadline) {
+				t.Fatalf("%d writes, want %d", len(w.written(0)), n)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	// A timeout and a pipe error are retried, and the third write succeeds.
+	if err := m.SubmitFrame(0, 30000, []Point{{X: 1}}); err != nil {
+		t.Fatal(err)
+	}
+	wait(3)
+	// A frame the device rejects is not retried.
+	if err := m.SubmitFrame(0, 30000, []Point{{X: 2}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-failures; err != ErrTooManyPoints {
+		t.Fatalf("permanent failure %v, want ErrTooManyPoints", err)
+	}
+	// Three I/O errors exhaust the attempts.
+	if err := m.SubmitFrame(0, 30000, []Point{{X: 3}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-failures; err != ErrLibusbBase-1 {
+		t.Fatalf("permanent failure %v, want the I/O error", err)
+	}
+	if n := len(w.written(0)); n != 7 {
+		t.Fatalf("%d writes, want 7", n)
+	}
+}
+
+func TestDeviceManagerReopens(t *testing.T) {
+	w := &fakeWriter{ready: true, err: ErrDeviceClosed, frames: map[int][][]Point{}}
+	m := newDeviceManager(w, 1)
+	defer m.Close()
+
+	if err := m.SubmitFrame(0, 30000, []Point{{X: 1}}); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for len(w.written(0)) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("frame was not written after the device was reopened")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.rescans != 1 {
+		t.Fatalf("%d rescans, want 1", w.rescans)
+	}
+}
+
+func TestDeviceManagerPassesFrameMeta(t *testing.T) {
+	w := &fakeWriter{ready: true, frames: map[int][][]Point{}}
+	m := newDeviceManager(w, 1)
+	defer m.Close()
+
+	meta := NewFrameMeta("test")
+	if err := m.SubmitFrameMeta(0, 30000, []Point{{X: 1}}, meta); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for len(w.written(0)) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("frame was not written")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.metas) != 1 || w.metas[0] != meta {
+		t.Fatalf("metas = %v, want [%v]", w.metas, meta)
+	}
+}
@@ -0,0 +1,349 @@
+// Package mapping routes the inputs of physical controllers to parameters
+// and presets through banks of pages, so one controller with a few faders
+// can address many generators.
+//
+// A Config, usually loaded from JSON, lists banks of pages. Each page maps
+// controller inputs to parameters (param.Set) or preset recalls
+// (preset.Bank); global controls apply whatever page is selected and are
+// typically used to switch pages. Inputs are named by the caller: OSC
+// addresses as they are, MIDI controllers with CC.
+//
+// Faders use soft takeover: after a page change, or after the parameter was
+// changed elsewhere, a fader only takes control once it reaches the
+// parameter's current value, so parameters never jump.
+package mapping
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/param"
+	"github.com/Grix/helios_dac/sdk/go/preset"
+	"github.com/Grix/helios_dac/sdk/go/store"
+)
+
+// Config is a set of banks and global controls.
+type Config struct {
+	Banks  []Bank    `json:"banks"`
+	Global []Control `json:"global,omitempty"`
+}
+
+// Bank is a group of pages, usually one per show section or generator
+// family.
+type Bank struct {
+	Name  string `json:"name"`
+	Pages []Page `json:"pages"`
+}
+
+// Page assigns a meaning to each controller input.
+type Page struct {
+	Name     string    `json:"name"`
+	Controls []Control `json:"controls"`
+}
+
+// Page navigation actions.
+const (
+	NextPage = "next_page"
+	PrevPage = "prev_page"
+	NextBank = "next_bank"
+	PrevBank = "prev_bank"
+)
+
+// Control maps one input. Exactly one of Param, Preset and Action is set.
+type Control struct {
+	// Input names the controller input.
+	Input string `json:"input"`
+
+	// Target names the parameter set or preset bank, as registered with
+	// Mapper.Params or Mapper.Presets.
+	Target string `json:"target,omitempty"`
+
+	// Param is a parameter of Target, set from the input's 0-1 value.
+	Param string `json:"param,omitempty"`
+
+	// Preset is a preset of Target, recalled when the input is pressed
+	// (rises to 0.5 or above), crossfading over Fade seconds.
+	Preset string  `json:"preset,omitempty"`
+	Fade   float64 `json:"fade,omitempty"`
+
+	// Action is NextPage, PrevPage, NextBank or PrevBank, performed when
+	// the input is pressed.
+	Action string `json:"action,omitempty"`
+
+	// Jump disables soft takeover, so the parameter follows the input
+	// immediately.
+	Jump bool `json:"jump,omitempty"`
+}
+
+// check reports configuration mistakes in c.
+func (c Control) check() error {
+	set := 0
+	for _, s := range []string{c.Param, c.Preset, c.Action} {
+		if s != "" {
+			set++
+		}
+	}
+	switch {
+	case c.Input == "":
+		return errors.New("control without input")
+	case set != 1:
+		return fmt.Errorf("input %q: exactly one of param, preset and action must be set", c.Input)
+	case c.Action != "" && c.Action != NextPage && c.Action != PrevPage && c.Action != NextBank && c.Action != PrevBank:
+		return fmt.Errorf("input %q: unknown action %q", c.Input, c.Action)
+	}
+	return nil
+}
+
+// configSchema versions mapping configs. Add a migration and bump the
+// version when a change to Config would misread older files.
+var configSchema = store.Schema{Name: "mapping config", Version: 1}
+
+// ParseConfig decodes a JSON config written for this or an earlier version.
+// Configs without a "version" member are version 1.
+func ParseConfig(data []byte) (Config, error) {
+	var c Config
+	if err := configSchema.Decode(data, &c); err != nil {
+		return c, fmt.Errorf("mapping: %w", err)
+	}
+	return c, nil
+}
+
+// MarshalConfig encodes c as versioned JSON for ParseConfig.
+func MarshalConfig(c Config) ([]byte, error) {
+	return configSchema.Encode(c)
+}
+
+// CC returns the input name of a MIDI control change,
+// "midi/<channel>/cc/<number>". Its value is the controller value / 127.
+func CC(channel, number int) string {
+	return fmt.Sprintf("midi/%d/cc/%d", channel, number)
+}
+
+// DefaultTolerance is how close, on the 0-1 scale, a fader must come to a
+// parameter's value to take it over.
+const DefaultTolerance = 0.02
+
+// input is the state of one controller input.
+type input struct {
+	last    float64 // last value received, NaN before the first
+	caught  bool    // soft takeover: the input controls its parameter
+	written float64 // normalized value the input last set
+}
+
+// Mapper applies controller input according to a Config. It is safe for
+// concurrent use.
+type Mapper struct {
+	// Tolerance is how close a fader must come to take over a parameter.
+	// Zero means DefaultTolerance.
+	Tolerance float64
+
+	mu      sync.Mutex
+	cfg     Config
+	params  map[string]*param.Set
+	presets map[string]*preset.Bank
+	bank    int
+	page    int
+	inputs  map[string]*input
+}
+
+// NewMapper returns a mapper for cfg, starting on the first page of the
+// first bank.
+func NewMapper(cfg Config) (*Mapper, error) {
+	for _, b := range cfg.Banks {
+		if len(b.Pages) == 0 {
+			return nil, fmt.Errorf("mapping: bank %q has no pages", b.Name)
+		}
+		for _, p := range b.Pages {
+			for _, c := range p.Controls {
+				if err := c.check(); err != nil {
+					return nil, fmt.Errorf("mapping: bank %q page %q: %w", b.Name, p.Name, err)
+				}
+			}
+		}
+	}
+	for _, c := range cfg.Global {
+		if err := c.check(); err != nil {
+			return nil, fmt.Errorf("mapping: global: %w", err)
+		}
+	}
+	return &Mapper{
+		cfg:     cfg,
+		params:  make(map[string]*param.Set),
+		presets: make(map[string]*preset.Bank),
+		inputs:  make(map[string]*input),
+	}, nil
+}
+
+// Params registers a parameter set as a target.
+func (m *Mapper) Params(name string, set *param.Set) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.params[name] = set
+}
+
+// Presets registers a preset bank as a target.
+func (m *Mapper) Presets(name string, b *preset.Bank) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.presets[name] = b
+}
+
+// Inputs returns the name of every input used by the config, for binding
+// them to a controller.
+func (m *Mapper) Inputs() []string {
+	seen := make(map[string]bool)
+	var out []string
+	add := func(cs []Control) {
+		for _, c := range cs {
+			if !seen[c.Input] {
+				seen[c.Input] = true
+				out = append(out, c.Input)
+			}
+		}
+	}
+	add(m.cfg.Global)
+	for _, b := range m.cfg.Banks {
+		for _, p := range b.Pages {
+			add(p.Controls)
+		}
+	}
+	return out
+}
+
+// Page returns the selected bank and page indices.
+func (m *Mapper) Page() (bank, page int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.bank, m.page
+}
+
+// SelectPage selects a page. Out of range indices are ignored.
+func (m *Mapper) SelectPage(bank, page int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.selectPage(bank, page)
+}
+
+func (m *Mapper) selectPage(bank, page int) {
+	if bank < 0 || bank >= len(m.cfg.Banks) || page < 0 || page >= len(m.cfg.Banks[bank].Pages) {
+		return
+	}
+	if bank == m.bank && page == m.page {
+		return
+	}
+	m.bank, m.page = bank, page
+	// Every fader now faces a different parameter.
+	for _, in := range m.inputs {
+		in.caught = false
+	}
+}
+
+// ErrUnmapped is returned by Input for inputs without a control on the
+// selected page.
+var ErrUnmapped = errors.New("mapping: input not mapped")
+
+// Input applies a value, 0-1, received from a controller input.
+func (m *Mapper) Input(name string, v float64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	in := m.inputs[name]
+	if in == nil {
+		in = &input{last: math.NaN()}
+		m.inputs[name] = in
+	}
+	prev := in.last
+	in.last = v
+	c, ok := m.control(name)
+	if !ok {
+		return ErrUnmapped
+	}
+	pressed := v >= 0.5 && !(prev >= 0.5)
+	switch {
+	case c.Action != "":
+		if pressed {
+			m.navigate(c.Action)
+		}
+		return nil
+	case c.Preset != "":
+		b := m.presets[c.Target]
+		if b == nil {
+			return fmt.Errorf("mapping: unknown preset bank %q", c.Target)
+		}
+		if pressed {
+			return b.Recall(c.Preset, time.Duration(c.Fade*float64(time.Second)))
+		}
+		return nil
+	}
+	set := m.params[c.Target]
+	if set == nil {
+		return fmt.Errorf("mapping: unknown parameter set %q", c.Target)
+	}
+	p := set.Lookup(c.Param)
+	if p == nil {
+		return fmt.Errorf("mapping: %s has no parameter %q", c.Target, c.Param)
+	}
+	if !c.Jump && !m.takeover(in, p.Normalized(), prev, v) {
+		return nil
+	}
+	p.SetNormalized(v)
+	in.written = p.Normalized()
+	return nil
+}
+
+// takeover reports whether an input moving from prev to v controls a
+// parameter currently at cur.
+func (m *Mapper) takeover(in *input, cur, prev, v float64) bool {
+	tol := m.Tolerance
+	if tol == 0 {
+		tol = DefaultTolerance
+	}
+	if in.caught && math.Abs(cur-in.written) > tol {
+		// Changed by something else, such as a preset recall.
+		in.caught = false
+	}
+	if !in.caught {
+		crossed := !math.IsNaN(prev) && (prev-cur)*(v-cur) <= 0
+		in.caught = crossed || math.Abs(v-cur) <= tol
+	}
+	return in.caught
+}
+
+// control returns the control for an input on the selected page, falling
+// back to the global controls.
+func (m *Mapper) control(name string) (Control, bool) {
+	if m.bank < len(m.cfg.Banks) {
+		for _, c := range m.cfg.Banks[m.bank].Pages[m.page].Controls {
+			if c.Input == name {
+				return c, true
+			}
+		}
+	}
+	for _, c := range m.cfg.Global {
+		if c.Input == name {
+			return c, true
+		}
+	}
+	return Control{}, false
+}
+
+// navigate performs a page navigation action, wrapping around.
+func (m *Mapper) navigate(action string) {
+	n := len(m.cfg.Banks)
+	if n == 0 {
+		return
+	}
+	pages := len(m.cfg.Banks[m.bank].Pages)
+	switch action {
+	case NextPage:
+		m.selectPage(m.bank, (m.page+1)%pages)
+	case PrevPage:
+		m.selectPage(m.bank, (m.page+pages-1)%pages)
+	case NextBank:
+		m.selectPage((m.bank+1)%n, 0)
+	case PrevBank:
+		m.selectPage((m.bank+n-1)%n, 0)
+	}
+}
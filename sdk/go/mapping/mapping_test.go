@@ -0,0 +1,146 @@
+package mapping
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Grix/helios_dac/sdk/go/param"
+	"github.com/Grix/helios_dac/sdk/go/preset"
+	"github.com/Grix/helios_dac/sdk/go/show"
+)
+
+const config = `{
+  "banks": [
+    {"name": "beams", "pages": [
+      {"name": "size", "controls": [
+        {"input": "/1/fader1", "target": "spiral", "param": "size"},
+        {"input": "/1/push1", "target": "looks", "preset": "dark"}
+      ]},
+      {"name": "speed", "controls": [
+        {"input": "/1/fader1", "target": "spiral", "param": "speed"}
+      ]}
+    ]},
+    {"name": "raw", "pages": [
+      {"name": "jump", "controls": [
+        {"input": "midi/1/cc/7", "target": "spiral", "param": "size", "jump": true}
+      ]}
+    ]}
+  ],
+  "global": [
+    {"input": "/1/next", "action": "next_page"},
+    {"input": "/1/bank", "action": "next_bank"}
+  ]
+}`
+
+type spiral struct {
+	Size  float64 `param:"size,min=0,max=1"`
+	Speed float64 `param:"speed,min=0,max=10"`
+}
+
+func newMapper(t *testing.T) (*Mapper, *spiral, *preset.Bank) {
+	t.Helper()
+	cfg, err := ParseConfig([]byte(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := NewMapper(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := &spiral{Size: 0.5, Speed: 5}
+	set, err := param.Of(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	looks := preset.NewBank(set, &show.ManualClock{})
+	m.Params("spiral", set)
+	m.Presets("looks", looks)
+	return m, g, looks
+}
+
+func TestSoftTakeover(t *testing.T) {
+	m, g, _ := newMapper(t)
+
+	// The fader starts away from the parameter and must reach it first.
+	m.Input("/1/fader1", 0.1)
+	m.Input("/1/fader1", 0.3)
+	if g.Size != 0.5 {
+		t.Fatalf("size = %v before takeover", g.Size)
+	}
+	m.Input("/1/fader1", 0.6) // crosses 0.5
+	m.Input("/1/fader1", 0.7)
+	if g.Size != 0.7 {
+		t.Fatalf("size = %v after takeover, want 0.7", g.Size)
+	}
+
+	// On the next page the same fader faces speed, at 0.5 normalized.
+	m.Input("/1/next", 1)
+	if b, p := m.Page(); b != 0 || p != 1 {
+		t.Fatalf("page = %d/%d, want 0/1", b, p)
+	}
+	m.Input("/1/fader1", 0.9)
+	if g.Speed != 5 || g.Size != 0.7 {
+		t.Fatalf("page change jumped: %+v", *g)
+	}
+	m.Input("/1/fader1", 0.51)
+	if g.Speed != 5.1 {
+		t.Fatalf("speed = %v, want 5.1", g.Speed)
+	}
+
+	// A change from elsewhere releases the fader until it catches up.
+	m.SelectPage(0, 0)
+	m.Input("/1/fader1", 0.7)
+	m.Input("/1/fader1", 0.8)
+	if g.Size != 0.8 {
+		t.Fatalf("size = %v, want 0.8", g.Size)
+	}
+	g.Size = 0.2
+	m.Input("/1/fader1", 0.85)
+	if g.Size != 0.2 {
+		t.Fatalf("size = %v, fader overrode an outside change", g.Size)
+	}
+}
+
+func TestJumpAndActions(t *testing.T) {
+	m, g, looks := newMapper(t)
+	m.Input("/1/bank", 1)
+	m.Input(CC(1, 7), 0.9)
+	if g.Size != 0.9 {
+		t.Fatalf("jump control: size = %v", g.Size)
+	}
+	if err := m.Input("/1/push1", 1); !errors.Is(err, ErrUnmapped) {
+		t.Fatalf("input of another bank: %v", err)
+	}
+
+	m.Input("/1/bank", 0)
+	m.Input("/1/bank", 1) // wraps to the first bank
+	g.Size = 0
+	looks.Save("dark")
+	g.Size = 1
+	m.Input("/1/push1", 0) // released since the press on the other bank
+	if err := m.Input("/1/push1", 1); err != nil || g.Size != 0 {
+		t.Fatalf("preset recall: %v, size %v", err, g.Size)
+	}
+	g.Size = 1
+	m.Input("/1/push1", 1) // held, not pressed again
+	if g.Size != 1 {
+		t.Fatal("held button recalled again")
+	}
+}
+
+func TestNewMapperErrors(t *testing.T) {
+	for _, cfg := range []Config{
+		{Banks: []Bank{{Name: "empty"}}},
+		{Global: []Control{{Input: "/x"}}},
+		{Global: []Control{{Input: "/x", Param: "a", Action: NextPage}}},
+		{Global: []Control{{Input: "/x", Action: "jump"}}},
+		{Global: []Control{{Param: "a"}}},
+	} {
+		if _, err := NewMapper(cfg); err == nil {
+			t.Errorf("NewMapper(%+v) succeeded", cfg)
+		}
+	}
+	if _, err := ParseConfig([]byte("{")); err == nil {
+		t.Error("ParseConfig accepted invalid JSON")
+	}
+}
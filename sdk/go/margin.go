@@ -0,0 +1,142 @@
+package helios
+
+import (
+	"errors"
+	"math"
+)
+
+// A margin keeps the beam away from the edges of the scan field, to protect
+// scanners from over-scan and keep the beam inside a mechanical aperture.
+// It is the last stage moving the points of every frame written to a
+// device, so no content or correction can move a point past it.
+
+// Margin is the distance kept from each edge of the scan field, in device
+// coordinates (0 - 4095). The zero Margin lets points reach the edges.
+type Margin struct {
+	Left   float64 `json:"left"`
+	Right  float64 `json:"right"`
+	Bottom float64 `json:"bottom"`
+	Top    float64 `json:"top"`
+
+	// Compress scales the whole frame into the area inside the margin,
+	// keeping its shape. Otherwise points outside are clipped: moved onto
+	// the margin and blanked, so no lines are drawn along it.
+	Compress bool `json:"compress,omitempty"`
+}
+
+// Validate checks that the margins are not negative and leave an area
+// inside them.
+func (m Margin) Validate() error {
+	if !(m.Left >= 0 && m.Right >= 0 && m.Bottom >= 0 && m.Top >= 0) {
+		return errors.New("helios: margins must not be negative")
+	}
+	if m.Left+m.Right >= maxCoord || m.Bottom+m.Top >= maxCoord {
+		return errors.New("helios: margins leave no scan area")
+	}
+	return nil
+}
+
+func (m Margin) zero() bool {
+	return m.Left == 0 && m.Right == 0 && m.Bottom == 0 && m.Top == 0
+}
+
+// SetMargin sets the margin of one device, taking effect from the next
+// frame written.
+func (d *DAC) SetMargin(deviceIndex int, m Margin) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+	d.levels.mu.Lock()
+	defer d.levels.mu.Unlock()
+	if m.zero() {
+		delete(d.levels.margins, deviceIndex)
+		return nil
+	}
+	if d.levels.margins == nil {
+		d.levels.margins = make(map[int]Margin)
+	}
+	d.levels.margins[deviceIndex] = m
+	return nil
+}
+
+// Margin returns the margin of one device.
+func (d *DAC) Margin(deviceIndex int) Margin {
+	return d.levels.margin(deviceIndex)
+}
+
+func (l *levels) margin(deviceIndex int) Margin {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.margins[deviceIndex]
+}
+
+// axis maps one coordinate into [lo, full-hi], in a coordinate range of
+// 0 to full, reporting whether it was clipped.
+func (m Margin) axis(v, lo, hi, full float64) (float64, bool) {
+	if m.Compress {
+		return lo + v*(full-lo-hi)/full, false
+	}
+	c := max(lo, min(v, full-hi))
+	return c, c != v
+}
+
+// place returns a point's position inside the margin, with the margin
+// scaled from 12-bit device coordinates to a range of 0 to full, and
+// whether it was clipped.
+func (m Margin) place(x, y uint16, full float64) (uint16, uint16, bool) {
+	s := full / maxCoord
+	nx, cx := m.axis(float64(x), m.Left*s, m.Right*s, full)
+	ny, cy := m.axis(float64(y), m.Bottom*s, m.Top*s, full)
+	return uint16(math.Round(nx)), uint16(math.Round(ny)), cx || cy
+}
+
+// The margin functions return points unchanged without a margin, and a
+// copy inside it otherwise; the caller's slice is never modified.
+
+func marginPoints(points []Point, m Margin) []Point {
+	if m.zero() {
+		return points
+	}
+	out := make([]Point, len(points))
+	for i, p := range points {
+		var clipped bool
+		p.X, p.Y, clipped = m.place(min(p.X, maxCoord), min(p.Y, maxCoord), maxCoord)
+		if clipped {
+			p.R, p.G, p.B, p.I = 0, 0, 0, 0
+		}
+		out[i] = p
+	}
+	return out
+}
+
+func marginPointsHighRes(points []PointHighRes, m Margin) []PointHighRes {
+	if m.zero() {
+		return points
+	}
+	out := make([]PointHighRes, len(points))
+	for i, p := range points {
+		var clipped bool
+		p.X, p.Y, clipped = m.place(p.X, p.Y, 0xFFFF)
+		if clipped {
+			p.R, p.G, p.B = 0, 0, 0
+		}
+		out[i] = p
+	}
+	return out
+}
+
+func marginPointsExt(points []PointExt, m Margin) []PointExt {
+	if m.zero() {
+		return points
+	}
+	out := make([]PointExt, len(points))
+	for i, p := range points {
+		var clipped bool
+		p.X, p.Y, clipped = m.place(p.X, p.Y, 0xFFFF)
+		if clipped {
+			p.R, p.G, p.B, p.I = 0, 0, 0, 0
+		}
+		out[i] = p
+	}
+	return out
+}
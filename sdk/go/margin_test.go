@@ -0,0 +1,63 @@
+package helios
+
+import "testing"
+
+func TestMarginClip(t *testing.T) {
+	m := Margin{Left: 100, Right: 200, Bottom: 50, Top: 50}
+	frame := []Point{
+		{X: 0, Y: 2000, R: 255, I: 255},
+		{X: 2000, Y: 2000, R: 255, I: 255},
+		{X: 4095, Y: 4095, G: 255, I: 255},
+	}
+	got := marginPoints(frame, m)
+	want := []Point{
+		{X: 100, Y: 2000},
+		{X: 2000, Y: 2000, R: 255, I: 255},
+		{X: 3895, Y: 4045},
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("point %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	if frame[0].X != 0 {
+		t.Fatal("margin modified the caller's frame")
+	}
+
+	ext := marginPointsExt([]PointExt{{X: 0xFFFF, Y: 0x8000, R: 1, User1: 3}}, m)
+	if ext[0].X != 62334 || ext[0].Y != 0x8000 || ext[0].R != 0 || ext[0].User1 != 3 {
+		t.Fatalf("ext point = %+v", ext[0])
+	}
+}
+
+func TestMarginCompress(t *testing.T) {
+	m := Margin{Left: 100, Right: 100, Bottom: 0, Top: 95, Compress: true}
+	got := marginPoints([]Point{{X: 0, Y: 0, R: 255, I: 255}, {X: 4095, Y: 4095, R: 255, I: 255}}, m)
+	if got[0] != (Point{X: 100, Y: 0, R: 255, I: 255}) || got[1] != (Point{X: 3995, Y: 4000, R: 255, I: 255}) {
+		t.Fatalf("compressed = %+v", got)
+	}
+	hr := marginPointsHighRes([]PointHighRes{{X: 0xFFFF, G: 7}}, m)
+	if hr[0].X != 0xFFFF-1600 || hr[0].G != 7 {
+		t.Fatalf("high-res point = %+v", hr[0])
+	}
+}
+
+func TestSetMargin(t *testing.T) {
+	d := &DAC{levels: newLevels()}
+	for _, bad := range []Margin{{Left: -1}, {Bottom: 2000, Top: 2095}} {
+		if err := d.SetMargin(0, bad); err == nil {
+			t.Errorf("SetMargin(%+v) accepted", bad)
+		}
+	}
+	m := Margin{Top: 10, Compress: true}
+	if err := d.SetMargin(1, m); err != nil {
+		t.Fatal(err)
+	}
+	if d.Margin(1) != m || d.Margin(0) != (Margin{}) {
+		t.Fatalf("margins = %+v %+v", d.Margin(1), d.Margin(0))
+	}
+	d.SetMargin(1, Margin{})
+	if _, ok := d.levels.margins[1]; ok {
+		t.Fatal("zero margin kept")
+	}
+}
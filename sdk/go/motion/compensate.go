@@ -0,0 +1,73 @@
+package motion
+
+import (
+	"math"
+
+	"github.com/Grix/helios_dac/sdk/go/ease"
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+// Compensation evens out the brightness of lit lines scanned at different
+// speeds. The beam leaves more light where it moves slowly, so slow
+// sections, such as densely sampled curves and corners, look brighter than
+// fast straight runs. Compensation dims each lit point according to the
+// local scan speed so the whole frame appears as bright as its fastest
+// parts.
+type Compensation struct {
+	// Reference is the scan speed, in coordinate units per point, that is
+	// drawn at full brightness; faster points are left as they are. Zero
+	// uses the fastest lit point of each frame.
+	Reference float64
+
+	// Floor is the least a point is dimmed to (0 to 1), so points where the
+	// beam holds still stay visible.
+	Floor float64
+
+	// Curve maps the speed relative to Reference (0 to 1) to the
+	// brightness scale. Nil is linear, which matches the light deposited
+	// per unit length; curves bulging above linear compensate less.
+	Curve ease.Func
+}
+
+// DefaultCompensation scales linearly with speed and never dims below 20%.
+var DefaultCompensation = Compensation{Floor: 0.2}
+
+// Apply returns a copy of frame with the R, G, B and I of lit points
+// scaled by their scan speed: the distance from the previous point, as a
+// point's color is drawn on the way to it (the first point uses the
+// distance to the second). Blanked points are kept as they are. The
+// caller's slice is never modified.
+func (c Compensation) Apply(frame []helios.Point) []helios.Point {
+	if len(frame) < 2 {
+		return frame
+	}
+	speed := make([]float64, len(frame))
+	fastest := 0.0
+	for i, p := range frame {
+		speed[i] = dist(frame[max(i-1, 0)], frame[max(i, 1)])
+		if lit(p) {
+			fastest = max(fastest, speed[i])
+		}
+	}
+	ref := c.Reference
+	if ref <= 0 {
+		ref = fastest
+	}
+	out := make([]helios.Point, len(frame))
+	for i, p := range frame {
+		if lit(p) && ref > 0 {
+			s := max(c.Floor, min(1, c.Curve.Apply(speed[i]/ref)))
+			p.R, p.G, p.B, p.I = helios.ScaleColor(p.R, s), helios.ScaleColor(p.G, s), helios.ScaleColor(p.B, s), helios.ScaleColor(p.I, s)
+		}
+		out[i] = p
+	}
+	return out
+}
+
+func dist(a, b helios.Point) float64 {
+	return math.Hypot(float64(b.X)-float64(a.X), float64(b.Y)-float64(a.Y))
+}
+
+func lit(p helios.Point) bool {
+	return p.I != 0 && (p.R != 0 || p.G != 0 || p.B != 0)
+}
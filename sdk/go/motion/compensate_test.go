@@ -0,0 +1,60 @@
+package motion
+
+import (
+	"testing"
+
+	"github.com/Grix/helios_dac/sdk/go/ease"
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+// run returns a lit horizontal run of n points step units apart from x.
+func run(x, step, n int) []helios.Point {
+	pts := make([]helios.Point, n)
+	for i := range pts {
+		pts[i] = helios.Point{X: uint16(x + i*step), Y: 2048, R: 200, G: 100, B: 0, I: 255}
+	}
+	return pts
+}
+
+func TestCompensationDimsSlowSections(t *testing.T) {
+	frame := append(run(0, 40, 10), run(400, 10, 10)...)
+	frame = append(frame, helios.Point{X: 600, Y: 2048})
+	orig := append([]helios.Point(nil), frame...)
+
+	out := DefaultCompensation.Apply(frame)
+	if out[5] != frame[5] {
+		t.Fatalf("fast point changed: %+v", out[5])
+	}
+	// A quarter of the speed gets a quarter of the brightness.
+	if p := out[15]; p.R != 50 || p.G != 25 || p.I != 64 {
+		t.Fatalf("slow point = %+v", p)
+	}
+	if out[len(out)-1] != frame[len(frame)-1] {
+		t.Fatal("blanked point changed")
+	}
+	for i := range frame {
+		if frame[i] != orig[i] {
+			t.Fatal("caller's frame modified")
+		}
+	}
+
+	// A bulging curve compensates less.
+	soft := Compensation{Curve: ease.OutQuad}.Apply(frame)
+	if soft[15].R <= out[15].R {
+		t.Fatalf("OutQuad gave %d, linear %d", soft[15].R, out[15].R)
+	}
+}
+
+func TestCompensationFloorAndReference(t *testing.T) {
+	frame := run(0, 0, 5) // the beam holds still
+	frame = append(frame, run(100, 20, 5)...)
+
+	out := Compensation{Reference: 80, Floor: 0.2}.Apply(frame)
+	if p := out[2]; p.R != 40 {
+		t.Fatalf("still point R = %d, want the floor 40", p.R)
+	}
+	// 20 units per point against a reference of 80.
+	if p := out[7]; p.R != 50 {
+		t.Fatalf("R = %d, want 50", p.R)
+	}
+}
@@ -0,0 +1,172 @@
+package motion
+
+import (
+	"container/heap"
+	"math"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+// Governor keeps frames within the point budget of a target frame rate.
+// At pps points per second, a frame of more than pps/fps points plays
+// slower than the target rate; Fit removes the points that matter least
+// until it fits.
+type Governor struct {
+	// FPS is the target frame rate.
+	FPS float64
+
+	// PPS is the rate the frames are played at.
+	PPS int
+
+	// Reserve is the fraction of each frame kept free for points added
+	// after content is generated, such as blanked travel back to the
+	// start.
+	Reserve float64
+}
+
+// Budget returns the number of content points per frame, at least 1.
+func (g Governor) Budget() int {
+	if g.FPS <= 0 {
+		return max(g.PPS, 1)
+	}
+	return max(int(float64(g.PPS)/g.FPS*(1-min(max(g.Reserve, 0), 1))), 1)
+}
+
+// Fit returns frame reduced to at most Budget points with Decimate. Frames
+// within the budget are returned unchanged.
+func (g Governor) Fit(frame []helios.Point) []helios.Point {
+	return Decimate(frame, g.Budget())
+}
+
+// Decimate returns frame reduced to at most n points, removing first the
+// points that change the drawing least:
+//
+//  1. points in the middle of straight runs, least deviating first;
+//  2. repeated points, such as corner and blanking dwells;
+//  3. if still too long, evenly spaced points, regardless of their effect.
+//
+// Points where the color changes are kept until the last step, so lines
+// keep their ends and blanked jumps their lengths. The first and last
+// points are always kept. The caller's slice is never modified.
+func Decimate(frame []helios.Point, n int) []helios.Point {
+	n = max(n, 2)
+	if len(frame) <= n {
+		return frame
+	}
+	// The kept points form a doubly linked list over frame.
+	prev, next := make([]int, len(frame)), make([]int, len(frame))
+	for i := range frame {
+		prev[i], next[i] = i-1, i+1
+	}
+	q := &costQueue{index: make([]int, len(frame))}
+	cost := func(i int) decimationCost {
+		return pointCost(frame[prev[i]], frame[i], frame[next[i]])
+	}
+	for i := 1; i < len(frame)-1; i++ {
+		q.index[i] = len(q.items)
+		q.items = append(q.items, costItem{i, cost(i)})
+	}
+	heap.Init(q)
+	kept := len(frame)
+	for kept > n && q.Len() > 0 && q.items[0].cost.tier < keepTier {
+		i := heap.Pop(q).(costItem).point
+		p, nx := prev[i], next[i]
+		next[p], prev[nx] = nx, p
+		kept--
+		for _, j := range [2]int{p, nx} {
+			if j > 0 && j < len(frame)-1 {
+				q.items[q.index[j]].cost = cost(j)
+				heap.Fix(q, q.index[j])
+			}
+		}
+	}
+	out := make([]helios.Point, 0, kept)
+	for i := 0; i < len(frame); i = next[i] {
+		out = append(out, frame[i])
+	}
+	if len(out) <= n {
+		return out
+	}
+	even := make([]helios.Point, n)
+	for k := range even {
+		even[k] = out[k*(len(out)-1)/(n-1)]
+	}
+	return even
+}
+
+// Decimation tiers, removed in order.
+const (
+	straightTier = iota
+	dwellTier
+	keepTier
+)
+
+type decimationCost struct {
+	tier      int
+	deviation float64
+}
+
+func (c decimationCost) less(d decimationCost) bool {
+	if c.tier != d.tier {
+		return c.tier < d.tier
+	}
+	return c.deviation < d.deviation
+}
+
+// pointCost rates removing b from between a and c. b's color is drawn on
+// the way to b, so b can only go if c continues in the same color.
+func pointCost(a, b, c helios.Point) decimationCost {
+	if b.R != c.R || b.G != c.G || b.B != c.B || b.I != c.I {
+		return decimationCost{tier: keepTier}
+	}
+	if b.X == a.X && b.Y == a.Y || b.X == c.X && b.Y == c.Y {
+		return decimationCost{tier: dwellTier}
+	}
+	// The distance from b to the line through a and c.
+	ax, ay := float64(c.X)-float64(a.X), float64(c.Y)-float64(a.Y)
+	bx, by := float64(b.X)-float64(a.X), float64(b.Y)-float64(a.Y)
+	length := math.Hypot(ax, ay)
+	if length == 0 {
+		return decimationCost{tier: straightTier, deviation: math.Hypot(bx, by)}
+	}
+	return decimationCost{tier: straightTier, deviation: math.Abs(ax*by-ay*bx) / length}
+}
+
+type costItem struct {
+	point int
+	cost  decimationCost
+}
+
+// costQueue is a min-heap of removable points; index maps a point to its
+// position in items.
+type costQueue struct {
+	items []costItem
+	index []int
+}
+
+func (q *costQueue) Len() int { return len(q.items) }
+
+func (q *costQueue) Less(i, j int) bool {
+	a, b := q.items[i], q.items[j]
+	if a.cost != b.cost {
+		return a.cost.less(b.cost)
+	}
+	return a.point < b.point
+}
+
+func (q *costQueue) Swap(i, j int) {
+	q.items[i], q.items[j] = q.items[j], q.items[i]
+	q.index[q.items[i].point], q.index[q.items[j].point] = i, j
+}
+
+func (q *costQueue) Push(x any) {
+	item := x.(costItem)
+	q.index[item.point] = len(q.items)
+	q.items = append(q.items, item)
+}
+
+func (q *costQueue) Pop() any {
+	item := q.items[len(q.items)-1]
+	q.items = q.items[:len(q.items)-1]
+	return item
+}
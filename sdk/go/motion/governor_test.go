@@ -0,0 +1,85 @@
+package motion
+
+import (
+	"testing"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+func TestGovernorBudget(t *testing.T) {
+	for _, tt := range []struct {
+		g    Governor
+		want int
+	}{
+		{Governor{FPS: 30, PPS: 30000}, 1000},
+		{Governor{FPS: 30, PPS: 30000, Reserve: 0.2}, 800},
+		{Governor{FPS: 0, PPS: 30000}, 30000},
+		{Governor{FPS: 100, PPS: 10, Reserve: 2}, 1},
+	} {
+		if got := tt.g.Budget(); got != tt.want {
+			t.Errorf("%+v: Budget() = %d, want %d", tt.g, got, tt.want)
+		}
+	}
+}
+
+// corner draws an L of lit points: right along y = 0, a dwell of three
+// points at the corner, then up.
+func corner() []helios.Point {
+	var frame []helios.Point
+	for x := 0; x <= 1000; x += 100 {
+		frame = append(frame, helios.Point{X: uint16(x), G: 255})
+	}
+	frame = append(frame, frame[len(frame)-1], frame[len(frame)-1])
+	for y := 100; y <= 1000; y += 100 {
+		frame = append(frame, helios.Point{X: 1000, Y: uint16(y), G: 255})
+	}
+	return frame
+}
+
+func TestDecimateStraightRunsFirst(t *testing.T) {
+	frame := corner()
+	got := Decimate(frame, 5)
+	want := []helios.Point{{G: 255}, {X: 1000, G: 255}, {X: 1000, G: 255}, {X: 1000, G: 255}, {X: 1000, Y: 1000, G: 255}}
+	if len(got) != len(want) {
+		t.Fatalf("got %d points %+v, want %+v", len(got), got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("point %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	if len(frame) != 23 || frame[1].X != 100 {
+		t.Fatal("caller's frame was modified")
+	}
+
+	// Below the straight points, the dwell goes before the corner.
+	got = Decimate(frame, 3)
+	if len(got) != 3 || got[1] != (helios.Point{X: 1000, G: 255}) {
+		t.Fatalf("got %+v, want the ends and the corner", got)
+	}
+	if got := Decimate(frame, 100); len(got) != len(frame) {
+		t.Fatalf("frame within budget decimated to %d points", len(got))
+	}
+}
+
+func TestDecimateKeepsColorChanges(t *testing.T) {
+	// A blanked jump between two lit runs on the same line.
+	var frame []helios.Point
+	for x := 0; x <= 400; x += 100 {
+		frame = append(frame, helios.Point{X: uint16(x), R: 255})
+	}
+	frame = append(frame, helios.Point{X: 2000}, helios.Point{X: 2100, R: 255}, helios.Point{X: 2200, R: 255})
+
+	got := Decimate(frame, 5)
+	want := []helios.Point{{R: 255}, {X: 400, R: 255}, {X: 2000}, {X: 2100, R: 255}, {X: 2200, R: 255}}
+	for i := range want {
+		if i >= len(got) || got[i] != want[i] {
+			t.Fatalf("got %+v, want %+v", got, want)
+		}
+	}
+
+	// With only color changes left, points are dropped evenly.
+	if got := Decimate(frame, 3); len(got) != 3 || got[0] != frame[0] || got[2] != frame[len(frame)-1] {
+		t.Fatalf("got %+v, want 3 points from first to last", got)
+	}
+}
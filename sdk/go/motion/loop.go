@@ -0,0 +1,18 @@
+package motion
+
+import "github.com/Grix/helios_dac/sdk/go/helios"
+
+// CloseLoop returns frame with a blanked travel from its last point back to
+// its first point appended, so the frame can be looped by the DAC without a
+// visible line or a hard jump at the seam. pps is the rate the frame will be
+// played at. Frames with fewer than two points are returned unchanged.
+func CloseLoop(frame []helios.Point, profile GalvoProfile, pps int) []helios.Point {
+	if len(frame) < 2 {
+		return frame
+	}
+	travel := profile.Travel(frame[len(frame)-1], frame[0], pps)
+
+	out := make([]helios.Point, 0, len(frame)+len(travel))
+	out = append(out, frame...)
+	return append(out, travel...)
+}
@@ -0,0 +1,62 @@
+package motion
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+func TestCloseLoop(t *testing.T) {
+	frame := []helios.Point{
+		{X: 1000, Y: 1000, G: 255, I: 255},
+		{X: 3000, Y: 1000, G: 255, I: 255},
+	}
+	out := CloseLoop(frame, DefaultProfile, 30000)
+
+	// A 2000 unit jump takes 250µs + 750µs*2000/4096 ≈ 616µs -> 19 points,
+	// followed by 150µs -> 5 settle points.
+	if want := 2 + 19 + 5; len(out) != want {
+		t.Fatalf("got %d points, want %d", len(out), want)
+	}
+	for i, p := range out[2:] {
+		if p.R != 0 || p.G != 0 || p.B != 0 || p.I != 0 {
+			t.Fatalf("travel point %d is not blanked: %+v", i, p)
+		}
+	}
+	if end := out[len(out)-1]; end.X != frame[0].X || end.Y != frame[0].Y {
+		t.Fatalf("travel ends at (%d, %d), want first point", end.X, end.Y)
+	}
+	if &out[0] == &frame[0] {
+		t.Fatal("CloseLoop must not modify the input frame in place")
+	}
+}
+
+func TestCloseLoopShortFrame(t *testing.T) {
+	frame := []helios.Point{{X: 10, Y: 10}}
+	if out := CloseLoop(frame, DefaultProfile, 30000); len(out) != 1 {
+		t.Fatalf("got %d points, want 1", len(out))
+	}
+}
+
+func TestTravelCustomProfile(t *testing.T) {
+	profile := GalvoProfile{
+		SmallStep: 100 * time.Microsecond,
+		LargeStep: 100 * time.Microsecond,
+		Settle:    0,
+		Easing:    func(t float64) float64 { return t },
+	}
+	from, to := helios.Point{X: 0, Y: 0, R: 255}, helios.Point{X: 300, Y: 0, G: 255}
+	points := profile.Travel(from, to, 30000)
+
+	// 100µs at 30kpps is 3 linear steps plus a single settle point.
+	want := []uint16{100, 200, 300, 300}
+	if len(points) != len(want) || profile.TravelPoints(300, 30000) != len(want) {
+		t.Fatalf("got %d points, want %d", len(points), len(want))
+	}
+	for i, p := range points {
+		if p.X != want[i] || p.G != 0 {
+			t.Fatalf("point %d = %+v, want blanked X=%d", i, p, want[i])
+		}
+	}
+}
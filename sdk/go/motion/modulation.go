@@ -0,0 +1,98 @@
+package motion
+
+import "github.com/Grix/helios_dac/sdk/go/helios"
+
+// Modulation corrects for the laser's modulation latency. The light
+// switches a little after its color is sent while the mirrors keep moving,
+// so lines start and end late and leave visible tails at blanking
+// boundaries. Shift sends colors ahead of positions to cancel the delay,
+// and the ramps fade the color in and out over a few points, softening what
+// is left of the tails.
+type Modulation struct {
+	// Shift is how many points earlier colors are sent than the positions
+	// they belong to. Negative values send them later.
+	Shift int
+
+	// RampIn is the number of points over which lit runs fade in after
+	// blanking.
+	RampIn int
+
+	// RampOut is the number of points over which lit runs fade out before
+	// blanking.
+	RampOut int
+}
+
+// Apply returns a copy of frame with the ramps applied to the R, G, B and
+// I of lit points and the colors then moved Shift points earlier. The
+// frame is taken to loop, so colors shifted past one end come in at the
+// other and a run crossing the end of the frame is ramped as one. The
+// caller's slice is never modified.
+func (m Modulation) Apply(frame []helios.Point) []helios.Point {
+	out := append([]helios.Point(nil), frame...)
+	n := len(frame)
+	if n == 0 {
+		return out
+	}
+	if m.RampIn > 0 || m.RampOut > 0 {
+		m.ramp(out)
+	}
+	if shift := m.Shift % n; shift != 0 {
+		colors := append([]helios.Point(nil), out...)
+		for i := range out {
+			c := colors[((i+shift)%n+n)%n]
+			out[i].R, out[i].G, out[i].B, out[i].I = c.R, c.G, c.B, c.I
+		}
+	}
+	return out
+}
+
+// ramp scales the points of each lit run by their position in it.
+func (m Modulation) ramp(frame []helios.Point) {
+	n := len(frame)
+	blank := -1
+	for i, p := range frame {
+		if !lit(p) {
+			blank = i
+			break
+		}
+	}
+	if blank < 0 {
+		return // one endless run
+	}
+	// Count each point's place from the start and from the end of its run,
+	// walking the loop from a blanked point.
+	fromStart, fromEnd := make([]int, n), make([]int, n)
+	run := 0
+	for k := 1; k <= n; k++ {
+		i := (blank + k) % n
+		if run = run + 1; !lit(frame[i]) {
+			run = 0
+		}
+		fromStart[i] = run
+	}
+	run = 0
+	for k := 1; k <= n; k++ {
+		i := ((blank-k)%n + n) % n
+		if run = run + 1; !lit(frame[i]) {
+			run = 0
+		}
+		fromEnd[i] = run
+	}
+	for i, p := range frame {
+		if !lit(p) {
+			continue
+		}
+		s := min(rampScale(fromStart[i], m.RampIn), rampScale(fromEnd[i], m.RampOut))
+		p.R, p.G, p.B, p.I = helios.ScaleColor(p.R, s), helios.ScaleColor(p.G, s), helios.ScaleColor(p.B, s), helios.ScaleColor(p.I, s)
+		frame[i] = p
+	}
+}
+
+// rampScale is the brightness of the k-th point (from 1) of a ramp over
+// points.
+func rampScale(k, points int) float64 {
+	if k > points {
+		return 1
+	}
+	return float64(k) / float64(points+1)
+}
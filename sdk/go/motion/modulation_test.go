@@ -0,0 +1,68 @@
+package motion
+
+import (
+	"testing"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+// litRun returns a frame of n blanked points, then lit lit points, then n
+// blanked points, with X counting up.
+func litRun(n, lit int) []helios.Point {
+	frame := make([]helios.Point, 2*n+lit)
+	for i := range frame {
+		frame[i].X = uint16(i)
+		if i >= n && i < n+lit {
+			frame[i].R, frame[i].I = 255, 255
+		}
+	}
+	return frame
+}
+
+func TestModulationShift(t *testing.T) {
+	frame := litRun(3, 4)
+	got := Modulation{Shift: 2}.Apply(frame)
+	for i, p := range got {
+		if p.X != uint16(i) {
+			t.Fatalf("point %d moved to X %d", i, p.X)
+		}
+		if want := i >= 1 && i < 5; (p.R == 255) != want {
+			t.Errorf("point %d lit = %v, want %v", i, p.R == 255, want)
+		}
+	}
+	if frame[1].R != 0 {
+		t.Fatal("Apply modified the caller's frame")
+	}
+
+	// Shifting later wraps around the end of the frame.
+	got = Modulation{Shift: -5}.Apply(frame)
+	if got[0].R != 255 || got[1].R != 255 || got[2].R != 0 || got[8].R != 255 {
+		t.Fatalf("shifted -5: %v", got)
+	}
+}
+
+func TestModulationRamps(t *testing.T) {
+	got := Modulation{RampIn: 3, RampOut: 1}.Apply(litRun(2, 8))
+	want := []uint8{0, 0, 64, 128, 191, 255, 255, 255, 255, 128, 0, 0}
+	for i, p := range got {
+		if p.R != want[i] || p.I != want[i] {
+			t.Errorf("point %d = %d/%d, want %d", i, p.R, p.I, want[i])
+		}
+	}
+
+	// A run crossing the end of the frame is ramped as one.
+	frame := litRun(2, 8)
+	for i := range frame {
+		frame[i].R, frame[i].I = 255-frame[i].R, 255-frame[i].I
+	}
+	got = Modulation{RampIn: 1}.Apply(frame)
+	if got[10].R != 128 || got[11].R != 255 || got[0].R != 255 {
+		t.Fatalf("wrapped run: %v", got)
+	}
+
+	// A frame with no blanking has nothing to ramp.
+	lit := litRun(0, 5)
+	if got := (Modulation{RampIn: 2, RampOut: 2}).Apply(lit); got[0] != lit[0] {
+		t.Fatalf("fully lit frame ramped: %v", got)
+	}
+}
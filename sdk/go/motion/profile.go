@@ -0,0 +1,108 @@
+// Package motion models how galvanometer scanners move between points, and
+// generates the blanked travel needed to jump cleanly from one place to another.
+//
+// The model is the one used by the dot example: the time a jump takes is
+// interpolated between the scanner's small-step and full-scale step response
+// based on the jump distance, positions follow an easing curve to minimize
+// mechanical jerk, and a short blanked dwell at the destination lets the
+// mirrors settle before the laser is enabled. All timings live in a
+// GalvoProfile so they can be tuned per projector.
+package motion
+
+import (
+	"math"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/ease"
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+// fullScale is the width of the helios.Point coordinate space.
+const fullScale = 4096
+
+// GalvoProfile describes the dynamic response of a scanner pair.
+type GalvoProfile struct {
+	// SmallStep is the step response time for a very small jump.
+	SmallStep time.Duration `json:"small_step_ns"`
+
+	// LargeStep is the step response time for a full-scale jump.
+	LargeStep time.Duration `json:"large_step_ns"`
+
+	// Settle is the blanked dwell at the destination after a jump, so the
+	// mirrors are stable before the laser is enabled again.
+	Settle time.Duration `json:"settle_ns"`
+
+	// Easing maps travel progress to position progress. Nil means
+	// ease.SmoothStep. It is not saved with the profile.
+	Easing ease.Func `json:"-"`
+}
+
+// DefaultProfile matches typical 30kpps ILDA scanners.
+var DefaultProfile = GalvoProfile{
+	SmallStep: 250 * time.Microsecond,
+	LargeStep: 1000 * time.Microsecond,
+	Settle:    150 * time.Microsecond,
+}
+
+// TravelTime returns how long the scanners need to jump dist coordinate units.
+func (g GalvoProfile) TravelTime(dist float64) time.Duration {
+	ratio := math.Abs(dist) / fullScale
+	if ratio > 1 {
+		ratio = 1
+	}
+	return g.SmallStep + time.Duration(float64(g.LargeStep-g.SmallStep)*ratio)
+}
+
+// TravelPoints returns the number of points Travel generates for a jump of
+// dist coordinate units at pps, including the settle dwell.
+func (g GalvoProfile) TravelPoints(dist float64, pps int) int {
+	return PointsFor(g.TravelTime(dist), pps) + PointsFor(g.Settle, pps)
+}
+
+// Travel generates blanked points moving from one position to another,
+// followed by the settle dwell at the destination. The starting position
+// itself is not included. Colors of from and to are ignored.
+func (g GalvoProfile) Travel(from, to helios.Point, pps int) []helios.Point {
+	return g.travel(float64(from.X), float64(from.Y), float64(to.X), float64(to.Y), pps)
+}
+
+func (g GalvoProfile) travel(x0, y0, x1, y1 float64, pps int) []helios.Point {
+	easing := g.Easing
+	if easing == nil {
+		easing = ease.SmoothStep
+	}
+	travelPoints := PointsFor(g.TravelTime(math.Hypot(x1-x0, y1-y0)), pps)
+
+	points := make([]helios.Point, 0, travelPoints+PointsFor(g.Settle, pps))
+	for k := 1; k <= travelPoints; k++ {
+		alpha := easing(float64(k) / float64(travelPoints))
+		points = append(points, helios.Point{
+			X: helios.ClampToCoord(x0 + (x1-x0)*alpha),
+			Y: helios.ClampToCoord(y0 + (y1-y0)*alpha),
+		})
+	}
+	end := helios.Point{X: helios.ClampToCoord(x1), Y: helios.ClampToCoord(y1)}
+	return append(points, Dwell(end, g.Settle, pps, false)...)
+}
+
+// Dwell returns copies of p covering d at pps, so the beam holds still at p.
+// If on is false the copies are blanked.
+func Dwell(p helios.Point, d time.Duration, pps int, on bool) []helios.Point {
+	if !on {
+		p = helios.Point{X: p.X, Y: p.Y}
+	}
+	points := make([]helios.Point, PointsFor(d, pps))
+	for i := range points {
+		points[i] = p
+	}
+	return points
+}
+
+// PointsFor returns the number of samples needed to cover d at pps, at least 1.
+func PointsFor(d time.Duration, pps int) int {
+	n := int(math.Ceil(d.Seconds() * float64(pps)))
+	if n < 1 {
+		return 1
+	}
+	return n
+}
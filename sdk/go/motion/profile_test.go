@@ -0,0 +1,126 @@
+package motion
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+func TestTravelTimeReference(t *testing.T) {
+	tests := []struct {
+		dist float64
+		want time.Duration
+	}{
+		{0, 250 * time.Microsecond},
+		{1024, 437500 * time.Nanosecond},
+		{2048, 625 * time.Microsecond},
+		{4096, 1000 * time.Microsecond},
+		// Beyond full scale the step response does not get any slower.
+		{5793, 1000 * time.Microsecond},
+		// Direction does not matter.
+		{-2048, 625 * time.Microsecond},
+	}
+	for _, tt := range tests {
+		if got := DefaultProfile.TravelTime(tt.dist); got != tt.want {
+			t.Errorf("TravelTime(%v) = %v, want %v", tt.dist, got, tt.want)
+		}
+	}
+}
+
+func TestPointsForReference(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		pps  int
+		want int
+	}{
+		{time.Millisecond, 30000, 30},
+		{time.Millisecond + time.Nanosecond, 30000, 31},
+		{250 * time.Microsecond, 30000, 8},
+		{time.Second, 7, 7},
+		// Degenerate inputs still produce one point, so a jump is never
+		// skipped entirely.
+		{0, 30000, 1},
+		{-time.Millisecond, 30000, 1},
+		{time.Millisecond, 0, 1},
+	}
+	for _, tt := range tests {
+		if got := PointsFor(tt.d, tt.pps); got != tt.want {
+			t.Errorf("PointsFor(%v, %d) = %d, want %d", tt.d, tt.pps, got, tt.want)
+		}
+	}
+}
+
+func TestTravelReference(t *testing.T) {
+	linear := GalvoProfile{SmallStep: 100 * time.Microsecond, LargeStep: 100 * time.Microsecond, Easing: func(t float64) float64 { return t }}
+	tests := []struct {
+		name     string
+		profile  GalvoProfile
+		from, to helios.Point
+		want     [][2]uint16
+	}{
+		{
+			name:    "linear diagonal",
+			profile: linear,
+			from:    helios.Point{X: 0, Y: 0},
+			to:      helios.Point{X: 300, Y: 600},
+			want:    [][2]uint16{{100, 200}, {200, 400}, {300, 600}, {300, 600}},
+		},
+		{
+			name:    "linear reverse",
+			profile: linear,
+			from:    helios.Point{X: 4095, Y: 10},
+			to:      helios.Point{X: 3795, Y: 10},
+			want:    [][2]uint16{{3995, 10}, {3895, 10}, {3795, 10}, {3795, 10}},
+		},
+		{
+			name:    "zero distance",
+			profile: linear,
+			from:    helios.Point{X: 7, Y: 9},
+			to:      helios.Point{X: 7, Y: 9},
+			want:    [][2]uint16{{7, 9}, {7, 9}, {7, 9}, {7, 9}},
+		},
+		{
+			// SmoothStep at 1/3 and 2/3 is 7/27 and 20/27.
+			name:    "default easing",
+			profile: GalvoProfile{SmallStep: 100 * time.Microsecond, LargeStep: 100 * time.Microsecond},
+			from:    helios.Point{X: 0, Y: 0},
+			to:      helios.Point{X: 2700, Y: 0},
+			want:    [][2]uint16{{700, 0}, {2000, 0}, {2700, 0}, {2700, 0}},
+		},
+		{
+			name: "full scale corners",
+			profile: GalvoProfile{
+				SmallStep: 100 * time.Microsecond, LargeStep: 100 * time.Microsecond,
+				Settle: 100 * time.Microsecond, Easing: linear.Easing,
+			},
+			from: helios.Point{X: 0, Y: 4095},
+			to:   helios.Point{X: 4095, Y: 0},
+			want: [][2]uint16{{1365, 2730}, {2730, 1365}, {4095, 0}, {4095, 0}, {4095, 0}, {4095, 0}},
+		},
+	}
+	for _, tt := range tests {
+		got := tt.profile.Travel(tt.from, tt.to, 30000)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %d points, want %d", tt.name, len(got), len(tt.want))
+			continue
+		}
+		for i, p := range got {
+			if p.X != tt.want[i][0] || p.Y != tt.want[i][1] || p.R|p.G|p.B|p.I != 0 {
+				t.Errorf("%s: point %d = %+v, want blanked at %v", tt.name, i, p, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestDwellReference(t *testing.T) {
+	p := helios.Point{X: 1, Y: 2, R: 255, I: 255}
+	lit := Dwell(p, 100*time.Microsecond, 30000, true)
+	dark := Dwell(p, 100*time.Microsecond, 30000, false)
+	if len(lit) != 3 || lit[2] != p {
+		t.Fatalf("lit dwell = %+v", lit)
+	}
+	if len(dark) != 3 || dark[0] != (helios.Point{X: 1, Y: 2}) {
+		t.Fatalf("dark dwell = %+v", dark)
+	}
+}
@@ -0,0 +1,51 @@
+package motion
+
+import (
+	"math"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+// Resample returns n points spaced evenly along the path through frame,
+// so the scanners move at constant speed and lit lines have even
+// brightness however unevenly the path was sampled. The first and last
+// points are kept. Each new point takes the color of the segment it lies
+// on, which is the color of that segment's end point. Frames with fewer
+// than two points, or with no length, are returned unchanged; n below 2 is
+// treated as 2.
+func Resample(frame []helios.Point, n int) []helios.Point {
+	if len(frame) < 2 {
+		return frame
+	}
+	n = max(n, 2)
+	// cum[i] is the path length up to frame[i].
+	cum := make([]float64, len(frame))
+	for i := 1; i < len(frame); i++ {
+		a, b := frame[i-1], frame[i]
+		cum[i] = cum[i-1] + math.Hypot(float64(b.X)-float64(a.X), float64(b.Y)-float64(a.Y))
+	}
+	total := cum[len(cum)-1]
+	if total == 0 {
+		return frame
+	}
+	out := make([]helios.Point, n)
+	seg := 1
+	for k := range n {
+		d := total * float64(k) / float64(n-1)
+		for seg < len(frame)-1 && cum[seg] < d {
+			seg++
+		}
+		a, b := frame[seg-1], frame[seg]
+		t := 0.0
+		if l := cum[seg] - cum[seg-1]; l > 0 {
+			t = (d - cum[seg-1]) / l
+		}
+		p := b
+		p.X = helios.ClampToCoord(float64(a.X) + (float64(b.X)-float64(a.X))*t)
+		p.Y = helios.ClampToCoord(float64(a.Y) + (float64(b.Y)-float64(a.Y))*t)
+		out[k] = p
+	}
+	out[0] = frame[0]
+	out[n-1] = frame[len(frame)-1]
+	return out
+}
@@ -0,0 +1,51 @@
+package motion
+
+import (
+	"testing"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+func TestResampleReference(t *testing.T) {
+	// An L of two 300 unit legs, sampled densely on the first leg only.
+	frame := []helios.Point{
+		{X: 0, Y: 0, R: 1},
+		{X: 10, Y: 0, R: 2},
+		{X: 20, Y: 0, R: 2},
+		{X: 300, Y: 0, R: 2},
+		{X: 300, Y: 300, G: 3},
+	}
+	want := []helios.Point{
+		{X: 0, Y: 0, R: 1},
+		{X: 200, Y: 0, R: 2},
+		{X: 300, Y: 100, G: 3},
+		{X: 300, Y: 300, G: 3},
+	}
+	got := Resample(frame, 4)
+	if len(got) != len(want) {
+		t.Fatalf("got %d points, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("point %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestResampleDegenerate(t *testing.T) {
+	one := []helios.Point{{X: 5}}
+	if got := Resample(one, 10); len(got) != 1 {
+		t.Fatalf("single point resampled to %d", len(got))
+	}
+	still := []helios.Point{{X: 5}, {X: 5}, {X: 5}}
+	if got := Resample(still, 10); len(got) != 3 {
+		t.Fatalf("zero-length path resampled to %d", len(got))
+	}
+	line := []helios.Point{{X: 0}, {X: 100}}
+	if got := Resample(line, 0); len(got) != 2 || got[1].X != 100 {
+		t.Fatalf("n = 0: %+v", got)
+	}
+	if got := Resample(line, 5); got[1].X != 25 || got[3].X != 75 {
+		t.Fatalf("line: %+v", got)
+	}
+}
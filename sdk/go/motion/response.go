@@ -0,0 +1,79 @@
+package motion
+
+import (
+	"math"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+// DefaultDamping is the damping ratio of a Response whose Damping is zero.
+// It overshoots a step by about 10%, like a typically tuned scanner.
+const DefaultDamping = 0.6
+
+// Response simulates the scanners following a frame, for previews: each
+// axis is a damped second-order system that lags behind the commanded
+// position, overshoots it on sharp changes and rounds corners, with its
+// speed limited so full-scale jumps take about LargeStep. The natural
+// frequency is set so a small step settles within SmallStep.
+type Response struct {
+	Profile GalvoProfile
+
+	// Damping is the damping ratio. Lower values overshoot and ring more;
+	// 1 is critically damped. Zero means DefaultDamping.
+	Damping float64
+}
+
+func (r Response) damping() float64 {
+	if r.Damping > 0 {
+		return r.Damping
+	}
+	return DefaultDamping
+}
+
+// Simulate returns where the mirrors point at each point of frame played
+// at pps in a loop, with the commanded colors, since the laser follows
+// modulation instantly. The frame is run through once before recording,
+// so the result is the steady state of the loop rather than a start from
+// rest. The caller's slice is never modified.
+func (r Response) Simulate(frame []helios.Point, pps int) []helios.Point {
+	out := make([]helios.Point, len(frame))
+	if len(frame) == 0 || pps <= 0 {
+		copy(out, frame)
+		return out
+	}
+	zeta := r.damping()
+	small := r.Profile.SmallStep.Seconds()
+	if small <= 0 {
+		small = DefaultProfile.SmallStep.Seconds()
+	}
+	// A second-order system settles to 2% in about 4/(ζω) seconds.
+	omega := 4 / (zeta * small)
+	vmax := math.Inf(1)
+	if large := r.Profile.LargeStep.Seconds(); large > 0 {
+		vmax = fullScale / large
+	}
+	// Integrate in steps short enough to be stable and accurate.
+	steps := max(1, int(math.Ceil(10*omega/float64(pps))))
+	h := 1 / float64(pps) / float64(steps)
+
+	x, y := float64(frame[0].X), float64(frame[0].Y)
+	var vx, vy float64
+	for pass := range 2 {
+		for i, p := range frame {
+			tx, ty := float64(p.X), float64(p.Y)
+			for range steps {
+				vx += h * (omega*omega*(tx-x) - 2*zeta*omega*vx)
+				vy += h * (omega*omega*(ty-y) - 2*zeta*omega*vy)
+				if v := math.Hypot(vx, vy); v > vmax {
+					vx, vy = vx*vmax/v, vy*vmax/v
+				}
+				x, y = x+h*vx, y+h*vy
+			}
+			if pass == 1 {
+				p.X, p.Y = helios.ClampToCoord(x), helios.ClampToCoord(y)
+				out[i] = p
+			}
+		}
+	}
+	return out
+}
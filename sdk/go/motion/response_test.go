@@ -0,0 +1,73 @@
+package motion
+
+import (
+	"testing"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+// step holds at 1000 and then at 3000, n points each.
+func step(n int) []helios.Point {
+	frame := make([]helios.Point, 2*n)
+	for i := range frame {
+		frame[i] = helios.Point{X: 1000, Y: 2048, G: 255, I: 255}
+		if i >= n {
+			frame[i].X = 3000
+		}
+	}
+	return frame
+}
+
+func TestResponseStep(t *testing.T) {
+	frame := step(60)
+	got := Response{Profile: DefaultProfile, Damping: 0.4}.Simulate(frame, 30000)
+	if len(got) != len(frame) || frame[60].X != 3000 {
+		t.Fatal("Simulate changed the length or the caller's frame")
+	}
+	// The mirrors lag behind the step, overshoot it and settle.
+	if got[60].X >= 2000 {
+		t.Errorf("first point after the step at %d, want lagging", got[60].X)
+	}
+	peak := uint16(0)
+	for _, p := range got[60:] {
+		peak = max(peak, p.X)
+		if p.G != 255 || p.Y != 2048 {
+			t.Fatalf("point %v lost its color or moved off axis", p)
+		}
+	}
+	if peak <= 3000 {
+		t.Errorf("peak %d, want overshoot", peak)
+	}
+	if end := got[len(got)-1].X; end < 2990 || end > 3010 {
+		t.Errorf("settled at %d, want 3000", end)
+	}
+
+	// Critically damped mirrors do not overshoot.
+	got = Response{Profile: DefaultProfile, Damping: 1}.Simulate(frame, 30000)
+	for _, p := range got[60:] {
+		if p.X > 3001 {
+			t.Fatalf("critically damped overshoot to %d", p.X)
+		}
+	}
+}
+
+func TestResponseSlewLimit(t *testing.T) {
+	frame := step(60)
+	for i := range frame {
+		frame[i].X = 0
+		if i >= 60 {
+			frame[i].X = 4095
+		}
+	}
+	got := Response{Profile: DefaultProfile}.Simulate(frame, 30000)
+	// A full-scale jump takes about LargeStep (30 points at 30kpps).
+	if got[60+20].X > 3500 {
+		t.Errorf("after 20 points at %d, want slew limited", got[80].X)
+	}
+}
+
+func TestResponseEmpty(t *testing.T) {
+	if got := (Response{}).Simulate(nil, 30000); len(got) != 0 {
+		t.Fatalf("got %v", got)
+	}
+}
@@ -0,0 +1,14 @@
+package helios
+
+// SetOpenHook sets a function called for every device found by each
+// OpenDevices or ReScanDevices call, after the scan, and for every device
+// opened with OpenNetworkDevice, to configure devices as they appear. It
+// is called on the scanning goroutine and may call any method of the DAC
+// except scanning or closing devices. Nil removes the hook.
+func (d *DAC) SetOpenHook(fn func(deviceIndex int)) {
+	if fn == nil {
+		d.openHook.Store(nil)
+		return
+	}
+	d.openHook.Store(&fn)
+}
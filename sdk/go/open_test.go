@@ -0,0 +1,46 @@
+package helios
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestOpenHook(t *testing.T) {
+	dac := NewDAC()
+	defer dac.Close()
+
+	var opened []int
+	dac.SetOpenHook(func(i int) {
+		// The hook may use the DAC: scanning locks are released.
+		dac.SetDeviceIntensity(i, 0.5)
+		opened = append(opened, i)
+	})
+	n := dac.OpenDevices()
+	if len(opened) != max(n, 0) {
+		t.Fatalf("hook called for %v, want %d devices", opened, n)
+	}
+	for i, idx := range opened {
+		if idx != i {
+			t.Fatalf("hook called for %v", opened)
+		}
+	}
+
+	dac.SetOpenHook(nil)
+	opened = nil
+	dac.ReScanDevices()
+	if len(opened) != 0 {
+		t.Fatalf("removed hook called for %v", opened)
+	}
+	dac.CloseDevices()
+}
+
+func TestOpenNetworkDeviceNeedsIPv4(t *testing.T) {
+	dac := NewDAC()
+	defer dac.Close()
+	if i, err := dac.OpenNetworkDevice(NetworkDevice{Addr: netip.MustParseAddr("fe80::1")}); err == nil || i != -1 {
+		t.Fatalf("opened IPv6 device: %d, %v", i, err)
+	}
+	if dac.NumDevices() != 0 {
+		t.Fatal("failed open added a device")
+	}
+}
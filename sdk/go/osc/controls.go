@@ -0,0 +1,187 @@
+package osc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/color"
+	"github.com/Grix/helios_dac/sdk/go/mapping"
+	"github.com/Grix/helios_dac/sdk/go/param"
+	"github.com/Grix/helios_dac/sdk/go/preset"
+	"github.com/Grix/helios_dac/sdk/go/show"
+)
+
+// DeviceController is the subset of *helios.DAC driven by BindDAC.
+type DeviceController interface {
+	SetShutter(deviceIndex int, level bool) int
+	Stop(deviceIndex int) int
+}
+
+// Ducker is the subset of *helios.DAC driven by BindDuck.
+type Ducker interface {
+	SetDucked(on bool)
+	Duck(hold time.Duration)
+}
+
+// IntensityController is the subset of *helios.DAC driven by BindIntensity.
+type IntensityController interface {
+	SetMasterIntensity(level float64)
+	SetBlackout(on bool)
+}
+
+// HandleFloat registers a handler receiving the first argument of messages
+// to address as a float64. It is the usual way to bind a fader or knob to a
+// parameter such as a layer transform. Messages without a numeric first
+// argument are ignored.
+func (s *Server) HandleFloat(address string, set func(v float64)) {
+	s.Handle(address, func(m *Message) {
+		if v, ok := m.Float(0); ok {
+			set(v)
+		}
+	})
+}
+
+// BindDAC registers shutter and stop controls for numDevices devices:
+//
+//	<prefix>/<index>/shutter  bool or number  open (true/non-zero) or close the shutter
+//	<prefix>/<index>/stop                     stop output
+//	<prefix>/shutter          bool or number  shutter of every device
+//	<prefix>/stop                             stop every device
+func BindDAC(s *Server, prefix string, dac DeviceController, numDevices int) {
+	for i := 0; i < numDevices; i++ {
+		s.Handle(fmt.Sprintf("%s/%d/shutter", prefix, i), func(m *Message) {
+			if open, ok := m.Bool(0); ok {
+				dac.SetShutter(i, open)
+			}
+		})
+		s.Handle(fmt.Sprintf("%s/%d/stop", prefix, i), func(m *Message) {
+			dac.Stop(i)
+		})
+	}
+	s.Handle(prefix+"/shutter", func(m *Message) {
+		if open, ok := m.Bool(0); ok {
+			for i := 0; i < numDevices; i++ {
+				dac.SetShutter(i, open)
+			}
+		}
+	})
+	s.Handle(prefix+"/stop", func(m *Message) {
+		for i := 0; i < numDevices; i++ {
+			dac.Stop(i)
+		}
+	})
+}
+
+// BindDuck registers ducking triggers:
+//
+//	<prefix>                   bool or number  duck while true/non-zero, release otherwise
+//	<prefix>/trigger  seconds                  duck for seconds, then release
+func BindDuck(s *Server, prefix string, d Ducker) {
+	s.Handle(prefix, func(m *Message) {
+		if on, ok := m.Bool(0); ok {
+			d.SetDucked(on)
+		}
+	})
+	s.HandleFloat(prefix+"/trigger", func(v float64) {
+		d.Duck(time.Duration(v * float64(time.Second)))
+	})
+}
+
+// BindIntensity registers the intensity master of every device:
+//
+//	<prefix>           0-1             master intensity
+//	<prefix>/blackout  bool or number  black out every device while true/non-zero
+func BindIntensity(s *Server, prefix string, dac IntensityController) {
+	s.HandleFloat(prefix, dac.SetMasterIntensity)
+	s.Handle(prefix+"/blackout", func(m *Message) {
+		if on, ok := m.Bool(0); ok {
+			dac.SetBlackout(on)
+		}
+	})
+}
+
+// BindEngine registers playback controls for a show engine:
+//
+//	<prefix>/play           start or resume playback
+//	<prefix>/pause          pause playback
+//	<prefix>/seek  seconds  move the playback position
+//	<prefix>/cue   name     jump to the start of the named cue
+func BindEngine(s *Server, prefix string, e *show.Engine) {
+	s.Handle(prefix+"/play", func(m *Message) { e.Play() })
+	s.Handle(prefix+"/pause", func(m *Message) { e.Pause() })
+	s.HandleFloat(prefix+"/seek", func(v float64) {
+		e.Seek(time.Duration(v * float64(time.Second)))
+	})
+	s.Handle(prefix+"/cue", func(m *Message) {
+		if name, ok := m.String(0); ok {
+			e.SeekCue(name)
+		}
+	})
+}
+
+// BindColorProfile registers a control selecting the active color profile of
+// a device by name:
+//
+//	<address>  name  activate the profile registered on sw under name
+//
+// The new profile applies from the next frame.
+func BindColorProfile(s *Server, address string, sw *color.Switch) {
+	s.Handle(address, func(m *Message) {
+		if name, ok := m.String(0); ok {
+			if err := sw.Select(name); err != nil {
+				s.logf("%v", err)
+			}
+		}
+	})
+}
+
+// BindParams registers a control for every parameter of a set:
+//
+//	<prefix>/<name>  0-1  position within the parameter's range
+//
+// Faders and knobs of OSC control surfaces send 0-1 by default, so they bind
+// to any parameter without configuration.
+func BindParams(s *Server, prefix string, set *param.Set) {
+	for _, p := range set.Params() {
+		s.HandleFloat(prefix+"/"+p.Name, p.SetNormalized)
+	}
+}
+
+// BindPresets registers preset controls for a bank:
+//
+//	<prefix>/save    name            save the current values as name
+//	<prefix>/recall  name [seconds]  recall name, crossfading over seconds
+func BindPresets(s *Server, prefix string, b *preset.Bank) {
+	s.Handle(prefix+"/save", func(m *Message) {
+		if name, ok := m.String(0); ok {
+			b.Save(name)
+		}
+	})
+	s.Handle(prefix+"/recall", func(m *Message) {
+		name, ok := m.String(0)
+		if !ok {
+			return
+		}
+		secs, _ := m.Float(1)
+		if err := b.Recall(name, time.Duration(secs*float64(time.Second))); err != nil {
+			s.logf("%v", err)
+		}
+	})
+}
+
+// BindMapper feeds the mapper's OSC inputs, those named by an address
+// starting with "/", from messages with a numeric first argument.
+func BindMapper(s *Server, m *mapping.Mapper) {
+	for _, name := range m.Inputs() {
+		if !strings.HasPrefix(name, "/") {
+			continue
+		}
+		s.HandleFloat(name, func(v float64) {
+			if err := m.Input(name, v); err != nil && !errors.Is(err, mapping.ErrUnmapped) {
+				s.logf("%v", err)
+			}
+		})
+	}
+}
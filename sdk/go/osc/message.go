@@ -0,0 +1,215 @@
+package osc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrMalformed is returned when a packet is not valid OSC.
+var ErrMalformed = errors.New("osc: malformed packet")
+
+// Message is a single OSC message. Args hold int32, float32, string, []byte
+// or bool values.
+type Message struct {
+	Address string
+	Args    []any
+}
+
+// Float returns argument i as a float64, converting integers and booleans.
+func (m *Message) Float(i int) (float64, bool) {
+	if i >= len(m.Args) {
+		return 0, false
+	}
+	switch v := m.Args[i].(type) {
+	case float32:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// Int returns argument i as an int, truncating floats.
+func (m *Message) Int(i int) (int, bool) {
+	f, ok := m.Float(i)
+	return int(f), ok
+}
+
+// Bool returns argument i as a bool. Numbers are true when non-zero, which
+// matches how controllers send toggle buttons.
+func (m *Message) Bool(i int) (bool, bool) {
+	f, ok := m.Float(i)
+	return f != 0, ok
+}
+
+// String returns argument i if it is a string.
+func (m *Message) String(i int) (string, bool) {
+	if i >= len(m.Args) {
+		return "", false
+	}
+	s, ok := m.Args[i].(string)
+	return s, ok
+}
+
+// MarshalBinary encodes the message in OSC wire format.
+func (m *Message) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	writeString(&buf, m.Address)
+	tags := []byte{','}
+	var args bytes.Buffer
+	for _, a := range m.Args {
+		switch v := a.(type) {
+		case int32:
+			tags = append(tags, 'i')
+			binary.Write(&args, binary.BigEndian, v)
+		case float32:
+			tags = append(tags, 'f')
+			binary.Write(&args, binary.BigEndian, v)
+		case string:
+			tags = append(tags, 's')
+			writeString(&args, v)
+		case []byte:
+			tags = append(tags, 'b')
+			binary.Write(&args, binary.BigEndian, int32(len(v)))
+			args.Write(v)
+			args.Write(make([]byte, pad(len(v))))
+		case bool:
+			if v {
+				tags = append(tags, 'T')
+			} else {
+				tags = append(tags, 'F')
+			}
+		default:
+			return nil, fmt.Errorf("osc: unsupported argument type %T", a)
+		}
+	}
+	writeString(&buf, string(tags))
+	buf.Write(args.Bytes())
+	return buf.Bytes(), nil
+}
+
+// ParsePacket decodes an OSC packet. Bundles are flattened into the list of
+// messages they contain; their time tags are ignored and the messages are
+// meant to be dispatched immediately.
+func ParsePacket(b []byte) ([]*Message, error) {
+	if bytes.HasPrefix(b, []byte("#bundle\x00")) {
+		return parseBundle(b)
+	}
+	m, err := parseMessage(b)
+	if err != nil {
+		return nil, err
+	}
+	return []*Message{m}, nil
+}
+
+func parseBundle(b []byte) ([]*Message, error) {
+	if len(b) < 16 {
+		return nil, ErrMalformed
+	}
+	b = b[16:] // "#bundle\0" and the 8 byte time tag.
+	var msgs []*Message
+	for len(b) > 0 {
+		if len(b) < 4 {
+			return nil, ErrMalformed
+		}
+		size := int(binary.BigEndian.Uint32(b))
+		if size < 0 || size > len(b)-4 {
+			return nil, ErrMalformed
+		}
+		inner, err := ParsePacket(b[4 : 4+size])
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, inner...)
+		b = b[4+size:]
+	}
+	return msgs, nil
+}
+
+func parseMessage(b []byte) (*Message, error) {
+	addr, b, err := readString(b)
+	if err != nil || len(addr) == 0 || addr[0] != '/' {
+		return nil, ErrMalformed
+	}
+	m := &Message{Address: addr}
+	if len(b) == 0 {
+		// Very old implementations omit the type tag string.
+		return m, nil
+	}
+	tags, b, err := readString(b)
+	if err != nil || len(tags) == 0 || tags[0] != ',' {
+		return nil, ErrMalformed
+	}
+	for _, tag := range tags[1:] {
+		switch tag {
+		case 'i', 'f':
+			if len(b) < 4 {
+				return nil, ErrMalformed
+			}
+			v := binary.BigEndian.Uint32(b)
+			if tag == 'i' {
+				m.Args = append(m.Args, int32(v))
+			} else {
+				m.Args = append(m.Args, math.Float32frombits(v))
+			}
+			b = b[4:]
+		case 's', 'S':
+			var s string
+			if s, b, err = readString(b); err != nil {
+				return nil, err
+			}
+			m.Args = append(m.Args, s)
+		case 'b':
+			if len(b) < 4 {
+				return nil, ErrMalformed
+			}
+			n := int(binary.BigEndian.Uint32(b))
+			if n < 0 || 4+n+pad(n) > len(b) {
+				return nil, ErrMalformed
+			}
+			m.Args = append(m.Args, append([]byte(nil), b[4:4+n]...))
+			b = b[4+n+pad(n):]
+		case 'T':
+			m.Args = append(m.Args, true)
+		case 'F':
+			m.Args = append(m.Args, false)
+		case 'N', 'I':
+			// Nil and Impulse carry no data.
+		default:
+			return nil, fmt.Errorf("osc: unsupported type tag %q", tag)
+		}
+	}
+	return m, nil
+}
+
+// readString reads a null-terminated, 4-byte padded OSC string.
+func readString(b []byte) (string, []byte, error) {
+	n := bytes.IndexByte(b, 0)
+	if n < 0 {
+		return "", nil, ErrMalformed
+	}
+	size := n + 1 + pad(n+1)
+	if size > len(b) {
+		return "", nil, ErrMalformed
+	}
+	return string(b[:n]), b[size:], nil
+}
+
+func writeString(buf *bytes.Buffer, s string) {
+	buf.WriteString(s)
+	buf.WriteByte(0)
+	buf.Write(make([]byte, pad(len(s)+1)))
+}
+
+// pad returns the number of bytes needed to align n to 4 bytes.
+func pad(n int) int {
+	return (4 - n%4) % 4
+}
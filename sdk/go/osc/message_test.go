@@ -0,0 +1,78 @@
+package osc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestMessageRoundTrip(t *testing.T) {
+	in := &Message{
+		Address: "/layer/1/rotate",
+		Args:    []any{float32(0.25), int32(3), "ab", []byte{1, 2, 3}, true},
+	}
+	b, err := in.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b)%4 != 0 {
+		t.Fatalf("encoded length %d is not 4-byte aligned", len(b))
+	}
+
+	msgs, err := ParsePacket(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := msgs[0]
+	if out.Address != in.Address || len(out.Args) != len(in.Args) {
+		t.Fatalf("got %+v", out)
+	}
+	if f, _ := out.Float(0); f != 0.25 {
+		t.Errorf("Float(0) = %v", f)
+	}
+	if i, _ := out.Int(1); i != 3 {
+		t.Errorf("Int(1) = %v", i)
+	}
+	if s, _ := out.String(2); s != "ab" {
+		t.Errorf("String(2) = %q", s)
+	}
+	if blob, _ := out.Args[3].([]byte); !bytes.Equal(blob, []byte{1, 2, 3}) {
+		t.Errorf("blob = %v", out.Args[3])
+	}
+	if v, _ := out.Bool(4); !v {
+		t.Errorf("Bool(4) = false")
+	}
+}
+
+func TestParseBundle(t *testing.T) {
+	a, _ := (&Message{Address: "/a"}).MarshalBinary()
+	b, _ := (&Message{Address: "/b", Args: []any{int32(1)}}).MarshalBinary()
+
+	var buf bytes.Buffer
+	buf.WriteString("#bundle\x00")
+	buf.Write(make([]byte, 8))
+	for _, m := range [][]byte{a, b} {
+		binary.Write(&buf, binary.BigEndian, int32(len(m)))
+		buf.Write(m)
+	}
+
+	msgs, err := ParsePacket(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 2 || msgs[0].Address != "/a" || msgs[1].Address != "/b" {
+		t.Fatalf("got %+v", msgs)
+	}
+}
+
+func TestParseMalformed(t *testing.T) {
+	for _, b := range [][]byte{
+		[]byte("no-slash\x00\x00\x00\x00"),
+		[]byte("/a\x00\x00,i\x00\x00\x00\x01"),
+		[]byte("#bundle\x00"),
+	} {
+		if _, err := ParsePacket(b); err == nil {
+			t.Errorf("ParsePacket(%q) succeeded", b)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+// Package osc exposes laser controls over Open Sound Control, so lighting
+// consoles and tools such as TouchDesigner can remote-control a Go Helios
+// player.
+//
+// A Server receives OSC packets over UDP and dispatches each message to the
+// handler registered for its address. The Bind* helpers register standard
+// address spaces for DAC and show engine controls; applications can add
+// their own handlers next to them.
+package osc
+
+import (
+	"errors"
+	"log"
+	"net"
+	"path"
+	"sync"
+)
+
+// Handler handles an OSC message.
+type Handler func(m *Message)
+
+// Server dispatches OSC messages received over UDP.
+type Server struct {
+	// ErrorLog receives malformed-packet and read errors. Nil uses the
+	// standard logger.
+	ErrorLog *log.Logger
+
+	mu       sync.RWMutex
+	handlers map[string]Handler
+	conn     net.PacketConn
+}
+
+// NewServer creates a server with no handlers.
+func NewServer() *Server {
+	return &Server{handlers: make(map[string]Handler)}
+}
+
+// Handle registers h for an exact OSC address. Registering an address twice
+// replaces the previous handler.
+func (s *Server) Handle(address string, h Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[address] = h
+}
+
+// Dispatch calls every handler whose address matches m.Address. Incoming
+// addresses may use OSC wildcards (*, ?, [...]). It returns false if no
+// handler matched.
+func (s *Server) Dispatch(m *Message) bool {
+	s.mu.RLock()
+	var matched []Handler
+	if h, ok := s.handlers[m.Address]; ok {
+		matched = append(matched, h)
+	} else {
+		for addr, h := range s.handlers {
+			if ok, _ := path.Match(m.Address, addr); ok {
+				matched = append(matched, h)
+			}
+		}
+	}
+	s.mu.RUnlock()
+
+	for _, h := range matched {
+		h(m)
+	}
+	return len(matched) > 0
+}
+
+// ListenAndServe listens on the UDP address addr (for example ":8000") and
+// serves until Close is called.
+func (s *Server) ListenAndServe(addr string) error {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(conn)
+}
+
+// Serve reads packets from conn and dispatches them until Close is called.
+// It returns nil after Close.
+func (s *Server) Serve(conn net.PacketConn) error {
+	s.mu.Lock()
+	s.conn = conn
+	s.mu.Unlock()
+
+	buf := make([]byte, 65536)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		msgs, err := ParsePacket(buf[:n])
+		if err != nil {
+			s.logf("osc: dropping packet: %v", err)
+			continue
+		}
+		for _, m := range msgs {
+			s.Dispatch(m)
+		}
+	}
+}
+
+// Close stops the server.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	return s.conn.Close()
+}
+
+func (s *Server) logf(format string, args ...any) {
+	if s.ErrorLog != nil {
+		s.ErrorLog.Printf(format, args...)
+	} else {
+		log.Printf(format, args...)
+	}
+}
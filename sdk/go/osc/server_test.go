@@ -0,0 +1,203 @@
+package osc
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/color"
+	"github.com/Grix/helios_dac/sdk/go/mapping"
+	"github.com/Grix/helios_dac/sdk/go/param"
+	"github.com/Grix/helios_dac/sdk/go/preset"
+	"github.com/Grix/helios_dac/sdk/go/show"
+)
+
+type fakeDAC struct {
+	shutter map[int]bool
+	stops   int
+}
+
+func (f *fakeDAC) SetShutter(i int, level bool) int { f.shutter[i] = level; return 1 }
+func (f *fakeDAC) Stop(i int) int                   { f.stops++; return 1 }
+
+type fakeDucker struct {
+	ducked bool
+	hold   time.Duration
+}
+
+func (f *fakeDucker) SetDucked(on bool)       { f.ducked = on }
+func (f *fakeDucker) Duck(hold time.Duration) { f.hold = hold }
+
+func TestBindDuck(t *testing.T) {
+	s := NewServer()
+	d := &fakeDucker{}
+	BindDuck(s, "/duck", d)
+
+	s.Dispatch(&Message{Address: "/duck", Args: []any{true}})
+	if !d.ducked {
+		t.Fatal("not ducked")
+	}
+	s.Dispatch(&Message{Address: "/duck", Args: []any{int32(0)}})
+	s.Dispatch(&Message{Address: "/duck/trigger", Args: []any{float32(1.5)}})
+	if d.ducked || d.hold != 1500*time.Millisecond {
+		t.Fatalf("ducker = %+v", d)
+	}
+}
+
+type fakeIntensity struct {
+	level    float64
+	blackout bool
+}
+
+func (f *fakeIntensity) SetMasterIntensity(level float64) { f.level = level }
+func (f *fakeIntensity) SetBlackout(on bool)              { f.blackout = on }
+
+func TestBindIntensity(t *testing.T) {
+	s := NewServer()
+	d := &fakeIntensity{}
+	BindIntensity(s, "/master", d)
+
+	s.Dispatch(&Message{Address: "/master", Args: []any{float32(0.25)}})
+	s.Dispatch(&Message{Address: "/master/blackout", Args: []any{int32(1)}})
+	if d.level != 0.25 || !d.blackout {
+		t.Fatalf("intensity = %+v", d)
+	}
+	s.Dispatch(&Message{Address: "/master/blackout", Args: []any{false}})
+	s.Dispatch(&Message{Address: "/master", Args: []any{"loud"}})
+	if d.level != 0.25 || d.blackout {
+		t.Fatalf("intensity = %+v", d)
+	}
+}
+
+func TestDispatchWildcard(t *testing.T) {
+	s := NewServer()
+	dac := &fakeDAC{shutter: map[int]bool{}}
+	BindDAC(s, "/dac", dac, 2)
+
+	s.Dispatch(&Message{Address: "/dac/1/shutter", Args: []any{int32(1)}})
+	if !dac.shutter[1] || dac.shutter[0] {
+		t.Fatalf("shutter = %v, want only device 1 open", dac.shutter)
+	}
+
+	if !s.Dispatch(&Message{Address: "/dac/*/stop"}) || dac.stops != 2 {
+		t.Fatalf("wildcard stop reached %d devices, want 2", dac.stops)
+	}
+	if s.Dispatch(&Message{Address: "/nothing"}) {
+		t.Fatal("Dispatch matched an unregistered address")
+	}
+}
+
+func TestServeEngineControls(t *testing.T) {
+	s := NewServer()
+	e := show.NewEngine(nil)
+	BindEngine(s, "/show", e)
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error)
+	go func() { done <- s.Serve(conn) }()
+
+	client, err := net.Dial("udp", conn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	b, _ := (&Message{Address: "/show/seek", Args: []any{float32(12)}}).MarshalBinary()
+	client.Write(b)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for e.Position() != 12*time.Second {
+		if time.Now().After(deadline) {
+			t.Fatalf("position = %v, want 12s", e.Position())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	s.Close()
+	if err := <-done; err != nil {
+		t.Fatalf("Serve returned %v after Close", err)
+	}
+}
+
+func TestBindColorProfile(t *testing.T) {
+	s := NewServer()
+	day := &color.Profile{Name: "day"}
+	sw := color.NewSwitch(nil)
+	sw.Register(day)
+	BindColorProfile(s, "/dac/0/profile", sw)
+
+	s.Dispatch(&Message{Address: "/dac/0/profile", Args: []any{"day"}})
+	if sw.Active() != day {
+		t.Fatal("profile was not switched")
+	}
+}
+
+func TestBindParams(t *testing.T) {
+	s := NewServer()
+	g := &struct {
+		Speed float64 `param:"speed,min=-2,max=2"`
+	}{}
+	set, err := param.Of(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	BindParams(s, "/spiral", set)
+
+	s.Dispatch(&Message{Address: "/spiral/speed", Args: []any{float32(0.75)}})
+	if g.Speed != 1 {
+		t.Fatalf("speed = %v, want 1", g.Speed)
+	}
+}
+
+func TestBindPresets(t *testing.T) {
+	s := NewServer()
+	g := &struct {
+		Size float64 `param:"size,min=0,max=1"`
+	}{}
+	set, err := param.Of(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := &show.ManualClock{}
+	b := preset.NewBank(set, clock)
+	BindPresets(s, "/presets", b)
+
+	g.Size = 1
+	s.Dispatch(&Message{Address: "/presets/save", Args: []any{"full"}})
+	g.Size = 0
+	s.Dispatch(&Message{Address: "/presets/recall", Args: []any{"full", float32(2)}})
+	clock.Advance(time.Second)
+	b.Update()
+	if g.Size != 0.5 {
+		t.Fatalf("size = %v halfway through the fade, want 0.5", g.Size)
+	}
+}
+
+func TestBindMapper(t *testing.T) {
+	s := NewServer()
+	g := &struct {
+		Size float64 `param:"size,min=0,max=1"`
+	}{}
+	set, err := param.Of(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := mapping.NewMapper(mapping.Config{Banks: []mapping.Bank{{Pages: []mapping.Page{{
+		Controls: []mapping.Control{
+			{Input: "/1/fader1", Target: "g", Param: "size", Jump: true},
+			{Input: mapping.CC(1, 1), Target: "g", Param: "size"},
+		},
+	}}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Params("g", set)
+	BindMapper(s, m)
+
+	s.Dispatch(&Message{Address: "/1/fader1", Args: []any{float32(0.25)}})
+	if g.Size != 0.25 {
+		t.Fatalf("size = %v, want 0.25", g.Size)
+	}
+}
@@ -0,0 +1,168 @@
+// Package chaos injects failures into a laser output, so applications can
+// be tested against the errors and stalls real hardware produces before
+// they meet them at a show.
+//
+// Wrap any output.Output; each call to the wrapped output may, at random,
+// be delayed or fail with one of the result codes of the C++ SDK instead of
+// reaching the device:
+//
+//	out := chaos.Wrap(output.NewDevice(dac, 0), chaos.Config{ErrorRate: 0.01, DelayRate: 0.05, MaxDelay: 50 * time.Millisecond})
+//
+// A failed call does not reach the wrapped output, as when the USB transfer
+// or network packet is lost. Close is never failed, so resources are always
+// released.
+package chaos
+
+import (
+	"maps"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/output"
+)
+
+// SDKErrors are the result codes the C++ SDK returns from device calls,
+// including the libusb errors most often seen on flaky USB connections
+// (I/O, no device, timeout and pipe errors).
+var SDKErrors = []helios.Error{
+	helios.ErrNotInitialized,
+	helios.ErrInvalidDevNum,
+	helios.ErrNullPoints,
+	helios.ErrTooManyPoints,
+	helios.ErrPpsTooHigh,
+	helios.ErrPpsTooLow,
+	helios.ErrFrameTooSmall,
+	helios.ErrDeviceClosed,
+	helios.ErrDeviceFrameReady,
+	helios.ErrSendControl,
+	helios.ErrDeviceResult,
+	helios.ErrNullBuffer,
+	helios.ErrSignalTooLong,
+	helios.ErrNotSupported,
+	helios.ErrNetwork,
+	helios.ErrLibusbBase - 1, // LIBUSB_ERROR_IO
+	helios.ErrLibusbBase - 4, // LIBUSB_ERROR_NO_DEVICE
+	helios.ErrLibusbBase - 7, // LIBUSB_ERROR_TIMEOUT
+	helios.ErrLibusbBase - 9, // LIBUSB_ERROR_PIPE
+}
+
+// Config selects what is injected.
+type Config struct {
+	// ErrorRate is the probability (0 to 1) that a call fails.
+	ErrorRate float64
+
+	// Errors are the codes failures are drawn from, uniformly. Empty means
+	// SDKErrors.
+	Errors []helios.Error
+
+	// DelayRate is the probability (0 to 1) that a call is delayed by a
+	// random time up to MaxDelay before it proceeds or fails.
+	DelayRate float64
+	MaxDelay  time.Duration
+
+	// Seed makes the injected failures repeatable for a given sequence of
+	// calls.
+	Seed uint64
+}
+
+// Output wraps an output.Output, injecting failures. It is safe for
+// concurrent use if the wrapped output is.
+type Output struct {
+	out   output.Output
+	cfg   Config
+	sleep func(time.Duration)
+
+	mu       sync.Mutex
+	rng      *rand.Rand
+	injected map[helios.Error]int
+	delays   int
+}
+
+var _ output.Output = (*Output)(nil)
+
+// Wrap returns out with failures injected according to cfg.
+func Wrap(out output.Output, cfg Config) *Output {
+	if len(cfg.Errors) == 0 {
+		cfg.Errors = SDKErrors
+	}
+	return &Output{
+		out:      out,
+		cfg:      cfg,
+		sleep:    time.Sleep,
+		rng:      rand.New(rand.NewPCG(cfg.Seed, cfg.Seed)),
+		injected: make(map[helios.Error]int),
+	}
+}
+
+// inject delays the caller and returns the error to fail the call with, or
+// nil to let it through.
+func (o *Output) inject() error {
+	o.mu.Lock()
+	var delay time.Duration
+	if o.cfg.MaxDelay > 0 && o.rng.Float64() < o.cfg.DelayRate {
+		delay = 1 + time.Duration(o.rng.Int64N(int64(o.cfg.MaxDelay)))
+		o.delays++
+	}
+	var err error
+	if o.rng.Float64() < o.cfg.ErrorRate {
+		code := o.cfg.Errors[o.rng.IntN(len(o.cfg.Errors))]
+		o.injected[code]++
+		err = code
+	}
+	o.mu.Unlock()
+	if delay > 0 {
+		o.sleep(delay)
+	}
+	return err
+}
+
+// Ready reports whether the wrapped output is ready, unless a failure is
+// injected.
+func (o *Output) Ready() (bool, error) {
+	if err := o.inject(); err != nil {
+		return false, err
+	}
+	return o.out.Ready()
+}
+
+// WriteFrame writes to the wrapped output, unless a failure is injected.
+func (o *Output) WriteFrame(pps int, points []helios.Point) error {
+	if err := o.inject(); err != nil {
+		return err
+	}
+	return o.out.WriteFrame(pps, points)
+}
+
+// Stop stops the wrapped output, unless a failure is injected.
+func (o *Output) Stop() error {
+	if err := o.inject(); err != nil {
+		return err
+	}
+	return o.out.Stop()
+}
+
+// Close closes the wrapped output. It is never failed or delayed.
+func (o *Output) Close() error {
+	return o.out.Close()
+}
+
+// Unwrap returns the wrapped output.
+func (o *Output) Unwrap() output.Output {
+	return o.out
+}
+
+// Injected returns how many times each error has been injected.
+func (o *Output) Injected() map[helios.Error]int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return maps.Clone(o.injected)
+}
+
+// Delays returns how many calls have been delayed.
+func (o *Output) Delays() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.delays
+}
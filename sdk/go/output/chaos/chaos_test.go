@@ -0,0 +1,105 @@
+package chaos
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+// fakeOutput counts the calls that reach it.
+type fakeOutput struct {
+	writes, stops, closes int
+}
+
+func (f *fakeOutput) Ready() (bool, error) { return true, nil }
+
+func (f *fakeOutput) WriteFrame(pps int, points []helios.Point) error {
+	f.writes++
+	return nil
+}
+
+func (f *fakeOutput) Stop() error {
+	f.stops++
+	return nil
+}
+
+func (f *fakeOutput) Close() error {
+	f.closes++
+	return nil
+}
+
+func TestWrapInjectsErrors(t *testing.T) {
+	fake := &fakeOutput{}
+	o := Wrap(fake, Config{ErrorRate: 0.3, Seed: 1})
+	failed := 0
+	for range 1000 {
+		err := o.WriteFrame(30000, []helios.Point{{}})
+		if err == nil {
+			continue
+		}
+		failed++
+		var code helios.Error
+		if !errors.As(err, &code) {
+			t.Fatalf("injected %v is not a helios.Error", err)
+		}
+	}
+	if failed < 250 || failed > 350 {
+		t.Fatalf("%d of 1000 writes failed, want about 300", failed)
+	}
+	if fake.writes != 1000-failed {
+		t.Fatalf("%d writes reached the output, want %d", fake.writes, 1000-failed)
+	}
+	total := 0
+	for _, n := range o.Injected() {
+		total += n
+	}
+	if total != failed || len(o.Injected()) < len(SDKErrors)/2 {
+		t.Fatalf("injected = %v", o.Injected())
+	}
+
+	o.Close()
+	if fake.closes != 1 {
+		t.Fatal("Close did not reach the output")
+	}
+}
+
+func TestWrapIsRepeatable(t *testing.T) {
+	run := func() []error {
+		o := Wrap(&fakeOutput{}, Config{ErrorRate: 0.5, Errors: []helios.Error{helios.ErrNetwork, helios.ErrDeviceResult}, Seed: 9})
+		var errs []error
+		for range 20 {
+			errs = append(errs, o.Stop())
+		}
+		return errs
+	}
+	a, b := run(), run()
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("call %d: %v, then %v", i, a[i], b[i])
+		}
+		if a[i] != nil && a[i] != helios.ErrNetwork && a[i] != helios.ErrDeviceResult {
+			t.Fatalf("injected %v, not one of the configured errors", a[i])
+		}
+	}
+}
+
+func TestWrapInjectsDelays(t *testing.T) {
+	o := Wrap(&fakeOutput{}, Config{DelayRate: 1, MaxDelay: 10 * time.Millisecond})
+	var slept []time.Duration
+	o.sleep = func(d time.Duration) { slept = append(slept, d) }
+	for range 10 {
+		if ready, err := o.Ready(); !ready || err != nil {
+			t.Fatalf("Ready() = %v, %v", ready, err)
+		}
+	}
+	if o.Delays() != 10 || len(slept) != 10 {
+		t.Fatalf("delayed %d calls, slept %d times", o.Delays(), len(slept))
+	}
+	for _, d := range slept {
+		if d <= 0 || d > 10*time.Millisecond {
+			t.Fatalf("delay %v out of range", d)
+		}
+	}
+}
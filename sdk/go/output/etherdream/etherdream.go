@@ -0,0 +1,346 @@
+// Package etherdream sends frames to Ether Dream DACs over their TCP
+// protocol.
+//
+// A Sender keeps the DAC's point buffer filled: WriteFrame streams a frame
+// as data commands, waiting for buffer space as needed, and starts playback
+// once the first points are queued. Point rate changes between frames are
+// queued so they take effect exactly at the frame boundary. Sender
+// implements output.Output.
+package etherdream
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/output"
+)
+
+// Port is the Ether Dream TCP port.
+const Port = 7765
+
+// Limits of the Ether Dream hardware.
+const (
+	MaxPPS = 100000
+
+	// DefaultBufferSize is the point buffer capacity of an Ether Dream.
+	DefaultBufferSize = 1799
+)
+
+// Light engine states reported in Status.
+const (
+	LightEngineReady = iota
+	LightEngineWarmup
+	LightEngineCooldown
+	LightEngineEmergencyStop
+)
+
+// Playback states reported in Status.
+const (
+	PlaybackIdle = iota
+	PlaybackPrepared
+	PlaybackPlaying
+)
+
+const (
+	cmdPrepare    = 'p'
+	cmdBegin      = 'b'
+	cmdQueueRate  = 'q'
+	cmdData       = 'd'
+	cmdStop       = 's'
+	cmdClearEstop = 'c'
+	cmdPing       = '?'
+
+	respAck = 'a'
+
+	statusLen   = 20
+	responseLen = 2 + statusLen
+	pointLen    = 18
+
+	controlRateChange = 0x8000
+)
+
+// Status is the DAC status sent with every response.
+type Status struct {
+	Protocol         uint8
+	LightEngineState uint8
+	PlaybackState    uint8
+	Source           uint8
+	LightEngineFlags uint16
+	PlaybackFlags    uint16
+	SourceFlags      uint16
+	BufferFullness   uint16
+	PointRate        uint32
+	PointCount       uint32
+}
+
+func parseStatus(b []byte) Status {
+	return Status{
+		Protocol:         b[0],
+		LightEngineState: b[1],
+		PlaybackState:    b[2],
+		Source:           b[3],
+		LightEngineFlags: binary.LittleEndian.Uint16(b[4:]),
+		PlaybackFlags:    binary.LittleEndian.Uint16(b[6:]),
+		SourceFlags:      binary.LittleEndian.Uint16(b[8:]),
+		BufferFullness:   binary.LittleEndian.Uint16(b[10:]),
+		PointRate:        binary.LittleEndian.Uint32(b[12:]),
+		PointCount:       binary.LittleEndian.Uint32(b[16:]),
+	}
+}
+
+// ErrEmergencyStop is returned by WriteFrame while the DAC's light engine
+// is in emergency stop. Output resumes only after ClearEstop.
+var ErrEmergencyStop = errors.New("etherdream: light engine is in emergency stop")
+
+// NakError reports that the DAC rejected a command.
+type NakError struct {
+	Command  byte
+	Response byte
+}
+
+func (e *NakError) Error() string {
+	return fmt.Sprintf("etherdream: command %q rejected (%q)", e.Command, e.Response)
+}
+
+// Sender streams frames to one Ether Dream. It is safe for concurrent use.
+type Sender struct {
+	// BufferSize is the DAC's point buffer capacity.
+	BufferSize int
+
+	// Latency is how much buffered output Ready tolerates: Ready reports
+	// true once less than Latency of points remains in the DAC's buffer.
+	Latency time.Duration
+
+	mu     sync.Mutex
+	conn   net.Conn
+	status Status
+	rate   int
+	resp   [responseLen]byte
+}
+
+// DefaultLatency is the Latency of senders created by Dial and NewSender.
+const DefaultLatency = 20 * time.Millisecond
+
+// Dial connects to an Ether Dream. If addr has no port, Port is used.
+func Dial(addr string) (*Sender, error) {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, strconv.Itoa(Port))
+	}
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	s, err := NewSender(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// NewSender returns a sender using conn, a fresh connection to the DAC. It
+// reads the status the DAC sends on connecting.
+func NewSender(conn net.Conn) (*Sender, error) {
+	s := &Sender{
+		BufferSize: DefaultBufferSize,
+		Latency:    DefaultLatency,
+		conn:       conn,
+	}
+	if err := s.readResponse(cmdPing); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Status returns the DAC status received with the last response.
+func (s *Sender) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status
+}
+
+func (s *Sender) readResponse(cmd byte) error {
+	if _, err := io.ReadFull(s.conn, s.resp[:]); err != nil {
+		return err
+	}
+	s.status = parseStatus(s.resp[2:])
+	if s.resp[0] != respAck || s.resp[1] != cmd {
+		return &NakError{Command: cmd, Response: s.resp[0]}
+	}
+	return nil
+}
+
+func (s *Sender) command(b []byte) error {
+	if s.conn == nil {
+		return helios.ErrDeviceClosed
+	}
+	if _, err := s.conn.Write(b); err != nil {
+		return err
+	}
+	return s.readResponse(b[0])
+}
+
+// Ready pings the DAC and reports whether less than Latency of output
+// remains in its buffer.
+func (s *Sender) Ready() (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.command([]byte{cmdPing}); err != nil {
+		return false, err
+	}
+	if s.status.PlaybackState != PlaybackPlaying || s.status.PointRate == 0 {
+		return true, nil
+	}
+	buffered := time.Duration(s.status.BufferFullness) * time.Second / time.Duration(s.status.PointRate)
+	return buffered < s.Latency, nil
+}
+
+// Buffered pings the DAC and returns the points in its buffer.
+func (s *Sender) Buffered() (output.Buffer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.command([]byte{cmdPing}); err != nil {
+		return output.Buffer{}, err
+	}
+	b := output.Buffer{Frames: -1, Points: int(s.status.BufferFullness)}
+	if s.status.PlaybackState == PlaybackPlaying && s.status.PointRate > 0 {
+		b.Duration = time.Duration(b.Points) * time.Second / time.Duration(s.status.PointRate)
+	}
+	return b, nil
+}
+
+// WriteFrame queues points to be scanned at pps points per second after the
+// previously written frames. It blocks while the DAC's buffer is full. While
+// the light engine is in emergency stop it returns ErrEmergencyStop and
+// sends nothing.
+func (s *Sender) WriteFrame(pps int, points []helios.Point) error {
+	switch {
+	case len(points) == 0:
+		return helios.ErrNullPoints
+	case pps > MaxPPS:
+		return helios.ErrPpsTooHigh
+	case pps <= 0:
+		return helios.ErrPpsTooLow
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.status.LightEngineState == LightEngineEmergencyStop {
+		return ErrEmergencyStop
+	}
+	if s.status.PlaybackState == PlaybackIdle {
+		if err := s.command([]byte{cmdPrepare}); err != nil {
+			return err
+		}
+	}
+
+	control := uint16(0)
+	if s.status.PlaybackState == PlaybackPlaying && pps != s.rate {
+		b := binary.LittleEndian.AppendUint32([]byte{cmdQueueRate}, uint32(pps))
+		if err := s.command(b); err != nil {
+			return err
+		}
+		control = controlRateChange
+	}
+
+	for len(points) > 0 {
+		free := s.BufferSize - int(s.status.BufferFullness)
+		if free <= 0 || (free < len(points) && free < s.BufferSize/4) {
+			if err := s.waitForSpace(pps, min(len(points), s.BufferSize/2)); err != nil {
+				return err
+			}
+			continue
+		}
+		n := min(free, len(points))
+		if err := s.command(dataCommand(points[:n], control)); err != nil {
+			return err
+		}
+		control = 0
+		points = points[n:]
+
+		if s.status.PlaybackState != PlaybackPlaying {
+			b := binary.LittleEndian.AppendUint16([]byte{cmdBegin}, 0)
+			b = binary.LittleEndian.AppendUint32(b, uint32(pps))
+			if err := s.command(b); err != nil {
+				return err
+			}
+		}
+		s.rate = pps
+	}
+	return nil
+}
+
+// waitForSpace sleeps until about want points of buffer are free and then
+// refreshes the status.
+func (s *Sender) waitForSpace(pps, want int) error {
+	rate := int(s.status.PointRate)
+	if rate == 0 {
+		rate = pps
+	}
+	need := want - (s.BufferSize - int(s.status.BufferFullness))
+	time.Sleep(max(time.Millisecond, time.Duration(need)*time.Second/time.Duration(rate)))
+	return s.command([]byte{cmdPing})
+}
+
+func dataCommand(points []helios.Point, control uint16) []byte {
+	b := make([]byte, 0, 3+len(points)*pointLen)
+	b = append(b, cmdData)
+	b = binary.LittleEndian.AppendUint16(b, uint16(len(points)))
+	for _, p := range points {
+		b = binary.LittleEndian.AppendUint16(b, control)
+		b = binary.LittleEndian.AppendUint16(b, uint16(p.X<<4)-0x8000)
+		b = binary.LittleEndian.AppendUint16(b, uint16(p.Y<<4)-0x8000)
+		b = binary.LittleEndian.AppendUint16(b, uint16(p.R)*257)
+		b = binary.LittleEndian.AppendUint16(b, uint16(p.G)*257)
+		b = binary.LittleEndian.AppendUint16(b, uint16(p.B)*257)
+		b = binary.LittleEndian.AppendUint16(b, uint16(p.I)*257)
+		b = append(b, 0, 0, 0, 0) // user channels
+		control = 0
+	}
+	return b
+}
+
+// ClearEstop clears an emergency stop of the DAC's light engine, so
+// WriteFrame sends frames again. It is never sent automatically: an
+// emergency stop is cleared only by the operator, once the cause has been
+// dealt with.
+func (s *Sender) ClearEstop() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.command([]byte{cmdClearEstop})
+}
+
+// Stop stops playback and clears the DAC's buffer.
+func (s *Sender) Stop() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.status.PlaybackState == PlaybackIdle {
+		return nil
+	}
+	return s.command([]byte{cmdStop})
+}
+
+// Close stops playback and closes the connection.
+func (s *Sender) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	var err error
+	if s.status.PlaybackState != PlaybackIdle {
+		err = s.command([]byte{cmdStop})
+	}
+	if cerr := s.conn.Close(); err == nil {
+		err = cerr
+	}
+	s.conn = nil
+	return err
+}
@@ -0,0 +1,161 @@
+package etherdream
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/output"
+)
+
+var (
+	_ output.Output   = (*Sender)(nil)
+	_ output.Buffered = (*Sender)(nil)
+)
+
+// fakeDAC implements enough of the protocol to accept a stream. Its buffer
+// drains completely whenever it is pinged.
+type fakeDAC struct {
+	status   Status
+	commands []byte
+	points   []uint16 // x of each received point
+	control  []uint16
+}
+
+func (f *fakeDAC) respond(w io.Writer, cmd byte) {
+	b := []byte{respAck, cmd, f.status.Protocol, f.status.LightEngineState, f.status.PlaybackState, 0}
+	b = binary.LittleEndian.AppendUint16(b, 0)
+	b = binary.LittleEndian.AppendUint16(b, 0)
+	b = binary.LittleEndian.AppendUint16(b, 0)
+	b = binary.LittleEndian.AppendUint16(b, f.status.BufferFullness)
+	b = binary.LittleEndian.AppendUint32(b, f.status.PointRate)
+	b = binary.LittleEndian.AppendUint32(b, f.status.PointCount)
+	w.Write(b)
+}
+
+func (f *fakeDAC) serve(conn net.Conn) {
+	defer conn.Close()
+	f.respond(conn, cmdPing)
+	var cmd [1]byte
+	for {
+		if _, err := io.ReadFull(conn, cmd[:]); err != nil {
+			return
+		}
+		f.commands = append(f.commands, cmd[0])
+		switch cmd[0] {
+		case cmdPrepare:
+			f.status.PlaybackState = PlaybackPrepared
+		case cmdBegin:
+			var b [6]byte
+			io.ReadFull(conn, b[:])
+			f.status.PlaybackState = PlaybackPlaying
+			f.status.PointRate = binary.LittleEndian.Uint32(b[2:])
+		case cmdQueueRate:
+			var b [4]byte
+			io.ReadFull(conn, b[:])
+		case cmdData:
+			var n [2]byte
+			io.ReadFull(conn, n[:])
+			data := make([]byte, int(binary.LittleEndian.Uint16(n[:]))*pointLen)
+			io.ReadFull(conn, data)
+			for i := 0; i < len(data); i += pointLen {
+				f.control = append(f.control, binary.LittleEndian.Uint16(data[i:]))
+				f.points = append(f.points, binary.LittleEndian.Uint16(data[i+2:]))
+			}
+			f.status.BufferFullness += uint16(len(data) / pointLen)
+		case cmdPing:
+			f.status.BufferFullness = 0
+		case cmdStop:
+			f.status.PlaybackState = PlaybackIdle
+		case cmdClearEstop:
+			f.status.LightEngineState = LightEngineReady
+		}
+		f.respond(conn, cmd[0])
+	}
+}
+
+func dial(t *testing.T, f *fakeDAC) *Sender {
+	t.Helper()
+	client, server := net.Pipe()
+	go f.serve(server)
+	s, err := NewSender(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestWriteFrameStartsPlayback(t *testing.T) {
+	f := &fakeDAC{}
+	s := dial(t, f)
+
+	frame := make([]helios.Point, 3000)
+	frame[0].X = 4095
+	if err := s.WriteFrame(30000, frame); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.points) != len(frame) {
+		t.Fatalf("DAC received %d points, want %d", len(f.points), len(frame))
+	}
+	if f.points[0] != 0x7ff0 || f.points[1] != 0x8000 {
+		t.Fatalf("x = %#x, %#x", f.points[0], f.points[1])
+	}
+	if string(f.commands[:3]) != "pdb" {
+		t.Fatalf("commands = %q, want prepare, data, begin first", f.commands)
+	}
+
+	if err := s.WriteFrame(20000, frame[:100]); err != nil {
+		t.Fatal(err)
+	}
+	if f.control[len(frame)] != controlRateChange || f.control[len(frame)+1] != 0 {
+		t.Fatal("rate change not flagged on the first point of the new frame")
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if f.commands[len(f.commands)-1] != cmdStop {
+		t.Fatalf("Close did not stop playback: %q", f.commands)
+	}
+}
+
+func TestNak(t *testing.T) {
+	client, server := net.Pipe()
+	go func() {
+		(&fakeDAC{}).respond(server, cmdPing)
+		io.ReadFull(server, make([]byte, 1))
+		server.Write(append([]byte{'N', cmdPrepare}, make([]byte, statusLen)...))
+	}()
+	s, err := NewSender(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.WriteFrame(30000, make([]helios.Point, 10))
+	if nak, ok := err.(*NakError); !ok || nak.Command != cmdPrepare {
+		t.Fatalf("err = %v, want NAK of prepare", err)
+	}
+}
+
+func TestEmergencyStop(t *testing.T) {
+	f := &fakeDAC{status: Status{LightEngineState: LightEngineEmergencyStop}}
+	s := dial(t, f)
+	defer s.Close()
+
+	if err := s.WriteFrame(30000, make([]helios.Point, 10)); err != ErrEmergencyStop {
+		t.Fatalf("err = %v, want ErrEmergencyStop", err)
+	}
+	if len(f.commands) != 0 {
+		t.Fatalf("sent %q while in emergency stop", f.commands)
+	}
+	if err := s.ClearEstop(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.WriteFrame(30000, make([]helios.Point, 10)); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.points) != 10 {
+		t.Fatalf("DAC received %d points after ClearEstop, want 10", len(f.points))
+	}
+}
@@ -0,0 +1,73 @@
+package idn
+
+import (
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+// batchSamples is how many samples fit in a message that also carries the
+// channel config, the most a batch collects.
+const batchSamples = (maxMessageLen - packetHeaderLen - messageHeaderLen - configLen - chunkHeaderLen) / sampleLen
+
+// batch holds points behind the samples of earlier frames, sending them
+// once they would no longer fit in one message, once they have been held
+// for Batch or once the DAC is running low.
+func (s *Sender) batch(pps int, points []helios.Point) error {
+	if len(s.pending) > 0 && (pps != s.pendingPPS || len(s.pending)+len(points) > batchSamples) {
+		if err := s.flush(); err != nil {
+			return err
+		}
+	}
+	if len(points) > batchSamples {
+		return s.send(pps, points)
+	}
+	if len(s.pending) == 0 {
+		if s.timer == nil {
+			s.timer = time.AfterFunc(s.Batch, s.flushHeld)
+		} else {
+			s.timer.Reset(s.Batch)
+		}
+	}
+	s.pending = append(s.pending, points...)
+	s.pendingPPS = pps
+	if s.next-s.now() < 2*s.Batch || s.pendingDuration() >= s.Batch {
+		return s.flush()
+	}
+	return nil
+}
+
+// pendingDuration is the scan time of the held samples.
+func (s *Sender) pendingDuration() time.Duration {
+	if len(s.pending) == 0 {
+		return 0
+	}
+	return time.Duration(len(s.pending)) * time.Second / time.Duration(s.pendingPPS)
+}
+
+// flush sends the held samples.
+func (s *Sender) flush() error {
+	if len(s.pending) == 0 {
+		return nil
+	}
+	// Sending may unlock to pace, so the held samples are handed over
+	// rather than reused by frames batched meanwhile.
+	points := s.pending
+	s.pending = nil
+	return s.send(s.pendingPPS, points)
+}
+
+// flushHeld sends samples held for Batch without a frame arriving to send
+// them.
+func (s *Sender) flushHeld() {
+	s.sending.Lock()
+	defer s.sending.Unlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return
+	}
+	if err := s.flush(); err != nil {
+		s.logf("idn: %v", err)
+	}
+}
@@ -0,0 +1,368 @@
+// Package idn sends frames to network DACs using the ILDA Digital Network
+// streaming protocol (IDN-Stream over UDP).
+//
+// A Sender opens a laser projector graphics channel in continuous (wave)
+// mode and streams each frame as a sequence of timestamped sample chunks in
+// XYRGBI format, the same format the C++ SDK uses for standard points. It
+// implements output.Output.
+//
+// Small frames each cost a packet, and on Wi-Fi the per-packet overhead
+// rather than the bandwidth limits how many frames per second get through.
+// With Batch set, the sender holds the samples of consecutive small frames
+// briefly and sends them together in one message, since in continuous mode
+// the DAC sees a stream of samples rather than frames.
+//
+// On congested networks, Monitor measures round trip time and loss with IDN
+// pings; with Pace and Adaptive set the sender then spreads frames over
+// time and reduces their size while the link cannot keep up.
+package idn
+
+import (
+	"encoding/binary"
+	"log"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/output"
+)
+
+// Port is the IDN UDP port.
+const Port = 7255
+
+// Limits of the sender, matching those of the C++ SDK for IDN devices.
+const (
+	MinPPS    = 7
+	MaxPPS    = 100000
+	MaxPoints = 0x2000
+
+	// minSamples is the smallest frame sent; shorter frames are repeated.
+	minSamples = 20
+)
+
+const (
+	cmdChannelMessage = 0x40
+	cmdChannelClose   = 0x44
+
+	contentChannelMessage = 0x8000
+	contentConfig         = 0x4000
+	chunkVoid             = 0x00
+	chunkWave             = 0x01
+
+	cfgRouting = 0x01
+	cfgClose   = 0x02
+
+	serviceModeContinuous = 0x01
+
+	maxMessageLen  = 1454 // stays below the Ethernet MTU
+	configInterval = 250 * time.Millisecond
+
+	packetHeaderLen  = 4
+	messageHeaderLen = 8
+	configLen        = 4 + 2*8 // header and xyrgbiDescriptors
+	chunkHeaderLen   = 4
+	sampleLen        = 8
+)
+
+// Standard IDTF-to-IDN descriptors for 16-bit X/Y and 8-bit R, G, B, I.
+var xyrgbiDescriptors = []uint16{
+	0x4200, 0x4010, // X, 16 bit precision
+	0x4210, 0x4010, // Y, 16 bit precision
+	0x527E, // Red, 638 nm
+	0x5214, // Green, 532 nm
+	0x51CC, // Blue, 460 nm
+	0x5C10, // Intensity, legacy signal
+}
+
+// Sender streams frames to one IDN service. It is safe for concurrent use.
+type Sender struct {
+	// ServiceID is the IDN service (1-64) frames are sent to. Zero means 1.
+	ServiceID int
+
+	// Latency is how far ahead of real time frames are queued on the DAC.
+	// Ready reports true once less than Latency of queued output remains.
+	Latency time.Duration
+
+	// Pace spreads each frame's messages over its duration, sending each
+	// no earlier than Latency before it is due, instead of sending the frame
+	// in one burst. Bursts can overflow the queues of congested Wi-Fi links.
+	Pace bool
+
+	// Adaptive reduces the points sent per frame while Monitor measures a
+	// congested link.
+	Adaptive bool
+
+	// Batch, if positive, is how long samples of small frames may be held
+	// to share a message with the frames after them. Frames are held only
+	// while at least twice Batch of earlier output remains queued on the
+	// DAC, so batching never starves it; a frame at a different point rate
+	// sends the held samples at once. Zero sends every frame directly.
+	Batch time.Duration
+
+	// ErrorLog receives link warnings. Nil means the log package's standard
+	// logger.
+	ErrorLog *log.Logger
+
+	// sending is held, before mu, for as long as samples are sent, so a
+	// paced send, which releases mu while it waits, is never interleaved
+	// with another.
+	sending sync.Mutex
+
+	mu         sync.Mutex
+	conn       net.Conn
+	epoch      time.Time
+	sequence   uint16
+	next       time.Duration // timestamp of the next sample, since epoch
+	configured time.Time
+	buf        []byte
+	pps        int
+	link       LinkStats
+
+	pending    []helios.Point // samples held by Batch
+	pendingPPS int
+	timer      *time.Timer
+}
+
+// DefaultLatency is the Latency of senders created by Dial and NewSender.
+const DefaultLatency = 20 * time.Millisecond
+
+// Dial connects to an IDN server. If addr has no port, Port is used.
+func Dial(addr string) (*Sender, error) {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, strconv.Itoa(Port))
+	}
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return NewSender(conn), nil
+}
+
+// NewSender returns a sender writing packets to conn.
+func NewSender(conn net.Conn) *Sender {
+	return &Sender{
+		Latency: DefaultLatency,
+		conn:    conn,
+		epoch:   time.Now(),
+		buf:     make([]byte, 0, maxMessageLen),
+		link:    LinkStats{Scale: 1},
+	}
+}
+
+func (s *Sender) now() time.Duration {
+	return time.Since(s.epoch)
+}
+
+// Ready reports whether less than Latency of previously written frames
+// remains to be scanned.
+func (s *Sender) Ready() (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return false, helios.ErrDeviceClosed
+	}
+	return s.next+s.pendingDuration()-s.now() < s.Latency, nil
+}
+
+// Buffered returns the output written and not yet scanned, held samples
+// included, reckoned from the sample timestamps.
+func (s *Sender) Buffered() (output.Buffer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return output.Buffer{}, helios.ErrDeviceClosed
+	}
+	b := output.Buffer{Frames: -1, Duration: max(s.next+s.pendingDuration()-s.now(), 0)}
+	if s.pps > 0 {
+		b.Points = int(b.Duration * time.Duration(s.pps) / time.Second)
+	}
+	return b, nil
+}
+
+// WriteFrame sends points, to be scanned at pps points per second directly
+// after the previously written frame. Frames shorter than 20 points are
+// repeated to fill the minimum frame size. WriteFrame does not wait for
+// Ready; writing faster than frames are scanned grows the DAC's buffer.
+func (s *Sender) WriteFrame(pps int, points []helios.Point) error {
+	switch {
+	case len(points) == 0:
+		return helios.ErrNullPoints
+	case len(points) > MaxPoints:
+		return helios.ErrTooManyPoints
+	case pps > MaxPPS:
+		return helios.ErrPpsTooHigh
+	case pps < MinPPS:
+		return helios.ErrPpsTooLow
+	}
+	if len(points) < minSamples {
+		factor := minSamples/len(points) + 1
+		if pps*factor > MaxPPS {
+			return helios.ErrFrameTooSmall
+		}
+		points = repeatPoints(points, factor)
+		pps *= factor
+	}
+
+	s.sending.Lock()
+	defer s.sending.Unlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return helios.ErrDeviceClosed
+	}
+	s.pps = pps
+	if s.Adaptive && s.link.Scale < 1 {
+		points, pps = decimate(points, pps, s.link.Scale)
+	}
+	if s.Batch > 0 {
+		return s.batch(pps, points)
+	}
+	if err := s.flush(); err != nil {
+		return err
+	}
+	return s.send(pps, points)
+}
+
+// send sends points as the next samples, in as many messages as needed.
+// The caller must hold sending as well as mu.
+func (s *Sender) send(pps int, points []helios.Point) error {
+	if now := s.now(); s.next < now {
+		s.next = now // first frame or underrun: start from now
+	}
+	for len(points) > 0 {
+		if wait := s.next - s.Latency - s.now(); s.Pace && wait > 0 {
+			s.mu.Unlock()
+			time.Sleep(wait)
+			s.mu.Lock()
+			if s.conn == nil {
+				return helios.ErrDeviceClosed
+			}
+		}
+		n, err := s.sendChunk(pps, points)
+		if err != nil {
+			return err
+		}
+		points = points[n:]
+	}
+	return nil
+}
+
+func repeatPoints(points []helios.Point, factor int) []helios.Point {
+	out := make([]helios.Point, 0, len(points)*factor)
+	for _, p := range points {
+		for j := 0; j < factor; j++ {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// sendChunk sends one channel message holding the first samples of points
+// and returns how many were sent.
+func (s *Sender) sendChunk(pps int, points []helios.Point) (int, error) {
+	withConfig := s.configured.IsZero() || time.Since(s.configured) > configInterval
+
+	headerLen := packetHeaderLen + messageHeaderLen + chunkHeaderLen
+	if withConfig {
+		headerLen += configLen
+	}
+	// Spread the frame evenly over the messages it needs.
+	perMessage := (maxMessageLen - headerLen) / sampleLen
+	n := len(points) / ((len(points) + perMessage - 1) / perMessage)
+	duration := time.Duration(n) * time.Second / time.Duration(pps)
+
+	contentID := uint16(contentChannelMessage | s.channel()<<8 | chunkWave)
+	if withConfig {
+		contentID |= contentConfig
+	}
+	b := s.packetHeader(cmdChannelMessage)
+	b = binary.BigEndian.AppendUint16(b, uint16(headerLen+n*sampleLen-packetHeaderLen))
+	b = binary.BigEndian.AppendUint16(b, contentID)
+	b = binary.BigEndian.AppendUint32(b, uint32(s.next/time.Microsecond))
+	if withConfig {
+		b = append(b, byte(configLen/4-1), cfgRouting|dataMatch, s.serviceID(), serviceModeContinuous)
+		for _, d := range xyrgbiDescriptors {
+			b = binary.BigEndian.AppendUint16(b, d)
+		}
+	}
+	b = binary.BigEndian.AppendUint32(b, uint32(dataMatch)<<24|uint32(duration/time.Microsecond))
+	for _, p := range points[:n] {
+		b = binary.BigEndian.AppendUint16(b, uint16(p.X<<4)-0x8000)
+		b = binary.BigEndian.AppendUint16(b, uint16(p.Y<<4)-0x8000)
+		b = append(b, p.R, p.G, p.B, p.I)
+	}
+	if _, err := s.conn.Write(b); err != nil {
+		return 0, err
+	}
+	if withConfig {
+		s.configured = time.Now()
+	}
+	s.next += duration
+	return n, nil
+}
+
+// dataMatch is the service data match field of configs and chunk headers.
+// The config never changes, so it stays constant.
+const dataMatch = 0x20
+
+func (s *Sender) serviceID() byte {
+	if s.ServiceID == 0 {
+		return 1
+	}
+	return byte(s.ServiceID)
+}
+
+func (s *Sender) channel() int {
+	return int(s.serviceID()-1) & 0x3F
+}
+
+func (s *Sender) packetHeader(cmd byte) []byte {
+	b := append(s.buf[:0], cmd, 0)
+	b = binary.BigEndian.AppendUint16(b, s.sequence)
+	s.sequence++
+	return b
+}
+
+// Stop blanks the output by queueing a short blanked frame at the center.
+// IDN has no stop command; the DAC keeps the beam off once it runs out of
+// frames.
+func (s *Sender) Stop() error {
+	blank := make([]helios.Point, minSamples)
+	for i := range blank {
+		blank[i] = helios.Point{X: 0x800, Y: 0x800}
+	}
+	return s.WriteFrame(MaxPPS/10, blank)
+}
+
+// Close closes the IDN channel and session and the underlying connection.
+func (s *Sender) Close() error {
+	s.sending.Lock()
+	defer s.sending.Unlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	if err := s.flush(); err != nil {
+		s.logf("idn: %v", err)
+	}
+	b := s.packetHeader(cmdChannelMessage)
+	b = binary.BigEndian.AppendUint16(b, messageHeaderLen+4)
+	b = binary.BigEndian.AppendUint16(b, uint16(contentChannelMessage|contentConfig|s.channel()<<8|chunkVoid))
+	b = binary.BigEndian.AppendUint32(b, uint32(s.now()/time.Microsecond))
+	b = append(b, 0, cfgClose, s.serviceID(), 0)
+	_, err := s.conn.Write(b)
+	if err == nil {
+		_, err = s.conn.Write(s.packetHeader(cmdChannelClose))
+	}
+	if cerr := s.conn.Close(); err == nil {
+		err = cerr
+	}
+	s.conn = nil
+	return err
+}
@@ -0,0 +1,348 @@
+package idn
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"log"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/output"
+)
+
+var (
+	_ output.Output   = (*Sender)(nil)
+	_ output.Buffered = (*Sender)(nil)
+)
+
+func listen(t *testing.T) (net.PacketConn, *Sender) {
+	t.Helper()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pc.Close() })
+	s, err := Dial(pc.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pc, s
+}
+
+func read(t *testing.T, pc net.PacketConn) []byte {
+	t.Helper()
+	pc.SetReadDeadline(time.Now().Add(2 * time.Second))
+	b := make([]byte, 2048)
+	n, _, err := pc.ReadFrom(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b[:n]
+}
+
+func TestWriteFrameFragments(t *testing.T) {
+	pc, s := listen(t)
+	defer s.Close()
+
+	frame := make([]helios.Point, 500)
+	frame[0] = helios.Point{X: 4095, Y: 0, R: 1, G: 2, B: 3, I: 4}
+	if err := s.WriteFrame(5000, frame); err != nil {
+		t.Fatal(err)
+	}
+
+	samples := 0
+	for i := 0; samples < len(frame); i++ {
+		b := read(t, pc)
+		if b[0] != cmdChannelMessage || binary.BigEndian.Uint16(b[2:]) != uint16(i) {
+			t.Fatalf("packet %d header = % x", i, b[:4])
+		}
+		if len(b) > maxMessageLen {
+			t.Fatalf("packet %d is %d bytes", i, len(b))
+		}
+		if int(binary.BigEndian.Uint16(b[4:])) != len(b)-packetHeaderLen {
+			t.Fatalf("packet %d total size %d, length %d", i, binary.BigEndian.Uint16(b[4:]), len(b))
+		}
+		contentID := binary.BigEndian.Uint16(b[6:])
+		chunk := b[12:]
+		if i == 0 {
+			if contentID != contentChannelMessage|contentConfig|chunkWave {
+				t.Fatalf("first content ID = %#x", contentID)
+			}
+			chunk = chunk[configLen:]
+			if got := chunk[chunkHeaderLen : chunkHeaderLen+sampleLen]; got[0] != 0x7f || got[1] != 0xf0 || got[2] != 0x80 || got[3] != 0 || got[4] != 1 || got[7] != 4 {
+				t.Fatalf("first sample = % x", got)
+			}
+		} else if contentID != contentChannelMessage|chunkWave {
+			t.Fatalf("packet %d content ID = %#x", i, contentID)
+		}
+		samples += (len(chunk) - chunkHeaderLen) / sampleLen
+	}
+	if samples != len(frame) {
+		t.Fatalf("sent %d samples, want %d", samples, len(frame))
+	}
+
+	if ready, _ := s.Ready(); ready {
+		t.Fatal("Ready directly after queueing 100ms of output")
+	}
+}
+
+func TestWriteFrameLimits(t *testing.T) {
+	_, s := listen(t)
+	defer s.Close()
+
+	if err := s.WriteFrame(MaxPPS+1, make([]helios.Point, 100)); err != helios.ErrPpsTooHigh {
+		t.Fatalf("err = %v, want ErrPpsTooHigh", err)
+	}
+	if err := s.WriteFrame(30000, make([]helios.Point, MaxPoints+1)); err != helios.ErrTooManyPoints {
+		t.Fatalf("err = %v, want ErrTooManyPoints", err)
+	}
+}
+
+func TestBuffered(t *testing.T) {
+	_, s := listen(t)
+	defer s.Close()
+	if b, err := s.Buffered(); err != nil || b.Duration != 0 || b.Points != 0 {
+		t.Fatalf("Buffered before writing = %+v, %v", b, err)
+	}
+	if err := s.WriteFrame(10000, make([]helios.Point, 1000)); err != nil {
+		t.Fatal(err)
+	}
+	b, err := s.Buffered()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Frames != -1 || b.Duration <= 50*time.Millisecond || b.Duration > 100*time.Millisecond || b.Points <= 500 || b.Points > 1000 {
+		t.Fatalf("Buffered after a 100ms frame = %+v", b)
+	}
+}
+
+func TestClose(t *testing.T) {
+	pc, s := listen(t)
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if b := read(t, pc); b[0] != cmdChannelMessage || b[9+4]&cfgClose == 0 {
+		t.Fatalf("close channel packet = % x", b)
+	}
+	if b := read(t, pc); b[0] != cmdChannelClose || len(b) != packetHeaderLen {
+		t.Fatalf("close session packet = % x", b)
+	}
+	if err := s.WriteFrame(30000, make([]helios.Point, 100)); err != helios.ErrDeviceClosed {
+		t.Fatalf("WriteFrame after Close: %v", err)
+	}
+}
+
+func TestAdaptiveScaling(t *testing.T) {
+	_, s := listen(t)
+	defer s.Close()
+	s.Adaptive = true
+	s.ErrorLog = log.New(io.Discard, "", 0)
+
+	// The test listener never answers pings.
+	for range 5 {
+		s.updateLink(0)
+	}
+	if l := s.LinkStats(); l.Scale >= 1 || l.Loss < maxLoss {
+		t.Fatalf("link stats after lost pings = %+v", l)
+	}
+	for range 100 {
+		s.updateLink(time.Millisecond)
+	}
+	if l := s.LinkStats(); l.Scale != 1 || l.RTT != time.Millisecond {
+		t.Fatalf("link stats after recovery = %+v", l)
+	}
+
+	frame := make([]helios.Point, 1000)
+	frame[999].X = 7
+	out, pps := decimate(frame, 30000, 0.5)
+	if len(out) != 500 || pps != 15000 || out[499].X != 7 {
+		t.Fatalf("decimate = %d points at %d pps, last %+v", len(out), pps, out[499])
+	}
+}
+
+func TestMonitorMeasuresRTT(t *testing.T) {
+	pc, s := listen(t)
+	defer s.Close()
+	go func() {
+		b := make([]byte, 64)
+		for {
+			n, addr, err := pc.ReadFrom(b)
+			if err != nil {
+				return
+			}
+			if b[0] == cmdPingRequest {
+				b[0] = cmdPingResponse
+				pc.WriteTo(b[:n], addr)
+			}
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	s.Monitor(ctx, 10*time.Millisecond)
+	if l := s.LinkStats(); l.RTT == 0 || l.Loss > 0.5 {
+		t.Fatalf("link stats = %+v", l)
+	}
+}
+
+func samplesIn(t *testing.T, b []byte) int {
+	t.Helper()
+	chunk := b[packetHeaderLen+messageHeaderLen:]
+	if binary.BigEndian.Uint16(b[6:])&contentConfig != 0 {
+		chunk = chunk[configLen:]
+	}
+	return (len(chunk) - chunkHeaderLen) / sampleLen
+}
+
+func TestBatchCoalescesSmallFrames(t *testing.T) {
+	pc, s := listen(t)
+	defer s.Close()
+	s.Batch = 20 * time.Millisecond
+
+	// Queue 100ms of output first: frames are only held while the DAC has
+	// plenty left to scan.
+	if err := s.WriteFrame(10000, make([]helios.Point, 1000)); err != nil {
+		t.Fatal(err)
+	}
+	for samples := 0; samples < 1000; {
+		samples += samplesIn(t, read(t, pc))
+	}
+
+	// Four 4ms frames are held, the fifth does not fit with them.
+	for range 5 {
+		if err := s.WriteFrame(10000, make([]helios.Point, 40)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := samplesIn(t, read(t, pc)); n != 160 {
+		t.Fatalf("batched message has %d samples, want 160", n)
+	}
+	// The fifth is sent once it has been held for Batch.
+	start := time.Now()
+	if n := samplesIn(t, read(t, pc)); n != 40 {
+		t.Fatalf("held message has %d samples, want 40", n)
+	}
+	if d := time.Since(start); d < 10*time.Millisecond {
+		t.Fatalf("held frame sent after %v, want about %v", d, s.Batch)
+	}
+
+	// A frame at another rate sends the held samples first.
+	for _, pps := range []int{10000, 20000} {
+		if err := s.WriteFrame(pps, make([]helios.Point, 40)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := samplesIn(t, read(t, pc)); n != 40 {
+		t.Fatalf("message has %d samples, want 40", n)
+	}
+}
+
+// wifiConn accounts the airtime of the packets written to it on a typical
+// 802.11n link, where each packet costs a fixed contention, preamble and
+// acknowledgement overhead on top of its bytes.
+type wifiConn struct {
+	net.Conn
+	packets int
+	airtime time.Duration
+}
+
+const (
+	wifiPacketOverhead = 150 * time.Microsecond
+	wifiBitRate        = 65e6
+)
+
+func (c *wifiConn) Write(b []byte) (int, error) {
+	c.packets++
+	c.airtime += wifiPacketOverhead + time.Duration(float64(len(b)*8)/wifiBitRate*float64(time.Second))
+	return len(b), nil
+}
+
+func (c *wifiConn) Close() error { return nil }
+
+func benchmarkSmallFrames(b *testing.B, batch time.Duration) {
+	conn := &wifiConn{}
+	s := NewSender(conn)
+	s.Batch = batch
+	frame := make([]helios.Point, 40)
+	for b.Loop() {
+		if err := s.WriteFrame(30000, frame); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(conn.packets)/float64(b.N), "packets/frame")
+	b.ReportMetric(float64(conn.airtime.Microseconds())/float64(b.N), "airtime-µs/frame")
+}
+
+// The airtime per frame bounds the frame rate a Wi-Fi link sustains: 40
+// point frames take about 190µs each sent directly and 80µs batched four to
+// a message, as the packet overhead dominates.
+func BenchmarkSmallFrames(b *testing.B) {
+	b.Run("direct", func(b *testing.B) { benchmarkSmallFrames(b, 0) })
+	b.Run("batched", func(b *testing.B) { benchmarkSmallFrames(b, 5*time.Millisecond) })
+}
+
+// recordConn records the packets written to it.
+type recordConn struct {
+	net.Conn
+	mu      sync.Mutex
+	packets [][]byte
+}
+
+func (c *recordConn) Write(b []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.packets = append(c.packets, append([]byte(nil), b...))
+	return len(b), nil
+}
+
+func (c *recordConn) Close() error { return nil }
+
+func TestPacedFramesDoNotInterleave(t *testing.T) {
+	conn := &recordConn{}
+	s := NewSender(conn)
+	s.Pace, s.Latency = true, time.Millisecond
+
+	// Each frame takes 100ms to scan and is sent in several paced messages.
+	var wg sync.WaitGroup
+	for _, red := range []uint8{1, 2} {
+		frame := make([]helios.Point, 1000)
+		for i := range frame {
+			frame[i].R = red
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.WriteFrame(10000, frame); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	var reds []uint8
+	for _, b := range conn.packets {
+		if b[0] != cmdChannelMessage {
+			continue
+		}
+		chunk := b[12:]
+		if binary.BigEndian.Uint16(b[6:])&contentConfig != 0 {
+			chunk = chunk[configLen:]
+		}
+		for i := chunkHeaderLen; i+sampleLen <= len(chunk); i += sampleLen {
+			reds = append(reds, chunk[i+4])
+		}
+	}
+	changes := 0
+	for i := 1; i < len(reds); i++ {
+		if reds[i] != reds[i-1] {
+			changes++
+		}
+	}
+	if len(reds) != 2000 || changes != 1 {
+		t.Fatalf("sent %d samples with %d changes between frames, want 2000 with 1", len(reds), changes)
+	}
+}
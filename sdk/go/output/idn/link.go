@@ -0,0 +1,147 @@
+package idn
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"log"
+	"net"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+const (
+	cmdPingRequest  = 0x08
+	cmdPingResponse = 0x09
+
+	// Link quality thresholds of the adaptive sizing. A link losing more
+	// than maxLoss of its pings, or slower than the sender's Latency, is
+	// treated as congested.
+	maxLoss       = 0.05
+	minScale      = 0.25
+	scaleDown     = 0.8
+	scaleUp       = 1.05
+	statSmoothing = 0.2
+)
+
+// LinkStats describes the measured quality of the network link.
+type LinkStats struct {
+	// RTT is the smoothed ping round trip time.
+	RTT time.Duration
+
+	// Loss is the smoothed fraction of pings that got no response.
+	Loss float64
+
+	// Scale is the fraction of each frame's points currently sent. It is
+	// below 1 while Adaptive sizing has reduced the bandwidth used.
+	Scale float64
+}
+
+// LinkStats returns the link quality measured by Monitor.
+func (s *Sender) LinkStats() LinkStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.link
+}
+
+// Monitor pings the IDN server every interval until ctx is done, updating
+// LinkStats. With Adaptive set, frames are scaled down while the link is
+// congested and back up once it recovers; each reduction is logged as a
+// warning that the link cannot sustain the requested rate. Monitor must be
+// the only reader of the sender's connection. It returns ctx.Err().
+func (s *Sender) Monitor(ctx context.Context, interval time.Duration) error {
+	buf := make([]byte, 64)
+	for {
+		s.mu.Lock()
+		if s.conn == nil {
+			s.mu.Unlock()
+			return helios.ErrDeviceClosed
+		}
+		conn, seq := s.conn, s.sequence
+		_, err := conn.Write(s.packetHeader(cmdPingRequest))
+		s.mu.Unlock()
+		if err != nil {
+			s.logf("idn: ping: %v", err)
+		}
+
+		sent := time.Now()
+		var rtt time.Duration
+		conn.SetReadDeadline(sent.Add(interval))
+		for rtt == 0 {
+			n, err := conn.Read(buf)
+			if err != nil {
+				var ne net.Error
+				if !errors.As(err, &ne) || !ne.Timeout() {
+					if errors.Is(err, net.ErrClosed) {
+						return helios.ErrDeviceClosed
+					}
+					s.logf("idn: ping: %v", err)
+				}
+				break
+			}
+			if n >= packetHeaderLen && buf[0] == cmdPingResponse && binary.BigEndian.Uint16(buf[2:]) == seq {
+				rtt = time.Since(sent)
+			}
+		}
+		s.updateLink(rtt)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Until(sent.Add(interval))):
+		}
+	}
+}
+
+// updateLink records a ping result; zero rtt means the ping was lost.
+func (s *Sender) updateLink(rtt time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l := &s.link
+	lost := 0.0
+	if rtt == 0 {
+		lost = 1
+	} else if l.RTT == 0 {
+		l.RTT = rtt
+	} else {
+		l.RTT += time.Duration(statSmoothing * float64(rtt-l.RTT))
+	}
+	l.Loss += statSmoothing * (lost - l.Loss)
+
+	if !s.Adaptive {
+		l.Scale = 1
+		return
+	}
+	if l.Loss > maxLoss || l.RTT > s.Latency {
+		if l.Scale > minScale {
+			l.Scale = max(minScale, l.Scale*scaleDown)
+			s.logf("idn: link cannot sustain %d pps (rtt %v, loss %.0f%%), sending %.0f%% of points",
+				s.pps, l.RTT, l.Loss*100, l.Scale*100)
+		}
+	} else {
+		l.Scale = min(1, l.Scale*scaleUp)
+	}
+}
+
+// decimate returns about scale of points, evenly spaced and keeping the
+// first and last point, and the rate that keeps the frame's duration.
+func decimate(points []helios.Point, pps int, scale float64) ([]helios.Point, int) {
+	n := max(minSamples, int(float64(len(points))*scale+0.5))
+	if n >= len(points) {
+		return points, pps
+	}
+	out := make([]helios.Point, n)
+	for i := range out {
+		out[i] = points[i*(len(points)-1)/(n-1)]
+	}
+	return out, max(MinPPS, pps*n/len(points))
+}
+
+func (s *Sender) logf(format string, args ...any) {
+	if s.ErrorLog != nil {
+		s.ErrorLog.Printf(format, args...)
+	} else {
+		log.Printf(format, args...)
+	}
+}
@@ -0,0 +1,59 @@
+package output
+
+import "github.com/Grix/helios_dac/sdk/go/helios"
+
+// DeviceWriter is the device-indexed frame interface of helios.DAC, also
+// implemented by remote.Client and tape.Recorder.
+type DeviceWriter interface {
+	GetStatus(deviceIndex int) int
+	WriteFrame(deviceIndex int, pps int, flags int, points []helios.Point) int
+}
+
+// Indexed is an Output writing to one device of a DeviceWriter, so sinks
+// such as a remote.Client or a tape.Recorder can take middleware like any
+// other output. Use Device for a helios.DAC, which also validates frames
+// and reports its buffer.
+type Indexed struct {
+	W     DeviceWriter
+	Index int
+
+	// Flags are passed to W's WriteFrame.
+	Flags int
+}
+
+// NewIndexed returns an Output for the device of w with the given index,
+// writing with helios.FlagsDefault.
+func NewIndexed(w DeviceWriter, index int) *Indexed {
+	return &Indexed{W: w, Index: index, Flags: helios.FlagsDefault}
+}
+
+// Ready reports whether the device is ready for the next frame.
+func (o *Indexed) Ready() (bool, error) {
+	s := helios.StatusFromCode(o.W.GetStatus(o.Index))
+	if s.Healthy() {
+		return s.Ready(), nil
+	}
+	return false, s.Err
+}
+
+// WriteFrame sends points to the device.
+func (o *Indexed) WriteFrame(pps int, points []helios.Point) error {
+	if len(points) == 0 {
+		return helios.ErrNullPoints
+	}
+	return helios.ErrorFromCode(o.W.WriteFrame(o.Index, pps, o.Flags, points))
+}
+
+// Stop stops output of the device if W has a Stop(deviceIndex int) int
+// method, as helios.DAC and remote.Client do, and otherwise does nothing.
+func (o *Indexed) Stop() error {
+	if s, ok := o.W.(interface{ Stop(int) int }); ok {
+		return helios.ErrorFromCode(s.Stop(o.Index))
+	}
+	return nil
+}
+
+// Close stops output.
+func (o *Indexed) Close() error {
+	return o.Stop()
+}
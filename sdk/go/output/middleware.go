@@ -0,0 +1,142 @@
+package output
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+// Middleware adds behavior to an Output by wrapping it: a safety mask, a
+// transform, a color map or metrics. Wrap methods such as
+// calibrate.Correction.Wrap and preview.Server.Wrap are Middleware.
+type Middleware func(Output) Output
+
+// Chain returns out wrapped in mw. Frames pass through the middleware in
+// the order given, the first seeing them first:
+//
+//	out := output.Chain(dev,
+//		output.Map(correction.Apply),
+//		output.Map(mask.Apply),
+//		metrics.Wrap,
+//	)
+func Chain(out Output, mw ...Middleware) Output {
+	for i := len(mw) - 1; i >= 0; i-- {
+		out = mw[i](out)
+	}
+	return out
+}
+
+// Map returns middleware writing f(points) in place of every frame, for
+// functions such as helios.AttenuationMap.Apply and color.Profile.Apply.
+// f must not modify points.
+func Map(f func(points []helios.Point) []helios.Point) Middleware {
+	return func(out Output) Output {
+		return &mapped{Output: out, f: f}
+	}
+}
+
+type mapped struct {
+	Output
+	f func([]helios.Point) []helios.Point
+}
+
+func (m *mapped) WriteFrame(pps int, points []helios.Point) error {
+	return m.Output.WriteFrame(pps, m.f(points))
+}
+
+func (m *mapped) WriteFrameMeta(pps int, points []helios.Point, meta helios.FrameMeta) error {
+	return WriteFrameMeta(m.Output, pps, m.f(points), meta)
+}
+
+func (m *mapped) Unwrap() Output { return m.Output }
+
+// Unwrapper is implemented by middleware outputs, returning the output
+// they wrap.
+type Unwrapper interface {
+	Unwrap() Output
+}
+
+// AsBuffered returns the first output implementing Buffered in the chain
+// of middleware from out, or false if there is none.
+func AsBuffered(out Output) (Buffered, bool) {
+	for out != nil {
+		if b, ok := out.(Buffered); ok {
+			return b, true
+		}
+		u, ok := out.(Unwrapper)
+		if !ok {
+			break
+		}
+		out = u.Unwrap()
+	}
+	return nil, false
+}
+
+// Counts are the frames written through a Metrics.
+type Counts struct {
+	// Frames and Points count the frames written successfully and their
+	// points.
+	Frames int64
+	Points int64
+
+	// Failed counts frames the output rejected.
+	Failed int64
+
+	// Playback is how long the frames written take to scan at their
+	// point rates.
+	Playback time.Duration
+}
+
+// Metrics counts the frames written to the outputs it wraps. The zero
+// Metrics is ready to use, and is safe for concurrent use.
+type Metrics struct {
+	mu     sync.Mutex
+	counts Counts
+}
+
+// Wrap returns an output counting every frame written to out.
+func (m *Metrics) Wrap(out Output) Output {
+	return &counted{Output: out, m: m}
+}
+
+// Counts returns the counts so far.
+func (m *Metrics) Counts() Counts {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.counts
+}
+
+// Reset zeroes the counts.
+func (m *Metrics) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counts = Counts{}
+}
+
+type counted struct {
+	Output
+	m *Metrics
+}
+
+func (c *counted) WriteFrame(pps int, points []helios.Point) error {
+	return c.WriteFrameMeta(pps, points, helios.FrameMeta{})
+}
+
+func (c *counted) WriteFrameMeta(pps int, points []helios.Point, meta helios.FrameMeta) error {
+	err := WriteFrameMeta(c.Output, pps, points, meta)
+	c.m.mu.Lock()
+	defer c.m.mu.Unlock()
+	if err != nil {
+		c.m.counts.Failed++
+		return err
+	}
+	c.m.counts.Frames++
+	c.m.counts.Points += int64(len(points))
+	if pps > 0 {
+		c.m.counts.Playback += time.Duration(len(points)) * time.Second / time.Duration(pps)
+	}
+	return nil
+}
+
+func (c *counted) Unwrap() Output { return c.Output }
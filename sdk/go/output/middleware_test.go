@@ -0,0 +1,143 @@
+package output
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+// recorder is an Output recording the frames written to it, and reporting
+// a fixed buffer.
+type recorder struct {
+	frames [][]helios.Point
+	fail   bool
+}
+
+func (r *recorder) Ready() (bool, error) { return true, nil }
+func (r *recorder) Stop() error          { return nil }
+func (r *recorder) Close() error         { return nil }
+
+func (r *recorder) WriteFrame(pps int, points []helios.Point) error {
+	if r.fail {
+		return helios.ErrTooManyPoints
+	}
+	r.frames = append(r.frames, points)
+	return nil
+}
+
+func (r *recorder) Buffered() (Buffer, error) {
+	return Buffer{Frames: 1}, nil
+}
+
+// shift returns middleware moving points right by dx.
+func shift(dx uint16) Middleware {
+	return Map(func(points []helios.Point) []helios.Point {
+		out := slices.Clone(points)
+		for i := range out {
+			out[i].X += dx
+		}
+		return out
+	})
+}
+
+func TestChain(t *testing.T) {
+	r := &recorder{}
+	var m Metrics
+	var order []string
+	trace := func(name string) Middleware {
+		return Map(func(points []helios.Point) []helios.Point {
+			order = append(order, name)
+			return points
+		})
+	}
+	out := Chain(r, trace("first"), shift(10), trace("second"), m.Wrap, shift(1))
+
+	in := []helios.Point{{X: 5}, {X: 6}}
+	if err := out.WriteFrame(1000, in); err != nil {
+		t.Fatal(err)
+	}
+	if got := r.frames[0]; got[0].X != 16 || got[1].X != 17 || in[0].X != 5 {
+		t.Fatalf("wrote %v from %v", got, in)
+	}
+	if !slices.Equal(order, []string{"first", "second"}) {
+		t.Fatalf("middleware ran in order %v", order)
+	}
+	if c := m.Counts(); c != (Counts{Frames: 1, Points: 2, Playback: 2 * time.Millisecond}) {
+		t.Fatalf("counts = %+v", c)
+	}
+	r.fail = true
+	if err := out.WriteFrame(1000, in); err == nil {
+		t.Fatal("WriteFrame hid the output's error")
+	}
+	if c := m.Counts(); c.Failed != 1 || c.Frames != 1 {
+		t.Fatalf("counts after a failure = %+v", c)
+	}
+	m.Reset()
+	if c := m.Counts(); c != (Counts{}) {
+		t.Fatalf("counts after Reset = %+v", c)
+	}
+
+	// The buffer of the output is found through the middleware.
+	if b, ok := AsBuffered(out); !ok {
+		t.Fatal("AsBuffered found no buffer")
+	} else if buf, _ := b.Buffered(); buf.Frames != 1 {
+		t.Fatalf("buffer = %+v", buf)
+	}
+	if _, ok := AsBuffered(Chain(struct{ Output }{r}, shift(1))); ok {
+		t.Fatal("AsBuffered looked through an output that does not unwrap")
+	}
+}
+
+// indexedWriter is a DeviceWriter recording the device written to.
+type indexedWriter struct {
+	device int
+	status int
+}
+
+func (w *indexedWriter) GetStatus(deviceIndex int) int { return w.status }
+
+func (w *indexedWriter) WriteFrame(deviceIndex int, pps int, flags int, points []helios.Point) int {
+	w.device = deviceIndex
+	return helios.Success
+}
+
+func TestIndexed(t *testing.T) {
+	w := &indexedWriter{status: helios.Success}
+	o := NewIndexed(w, 3)
+	if ready, err := o.Ready(); !ready || err != nil {
+		t.Fatalf("Ready = %v, %v", ready, err)
+	}
+	if err := o.WriteFrame(1000, []helios.Point{{}}); err != nil || w.device != 3 {
+		t.Fatalf("WriteFrame = %v to device %d", err, w.device)
+	}
+	if err := o.WriteFrame(1000, nil); err != helios.ErrNullPoints {
+		t.Fatalf("WriteFrame(nil) = %v", err)
+	}
+	w.status = int(helios.ErrDeviceClosed)
+	if _, err := o.Ready(); err != helios.ErrDeviceClosed {
+		t.Fatalf("Ready of a closed device = %v", err)
+	}
+	if err := o.Stop(); err != nil {
+		t.Fatalf("Stop = %v", err)
+	}
+}
+
+func TestDeviceEmptyFrame(t *testing.T) {
+	dac := helios.NewDAC()
+	defer dac.Close()
+	var written []int
+	dac.SetWriteHook(func(_ int, info helios.WriteInfo) { written = append(written, info.Points) })
+	o := NewDevice(dac, 0)
+	if err := o.WriteFrame(1000, nil); err != nil || len(written) != 0 {
+		t.Fatalf("WriteFrame(nil) = %v, wrote %v", err, written)
+	}
+
+	// Empty frames reach the DAC, which blanks the device if asked to.
+	dac.SetBlankOnEmpty(true)
+	o.WriteFrame(1000, nil)
+	if len(written) != 1 || written[0] == 0 {
+		t.Fatalf("blank on empty wrote %v", written)
+	}
+}
@@ -0,0 +1,190 @@
+package output
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+// Region is the part of a shared canvas an output of a Multi covers. The
+// canvas is the coordinate space of the frames written to the Multi
+// (0 - 4095 on both axes), and each region is stretched over the full
+// scan field of its output.
+type Region struct {
+	Out Output `json:"-"`
+
+	// X0, Y0, X1 and Y1 are the corners of the region on the canvas.
+	X0 float64 `json:"x0"`
+	Y0 float64 `json:"y0"`
+	X1 float64 `json:"x1"`
+	Y1 float64 `json:"y1"`
+}
+
+// Validate checks that the region is a non-empty rectangle on the canvas.
+func (r Region) Validate() error {
+	if !(r.X0 >= 0 && r.X0 < r.X1 && r.X1 <= maxCoord && r.Y0 >= 0 && r.Y0 < r.Y1 && r.Y1 <= maxCoord) {
+		return fmt.Errorf("output: region (%v, %v)-(%v, %v) is not a rectangle within 0 - %d", r.X0, r.Y0, r.X1, r.Y1, maxCoord)
+	}
+	return nil
+}
+
+func (r Region) contains(x, y float64) bool {
+	return x >= r.X0 && x <= r.X1 && y >= r.Y0 && y <= r.Y1
+}
+
+// maxCoord is the highest helios.Point coordinate.
+const maxCoord = 4095
+
+// Multi is an Output splitting frames across several projectors covering
+// adjacent regions of one canvas, such as two projectors side by side
+// making one wide image. Where regions overlap, the projectors are edge
+// blended: each point is drawn by every projector covering it, with its
+// color scaled by weights that fade from one projector to the other across
+// the overlap and add up to one, so content crossing the seam keeps its
+// brightness.
+//
+// Every output receives a frame with one point for each point written, so
+// all of them scan in step at the same rate. Points outside an output's
+// region are blanked at the region's edge.
+type Multi struct {
+	regions []Region
+	inner   [][4]bool // per region, whether each edge lies inside the canvas
+}
+
+// NewMulti returns a Multi writing to the outputs of regions.
+func NewMulti(regions ...Region) (*Multi, error) {
+	if len(regions) == 0 {
+		return nil, errors.New("output: multi needs at least one region")
+	}
+	bounds := regions[0]
+	for i, r := range regions {
+		if err := r.Validate(); err != nil {
+			return nil, err
+		}
+		if r.Out == nil {
+			return nil, fmt.Errorf("output: region %d has no output", i)
+		}
+		bounds.X0, bounds.Y0 = min(bounds.X0, r.X0), min(bounds.Y0, r.Y0)
+		bounds.X1, bounds.Y1 = max(bounds.X1, r.X1), max(bounds.Y1, r.Y1)
+	}
+	m := &Multi{regions: regions, inner: make([][4]bool, len(regions))}
+	for i, r := range regions {
+		m.inner[i] = [4]bool{r.X0 > bounds.X0, r.X1 < bounds.X1, r.Y0 > bounds.Y0, r.Y1 < bounds.Y1}
+	}
+	return m, nil
+}
+
+// Ready reports whether every output can accept the next frame.
+func (m *Multi) Ready() (bool, error) {
+	for _, r := range m.regions {
+		if ready, err := r.Out.Ready(); err != nil || !ready {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// WriteFrame writes each output its part of points, blended where regions
+// overlap. It writes to every output even if one fails, and returns the
+// errors joined.
+func (m *Multi) WriteFrame(pps int, points []helios.Point) error {
+	frames := m.Split(points)
+	var errs []error
+	for i, r := range m.regions {
+		errs = append(errs, r.Out.WriteFrame(pps, frames[i]))
+	}
+	return errors.Join(errs...)
+}
+
+// Split returns the frame of each region for points, in the order of the
+// regions, without writing them.
+func (m *Multi) Split(points []helios.Point) [][]helios.Point {
+	frames := make([][]helios.Point, len(m.regions))
+	for i := range frames {
+		frames[i] = make([]helios.Point, len(points))
+	}
+	weights := make([]float64, len(m.regions))
+	for k, p := range points {
+		m.weigh(float64(p.X), float64(p.Y), weights)
+		for i, r := range m.regions {
+			q := helios.Point{
+				X: helios.ClampToCoord((min(max(float64(p.X), r.X0), r.X1) - r.X0) * maxCoord / (r.X1 - r.X0)),
+				Y: helios.ClampToCoord((min(max(float64(p.Y), r.Y0), r.Y1) - r.Y0) * maxCoord / (r.Y1 - r.Y0)),
+			}
+			if w := weights[i]; w > 0 {
+				q.R, q.G, q.B, q.I = helios.ScaleColor(p.R, w), helios.ScaleColor(p.G, w), helios.ScaleColor(p.B, w), helios.ScaleColor(p.I, w)
+			}
+			frames[i][k] = q
+		}
+	}
+	return frames
+}
+
+// weigh sets the blend weight of each region at (x, y). A region's raw
+// weight is the distance to its nearest edge inside the canvas, so it
+// falls to zero where another projector takes over; the weights are then
+// normalized to add up to one. Regions without edges inside the canvas
+// share the points they cover evenly.
+func (m *Multi) weigh(x, y float64, weights []float64) {
+	var sum float64
+	covering, unbounded := 0, 0
+	for i, r := range m.regions {
+		weights[i] = 0
+		if !r.contains(x, y) {
+			continue
+		}
+		covering++
+		d := math.Inf(1)
+		for e, dist := range [4]float64{x - r.X0, r.X1 - x, y - r.Y0, r.Y1 - y} {
+			if m.inner[i][e] {
+				d = min(d, dist)
+			}
+		}
+		if math.IsInf(d, 1) {
+			unbounded++
+			continue
+		}
+		weights[i] = d
+		sum += d
+	}
+	for i, r := range m.regions {
+		switch {
+		case !r.contains(x, y):
+		case unbounded > 0:
+			if !m.bounded(i) {
+				weights[i] = 1 / float64(unbounded)
+			} else {
+				weights[i] = 0
+			}
+		case sum == 0:
+			weights[i] = 1 / float64(covering)
+		default:
+			weights[i] /= sum
+		}
+	}
+}
+
+// bounded reports whether a region has an edge inside the canvas.
+func (m *Multi) bounded(i int) bool {
+	return m.inner[i] != [4]bool{}
+}
+
+// Stop stops every output.
+func (m *Multi) Stop() error {
+	var errs []error
+	for _, r := range m.regions {
+		errs = append(errs, r.Out.Stop())
+	}
+	return errors.Join(errs...)
+}
+
+// Close closes every output.
+func (m *Multi) Close() error {
+	var errs []error
+	for _, r := range m.regions {
+		errs = append(errs, r.Out.Close())
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,78 @@
+package output
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+func TestMultiBlendsOverlap(t *testing.T) {
+	left, right := &recorder{}, &recorder{}
+	m, err := NewMulti(
+		Region{Out: left, X0: 0, Y0: 0, X1: 2400, Y1: 4095},
+		Region{Out: right, X0: 1700, Y0: 0, X1: 4095, Y1: 4095},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := []helios.Point{
+		{X: 1000, Y: 2000, R: 200, I: 200}, // left only
+		{X: 2050, Y: 2000, R: 200, I: 200}, // middle of the overlap
+		{X: 2225, Y: 2000, R: 200, I: 200}, // three quarters across it
+		{X: 3000, Y: 4095, R: 200, I: 200}, // right only
+		{X: 4095, Y: 0, R: 0, I: 0},        // blanked
+	}
+	if err := m.WriteFrame(30000, in); err != nil {
+		t.Fatal(err)
+	}
+	l, r := left.frames[0], right.frames[0]
+	if len(l) != len(in) || len(r) != len(in) {
+		t.Fatalf("frames of %d and %d points, want %d", len(l), len(r), len(in))
+	}
+	for i, want := range [][2]uint8{{200, 0}, {100, 100}, {50, 150}, {0, 200}, {0, 0}} {
+		if l[i].R != want[0] || r[i].R != want[1] {
+			t.Errorf("point %d: red %d + %d, want %d + %d", i, l[i].R, r[i].R, want[0], want[1])
+		}
+	}
+	// Regions are stretched over the whole field; points outside park
+	// blanked on the edge.
+	if l[1].X != helios.ClampToCoord(2050*4095.0/2400) || l[3].X != 4095 || l[3].Y != 4095 {
+		t.Errorf("left points %+v, %+v", l[1], l[3])
+	}
+	if r[0].X != 0 || r[0].R != 0 || r[3].X != helios.ClampToCoord(1300*4095.0/2395) {
+		t.Errorf("right points %+v, %+v", r[0], r[3])
+	}
+}
+
+func TestMultiSingleRegion(t *testing.T) {
+	out := &recorder{}
+	m, err := NewMulti(Region{Out: out, X1: 4095, Y1: 4095})
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := []helios.Point{{X: 10, Y: 20, G: 255, I: 255}}
+	m.WriteFrame(1000, in)
+	if out.frames[0][0] != in[0] {
+		t.Fatalf("point = %+v, want %+v", out.frames[0][0], in[0])
+	}
+}
+
+func TestMultiErrors(t *testing.T) {
+	if _, err := NewMulti(); err == nil {
+		t.Error("no regions accepted")
+	}
+	if _, err := NewMulti(Region{Out: &recorder{}, X0: 100, X1: 50, Y1: 10}); err == nil {
+		t.Error("inverted region accepted")
+	}
+	if _, err := NewMulti(Region{X1: 10, Y1: 10}); err == nil {
+		t.Error("region without output accepted")
+	}
+
+	ok, failing := &recorder{}, &recorder{fail: true}
+	m, _ := NewMulti(Region{Out: failing, X1: 2048, Y1: 4095}, Region{Out: ok, X0: 2048, X1: 4095, Y1: 4095})
+	err := m.WriteFrame(1000, []helios.Point{{}})
+	if !errors.Is(err, helios.ErrTooManyPoints) || len(ok.frames) != 1 {
+		t.Fatalf("err = %v, %d frames written to the working output", err, len(ok.frames))
+	}
+}
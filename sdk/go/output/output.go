@@ -0,0 +1,114 @@
+// Package output defines a backend-agnostic interface for laser DACs.
+//
+// An Output accepts frames of helios.Point regardless of how they reach the
+// projector. Device adapts one device opened through the Helios SDK; the idn
+// (IDN-Stream) and etherdream subpackages implement the same interface for
+// other DAC families, and Indexed adapts device-indexed sinks such as
+// remote.Client and tape.Recorder, so a frame pipeline can drive any of
+// them. Middleware wraps an Output to mask, transform, color or count the
+// frames written to it; Chain stacks several. Multi splits one canvas
+// across projectors covering adjacent regions, edge blending where they
+// overlap. WriteFrameMeta writes a frame with the helios.FrameMeta
+// identifying it, kept through middleware implementing MetaWriter, so Log
+// and the DAC's write hook can name the generator of a failing frame.
+package output
+
+import (
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+// Output is a single laser output.
+type Output interface {
+	// Ready reports whether the output can accept the next frame.
+	Ready() (bool, error)
+
+	// WriteFrame queues a frame to be scanned at pps points per second.
+	WriteFrame(pps int, points []helios.Point) error
+
+	// Stop blanks the output until the next frame is written.
+	Stop() error
+
+	// Close stops the output and releases its resources.
+	Close() error
+}
+
+// Buffered is implemented by outputs that report the output written to
+// them and not scanned yet, so callers can tell how soon they run out.
+type Buffered interface {
+	Buffered() (Buffer, error)
+}
+
+// Buffer is output queued on a DAC.
+type Buffer struct {
+	// Frames is the number of frames waiting behind the one scanning, or
+	// -1 for DACs queuing points rather than frames.
+	Frames int
+
+	// Points is the number of points queued.
+	Points int
+
+	// Duration is how long the DAC takes to scan the queued points.
+	Duration time.Duration
+}
+
+// Device is an Output writing to one device of a helios.DAC.
+type Device struct {
+	DAC   *helios.DAC
+	Index int
+
+	// Flags are passed to helios.DAC.WriteFrame.
+	Flags int
+}
+
+// NewDevice returns an Output for the device with the given index, writing
+// with helios.FlagsDefault. The devices of dac must already be open.
+func NewDevice(dac *helios.DAC, index int) *Device {
+	return &Device{DAC: dac, Index: index, Flags: helios.FlagsDefault}
+}
+
+// Ready reports whether the device is ready for the next frame.
+func (d *Device) Ready() (bool, error) {
+	s := d.DAC.Status(d.Index)
+	if s.Healthy() {
+		return s.Ready(), nil
+	}
+	return false, s.Err
+}
+
+// Buffered returns the device's buffer, estimated by
+// helios.DAC.Buffer.
+func (d *Device) Buffered() (Buffer, error) {
+	b, err := d.DAC.Buffer(d.Index)
+	if err != nil {
+		return Buffer{}, err
+	}
+	return Buffer{Frames: b.Frames, Points: b.Points, Duration: b.Remaining}, nil
+}
+
+// WriteFrame sends points to the device with helios.DAC.WriteFrameChecked.
+// If validation is enabled on the DAC, a rejected frame is reported with a
+// *helios.FrameError describing the problem, as the DAC found it after
+// adapting, limiting slew and splitting. A frame without points blanks the
+// device if helios.DAC.SetBlankOnEmpty is on, and is ignored otherwise.
+func (d *Device) WriteFrame(pps int, points []helios.Point) error {
+	return d.WriteFrameMeta(pps, points, helios.FrameMeta{})
+}
+
+// WriteFrameMeta sends points to the device like WriteFrame, passing meta
+// to the DAC's write hook.
+func (d *Device) WriteFrameMeta(pps int, points []helios.Point, meta helios.FrameMeta) error {
+	return d.DAC.WriteFrameChecked(d.Index, pps, d.Flags, points, meta)
+}
+
+// Stop stops output of the device.
+func (d *Device) Stop() error {
+	return helios.ErrorFromCode(d.DAC.Stop(d.Index))
+}
+
+// Close stops output. The device itself stays open until the DAC's devices
+// are closed.
+func (d *Device) Close() error {
+	return d.Stop()
+}
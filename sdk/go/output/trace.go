@@ -0,0 +1,53 @@
+package output
+
+import (
+	"log"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+// MetaWriter is implemented by outputs that take the metadata of the
+// frames written to them. Middleware implementing it passes the metadata
+// on to the output it wraps; other outputs drop it.
+type MetaWriter interface {
+	WriteFrameMeta(pps int, points []helios.Point, meta helios.FrameMeta) error
+}
+
+// WriteFrameMeta writes a frame with its metadata to out, or only the frame
+// if out does not implement MetaWriter.
+func WriteFrameMeta(out Output, pps int, points []helios.Point, meta helios.FrameMeta) error {
+	if w, ok := out.(MetaWriter); ok {
+		return w.WriteFrameMeta(pps, points, meta)
+	}
+	return out.WriteFrame(pps, points)
+}
+
+// Log returns middleware logging every frame the output rejects to l, with
+// the frame's metadata, or to the standard logger if l is nil.
+func Log(l *log.Logger) Middleware {
+	if l == nil {
+		l = log.Default()
+	}
+	return func(out Output) Output {
+		return &logged{Output: out, l: l}
+	}
+}
+
+type logged struct {
+	Output
+	l *log.Logger
+}
+
+func (o *logged) WriteFrame(pps int, points []helios.Point) error {
+	return o.WriteFrameMeta(pps, points, helios.FrameMeta{})
+}
+
+func (o *logged) WriteFrameMeta(pps int, points []helios.Point, meta helios.FrameMeta) error {
+	err := WriteFrameMeta(o.Output, pps, points, meta)
+	if err != nil {
+		o.l.Printf("output: %v (%d points at %d pps) failed: %v", meta, len(points), pps, err)
+	}
+	return err
+}
+
+func (o *logged) Unwrap() Output { return o.Output }
@@ -0,0 +1,59 @@
+package output
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+// metaRecorder is a recorder also recording frame metadata.
+type metaRecorder struct {
+	recorder
+	metas []helios.FrameMeta
+}
+
+func (r *metaRecorder) WriteFrameMeta(pps int, points []helios.Point, meta helios.FrameMeta) error {
+	r.metas = append(r.metas, meta)
+	return r.WriteFrame(pps, points)
+}
+
+func TestFrameMetaThroughMiddleware(t *testing.T) {
+	r := &metaRecorder{}
+	var m Metrics
+	var buf bytes.Buffer
+	out := Chain(r, shift(1), m.Wrap, Log(log.New(&buf, "", 0)))
+
+	meta := helios.FrameMeta{ID: 42, Source: "tunnel"}
+	if err := WriteFrameMeta(out, 1000, []helios.Point{{}}, meta); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.metas) != 1 || r.metas[0] != meta {
+		t.Fatalf("metas = %v, want [%v]", r.metas, meta)
+	}
+	if r.frames[0][0].X != 1 {
+		t.Fatal("frame not mapped")
+	}
+	if c := m.Counts(); c.Frames != 1 {
+		t.Fatalf("counted %d frames, want 1", c.Frames)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("logged %q for a successful write", buf.String())
+	}
+
+	r.fail = true
+	if err := WriteFrameMeta(out, 1000, []helios.Point{{}}, meta); err == nil {
+		t.Fatal("write did not fail")
+	}
+	if !strings.Contains(buf.String(), "frame 42 from tunnel") {
+		t.Fatalf("log %q does not name the frame", buf.String())
+	}
+
+	// Outputs without MetaWriter get the frame alone.
+	plain := &recorder{}
+	if err := WriteFrameMeta(plain, 1000, []helios.Point{{}}, meta); err != nil || len(plain.frames) != 1 {
+		t.Fatalf("plain write = %v, %d frames", err, len(plain.frames))
+	}
+}
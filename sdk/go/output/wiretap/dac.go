@@ -0,0 +1,36 @@
+package wiretap
+
+import (
+	"net"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+// TapDAC records every USB transfer and network packet exchanged with the
+// DACs of the helios package to sink, until stop is called. Sink errors are
+// ignored. Output waits for the sink, so it should be buffered; the capture
+// is process-wide, and only one tap is active at a time.
+func TapDAC(sink Sink) (stop func()) {
+	helios.SetWireTap(func(w helios.WireRecord) {
+		sink.Record(fromDAC(w))
+	})
+	return func() { helios.SetWireTap(nil) }
+}
+
+func fromDAC(w helios.WireRecord) Record {
+	r := Record{
+		Time:     w.Time,
+		Dir:      Received,
+		Network:  w.Transport.String(),
+		Endpoint: w.Endpoint,
+		Err:      w.Err,
+		Data:     w.Data,
+	}
+	if w.Sent {
+		r.Dir = Sent
+	}
+	if w.Transport == helios.WireUDP {
+		r.Remote = net.UDPAddrFromAddrPort(w.Addr)
+	}
+	return r
+}
@@ -0,0 +1,63 @@
+package wiretap
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// LogSink writes one line per record: the time since the first record, the
+// direction, the length, the leading bytes in hex and the error of a failed
+// transfer.
+//
+//   - 12.345ms > 1454 bytes 40 00 00 01 05 aa c2 01 ...
+type LogSink struct {
+	// MaxBytes limits the bytes printed per record. Zero means 32.
+	MaxBytes int
+
+	mu    sync.Mutex
+	w     io.Writer
+	start time.Time
+}
+
+// NewLogSink creates a sink writing to w.
+func NewLogSink(w io.Writer) *LogSink {
+	return &LogSink{w: w}
+}
+
+// Record writes r.
+func (s *LogSink) Record(r Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.start.IsZero() {
+		s.start = r.Time
+	}
+	limit := s.MaxBytes
+	if limit == 0 {
+		limit = 32
+	}
+	data, more := r.Data, ""
+	if len(data) > limit {
+		data, more = data[:limit], " ..."
+	}
+	if r.Err != nil {
+		more += fmt.Sprintf(" (%v)", r.Err)
+	}
+	elapsed := float64(r.Time.Sub(s.start)) / float64(time.Millisecond)
+	_, err := fmt.Fprintf(s.w, "+%10.3fms %v %4d bytes %s%s\n", elapsed, r.Dir, len(r.Data), spaced(data), more)
+	return err
+}
+
+func spaced(b []byte) string {
+	h := hex.EncodeToString(b)
+	out := make([]byte, 0, len(h)*3/2)
+	for i := 0; i < len(h); i += 2 {
+		if i > 0 {
+			out = append(out, ' ')
+		}
+		out = append(out, h[i], h[i+1])
+	}
+	return string(out)
+}
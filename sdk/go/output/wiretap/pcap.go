@@ -0,0 +1,141 @@
+package wiretap
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	pcapMagic      = 0xa1b2c3d4
+	pcapSnapLen    = 65535
+	linkTypeRawIP  = 101
+	ipv4HeaderLen  = 20
+	udpHeaderLen   = 8
+	tcpHeaderLen   = 20
+	protoTCP       = 6
+	protoUDP       = 17
+	tcpFlagsPshAck = 0x18
+)
+
+// PcapSink writes records as IPv4 packets to a pcap capture. UDP datagrams
+// are written as they were sent; TCP reads and writes become segments with
+// consistent sequence numbers, so Wireshark can follow the stream.
+type PcapSink struct {
+	mu  sync.Mutex
+	w   io.Writer
+	seq map[Direction]uint32
+	id  uint16
+}
+
+// NewPcapSink writes the capture file header to w and returns a sink
+// appending packets to it.
+func NewPcapSink(w io.Writer) (*PcapSink, error) {
+	if err := writePcapHeader(w, linkTypeRawIP); err != nil {
+		return nil, err
+	}
+	return &PcapSink{w: w, seq: make(map[Direction]uint32)}, nil
+}
+
+func writePcapHeader(w io.Writer, linkType uint32) error {
+	h := make([]byte, 0, 24)
+	h = binary.LittleEndian.AppendUint32(h, pcapMagic)
+	h = binary.LittleEndian.AppendUint16(h, 2)
+	h = binary.LittleEndian.AppendUint16(h, 4)
+	h = binary.LittleEndian.AppendUint32(h, 0) // GMT offset
+	h = binary.LittleEndian.AppendUint32(h, 0) // timestamp accuracy
+	h = binary.LittleEndian.AppendUint32(h, pcapSnapLen)
+	h = binary.LittleEndian.AppendUint32(h, linkType)
+	_, err := w.Write(h)
+	return err
+}
+
+// pcapRecordHeader returns the header of a packet of n bytes.
+func pcapRecordHeader(t time.Time, n int) []byte {
+	rec := make([]byte, 0, 16)
+	usec := t.UnixMicro()
+	rec = binary.LittleEndian.AppendUint32(rec, uint32(usec/1e6))
+	rec = binary.LittleEndian.AppendUint32(rec, uint32(usec%1e6))
+	rec = binary.LittleEndian.AppendUint32(rec, uint32(n))
+	rec = binary.LittleEndian.AppendUint32(rec, uint32(n))
+	return rec
+}
+
+// Record appends r as one packet. USB records are skipped; see UsbPcapSink.
+func (s *PcapSink) Record(r Record) error {
+	if r.usb() {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	src, dst := r.Local, r.Remote
+	if r.Dir == Received {
+		src, dst = dst, src
+	}
+	srcIP, srcPort := ipPort(src)
+	dstIP, dstPort := ipPort(dst)
+
+	proto, transport := byte(protoUDP), make([]byte, 0, tcpHeaderLen)
+	transport = binary.BigEndian.AppendUint16(transport, srcPort)
+	transport = binary.BigEndian.AppendUint16(transport, dstPort)
+	if r.Network == "tcp" {
+		proto = protoTCP
+		transport = binary.BigEndian.AppendUint32(transport, s.seq[r.Dir])
+		transport = binary.BigEndian.AppendUint32(transport, s.seq[1-r.Dir])
+		transport = append(transport, tcpHeaderLen/4<<4, tcpFlagsPshAck)
+		transport = binary.BigEndian.AppendUint16(transport, 0xffff) // window
+		transport = append(transport, 0, 0, 0, 0)                    // checksum, urgent
+		s.seq[r.Dir] += uint32(len(r.Data))
+	} else {
+		transport = binary.BigEndian.AppendUint16(transport, uint16(udpHeaderLen+len(r.Data)))
+		transport = append(transport, 0, 0) // no checksum
+	}
+
+	total := ipv4HeaderLen + len(transport) + len(r.Data)
+	ip := make([]byte, 0, ipv4HeaderLen)
+	ip = append(ip, 0x45, 0)
+	ip = binary.BigEndian.AppendUint16(ip, uint16(total))
+	ip = binary.BigEndian.AppendUint16(ip, s.id)
+	ip = append(ip, 0x40, 0, 64, proto, 0, 0) // don't fragment, TTL, checksum below
+	ip = append(ip, srcIP...)
+	ip = append(ip, dstIP...)
+	binary.BigEndian.PutUint16(ip[10:], checksum(ip))
+	s.id++
+
+	for _, b := range [][]byte{pcapRecordHeader(r.Time, total), ip, transport, r.Data} {
+		if _, err := s.w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ipPort extracts an IPv4 address and port; other addresses become 0.0.0.0.
+func ipPort(a net.Addr) (net.IP, uint16) {
+	var ip net.IP
+	var port int
+	switch a := a.(type) {
+	case *net.UDPAddr:
+		ip, port = a.IP, a.Port
+	case *net.TCPAddr:
+		ip, port = a.IP, a.Port
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4, uint16(port)
+	}
+	return net.IPv4zero.To4(), uint16(port)
+}
+
+func checksum(b []byte) uint16 {
+	var sum uint32
+	for i := 0; i+1 < len(b); i += 2 {
+		sum += uint32(binary.BigEndian.Uint16(b[i:]))
+	}
+	for sum > 0xffff {
+		sum = sum>>16 + sum&0xffff
+	}
+	return ^uint16(sum)
+}
@@ -0,0 +1,76 @@
+package wiretap
+
+import (
+	"encoding/binary"
+	"io"
+	"sync"
+)
+
+const (
+	linkTypeUsbLinuxMmapped = 220
+	usbHeaderLen            = 64
+	usbTransferInterrupt    = 1
+	usbTransferBulk         = 3
+	usbStatusEIO            = -5
+)
+
+// UsbPcapSink writes USB records to a pcap capture in the Linux usbmon
+// format, which Wireshark decodes on any platform. Sent data appears as an
+// URB submission and received data as an URB completion; failed transfers
+// complete with status -EIO. Network records are skipped; see PcapSink.
+type UsbPcapSink struct {
+	mu sync.Mutex
+	w  io.Writer
+	id uint64
+}
+
+// NewUsbPcapSink writes the capture file header to w and returns a sink
+// appending transfers to it.
+func NewUsbPcapSink(w io.Writer) (*UsbPcapSink, error) {
+	if err := writePcapHeader(w, linkTypeUsbLinuxMmapped); err != nil {
+		return nil, err
+	}
+	return &UsbPcapSink{w: w}, nil
+}
+
+// Record appends r as one URB.
+func (s *UsbPcapSink) Record(r Record) error {
+	if !r.usb() {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.id++
+
+	kind, transfer := byte('C'), byte(usbTransferInterrupt)
+	if r.Dir == Sent {
+		kind = 'S'
+	}
+	if r.Network == "usb-bulk" {
+		transfer = usbTransferBulk
+	}
+	status := int32(0)
+	if r.Err != nil {
+		status = usbStatusEIO
+	}
+	usec := r.Time.UnixMicro()
+
+	h := make([]byte, 0, usbHeaderLen)
+	h = binary.LittleEndian.AppendUint64(h, s.id)
+	h = append(h, kind, transfer, byte(r.Endpoint), 1) // device 1
+	h = binary.LittleEndian.AppendUint16(h, 1)         // bus 1
+	h = append(h, '-', 0)                              // no setup packet, data present
+	h = binary.LittleEndian.AppendUint64(h, uint64(usec/1e6))
+	h = binary.LittleEndian.AppendUint32(h, uint32(usec%1e6))
+	h = binary.LittleEndian.AppendUint32(h, uint32(status))
+	h = binary.LittleEndian.AppendUint32(h, uint32(len(r.Data))) // URB length
+	h = binary.LittleEndian.AppendUint32(h, uint32(len(r.Data))) // captured length
+	h = append(h, make([]byte, usbHeaderLen-len(h))...)          // setup, interval, start frame, flags, descriptors
+
+	for _, b := range [][]byte{pcapRecordHeader(r.Time, usbHeaderLen+len(r.Data)), h, r.Data} {
+		if _, err := s.w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
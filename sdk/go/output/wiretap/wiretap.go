@@ -0,0 +1,137 @@
+// Package wiretap records the traffic between the SDK and DACs for protocol
+// debugging.
+//
+// Wrap a connection before handing it to a backend; every read and write is
+// passed with its timestamp to a Sink:
+//
+//	conn, _ := net.Dial("udp", "10.0.0.5:7255")
+//	f, _ := os.Create("idn.pcap")
+//	sink, _ := wiretap.NewPcapSink(f)
+//	sender := idn.NewSender(wiretap.Wrap(conn, sink))
+//
+// TapDAC records the USB transfers and network packets of the Helios DACs
+// driven through the helios package in the same way:
+//
+//	usb, _ := os.Create("helios-usb.pcap")
+//	net, _ := os.Create("helios-net.pcap")
+//	usbSink, _ := wiretap.NewUsbPcapSink(usb)
+//	netSink, _ := wiretap.NewPcapSink(net)
+//	stop := wiretap.TapDAC(wiretap.Tee(usbSink, netSink))
+//	defer stop()
+//
+// LogSink prints a readable trace; PcapSink and UsbPcapSink write capture
+// files that open in Wireshark and can be attached to a bug report.
+package wiretap
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"time"
+)
+
+// Direction tells whether data was sent to or received from the DAC.
+type Direction int
+
+const (
+	Sent Direction = iota
+	Received
+)
+
+func (d Direction) String() string {
+	if d == Sent {
+		return ">"
+	}
+	return "<"
+}
+
+// Record is one read or write on a tapped connection.
+type Record struct {
+	Time time.Time
+	Dir  Direction
+
+	// Network is the connection's network, e.g. "udp" or "tcp", or
+	// "usb-bulk" or "usb-interrupt" for USB transfers.
+	Network       string
+	Local, Remote net.Addr
+
+	// Endpoint is the endpoint address of a USB transfer.
+	Endpoint int
+
+	// Err is the error of a failed USB transfer.
+	Err error
+
+	Data []byte
+}
+
+func (r Record) usb() bool {
+	return strings.HasPrefix(r.Network, "usb")
+}
+
+// Sink receives records. Data is only valid during the call.
+type Sink interface {
+	Record(r Record) error
+}
+
+// Tee returns a sink passing records to all of sinks.
+func Tee(sinks ...Sink) Sink {
+	return tee(sinks)
+}
+
+type tee []Sink
+
+func (t tee) Record(r Record) error {
+	var errs []error
+	for _, s := range t {
+		errs = append(errs, s.Record(r))
+	}
+	return errors.Join(errs...)
+}
+
+// Conn is a net.Conn passing all traffic to a Sink.
+type Conn struct {
+	net.Conn
+	sink Sink
+}
+
+// Wrap returns conn with its traffic recorded to sink. Sink errors are
+// ignored so a failing capture never disturbs output.
+func Wrap(conn net.Conn, sink Sink) *Conn {
+	return &Conn{Conn: conn, sink: sink}
+}
+
+// Dial connects like net.Dial and wraps the connection.
+func Dial(network, addr string, sink Sink) (*Conn, error) {
+	conn, err := net.Dial(network, addr)
+	if err != nil {
+		return nil, err
+	}
+	return Wrap(conn, sink), nil
+}
+
+func (c *Conn) record(dir Direction, b []byte) {
+	c.sink.Record(Record{
+		Time:    time.Now(),
+		Dir:     dir,
+		Network: c.LocalAddr().Network(),
+		Local:   c.LocalAddr(),
+		Remote:  c.RemoteAddr(),
+		Data:    b,
+	})
+}
+
+func (c *Conn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if n > 0 {
+		c.record(Received, b[:n])
+	}
+	return n, err
+}
+
+func (c *Conn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	if n > 0 {
+		c.record(Sent, b[:n])
+	}
+	return n, err
+}
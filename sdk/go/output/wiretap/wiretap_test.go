@@ -0,0 +1,129 @@
+package wiretap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+type multiSink []Sink
+
+func (m multiSink) Record(r Record) error {
+	for _, s := range m {
+		if err := s.Record(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestTapUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	var text, capture bytes.Buffer
+	pcap, err := NewPcapSink(&capture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := Dial("udp", pc.LocalAddr().String(), multiSink{NewLogSink(&text), pcap})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.Write([]byte{0x40, 0x00, 0x00, 0x01, 0xaa})
+
+	if got := text.String(); !strings.Contains(got, ">    5 bytes 40 00 00 01 aa\n") {
+		t.Fatalf("log = %q", got)
+	}
+
+	b := capture.Bytes()
+	if len(b) != 24+16+ipv4HeaderLen+udpHeaderLen+5 {
+		t.Fatalf("capture is %d bytes", len(b))
+	}
+	if binary.LittleEndian.Uint32(b[20:]) != linkTypeRawIP {
+		t.Fatal("wrong link type")
+	}
+	ip := b[40:]
+	if ip[9] != protoUDP || checksum(ip[:ipv4HeaderLen]) != 0 {
+		t.Fatalf("bad IPv4 header % x", ip[:ipv4HeaderLen])
+	}
+	udp := ip[ipv4HeaderLen:]
+	if int(binary.BigEndian.Uint16(udp[2:])) != pc.LocalAddr().(*net.UDPAddr).Port {
+		t.Fatal("wrong destination port")
+	}
+	if !bytes.Equal(udp[udpHeaderLen:], []byte{0x40, 0x00, 0x00, 0x01, 0xaa}) {
+		t.Fatalf("payload = % x", udp[udpHeaderLen:])
+	}
+}
+
+func TestPcapTCPSequence(t *testing.T) {
+	var capture bytes.Buffer
+	s, _ := NewPcapSink(&capture)
+	local := &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 50000}
+	remote := &net.TCPAddr{IP: net.IPv4(10, 0, 0, 2), Port: 7765}
+	s.Record(Record{Dir: Sent, Network: "tcp", Local: local, Remote: remote, Data: []byte("p")})
+	s.Record(Record{Dir: Sent, Network: "tcp", Local: local, Remote: remote, Data: []byte("?")})
+
+	second := capture.Bytes()[24+16+ipv4HeaderLen+tcpHeaderLen+1+16:]
+	tcp := second[ipv4HeaderLen:]
+	if seq := binary.BigEndian.Uint32(tcp[4:]); seq != 1 {
+		t.Fatalf("second segment seq = %d, want 1", seq)
+	}
+}
+
+func TestUsbPcap(t *testing.T) {
+	var capture, ip bytes.Buffer
+	usb, _ := NewUsbPcapSink(&capture)
+	pcap, _ := NewPcapSink(&ip)
+	frame := Record{Dir: Sent, Network: "usb-bulk", Endpoint: 0x02, Data: []byte{1, 2, 3}}
+	status := Record{Dir: Received, Network: "usb-interrupt", Endpoint: 0x83, Err: helios.ErrLibusbBase - 7}
+	Tee(usb, pcap).Record(frame)
+	Tee(usb, pcap).Record(status)
+
+	if ip.Len() != 24 {
+		t.Fatal("pcap sink wrote USB records")
+	}
+	b := capture.Bytes()
+	if binary.LittleEndian.Uint32(b[20:]) != linkTypeUsbLinuxMmapped {
+		t.Fatal("wrong link type")
+	}
+	urb := b[24+16:]
+	if urb[8] != 'S' || urb[9] != usbTransferBulk || urb[10] != 0x02 {
+		t.Fatalf("bad submission header % x", urb[:16])
+	}
+	if n := binary.LittleEndian.Uint32(urb[36:]); n != 3 || !bytes.Equal(urb[usbHeaderLen:usbHeaderLen+3], []byte{1, 2, 3}) {
+		t.Fatalf("submission carries %d bytes % x", n, urb[usbHeaderLen:])
+	}
+	urb = urb[usbHeaderLen+3+16:]
+	if urb[8] != 'C' || urb[9] != usbTransferInterrupt || urb[10] != 0x83 {
+		t.Fatalf("bad completion header % x", urb[:16])
+	}
+	if status := int32(binary.LittleEndian.Uint32(urb[28:])); status != usbStatusEIO {
+		t.Fatalf("failed transfer completed with status %d", status)
+	}
+}
+
+func TestFromDAC(t *testing.T) {
+	r := fromDAC(helios.WireRecord{
+		Sent:      true,
+		Transport: helios.WireUDP,
+		Addr:      netip.MustParseAddrPort("10.0.0.5:7255"),
+		Data:      []byte{0x40},
+	})
+	if r.Dir != Sent || r.Network != "udp" || r.Remote.String() != "10.0.0.5:7255" {
+		t.Fatalf("UDP record = %+v", r)
+	}
+	r = fromDAC(helios.WireRecord{Transport: helios.WireUSBInterrupt, Endpoint: 0x83})
+	if r.Dir != Received || !r.usb() || r.Remote != nil || r.Endpoint != 0x83 {
+		t.Fatalf("USB record = %+v", r)
+	}
+}
@@ -0,0 +1,56 @@
+package param
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// state is a parameter with its current value, as served by Handler.
+type state struct {
+	*Param
+	Value float64 `json:"value"`
+}
+
+// Handler returns an HTTP API for the parameters, from which a web UI can
+// build its controls:
+//
+//	GET  /params                       every parameter with its range, default and value, as JSON
+//	PUT  /params/{name}?value=v        set a parameter
+//	PUT  /params/{name}?normalized=v   set a parameter from 0-1 within its range
+//	POST /params/reset                 reset every parameter to its default
+func (s *Set) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /params", func(w http.ResponseWriter, r *http.Request) {
+		states := make([]state, len(s.params))
+		for i, p := range s.params {
+			states[i] = state{p, p.Get()}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(states)
+	})
+	mux.HandleFunc("PUT /params/{name...}", func(w http.ResponseWriter, r *http.Request) {
+		p := s.Lookup(r.PathValue("name"))
+		if p == nil {
+			http.NotFound(w, r)
+			return
+		}
+		q := r.URL.Query()
+		set, arg := p.Set, q.Get("value")
+		if q.Has("normalized") {
+			set, arg = p.SetNormalized, q.Get("normalized")
+		}
+		v, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			http.Error(w, "invalid value", http.StatusBadRequest)
+			return
+		}
+		set(v)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("POST /params/reset", func(w http.ResponseWriter, r *http.Request) {
+		s.Reset()
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return mux
+}
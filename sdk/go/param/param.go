@@ -0,0 +1,275 @@
+// Package param exposes the tunable parameters of generators and effects,
+// declared with struct tags, so control surfaces can discover and bind them
+// without hand-written glue per effect.
+//
+// A field is a parameter if it has a param tag giving its name and, for
+// numbers, its range and optional default:
+//
+//	type Spiral struct {
+//		Turns  float64 `param:"turns,min=1,max=20,default=5"`
+//		Arms   int     `param:"arms,min=1,max=8"`
+//		Mirror bool    `param:"mirror"`
+//	}
+//
+// Float, integer and bool fields are supported. A tagged struct field
+// contributes its own parameters under its name, as "<name>/<param>".
+// Without a default, the value at the time of Of is the default.
+//
+// Every value is read and written as a float64; bools are 0 or 1. Set
+// clamps to the range and rounds integers. SetNormalized maps 0-1 to the
+// range, which suits OSC faders and MIDI controllers (CC value / 127).
+package param
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Kind is the type of a parameter's field.
+type Kind string
+
+// Parameter kinds.
+const (
+	Float Kind = "float"
+	Int   Kind = "int"
+	Bool  Kind = "bool"
+)
+
+// Param is one tunable parameter of a Set.
+type Param struct {
+	Name    string  `json:"name"`
+	Kind    Kind    `json:"kind"`
+	Min     float64 `json:"min"`
+	Max     float64 `json:"max"`
+	Default float64 `json:"default"`
+
+	field reflect.Value
+	mu    *sync.RWMutex
+}
+
+// Get returns the current value.
+func (p *Param) Get() float64 {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.get()
+}
+
+func (p *Param) get() float64 {
+	switch f := p.field; f.Kind() {
+	case reflect.Bool:
+		if f.Bool() {
+			return 1
+		}
+		return 0
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(f.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(f.Uint())
+	}
+	return p.field.Float()
+}
+
+// Set sets the value, clamped to the parameter's range. Integers are
+// rounded and bools are true for values of 0.5 and above. NaN is ignored.
+func (p *Param) Set(v float64) {
+	if math.IsNaN(v) {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.set(v)
+}
+
+func (p *Param) set(v float64) {
+	v = min(max(v, p.Min), p.Max)
+	switch f := p.field; f.Kind() {
+	case reflect.Bool:
+		f.SetBool(v >= 0.5)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		f.SetInt(int64(math.Round(v)))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		f.SetUint(uint64(math.Round(v)))
+	default:
+		f.SetFloat(v)
+	}
+}
+
+// SetNormalized sets the value from a position 0-1 within the range.
+func (p *Param) SetNormalized(v float64) {
+	p.Set(p.Min + v*(p.Max-p.Min))
+}
+
+// Normalized returns the value's position 0-1 within the range.
+func (p *Param) Normalized() float64 {
+	if p.Max == p.Min {
+		return 0
+	}
+	return (p.Get() - p.Min) / (p.Max - p.Min)
+}
+
+// Reset sets the default value.
+func (p *Param) Reset() {
+	p.Set(p.Default)
+}
+
+// Set is the parameters of one struct.
+//
+// Control surfaces change parameters from their own goroutines. A
+// generator reading its fields while they may change should hold RLock
+// while it does.
+type Set struct {
+	mu     sync.RWMutex
+	params []*Param
+	byName map[string]*Param
+}
+
+// Of returns the parameters of the struct v points to.
+func Of(v any) (*Set, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("param: %T is not a pointer to a struct", v)
+	}
+	s := &Set{byName: make(map[string]*Param)}
+	if err := s.add(rv.Elem(), ""); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Set) add(v reflect.Value, prefix string) error {
+	t := v.Type()
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		tag, ok := sf.Tag.Lookup("param")
+		if !ok || tag == "-" {
+			continue
+		}
+		if !sf.IsExported() {
+			return fmt.Errorf("param: field %s is not exported", sf.Name)
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			return fmt.Errorf("param: field %s has no name", sf.Name)
+		}
+		name = prefix + name
+		if sf.Type.Kind() == reflect.Struct {
+			if err := s.add(v.Field(i), name+"/"); err != nil {
+				return err
+			}
+			continue
+		}
+		p := &Param{Name: name, field: v.Field(i), mu: &s.mu}
+		if err := p.parse(opts); err != nil {
+			return fmt.Errorf("param: field %s: %w", sf.Name, err)
+		}
+		if _, dup := s.byName[name]; dup {
+			return fmt.Errorf("param: duplicate parameter %q", name)
+		}
+		s.params = append(s.params, p)
+		s.byName[name] = p
+	}
+	return nil
+}
+
+// parse sets the kind, range and default of p from its field and tag
+// options.
+func (p *Param) parse(opts string) error {
+	switch p.field.Kind() {
+	case reflect.Bool:
+		p.Kind, p.Max = Bool, 1
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		p.Kind = Int
+	case reflect.Float32, reflect.Float64:
+		p.Kind = Float
+	default:
+		return fmt.Errorf("unsupported type %s", p.field.Type())
+	}
+	p.Default = p.get()
+	var hasMin, hasMax bool
+	for opt := range strings.SplitSeq(opts, ",") {
+		if opt == "" {
+			continue
+		}
+		key, val, _ := strings.Cut(opt, "=")
+		f, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return fmt.Errorf("option %q: %w", opt, err)
+		}
+		switch key {
+		case "min":
+			p.Min, hasMin = f, true
+		case "max":
+			p.Max, hasMax = f, true
+		case "default":
+			p.Default = f
+		default:
+			return fmt.Errorf("unknown option %q", key)
+		}
+	}
+	if p.Kind != Bool && (!hasMin || !hasMax) {
+		return errors.New("min and max are required")
+	}
+	if p.Min > p.Max {
+		return fmt.Errorf("min %v is above max %v", p.Min, p.Max)
+	}
+	p.Default = min(max(p.Default, p.Min), p.Max)
+	return nil
+}
+
+// Params returns the parameters in field order.
+func (s *Set) Params() []*Param {
+	return s.params
+}
+
+// Lookup returns the named parameter, or nil.
+func (s *Set) Lookup(name string) *Param {
+	return s.byName[name]
+}
+
+// Reset sets every parameter to its default.
+func (s *Set) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range s.params {
+		p.set(p.Default)
+	}
+}
+
+// Values returns the current value of every parameter by name.
+func (s *Set) Values() map[string]float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	values := make(map[string]float64, len(s.params))
+	for _, p := range s.params {
+		values[p.Name] = p.get()
+	}
+	return values
+}
+
+// SetValues sets the named parameters at once, so a reader holding RLock
+// never sees half of them changed. Unknown names and NaN are ignored.
+func (s *Set) SetValues(values map[string]float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for name, v := range values {
+		if p := s.byName[name]; p != nil && !math.IsNaN(v) {
+			p.set(v)
+		}
+	}
+}
+
+// RLock locks the parameters for reading.
+func (s *Set) RLock() {
+	s.mu.RLock()
+}
+
+// RUnlock undoes a call to RLock.
+func (s *Set) RUnlock() {
+	s.mu.RUnlock()
+}
@@ -0,0 +1,123 @@
+package param
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type wave struct {
+	Amplitude float64 `param:"amplitude,min=0,max=2,default=1"`
+	Cycles    int     `param:"cycles,min=1,max=9"`
+}
+
+type spiral struct {
+	Turns  float64 `param:"turns,min=1,max=20,default=5"`
+	Arms   uint8   `param:"arms,min=1,max=8"`
+	Mirror bool    `param:"mirror"`
+	Wave   wave    `param:"wave"`
+	Label  string
+}
+
+func TestOf(t *testing.T) {
+	g := &spiral{Arms: 3, Wave: wave{Cycles: 4}}
+	s, err := Of(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, p := range s.Params() {
+		names = append(names, p.Name)
+	}
+	if got := strings.Join(names, " "); got != "turns arms mirror wave/amplitude wave/cycles" {
+		t.Fatalf("params = %s", got)
+	}
+	if p := s.Lookup("arms"); p.Kind != Int || p.Default != 3 || p.Min != 1 || p.Max != 8 {
+		t.Fatalf("arms = %+v", p)
+	}
+
+	s.Lookup("arms").Set(5.6)
+	s.Lookup("turns").Set(100)
+	s.Lookup("mirror").SetNormalized(1)
+	s.Lookup("wave/amplitude").SetNormalized(0.25)
+	if g.Arms != 6 || g.Turns != 20 || !g.Mirror || g.Wave.Amplitude != 0.5 {
+		t.Fatalf("after Set: %+v", g)
+	}
+	if n := s.Lookup("turns").Normalized(); n != 1 {
+		t.Fatalf("Normalized = %v", n)
+	}
+
+	s.Reset()
+	if *g != (spiral{Turns: 5, Arms: 3, Wave: wave{Amplitude: 1, Cycles: 4}}) {
+		t.Fatalf("after Reset: %+v", g)
+	}
+
+	s.SetValues(map[string]float64{"wave/cycles": 7, "unknown": 1})
+	if v := s.Values(); v["wave/cycles"] != 7 || len(v) != 5 {
+		t.Fatalf("Values = %v", v)
+	}
+}
+
+func TestOfErrors(t *testing.T) {
+	for _, v := range []any{
+		spiral{},
+		&struct {
+			X float64 `param:"x"`
+		}{},
+		&struct {
+			X float64 `param:"x,min=2,max=1"`
+		}{},
+		&struct {
+			X string `param:"x"`
+		}{},
+		&struct {
+			X float64 `param:"x,min=0,max=1,step=2"`
+		}{},
+		&struct {
+			A bool `param:"a"`
+			B bool `param:"a"`
+		}{},
+	} {
+		if _, err := Of(v); err == nil {
+			t.Errorf("Of(%T) succeeded", v)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	g := &spiral{}
+	s, err := Of(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := s.Handler()
+	do := func(method, target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+		return rec
+	}
+
+	if rec := do("PUT", "/params/wave/amplitude?value=1.5"); rec.Code != http.StatusNoContent || g.Wave.Amplitude != 1.5 {
+		t.Fatalf("PUT value: %d, amplitude %v", rec.Code, g.Wave.Amplitude)
+	}
+	if rec := do("PUT", "/params/turns?normalized=0.5"); rec.Code != http.StatusNoContent || g.Turns != 10.5 {
+		t.Fatalf("PUT normalized: %d, turns %v", rec.Code, g.Turns)
+	}
+	if rec := do("PUT", "/params/turns?value=x"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("PUT invalid value: %d", rec.Code)
+	}
+	if rec := do("PUT", "/params/nope?value=1"); rec.Code != http.StatusNotFound {
+		t.Fatalf("PUT unknown: %d", rec.Code)
+	}
+
+	var states []struct {
+		Name  string
+		Value float64
+	}
+	rec := do("GET", "/params")
+	if err := json.Unmarshal(rec.Body.Bytes(), &states); err != nil || len(states) != 5 || states[0].Value != 10.5 {
+		t.Fatalf("GET: %v %s", err, rec.Body)
+	}
+}
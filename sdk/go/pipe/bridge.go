@@ -0,0 +1,271 @@
+package pipe
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+// Sink takes the frames of a Bridge. *helios.DeviceManager implements it,
+// pacing frames to each device as it becomes ready.
+type Sink interface {
+	SubmitFrame(deviceIndex int, pps int, points []helios.Point) error
+}
+
+var _ Sink = (*helios.DeviceManager)(nil)
+
+// DefaultTimeout is the Timeout of a Bridge whose Timeout is zero.
+const DefaultTimeout = time.Second
+
+// blankPPS is the rate blank frames are written at to devices that have
+// not been sent a frame.
+const blankPPS = 1000
+
+// Bridge passes the messages of external programs to a Sink. Frames are
+// submitted as they are; a blank message, the end of the stream that
+// wrote to a device, or Timeout without a frame for it submits a blanked
+// frame at the center, so a program that exits or hangs does not leave the
+// beam on. Its methods are safe for concurrent use, so one Bridge can
+// serve several streams.
+type Bridge struct {
+	// Timeout is how long a device keeps its last frame when no new one
+	// arrives before it is blanked. Zero means DefaultTimeout; negative
+	// never blanks for lack of frames.
+	Timeout time.Duration
+
+	// ErrorLog receives malformed-message, connection and sink errors.
+	// Nil uses the standard logger.
+	ErrorLog *log.Logger
+
+	sink Sink
+
+	mu      sync.Mutex
+	devices map[int]*device
+	closers []io.Closer
+	closed  bool
+}
+
+// device is the state of a device a bridge has written to.
+type device struct {
+	pps   int
+	timer *time.Timer // blanks the device after Timeout
+}
+
+// NewBridge creates a bridge submitting frames to sink.
+func NewBridge(sink Sink) *Bridge {
+	return &Bridge{sink: sink, devices: make(map[int]*device)}
+}
+
+func (b *Bridge) timeout() time.Duration {
+	if b.Timeout != 0 {
+		return b.Timeout
+	}
+	return DefaultTimeout
+}
+
+// Handle submits the frame of a frame message, or a blanked frame for a
+// blank message.
+func (b *Bridge) Handle(m Message) error {
+	switch m.Kind {
+	case KindFrame:
+		b.mu.Lock()
+		d := b.devices[m.Device]
+		if d == nil {
+			d = &device{}
+			b.devices[m.Device] = d
+		}
+		d.pps = m.PPS
+		if t := b.timeout(); t > 0 && !b.closed {
+			if d.timer == nil {
+				i := m.Device
+				d.timer = time.AfterFunc(t, func() { b.blank(i) })
+			} else {
+				d.timer.Reset(t)
+			}
+		}
+		b.mu.Unlock()
+		return b.sink.SubmitFrame(m.Device, m.PPS, m.Points)
+	case KindBlank:
+		return b.blank(m.Device)
+	}
+	return errors.New("pipe: unknown message kind")
+}
+
+// blank submits a blanked frame to device i at the rate of its last frame.
+func (b *Bridge) blank(i int) error {
+	b.mu.Lock()
+	pps := blankPPS
+	if d := b.devices[i]; d != nil {
+		if d.timer != nil {
+			d.timer.Stop()
+		}
+		pps = d.pps
+	}
+	b.mu.Unlock()
+	err := b.sink.SubmitFrame(i, pps, []helios.Point{{X: 0x800, Y: 0x800}})
+	if err != nil {
+		b.logf("pipe: blanking device %d: %v", i, err)
+	}
+	return err
+}
+
+// Serve handles the messages of a stream, such as os.Stdin or a pipe, until
+// it ends, then blanks the devices it wrote to. It returns nil at the end
+// of the stream, or the error that ended it.
+func (b *Bridge) Serve(r io.Reader) error {
+	written := make(map[int]bool)
+	defer func() {
+		for i := range written {
+			b.blank(i)
+		}
+	}()
+	mr := NewReader(r)
+	for {
+		m, err := mr.Next()
+		if err != nil {
+			if err == io.EOF || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		if m.Kind == KindFrame {
+			written[m.Device] = true
+		}
+		if err := b.Handle(m); err != nil {
+			b.logf("pipe: device %d: %v", m.Device, err)
+		}
+	}
+}
+
+// ListenAndServe listens on a stream network and address, such as "unix"
+// and "/run/helios.sock" or "tcp" and ":7301", and serves each connection
+// until Close is called.
+func (b *Bridge) ListenAndServe(network, addr string) error {
+	ln, err := net.Listen(network, addr)
+	if err != nil {
+		return err
+	}
+	return b.ServeListener(ln)
+}
+
+// ServeListener serves each connection accepted from ln with Serve until
+// Close is called. It returns nil after Close.
+func (b *Bridge) ServeListener(ln net.Listener) error {
+	if !b.track(ln) {
+		return nil
+	}
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		if !b.track(conn) {
+			return nil
+		}
+		go func() {
+			defer b.untrack(conn)
+			if err := b.Serve(conn); err != nil {
+				b.logf("pipe: %v: %v", conn.RemoteAddr(), err)
+			}
+		}()
+	}
+}
+
+// ListenAndServePacket listens on the UDP address addr and serves it with
+// ServePacket.
+func (b *Bridge) ListenAndServePacket(addr string) error {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return err
+	}
+	return b.ServePacket(conn)
+}
+
+// ServePacket handles one message per datagram read from conn until Close
+// is called. It returns nil after Close. Datagrams have no end, so only
+// Timeout blanks devices whose sender stopped.
+func (b *Bridge) ServePacket(conn net.PacketConn) error {
+	if !b.track(conn) {
+		return nil
+	}
+	buf := make([]byte, 65536)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		m, err := ParseMessage(buf[:n])
+		if err != nil {
+			b.logf("pipe: dropping datagram: %v", err)
+			continue
+		}
+		if err := b.Handle(m); err != nil {
+			b.logf("pipe: device %d: %v", m.Device, err)
+		}
+	}
+}
+
+// track records c to be closed by Close. It closes c and returns false if
+// the bridge is already closed.
+func (b *Bridge) track(c io.Closer) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		c.Close()
+		return false
+	}
+	b.closers = append(b.closers, c)
+	return true
+}
+
+func (b *Bridge) untrack(c io.Closer) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i, x := range b.closers {
+		if x == c {
+			b.closers = append(b.closers[:i], b.closers[i+1:]...)
+			break
+		}
+	}
+}
+
+// Close stops the listeners and connections being served, which blank
+// the devices they wrote to, and stops the timeouts.
+func (b *Bridge) Close() error {
+	b.mu.Lock()
+	b.closed = true
+	closers := b.closers
+	b.closers = nil
+	for _, d := range b.devices {
+		if d.timer != nil {
+			d.timer.Stop()
+		}
+	}
+	b.mu.Unlock()
+	var errs []error
+	for _, c := range closers {
+		if err := c.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (b *Bridge) logf(format string, args ...any) {
+	if b.ErrorLog != nil {
+		b.ErrorLog.Printf(format, args...)
+	} else {
+		log.Printf(format, args...)
+	}
+}
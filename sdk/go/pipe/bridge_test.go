@@ -0,0 +1,185 @@
+package pipe
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+// submission is a frame submitted to a fakeSink.
+type submission struct {
+	device, pps int
+	points      []helios.Point
+}
+
+func (s submission) blank() bool {
+	return len(s.points) == 1 && s.points[0] == helios.Point{X: 0x800, Y: 0x800}
+}
+
+type fakeSink struct {
+	mu     sync.Mutex
+	frames []submission
+}
+
+func (s *fakeSink) SubmitFrame(i, pps int, points []helios.Point) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.frames = append(s.frames, submission{i, pps, points})
+	return nil
+}
+
+func (s *fakeSink) submitted() []submission {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]submission(nil), s.frames...)
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(2 * time.Second); !cond(); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out")
+		}
+	}
+}
+
+func TestServeBlanksAtEnd(t *testing.T) {
+	sink := &fakeSink{}
+	b := NewBridge(sink)
+	var in []byte
+	in = AppendFrame(in, 0, 30000, frame)
+	in = AppendFrame(in, 1, 20000, frame)
+	in = AppendBlank(in, 1)
+	if err := b.Serve(bytes.NewReader(in)); err != nil {
+		t.Fatal(err)
+	}
+	got := sink.submitted()
+	if len(got) != 5 {
+		t.Fatalf("submitted %+v", got)
+	}
+	if got[0].device != 0 || got[0].pps != 30000 || len(got[0].points) != 2 || got[1].device != 1 {
+		t.Errorf("frames = %+v", got[:2])
+	}
+	if !got[2].blank() || got[2].device != 1 {
+		t.Errorf("blank message submitted %+v", got[2])
+	}
+	// The end of the stream blanks both devices, at their rates.
+	ends := map[int]int{}
+	for _, s := range got[3:] {
+		if !s.blank() {
+			t.Errorf("submitted %+v at the end", s)
+		}
+		ends[s.device] = s.pps
+	}
+	if ends[0] != 30000 || ends[1] != 20000 {
+		t.Errorf("blanked at the end: %v", ends)
+	}
+
+	// A broken stream blanks too, and reports the error.
+	sink.frames = nil
+	in = AppendFrame(nil, 2, 30000, frame)
+	in = AppendFrame(in, 2, 30000, frame)
+	if err := b.Serve(bytes.NewReader(in[:len(in)-3])); err != io.ErrUnexpectedEOF {
+		t.Fatalf("Serve(truncated) = %v", err)
+	}
+	if got := sink.submitted(); len(got) != 2 || got[0].blank() || !got[1].blank() {
+		t.Errorf("truncated stream submitted %+v", got)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	sink := &fakeSink{}
+	b := NewBridge(sink)
+	b.Timeout = 20 * time.Millisecond
+	b.Handle(Message{Kind: KindFrame, Device: 0, PPS: 30000, Points: frame})
+	waitFor(t, func() bool { return len(sink.submitted()) == 2 })
+	if got := sink.submitted(); !got[1].blank() || got[1].pps != 30000 {
+		t.Errorf("timed out with %+v", got[1])
+	}
+
+	// Frames keep resetting the timeout.
+	b.Timeout = 50 * time.Millisecond
+	for range 5 {
+		b.Handle(Message{Kind: KindFrame, Device: 0, PPS: 30000, Points: frame})
+		time.Sleep(10 * time.Millisecond)
+	}
+	for _, s := range sink.submitted()[2:] {
+		if s.blank() {
+			t.Fatal("blanked while frames arrived")
+		}
+	}
+
+	// Closing the bridge stops the timeouts.
+	b.Close()
+	n := len(sink.submitted())
+	time.Sleep(80 * time.Millisecond)
+	if len(sink.submitted()) != n {
+		t.Error("blanked after Close")
+	}
+}
+
+func TestServePacket(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	sink := &fakeSink{}
+	b := NewBridge(sink)
+	b.ErrorLog = log.New(io.Discard, "", 0)
+	done := make(chan error)
+	go func() { done <- b.ServePacket(conn) }()
+
+	c, err := net.Dial("udp", conn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	c.Write([]byte{'?', 0})
+	c.Write(AppendFrame(nil, 1, 30000, frame))
+	waitFor(t, func() bool { return len(sink.submitted()) == 1 })
+	if s := sink.submitted()[0]; s.device != 1 || len(s.points) != 2 {
+		t.Errorf("submitted %+v", s)
+	}
+
+	b.Close()
+	if err := <-done; err != nil {
+		t.Errorf("ServePacket after Close = %v", err)
+	}
+}
+
+func TestServeListener(t *testing.T) {
+	ln, err := net.Listen("unix", filepath.Join(t.TempDir(), "helios.sock"))
+	if err != nil {
+		t.Skip(err)
+	}
+	sink := &fakeSink{}
+	b := NewBridge(sink)
+	done := make(chan error)
+	go func() { done <- b.ServeListener(ln) }()
+
+	c, err := net.Dial("unix", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Write(AppendFrame(nil, 0, 30000, frame))
+	waitFor(t, func() bool { return len(sink.submitted()) == 1 })
+
+	// Disconnecting blanks the device.
+	c.Close()
+	waitFor(t, func() bool { return len(sink.submitted()) == 2 })
+	if s := sink.submitted()[1]; !s.blank() {
+		t.Errorf("disconnect submitted %+v", s)
+	}
+
+	b.Close()
+	if err := <-done; err != nil {
+		t.Errorf("ServeListener after Close = %v", err)
+	}
+}
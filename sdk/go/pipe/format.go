@@ -0,0 +1,173 @@
+// Package pipe reads point streams written by external programs, such as a
+// Python script or a Processing sketch, over stdin, a UNIX socket or UDP,
+// so a Go process can act as the output daemon: the program renders frames
+// and the Bridge paces them to the DACs through a helios.DeviceManager,
+// with its blanking and scan-fail protection, and blanks a device whose
+// stream ends or stalls.
+//
+// The wire format is a sequence of messages, little-endian, one per
+// datagram over UDP and back to back over streams:
+//
+//	frame: 'F' device:uint8 pps:uint32 count:uint32 points
+//	blank: 'B' device:uint8
+//
+// Points are 8 bytes each, as in package remote: X and Y as uint16 (0 -
+// 4095), then R, G, B and I. A Python program can write a frame with
+//
+//	import struct, sys
+//	msg = struct.pack("<cBII", b"F", 0, 30000, len(points))
+//	msg += b"".join(struct.pack("<HHBBBB", *p) for p in points)
+//	sys.stdout.buffer.write(msg); sys.stdout.buffer.flush()
+//
+// and be piped into a bridge reading stdin.
+package pipe
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/remote"
+)
+
+// Message kinds.
+const (
+	KindFrame = 'F'
+	KindBlank = 'B'
+)
+
+// MaxPoints is the largest frame a message may hold, well above any device
+// limit so oversized frames fail at the device as they would locally.
+const MaxPoints = 1 << 20
+
+// pointLen is the encoded size of a point.
+const pointLen = 8
+
+// frameHeaderLen is the size of a frame message before its points.
+const frameHeaderLen = 1 + 1 + 4 + 4
+
+// Message is a decoded message.
+type Message struct {
+	Kind   byte
+	Device int
+
+	// PPS and Points are the frame of a KindFrame message.
+	PPS    int
+	Points []helios.Point
+}
+
+// AppendFrame appends a frame message to b.
+func AppendFrame(b []byte, device, pps int, points []helios.Point) []byte {
+	b = append(b, KindFrame, byte(device))
+	b = binary.LittleEndian.AppendUint32(b, uint32(pps))
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(points)))
+	return remote.AppendPoints(b, points)
+}
+
+// AppendBlank appends a blank message to b.
+func AppendBlank(b []byte, device int) []byte {
+	return append(b, KindBlank, byte(device))
+}
+
+// ParseMessage decodes a datagram holding one message.
+func ParseMessage(b []byte) (Message, error) {
+	if len(b) < 2 {
+		return Message{}, errors.New("pipe: truncated message")
+	}
+	m := Message{Kind: b[0], Device: int(b[1])}
+	switch m.Kind {
+	case KindBlank:
+		if len(b) != 2 {
+			return Message{}, errors.New("pipe: blank message has trailing bytes")
+		}
+		return m, nil
+	case KindFrame:
+		if len(b) < frameHeaderLen {
+			return Message{}, errors.New("pipe: truncated frame header")
+		}
+		count, err := parseFrameHeader(b[2:], &m)
+		if err != nil {
+			return Message{}, err
+		}
+		if len(b)-frameHeaderLen != count*pointLen {
+			return Message{}, fmt.Errorf("pipe: frame of %d points has %d bytes of points", count, len(b)-frameHeaderLen)
+		}
+		m.Points, err = remote.ParsePoints(b[frameHeaderLen:])
+		return m, err
+	}
+	return Message{}, fmt.Errorf("pipe: unknown message kind %q", m.Kind)
+}
+
+// parseFrameHeader sets the PPS of m from the fields of a frame header
+// after the device and returns the number of points that follow.
+func parseFrameHeader(b []byte, m *Message) (int, error) {
+	m.PPS = int(binary.LittleEndian.Uint32(b))
+	count := binary.LittleEndian.Uint32(b[4:])
+	if count > MaxPoints {
+		return 0, fmt.Errorf("pipe: frame of %d points exceeds %d", count, MaxPoints)
+	}
+	return int(count), nil
+}
+
+// Reader decodes messages written back to back to a stream.
+type Reader struct {
+	r   *bufio.Reader
+	buf []byte
+}
+
+// NewReader returns a Reader decoding r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// Next returns the next message. It returns io.EOF at the end of the
+// stream between messages, and io.ErrUnexpectedEOF within one. After an
+// error other than io.EOF the stream cannot be resynchronized.
+func (r *Reader) Next() (Message, error) {
+	head, err := r.read(2)
+	if err != nil {
+		return Message{}, err
+	}
+	m := Message{Kind: head[0], Device: int(head[1])}
+	switch m.Kind {
+	case KindBlank:
+		return m, nil
+	case KindFrame:
+		head, err := r.read(frameHeaderLen - 2)
+		if err != nil {
+			return Message{}, noEOF(err)
+		}
+		count, err := parseFrameHeader(head, &m)
+		if err != nil {
+			return Message{}, err
+		}
+		body, err := r.read(count * pointLen)
+		if err != nil {
+			return Message{}, noEOF(err)
+		}
+		m.Points, err = remote.ParsePoints(body)
+		return m, err
+	}
+	return Message{}, fmt.Errorf("pipe: unknown message kind %q", m.Kind)
+}
+
+// read reads n bytes into the reader's buffer, valid until the next call.
+func (r *Reader) read(n int) ([]byte, error) {
+	if cap(r.buf) < n {
+		r.buf = make([]byte, n)
+	}
+	b := r.buf[:n]
+	_, err := io.ReadFull(r.r, b)
+	return b, err
+}
+
+// noEOF reports the end of the stream within a message as unexpected.
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
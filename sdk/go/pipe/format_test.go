@@ -0,0 +1,90 @@
+package pipe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+var frame = []helios.Point{{X: 1, Y: 2, R: 3, G: 4, B: 5, I: 6}, {X: 4095, Y: 4095}}
+
+func TestParseMessage(t *testing.T) {
+	b := AppendFrame(nil, 2, 30000, frame)
+	if len(b) != frameHeaderLen+len(frame)*pointLen {
+		t.Fatalf("frame message is %d bytes", len(b))
+	}
+	m, err := ParseMessage(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Message{Kind: KindFrame, Device: 2, PPS: 30000, Points: frame}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("ParseMessage = %+v, want %+v", m, want)
+	}
+
+	m, err = ParseMessage(AppendBlank(nil, 1))
+	if err != nil || m.Kind != KindBlank || m.Device != 1 {
+		t.Errorf("ParseMessage(blank) = %+v, %v", m, err)
+	}
+
+	huge := AppendFrame(nil, 0, 30000, nil)
+	binary.LittleEndian.PutUint32(huge[6:], MaxPoints+1)
+	bad := map[string][]byte{
+		"empty":          nil,
+		"unknown kind":   {'X', 0},
+		"short header":   b[:5],
+		"missing points": b[:len(b)-1],
+		"extra points":   append(AppendFrame(nil, 0, 1, frame), 0),
+		"long blank":     {KindBlank, 0, 0},
+		"too many":       huge,
+	}
+	for name, b := range bad {
+		if _, err := ParseMessage(b); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
+
+func TestReader(t *testing.T) {
+	var b []byte
+	b = AppendFrame(b, 0, 20000, frame)
+	b = AppendBlank(b, 1)
+	b = AppendFrame(b, 1, 40000, frame[:1])
+	b = AppendFrame(b, 3, 40000, nil)
+
+	r := NewReader(bytes.NewReader(b))
+	want := []Message{
+		{Kind: KindFrame, Device: 0, PPS: 20000, Points: frame},
+		{Kind: KindBlank, Device: 1},
+		{Kind: KindFrame, Device: 1, PPS: 40000, Points: frame[:1]},
+		{Kind: KindFrame, Device: 3, PPS: 40000, Points: []helios.Point{}},
+	}
+	for i, w := range want {
+		m, err := r.Next()
+		if err != nil {
+			t.Fatalf("message %d: %v", i, err)
+		}
+		if !reflect.DeepEqual(m, w) {
+			t.Errorf("message %d = %+v, want %+v", i, m, w)
+		}
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Errorf("Next at end = %v, want io.EOF", err)
+	}
+
+	// Cut anywhere within a message, the stream ends unexpectedly.
+	full := AppendFrame(nil, 0, 20000, frame)
+	for n := 1; n < len(full); n++ {
+		if _, err := NewReader(bytes.NewReader(full[:n])).Next(); !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Fatalf("cut at %d: %v", n, err)
+		}
+	}
+	if _, err := NewReader(bytes.NewReader([]byte{'?', 0})).Next(); err == nil {
+		t.Error("no error for an unknown kind")
+	}
+}
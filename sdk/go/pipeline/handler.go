@@ -0,0 +1,143 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+)
+
+// maxConfig limits the size of configurations sent over HTTP.
+const maxConfig = 1 << 20
+
+// errNoNode is returned by edits naming a node that does not exist.
+var errNoNode = errors.New("pipeline: no such node")
+
+// Handler returns an HTTP API for inspecting and editing the running
+// pipeline. Edits take effect at the next frame; edits that leave the graph
+// invalid are rejected with 400 and change nothing.
+//
+//	GET    /pipeline                      the graph with parameter values, as a JSON Config
+//	PUT    /pipeline                      replace the graph with a JSON Config
+//	POST   /pipeline/nodes                add a node from a JSON NodeConfig
+//	DELETE /pipeline/nodes/{name}         remove a node and its uses as an input
+//	PUT    /pipeline/nodes/{name}/inputs  set the inputs from a JSON array of names
+//	PATCH  /pipeline/nodes/{name}/params  set parameters from a JSON object
+//	PUT    /pipeline/output?node=name     select the output node
+func (p *Pipeline) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /pipeline", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, p.Config())
+	})
+	mux.HandleFunc("PUT /pipeline", func(w http.ResponseWriter, r *http.Request) {
+		var next Config
+		if !readJSON(w, r, &next) {
+			return
+		}
+		p.edit(w, func(cfg *Config) error {
+			*cfg = next
+			return nil
+		})
+	})
+	mux.HandleFunc("POST /pipeline/nodes", func(w http.ResponseWriter, r *http.Request) {
+		var nc NodeConfig
+		if !readJSON(w, r, &nc) {
+			return
+		}
+		p.edit(w, func(cfg *Config) error {
+			cfg.Nodes = append(cfg.Nodes, nc)
+			return nil
+		})
+	})
+	mux.HandleFunc("DELETE /pipeline/nodes/{name}", func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		p.edit(w, func(cfg *Config) error {
+			i := nodeIndex(cfg, name)
+			if i < 0 {
+				return errNoNode
+			}
+			cfg.Nodes = slices.Delete(cfg.Nodes, i, i+1)
+			for j := range cfg.Nodes {
+				cfg.Nodes[j].Inputs = slices.DeleteFunc(cfg.Nodes[j].Inputs, func(in string) bool { return in == name })
+			}
+			if cfg.Output == name {
+				cfg.Output = ""
+			}
+			return nil
+		})
+	})
+	mux.HandleFunc("PUT /pipeline/nodes/{name}/inputs", func(w http.ResponseWriter, r *http.Request) {
+		var inputs []string
+		if !readJSON(w, r, &inputs) {
+			return
+		}
+		p.edit(w, func(cfg *Config) error {
+			i := nodeIndex(cfg, r.PathValue("name"))
+			if i < 0 {
+				return errNoNode
+			}
+			cfg.Nodes[i].Inputs = inputs
+			return nil
+		})
+	})
+	mux.HandleFunc("PATCH /pipeline/nodes/{name}/params", func(w http.ResponseWriter, r *http.Request) {
+		var values map[string]float64
+		if !readJSON(w, r, &values) {
+			return
+		}
+		p.edit(w, func(cfg *Config) error {
+			i := nodeIndex(cfg, r.PathValue("name"))
+			if i < 0 {
+				return errNoNode
+			}
+			if cfg.Nodes[i].Params == nil {
+				cfg.Nodes[i].Params = make(map[string]float64)
+			}
+			for name, v := range values {
+				cfg.Nodes[i].Params[name] = v
+			}
+			return nil
+		})
+	})
+	mux.HandleFunc("PUT /pipeline/output", func(w http.ResponseWriter, r *http.Request) {
+		p.edit(w, func(cfg *Config) error {
+			cfg.Output = r.URL.Query().Get("node")
+			return nil
+		})
+	})
+	return mux
+}
+
+// edit applies f and writes the resulting graph.
+func (p *Pipeline) edit(w http.ResponseWriter, f func(cfg *Config) error) {
+	if err := p.Edit(f); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, errNoNode) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	writeJSON(w, http.StatusOK, p.Config())
+}
+
+func nodeIndex(cfg *Config, name string) int {
+	return slices.IndexFunc(cfg.Nodes, func(nc NodeConfig) bool { return nc.Name == name })
+}
+
+func readJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxConfig))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		http.Error(w, fmt.Sprintf("pipeline: %v", err), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,393 @@
+// Package pipeline builds frame processing graphs from configuration.
+//
+// A pipeline is a set of named nodes, each created by type from a Registry
+// and tuned through its param tags, with the outputs of some nodes feeding
+// the inputs of others: generators produce points, effects, optimizers and
+// safety stages transform them, and merge nodes combine several branches.
+// Installations declare the graph in a config file and restructure
+// processing without code changes:
+//
+//	{
+//	  "nodes": [
+//	    {"name": "figure", "type": "lissajous", "params": {"a": 5, "b": 4}},
+//	    {"name": "colors", "type": "hue_cycle", "inputs": ["figure"]},
+//	    {"name": "even", "type": "compensate", "inputs": ["colors"]},
+//	    {"name": "limit", "type": "attenuate", "inputs": ["even"], "params": {"level": 0.6}}
+//	  ],
+//	  "output": "limit"
+//	}
+//
+// Frame evaluates the graph from the output node back, once per node per
+// frame, and returns the points to write to the DAC. A running pipeline is
+// restructured with Edit, or remotely through Handler; changes apply from
+// the next frame, so a frame is never rendered by a half-edited graph.
+package pipeline
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/param"
+	"github.com/Grix/helios_dac/sdk/go/store"
+)
+
+// Config declares a pipeline.
+type Config struct {
+	Nodes []NodeConfig `json:"nodes"`
+
+	// Output names the node whose points make the frame. Empty means the
+	// last node.
+	Output string `json:"output,omitempty"`
+}
+
+// NodeConfig declares one node.
+type NodeConfig struct {
+	// Name identifies the node within the pipeline.
+	Name string `json:"name"`
+
+	// Type selects the Registry entry the node is created from.
+	Type string `json:"type"`
+
+	// Inputs name the nodes feeding this one, in order.
+	Inputs []string `json:"inputs,omitempty"`
+
+	// Params set parameters of the node by their param name. Parameters
+	// not listed keep their defaults.
+	Params map[string]float64 `json:"params,omitempty"`
+}
+
+// Pipeline is a built processing graph. Its parameters may be changed from
+// other goroutines while frames are rendered; nodes hold their parameter
+// set's read lock while they process. The graph itself is changed with
+// Edit, which takes effect at the start of the next frame.
+type Pipeline struct {
+	reg Registry
+
+	mu      sync.Mutex
+	current *graph
+	pending *graph
+}
+
+type graph struct {
+	nodes  []*node
+	byName map[string]*node
+	output *node
+
+	// outputName is Config.Output, which may be empty.
+	outputName string
+}
+
+type node struct {
+	name, typ string
+	inputs    []*node
+	stage     Stage
+	params    *param.Set
+
+	// values are parameters to set when the graph takes effect.
+	values map[string]float64
+}
+
+// configSchema versions pipeline configs. Add a migration and bump the
+// version when a change to Config would misread older files.
+var configSchema = store.Schema{Name: "pipeline config", Version: 1}
+
+// Load decodes a JSON config from r, written for this or an earlier
+// version, and builds it with reg. Configs without a "version" member are
+// version 1.
+func Load(r io.Reader, reg Registry) (*Pipeline, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("pipeline: %w", err)
+	}
+	var cfg Config
+	if err := configSchema.Decode(data, &cfg); err != nil {
+		return nil, fmt.Errorf("pipeline: %w", err)
+	}
+	return Build(cfg, reg)
+}
+
+// Marshal encodes the pipeline's Config as versioned JSON for Load.
+func (p *Pipeline) Marshal() ([]byte, error) {
+	return configSchema.Encode(p.Config())
+}
+
+// Build creates the nodes of cfg from reg and connects them. It fails on
+// unknown types, inputs or parameters, inputs the node type does not
+// accept, and cycles.
+func Build(cfg Config, reg Registry) (*Pipeline, error) {
+	g, err := build(cfg, reg, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Pipeline{reg: reg, current: g}, nil
+}
+
+// build creates the graph of cfg. Nodes of old with the same name and type
+// are reused, keeping their state, with the parameters that differ from
+// their current values set when the graph takes effect.
+func build(cfg Config, reg Registry, old *graph) (*graph, error) {
+	if len(cfg.Nodes) == 0 {
+		return nil, fmt.Errorf("pipeline: no nodes")
+	}
+	g := &graph{byName: make(map[string]*node, len(cfg.Nodes))}
+	for _, nc := range cfg.Nodes {
+		if nc.Name == "" {
+			return nil, fmt.Errorf("pipeline: node of type %q has no name", nc.Type)
+		}
+		if _, dup := g.byName[nc.Name]; dup {
+			return nil, fmt.Errorf("pipeline: duplicate node %q", nc.Name)
+		}
+		var n *node
+		var err error
+		if o := old.lookup(nc.Name); o != nil && o.typ == nc.Type {
+			n, err = reuseNode(o, nc)
+		} else {
+			n, err = newNode(nc, reg)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("pipeline: node %q: %w", nc.Name, err)
+		}
+		g.nodes = append(g.nodes, n)
+		g.byName[nc.Name] = n
+	}
+	for i, nc := range cfg.Nodes {
+		n := g.nodes[i]
+		if err := checkInputs(n.stage, len(nc.Inputs)); err != nil {
+			return nil, fmt.Errorf("pipeline: node %q: %w", nc.Name, err)
+		}
+		for _, in := range nc.Inputs {
+			m := g.byName[in]
+			if m == nil {
+				return nil, fmt.Errorf("pipeline: node %q: unknown input %q", nc.Name, in)
+			}
+			n.inputs = append(n.inputs, m)
+		}
+	}
+	if err := g.checkCycles(); err != nil {
+		return nil, err
+	}
+	g.output, g.outputName = g.nodes[len(g.nodes)-1], cfg.Output
+	if cfg.Output != "" {
+		if g.output = g.byName[cfg.Output]; g.output == nil {
+			return nil, fmt.Errorf("pipeline: unknown output %q", cfg.Output)
+		}
+	}
+	return g, nil
+}
+
+func (g *graph) lookup(name string) *node {
+	if g == nil {
+		return nil
+	}
+	return g.byName[name]
+}
+
+func newNode(nc NodeConfig, reg Registry) (*node, error) {
+	factory := reg[nc.Type]
+	if factory == nil {
+		return nil, fmt.Errorf("unknown type %q", nc.Type)
+	}
+	v := factory()
+	stage, err := stageOf(v)
+	if err != nil {
+		return nil, err
+	}
+	n := &node{name: nc.Name, typ: nc.Type, stage: stage}
+	if rv := reflect.ValueOf(v); rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return n, checkParams(nil, nc)
+	}
+	if n.params, err = param.Of(v); err != nil {
+		return nil, err
+	}
+	n.params.Reset()
+	if err := checkParams(n.params, nc); err != nil {
+		return nil, err
+	}
+	n.params.SetValues(nc.Params)
+	return n, nil
+}
+
+func reuseNode(old *node, nc NodeConfig) (*node, error) {
+	n := &node{name: nc.Name, typ: nc.Type, stage: old.stage, params: old.params}
+	if err := checkParams(n.params, nc); err != nil {
+		return nil, err
+	}
+	if len(nc.Params) == 0 {
+		return n, nil
+	}
+	current := n.params.Values()
+	for name, v := range nc.Params {
+		if v != current[name] {
+			if n.values == nil {
+				n.values = make(map[string]float64)
+			}
+			n.values[name] = v
+		}
+	}
+	return n, nil
+}
+
+func checkParams(set *param.Set, nc NodeConfig) error {
+	for name := range nc.Params {
+		if set == nil {
+			return fmt.Errorf("type %q has no parameters", nc.Type)
+		}
+		if set.Lookup(name) == nil {
+			return fmt.Errorf("unknown parameter %q", name)
+		}
+	}
+	return nil
+}
+
+// checkCycles fails if a node is its own input, directly or through others.
+func (g *graph) checkCycles() error {
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[*node]int, len(g.nodes))
+	var visit func(n *node) error
+	visit = func(n *node) error {
+		switch state[n] {
+		case visiting:
+			return fmt.Errorf("pipeline: cycle through node %q", n.name)
+		case done:
+			return nil
+		}
+		state[n] = visiting
+		for _, in := range n.inputs {
+			if err := visit(in); err != nil {
+				return err
+			}
+		}
+		state[n] = done
+		return nil
+	}
+	for _, n := range g.nodes {
+		if err := visit(n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Frame renders the pipeline's output at time t, with budget points for
+// the output node to fill. It first applies the latest Edit, if any.
+func (p *Pipeline) Frame(t time.Duration, budget int) []helios.Point {
+	p.mu.Lock()
+	if p.pending != nil {
+		for _, n := range p.pending.nodes {
+			if n.values != nil {
+				n.params.SetValues(n.values)
+				n.values = nil
+			}
+		}
+		p.current, p.pending = p.pending, nil
+	}
+	g := p.current
+	p.mu.Unlock()
+	return eval(g.output, t, budget, make(map[*node][]helios.Point, len(g.nodes)))
+}
+
+// eval renders n and its inputs, each at most once per frame; a node
+// feeding several others renders with the budget of the first to ask.
+func eval(n *node, t time.Duration, budget int, done map[*node][]helios.Point) []helios.Point {
+	if points, ok := done[n]; ok {
+		return points
+	}
+	budgets := inputBudgets(n.stage, budget, len(n.inputs))
+	inputs := make([][]helios.Point, len(n.inputs))
+	for i, in := range n.inputs {
+		inputs[i] = eval(in, t, budgets[i], done)
+	}
+	if n.params != nil {
+		n.params.RLock()
+	}
+	points := n.stage.Process(t, budget, inputs)
+	if n.params != nil {
+		n.params.RUnlock()
+	}
+	done[n] = points
+	return points
+}
+
+// latest returns the graph the next frame will use.
+func (p *Pipeline) latest() *graph {
+	if p.pending != nil {
+		return p.pending
+	}
+	return p.current
+}
+
+// Config returns the configuration of the graph the next frame will use,
+// with the current value of every parameter.
+func (p *Pipeline) Config() Config {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.config()
+}
+
+func (p *Pipeline) config() Config {
+	g := p.latest()
+	cfg := Config{Nodes: make([]NodeConfig, len(g.nodes)), Output: g.outputName}
+	for i, n := range g.nodes {
+		nc := NodeConfig{Name: n.name, Type: n.typ}
+		for _, in := range n.inputs {
+			nc.Inputs = append(nc.Inputs, in.name)
+		}
+		if n.params != nil {
+			nc.Params = n.params.Values()
+			maps.Copy(nc.Params, n.values)
+		}
+		cfg.Nodes[i] = nc
+	}
+	return cfg
+}
+
+// Edit changes the graph: f modifies a copy of Config, which is built with
+// the pipeline's registry and takes effect at the start of the next frame.
+// Nodes keeping their name and type keep their state. If the edited
+// configuration does not build, or f fails, the pipeline is left as it
+// was. Edits are serialized, so f must not call methods of p.
+func (p *Pipeline) Edit(f func(cfg *Config) error) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	cfg := p.config()
+	if err := f(&cfg); err != nil {
+		return err
+	}
+	g, err := build(cfg, p.reg, p.latest())
+	if err != nil {
+		return err
+	}
+	p.pending = g
+	return nil
+}
+
+// Nodes returns the names of the nodes in declaration order.
+func (p *Pipeline) Nodes() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	g := p.latest()
+	names := make([]string, len(g.nodes))
+	for i, n := range g.nodes {
+		names[i] = n.name
+	}
+	return names
+}
+
+// Params returns the parameters of the named node, or nil if it has none
+// or does not exist.
+func (p *Pipeline) Params(name string) *param.Set {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if n := p.latest().lookup(name); n != nil {
+		return n.params
+	}
+	return nil
+}
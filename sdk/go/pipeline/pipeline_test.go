@@ -0,0 +1,236 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/effects"
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/scene"
+)
+
+// testRegistry adds a source of budget points at a fixed position.
+func testRegistry() Registry {
+	reg := Builtin()
+	reg["dot"] = func() any {
+		return scene.LayerFunc(func(t time.Duration, budget int) []helios.Point {
+			points := make([]helios.Point, budget)
+			for i := range points {
+				points[i] = helios.Point{X: 100, Y: 200, R: 200, G: 200, B: 200, I: 255}
+			}
+			return points
+		})
+	}
+	return reg
+}
+
+func TestLoadAndFrame(t *testing.T) {
+	const config = `{
+		"nodes": [
+			{"name": "a", "type": "dot"},
+			{"name": "b", "type": "lissajous", "params": {"a": 5, "stroke/size": 0.5}},
+			{"name": "both", "type": "merge", "inputs": ["a", "b"], "params": {"blank": 2}},
+			{"name": "limit", "type": "attenuate", "inputs": ["both"], "params": {"level": 0.5}},
+			{"name": "unused", "type": "spin", "inputs": ["a"]}
+		],
+		"output": "limit"
+	}`
+	p, err := Load(strings.NewReader(config), testRegistry())
+	if err != nil {
+		t.Fatal(err)
+	}
+	frame := p.Frame(0, 108)
+	if len(frame) != 108 {
+		t.Fatalf("frame has %d points, want 108", len(frame))
+	}
+	if frame[0] != (helios.Point{X: 100, Y: 200}) || frame[2] != (helios.Point{X: 100, Y: 200, R: 100, G: 100, B: 100, I: 128}) {
+		t.Fatalf("frame starts %+v", frame[:3])
+	}
+	if got := p.Params("b").Lookup("a").Get(); got != 5 {
+		t.Fatalf("lissajous a = %v, want 5", got)
+	}
+	if got := p.Params("b").Lookup("b").Get(); got != 2 {
+		t.Fatalf("lissajous b = %v, want its default 2", got)
+	}
+	if p.Params("a") != nil {
+		t.Fatal("function source has parameters")
+	}
+
+	// Parameters changed on the running pipeline apply to the next frame.
+	p.Params("limit").Lookup("level").Set(0)
+	for _, pt := range p.Frame(0, 108) {
+		if pt.R != 0 || pt.I != 0 {
+			t.Fatalf("attenuated point %+v is lit", pt)
+		}
+	}
+}
+
+func TestModulation(t *testing.T) {
+	p, err := Build(Config{Nodes: []NodeConfig{
+		{Name: "src", Type: "dot"},
+		{Name: "both", Type: "merge", Inputs: []string{"src"}, Params: map[string]float64{"blank": 2}},
+		{Name: "mod", Type: "modulation", Inputs: []string{"both"}, Params: map[string]float64{"ramp_in": 2, "shift": 1}},
+	}}, testRegistry())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Two blanked points, six dots fading in over two, two blanked points,
+	// with the colors sent one point early.
+	want := []uint8{0, 85, 170, 255, 255, 255, 255, 0, 0, 0}
+	for i, pt := range p.Frame(0, 10) {
+		if pt.I != want[i] {
+			t.Errorf("point %d intensity %d, want %d", i, pt.I, want[i])
+		}
+	}
+}
+
+func TestEffectBudget(t *testing.T) {
+	p, err := Build(Config{Nodes: []NodeConfig{
+		{Name: "src", Type: "dot"},
+		{Name: "k", Type: "kaleidoscope", Inputs: []string{"src"}, Params: map[string]float64{"copies": 3}},
+	}}, testRegistry())
+	if err != nil {
+		t.Fatal(err)
+	}
+	lit := 0
+	for _, pt := range p.Frame(0, 1000) {
+		if pt.I != 0 {
+			lit++
+		}
+	}
+	// The source is drawn with the kaleidoscope's share of the budget.
+	if want := 3 * (&effects.Kaleidoscope{Copies: 3}).Budget(1000); lit != want {
+		t.Errorf("lit %d points, want %d", lit, want)
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		nodes []NodeConfig
+		want  string
+	}{
+		{"empty", nil, "no nodes"},
+		{"type", []NodeConfig{{Name: "x", Type: "laser"}}, `unknown type "laser"`},
+		{"param", []NodeConfig{{Name: "x", Type: "rose", Params: map[string]float64{"petals": 3}}}, `unknown parameter "petals"`},
+		{"func params", []NodeConfig{{Name: "x", Type: "dot", Params: map[string]float64{"n": 1}}}, "has no parameters"},
+		{"input", []NodeConfig{{Name: "x", Type: "spin", Inputs: []string{"y"}}}, `unknown input "y"`},
+		{"arity", []NodeConfig{{Name: "x", Type: "spin"}}, "has 0 inputs"},
+		{"source inputs", []NodeConfig{{Name: "x", Type: "dot"}, {Name: "y", Type: "dot", Inputs: []string{"x"}}}, "has 1 inputs"},
+		{"duplicate", []NodeConfig{{Name: "x", Type: "dot"}, {Name: "x", Type: "dot"}}, `duplicate node "x"`},
+		{"cycle", []NodeConfig{
+			{Name: "x", Type: "spin", Inputs: []string{"y"}},
+			{Name: "y", Type: "zoom", Inputs: []string{"x"}},
+		}, "cycle"},
+	} {
+		_, err := Build(Config{Nodes: tt.nodes}, testRegistry())
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestEditAppliesAtNextFrame(t *testing.T) {
+	p, err := Build(Config{Nodes: []NodeConfig{
+		{Name: "src", Type: "dot"},
+		{Name: "spin", Type: "spin", Inputs: []string{"src"}, Params: map[string]float64{"speed": 0}},
+	}}, testRegistry())
+	if err != nil {
+		t.Fatal(err)
+	}
+	spin := p.Params("spin")
+
+	err = p.Edit(func(cfg *Config) error {
+		cfg.Nodes = append(cfg.Nodes, NodeConfig{Name: "limit", Type: "attenuate", Inputs: []string{"spin"}, Params: map[string]float64{"level": 0}})
+		cfg.Nodes[1].Params["speed"] = 1
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := spin.Lookup("speed").Get(); got != 0 {
+		t.Fatalf("speed = %v before the next frame, want 0", got)
+	}
+	if cfg := p.Config(); len(cfg.Nodes) != 3 || cfg.Nodes[1].Params["speed"] != 1 {
+		t.Fatalf("pending config = %+v", cfg)
+	}
+
+	frame := p.Frame(0, 10)
+	if frame[0].I != 0 {
+		t.Fatalf("inserted attenuation not applied: %+v", frame[0])
+	}
+	if p.Params("spin") != spin || spin.Lookup("speed").Get() != 1 {
+		t.Fatal("edited node lost its state or parameter change")
+	}
+
+	// A failing edit changes nothing.
+	err = p.Edit(func(cfg *Config) error {
+		cfg.Nodes[0].Inputs = []string{"limit"}
+		return nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "has 1 inputs") {
+		t.Fatalf("err = %v", err)
+	}
+	if got := p.Nodes(); len(got) != 3 {
+		t.Fatalf("nodes = %v after a failed edit", got)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	p, err := Build(Config{Nodes: []NodeConfig{
+		{Name: "src", Type: "dot"},
+		{Name: "limit", Type: "attenuate", Inputs: []string{"src"}},
+	}}, testRegistry())
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := p.Handler()
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+
+	if rec := do("POST", "/pipeline/nodes", `{"name": "zoom", "type": "zoom", "inputs": ["src"]}`); rec.Code != http.StatusOK {
+		t.Fatalf("add node: %d %s", rec.Code, rec.Body)
+	}
+	if rec := do("PUT", "/pipeline/nodes/limit/inputs", `["zoom"]`); rec.Code != http.StatusOK {
+		t.Fatalf("set inputs: %d %s", rec.Code, rec.Body)
+	}
+	if rec := do("PATCH", "/pipeline/nodes/limit/params", `{"level": 0.25}`); rec.Code != http.StatusOK {
+		t.Fatalf("set params: %d %s", rec.Code, rec.Body)
+	}
+	if rec := do("PUT", "/pipeline/output?node=limit", ""); rec.Code != http.StatusOK {
+		t.Fatalf("set output: %d %s", rec.Code, rec.Body)
+	}
+
+	var cfg Config
+	rec := do("GET", "/pipeline", "")
+	if err := json.NewDecoder(rec.Body).Decode(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Output != "limit" || len(cfg.Nodes) != 3 || cfg.Nodes[1].Inputs[0] != "zoom" || cfg.Nodes[1].Params["level"] != 0.25 {
+		t.Fatalf("config = %+v", cfg)
+	}
+
+	if rec := do("DELETE", "/pipeline/nodes/zoom", ""); rec.Code != http.StatusBadRequest {
+		t.Fatalf("removing the only input of limit: %d, want 400", rec.Code)
+	}
+	if rec := do("PATCH", "/pipeline/nodes/nope/params", `{}`); rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown node: %d, want 404", rec.Code)
+	}
+	if rec := do("PATCH", "/pipeline/nodes/limit/params", `{"volume": 1}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("unknown parameter: %d, want 400", rec.Code)
+	}
+	if rec := do("PUT", "/pipeline/output?node=limit", ""); rec.Code != http.StatusOK {
+		t.Fatal(rec.Body)
+	}
+	p.Frame(0, 10)
+	if got := p.Params("limit").Lookup("level").Get(); got != 0.25 {
+		t.Fatalf("level = %v after the frame, want 0.25", got)
+	}
+}
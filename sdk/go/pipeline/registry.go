@@ -0,0 +1,232 @@
+package pipeline
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/effects"
+	"github.com/Grix/helios_dac/sdk/go/generators"
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/motion"
+	"github.com/Grix/helios_dac/sdk/go/scene"
+)
+
+// Stage is a node's processing. Process returns the node's points at time
+// t from the points of its inputs, for a frame of budget points; it must
+// not modify the input slices.
+type Stage interface {
+	Process(t time.Duration, budget int, inputs [][]helios.Point) []helios.Point
+}
+
+// StageFunc adapts an ordinary function to the Stage interface.
+type StageFunc func(t time.Duration, budget int, inputs [][]helios.Point) []helios.Point
+
+// Process calls f(t, budget, inputs).
+func (f StageFunc) Process(t time.Duration, budget int, inputs [][]helios.Point) []helios.Point {
+	return f(t, budget, inputs)
+}
+
+// Inputs is implemented by stages that constrain their number of inputs.
+type Inputs interface {
+	// Inputs returns the least and most inputs the stage accepts; a
+	// negative most means any number.
+	Inputs() (least, most int)
+}
+
+// Budgeter is implemented by stages that divide their budget between their
+// inputs. Other stages give every input the whole budget.
+type Budgeter interface {
+	Budgets(budget, inputs int) []int
+}
+
+// Factory returns a new node value. It is a Stage, a scene.Layer, which
+// becomes a source without inputs, or an effects.Effect, which takes one
+// input. A value that is a pointer to a struct has its param-tagged fields
+// exposed as the node's parameters.
+type Factory func() any
+
+// Registry maps node types to factories.
+type Registry map[string]Factory
+
+// Builtin returns a registry of the generators and effects of this module
+// and the stages of this package. Add entries to it for custom nodes.
+func Builtin() Registry {
+	return Registry{
+		"lissajous":    func() any { return &generators.Lissajous{} },
+		"spirograph":   func() any { return &generators.Spirograph{} },
+		"harmonograph": func() any { return &generators.Harmonograph{} },
+		"rose":         func() any { return &generators.Rose{} },
+
+		"strobe":       func() any { return &effects.Strobe{} },
+		"hue_cycle":    func() any { return &effects.HueCycle{} },
+		"chase":        func() any { return &effects.Chase{} },
+		"wave":         func() any { return &effects.Wave{} },
+		"zoom":         func() any { return &effects.Zoom{} },
+		"spin":         func() any { return &effects.Spin{} },
+		"kaleidoscope": func() any { return &effects.Kaleidoscope{} },
+
+		"merge":      func() any { return &Merge{} },
+		"compensate": func() any { return &Compensate{} },
+		"modulation": func() any { return &Modulation{} },
+		"attenuate":  func() any { return &Attenuate{} },
+	}
+}
+
+func stageOf(v any) (Stage, error) {
+	switch v := v.(type) {
+	case Stage:
+		return v, nil
+	case scene.Layer:
+		return layerStage{v}, nil
+	case effects.Effect:
+		return effectStage{v}, nil
+	}
+	return nil, fmt.Errorf("%T is not a Stage, scene.Layer or effects.Effect", v)
+}
+
+func checkInputs(s Stage, n int) error {
+	least, most := 0, -1
+	if in, ok := s.(Inputs); ok {
+		least, most = in.Inputs()
+	}
+	if n < least || most >= 0 && n > most {
+		return fmt.Errorf("has %d inputs, want %d to %d", n, least, most)
+	}
+	return nil
+}
+
+func inputBudgets(s Stage, budget, n int) []int {
+	if b, ok := s.(Budgeter); ok {
+		return b.Budgets(budget, n)
+	}
+	budgets := make([]int, n)
+	for i := range budgets {
+		budgets[i] = budget
+	}
+	return budgets
+}
+
+type layerStage struct{ scene.Layer }
+
+func (s layerStage) Process(t time.Duration, budget int, _ [][]helios.Point) []helios.Point {
+	return s.Points(t, budget)
+}
+
+func (layerStage) Inputs() (int, int) { return 0, 0 }
+
+type effectStage struct{ effects.Effect }
+
+func (s effectStage) Process(t time.Duration, _ int, inputs [][]helios.Point) []helios.Point {
+	return s.Apply(t, inputs[0])
+}
+
+func (effectStage) Inputs() (int, int) { return 1, 1 }
+
+func (s effectStage) Budgets(budget, _ int) []int {
+	return []int{effects.InputBudget(s.Effect, budget)}
+}
+
+// Merge draws its inputs one after the other, with blanked points at each
+// end of the jumps between them, dividing the budget evenly.
+type Merge struct {
+	// Blank is the number of blanked points at each end of a jump.
+	Blank int `param:"blank,min=0,max=64,default=8"`
+}
+
+// Inputs accepts one or more inputs.
+func (m *Merge) Inputs() (int, int) { return 1, -1 }
+
+// Budgets gives each input an even share of the points left after
+// blanking.
+func (m *Merge) Budgets(budget, inputs int) []int {
+	budgets := make([]int, inputs)
+	if inputs == 0 {
+		return budgets
+	}
+	free := max(0, budget-2*m.Blank*inputs)
+	for i := range budgets {
+		budgets[i] = free / inputs
+		if i < free%inputs {
+			budgets[i]++
+		}
+	}
+	return budgets
+}
+
+// Process concatenates the inputs.
+func (m *Merge) Process(_ time.Duration, _ int, inputs [][]helios.Point) []helios.Point {
+	var out []helios.Point
+	for _, in := range inputs {
+		if len(in) == 0 {
+			continue
+		}
+		out = m.appendBlank(out, in[0])
+		out = append(out, in...)
+		out = m.appendBlank(out, in[len(in)-1])
+	}
+	return out
+}
+
+func (m *Merge) appendBlank(out []helios.Point, p helios.Point) []helios.Point {
+	for range m.Blank {
+		out = append(out, helios.Point{X: p.X, Y: p.Y})
+	}
+	return out
+}
+
+// Compensate evens out brightness by scan speed with motion.Compensation.
+type Compensate struct {
+	// Reference is the scan speed drawn at full brightness; zero uses the
+	// fastest lit point of each frame.
+	Reference float64 `param:"reference,min=0,max=4096"`
+
+	// Floor is the least a point is dimmed to.
+	Floor float64 `param:"floor,min=0,max=1,default=0.2"`
+}
+
+// Inputs accepts one input.
+func (c *Compensate) Inputs() (int, int) { return 1, 1 }
+
+// Process compensates the input.
+func (c *Compensate) Process(_ time.Duration, _ int, inputs [][]helios.Point) []helios.Point {
+	return motion.Compensation{Reference: c.Reference, Floor: c.Floor}.Apply(inputs[0])
+}
+
+// Modulation corrects for laser modulation latency with
+// motion.Modulation.
+type Modulation struct {
+	// Shift is how many points earlier colors are sent than positions.
+	Shift int `param:"shift,min=-16,max=16"`
+
+	// RampIn and RampOut are the points over which lit runs fade in and
+	// out at blanking boundaries.
+	RampIn  int `param:"ramp_in,min=0,max=16"`
+	RampOut int `param:"ramp_out,min=0,max=16"`
+}
+
+// Inputs accepts one input.
+func (m *Modulation) Inputs() (int, int) { return 1, 1 }
+
+// Process corrects the input.
+func (m *Modulation) Process(_ time.Duration, _ int, inputs [][]helios.Point) []helios.Point {
+	return motion.Modulation{Shift: m.Shift, RampIn: m.RampIn, RampOut: m.RampOut}.Apply(inputs[0])
+}
+
+// Attenuate scales the color and intensity of every point, as a safety
+// limit on output power.
+type Attenuate struct {
+	Level float64 `param:"level,min=0,max=1,default=1"`
+}
+
+// Inputs accepts one input.
+func (a *Attenuate) Inputs() (int, int) { return 1, 1 }
+
+// Process scales the input.
+func (a *Attenuate) Process(_ time.Duration, _ int, inputs [][]helios.Point) []helios.Point {
+	out := make([]helios.Point, len(inputs[0]))
+	for i, p := range inputs[0] {
+		p.R, p.G, p.B, p.I = helios.ScaleColor(p.R, a.Level), helios.ScaleColor(p.G, a.Level), helios.ScaleColor(p.B, a.Level), helios.ScaleColor(p.I, a.Level)
+		out[i] = p
+	}
+	return out
+}
@@ -0,0 +1,58 @@
+package playlist
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// Handler returns an HTTP API for controlling playback:
+//
+//	GET  /playlist           the State, as JSON
+//	GET  /playlist/items     the item names, as JSON
+//	POST /playlist/next      skip to the next item
+//	POST /playlist/previous  restart the previous item
+//	POST /playlist/jump?index=i  start item i
+//	POST /playlist/pause     hold the current frame
+//	POST /playlist/resume    continue playback
+//
+// The POST endpoints respond with the new State.
+func (p *Playlist) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /playlist", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, p.State())
+	})
+	mux.HandleFunc("GET /playlist/items", func(w http.ResponseWriter, r *http.Request) {
+		names := make([]string, len(p.items))
+		for i, it := range p.items {
+			names[i] = it.Name
+		}
+		writeJSON(w, names)
+	})
+	for path, action := range map[string]func(){
+		"next":     p.Next,
+		"previous": p.Previous,
+		"pause":    p.Pause,
+		"resume":   p.Resume,
+	} {
+		mux.HandleFunc("POST /playlist/"+path, func(w http.ResponseWriter, r *http.Request) {
+			action()
+			writeJSON(w, p.State())
+		})
+	}
+	mux.HandleFunc("POST /playlist/jump", func(w http.ResponseWriter, r *http.Request) {
+		i, err := strconv.Atoi(r.URL.Query().Get("index"))
+		if err != nil || i < 0 || i >= len(p.items) {
+			http.Error(w, "invalid item index", http.StatusBadRequest)
+			return
+		}
+		p.Jump(i)
+		writeJSON(w, p.State())
+	})
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
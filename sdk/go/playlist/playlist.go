@@ -0,0 +1,298 @@
+// Package playlist plays directories of ILDA files, as a standalone laser
+// player would.
+//
+// A Playlist steps through its items in order or shuffled, showing each
+// for a fixed time or once through, with a blanked gap or a transition
+// between them. Frame returns the points to draw now; call it once per
+// output frame:
+//
+//	p, _ := playlist.Load("/srv/laser/shows", nil)
+//	p.Fade = time.Second
+//	for {
+//		manager.SubmitFrame(0, 30000, p.Frame())
+//	}
+//
+// Next, Previous, Jump, Pause and Resume control playback, from code or
+// over HTTP with Handler.
+package playlist
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/ilda"
+	"github.com/Grix/helios_dac/sdk/go/show"
+	"github.com/Grix/helios_dac/sdk/go/transition"
+)
+
+// DefaultFrameRate is the rate ILDA frames are shown at when
+// Playlist.FrameRate is zero. ILDA files carry no frame rate.
+const DefaultFrameRate = 30
+
+// ErrEmpty is returned by Load for directories without playable files.
+var ErrEmpty = errors.New("playlist: no ILDA files")
+
+// Item is one file of a playlist.
+type Item struct {
+	Name   string
+	Frames []ilda.Frame
+}
+
+// Playlist plays items one after the other. It is safe for concurrent use;
+// set its fields before playback starts.
+type Playlist struct {
+	// FrameRate is the rate the frames of an item are shown at. Zero means
+	// DefaultFrameRate.
+	FrameRate float64
+
+	// Duration is how long each item is shown, looping its frames. Zero
+	// shows each item's frames once.
+	Duration time.Duration
+
+	// Fade is the length of the transition between items, during which
+	// both are drawn. Zero cuts.
+	Fade time.Duration
+
+	// Transition blends the items during Fade. Nil means
+	// transition.Crossfade, one dimming and the other brightening.
+	Transition transition.Transition
+
+	// Gap is the blanked time between items when Fade is zero.
+	Gap time.Duration
+
+	// Shuffle plays a random item next instead of the following one.
+	Shuffle bool
+
+	// Seed selects the shuffled order.
+	Seed uint64
+
+	mu        sync.Mutex
+	items     []Item
+	current   int
+	prev      int
+	transport *show.Transport
+	rng       *rand.Rand
+}
+
+// New returns a playlist of items, starting at the first, timed by clock.
+// A nil clock uses the system clock.
+func New(items []Item, clock show.Clock) *Playlist {
+	return &Playlist{items: items, prev: -1, transport: show.NewTransport(clock)}
+}
+
+// Load returns a playlist of the .ild files of dir in name order. Files
+// without frames are skipped; files that cannot be read fail the load.
+func Load(dir string, clock show.Clock) (*Playlist, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("playlist: %w", err)
+	}
+	var items []Item
+	for _, e := range entries {
+		if e.IsDir() || !strings.EqualFold(filepath.Ext(e.Name()), ".ild") {
+			continue
+		}
+		f, err := os.Open(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("playlist: %w", err)
+		}
+		frames, err := ilda.Read(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("playlist: %s: %w", e.Name(), err)
+		}
+		if len(frames) > 0 {
+			items = append(items, Item{Name: e.Name(), Frames: frames})
+		}
+	}
+	if len(items) == 0 {
+		return nil, ErrEmpty
+	}
+	return New(items, clock), nil
+}
+
+// Items returns the items in playlist order.
+func (p *Playlist) Items() []Item {
+	return p.items
+}
+
+func (p *Playlist) frameRate() float64 {
+	if p.FrameRate > 0 {
+		return p.FrameRate
+	}
+	return DefaultFrameRate
+}
+
+// duration returns how long item i is shown.
+func (p *Playlist) duration(i int) time.Duration {
+	if p.Duration > 0 {
+		return p.Duration
+	}
+	return time.Duration(float64(len(p.items[i].Frames)) / p.frameRate() * float64(time.Second))
+}
+
+// fade returns the transition length, at most half of each item's duration.
+func (p *Playlist) fade(i int) time.Duration {
+	return min(p.Fade, p.duration(i)/2)
+}
+
+// span returns the time from the start of item i to the start of the next.
+func (p *Playlist) span(i int) time.Duration {
+	if p.Fade > 0 {
+		return p.duration(i) - p.fade(i)
+	}
+	return p.duration(i) + p.Gap
+}
+
+// next returns the item following i.
+func (p *Playlist) next(i int) int {
+	if !p.Shuffle || len(p.items) < 2 {
+		return (i + 1) % len(p.items)
+	}
+	if p.rng == nil {
+		p.rng = rand.New(rand.NewPCG(p.Seed, 0))
+	}
+	return (i + 1 + p.rng.IntN(len(p.items)-1)) % len(p.items)
+}
+
+// Frame returns the points to draw now, advancing to the next item when
+// the current one is over. It returns nil during gaps and for an empty
+// playlist. The returned points must not be modified.
+func (p *Playlist) Frame() []helios.Point {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.items) == 0 {
+		return nil
+	}
+	now := p.transport.Now()
+	for range len(p.items) {
+		span := p.span(p.current)
+		if now < span || span <= 0 {
+			break
+		}
+		now -= span
+		p.prev, p.current = p.current, p.next(p.current)
+		p.transport.Scrub(now)
+	}
+	if now >= p.duration(p.current) {
+		return nil
+	}
+	points := p.frameAt(p.current, now)
+	if p.Fade <= 0 || p.prev < 0 || now >= p.fade(p.prev) {
+		return points
+	}
+	tr := p.Transition
+	if tr == nil {
+		tr = transition.Crossfade{}
+	}
+	return tr.Blend(p.frameAt(p.prev, p.span(p.prev)+now), points, float64(now)/float64(p.fade(p.prev)))
+}
+
+// frameAt returns the frame of item i shown t into it.
+func (p *Playlist) frameAt(i int, t time.Duration) []helios.Point {
+	frames := p.items[i].Frames
+	return frames[int(t.Seconds()*p.frameRate())%len(frames)].Points
+}
+
+// Jump starts item i from its beginning, without a transition. It does
+// nothing if i is out of range.
+func (p *Playlist) Jump(i int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if i < 0 || i >= len(p.items) {
+		return
+	}
+	p.current, p.prev = i, -1
+	p.transport.Scrub(0)
+}
+
+// Next skips to the next item.
+func (p *Playlist) Next() {
+	p.mu.Lock()
+	i := 0
+	if len(p.items) > 0 {
+		i = p.next(p.current)
+	}
+	p.mu.Unlock()
+	p.Jump(i)
+}
+
+// Previous restarts the previous item in playlist order.
+func (p *Playlist) Previous() {
+	p.mu.Lock()
+	i := 0
+	if len(p.items) > 0 {
+		i = (p.current + len(p.items) - 1) % len(p.items)
+	}
+	p.mu.Unlock()
+	p.Jump(i)
+}
+
+// Pause holds the current frame.
+func (p *Playlist) Pause() {
+	p.transport.Pause()
+}
+
+// Resume continues playback.
+func (p *Playlist) Resume() {
+	p.transport.Resume()
+}
+
+// State describes what a playlist is playing.
+type State struct {
+	Index    int           `json:"index"`
+	Item     string        `json:"item"`
+	Count    int           `json:"count"`
+	Position time.Duration `json:"position_ns"`
+	Duration time.Duration `json:"duration_ns"`
+	Paused   bool          `json:"paused"`
+}
+
+// State returns the current item and position. The position is as of the
+// last Frame call if the item has ended since.
+func (p *Playlist) State() State {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s := State{Index: p.current, Count: len(p.items), Paused: p.transport.Paused()}
+	if len(p.items) > 0 {
+		s.Item, s.Duration = p.items[p.current].Name, p.duration(p.current)
+		s.Position = min(p.transport.Now(), s.Duration)
+	}
+	return s
+}
+
+// Restore returns to the item and position of s, as saved from State,
+// finding the item by name so a playlist whose directory has changed
+// resumes the same file, or by index if no item has the name. It does
+// nothing if neither is found.
+func (p *Playlist) Restore(s State) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	i := -1
+	for k, it := range p.items {
+		if it.Name == s.Item {
+			i = k
+			break
+		}
+	}
+	if i < 0 {
+		if s.Index < 0 || s.Index >= len(p.items) {
+			return
+		}
+		i = s.Index
+	}
+	p.current, p.prev = i, -1
+	p.transport.Scrub(min(max(s.Position, 0), p.duration(i)))
+	if s.Paused {
+		p.transport.Pause()
+	} else {
+		p.transport.Resume()
+	}
+}
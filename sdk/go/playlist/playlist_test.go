@@ -0,0 +1,200 @@
+package playlist
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/ilda"
+	"github.com/Grix/helios_dac/sdk/go/show"
+	"github.com/Grix/helios_dac/sdk/go/transition"
+)
+
+// item returns an item of n one-point frames, the point of frame f at
+// X = base + f, lit red.
+func item(name string, base, n int) Item {
+	it := Item{Name: name}
+	for f := range n {
+		it.Frames = append(it.Frames, ilda.Frame{Points: []helios.Point{{X: uint16(base + f), R: 255, I: 255}}})
+	}
+	return it
+}
+
+// writeILDA writes a format 5 file of one-point frames.
+func writeILDA(t *testing.T, path string, frames int) {
+	t.Helper()
+	var buf bytes.Buffer
+	for f := range frames + 1 {
+		records := 1
+		if f == frames {
+			records = 0
+		}
+		buf.WriteString("ILDA\x00\x00\x00\x05")
+		buf.Write(make([]byte, 16))
+		binary.Write(&buf, binary.BigEndian, [4]uint16{uint16(records), uint16(f), uint16(frames), 0})
+		if records > 0 {
+			binary.Write(&buf, binary.BigEndian, struct {
+				X, Y   int16
+				Status uint8
+				BGR    [3]uint8
+			}{int16(f), 0, 0x80, [3]uint8{0, 0, 255}})
+		}
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	writeILDA(t, filepath.Join(dir, "b.ILD"), 3)
+	writeILDA(t, filepath.Join(dir, "a.ild"), 2)
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("x"), 0o644)
+
+	p, err := Load(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := p.Items()
+	if len(items) != 2 || items[0].Name != "a.ild" || len(items[1].Frames) != 3 {
+		t.Fatalf("items = %+v", items)
+	}
+	if _, err := Load(t.TempDir(), nil); !errors.Is(err, ErrEmpty) {
+		t.Fatalf("empty dir: err = %v", err)
+	}
+}
+
+func TestSequenceAndGap(t *testing.T) {
+	clock := &show.ManualClock{}
+	p := New([]Item{item("a", 100, 3), item("b", 200, 2)}, clock)
+	p.FrameRate = 10
+	p.Gap = 150 * time.Millisecond
+
+	var xs []int
+	for range 14 {
+		if f := p.Frame(); f == nil {
+			xs = append(xs, -1)
+		} else {
+			xs = append(xs, int(f[0].X))
+		}
+		clock.Advance(100 * time.Millisecond)
+	}
+	// a for 300ms, a 150ms gap, b for 200ms from 450ms, a gap, a again
+	// from 800ms; the item clock keeps the overshoot of each change.
+	want := []int{100, 101, 102, -1, -1, 200, 201, -1, 100, 101, 102, -1, -1, 200}
+	for i := range want {
+		if xs[i] != want[i] {
+			t.Fatalf("frames = %v, want %v", xs, want)
+		}
+	}
+}
+
+func TestCrossfade(t *testing.T) {
+	clock := &show.ManualClock{}
+	p := New([]Item{item("a", 100, 10), item("b", 200, 10)}, clock)
+	p.FrameRate = 10
+	p.Fade = 200 * time.Millisecond
+
+	// a starts fading at 800ms, when b starts; halfway through both are
+	// drawn at half brightness with a blanked jump between them.
+	clock.Advance(900 * time.Millisecond)
+	f := p.Frame()
+	if len(f) != 2+2*transition.DefaultBlankPoints {
+		t.Fatalf("crossfade frame has %d points", len(f))
+	}
+	if f[0].X != 109 || f[0].R != 128 || f[len(f)-1].X != 201 || f[len(f)-1].R != 128 {
+		t.Fatalf("crossfade frame = %+v ... %+v", f[0], f[len(f)-1])
+	}
+	if s := p.State(); s.Item != "b" || s.Position != 100*time.Millisecond {
+		t.Fatalf("state = %+v", s)
+	}
+
+	// Skipping cuts without a crossfade.
+	p.Next()
+	if f := p.Frame(); len(f) != 1 || f[0].X != 100 {
+		t.Fatalf("after Next: %+v", f)
+	}
+}
+
+func TestHandlerControls(t *testing.T) {
+	clock := &show.ManualClock{}
+	p := New([]Item{item("a", 100, 3), item("b", 200, 3), item("c", 300, 3)}, clock)
+	h := p.Handler()
+	post := func(path string) (State, int) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, nil))
+		var s State
+		json.NewDecoder(rec.Body).Decode(&s)
+		return s, rec.Code
+	}
+	if s, _ := post("/playlist/next"); s.Item != "b" {
+		t.Fatalf("next: %+v", s)
+	}
+	if s, _ := post("/playlist/jump?index=2"); s.Item != "c" || s.Count != 3 {
+		t.Fatalf("jump: %+v", s)
+	}
+	if s, _ := post("/playlist/pause"); !s.Paused {
+		t.Fatalf("pause: %+v", s)
+	}
+	clock.Advance(time.Hour)
+	if f := p.Frame(); f[0].X != 300 {
+		t.Fatalf("paused playlist moved on to %+v", f)
+	}
+	if s, _ := post("/playlist/previous"); s.Item != "b" || !s.Paused {
+		t.Fatalf("previous: %+v", s)
+	}
+	if _, code := post("/playlist/jump?index=3"); code != http.StatusBadRequest {
+		t.Fatalf("jump out of range: %d, want 400", code)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	clock := &show.ManualClock{}
+	p := New([]Item{item("a", 100, 3), item("b", 200, 3), item("c", 300, 3)}, clock)
+	p.Jump(1)
+	clock.Advance(40 * time.Millisecond)
+	p.Pause()
+	saved := p.State()
+
+	// The restarted playlist has lost an item before the saved one, so the
+	// item is found by name rather than index.
+	q := New([]Item{item("b", 200, 3), item("c", 300, 3)}, &show.ManualClock{})
+	q.Restore(saved)
+	if s := q.State(); s.Item != "b" || s.Position != saved.Position || !s.Paused {
+		t.Fatalf("restored %+v, saved %+v", s, saved)
+	}
+	q.Restore(State{Item: "gone", Index: 1})
+	if s := q.State(); s.Item != "c" || s.Position != 0 || s.Paused {
+		t.Fatalf("restored by index: %+v", s)
+	}
+	q.Restore(State{Item: "gone", Index: 5})
+	if s := q.State(); s.Item != "c" {
+		t.Fatalf("restoring an unknown item moved to %+v", s)
+	}
+}
+
+func TestShuffleNeverRepeats(t *testing.T) {
+	p := New([]Item{item("a", 0, 1), item("b", 0, 1), item("c", 0, 1)}, &show.ManualClock{})
+	p.Shuffle = true
+	seen := map[int]bool{}
+	for range 30 {
+		before := p.State().Index
+		p.Next()
+		after := p.State().Index
+		if after == before {
+			t.Fatal("shuffle repeated an item")
+		}
+		seen[after] = true
+	}
+	if len(seen) != 3 {
+		t.Fatalf("shuffle played %d of 3 items", len(seen))
+	}
+}
@@ -0,0 +1,103 @@
+package helios
+
+import "math"
+
+// PointF is a point in normalized, device-independent units.
+// X, Y: -1 to 1. -1 is Bottom/Left, 0 is the center, 1 is Top/Right.
+// R, G, B, I: 0 to 1.
+//
+// Out-of-range values are clamped when converting to device resolution, so
+// a shape drawn partly outside the projection area flattens against its edge
+// instead of wrapping around to the opposite side. NaN converts to the
+// center for coordinates and to zero for colors.
+type PointF struct {
+	X, Y       float64
+	R, G, B, I float64
+}
+
+// Point converts p to the standard point structure.
+func (p PointF) Point() Point {
+	return Point{
+		X: uint16(coord(p.X, 0xFFF)),
+		Y: uint16(coord(p.Y, 0xFFF)),
+		R: uint8(level(p.R, 0xFF)),
+		G: uint8(level(p.G, 0xFF)),
+		B: uint8(level(p.B, 0xFF)),
+		I: uint8(level(p.I, 0xFF)),
+	}
+}
+
+// HighRes converts p to the high-resolution point structure, using the full
+// 16-bit XY range.
+func (p PointF) HighRes() PointHighRes {
+	return PointHighRes{
+		X: uint16(coord(p.X, 0xFFFF)),
+		Y: uint16(coord(p.Y, 0xFFFF)),
+		R: uint16(level(p.R, 0xFFFF)),
+		G: uint16(level(p.G, 0xFFFF)),
+		B: uint16(level(p.B, 0xFFFF)),
+	}
+}
+
+// Ext converts p to the extended point structure, using the full 16-bit XY
+// range. The user fields are zero.
+func (p PointF) Ext() PointExt {
+	return PointExt{
+		X: uint16(coord(p.X, 0xFFFF)),
+		Y: uint16(coord(p.Y, 0xFFFF)),
+		R: uint16(level(p.R, 0xFFFF)),
+		G: uint16(level(p.G, 0xFFFF)),
+		B: uint16(level(p.B, 0xFFFF)),
+		I: uint16(level(p.I, 0xFFFF)),
+	}
+}
+
+// PointF converts p to normalized units.
+func (p Point) PointF() PointF {
+	return PointF{
+		X: float64(p.X)/0xFFF*2 - 1,
+		Y: float64(p.Y)/0xFFF*2 - 1,
+		R: float64(p.R) / 0xFF,
+		G: float64(p.G) / 0xFF,
+		B: float64(p.B) / 0xFF,
+		I: float64(p.I) / 0xFF,
+	}
+}
+
+// PointsFromF converts a frame of normalized points to standard points.
+func PointsFromF(points []PointF) []Point {
+	out := make([]Point, len(points))
+	for i, p := range points {
+		out[i] = p.Point()
+	}
+	return out
+}
+
+// coord maps v from -1..1 to 0..full, clamping out-of-range values.
+func coord(v float64, full float64) float64 {
+	if math.IsNaN(v) {
+		v = 0
+	}
+	return math.Round((max(-1, min(v, 1)) + 1) / 2 * full)
+}
+
+// level maps v from 0..1 to 0..full, clamping out-of-range values.
+func level(v float64, full float64) float64 {
+	if math.IsNaN(v) {
+		return 0
+	}
+	return math.Round(max(0, min(v, 1)) * full)
+}
+
+// WriteFrameF converts a normalized frame to standard points and sends it
+// to the device. Use the HighRes conversion with WriteFrameHighResolution
+// for devices supporting higher resolutions.
+func (d *DAC) WriteFrameF(deviceIndex int, pps int, flags int, points []PointF) int {
+	return d.WriteFrame(deviceIndex, pps, flags, PointsFromF(points))
+}
+
+// SubmitFrameF converts a normalized frame to standard points and queues it
+// like SubmitFrame.
+func (m *DeviceManager) SubmitFrameF(deviceIndex int, pps int, points []PointF) error {
+	return m.SubmitFrame(deviceIndex, pps, PointsFromF(points))
+}
@@ -0,0 +1,47 @@
+package helios
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPointF(t *testing.T) {
+	tests := []struct {
+		in   PointF
+		want Point
+	}{
+		{PointF{X: -1, Y: -1}, Point{X: 0, Y: 0}},
+		{PointF{X: 0, Y: 0, R: 1, G: 0.5, I: 1}, Point{X: 2048, Y: 2048, R: 255, G: 128, I: 255}},
+		{PointF{X: 1, Y: 1}, Point{X: 4095, Y: 4095}},
+		// Out of range clamps to the edge instead of wrapping.
+		{PointF{X: 1.5, Y: -3, R: 2, B: -1}, Point{X: 4095, Y: 0, R: 255}},
+		{PointF{X: math.NaN(), R: math.NaN()}, Point{X: 2048, Y: 2048}},
+	}
+	for _, tt := range tests {
+		if got := tt.in.Point(); got != tt.want {
+			t.Errorf("%+v.Point() = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+
+	hr := PointF{X: 1, Y: -1, R: 1}.HighRes()
+	if hr.X != 0xFFFF || hr.Y != 0 || hr.R != 0xFFFF {
+		t.Fatalf("HighRes = %+v", hr)
+	}
+
+	p := Point{X: 4095, Y: 0, R: 255}
+	if got := p.PointF().Point(); got != p {
+		t.Fatalf("round trip = %+v, want %+v", got, p)
+	}
+}
+
+func TestSubmitFrameF(t *testing.T) {
+	w := &fakeWriter{ready: true, frames: map[int][][]Point{}}
+	m := newDeviceManager(w, 1)
+	defer m.Close()
+	if err := m.SubmitFrameF(0, 30000, nil); err != ErrNullPoints {
+		t.Fatalf("empty frame: %v, want ErrNullPoints", err)
+	}
+	if err := m.SubmitFrameF(0, 30000, []PointF{{X: 1, Y: 1, R: 1}}); err != nil {
+		t.Fatal(err)
+	}
+}
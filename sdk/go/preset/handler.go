@@ -0,0 +1,54 @@
+package preset
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Handler returns an HTTP API for the bank:
+//
+//	GET    /presets                          every preset, as JSON
+//	PUT    /presets/{name}                   save the current values as name
+//	DELETE /presets/{name}                   delete
+//	POST   /presets/{name}/recall?fade=secs  recall, crossfading over secs
+func (b *Bank) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /presets", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, b.Presets())
+	})
+	mux.HandleFunc("PUT /presets/{name}", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, b.Save(r.PathValue("name")))
+	})
+	mux.HandleFunc("DELETE /presets/{name}", func(w http.ResponseWriter, r *http.Request) {
+		b.Delete(r.PathValue("name"))
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("POST /presets/{name}/recall", func(w http.ResponseWriter, r *http.Request) {
+		var secs float64
+		if s := r.URL.Query().Get("fade"); s != "" {
+			var err error
+			if secs, err = strconv.ParseFloat(s, 64); err != nil {
+				http.Error(w, "invalid fade time", http.StatusBadRequest)
+				return
+			}
+		}
+		err := b.Recall(r.PathValue("name"), time.Duration(secs*float64(time.Second)))
+		switch {
+		case errors.Is(err, ErrNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,187 @@
+// Package preset saves and recalls named snapshots of a parameter set, so
+// operators can build looks during rehearsal and fire them during the show.
+//
+// A recall can crossfade from the current values over a given time. The
+// fade advances when Update is called, which the render loop does once per
+// frame before drawing.
+package preset
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/param"
+	"github.com/Grix/helios_dac/sdk/go/show"
+	"github.com/Grix/helios_dac/sdk/go/store"
+)
+
+// ErrNotFound is returned when recalling a preset that does not exist.
+var ErrNotFound = errors.New("preset: not found")
+
+// Preset is a named snapshot of parameter values.
+type Preset struct {
+	Name   string             `json:"name"`
+	Values map[string]float64 `json:"values"`
+}
+
+// fade is a crossfade in progress.
+type fade struct {
+	from, to   map[string]float64
+	start, end time.Duration
+}
+
+// Bank holds the presets of one parameter set. It is safe for concurrent
+// use.
+type Bank struct {
+	set   *param.Set
+	clock show.Clock
+
+	mu      sync.Mutex
+	presets map[string]Preset
+	fade    *fade
+}
+
+// NewBank creates an empty bank for set, timing crossfades with clock. A nil
+// clock uses a show.WallClock.
+func NewBank(set *param.Set, clock show.Clock) *Bank {
+	if clock == nil {
+		clock = show.NewWallClock()
+	}
+	return &Bank{set: set, clock: clock, presets: make(map[string]Preset)}
+}
+
+// Save stores the current parameter values under name, replacing any preset
+// of that name.
+func (b *Bank) Save(name string) Preset {
+	p := Preset{Name: name, Values: b.set.Values()}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.presets[name] = p
+	return p
+}
+
+// Delete removes the named preset.
+func (b *Bank) Delete(name string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.presets, name)
+}
+
+// Presets returns every preset, sorted by name.
+func (b *Bank) Presets() []Preset {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	names := slices.Sorted(maps.Keys(b.presets))
+	out := make([]Preset, len(names))
+	for i, name := range names {
+		out[i] = b.presets[name]
+	}
+	return out
+}
+
+// Recall moves the parameters to the named preset over the fade time,
+// replacing any fade in progress. A zero fade applies the preset at once.
+// Parameters the preset does not list keep their values.
+func (b *Bank) Recall(name string, fadeTime time.Duration) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	p, ok := b.presets[name]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrNotFound, name)
+	}
+	if fadeTime <= 0 {
+		b.fade = nil
+		b.set.SetValues(p.Values)
+		return nil
+	}
+	now := b.clock.Now()
+	b.fade = &fade{from: b.set.Values(), to: p.Values, start: now, end: now + fadeTime}
+	return nil
+}
+
+// Update applies the crossfade in progress at the clock's current time and
+// reports whether it is still running.
+func (b *Bank) Update() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	f := b.fade
+	if f == nil {
+		return false
+	}
+	now := b.clock.Now()
+	if now >= f.end {
+		b.fade = nil
+		b.set.SetValues(f.to)
+		return false
+	}
+	t := float64(now-f.start) / float64(f.end-f.start)
+	values := make(map[string]float64, len(f.to))
+	for name, to := range f.to {
+		if from, ok := f.from[name]; ok {
+			values[name] = from + (to-from)*t
+		}
+	}
+	b.set.SetValues(values)
+	return true
+}
+
+// Fading reports whether a crossfade is in progress.
+func (b *Bank) Fading() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.fade != nil
+}
+
+// presetsSchema versions saved banks. Version 1 was a bare array of
+// presets; version 2 wraps it in an object so it can carry a version.
+var presetsSchema = store.Schema{
+	Name:    "preset bank",
+	Version: 2,
+	Migrations: map[int]store.Migration{
+		1: func(doc any) (any, error) {
+			if _, ok := doc.([]any); !ok {
+				return nil, errors.New("expected an array of presets")
+			}
+			return map[string]any{"presets": doc}, nil
+		},
+	},
+}
+
+// savedBank is the saved form of a bank.
+type savedBank struct {
+	Presets []Preset `json:"presets"`
+}
+
+// SaveTo writes every preset to key in s as versioned JSON.
+func (b *Bank) SaveTo(ctx context.Context, s store.Store, key string) error {
+	data, err := presetsSchema.Encode(savedBank{Presets: b.Presets()})
+	if err != nil {
+		return err
+	}
+	return s.Put(ctx, key, data)
+}
+
+// LoadFrom replaces the bank's presets with those written to key in s by
+// SaveTo, in this or an earlier version.
+func (b *Bank) LoadFrom(ctx context.Context, s store.Store, key string) error {
+	data, err := s.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	var saved savedBank
+	if err := presetsSchema.Decode(data, &saved); err != nil {
+		return fmt.Errorf("preset: %s: %w", key, err)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.presets = make(map[string]Preset, len(saved.Presets))
+	for _, p := range saved.Presets {
+		b.presets[p.Name] = p
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package preset
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/param"
+	"github.com/Grix/helios_dac/sdk/go/show"
+	"github.com/Grix/helios_dac/sdk/go/store"
+)
+
+type look struct {
+	Size  float64 `param:"size,min=0,max=1"`
+	Count int     `param:"count,min=0,max=10"`
+}
+
+func newBank(t *testing.T) (*look, *Bank, *show.ManualClock) {
+	t.Helper()
+	l := &look{}
+	set, err := param.Of(l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := &show.ManualClock{}
+	return l, NewBank(set, clock), clock
+}
+
+func TestRecallCrossfade(t *testing.T) {
+	l, b, clock := newBank(t)
+	*l = look{Size: 1, Count: 10}
+	b.Save("big")
+	*l = look{}
+	b.Save("off")
+
+	if err := b.Recall("big", 0); err != nil || l.Size != 1 || l.Count != 10 {
+		t.Fatalf("instant recall: %v, %+v", err, *l)
+	}
+
+	if err := b.Recall("off", 2*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(500 * time.Millisecond)
+	if !b.Update() || l.Size != 0.75 || l.Count != 8 {
+		t.Fatalf("quarter way: %+v", *l)
+	}
+	clock.Advance(2 * time.Second)
+	if b.Update() || b.Fading() || *l != (look{}) {
+		t.Fatalf("after fade: %+v", *l)
+	}
+
+	if err := b.Recall("missing", 0); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("missing preset: %v", err)
+	}
+}
+
+func TestSaveToLoadFrom(t *testing.T) {
+	ctx := context.Background()
+	l, b, _ := newBank(t)
+	l.Size = 0.5
+	b.Save("half")
+	s := store.NewMemory()
+	if err := b.SaveTo(ctx, s, "presets/show.json"); err != nil {
+		t.Fatal(err)
+	}
+
+	_, b2, _ := newBank(t)
+	if err := b2.LoadFrom(ctx, s, "presets/show.json"); err != nil {
+		t.Fatal(err)
+	}
+	if p := b2.Presets(); len(p) != 1 || p[0].Name != "half" || p[0].Values["size"] != 0.5 {
+		t.Fatalf("loaded %+v", p)
+	}
+}
+
+func TestLoadFromUnversioned(t *testing.T) {
+	ctx := context.Background()
+	s := store.NewMemory()
+	// Banks saved before versioning were a bare array.
+	s.Put(ctx, "old.json", []byte(`[{"name": "dim", "values": {"size": 0.25}}]`))
+	_, b, _ := newBank(t)
+	if err := b.LoadFrom(ctx, s, "old.json"); err != nil {
+		t.Fatal(err)
+	}
+	if p := b.Presets(); len(p) != 1 || p[0].Values["size"] != 0.25 {
+		t.Fatalf("loaded %+v", p)
+	}
+	s.Put(ctx, "new.json", []byte(`{"version": 9, "presets": []}`))
+	if err := b.LoadFrom(ctx, s, "new.json"); !errors.Is(err, store.ErrNewerVersion) {
+		t.Fatalf("newer bank: err = %v", err)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	l, b, _ := newBank(t)
+	h := b.Handler()
+	do := func(method, target string) int {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+		return rec.Code
+	}
+	l.Count = 3
+	if code := do("PUT", "/presets/three"); code != http.StatusOK {
+		t.Fatalf("PUT: %d", code)
+	}
+	l.Count = 0
+	if code := do("POST", "/presets/three/recall"); code != http.StatusNoContent || l.Count != 3 {
+		t.Fatalf("recall: %d, count %d", code, l.Count)
+	}
+	if code := do("POST", "/presets/three/recall?fade=x"); code != http.StatusBadRequest {
+		t.Fatalf("bad fade: %d", code)
+	}
+	if code := do("POST", "/presets/nope/recall"); code != http.StatusNotFound {
+		t.Fatalf("missing: %d", code)
+	}
+}
@@ -0,0 +1,285 @@
+// Package preview shows what the laser is drawing in a web browser.
+//
+// A Server keeps the most recently published frame and streams it over
+// WebSocket to every connected browser, together with a small page that
+// draws it on a canvas. Wrap an output to publish every frame written to
+// it:
+//
+//	p := preview.NewServer()
+//	out = p.Wrap(out)
+//	go http.ListenAndServe(":8080", p)
+//
+// Browsers receive at most MaxRate frames per second and always the latest
+// one, so a slow connection never holds up the output. Set Response to show
+// where the scanners actually point, with the lag, overshoot and corner
+// rounding of real mirrors, instead of the commanded positions; this helps
+// tune dwells without the hardware. Each message is
+// binary: the point rate as a little-endian uint32, followed by 8 bytes per
+// point (x:uint16 y:uint16 r g b i:uint8, little-endian).
+package preview
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/motion"
+	"github.com/Grix/helios_dac/sdk/go/output"
+)
+
+// DefaultMaxRate is the frame rate sent to browsers when Server.MaxRate is
+// zero.
+const DefaultMaxRate = 30
+
+// Server publishes frames to browsers. It is an http.Handler serving
+//
+//	GET /        the viewer page
+//	GET /frames  the WebSocket frame stream
+type Server struct {
+	// MaxRate limits the frames per second sent to each browser. Zero
+	// means DefaultMaxRate.
+	MaxRate int
+
+	// Response, if not nil, simulates the scanners following each
+	// published frame. Set it before publishing.
+	Response *motion.Response
+
+	mux *http.ServeMux
+
+	mu      sync.Mutex
+	frame   []byte
+	clients map[*client]struct{}
+}
+
+type client struct {
+	conn   net.Conn
+	notify chan struct{}
+}
+
+// NewServer returns a server with no frame published yet.
+func NewServer() *Server {
+	s := &Server{clients: make(map[*client]struct{})}
+	s.mux = http.NewServeMux()
+	s.mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		io.WriteString(w, viewer)
+	})
+	s.mux.HandleFunc("GET /frames", s.serveFrames)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Publish makes points the current frame. The points are copied.
+func (s *Server) Publish(pps int, points []helios.Point) {
+	if s.Response != nil {
+		points = s.Response.Simulate(points, pps)
+	}
+	msg := binary.LittleEndian.AppendUint32(make([]byte, 0, 4+len(points)*8), uint32(pps))
+	for _, p := range points {
+		msg = binary.LittleEndian.AppendUint16(msg, p.X)
+		msg = binary.LittleEndian.AppendUint16(msg, p.Y)
+		msg = append(msg, p.R, p.G, p.B, p.I)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.frame = msg
+	for c := range s.clients {
+		select {
+		case c.notify <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Close disconnects all browsers.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for c := range s.clients {
+		c.conn.Close()
+	}
+	return nil
+}
+
+// Wrap returns an output publishing every frame written to out, whether or
+// not out accepts it.
+func (s *Server) Wrap(out output.Output) output.Output {
+	return &tap{Output: out, s: s}
+}
+
+type tap struct {
+	output.Output
+	s *Server
+}
+
+func (t *tap) WriteFrame(pps int, points []helios.Point) error {
+	t.s.Publish(pps, points)
+	return t.Output.WriteFrame(pps, points)
+}
+
+func (t *tap) Unwrap() output.Output { return t.Output }
+
+// websocketGUID is appended to the client's key to form the accept key, as
+// specified by RFC 6455.
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// WebSocket opcodes.
+const (
+	opBinary = 0x2
+	opClose  = 0x8
+	opPing   = 0x9
+	opPong   = 0xA
+)
+
+func (s *Server) serveFrames(w http.ResponseWriter, r *http.Request) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") || key == "" {
+		http.Error(w, "preview: expected a WebSocket upgrade", http.StatusBadRequest)
+		return
+	}
+	conn, rw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer conn.Close()
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: " +
+		base64.StdEncoding.EncodeToString(sum[:]) + "\r\n\r\n")
+	if err := rw.Flush(); err != nil {
+		return
+	}
+
+	c := &client{conn: conn, notify: make(chan struct{}, 1)}
+	s.mu.Lock()
+	s.clients[c] = struct{}{}
+	if s.frame != nil {
+		c.notify <- struct{}{}
+	}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.clients, c)
+		s.mu.Unlock()
+	}()
+
+	var wmu sync.Mutex
+	write := func(op byte, payload []byte) error {
+		wmu.Lock()
+		defer wmu.Unlock()
+		return writeMessage(conn, op, payload)
+	}
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		readControl(rw.Reader, write)
+	}()
+
+	rate := s.MaxRate
+	if rate <= 0 {
+		rate = DefaultMaxRate
+	}
+	interval := time.Second / time.Duration(rate)
+	for {
+		select {
+		case <-closed:
+			return
+		case <-c.notify:
+		}
+		s.mu.Lock()
+		frame := s.frame
+		s.mu.Unlock()
+		if err := write(opBinary, frame); err != nil {
+			return
+		}
+		select {
+		case <-closed:
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// writeMessage writes an unfragmented, unmasked server message.
+func writeMessage(w io.Writer, op byte, payload []byte) error {
+	header := []byte{0x80 | op}
+	switch n := len(payload); {
+	case n < 126:
+		header = append(header, byte(n))
+	case n <= 0xFFFF:
+		header = binary.BigEndian.AppendUint16(append(header, 126), uint16(n))
+	default:
+		header = binary.BigEndian.AppendUint64(append(header, 127), uint64(n))
+	}
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	_, err := w.Write(payload)
+	return err
+}
+
+// maxControl bounds the messages read from browsers, which only send
+// control frames.
+const maxControl = 1 << 16
+
+// readControl reads the browser's messages until the connection closes,
+// answering pings and close requests and discarding everything else.
+func readControl(r *bufio.Reader, write func(op byte, payload []byte) error) {
+	for {
+		var h [2]byte
+		if _, err := io.ReadFull(r, h[:]); err != nil {
+			return
+		}
+		op, n := h[0]&0x0F, uint64(h[1]&0x7F)
+		switch n {
+		case 126:
+			var b [2]byte
+			if _, err := io.ReadFull(r, b[:]); err != nil {
+				return
+			}
+			n = uint64(binary.BigEndian.Uint16(b[:]))
+		case 127:
+			var b [8]byte
+			if _, err := io.ReadFull(r, b[:]); err != nil {
+				return
+			}
+			n = binary.BigEndian.Uint64(b[:])
+		}
+		if n > maxControl {
+			return
+		}
+		var mask [4]byte
+		if h[1]&0x80 != 0 {
+			if _, err := io.ReadFull(r, mask[:]); err != nil {
+				return
+			}
+		}
+		payload := make([]byte, n)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return
+		}
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+		switch op {
+		case opClose:
+			write(opClose, payload)
+			return
+		case opPing:
+			if write(opPong, payload) != nil {
+				return
+			}
+		}
+	}
+}
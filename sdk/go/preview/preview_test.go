@@ -0,0 +1,132 @@
+package preview
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/motion"
+)
+
+type nopOutput struct{ frames int }
+
+func (o *nopOutput) Ready() (bool, error)                 { return true, nil }
+func (o *nopOutput) WriteFrame(int, []helios.Point) error { o.frames++; return nil }
+func (o *nopOutput) Stop() error                          { return nil }
+func (o *nopOutput) Close() error                         { return nil }
+
+// dial opens a WebSocket to the server's frame stream.
+func dial(t *testing.T, srv *httptest.Server) (net.Conn, *bufio.Reader) {
+	t.Helper()
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	io.WriteString(conn, "GET /frames HTTP/1.1\r\nHost: x\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n"+
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n\r\n")
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The accept key for the RFC 6455 sample nonce.
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Sec-WebSocket-Accept") != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("handshake: %s %v", resp.Status, resp.Header)
+	}
+	return conn, br
+}
+
+func readMessage(t *testing.T, conn net.Conn, br *bufio.Reader) (byte, []byte) {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var h [2]byte
+	if _, err := io.ReadFull(br, h[:]); err != nil {
+		t.Fatal(err)
+	}
+	n := int(h[1] & 0x7F)
+	if n == 126 {
+		var b [2]byte
+		io.ReadFull(br, b[:])
+		n = int(binary.BigEndian.Uint16(b[:]))
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(br, payload); err != nil {
+		t.Fatal(err)
+	}
+	return h[0] & 0x0F, payload
+}
+
+func TestStreamsLatestFrame(t *testing.T) {
+	s := NewServer()
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+	defer s.Close()
+
+	s.Publish(20000, []helios.Point{{X: 1}})
+	out := &nopOutput{}
+	wrapped := s.Wrap(out)
+	conn, br := dial(t, srv)
+
+	op, msg := readMessage(t, conn, br)
+	if op != opBinary || len(msg) != 12 || binary.LittleEndian.Uint32(msg) != 20000 {
+		t.Fatalf("first message op %d: % x", op, msg)
+	}
+
+	wrapped.WriteFrame(30000, []helios.Point{{X: 4095, Y: 2, R: 255, I: 255}, {X: 3}})
+	if out.frames != 1 {
+		t.Fatal("frame not passed to the output")
+	}
+	_, msg = readMessage(t, conn, br)
+	want := []byte{0x30, 0x75, 0, 0, 0xFF, 0x0F, 2, 0, 255, 0, 0, 255, 3, 0, 0, 0, 0, 0, 0, 0}
+	if string(msg) != string(want) {
+		t.Fatalf("message = % x, want % x", msg, want)
+	}
+
+	// A masked ping is answered with a pong carrying the same payload.
+	conn.Write([]byte{0x80 | opPing, 0x80 | 2, 1, 2, 3, 4, 'h' ^ 1, 'i' ^ 2})
+	if op, msg := readMessage(t, conn, br); op != opPong || string(msg) != "hi" {
+		t.Fatalf("got op %d %q, want pong", op, msg)
+	}
+}
+
+func TestViewerAndBadUpgrade(t *testing.T) {
+	s := NewServer()
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "<canvas") {
+		t.Fatalf("viewer: %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/frames", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("plain GET /frames: %d, want 400", rec.Code)
+	}
+}
+
+func TestPublishSimulatesResponse(t *testing.T) {
+	s := NewServer()
+	s.Response = &motion.Response{Profile: motion.DefaultProfile}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+	defer s.Close()
+
+	// A jump across the field: the mirrors lag behind it.
+	frame := []helios.Point{{X: 0}, {X: 4095}, {X: 4095}}
+	s.Publish(30000, frame)
+	conn, br := dial(t, srv)
+	_, msg := readMessage(t, conn, br)
+	if x := binary.LittleEndian.Uint16(msg[4+8:]); x >= 4095 {
+		t.Fatalf("point after the jump at %d, want lagging", x)
+	}
+	if frame[1].X != 4095 {
+		t.Fatal("Publish modified the caller's frame")
+	}
+}
@@ -0,0 +1,55 @@
+package preview
+
+// viewer is the page served at /. It draws lit segments in their color on
+// a black square, with y up as on the projection surface.
+const viewer = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Laser preview</title>
+<style>
+body { margin: 0; background: #111; color: #888; font: 12px sans-serif; }
+canvas { display: block; margin: 0 auto; background: #000; width: min(100vw, 100vh - 20px); height: min(100vw, 100vh - 20px); }
+#info { text-align: center; line-height: 20px; }
+</style>
+</head>
+<body>
+<canvas id="c" width="1024" height="1024"></canvas>
+<div id="info">connecting</div>
+<script>
+const canvas = document.getElementById("c"), ctx = canvas.getContext("2d"), info = document.getElementById("info");
+const scale = canvas.width / 4096;
+function draw(buf) {
+	const d = new DataView(buf), pps = d.getUint32(0, true), n = (buf.byteLength - 4) / 8;
+	ctx.fillStyle = "#000";
+	ctx.fillRect(0, 0, canvas.width, canvas.height);
+	ctx.lineWidth = 2;
+	ctx.lineCap = "round";
+	let px = 0, py = 0;
+	for (let i = 0; i < n; i++) {
+		const o = 4 + i * 8;
+		const x = d.getUint16(o, true) * scale, y = canvas.height - d.getUint16(o + 2, true) * scale;
+		const r = d.getUint8(o + 4), g = d.getUint8(o + 5), b = d.getUint8(o + 6), a = d.getUint8(o + 7) / 255;
+		if (i > 0 && (r || g || b) && a > 0) {
+			ctx.strokeStyle = "rgba(" + r + "," + g + "," + b + "," + a + ")";
+			ctx.beginPath();
+			ctx.moveTo(px, py);
+			ctx.lineTo(x, y);
+			ctx.stroke();
+		}
+		px = x;
+		py = y;
+	}
+	info.textContent = n + " points at " + pps + " pps";
+}
+function connect() {
+	const ws = new WebSocket(new URL("frames", location.href).href.replace(/^http/, "ws"));
+	ws.binaryType = "arraybuffer";
+	ws.onmessage = e => draw(e.data);
+	ws.onclose = () => { info.textContent = "disconnected"; setTimeout(connect, 1000); };
+}
+connect();
+</script>
+</body>
+</html>
+`
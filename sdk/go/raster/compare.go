@@ -0,0 +1,45 @@
+package raster
+
+import (
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"io"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+// dividerColor separates the halves of a Compare image.
+var dividerColor = color.RGBA{R: 96, G: 96, B: 96, A: 255}
+
+// Compare draws two versions of a frame side by side with Inspect, such as
+// a frame before and after optimization, so the effect of each processing
+// stage can be judged. The image is 2*size+1 pixels wide: before on the
+// left, after on the right, separated by a gray line.
+func Compare(before, after []helios.Point, size int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 2*size+1, size))
+	draw.Draw(img, image.Rect(0, 0, size, size), Inspect(before, size), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(size, 0, size+1, size), image.NewUniform(dividerColor), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(size+1, 0, 2*size+1, size), Inspect(after, size), image.Point{}, draw.Src)
+	return img
+}
+
+// CompareGIF writes a looping GIF toggling between Inspect images of two
+// versions of a frame every interval, for spotting differences that are
+// hard to see side by side.
+func CompareGIF(w io.Writer, before, after []helios.Point, size int, interval time.Duration) error {
+	// GIF delays are in hundredths of a second.
+	delay := max(int(interval/(10*time.Millisecond)), 2)
+	anim := &gif.GIF{}
+	for _, frame := range [][]helios.Point{before, after} {
+		src := Inspect(frame, size)
+		dst := image.NewPaletted(src.Bounds(), palette.Plan9)
+		draw.Draw(dst, dst.Bounds(), src, image.Point{}, draw.Src)
+		anim.Image = append(anim.Image, dst)
+		anim.Delay = append(anim.Delay, delay)
+	}
+	return gif.EncodeAll(w, anim)
+}
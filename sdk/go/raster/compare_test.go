@@ -0,0 +1,46 @@
+package raster
+
+import (
+	"bytes"
+	"image/color"
+	"image/gif"
+	"testing"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+func TestCompare(t *testing.T) {
+	// After processing the line gets a dwell at its end and a blanked
+	// return to the start.
+	before := []helios.Point{{X: 0, Y: 0, R: 255, I: 255}, {X: 4095, Y: 0, R: 255, I: 255}}
+	after := append(append([]helios.Point(nil), before...), before[1], helios.Point{X: 0, Y: 4095})
+	img := Compare(before, after, 64)
+	if img.Bounds().Dx() != 129 || img.Bounds().Dy() != 64 {
+		t.Fatalf("bounds = %v", img.Bounds())
+	}
+
+	for _, tt := range []struct {
+		x, y int
+		want color.RGBA
+	}{
+		{32, 63, color.RGBA{R: 255, A: 255}},                    // before: the line
+		{32, 32, color.RGBA{A: 255}},                            // before: nothing blanked
+		{64, 10, dividerColor},                                  // divider
+		{65 + 32, 32, blankedColor},                             // after: the blanked return
+		{65 + 63, 63, color.RGBA{R: 255, G: 80, B: 80, A: 255}}, // after: two dots where it dwells
+	} {
+		if got := img.RGBAAt(tt.x, tt.y); got != tt.want {
+			t.Errorf("pixel (%d, %d) = %v, want %v", tt.x, tt.y, got, tt.want)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := CompareGIF(&buf, before, after, 32, 500*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	anim, err := gif.DecodeAll(&buf)
+	if err != nil || len(anim.Image) != 2 || anim.Delay[0] != 50 {
+		t.Fatalf("GIF: %v, %d frames", err, len(anim.Image))
+	}
+}
@@ -0,0 +1,138 @@
+// Package raster renders laser frames to images, approximating what the
+// projected beam looks like: lit segments between consecutive points are
+// drawn with additive blending on black, and blanked moves are invisible.
+//
+// It is used for thumbnails and previews of content, so it favors speed and
+// simplicity over physical accuracy; scanner inertia and beam width are not
+// modeled.
+package raster
+
+import (
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"io"
+	"math"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+// fullScale is the width of the helios.Point coordinate space.
+const fullScale = 4096
+
+// Render draws a frame into a square image of size by size pixels. The
+// projection's bottom left corner is the image's bottom left.
+func Render(frame []helios.Point, size int) *image.RGBA {
+	return render(frame, size, false)
+}
+
+// Inspect draws a frame like Render, and also shows what the beam does
+// while it is off: blanked moves are drawn in dark gray and every point is
+// marked with a dim dot, so repeated points (dwell) show up brighter. It is
+// meant for judging how processing changes a frame.
+func Inspect(frame []helios.Point, size int) *image.RGBA {
+	return render(frame, size, true)
+}
+
+// Colors used by Inspect.
+var (
+	blankedColor = color.RGBA{R: 48, G: 48, B: 48, A: 255}
+	pointColor   = color.RGBA{R: 40, G: 40, B: 40, A: 255}
+)
+
+func render(frame []helios.Point, size int, inspect bool) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.Black), image.Point{}, draw.Src)
+	if len(frame) == 0 {
+		return img
+	}
+	px := func(p helios.Point) (float64, float64) {
+		s := float64(size-1) / (fullScale - 1)
+		return float64(p.X) * s, float64(size-1) - float64(p.Y)*s
+	}
+	x0, y0 := px(frame[0])
+	for i, p := range frame {
+		x1, y1 := px(p)
+		c, lit := beam(p)
+		if !lit && inspect {
+			c, lit = blankedColor, true
+		}
+		if lit {
+			if i == 0 {
+				plot(img, x1, y1, c)
+			} else {
+				line(img, x0, y0, x1, y1, c)
+			}
+		}
+		if inspect {
+			plot(img, x1, y1, pointColor)
+		}
+		x0, y0 = x1, y1
+	}
+	return img
+}
+
+// beam returns the color of the segment ending at p, scaled by intensity.
+func beam(p helios.Point) (color.RGBA, bool) {
+	if p.I == 0 || p.R == 0 && p.G == 0 && p.B == 0 {
+		return color.RGBA{}, false
+	}
+	s := uint16(p.I)
+	return color.RGBA{
+		R: uint8(uint16(p.R) * s / 255),
+		G: uint8(uint16(p.G) * s / 255),
+		B: uint8(uint16(p.B) * s / 255),
+		A: 255,
+	}, true
+}
+
+// line draws a segment, plotting each pixel once.
+func line(img *image.RGBA, x0, y0, x1, y1 float64, c color.RGBA) {
+	n := int(math.Ceil(max(math.Abs(x1-x0), math.Abs(y1-y0))))
+	for i := 1; i <= n; i++ {
+		t := float64(i) / float64(n)
+		plot(img, x0+(x1-x0)*t, y0+(y1-y0)*t, c)
+	}
+	if n == 0 {
+		plot(img, x1, y1, c)
+	}
+}
+
+// plot adds c to the pixel at (x, y), saturating.
+func plot(img *image.RGBA, x, y float64, c color.RGBA) {
+	i := img.PixOffset(int(math.Round(x)), int(math.Round(y)))
+	if i < 0 || i+3 >= len(img.Pix) {
+		return
+	}
+	p := img.Pix[i : i+3 : i+3]
+	p[0] = uint8(min(int(p[0])+int(c.R), 255))
+	p[1] = uint8(min(int(p[1])+int(c.G), 255))
+	p[2] = uint8(min(int(p[2])+int(c.B), 255))
+}
+
+// PNG renders a frame and encodes it as PNG.
+func PNG(w io.Writer, frame []helios.Point, size int) error {
+	return png.Encode(w, Render(frame, size))
+}
+
+// GIF renders an animation as a looping GIF played at fps frames per second.
+// At most maxFrames frames, evenly spaced over the animation, are included
+// to keep previews small; the frame delay is stretched to match, so the
+// preview lasts as long as the animation.
+func GIF(w io.Writer, frames [][]helios.Point, size int, fps float64, maxFrames int) error {
+	n := min(len(frames), max(maxFrames, 1))
+	anim := &gif.GIF{}
+	// GIF delays are in hundredths of a second.
+	delay := max(int(math.Round(float64(len(frames))/float64(n)/fps*100)), 2)
+	for i := range n {
+		src := Render(frames[i*len(frames)/n], size)
+		dst := image.NewPaletted(src.Bounds(), palette.Plan9)
+		draw.Draw(dst, dst.Bounds(), src, image.Point{}, draw.Src)
+		anim.Image = append(anim.Image, dst)
+		anim.Delay = append(anim.Delay, delay)
+	}
+	return gif.EncodeAll(w, anim)
+}
@@ -0,0 +1,67 @@
+package raster
+
+import (
+	"bytes"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"testing"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+func TestRender(t *testing.T) {
+	// A blanked move to the bottom left, then a red line to the bottom right
+	// and a dimmed green line up to the top right.
+	frame := []helios.Point{
+		{X: 2048, Y: 2048},
+		{X: 0, Y: 0},
+		{X: 4095, Y: 0, R: 255, I: 255},
+		{X: 4095, Y: 4095, G: 255, I: 128},
+	}
+	img := Render(frame, 64)
+
+	for _, tt := range []struct {
+		x, y int
+		want color.RGBA
+	}{
+		{32, 32, color.RGBA{A: 255}},         // blanked move
+		{32, 63, color.RGBA{R: 255, A: 255}}, // bottom edge
+		{63, 10, color.RGBA{G: 128, A: 255}}, // right edge
+		// Segments do not redraw their start, so the corner is not brighter.
+		{63, 63, color.RGBA{R: 255, A: 255}},
+	} {
+		if got := img.RGBAAt(tt.x, tt.y); got != tt.want {
+			t.Errorf("pixel (%d, %d) = %v, want %v", tt.x, tt.y, got, tt.want)
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	frames := [][]helios.Point{
+		{{X: 0, Y: 0}, {X: 4095, Y: 4095, R: 255, G: 255, B: 255, I: 255}},
+		{{X: 0, Y: 4095}, {X: 4095, Y: 0, R: 255, G: 255, B: 255, I: 255}},
+		{{X: 0, Y: 2048}, {X: 4095, Y: 2048, R: 255, G: 255, B: 255, I: 255}},
+		{{X: 2048, Y: 0}, {X: 2048, Y: 4095, R: 255, G: 255, B: 255, I: 255}},
+	}
+	var buf bytes.Buffer
+	if err := PNG(&buf, frames[0], 32); err != nil {
+		t.Fatal(err)
+	}
+	if img, err := png.Decode(&buf); err != nil || img.Bounds().Dx() != 32 {
+		t.Fatalf("PNG decode: %v", err)
+	}
+
+	buf.Reset()
+	if err := GIF(&buf, frames, 32, 25, 2); err != nil {
+		t.Fatal(err)
+	}
+	anim, err := gif.DecodeAll(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Two of four frames at 25 fps: each shown for 80ms.
+	if len(anim.Image) != 2 || anim.Delay[0] != 8 {
+		t.Fatalf("GIF has %d frames, delay %d", len(anim.Image), anim.Delay[0])
+	}
+}
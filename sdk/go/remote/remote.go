@@ -0,0 +1,240 @@
+// Package remote lets an application drive DACs attached to another
+// machine.
+//
+// A daemon on the machine with USB access serves its DACs with Handler;
+// the rendering application talks to it through a Client, which has the
+// same methods as *helios.DAC, so the two can run on different machines or
+// be restarted independently:
+//
+//	// On the DAC host:
+//	dac := helios.NewDAC()
+//	dac.OpenDevices()
+//	http.ListenAndServe(":7300", remote.Handler(dac))
+//
+//	// In the application:
+//	dac := remote.NewClient("http://laserhost:7300", nil)
+//	dac.WriteFrame(0, 30000, helios.FlagsDefault, points)
+//
+// The API is plain HTTP:
+//
+//	GET  /devices                          {"count": n}
+//	GET  /devices/{i}/status               {"result": status}
+//	POST /devices/{i}/frame?pps=&flags=    {"result": code}, body: points
+//	POST /devices/{i}/stop                 {"result": code}
+//
+// Frame bodies are the points back to back, 8 bytes each: X and Y as
+// little-endian uint16, then R, G, B and I.
+package remote
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+// Backend is the part of *helios.DAC served by Handler.
+type Backend interface {
+	NumDevices() int
+	GetStatus(deviceIndex int) int
+	WriteFrame(deviceIndex int, pps int, flags int, points []helios.Point) int
+	Stop(deviceIndex int) int
+}
+
+var (
+	_ Backend = (*helios.DAC)(nil)
+	_ Backend = (*Client)(nil)
+)
+
+// pointLen is the encoded size of a point.
+const pointLen = 8
+
+// maxFrame limits the size of frame bodies, well above any device limit so
+// oversized frames reach the DAC and fail there as they would locally.
+const maxFrame = 1 << 20 * pointLen
+
+// AppendPoints appends the wire encoding of points to b.
+func AppendPoints(b []byte, points []helios.Point) []byte {
+	for _, p := range points {
+		b = binary.LittleEndian.AppendUint16(b, p.X)
+		b = binary.LittleEndian.AppendUint16(b, p.Y)
+		b = append(b, p.R, p.G, p.B, p.I)
+	}
+	return b
+}
+
+// ParsePoints decodes points encoded by AppendPoints.
+func ParsePoints(b []byte) ([]helios.Point, error) {
+	if len(b)%pointLen != 0 {
+		return nil, fmt.Errorf("remote: frame of %d bytes is not a whole number of points", len(b))
+	}
+	points := make([]helios.Point, len(b)/pointLen)
+	for i := range points {
+		d := b[i*pointLen:]
+		points[i] = helios.Point{
+			X: binary.LittleEndian.Uint16(d),
+			Y: binary.LittleEndian.Uint16(d[2:]),
+			R: d[4], G: d[5], B: d[6], I: d[7],
+		}
+	}
+	return points, nil
+}
+
+// result is the response of the device calls.
+type result struct {
+	Result int `json:"result"`
+}
+
+// Handler serves dac over HTTP. Result codes, including errors, are
+// returned as they are with status 200; only malformed requests fail.
+func Handler(dac Backend) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /devices", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]int{"count": dac.NumDevices()})
+	})
+	mux.HandleFunc("GET /devices/{i}/status", withDevice(func(w http.ResponseWriter, r *http.Request, i int) {
+		writeJSON(w, http.StatusOK, result{dac.GetStatus(i)})
+	}))
+	mux.HandleFunc("POST /devices/{i}/frame", withDevice(func(w http.ResponseWriter, r *http.Request, i int) {
+		q := r.URL.Query()
+		pps, err := strconv.Atoi(q.Get("pps"))
+		if err != nil {
+			http.Error(w, "invalid pps", http.StatusBadRequest)
+			return
+		}
+		flags, err := strconv.Atoi(q.Get("flags"))
+		if err != nil && q.Has("flags") {
+			http.Error(w, "invalid flags", http.StatusBadRequest)
+			return
+		}
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxFrame))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		points, err := ParsePoints(body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, http.StatusOK, result{dac.WriteFrame(i, pps, flags, points)})
+	}))
+	mux.HandleFunc("POST /devices/{i}/stop", withDevice(func(w http.ResponseWriter, r *http.Request, i int) {
+		writeJSON(w, http.StatusOK, result{dac.Stop(i)})
+	}))
+	return mux
+}
+
+func withDevice(h func(http.ResponseWriter, *http.Request, int)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		i, err := strconv.Atoi(r.PathValue("i"))
+		if err != nil || i < 0 {
+			http.Error(w, "invalid device index", http.StatusBadRequest)
+			return
+		}
+		h(w, r, i)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// Client drives the DACs served by a remote Handler. Its methods return
+// the remote result codes; when the server cannot be reached they return
+// helios.ErrNetwork and report the cause to OnError. It is safe for
+// concurrent use.
+type Client struct {
+	// OnError, if set, is called with transport errors.
+	OnError func(error)
+
+	base string
+	hc   *http.Client
+}
+
+// NewClient returns a client for the server at baseURL, e.g.
+// "http://laserhost:7300". A nil hc uses http.DefaultClient; set a timeout
+// on it so a lost server cannot stall the render loop.
+func NewClient(baseURL string, hc *http.Client) *Client {
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	return &Client{base: baseURL, hc: hc}
+}
+
+// NumDevices returns the number of devices open on the server, or 0 if it
+// cannot be reached.
+func (c *Client) NumDevices() int {
+	var v struct {
+		Count int `json:"count"`
+	}
+	if err := c.do(http.MethodGet, "/devices", nil, &v); err != nil {
+		return 0
+	}
+	return v.Count
+}
+
+// GetStatus returns the status of a remote device: 1 if it is ready for the
+// next frame.
+func (c *Client) GetStatus(deviceIndex int) int {
+	return c.call(http.MethodGet, fmt.Sprintf("/devices/%d/status", deviceIndex), nil)
+}
+
+// Status returns the typed status of a remote device. Transport failures
+// are reported as helios.StatusReconnecting.
+func (c *Client) Status(deviceIndex int) helios.DeviceStatus {
+	return helios.StatusFromCode(c.GetStatus(deviceIndex))
+}
+
+// WriteFrame sends a frame to a remote device.
+func (c *Client) WriteFrame(deviceIndex int, pps int, flags int, points []helios.Point) int {
+	path := fmt.Sprintf("/devices/%d/frame?pps=%d&flags=%d", deviceIndex, pps, flags)
+	return c.call(http.MethodPost, path, AppendPoints(make([]byte, 0, len(points)*pointLen), points))
+}
+
+// Stop stops output of a remote device.
+func (c *Client) Stop(deviceIndex int) int {
+	return c.call(http.MethodPost, fmt.Sprintf("/devices/%d/stop", deviceIndex), nil)
+}
+
+func (c *Client) call(method, path string, body []byte) int {
+	var v result
+	if err := c.do(method, path, body, &v); err != nil {
+		return int(helios.ErrNetwork)
+	}
+	return v.Result
+}
+
+func (c *Client) do(method, path string, body []byte, v any) error {
+	err := func() error {
+		req, err := http.NewRequestWithContext(context.Background(), method, c.base+path, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		resp, err := c.hc.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+			return fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(msg))
+		}
+		return json.NewDecoder(resp.Body).Decode(v)
+	}()
+	if err != nil {
+		err = fmt.Errorf("remote: %s %s: %w", method, path, err)
+		if c.OnError != nil {
+			c.OnError(err)
+		}
+	}
+	return err
+}
@@ -0,0 +1,107 @@
+package remote
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+type fakeDAC struct {
+	frames [][]helios.Point
+	pps    []int
+	flags  []int
+	stops  int
+}
+
+func (f *fakeDAC) NumDevices() int { return 2 }
+
+func (f *fakeDAC) GetStatus(i int) int {
+	if i >= 2 {
+		return int(helios.ErrInvalidDevNum)
+	}
+	return 1
+}
+
+func (f *fakeDAC) WriteFrame(i, pps, flags int, points []helios.Point) int {
+	if len(points) > 0xFFF {
+		return int(helios.ErrTooManyPoints)
+	}
+	f.frames, f.pps, f.flags = append(f.frames, points), append(f.pps, pps), append(f.flags, flags)
+	return helios.Success
+}
+
+func (f *fakeDAC) Stop(i int) int {
+	f.stops++
+	return helios.Success
+}
+
+func TestClientServer(t *testing.T) {
+	dac := &fakeDAC{}
+	srv := httptest.NewServer(Handler(dac))
+	defer srv.Close()
+	c := NewClient(srv.URL, nil)
+
+	if n := c.NumDevices(); n != 2 {
+		t.Fatalf("NumDevices = %d", n)
+	}
+	if s := c.GetStatus(0); s != 1 {
+		t.Fatalf("GetStatus = %d", s)
+	}
+	if s := c.GetStatus(5); s != int(helios.ErrInvalidDevNum) {
+		t.Fatalf("GetStatus(5) = %d, want the remote error", s)
+	}
+
+	frame := []helios.Point{{X: 4095, Y: 1, R: 2, G: 3, B: 4, I: 255}, {X: 7}}
+	if r := c.WriteFrame(1, 30000, helios.FlagSingleMode, frame); r != helios.Success {
+		t.Fatalf("WriteFrame = %d", r)
+	}
+	if len(dac.frames) != 1 || !slices.Equal(dac.frames[0], frame) || dac.pps[0] != 30000 || dac.flags[0] != helios.FlagSingleMode {
+		t.Fatalf("server got %+v at %v pps, flags %v", dac.frames, dac.pps, dac.flags)
+	}
+	if r := c.WriteFrame(0, 30000, 0, make([]helios.Point, 0x1000)); r != int(helios.ErrTooManyPoints) {
+		t.Fatalf("oversized WriteFrame = %d", r)
+	}
+	if r := c.Stop(0); r != helios.Success || dac.stops != 1 {
+		t.Fatalf("Stop = %d after %d stops", r, dac.stops)
+	}
+}
+
+func TestClientUnreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	c := NewClient(srv.URL, nil)
+	var errs []error
+	c.OnError = func(err error) { errs = append(errs, err) }
+	if r := c.WriteFrame(0, 30000, 0, []helios.Point{{}}); r != int(helios.ErrNetwork) {
+		t.Fatalf("WriteFrame = %d, want ErrNetwork", r)
+	}
+	if len(errs) != 1 || !strings.HasPrefix(errs[0].Error(), "remote: POST") {
+		t.Fatalf("errors = %v", errs)
+	}
+	if s := c.Status(0); s.Kind != helios.StatusReconnecting {
+		t.Fatalf("Status = %v, want reconnecting", s)
+	}
+}
+
+func TestHandlerRejectsMalformed(t *testing.T) {
+	h := Handler(&fakeDAC{})
+	for _, tt := range []struct {
+		path, body string
+		want       int
+	}{
+		{"/devices/x/stop", "", http.StatusBadRequest},
+		{"/devices/0/frame", "", http.StatusBadRequest},
+		{"/devices/0/frame?pps=30000", "abc", http.StatusBadRequest},
+		{"/devices/0/frame?pps=30000&flags=z", "", http.StatusBadRequest},
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body)))
+		if rec.Code != tt.want {
+			t.Errorf("POST %s: %d, want %d", tt.path, rec.Code, tt.want)
+		}
+	}
+}
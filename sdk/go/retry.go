@@ -0,0 +1,83 @@
+package helios
+
+import (
+	"errors"
+	"time"
+)
+
+// libusb error codes that clear up on their own, as returned by the C++
+// SDK offset by ErrLibusbBase.
+var transientLibusb = map[int]bool{
+	-1:  true, // LIBUSB_ERROR_IO
+	-4:  true, // LIBUSB_ERROR_NO_DEVICE, until the device is reopened
+	-6:  true, // LIBUSB_ERROR_BUSY
+	-7:  true, // LIBUSB_ERROR_TIMEOUT
+	-8:  true, // LIBUSB_ERROR_OVERFLOW
+	-9:  true, // LIBUSB_ERROR_PIPE
+	-10: true, // LIBUSB_ERROR_INTERRUPTED
+}
+
+// IsTransient reports whether err is a write error worth retrying: a
+// transfer or network failure, a device not yet ready or closed after
+// dropping off the bus, as opposed to a frame the device will never
+// accept.
+func IsTransient(err error) bool {
+	var e Error
+	if !errors.As(err, &e) {
+		return false
+	}
+	switch e {
+	case ErrDeviceFrameReady, ErrSendControl, ErrDeviceResult, ErrNetwork, ErrDeviceClosed:
+		return true
+	}
+	if e <= ErrLibusbBase && e > ErrLibusbBase-100 {
+		return transientLibusb[int(e-ErrLibusbBase)]
+	}
+	return false
+}
+
+// RetryPolicy is how a DeviceManager handles failing writes.
+type RetryPolicy struct {
+	// MaxAttempts is how many times a frame is written before it is given
+	// up, counting the first. Zero means 1. Only transient errors are
+	// retried; see IsTransient.
+	MaxAttempts int
+
+	// Backoff is the wait before the first retry, doubling for each
+	// retry after it up to MaxBackoff (unlimited if zero).
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+
+	// Reopen is how often devices are rescanned while one reports it is
+	// closed, which reopens it in place once it is back on the bus. Zero
+	// never rescans.
+	Reopen time.Duration
+
+	// OnPermanentFailure, if set, is called from the device's goroutine
+	// when a frame is given up.
+	OnPermanentFailure func(deviceIndex int, err error)
+}
+
+// DefaultRetryPolicy retries a frame twice within a few milliseconds and
+// looks for a dropped device twice a second.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	Backoff:     2 * time.Millisecond,
+	MaxBackoff:  50 * time.Millisecond,
+	Reopen:      500 * time.Millisecond,
+}
+
+// backoff returns the wait before retry n, from 1.
+func (p RetryPolicy) backoff(n int) time.Duration {
+	d := p.Backoff
+	for range n - 1 {
+		if p.MaxBackoff > 0 && d >= p.MaxBackoff {
+			break
+		}
+		d *= 2
+	}
+	if p.MaxBackoff > 0 {
+		d = min(d, p.MaxBackoff)
+	}
+	return d
+}
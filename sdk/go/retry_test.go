@@ -0,0 +1,36 @@
+package helios
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestIsTransient(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want bool
+	}{
+		{ErrLibusbBase - 6, true},
+		{ErrLibusbBase - 4, true},
+		{ErrLibusbBase - 3, false},
+		{ErrNetwork, true},
+		{fmt.Errorf("write: %w", ErrDeviceFrameReady), true},
+		{ErrTooManyPoints, false},
+		{ErrPpsTooHigh, false},
+		{nil, false},
+	} {
+		if got := IsTransient(tc.err); got != tc.want {
+			t.Errorf("IsTransient(%v) = %v, want %v", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	p := RetryPolicy{Backoff: 2 * time.Millisecond, MaxBackoff: 10 * time.Millisecond}
+	for n, want := range []time.Duration{2, 4, 8, 10, 10} {
+		if got := p.backoff(n + 1); got != want*time.Millisecond {
+			t.Errorf("backoff(%d) = %v, want %v", n+1, got, want*time.Millisecond)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package helios
+
+import "time"
+
+// The scan-fail interlock mirrors the hardware safeguard that blanks a
+// projector whose galvos have stopped moving: a lit beam held on one spot
+// concentrates its power there. Frames written by a DeviceManager are
+// checked before they are sent, and a frame that would hold the lit beam
+// within a tiny window for too long is written blanked instead.
+
+// ScanFailSettings configures the scan-fail interlock.
+type ScanFailSettings struct {
+	// Window is the size, in device units, of the square the lit beam
+	// must leave. A negative Window is taken as zero.
+	Window int
+
+	// Duration is how long the lit beam may stay within Window. Zero
+	// disables the interlock.
+	Duration time.Duration
+}
+
+// DefaultScanFail trips when the lit beam stays within about 1% of the
+// scan range for 100ms.
+var DefaultScanFail = ScanFailSettings{Window: 40, Duration: 100 * time.Millisecond}
+
+// ScanFailEvent reports that the interlock started or stopped blanking a
+// device.
+type ScanFailEvent struct {
+	// Tripped is true when blanking starts and false when a frame passes
+	// the check again.
+	Tripped bool
+
+	// Hold is how long the frame that changed the state holds the lit
+	// beam within the window, counting earlier static frames.
+	Hold time.Duration
+
+	// Static reports that every lit point of the frame is within the
+	// window.
+	Static bool
+}
+
+// ScanFailInterlock applies the interlock to the frames of one output for
+// writers other than DeviceManager and Swapchain, such as stream.Streamer.
+// The zero ScanFailInterlock is ready to use. It is not safe for
+// concurrent use.
+type ScanFailInterlock struct {
+	state scanFail
+}
+
+// Check checks a frame about to be written at pps with flags, returning it
+// blanked if the interlock trips, the event, and whether the interlock
+// started or stopped blanking. A zero cfg.Duration passes every frame. The
+// caller's slice is not modified.
+func (l *ScanFailInterlock) Check(cfg ScanFailSettings, points []Point, pps int, flags int) ([]Point, ScanFailEvent, bool) {
+	if cfg.Duration <= 0 {
+		return points, ScanFailEvent{}, false
+	}
+	ev, changed := l.state.check(cfg, points, pps, flags, time.Now())
+	if ev.Tripped {
+		points = blanked(points)
+	}
+	return points, ev, changed
+}
+
+// scanFail is the interlock state of one device.
+type scanFail struct {
+	// since is when the current run of static frames started.
+	since   time.Time
+	tripped bool
+}
+
+// check reports whether a frame written at now must be blanked. A static
+// frame written to loop trips at once, as it holds the beam until the
+// next frame arrives however long that takes.
+func (s *scanFail) check(cfg ScanFailSettings, points []Point, pps int, flags int, now time.Time) (ScanFailEvent, bool) {
+	run, static := longestStill(points, max(cfg.Window, 0))
+	if pps <= 0 {
+		pps = 1
+	}
+	hold := time.Duration(run) * time.Second / time.Duration(pps)
+	ev := ScanFailEvent{Hold: hold, Static: static}
+	if !static {
+		s.since = time.Time{}
+	} else {
+		if s.since.IsZero() {
+			s.since = now
+		}
+		ev.Hold += now.Sub(s.since)
+	}
+	ev.Tripped = ev.Hold > cfg.Duration || static && flags&FlagSingleMode == 0
+	changed := ev.Tripped != s.tripped
+	s.tripped = ev.Tripped
+	return ev, changed
+}
+
+// longestStill returns the longest run of consecutive lit points that fits
+// in a window×window square, and whether all lit points fit in one (false
+// if none are lit).
+func longestStill(points []Point, window int) (int, bool) {
+	// Monotonic deques of indices give the extremes of the current run.
+	var minX, maxX, minY, maxY []int
+	push := func(q []int, i int, worse func(a, b uint16) bool, v func(Point) uint16) []int {
+		for len(q) > 0 && worse(v(points[q[len(q)-1]]), v(points[i])) {
+			q = q[:len(q)-1]
+		}
+		return append(q, i)
+	}
+	x := func(p Point) uint16 { return p.X }
+	y := func(p Point) uint16 { return p.Y }
+	greater := func(a, b uint16) bool { return a >= b }
+	less := func(a, b uint16) bool { return a <= b }
+
+	best, start, lit := 0, 0, 0
+	// The bounds of all lit points.
+	x0, x1, y0, y1 := maxCoord+1, -1, maxCoord+1, -1
+	for i, p := range points {
+		if !isLit(p) {
+			minX, maxX, minY, maxY = minX[:0], maxX[:0], minY[:0], maxY[:0]
+			start = i + 1
+			continue
+		}
+		lit++
+		x0, x1 = min(x0, int(p.X)), max(x1, int(p.X))
+		y0, y1 = min(y0, int(p.Y)), max(y1, int(p.Y))
+		minX, maxX = push(minX, i, greater, x), push(maxX, i, less, x)
+		minY, maxY = push(minY, i, greater, y), push(maxY, i, less, y)
+		for int(points[maxX[0]].X)-int(points[minX[0]].X) > window || int(points[maxY[0]].Y)-int(points[minY[0]].Y) > window {
+			for _, q := range []*[]int{&minX, &maxX, &minY, &maxY} {
+				if (*q)[0] == start {
+					*q = (*q)[1:]
+				}
+			}
+			start++
+		}
+		best = max(best, i-start+1)
+	}
+	return best, lit > 0 && x1-x0 <= window && y1-y0 <= window
+}
+
+// isLit reports whether any color is on, whatever the intensity, as not
+// all projectors use the intensity channel.
+func isLit(p Point) bool {
+	return p.R != 0 || p.G != 0 || p.B != 0
+}
+
+// blanked returns a copy of points with the beam off.
+func blanked(points []Point) []Point {
+	out := make([]Point, len(points))
+	for i, p := range points {
+		out[i] = Point{X: p.X, Y: p.Y}
+	}
+	return out
+}
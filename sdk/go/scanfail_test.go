@@ -0,0 +1,132 @@
+package helios
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func lit(x, y uint16) Point { return Point{X: x, Y: y, R: 255, I: 255} }
+
+func TestLongestStill(t *testing.T) {
+	line := make([]Point, 100)
+	for i := range line {
+		line[i] = lit(uint16(i*40), 2000)
+	}
+	dot := make([]Point, 100)
+	for i := range dot {
+		dot[i] = lit(2000+uint16(i%3), 2000)
+	}
+	// Two dwells of 30 and 50 lit points, apart and separated by blanking.
+	dwells := append(append(make([]Point, 0, 90), dot[:30]...), Point{X: 2000, Y: 2000})
+	for range 50 {
+		dwells = append(dwells, lit(100, 100))
+	}
+
+	for _, tc := range []struct {
+		name   string
+		points []Point
+		run    int
+		static bool
+	}{
+		{"line", line, 2, false},
+		{"dot", dot, 100, true},
+		{"dwells", dwells, 50, false},
+		{"blank", make([]Point, 10), 0, false},
+	} {
+		run, static := longestStill(tc.points, 40)
+		if run != tc.run || static != tc.static {
+			t.Errorf("%s: longestStill = %d, %v, want %d, %v", tc.name, run, static, tc.run, tc.static)
+		}
+	}
+}
+
+func TestScanFailCheck(t *testing.T) {
+	cfg := DefaultScanFail
+	dot := make([]Point, 300)
+	for i := range dot {
+		dot[i] = lit(2000, 2000)
+	}
+	circle := make([]Point, 300)
+	for i := range circle {
+		circle[i] = lit(uint16(i*10), uint16(4000-i*10))
+	}
+	now := time.Unix(0, 0)
+
+	// 300 points at 30k pps is 10ms: static frames accumulate until the
+	// beam has been held for longer than 100ms.
+	var s scanFail
+	for i := range 20 {
+		ev, changed := s.check(cfg, dot, 30000, FlagSingleMode, now.Add(time.Duration(i)*10*time.Millisecond))
+		want := i >= 10
+		if ev.Tripped != want || changed != (i == 10) || !ev.Static {
+			t.Fatalf("frame %d: event %+v, changed %v", i, ev, changed)
+		}
+	}
+	ev, changed := s.check(cfg, circle, 30000, FlagSingleMode, now.Add(time.Second))
+	if ev.Tripped || !changed || ev.Static {
+		t.Fatalf("moving frame: event %+v, changed %v", ev, changed)
+	}
+
+	// A looped static frame holds the beam indefinitely.
+	if ev, _ := s.check(cfg, dot, 30000, 0, now); !ev.Tripped {
+		t.Fatal("looped static frame did not trip")
+	}
+
+	// A single long dwell trips within one frame.
+	var fresh scanFail
+	if ev, _ := fresh.check(cfg, append(dot, circle...), 1000, FlagSingleMode, now); !ev.Tripped || ev.Static || ev.Hold != 300*time.Millisecond {
+		t.Fatalf("long dwell: event %+v", ev)
+	}
+}
+
+func TestScanFailNegativeWindow(t *testing.T) {
+	dot := []Point{lit(2000, 2000), lit(2000, 2000), lit(2001, 2000)}
+	var s scanFail
+	ev, _ := s.check(ScanFailSettings{Window: -5, Duration: time.Millisecond}, dot, 1000, FlagSingleMode, time.Unix(0, 0))
+	if !ev.Tripped || ev.Static || ev.Hold != 2*time.Millisecond {
+		t.Fatalf("event %+v, want a 2ms hold as with a zero window", ev)
+	}
+}
+
+func TestDeviceManagerScanFail(t *testing.T) {
+	w := &fakeWriter{ready: true, frames: map[int][][]Point{}}
+	m := newDeviceManager(w, 1)
+	var mu sync.Mutex
+	var events []ScanFailEvent
+	m.OnScanFail = func(_ int, ev ScanFailEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, ev)
+	}
+	defer m.Close()
+
+	// 1000 points at 1000 pps hold the beam still for a second.
+	dot := make([]Point, 1000)
+	for i := range dot {
+		dot[i] = lit(2000, 2000)
+	}
+	if err := m.SubmitFrame(0, 1000, dot); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for len(w.written(0)) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("frame was not written")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	for _, p := range w.written(0)[0] {
+		if isLit(p) {
+			t.Fatalf("point %+v written lit", p)
+		}
+	}
+	if !isLit(dot[0]) {
+		t.Fatal("submitted frame was modified")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != 1 || !events[0].Tripped {
+		t.Fatalf("events = %+v, want one trip", events)
+	}
+}
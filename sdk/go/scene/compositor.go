@@ -0,0 +1,221 @@
+// Package scene composes multiple independently generated layers into a
+// single laser frame.
+//
+// Each Layer produces its own points for a given tick. The Compositor draws
+// the layers in z-order, applies per-layer transforms and color modulation,
+// divides the frame's point budget between layers and inserts blanked moves
+// between them so the beam never draws a visible line from one element to
+// the next.
+//
+// Layers can run at their own point rate within the frame: a layer with a
+// lower PPS than the compositor has each of its points repeated, so the beam
+// slows down for detailed graphics, and a layer with a higher PPS has points
+// dropped, so a beam effect moves faster than the output rate would allow.
+//
+// A Scheduler draws the same nodes without a fixed order, planning the
+// order and direction of the layers to keep the blanked travel between
+// them short, and spends the points saved on the layers themselves.
+package scene
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+// DefaultBlankPoints is the number of blanked points inserted at each end of
+// a jump between two layers when Compositor.BlankPoints is zero.
+const DefaultBlankPoints = 8
+
+// Layer produces the points of one show element.
+type Layer interface {
+	// Points returns the layer's points at time t. budget is the number of
+	// points allotted to the layer; implementations should not exceed it.
+	Points(t time.Duration, budget int) []helios.Point
+}
+
+// LayerFunc adapts an ordinary function to the Layer interface.
+type LayerFunc func(t time.Duration, budget int) []helios.Point
+
+// Points calls f(t, budget).
+func (f LayerFunc) Points(t time.Duration, budget int) []helios.Point {
+	return f(t, budget)
+}
+
+// Node places a Layer in the compositor.
+type Node struct {
+	Layer Layer
+
+	// Z orders layers within the frame. Lower values are drawn first.
+	Z int
+
+	// Transform is applied to every point of the layer.
+	Transform Transform
+
+	// Color scales the layer's color channels.
+	Color ColorMod
+
+	// Weight is the layer's share of the point budget relative to the
+	// other visible layers. Zero counts as 1.
+	Weight float64
+
+	// Hidden excludes the layer from the frame without removing it.
+	Hidden bool
+
+	// PPS is the layer's effective point rate. Zero means the compositor's
+	// rate. Layers are asked for points at their own rate and resampled to
+	// the compositor's by repeating or dropping points.
+	PPS int
+}
+
+// Compositor combines layers into frames.
+type Compositor struct {
+	// Budget is the total number of points per frame, including blanking.
+	Budget int
+
+	// BlankPoints is the number of blanked points inserted at each end of a
+	// jump between layers. Zero means DefaultBlankPoints.
+	BlankPoints int
+
+	// PPS is the point rate the frame is played at. Zero disables per-layer
+	// rates.
+	PPS int
+
+	nodes []*Node
+}
+
+// NewCompositor creates a compositor with the given per-frame point budget.
+func NewCompositor(budget int) *Compositor {
+	return &Compositor{Budget: budget}
+}
+
+// Add adds a node to the compositor and returns it for further adjustment.
+func (c *Compositor) Add(n *Node) *Node {
+	c.nodes = append(c.nodes, n)
+	return n
+}
+
+// Remove removes a node previously added with Add.
+func (c *Compositor) Remove(n *Node) {
+	for i, m := range c.nodes {
+		if m == n {
+			c.nodes = append(c.nodes[:i], c.nodes[i+1:]...)
+			return
+		}
+	}
+}
+
+// Frame renders all visible layers at time t into a single frame.
+func (c *Compositor) Frame(t time.Duration) []helios.Point {
+	var visible []*Node
+	for _, n := range c.nodes {
+		if !n.Hidden && n.Layer != nil {
+			visible = append(visible, n)
+		}
+	}
+	if len(visible) == 0 {
+		return nil
+	}
+	sort.SliceStable(visible, func(i, j int) bool { return visible[i].Z < visible[j].Z })
+
+	blank := c.BlankPoints
+	if blank <= 0 {
+		blank = DefaultBlankPoints
+	}
+	budgets := allocate(c.Budget-2*blank*len(visible), visible)
+
+	frame := make([]helios.Point, 0, c.Budget)
+	for i, n := range visible {
+		r := ratio(c.PPS, n)
+		points := n.Layer.Points(t, int(float64(budgets[i])/r))
+		if len(points) == 0 {
+			continue
+		}
+		points = render(n, rerate(points, r))
+		frame = appendBlank(frame, points[0], blank)
+		frame = append(frame, points...)
+		frame = appendBlank(frame, points[len(points)-1], blank)
+	}
+	return frame
+}
+
+// ratio returns the number of output points at pps per point of the node's
+// layer.
+func ratio(pps int, n *Node) float64 {
+	if pps <= 0 || n.PPS <= 0 {
+		return 1
+	}
+	return float64(pps) / float64(n.PPS)
+}
+
+// rerate resamples points to ratio output points per input point, repeating
+// points when ratio is above 1 and dropping them when it is below. The last
+// point is always kept so the layer ends where it would have.
+func rerate(points []helios.Point, ratio float64) []helios.Point {
+	if ratio == 1 {
+		return points
+	}
+	n := max(1, int(math.Round(float64(len(points))*ratio)))
+	out := make([]helios.Point, n)
+	for k := range out {
+		out[k] = points[min(int(float64(k)/ratio), len(points)-1)]
+	}
+	out[n-1] = points[len(points)-1]
+	return out
+}
+
+// allocate divides budget between nodes proportionally to their weights.
+func allocate(budget int, nodes []*Node) []int {
+	budgets := make([]int, len(nodes))
+	if budget <= 0 {
+		return budgets
+	}
+	total := 0.0
+	for _, n := range nodes {
+		total += weight(n)
+	}
+	used := 0
+	for i, n := range nodes {
+		budgets[i] = int(float64(budget) * weight(n) / total)
+		used += budgets[i]
+	}
+	// Hand out the points lost to rounding, front to back.
+	for i := 0; used < budget; i = (i + 1) % len(budgets) {
+		budgets[i]++
+		used++
+	}
+	return budgets
+}
+
+func weight(n *Node) float64 {
+	if n.Weight <= 0 {
+		return 1
+	}
+	return n.Weight
+}
+
+// render applies the node's transform and color modulation to a copy of points.
+func render(n *Node, points []helios.Point) []helios.Point {
+	out := make([]helios.Point, len(points))
+	for i, p := range points {
+		if !n.Transform.isIdentity() {
+			x, y := n.Transform.Apply(float64(p.X), float64(p.Y))
+			p.X, p.Y = helios.ClampToCoord(x), helios.ClampToCoord(y)
+		}
+		if !n.Color.isNone() {
+			p = n.Color.apply(p)
+		}
+		out[i] = p
+	}
+	return out
+}
+
+// appendBlank appends count blanked copies of p's position.
+func appendBlank(frame []helios.Point, p helios.Point, count int) []helios.Point {
+	for i := 0; i < count; i++ {
+		frame = append(frame, helios.Point{X: p.X, Y: p.Y})
+	}
+	return frame
+}
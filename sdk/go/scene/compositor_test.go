@@ -0,0 +1,117 @@
+package scene
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+func constLayer(p helios.Point) Layer {
+	return LayerFunc(func(t time.Duration, budget int) []helios.Point {
+		points := make([]helios.Point, budget)
+		for i := range points {
+			points[i] = p
+		}
+		return points
+	})
+}
+
+func TestCompositorBudgetAndOrder(t *testing.T) {
+	c := NewCompositor(200)
+	c.BlankPoints = 5
+	c.Add(&Node{Layer: constLayer(helios.Point{X: 100, G: 255}), Z: 1})
+	c.Add(&Node{Layer: constLayer(helios.Point{X: 200, R: 255}), Z: 0, Weight: 3})
+
+	frame := c.Frame(0)
+	if len(frame) != 200 {
+		t.Fatalf("frame has %d points, want 200", len(frame))
+	}
+	// The Z=0 layer is drawn first, after its leading blank points, and gets
+	// three quarters of the 180 drawable points.
+	if frame[5].X != 200 || frame[5].R != 255 {
+		t.Fatalf("first drawn point = %+v, want Z=0 layer", frame[5])
+	}
+	red := 0
+	for _, p := range frame {
+		if p.R == 255 {
+			red++
+		}
+	}
+	if red != 135 {
+		t.Fatalf("Z=0 layer drew %d points, want 135", red)
+	}
+}
+
+func TestCompositorTransformAndColor(t *testing.T) {
+	c := NewCompositor(20)
+	c.BlankPoints = 1
+	c.Add(&Node{
+		Layer:     constLayer(helios.Point{X: 1000, Y: 1000, R: 200, I: 255}),
+		Transform: Translate(5000, -10),
+		Color:     ColorMod{R: 0.5, G: 1, B: 1, I: 1},
+	})
+
+	p := c.Frame(0)[1]
+	if p.X != 4095 || p.Y != 990 || p.R != 100 || p.I != 255 {
+		t.Fatalf("unexpected point %+v", p)
+	}
+}
+
+func TestTransformThen(t *testing.T) {
+	tr := Rotate(math.Pi/2, 0, 0).Then(Translate(10, 0))
+	x, y := tr.Apply(1, 0)
+	if math.Abs(x-10) > 1e-9 || math.Abs(y-1) > 1e-9 {
+		t.Fatalf("got (%v, %v), want (10, 1)", x, y)
+	}
+
+	x, y = Scale(2, 2, 100, 100).Apply(150, 100)
+	if x != 200 || y != 100 {
+		t.Fatalf("got (%v, %v), want (200, 100)", x, y)
+	}
+}
+
+func TestCompositorLayerRates(t *testing.T) {
+	budgets := map[int]int{}
+	ramp := func(id int) Layer {
+		return LayerFunc(func(t time.Duration, budget int) []helios.Point {
+			budgets[id] = budget
+			points := make([]helios.Point, budget)
+			for i := range points {
+				points[i] = helios.Point{X: uint16(id*1000 + i), G: 255}
+			}
+			return points
+		})
+	}
+	c := NewCompositor(104)
+	c.BlankPoints = 1
+	c.PPS = 30000
+	c.Add(&Node{Layer: ramp(1), PPS: 10000})
+	c.Add(&Node{Layer: ramp(2), PPS: 60000})
+
+	frame := c.Frame(0)
+	// Each layer gets 50 output points: the slow layer 16 points shown three
+	// times each, the fast one 100 points of which every other is shown.
+	if budgets[1] != 16 || budgets[2] != 100 {
+		t.Fatalf("layer budgets = %v, want 16 and 100", budgets)
+	}
+	if len(frame) > 104 {
+		t.Fatalf("frame has %d points, over the budget", len(frame))
+	}
+	slow := frame[1:49]
+	for i, p := range slow {
+		if want := uint16(1000 + i/3); p.X != want {
+			t.Fatalf("slow point %d X = %d, want %d", i, p.X, want)
+		}
+	}
+	fast := frame[51:101]
+	for i, p := range fast[:len(fast)-1] {
+		if want := uint16(2000 + 2*i); p.X != want {
+			t.Fatalf("fast point %d X = %d, want %d", i, p.X, want)
+		}
+	}
+	if fast[len(fast)-1].X != 2099 {
+		t.Fatalf("fast layer ends at %d, want its last point", fast[len(fast)-1].X)
+	}
+}
@@ -0,0 +1,253 @@
+package scene
+
+import (
+	"math"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/motion"
+)
+
+// Scheduler merges independent layers into a single frame, ordering them
+// to minimize the blanked travel between them. Unlike a Compositor, it
+// ignores Node.Z: the layers are drawn in the order, and each in the
+// direction, that makes the jumps from the end of one layer to the start of
+// the next shortest, and the points the shorter jumps save are given to the
+// layers.
+//
+// A closed layer, one ending where it starts such as a circle, may also be
+// started at any of its points, so it is entered and left at the point
+// nearest to where the previous layer ended.
+//
+// The order is planned for every frame by nearest-neighbor search from each
+// possible first layer, keeping the plan with the least travel, including
+// the jump from the last layer back to the first that loops the frame.
+type Scheduler struct {
+	// Budget is the total number of points per frame, including travel.
+	Budget int
+
+	// PPS is the rate the frame is played at. It times the jumps, and
+	// per-layer rates are relative to it.
+	PPS int
+
+	// Profile shapes the blanked jumps between layers.
+	Profile motion.GalvoProfile
+
+	// KeepDirection draws every layer in the direction its points are
+	// given, for layers whose drawing order is visible, such as a line
+	// being written. Otherwise a layer may be drawn backwards when that
+	// shortens the jumps to and from it.
+	KeepDirection bool
+
+	// KeepStart draws every closed layer from its first point. Together
+	// with KeepDirection it keeps the drawing order of every layer as
+	// given, for persistence-of-vision content whose look depends on where
+	// the beam is at each moment of the frame.
+	KeepStart bool
+
+	nodes  []*Node
+	travel int
+}
+
+// NewScheduler creates a scheduler with the given per-frame point budget
+// and point rate, using motion.DefaultProfile.
+func NewScheduler(budget, pps int) *Scheduler {
+	return &Scheduler{Budget: budget, PPS: pps, Profile: motion.DefaultProfile}
+}
+
+// Add adds a node to the scheduler and returns it for further adjustment.
+func (s *Scheduler) Add(n *Node) *Node {
+	s.nodes = append(s.nodes, n)
+	return n
+}
+
+// Remove removes a node previously added with Add.
+func (s *Scheduler) Remove(n *Node) {
+	for i, m := range s.nodes {
+		if m == n {
+			s.nodes = append(s.nodes[:i], s.nodes[i+1:]...)
+			return
+		}
+	}
+}
+
+// Travel returns the number of blanked travel points in the last frame.
+func (s *Scheduler) Travel() int {
+	return s.travel
+}
+
+// Frame renders all visible layers at time t into a single frame.
+//
+// The layers share the budget left after the travel of the previous frame.
+// When the new plan needs more travel, such as on the first frame, the
+// frame is reduced to the budget with motion.Decimate.
+func (s *Scheduler) Frame(t time.Duration) []helios.Point {
+	var visible []*Node
+	for _, n := range s.nodes {
+		if !n.Hidden && n.Layer != nil {
+			visible = append(visible, n)
+		}
+	}
+	if len(visible) == 0 {
+		s.travel = 0
+		return nil
+	}
+	budgets := allocate(s.Budget-s.travel, visible)
+
+	var pieces [][]helios.Point
+	for i, n := range visible {
+		r := ratio(s.PPS, n)
+		points := n.Layer.Points(t, int(float64(budgets[i])/r))
+		if len(points) > 0 {
+			pieces = append(pieces, render(n, rerate(points, r)))
+		}
+	}
+	s.travel = 0
+	if len(pieces) == 0 {
+		return nil
+	}
+
+	frame := make([]helios.Point, 0, s.Budget)
+	for k, st := range s.plan(pieces) {
+		points := rotated(pieces[st.piece], st.start)
+		if st.reverse {
+			points = reversed(points)
+		}
+		if k > 0 {
+			frame = s.appendJump(frame, points[0])
+		}
+		frame = append(frame, points...)
+	}
+	frame = s.appendJump(frame, frame[0])
+	return motion.Decimate(frame, s.Budget)
+}
+
+// appendJump appends the blanked travel from the last point of frame to
+// to, counting it in s.travel.
+func (s *Scheduler) appendJump(frame []helios.Point, to helios.Point) []helios.Point {
+	from := frame[len(frame)-1]
+	if from.X == to.X && from.Y == to.Y {
+		return frame
+	}
+	jump := s.Profile.Travel(from, to, s.PPS)
+	s.travel += len(jump)
+	return append(frame, jump...)
+}
+
+// jumpCost returns the number of travel points appendJump adds between two
+// points.
+func (s *Scheduler) jumpCost(from, to helios.Point) int {
+	if from.X == to.X && from.Y == to.Y {
+		return 0
+	}
+	dist := math.Hypot(float64(to.X)-float64(from.X), float64(to.Y)-float64(from.Y))
+	return s.Profile.TravelPoints(dist, s.PPS)
+}
+
+// step draws one piece, forwards or backwards, from its start'th point if
+// it is closed.
+type step struct {
+	piece   int
+	reverse bool
+	start   int
+}
+
+// plan returns the order and directions to draw pieces in with the least
+// travel found, trying a nearest-neighbor tour from every first piece.
+func (s *Scheduler) plan(pieces [][]helios.Point) []step {
+	ends := func(st step) (helios.Point, helios.Point) {
+		p := pieces[st.piece]
+		if st.start > 0 {
+			return p[st.start], p[st.start]
+		}
+		if st.reverse {
+			return p[len(p)-1], p[0]
+		}
+		return p[0], p[len(p)-1]
+	}
+	directions := []bool{false}
+	if !s.KeepDirection {
+		directions = append(directions, true)
+	}
+
+	var best []step
+	bestCost := -1
+	used := make([]bool, len(pieces))
+	for first := range pieces {
+		clear(used)
+		used[first] = true
+		tour := []step{{piece: first}}
+		start, cur := ends(tour[0])
+		cost := 0
+		for len(tour) < len(pieces) {
+			next, nextCost := step{}, -1
+			for i := range pieces {
+				if used[i] {
+					continue
+				}
+				start := 0
+				if !s.KeepStart && closed(pieces[i]) {
+					start = nearest(pieces[i], cur)
+				}
+				for _, rev := range directions {
+					st := step{i, rev, start}
+					from, _ := ends(st)
+					if c := s.jumpCost(cur, from); nextCost < 0 || c < nextCost {
+						next, nextCost = st, c
+					}
+				}
+			}
+			used[next.piece] = true
+			tour = append(tour, next)
+			_, cur = ends(next)
+			cost += nextCost
+		}
+		cost += s.jumpCost(cur, start)
+		if bestCost < 0 || cost < bestCost {
+			best, bestCost = tour, cost
+		}
+	}
+	return best
+}
+
+// reversed returns a reversed copy of points.
+func reversed(points []helios.Point) []helios.Point {
+	out := make([]helios.Point, len(points))
+	for i, p := range points {
+		out[len(points)-1-i] = p
+	}
+	return out
+}
+
+// closed reports whether points end where they start, so they can be drawn
+// from any of their points.
+func closed(points []helios.Point) bool {
+	first, last := points[0], points[len(points)-1]
+	return len(points) > 2 && first.X == last.X && first.Y == last.Y
+}
+
+// nearest returns the index of the point of a closed piece nearest to p,
+// leaving out the last, which repeats the first.
+func nearest(points []helios.Point, p helios.Point) int {
+	best, bestDist := 0, math.Inf(1)
+	for i, q := range points[:len(points)-1] {
+		if d := math.Hypot(float64(q.X)-float64(p.X), float64(q.Y)-float64(p.Y)); d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return best
+}
+
+// rotated returns closed points redrawn from their start'th point, or
+// points itself if start is zero. Every point keeps its color, so each
+// stretch of the shape is drawn as before; the first point, which only
+// starts the shape, is dropped in favor of the last, which ends it.
+func rotated(points []helios.Point, start int) []helios.Point {
+	if start == 0 {
+		return points
+	}
+	n := len(points)
+	out := make([]helios.Point, 0, n)
+	out = append(out, points[start:n]...)
+	return append(out, points[1:start+1]...)
+}
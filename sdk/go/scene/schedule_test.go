@@ -0,0 +1,136 @@
+package scene
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/motion"
+)
+
+// lineLayer draws a lit horizontal line from x0 to x1 at y.
+func lineLayer(x0, x1, y int) Layer {
+	return LayerFunc(func(t time.Duration, budget int) []helios.Point {
+		points := make([]helios.Point, max(budget, 2))
+		for i := range points {
+			x := x0 + (x1-x0)*i/(len(points)-1)
+			points[i] = helios.Point{X: uint16(x), Y: uint16(y), G: 255, I: 255}
+		}
+		return points
+	})
+}
+
+// litRuns returns the first X of each run of lit points in frame.
+func litRuns(frame []helios.Point) []uint16 {
+	var starts []uint16
+	lit := false
+	for _, p := range frame {
+		if p.I > 0 && !lit {
+			starts = append(starts, p.X)
+		}
+		lit = p.I > 0
+	}
+	return starts
+}
+
+func TestSchedulerOrdersByDistance(t *testing.T) {
+	s := NewScheduler(1000, 30000)
+	s.Add(&Node{Layer: lineLayer(0, 500, 2000)})
+	s.Add(&Node{Layer: lineLayer(3500, 4000, 2000)})
+	s.Add(&Node{Layer: lineLayer(1500, 2000, 2000)})
+
+	frame := s.Frame(0)
+	if len(frame) > 1000 {
+		t.Fatalf("frame has %d points, over the budget", len(frame))
+	}
+	// Left to right, then the long jump back.
+	if got := litRuns(frame); len(got) != 3 || got[0] != 0 || got[1] != 1500 || got[2] != 3500 {
+		t.Fatalf("layers start at %v, want 0 1500 3500", got)
+	}
+	p := motion.DefaultProfile
+	want := p.TravelPoints(1000, 30000) + p.TravelPoints(1500, 30000) + p.TravelPoints(4000, 30000)
+	if s.Travel() != want {
+		t.Fatalf("travel = %d points, want %d", s.Travel(), want)
+	}
+	// Drawn in the order added, the jumps would be 3000, 2500 and 2000.
+	if naive := p.TravelPoints(3000, 30000) + p.TravelPoints(2500, 30000) + p.TravelPoints(2000, 30000); s.Travel() >= naive {
+		t.Fatalf("travel = %d points, not less than %d in the order added", s.Travel(), naive)
+	}
+
+	// The next frame gives the travel's points back to the layers.
+	if frame = s.Frame(0); len(frame) != 1000 {
+		t.Fatalf("second frame has %d points, want 1000", len(frame))
+	}
+}
+
+func TestSchedulerReversesLayers(t *testing.T) {
+	s := NewScheduler(400, 30000)
+	s.Add(&Node{Layer: lineLayer(0, 1000, 1000)})
+	s.Add(&Node{Layer: lineLayer(0, 1000, 1100)})
+
+	// Drawing the second line backwards leaves two short jumps.
+	s.Frame(0)
+	p := motion.DefaultProfile
+	if want := 2 * p.TravelPoints(100, 30000); s.Travel() != want {
+		t.Fatalf("travel = %d points, want %d", s.Travel(), want)
+	}
+	if got := litRuns(s.Frame(0)); len(got) != 2 || got[0] != 0 || got[1] != 1000 {
+		t.Fatalf("layers start at %v, want 0 1000", got)
+	}
+
+	s.KeepDirection = true
+	if got := litRuns(s.Frame(0)); len(got) != 2 || got[0] != 0 || got[1] != 0 {
+		t.Fatalf("layers start at %v, want 0 0", got)
+	}
+}
+
+func TestSchedulerStartsClosedLayers(t *testing.T) {
+	// A closed square starting at its corner far from the line.
+	square := LayerFunc(func(time.Duration, int) []helios.Point {
+		var points []helios.Point
+		for _, c := range [][2]uint16{{2000, 2000}, {1100, 2000}, {1100, 1000}, {2000, 1000}, {2000, 2000}} {
+			points = append(points, helios.Point{X: c[0], Y: c[1], R: 255, I: 255})
+		}
+		return points
+	})
+	s := NewScheduler(400, 30000)
+	s.Add(&Node{Layer: lineLayer(0, 1000, 1000)})
+	s.Add(&Node{Layer: square})
+
+	// The square is entered at the corner nearest the end of the line and
+	// left there again.
+	frame := s.Frame(0)
+	if got := litRuns(frame); len(got) != 2 || got[0] != 0 || got[1] != 1100 {
+		t.Fatalf("layers start at %v, want 0 1100", got)
+	}
+	p := motion.DefaultProfile
+	if want := p.TravelPoints(100, 30000) + p.TravelPoints(1100, 30000); s.Travel() != want {
+		t.Fatalf("travel = %d points, want %d", s.Travel(), want)
+	}
+	var corners int
+	for _, pt := range frame {
+		if pt.I > 0 && pt.X == 1100 && pt.Y == 1000 {
+			corners++
+		}
+	}
+	if corners != 2 {
+		t.Errorf("drawn through the entry corner %d times, want 2", corners)
+	}
+
+	s.KeepStart = true
+	if got := litRuns(s.Frame(0)); len(got) != 2 || got[1] != 2000 {
+		t.Fatalf("with KeepStart layers start at %v, want the square at 2000", got)
+	}
+}
+
+func TestSchedulerEmpty(t *testing.T) {
+	s := NewScheduler(100, 30000)
+	n := s.Add(&Node{Layer: lineLayer(0, 10, 0), Hidden: true})
+	if frame := s.Frame(0); frame != nil {
+		t.Fatalf("frame = %v, want nil", frame)
+	}
+	s.Remove(n)
+	if len(s.nodes) != 0 {
+		t.Fatal("node not removed")
+	}
+}
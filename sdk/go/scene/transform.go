@@ -0,0 +1,82 @@
+package scene
+
+import (
+	"math"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+// Transform is a 2D affine transform in device coordinates:
+//
+//	x' = A*x + B*y + C
+//	y' = D*x + E*y + F
+type Transform struct {
+	A, B, C float64
+	D, E, F float64
+}
+
+// Identity returns the transform that leaves points unchanged.
+func Identity() Transform {
+	return Transform{A: 1, E: 1}
+}
+
+// Translate returns a transform that offsets points by (dx, dy).
+func Translate(dx, dy float64) Transform {
+	return Transform{A: 1, C: dx, E: 1, F: dy}
+}
+
+// Scale returns a transform that scales points by (sx, sy) around (cx, cy).
+func Scale(sx, sy, cx, cy float64) Transform {
+	return Transform{A: sx, C: cx - sx*cx, E: sy, F: cy - sy*cy}
+}
+
+// Rotate returns a transform that rotates points by angle radians
+// counter-clockwise around (cx, cy).
+func Rotate(angle, cx, cy float64) Transform {
+	sin, cos := math.Sincos(angle)
+	return Transform{
+		A: cos, B: -sin, C: cx - cos*cx + sin*cy,
+		D: sin, E: cos, F: cy - sin*cx - cos*cy,
+	}
+}
+
+// Then returns the transform that applies t followed by next.
+func (t Transform) Then(next Transform) Transform {
+	return Transform{
+		A: next.A*t.A + next.B*t.D,
+		B: next.A*t.B + next.B*t.E,
+		C: next.A*t.C + next.B*t.F + next.C,
+		D: next.D*t.A + next.E*t.D,
+		E: next.D*t.B + next.E*t.E,
+		F: next.D*t.C + next.E*t.F + next.F,
+	}
+}
+
+// Apply transforms (x, y).
+func (t Transform) Apply(x, y float64) (float64, float64) {
+	return t.A*x + t.B*y + t.C, t.D*x + t.E*y + t.F
+}
+
+// isIdentity reports whether t is exactly the identity transform. The zero
+// Transform is treated as identity so unset Node transforms are no-ops.
+func (t Transform) isIdentity() bool {
+	return t == Identity() || t == Transform{}
+}
+
+// ColorMod scales the color channels of a point. Values are multipliers in
+// the range 0.0 - 1.0. The zero ColorMod is treated as no modulation.
+type ColorMod struct {
+	R, G, B, I float64
+}
+
+func (m ColorMod) isNone() bool {
+	return m == ColorMod{} || m == ColorMod{1, 1, 1, 1}
+}
+
+func (m ColorMod) apply(p helios.Point) helios.Point {
+	p.R = helios.ScaleColor(p.R, m.R)
+	p.G = helios.ScaleColor(p.G, m.G)
+	p.B = helios.ScaleColor(p.B, m.B)
+	p.I = helios.ScaleColor(p.I, m.I)
+	return p
+}
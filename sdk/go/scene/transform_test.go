@@ -0,0 +1,83 @@
+package scene
+
+import (
+	"math"
+	"testing"
+)
+
+// near compares coordinates to well below the device resolution.
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestTransformReference(t *testing.T) {
+	tests := []struct {
+		name   string
+		tr     Transform
+		x, y   float64
+		wx, wy float64
+	}{
+		{"identity", Identity(), 123, 456, 123, 456},
+		{"zero value", Transform{}, 123, 456, 0, 0},
+		{"translate", Translate(10, -20), 100, 100, 110, 80},
+		{"translate out of range", Translate(5000, -5000), 0, 0, 5000, -5000},
+		{"scale about origin", Scale(2, 3, 0, 0), 10, 10, 20, 30},
+		{"scale about center", Scale(0.5, 0.5, 2048, 2048), 4096, 0, 3072, 1024},
+		{"scale keeps center", Scale(7, -3, 2048, 2048), 2048, 2048, 2048, 2048},
+		{"mirror x", Scale(-1, 1, 2048, 0), 0, 5, 4096, 5},
+		{"collapse to point", Scale(0, 0, 100, 200), 4000, 3000, 100, 200},
+		{"rotate 90 about origin", Rotate(math.Pi/2, 0, 0), 1, 0, 0, 1},
+		{"rotate -90 about origin", Rotate(-math.Pi/2, 0, 0), 1, 0, 0, -1},
+		{"rotate 180 about center", Rotate(math.Pi, 2048, 2048), 0, 0, 4096, 4096},
+		{"rotate 90 about center", Rotate(math.Pi/2, 2048, 2048), 4096, 2048, 2048, 4096},
+		{"rotate full turn", Rotate(2*math.Pi, 2048, 2048), 100, 3000, 100, 3000},
+		{"rotate 45", Rotate(math.Pi/4, 0, 0), 1, 1, 0, math.Sqrt2},
+		{"rotate keeps center", Rotate(1.234, 300, 400), 300, 400, 300, 400},
+		{"scale then translate", Scale(2, 2, 0, 0).Then(Translate(1, 1)), 3, 4, 7, 9},
+		{"translate then scale", Translate(1, 1).Then(Scale(2, 2, 0, 0)), 3, 4, 8, 10},
+		{"rotate then translate", Rotate(math.Pi/2, 0, 0).Then(Translate(10, 0)), 1, 0, 10, 1},
+		{"identity then", Identity().Then(Translate(3, 4)), 0, 0, 3, 4},
+		{"then identity", Rotate(math.Pi, 0, 0).Then(Identity()), 2, 3, -2, -3},
+	}
+	for _, tt := range tests {
+		x, y := tt.tr.Apply(tt.x, tt.y)
+		if !near(x, tt.wx) || !near(y, tt.wy) {
+			t.Errorf("%s: Apply(%v, %v) = (%v, %v), want (%v, %v)", tt.name, tt.x, tt.y, x, y, tt.wx, tt.wy)
+		}
+	}
+}
+
+func TestTransformThenAssociative(t *testing.T) {
+	a := Rotate(0.3, 100, 200)
+	b := Scale(1.5, 0.5, 2048, 2048)
+	c := Translate(-30, 70)
+	left, right := a.Then(b).Then(c), a.Then(b.Then(c))
+	for _, p := range [][2]float64{{0, 0}, {4095, 0}, {1234, 3456}, {-10, 5000}} {
+		x1, y1 := left.Apply(p[0], p[1])
+		x2, y2 := right.Apply(p[0], p[1])
+		if !near(x1, x2) || !near(y1, y2) {
+			t.Fatalf("(ab)c = (%v, %v), a(bc) = (%v, %v) at %v", x1, y1, x2, y2, p)
+		}
+	}
+}
+
+func TestTransformIsIdentity(t *testing.T) {
+	tests := []struct {
+		tr   Transform
+		want bool
+	}{
+		{Identity(), true},
+		{Transform{}, true},
+		{Translate(0, 0), true},
+		{Scale(1, 1, 500, 500), true},
+		{Rotate(0, 0, 0), true},
+		{Translate(1, 0), false},
+		{Scale(1, -1, 0, 0), false},
+		{Rotate(math.Pi/2, 0, 0), false},
+	}
+	for i, tt := range tests {
+		if got := tt.tr.isIdentity(); got != tt.want {
+			t.Errorf("%d: isIdentity(%+v) = %v, want %v", i, tt.tr, got, tt.want)
+		}
+	}
+}
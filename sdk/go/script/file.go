@@ -0,0 +1,122 @@
+package script
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+// DefaultPollInterval is how often Watch checks a file for changes when
+// its interval is zero.
+const DefaultPollInterval = 250 * time.Millisecond
+
+// File is a scene.Layer running the script in a file, reloaded when the
+// file changes. It is safe for concurrent use.
+type File struct {
+	path string
+
+	// OnReload, if set, is called after each reload attempt of a changed
+	// file, with nil or the error that kept the previous program running.
+	// Set it before calling Watch.
+	OnReload func(err error)
+
+	mu      sync.Mutex
+	prog    *Program
+	modTime time.Time
+	size    int64
+	err     error
+}
+
+// Open loads the script in the file at path.
+func Open(path string) (*File, error) {
+	f := &File{path: path}
+	if _, err := f.Reload(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Points evaluates the current program.
+func (f *File) Points(t time.Duration, budget int) []helios.Point {
+	f.mu.Lock()
+	prog := f.prog
+	f.mu.Unlock()
+	return prog.Points(t, budget)
+}
+
+// Reload reloads the script if the file has changed since it was last
+// read, reporting whether it did. If the file cannot be read or the script
+// has errors, the previous program keeps running and the error is
+// returned and kept for Err.
+func (f *File) Reload() (bool, error) {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return false, f.fail(err)
+	}
+	f.mu.Lock()
+	unchanged := f.prog != nil && info.ModTime().Equal(f.modTime) && info.Size() == f.size
+	f.mu.Unlock()
+	if unchanged {
+		return false, nil
+	}
+	src, err := os.ReadFile(f.path)
+	if err != nil {
+		return false, f.fail(err)
+	}
+	prog, err := Parse(string(src))
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	// Remember the version even if it fails, so it is not parsed again
+	// until the next change.
+	f.modTime, f.size = info.ModTime(), info.Size()
+	if err != nil {
+		f.err = fmt.Errorf("%s: %w", f.path, err)
+		return false, f.err
+	}
+	f.prog, f.err = prog, nil
+	return true, nil
+}
+
+func (f *File) fail(err error) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err = err
+	return err
+}
+
+// Err returns the error of the last reload, or nil if it succeeded.
+func (f *File) Err() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.err
+}
+
+// Watch reloads the script whenever the file changes, checking every
+// interval until ctx is done, and returns ctx.Err(). Zero means
+// DefaultPollInterval.
+func (f *File) Watch(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		f.mu.Lock()
+		before := f.err
+		f.mu.Unlock()
+		changed, err := f.Reload()
+		// Report each change, and each new error, once.
+		if f.OnReload != nil && (changed || err != nil && (before == nil || err.Error() != before.Error())) {
+			f.OnReload(err)
+		}
+	}
+}
@@ -0,0 +1,317 @@
+package script
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// expr evaluates an expression against the variable slots of one point.
+type expr func(env []float64) float64
+
+// stmt assigns the value of an expression to a slot.
+type stmt struct {
+	slot int
+	e    expr
+}
+
+// funcs are the functions scripts may call, by arity.
+var (
+	funcs1 = map[string]func(float64) float64{
+		"sin": math.Sin, "cos": math.Cos, "tan": math.Tan,
+		"asin": math.Asin, "acos": math.Acos, "atan": math.Atan,
+		"sqrt": math.Sqrt, "abs": math.Abs, "exp": math.Exp, "log": math.Log,
+		"floor": math.Floor, "ceil": math.Ceil, "round": math.Round,
+		"fract": func(x float64) float64 { return x - math.Floor(x) },
+	}
+	funcs2 = map[string]func(float64, float64) float64{
+		"atan2": math.Atan2, "pow": math.Pow, "min": math.Min, "max": math.Max,
+		"mod": func(x, y float64) float64 { return x - y*math.Floor(x/y) },
+		"step": func(edge, x float64) float64 {
+			if x < edge {
+				return 0
+			}
+			return 1
+		},
+	}
+	funcs3 = map[string]func(float64, float64, float64) float64{
+		"clamp": func(x, lo, hi float64) float64 { return math.Max(lo, math.Min(x, hi)) },
+		"mix":   func(a, b, t float64) float64 { return a + (b-a)*t },
+		"if": func(c, a, b float64) float64 {
+			if c != 0 {
+				return a
+			}
+			return b
+		},
+	}
+	constants = map[string]float64{"pi": math.Pi, "tau": 2 * math.Pi}
+)
+
+// parser compiles one line.
+type parser struct {
+	line int
+	toks []string
+	pos  int
+	vars map[string]int
+	set  map[int]bool // slots assigned so far
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("script: line %d: %s", p.line, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) peek() string {
+	if p.pos < len(p.toks) {
+		return p.toks[p.pos]
+	}
+	return ""
+}
+
+func (p *parser) next() string {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+func (p *parser) expect(t string) error {
+	if got := p.next(); got != t {
+		return p.errorf("expected %q, found %s", t, describe(got))
+	}
+	return nil
+}
+
+func describe(tok string) string {
+	if tok == "" {
+		return "end of line"
+	}
+	return strconv.Quote(tok)
+}
+
+// statement parses "name = expr".
+func (p *parser) statement() (stmt, error) {
+	name := p.next()
+	if !isIdent(name) {
+		return stmt{}, p.errorf("expected a variable name, found %s", describe(name))
+	}
+	if _, ok := constants[name]; ok || inputs[name] {
+		return stmt{}, p.errorf("cannot assign to %s", name)
+	}
+	if err := p.expect("="); err != nil {
+		return stmt{}, err
+	}
+	e, err := p.expr(0)
+	if err != nil {
+		return stmt{}, err
+	}
+	if p.pos < len(p.toks) {
+		return stmt{}, p.errorf("unexpected %s", describe(p.peek()))
+	}
+	slot, ok := p.vars[name]
+	if !ok {
+		slot = len(p.vars)
+		p.vars[name] = slot
+	}
+	p.set[slot] = true
+	return stmt{slot, e}, nil
+}
+
+// binary operators by precedence, lowest first.
+var precedence = map[string]int{
+	"==": 1, "!=": 1, "<": 1, "<=": 1, ">": 1, ">=": 1,
+	"+": 2, "-": 2,
+	"*": 3, "/": 3, "%": 3,
+	"^": 5, // above unary minus, which is 4
+}
+
+// expr parses an expression of operators binding tighter than minPrec.
+func (p *parser) expr(minPrec int) (expr, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := p.peek()
+		prec, ok := precedence[op]
+		if !ok || prec <= minPrec {
+			return left, nil
+		}
+		p.next()
+		next := prec
+		if op == "^" {
+			next-- // right associative
+		}
+		right, err := p.expr(next)
+		if err != nil {
+			return nil, err
+		}
+		left = binary(op, left, right)
+	}
+}
+
+func (p *parser) unary() (expr, error) {
+	if p.peek() == "-" {
+		p.next()
+		e, err := p.expr(4)
+		if err != nil {
+			return nil, err
+		}
+		return func(env []float64) float64 { return -e(env) }, nil
+	}
+	return p.primary()
+}
+
+func (p *parser) primary() (expr, error) {
+	tok := p.next()
+	switch {
+	case tok == "(":
+		e, err := p.expr(0)
+		if err != nil {
+			return nil, err
+		}
+		return e, p.expect(")")
+	case tok != "" && (unicode.IsDigit(rune(tok[0])) || tok[0] == '.'):
+		v, err := strconv.ParseFloat(tok, 64)
+		if err != nil {
+			return nil, p.errorf("bad number %q", tok)
+		}
+		return func([]float64) float64 { return v }, nil
+	case isIdent(tok):
+		if p.peek() == "(" {
+			return p.call(tok)
+		}
+		if v, ok := constants[tok]; ok {
+			return func([]float64) float64 { return v }, nil
+		}
+		slot, ok := p.vars[tok]
+		if !ok || !p.set[slot] {
+			return nil, p.errorf("%s is used before it is set", tok)
+		}
+		return func(env []float64) float64 { return env[slot] }, nil
+	}
+	return nil, p.errorf("unexpected %s", describe(tok))
+}
+
+func (p *parser) call(name string) (expr, error) {
+	p.next() // (
+	var args []expr
+	for p.peek() != ")" {
+		if len(args) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		a, err := p.expr(0)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, a)
+	}
+	p.next() // )
+	if f, ok := funcs1[name]; ok && len(args) == 1 {
+		a := args[0]
+		return func(env []float64) float64 { return f(a(env)) }, nil
+	}
+	if f, ok := funcs2[name]; ok && len(args) == 2 {
+		a, b := args[0], args[1]
+		return func(env []float64) float64 { return f(a(env), b(env)) }, nil
+	}
+	if f, ok := funcs3[name]; ok && len(args) == 3 {
+		a, b, c := args[0], args[1], args[2]
+		return func(env []float64) float64 { return f(a(env), b(env), c(env)) }, nil
+	}
+	_, ok1 := funcs1[name]
+	_, ok2 := funcs2[name]
+	_, ok3 := funcs3[name]
+	if ok1 || ok2 || ok3 {
+		return nil, p.errorf("wrong number of arguments to %s: %d", name, len(args))
+	}
+	return nil, p.errorf("unknown function %s", name)
+}
+
+func binary(op string, a, b expr) expr {
+	truth := func(v bool) float64 {
+		if v {
+			return 1
+		}
+		return 0
+	}
+	switch op {
+	case "+":
+		return func(env []float64) float64 { return a(env) + b(env) }
+	case "-":
+		return func(env []float64) float64 { return a(env) - b(env) }
+	case "*":
+		return func(env []float64) float64 { return a(env) * b(env) }
+	case "/":
+		return func(env []float64) float64 { return a(env) / b(env) }
+	case "%":
+		return func(env []float64) float64 { return math.Mod(a(env), b(env)) }
+	case "^":
+		return func(env []float64) float64 { return math.Pow(a(env), b(env)) }
+	case "<":
+		return func(env []float64) float64 { return truth(a(env) < b(env)) }
+	case "<=":
+		return func(env []float64) float64 { return truth(a(env) <= b(env)) }
+	case ">":
+		return func(env []float64) float64 { return truth(a(env) > b(env)) }
+	case ">=":
+		return func(env []float64) float64 { return truth(a(env) >= b(env)) }
+	case "==":
+		return func(env []float64) float64 { return truth(a(env) == b(env)) }
+	default: // !=
+		return func(env []float64) float64 { return truth(a(env) != b(env)) }
+	}
+}
+
+func isIdent(tok string) bool {
+	if tok == "" || !(unicode.IsLetter(rune(tok[0])) || tok[0] == '_') {
+		return false
+	}
+	return strings.IndexFunc(tok, func(r rune) bool {
+		return !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_')
+	}) < 0
+}
+
+// tokenize splits a line into numbers, names and operators.
+func tokenize(line string, n int) ([]string, error) {
+	var toks []string
+	for i := 0; i < len(line); {
+		c := line[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case c >= '0' && c <= '9' || c == '.':
+			j := i
+			for j < len(line) && (line[j] >= '0' && line[j] <= '9' || line[j] == '.') {
+				j++
+			}
+			// An exponent, as in 1e-3.
+			if j < len(line) && (line[j] == 'e' || line[j] == 'E') {
+				k := j + 1
+				if k < len(line) && (line[k] == '+' || line[k] == '-') {
+					k++
+				}
+				if k < len(line) && line[k] >= '0' && line[k] <= '9' {
+					for j = k; j < len(line) && line[j] >= '0' && line[j] <= '9'; j++ {
+					}
+				}
+			}
+			toks, i = append(toks, line[i:j]), j
+		case c == '_' || unicode.IsLetter(rune(c)):
+			j := i
+			for j < len(line) && (line[j] == '_' || unicode.IsLetter(rune(line[j])) || line[j] >= '0' && line[j] <= '9') {
+				j++
+			}
+			toks, i = append(toks, line[i:j]), j
+		case strings.ContainsRune("<>=!", rune(c)) && i+1 < len(line) && line[i+1] == '=':
+			toks, i = append(toks, line[i:i+2]), i+2
+		case strings.ContainsRune("+-*/%^(),<>=", rune(c)):
+			toks, i = append(toks, line[i:i+1]), i+1
+		default:
+			return nil, fmt.Errorf("script: line %d: unexpected %q", n, c)
+		}
+	}
+	return toks, nil
+}
@@ -0,0 +1,119 @@
+// Package script defines frame generators in small script files, so artists
+// iterate on patterns without recompiling the host application.
+//
+// A script is a list of assignments, one per line, evaluated in order for
+// every point of the frame. It sets x and y (-1 to 1), and optionally r, g
+// and b (0 to 1, default 1) and on (the point is blanked where on is zero
+// or less, default 1). It may read t, the time in seconds, u, the position
+// along the frame (0 to 1, excluding 1), i, the point's index and n, the
+// number of points, and any variable assigned on an earlier line:
+//
+//	# Lissajous figure with a rotating hue
+//	a = u * tau
+//	x = 0.8 * sin(3*a + t)
+//	y = 0.8 * sin(2*a)
+//	r = 0.5 + 0.5*sin(a + t)
+//	g = 0.5 + 0.5*sin(a + t + tau/3)
+//	b = 0.5 + 0.5*sin(a + t + 2*tau/3)
+//
+// Expressions have the usual arithmetic operators, ^ for powers, the
+// comparisons, which give 1 or 0, the constants pi and tau and the
+// functions sin, cos, tan, asin, acos, atan, atan2, sqrt, abs, exp, log,
+// floor, ceil, round, fract, pow, min, max, mod, step, clamp, mix and
+// if(cond, then, else). Comments start with #.
+//
+// A Program is a scene.Layer. A File is one whose script is reloaded when
+// the file changes, keeping the previous program if the new one has
+// errors, so a show keeps running while its scripts are edited.
+package script
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+// inputs are the variables set for every point.
+var inputs = map[string]bool{"t": true, "u": true, "i": true, "n": true}
+
+// Slots of the inputs and outputs.
+const (
+	slotT = iota
+	slotU
+	slotI
+	slotN
+	slotX
+	slotY
+	slotR
+	slotG
+	slotB
+	slotOn
+	numFixed
+)
+
+// Program is a compiled script. It is safe for concurrent use.
+type Program struct {
+	stmts []stmt
+	slots int
+}
+
+// Parse compiles a script.
+func Parse(src string) (*Program, error) {
+	p := &parser{
+		vars: map[string]int{
+			"t": slotT, "u": slotU, "i": slotI, "n": slotN,
+			"x": slotX, "y": slotY, "r": slotR, "g": slotG, "b": slotB, "on": slotOn,
+		},
+		set: map[int]bool{slotT: true, slotU: true, slotI: true, slotN: true, slotR: true, slotG: true, slotB: true, slotOn: true},
+	}
+	var prog Program
+	for n, line := range strings.Split(src, "\n") {
+		if c := strings.IndexByte(line, '#'); c >= 0 {
+			line = line[:c]
+		}
+		toks, err := tokenize(line, n+1)
+		if err != nil {
+			return nil, err
+		}
+		if len(toks) == 0 {
+			continue
+		}
+		p.line, p.toks, p.pos = n+1, toks, 0
+		s, err := p.statement()
+		if err != nil {
+			return nil, err
+		}
+		prog.stmts = append(prog.stmts, s)
+	}
+	for _, name := range []string{"x", "y"} {
+		if !p.set[p.vars[name]] {
+			return nil, fmt.Errorf("script: %s is never set", name)
+		}
+	}
+	prog.slots = len(p.vars)
+	return &prog, nil
+}
+
+// Points evaluates the script for budget points at time t.
+func (p *Program) Points(t time.Duration, budget int) []helios.Point {
+	points := make([]helios.Point, max(budget, 0))
+	env := make([]float64, p.slots)
+	for i := range points {
+		env[slotT] = t.Seconds()
+		env[slotU] = float64(i) / float64(budget)
+		env[slotI] = float64(i)
+		env[slotN] = float64(budget)
+		env[slotR], env[slotG], env[slotB], env[slotOn] = 1, 1, 1, 1
+		for _, s := range p.stmts {
+			env[s.slot] = s.e(env)
+		}
+		f := helios.PointF{X: env[slotX], Y: env[slotY]}
+		if env[slotOn] > 0 {
+			f.R, f.G, f.B, f.I = env[slotR], env[slotG], env[slotB], 1
+		}
+		points[i] = f.Point()
+	}
+	return points
+}
@@ -0,0 +1,131 @@
+package script
+
+import (
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+func TestProgram(t *testing.T) {
+	prog, err := Parse(`
+# a horizontal line, lit on its right half, fading in over time
+x = 2*u - 1   # across the field
+y = -2^2 / 8 + t*0   # -0.5
+on = x >= 0
+r = clamp(t, 0, 1)
+g = if(i == n - 1, 1, 0)
+b = 0
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	points := prog.Points(500*time.Millisecond, 4)
+	want := []helios.PointF{
+		{X: -1, Y: -0.5},
+		{X: -0.5, Y: -0.5},
+		{X: 0, Y: -0.5, R: 0.5, I: 1},
+		{X: 0.5, Y: -0.5, R: 0.5, G: 1, I: 1},
+	}
+	for i, p := range points {
+		if p != want[i].Point() {
+			t.Errorf("point %d = %+v, want %+v", i, p, want[i].Point())
+		}
+	}
+}
+
+func TestPrecedence(t *testing.T) {
+	for src, want := range map[string]float64{
+		"1 + 2 * 3":            7,
+		"(1 + 2) * 3":          9,
+		"2 ^ 3 ^ 2":            512,
+		"-2 ^ 2":               -4,
+		"2 * -3":               -6,
+		"7 % 4 + 1e-1":         3.1,
+		"1 < 2 == 1":           1,
+		"min(3, max(1, 2))":    2,
+		"atan2(1, 1) * 4 / pi": 1,
+	} {
+		prog, err := Parse("x = " + src + "\ny = 0")
+		if err != nil {
+			t.Errorf("%s: %v", src, err)
+			continue
+		}
+		env := make([]float64, prog.slots)
+		got := prog.stmts[0].e(env)
+		if math.Abs(got-want) > 1e-9 {
+			t.Errorf("%s = %v, want %v", src, got, want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for src, want := range map[string]string{
+		"x = 1":                "y is never set",
+		"x = z\ny = 0":         "line 1: z is used before it is set",
+		"x = 1\ny = foo(1)":    "line 2: unknown function foo",
+		"x = sin(1, 2)\ny = 0": "wrong number of arguments to sin",
+		"t = 1":                "cannot assign to t",
+		"x = (1\ny = 0":        `expected ")"`,
+		"x = 1 $ 2":            `unexpected '$'`,
+		"x 1":                  `expected "="`,
+		"x = 1 2\ny = 0":       `unexpected "2"`,
+	} {
+		if _, err := Parse(src); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: err = %v, want %q", src, err, want)
+		}
+	}
+}
+
+func TestFileReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pattern.txt")
+	write := func(src string, mod time.Time) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		os.Chtimes(path, mod, mod)
+	}
+	start := time.Now().Add(-time.Hour)
+	write("x = 0\ny = 0", start)
+	f, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := f.Reload(); changed || err != nil {
+		t.Fatalf("unchanged file reloaded: %v %v", changed, err)
+	}
+
+	// A broken edit keeps the previous program.
+	write("x = 1\ny = oops", start.Add(time.Second))
+	if _, err := f.Reload(); err == nil || f.Err() == nil {
+		t.Fatal("broken script accepted")
+	}
+	if p := f.Points(0, 1)[0]; p.X != 2048 {
+		t.Fatalf("point after broken edit = %+v", p)
+	}
+
+	// Watch picks up the fix.
+	reloaded := make(chan error, 1)
+	f.OnReload = func(err error) { reloaded <- err }
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go f.Watch(ctx, time.Millisecond)
+	write("x = 1\ny = 0", start.Add(2*time.Second))
+	select {
+	case err := <-reloaded:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("change not picked up")
+	}
+	if p := f.Points(0, 1)[0]; p.X != 4095 || f.Err() != nil {
+		t.Fatalf("point after fix = %+v, err %v", p, f.Err())
+	}
+}
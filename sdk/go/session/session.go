@@ -0,0 +1,204 @@
+// Package session saves the runtime state of an installation, so a
+// restart after a crash or power cut picks up where it left off instead of
+// coming back dark at the top of the playlist.
+//
+// A Session ties together the parts that make up the state: the DAC's
+// devices and their levels, the device profiles applied to them, the
+// playlist position and the show engine. AutoSave snapshots them
+// periodically; Recover restores the last snapshot on startup.
+//
+//	sess := &session.Session{DAC: dac, Profiles: profiles, Playlist: list}
+//	if _, err := sess.Recover(ctx, dir, "session.json"); err != nil {
+//		log.Print(err)
+//	}
+//	go sess.AutoSave(ctx, dir, "session.json", 5*time.Second)
+//
+// Devices are matched by name, as device profiles are, so a projector
+// keeps its levels across USB ports and IP addresses.
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/devprofile"
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/playlist"
+	"github.com/Grix/helios_dac/sdk/go/show"
+	"github.com/Grix/helios_dac/sdk/go/store"
+)
+
+// State is a snapshot of the runtime state of a Session.
+type State struct {
+	// Taken is when the snapshot was made.
+	Taken time.Time `json:"taken"`
+
+	// Master is the DAC's master intensity and Blackout its global
+	// blackout.
+	Master   float64 `json:"master"`
+	Blackout bool    `json:"blackout,omitempty"`
+
+	// Devices lists the open devices in index order.
+	Devices []Device `json:"devices,omitempty"`
+
+	// Playlist is the playlist position, nil if the Session has no
+	// playlist.
+	Playlist *playlist.State `json:"playlist,omitempty"`
+
+	// Show is the show engine's playback state, nil if the Session has no
+	// engine.
+	Show *show.Snapshot `json:"show,omitempty"`
+}
+
+// Device is the saved state of one device.
+type Device struct {
+	Name      string  `json:"name"`
+	Intensity float64 `json:"intensity"`
+	Blackout  bool    `json:"blackout,omitempty"`
+
+	// Profile is the key of the device profile applied to the device,
+	// empty if it had none.
+	Profile string `json:"profile,omitempty"`
+}
+
+// Session is the state of an installation to save and restore. Any field
+// may be nil; the parts it holds are left out of the state.
+type Session struct {
+	DAC      *helios.DAC
+	Profiles devprofile.Profiles
+	Playlist *playlist.Playlist
+	Engine   *show.Engine
+}
+
+// Capture snapshots the current state.
+func (s *Session) Capture() State {
+	st := State{Taken: time.Now(), Master: 1}
+	if s.DAC != nil {
+		st.Master, st.Blackout = s.DAC.MasterIntensity(), s.DAC.Blackout()
+		for i := range s.DAC.NumDevices() {
+			d := Device{
+				Name:      s.DAC.GetName(i),
+				Intensity: s.DAC.DeviceIntensity(i),
+				Blackout:  s.DAC.DeviceBlackout(i),
+			}
+			if s.Profiles[d.Name] != nil {
+				d.Profile = d.Name
+			}
+			st.Devices = append(st.Devices, d)
+		}
+	}
+	if s.Playlist != nil {
+		p := s.Playlist.State()
+		st.Playlist = &p
+	}
+	if s.Engine != nil {
+		e := s.Engine.Snapshot()
+		st.Show = &e
+	}
+	return st
+}
+
+// Restore returns the session to st. If the DAC has no devices open and st
+// has some, the devices are opened first. Each saved device is matched to
+// an open device by name and has its profile, then its levels, applied;
+// saved devices that are no longer connected are reported in the returned
+// error after everything else has been restored.
+func (s *Session) Restore(st State) error {
+	var errs []error
+	if s.DAC != nil {
+		if s.DAC.NumDevices() == 0 && len(st.Devices) > 0 {
+			s.DAC.OpenDevices()
+		}
+		s.DAC.SetMasterIntensity(st.Master)
+		s.DAC.SetBlackout(st.Blackout)
+		open := make(map[string]int, s.DAC.NumDevices())
+		for i := range s.DAC.NumDevices() {
+			open[s.DAC.GetName(i)] = i
+		}
+		for _, d := range st.Devices {
+			i, ok := open[d.Name]
+			if !ok {
+				errs = append(errs, fmt.Errorf("session: device %q is not connected", d.Name))
+				continue
+			}
+			if p := s.Profiles[d.Profile]; p != nil {
+				if err := p.Apply(s.DAC, i); err != nil {
+					errs = append(errs, fmt.Errorf("session: device %q: %w", d.Name, err))
+				}
+			}
+			s.DAC.SetDeviceIntensity(i, d.Intensity)
+			s.DAC.SetDeviceBlackout(i, d.Blackout)
+		}
+	}
+	if s.Playlist != nil && st.Playlist != nil {
+		s.Playlist.Restore(*st.Playlist)
+	}
+	if s.Engine != nil && st.Show != nil {
+		s.Engine.Restore(*st.Show)
+	}
+	return errors.Join(errs...)
+}
+
+// stateSchema versions saved sessions. Add a migration and bump the
+// version when a change to State would misread older files.
+var stateSchema = store.Schema{Name: "session", Version: 1}
+
+// Save writes st to key in s as versioned JSON.
+func Save(ctx context.Context, s store.Store, key string, st State) error {
+	data, err := stateSchema.Encode(st)
+	if err != nil {
+		return err
+	}
+	return s.Put(ctx, key, data)
+}
+
+// Load reads a state written to key in s by Save.
+func Load(ctx context.Context, s store.Store, key string) (State, error) {
+	var st State
+	data, err := s.Get(ctx, key)
+	if err != nil {
+		return st, err
+	}
+	if err := stateSchema.Decode(data, &st); err != nil {
+		return st, fmt.Errorf("session: %s: %w", key, err)
+	}
+	return st, nil
+}
+
+// AutoSave saves the state to key in st every interval until ctx is done,
+// and once more when it is. It returns the first error from saving, or
+// ctx.Err().
+func (s *Session) AutoSave(ctx context.Context, st store.Store, key string, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			// Save even though ctx is done, or the final state is lost.
+			if err := Save(context.WithoutCancel(ctx), st, key, s.Capture()); err != nil {
+				return err
+			}
+			return ctx.Err()
+		case <-ticker.C:
+			if err := Save(ctx, st, key, s.Capture()); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Recover restores the state saved to key in st, as on startup after a
+// crash. It reports whether there was a state to restore; a missing key is
+// not an error, since there is none on the first run.
+func (s *Session) Recover(ctx context.Context, st store.Store, key string) (bool, error) {
+	state, err := Load(ctx, st, key)
+	if errors.Is(err, store.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, s.Restore(state)
+}
@@ -0,0 +1,95 @@
+package session
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/ilda"
+	"github.com/Grix/helios_dac/sdk/go/playlist"
+	"github.com/Grix/helios_dac/sdk/go/show"
+	"github.com/Grix/helios_dac/sdk/go/store"
+)
+
+func testPlaylist(clock show.Clock) *playlist.Playlist {
+	var items []playlist.Item
+	for _, name := range []string{"a", "b", "c"} {
+		items = append(items, playlist.Item{Name: name, Frames: make([]ilda.Frame, 10)})
+	}
+	return playlist.New(items, clock)
+}
+
+func TestRecover(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewMemory()
+
+	dac := helios.NewDAC()
+	defer dac.Close()
+	clock := &show.ManualClock{}
+	s := &Session{DAC: dac, Playlist: testPlaylist(clock), Engine: show.NewEngine(clock)}
+	dac.SetMasterIntensity(0.4)
+	dac.SetBlackout(true)
+	s.Playlist.Jump(2)
+	s.Engine.SetBrightness(0.5)
+	if err := Save(ctx, st, "session.json", s.Capture()); err != nil {
+		t.Fatal(err)
+	}
+
+	// A restart comes up with fresh state and recovers the saved one.
+	restarted := helios.NewDAC()
+	defer restarted.Close()
+	r := &Session{DAC: restarted, Playlist: testPlaylist(&show.ManualClock{}), Engine: show.NewEngine(&show.ManualClock{})}
+	if ok, err := r.Recover(ctx, st, "session.json"); !ok || err != nil {
+		t.Fatalf("Recover = %v, %v", ok, err)
+	}
+	if restarted.MasterIntensity() != 0.4 || !restarted.Blackout() {
+		t.Fatalf("master = %v, blackout = %v", restarted.MasterIntensity(), restarted.Blackout())
+	}
+	if p := r.Playlist.State(); p.Item != "c" {
+		t.Fatalf("playlist = %+v", p)
+	}
+	if got := r.Engine.Brightness(); got != 0.5 {
+		t.Fatalf("brightness = %v", got)
+	}
+}
+
+func TestRecoverFirstRun(t *testing.T) {
+	s := &Session{}
+	if ok, err := s.Recover(context.Background(), store.NewMemory(), "session.json"); ok || err != nil {
+		t.Fatalf("Recover = %v, %v", ok, err)
+	}
+}
+
+func TestRestoreReportsMissingDevices(t *testing.T) {
+	dac := helios.NewDAC()
+	defer dac.Close()
+	s := &Session{DAC: dac}
+	err := s.Restore(State{Master: 0.7, Devices: []Device{{Name: "Helios 1234", Intensity: 0.5}}})
+	if err == nil || !strings.Contains(err.Error(), `"Helios 1234"`) {
+		t.Fatalf("err = %v", err)
+	}
+	// The rest of the state is restored regardless.
+	if got := dac.MasterIntensity(); got != 0.7 {
+		t.Fatalf("master = %v", got)
+	}
+}
+
+func TestAutoSave(t *testing.T) {
+	st := store.NewMemory()
+	s := &Session{Playlist: testPlaylist(&show.ManualClock{})}
+	s.Playlist.Jump(1)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := s.AutoSave(ctx, st, "session.json", time.Hour); err != context.Canceled {
+		t.Fatalf("err = %v", err)
+	}
+	saved, err := Load(context.Background(), st, "session.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Playlist == nil || saved.Playlist.Item != "b" || saved.Show != nil {
+		t.Fatalf("saved %+v", saved)
+	}
+}
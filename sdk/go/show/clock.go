@@ -0,0 +1,174 @@
+package show
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is a monotonic time source driving show playback. Only differences
+// between readings are meaningful, so a clock may start at any value, unless
+// a Transport is locked to it. The timecode package has clocks following
+// MIDI clock and LTC timecode.
+type Clock interface {
+	Now() time.Duration
+}
+
+// WallClock is a Clock backed by the system's monotonic clock.
+type WallClock struct {
+	start time.Time
+}
+
+// NewWallClock creates a WallClock starting at zero.
+func NewWallClock() *WallClock {
+	return &WallClock{start: time.Now()}
+}
+
+// Now returns the time elapsed since the clock was created.
+func (c *WallClock) Now() time.Duration {
+	return time.Since(c.start)
+}
+
+// ManualClock is a Clock that only moves when told to. It is useful for
+// tests and for rendering a show offline at a fixed frame rate.
+type ManualClock struct {
+	now time.Duration
+}
+
+// Now returns the clock's current reading.
+func (c *ManualClock) Now() time.Duration {
+	return c.now
+}
+
+// Advance moves the clock forward by d.
+func (c *ManualClock) Advance(d time.Duration) {
+	c.now += d
+}
+
+// Transport is an animation clock driven by another Clock, with its own
+// speed, pause and scrub controls. Generators that read a Transport instead
+// of the system time can be slowed down for review, held at a cue and
+// rendered deterministically from a ManualClock. It is safe for concurrent
+// use.
+type Transport struct {
+	mu     sync.Mutex
+	source Clock
+	speed  float64
+	paused bool
+	locked bool
+	offset time.Duration
+	// pos is the transport's reading at source reading ref.
+	pos time.Duration
+	ref time.Duration
+}
+
+// NewTransport creates a Transport running at normal speed from zero. A nil
+// source uses a WallClock.
+func NewTransport(source Clock) *Transport {
+	if source == nil {
+		source = NewWallClock()
+	}
+	return &Transport{source: source, speed: 1, ref: source.Now()}
+}
+
+// Now returns the animation time.
+func (t *Transport) Now() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.nowLocked()
+}
+
+func (t *Transport) nowLocked() time.Duration {
+	if t.locked {
+		return max(t.source.Now()-t.offset, 0)
+	}
+	if t.paused {
+		return t.pos
+	}
+	return t.pos + time.Duration(float64(t.source.Now()-t.ref)*t.speed)
+}
+
+// rebase folds the time elapsed so far into pos, so the speed or play
+// state can change without a jump.
+func (t *Transport) rebase() {
+	t.pos = t.nowLocked()
+	t.ref = t.source.Now()
+}
+
+// Pause holds the animation time.
+func (t *Transport) Pause() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rebase()
+	t.paused = true
+}
+
+// Resume continues from the held animation time.
+func (t *Transport) Resume() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rebase()
+	t.paused = false
+}
+
+// Paused reports whether the transport is paused.
+func (t *Transport) Paused() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.paused
+}
+
+// SetSpeed sets the rate of animation time relative to the source clock:
+// 0.25 for slow motion, 2 for double speed. Negative speeds count as zero.
+func (t *Transport) SetSpeed(speed float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rebase()
+	t.speed = max(speed, 0)
+}
+
+// Speed returns the rate set by SetSpeed.
+func (t *Transport) Speed() float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.speed
+}
+
+// Scrub jumps to animation time pos, keeping the play state and speed.
+// Negative times count as zero.
+func (t *Transport) Scrub(pos time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.pos = max(pos, 0)
+	t.ref = t.source.Now()
+}
+
+// Lock makes the animation time follow the source clock's readings minus
+// offset, for sources whose readings are absolute positions such as
+// timecode: with an offset of one hour, timecode 01:00:10:00 plays the
+// show at 10s. While locked the source alone moves the transport, so
+// pause, speed and scrubbing take effect after Unlock.
+func (t *Transport) Lock(offset time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.locked = true
+	t.offset = offset
+}
+
+// Unlock returns to free-running from the current animation time.
+func (t *Transport) Unlock() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.locked {
+		return
+	}
+	t.pos = t.nowLocked()
+	t.ref = t.source.Now()
+	t.locked = false
+}
+
+// Locked reports whether the transport is locked to its source.
+func (t *Transport) Locked() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.locked
+}
@@ -0,0 +1,69 @@
+package show
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTransport(t *testing.T) {
+	source := &ManualClock{}
+	source.Advance(time.Hour) // the source need not start at zero
+	tr := NewTransport(source)
+
+	source.Advance(time.Second)
+	if got := tr.Now(); got != time.Second {
+		t.Fatalf("Now = %v, want 1s", got)
+	}
+
+	tr.SetSpeed(0.25)
+	source.Advance(2 * time.Second)
+	if got := tr.Now(); got != 1500*time.Millisecond {
+		t.Fatalf("Now in slow motion = %v, want 1.5s", got)
+	}
+
+	tr.Pause()
+	source.Advance(time.Minute)
+	if got := tr.Now(); got != 1500*time.Millisecond || !tr.Paused() {
+		t.Fatalf("Now while paused = %v, want 1.5s", got)
+	}
+
+	tr.Scrub(10 * time.Second)
+	if got := tr.Now(); got != 10*time.Second {
+		t.Fatalf("Now after scrub = %v, want 10s", got)
+	}
+
+	tr.Resume()
+	tr.SetSpeed(2)
+	source.Advance(time.Second)
+	if got := tr.Now(); got != 12*time.Second {
+		t.Fatalf("Now at double speed = %v, want 12s", got)
+	}
+
+	tr.SetSpeed(-1)
+	tr.Scrub(-time.Second)
+	source.Advance(time.Second)
+	if got := tr.Now(); got != 0 || tr.Speed() != 0 {
+		t.Fatalf("negative speed and scrub: Now = %v, speed %v", got, tr.Speed())
+	}
+}
+
+func TestTransportLock(t *testing.T) {
+	source := &ManualClock{}
+	source.Advance(time.Hour + 10*time.Second)
+	tr := NewTransport(source)
+	tr.SetSpeed(2)
+	tr.Lock(time.Hour)
+	if got := tr.Now(); got != 10*time.Second || !tr.Locked() {
+		t.Fatalf("locked Now = %v, want 10s", got)
+	}
+	source.Advance(time.Second)
+	if got := tr.Now(); got != 11*time.Second {
+		t.Fatalf("locked Now = %v, want 11s at the source's rate", got)
+	}
+
+	tr.Unlock()
+	source.Advance(time.Second)
+	if got := tr.Now(); got != 13*time.Second || tr.Locked() {
+		t.Fatalf("Now after unlock = %v, want 13s at double speed", got)
+	}
+}
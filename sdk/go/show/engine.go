@@ -0,0 +1,283 @@
+// Package show plays content placed on a timeline.
+//
+// Content sources are scene.Layers wrapped in Cues, each with a start time,
+// duration and optional fade in/out. The Engine tracks the playback position
+// with a Transport and supports Play, Pause, Seek and playback speed. Frame renders every cue
+// active at the current position into a single frame, scaled by the master
+// brightness and blank while the engine is disarmed. Snapshot and Restore
+// persist the playback state so a show can resume after a restart.
+package show
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/ease"
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/scene"
+)
+
+// Cue places a content source on the timeline.
+type Cue struct {
+	Name string
+
+	// Source produces the cue's points. It is called with the time elapsed
+	// since the start of the cue.
+	Source scene.Layer
+
+	// Start is the timeline position at which the cue begins.
+	Start time.Duration
+
+	// Duration is how long the cue plays. Zero means until the end of the show.
+	Duration time.Duration
+
+	// FadeIn and FadeOut ramp the cue's brightness at its start and end.
+	FadeIn, FadeOut time.Duration
+
+	// FadeEasing shapes the fades. Nil means linear.
+	FadeEasing ease.Func
+
+	// Z orders overlapping cues. Lower values are drawn first.
+	Z int
+}
+
+// End returns the timeline position at which the cue ends, or -1 if it
+// plays until the end of the show.
+func (c *Cue) End() time.Duration {
+	if c.Duration <= 0 {
+		return -1
+	}
+	return c.Start + c.Duration
+}
+
+// active reports whether the cue plays at pos.
+func (c *Cue) active(pos time.Duration) bool {
+	end := c.End()
+	return pos >= c.Start && (end < 0 || pos < end)
+}
+
+// level returns the cue's fade multiplier at pos.
+func (c *Cue) level(pos time.Duration) float64 {
+	level := 1.0
+	if c.FadeIn > 0 && pos-c.Start < c.FadeIn {
+		level = float64(pos-c.Start) / float64(c.FadeIn)
+	}
+	if end := c.End(); end >= 0 && c.FadeOut > 0 && end-pos < c.FadeOut {
+		level = min(level, float64(end-pos)/float64(c.FadeOut))
+	}
+	return c.FadeEasing.Apply(level)
+}
+
+// Engine plays a timeline of cues. It is safe for concurrent use, so
+// playback can be controlled from a different goroutine than the one
+// rendering frames.
+type Engine struct {
+	mu        sync.Mutex
+	transport *Transport
+	cues      []*Cue
+
+	brightness float64
+	armed      bool
+	params     map[string]float64
+}
+
+// NewEngine creates a paused engine positioned at the start of the timeline.
+// A nil clock uses a WallClock.
+func NewEngine(clock Clock) *Engine {
+	transport := NewTransport(clock)
+	transport.Pause()
+	return &Engine{transport: transport, brightness: 1, armed: true, params: make(map[string]float64)}
+}
+
+// Add places a cue on the timeline.
+func (e *Engine) Add(c *Cue) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.cues = append(e.cues, c)
+	sort.SliceStable(e.cues, func(i, j int) bool { return e.cues[i].Start < e.cues[j].Start })
+}
+
+// Remove removes a cue previously added with Add.
+func (e *Engine) Remove(c *Cue) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for i, m := range e.cues {
+		if m == c {
+			e.cues = append(e.cues[:i], e.cues[i+1:]...)
+			return
+		}
+	}
+}
+
+// Cues returns the cues on the timeline ordered by start time.
+func (e *Engine) Cues() []*Cue {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]*Cue(nil), e.cues...)
+}
+
+// Play starts or resumes playback from the current position.
+func (e *Engine) Play() {
+	e.transport.Resume()
+}
+
+// Pause stops playback, holding the current position.
+func (e *Engine) Pause() {
+	e.transport.Pause()
+}
+
+// Seek moves the playback position to pos without changing the play state.
+func (e *Engine) Seek(pos time.Duration) {
+	e.transport.Scrub(pos)
+}
+
+// SetSpeed sets the playback rate: 0.25 plays the show in slow motion, 2 at
+// double speed.
+func (e *Engine) SetSpeed(speed float64) {
+	e.transport.SetSpeed(speed)
+}
+
+// Speed returns the playback rate.
+func (e *Engine) Speed() float64 {
+	return e.transport.Speed()
+}
+
+// SeekCue moves the playback position to the start of the named cue. It
+// returns false if no cue has that name.
+func (e *Engine) SeekCue(name string) bool {
+	for _, c := range e.Cues() {
+		if c.Name == name {
+			e.Seek(c.Start)
+			return true
+		}
+	}
+	return false
+}
+
+// Lock chases the engine's clock: the playback position follows its
+// readings minus offset, as for timecode; see Transport.Lock.
+func (e *Engine) Lock(offset time.Duration) {
+	e.transport.Lock(offset)
+}
+
+// Unlock stops chasing the clock, continuing from the current position.
+func (e *Engine) Unlock() {
+	e.transport.Unlock()
+}
+
+// Playing reports whether the engine is playing.
+func (e *Engine) Playing() bool {
+	return !e.transport.Paused()
+}
+
+// Position returns the current playback position.
+func (e *Engine) Position() time.Duration {
+	return e.transport.Now()
+}
+
+// SetBrightness sets the master brightness (0.0 - 1.0) applied to every cue.
+func (e *Engine) SetBrightness(level float64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.brightness = max(0, min(level, 1))
+}
+
+// Brightness returns the master brightness.
+func (e *Engine) Brightness() float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.brightness
+}
+
+// Arm enables output. Engines start armed.
+func (e *Engine) Arm() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.armed = true
+}
+
+// Disarm blanks output without stopping playback.
+func (e *Engine) Disarm() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.armed = false
+}
+
+// Armed reports whether output is enabled.
+func (e *Engine) Armed() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.armed
+}
+
+// SetParam sets a named show parameter. Parameters are free-form values
+// shared between control inputs and content sources, and are saved in
+// snapshots.
+func (e *Engine) SetParam(name string, v float64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.params[name] = v
+}
+
+// Param returns the named show parameter.
+func (e *Engine) Param(name string) (float64, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	v, ok := e.params[name]
+	return v, ok
+}
+
+// Active returns the cues playing at the current position.
+func (e *Engine) Active() []*Cue {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.activeLocked(e.transport.Now())
+}
+
+func (e *Engine) activeLocked(pos time.Duration) []*Cue {
+	var active []*Cue
+	for _, c := range e.cues {
+		if c.active(pos) {
+			active = append(active, c)
+		}
+	}
+	return active
+}
+
+// Frame renders all cues active at the current position into one frame of
+// at most budget points.
+func (e *Engine) Frame(budget int) []helios.Point {
+	e.mu.Lock()
+	pos := e.transport.Now()
+	var active []*Cue
+	if e.armed {
+		active = e.activeLocked(pos)
+	}
+	brightness := e.brightness
+	e.mu.Unlock()
+
+	comp := scene.NewCompositor(budget)
+	for _, c := range active {
+		level := c.level(pos) * brightness
+		if level <= 0 {
+			continue
+		}
+		comp.Add(&scene.Node{
+			Layer: offsetLayer{c.Source, c.Start},
+			Z:     c.Z,
+			Color: scene.ColorMod{R: level, G: level, B: level, I: level},
+		})
+	}
+	return comp.Frame(pos)
+}
+
+// offsetLayer presents cue-relative time to a cue's source.
+type offsetLayer struct {
+	layer scene.Layer
+	start time.Duration
+}
+
+func (l offsetLayer) Points(t time.Duration, budget int) []helios.Point {
+	return l.layer.Points(t-l.start, budget)
+}
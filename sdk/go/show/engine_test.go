@@ -0,0 +1,123 @@
+package show
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/scene"
+)
+
+func solid(x uint16) scene.Layer {
+	return scene.LayerFunc(func(t time.Duration, budget int) []helios.Point {
+		points := make([]helios.Point, budget)
+		for i := range points {
+			points[i] = helios.Point{X: x, R: 200, I: 255}
+		}
+		return points
+	})
+}
+
+func TestEnginePlayPauseSeek(t *testing.T) {
+	clock := &ManualClock{}
+	e := NewEngine(clock)
+
+	e.Play()
+	clock.Advance(2 * time.Second)
+	if got := e.Position(); got != 2*time.Second {
+		t.Fatalf("position = %v, want 2s", got)
+	}
+
+	e.Pause()
+	clock.Advance(time.Second)
+	if got := e.Position(); got != 2*time.Second {
+		t.Fatalf("position while paused = %v, want 2s", got)
+	}
+
+	e.Seek(10 * time.Second)
+	e.Play()
+	clock.Advance(500 * time.Millisecond)
+	if got := e.Position(); got != 10500*time.Millisecond {
+		t.Fatalf("position after seek = %v, want 10.5s", got)
+	}
+
+	e.SetSpeed(0.5)
+	clock.Advance(time.Second)
+	if got := e.Position(); got != 11*time.Second {
+		t.Fatalf("position at half speed = %v, want 11s", got)
+	}
+}
+
+func TestEngineActiveCuesAndFades(t *testing.T) {
+	clock := &ManualClock{}
+	e := NewEngine(clock)
+	e.Add(&Cue{Name: "a", Source: solid(100), Start: 0, Duration: 4 * time.Second, FadeOut: 2 * time.Second})
+	e.Add(&Cue{Name: "b", Source: solid(200), Start: 3 * time.Second, FadeIn: time.Second})
+
+	e.Seek(time.Second)
+	if active := e.Active(); len(active) != 1 || active[0].Name != "a" {
+		t.Fatalf("active at 1s = %v, want [a]", active)
+	}
+
+	if !e.SeekCue("b") {
+		t.Fatal("SeekCue(b) = false")
+	}
+	e.Seek(3500 * time.Millisecond)
+	if active := e.Active(); len(active) != 2 {
+		t.Fatalf("got %d active cues at 3.5s, want 2", len(active))
+	}
+
+	// At 3.5s cue a is 0.5s from its end (level 0.25) and cue b is halfway
+	// through its fade in (level 0.5).
+	seen := map[uint16]uint8{}
+	for _, p := range e.Frame(100) {
+		if p.R > 0 {
+			seen[p.X] = p.R
+		}
+	}
+	if seen[100] != 50 || seen[200] != 100 {
+		t.Fatalf("faded colors = %v, want a=50 b=100", seen)
+	}
+}
+
+func TestEngineSnapshotRestore(t *testing.T) {
+	clock := &ManualClock{}
+	e := NewEngine(clock)
+	e.Add(&Cue{Name: "intro", Source: solid(100), Duration: 10 * time.Second})
+	e.Add(&Cue{Name: "main", Source: solid(200), Start: 10 * time.Second})
+	e.SetBrightness(0.5)
+	e.SetParam("speed", 2)
+	e.Seek(12 * time.Second)
+	e.Play()
+	clock.Advance(time.Second)
+	e.SetSpeed(0.5)
+
+	path := filepath.Join(t.TempDir(), "show.json")
+	if err := SaveSnapshot(path, e.Snapshot()); err != nil {
+		t.Fatal(err)
+	}
+	s, err := LoadSnapshot(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Cue != "main" || s.CueOffset != 3*time.Second {
+		t.Fatalf("snapshot cue = %q at %v", s.Cue, s.CueOffset)
+	}
+
+	// The restarted show's main cue starts later than before.
+	r := NewEngine(clock)
+	r.Add(&Cue{Name: "main", Source: solid(200), Start: 20 * time.Second})
+	r.Restore(s)
+	if r.Position() != 23*time.Second || !r.Playing() || r.Speed() != 0.5 {
+		t.Fatalf("restored position = %v, playing = %v, speed = %v", r.Position(), r.Playing(), r.Speed())
+	}
+	if v, _ := r.Param("speed"); v != 2 || r.Brightness() != 0.5 || !r.Armed() {
+		t.Fatalf("restored params/brightness/armed = %v/%v/%v", v, r.Brightness(), r.Armed())
+	}
+
+	r.Disarm()
+	if f := r.Frame(100); len(f) != 0 {
+		t.Fatalf("disarmed engine rendered %d points", len(f))
+	}
+}
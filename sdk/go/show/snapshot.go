@@ -0,0 +1,155 @@
+package show
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/store"
+)
+
+// Snapshot is the restorable playback state of an Engine.
+type Snapshot struct {
+	// Taken is when the snapshot was made.
+	Taken time.Time `json:"taken"`
+
+	Position time.Duration `json:"position"`
+	Playing  bool          `json:"playing"`
+
+	// Speed is the playback rate. Zero, as in snapshots saved before speed
+	// control existed, restores normal speed.
+	Speed float64 `json:"speed,omitempty"`
+
+	// Cue names the most recently started cue active at Position, and
+	// CueOffset is Position relative to its start. Restore prefers them
+	// over Position, so a snapshot survives edits to earlier cues.
+	Cue       string        `json:"cue,omitempty"`
+	CueOffset time.Duration `json:"cue_offset,omitempty"`
+
+	Brightness float64            `json:"brightness"`
+	Armed      bool               `json:"armed"`
+	Params     map[string]float64 `json:"params,omitempty"`
+}
+
+// snapshotSchema versions snapshot files. Add a migration and bump the
+// version when a change to Snapshot would misread older files.
+var snapshotSchema = store.Schema{Name: "show snapshot", Version: 1}
+
+// Snapshot captures the current playback state.
+func (e *Engine) Snapshot() Snapshot {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	pos := e.transport.Now()
+	s := Snapshot{
+		Taken:      time.Now(),
+		Position:   pos,
+		Playing:    !e.transport.Paused(),
+		Speed:      e.transport.Speed(),
+		Brightness: e.brightness,
+		Armed:      e.armed,
+		Params:     make(map[string]float64, len(e.params)),
+	}
+	if active := e.activeLocked(pos); len(active) > 0 {
+		c := active[len(active)-1]
+		s.Cue, s.CueOffset = c.Name, pos-c.Start
+	}
+	for k, v := range e.params {
+		s.Params[k] = v
+	}
+	return s
+}
+
+// Restore returns the engine to the state captured in s. Cues are not part
+// of a snapshot; the timeline must be set up before restoring.
+func (e *Engine) Restore(s Snapshot) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	pos := s.Position
+	if s.Cue != "" {
+		for _, c := range e.cues {
+			if c.Name == s.Cue {
+				pos = c.Start + s.CueOffset
+				break
+			}
+		}
+	}
+	e.transport.Scrub(pos)
+	speed := s.Speed
+	if speed == 0 {
+		speed = 1
+	}
+	e.transport.SetSpeed(speed)
+	if s.Playing {
+		e.transport.Resume()
+	} else {
+		e.transport.Pause()
+	}
+	e.brightness = max(0, min(s.Brightness, 1))
+	e.armed = s.Armed
+	e.params = make(map[string]float64, len(s.Params))
+	for k, v := range s.Params {
+		e.params[k] = v
+	}
+}
+
+// SaveSnapshot writes s to path as versioned JSON. The file is replaced
+// atomically, so a crash during the write leaves the previous snapshot
+// intact.
+func SaveSnapshot(path string, s Snapshot) error {
+	b, err := snapshotSchema.Encode(s)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadSnapshot reads a snapshot written by SaveSnapshot, by this or an
+// earlier version. Snapshots from newer versions fail with
+// store.ErrNewerVersion.
+func LoadSnapshot(path string) (Snapshot, error) {
+	var s Snapshot
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return s, err
+	}
+	err = snapshotSchema.Decode(b, &s)
+	return s, err
+}
+
+// AutoSave saves a snapshot of e to path every interval until ctx is done,
+// and once more when it is. It returns the first error from saving, or
+// ctx.Err().
+func (e *Engine) AutoSave(ctx context.Context, path string, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			if err := SaveSnapshot(path, e.Snapshot()); err != nil {
+				return err
+			}
+			return ctx.Err()
+		case <-ticker.C:
+			if err := SaveSnapshot(path, e.Snapshot()); err != nil {
+				return err
+			}
+		}
+	}
+}
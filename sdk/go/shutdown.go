@@ -0,0 +1,91 @@
+package helios
+
+import (
+	"context"
+	"sync"
+)
+
+// writeGate tracks frame writes in flight so Shutdown can wait for them,
+// and rejects writes once shutdown has begun.
+type writeGate struct {
+	mu       sync.RWMutex
+	closing  bool
+	inflight sync.WaitGroup
+}
+
+func (g *writeGate) enter() bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if g.closing {
+		return false
+	}
+	g.inflight.Add(1)
+	return true
+}
+
+func (g *writeGate) leave() {
+	g.inflight.Done()
+}
+
+// close rejects further writes and returns a channel closed once the writes
+// in flight have finished.
+func (g *writeGate) close() <-chan struct{} {
+	g.mu.Lock()
+	g.closing = true
+	g.mu.Unlock()
+	done := make(chan struct{})
+	go func() {
+		g.inflight.Wait()
+		close(done)
+	}()
+	return done
+}
+
+// Shutdown turns off all output and releases the DAC. It rejects further
+// frame writes (they return ErrDeviceClosed), waits for writes in flight,
+// blanks and stops every device, all in parallel, then closes the devices
+// and the DAC.
+// Shutdown is safe to call from a defer or signal handler alongside Close.
+//
+// If ctx is done before the devices have stopped, Shutdown returns
+// ctx.Err() at once. The devices are left open, since closing them under a
+// running call is unsafe, and are stopped in the background as their
+// calls finish; stops still queued when the DAC is closed return
+// ErrDeviceClosed.
+func (d *DAC) Shutdown(ctx context.Context) error {
+	d.locks.scan.RLock()
+	closed := d.lib.closed()
+	d.locks.scan.RUnlock()
+	if closed {
+		return nil
+	}
+	d.watchdog.stop()
+
+	select {
+	case <-d.gate.close():
+	case <-ctx.Done():
+		// Stopping waits for the device lock held by the write in
+		// flight, so it must not be waited for.
+		for i := range d.NumDevices() {
+			d.StopAsync(i)
+		}
+		return ctx.Err()
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		fanOut(d.NumDevices(), func(i int) int {
+			d.writeFrame(i, 1000, FlagStartImmediately|FlagSingleMode, blankFrame(), FrameMeta{})
+			return d.Stop(i)
+		})
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	d.CloseDevices()
+	d.Close()
+	return nil
+}
@@ -0,0 +1,52 @@
+package helios
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWriteGate(t *testing.T) {
+	var g writeGate
+	if !g.enter() {
+		t.Fatal("open gate rejected a write")
+	}
+	done := g.close()
+	if g.enter() {
+		t.Fatal("closing gate accepted a write")
+	}
+	select {
+	case <-done:
+		t.Fatal("close finished with a write in flight")
+	case <-time.After(10 * time.Millisecond):
+	}
+	g.leave()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("close did not finish after the write left")
+	}
+}
+
+func TestShutdownDeadline(t *testing.T) {
+	d := NewDAC()
+	d.numDevices = 1
+	unlock := d.lockDevice(0) // a device call that never returns
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := d.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Shutdown = %v, want DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Shutdown took %v past its deadline", elapsed)
+	}
+
+	unlock()
+	d.Close()
+	// A stop queued by the timed out Shutdown may run after Close.
+	if code := d.Stop(0); Backend == "cgo" && code != int(ErrDeviceClosed) {
+		t.Fatalf("Stop after Close = %d, want ErrDeviceClosed", code)
+	}
+}
@@ -0,0 +1,130 @@
+package helios
+
+import (
+	"errors"
+	"math"
+)
+
+// A slew limit keeps the scanners from being commanded jumps they cannot
+// follow. A content bug, or a misbehaving sender on the network, can put
+// consecutive points at opposite edges of the scan field; the mirrors then
+// overshoot and ring, and repeated full-scale steps stress them. With a
+// limit set, every step between consecutive points longer than the limit
+// on either axis is broken into interpolated steps within it, including the
+// step from the last point back to the first as the frame loops. The
+// inserted points take the color of the point they lead to, which the
+// device would have drawn the jump in anyway, so frames look the same and
+// only take longer to play. The stage runs before splitting and
+// validation, so a frame grown past the device's size is split or rejected
+// like any other.
+
+// SetSlewLimit sets the largest step of one device between consecutive
+// points, in device coordinates (0 - 4095) on each axis, taking effect from
+// the next frame written. Zero removes the limit.
+func (d *DAC) SetSlewLimit(deviceIndex int, limit float64) error {
+	if !(limit >= 0) || math.IsInf(limit, 1) {
+		return errors.New("helios: slew limit must be a positive number or zero")
+	}
+	d.levels.mu.Lock()
+	defer d.levels.mu.Unlock()
+	if limit == 0 {
+		delete(d.levels.slewLimits, deviceIndex)
+		return nil
+	}
+	if d.levels.slewLimits == nil {
+		d.levels.slewLimits = make(map[int]float64)
+	}
+	d.levels.slewLimits[deviceIndex] = limit
+	return nil
+}
+
+// SlewLimit returns the slew limit of one device, zero if it has none.
+func (d *DAC) SlewLimit(deviceIndex int) float64 {
+	return d.levels.slewLimit(deviceIndex)
+}
+
+func (l *levels) slewLimit(deviceIndex int) float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.slewLimits[deviceIndex]
+}
+
+// LimitSlew returns points with interpolated points inserted wherever a
+// step is longer than limit, as the slew stage of WriteFrame does, or
+// points itself if no step is. The caller's slice is never modified.
+func LimitSlew(points []Point, limit float64) []Point {
+	return slewPoints(points, limit)
+}
+
+// highResScale converts 12-bit device coordinates to the 16-bit ones of
+// PointHighRes and PointExt.
+const highResScale = 0xFFFF / float64(maxCoord)
+
+func slewPoints(points []Point, limit float64) []Point {
+	return limitSlew(points, limit,
+		func(p Point) (uint16, uint16) { return p.X, p.Y },
+		func(p Point, x, y float64) Point {
+			p.X, p.Y = ClampToCoord(x), ClampToCoord(y)
+			return p
+		})
+}
+
+func slewPointsHighRes(points []PointHighRes, limit float64) []PointHighRes {
+	return limitSlew(points, limit*highResScale,
+		func(p PointHighRes) (uint16, uint16) { return p.X, p.Y },
+		func(p PointHighRes, x, y float64) PointHighRes {
+			p.X, p.Y = ClampToCoordHighRes(x), ClampToCoordHighRes(y)
+			return p
+		})
+}
+
+func slewPointsExt(points []PointExt, limit float64) []PointExt {
+	return limitSlew(points, limit*highResScale,
+		func(p PointExt) (uint16, uint16) { return p.X, p.Y },
+		func(p PointExt, x, y float64) PointExt {
+			p.X, p.Y = ClampToCoordHighRes(x), ClampToCoordHighRes(y)
+			return p
+		})
+}
+
+// limitSlew inserts points between any two consecutive points, and between
+// the last and the first, further apart than limit on either axis. pos
+// returns the position of a point and move a copy of it at another
+// position.
+func limitSlew[P any](points []P, limit float64, pos func(P) (uint16, uint16), move func(P, float64, float64) P) []P {
+	n := len(points)
+	if limit <= 0 || n < 2 {
+		return points
+	}
+	// steps returns the number of steps needed from point i-1 to point i.
+	steps := func(i int) int {
+		ax, ay := pos(points[i-1])
+		bx, by := pos(points[i%n])
+		d := max(math.Abs(float64(bx)-float64(ax)), math.Abs(float64(by)-float64(ay)))
+		return int(math.Ceil(d / limit))
+	}
+	extra := 0
+	for i := 1; i <= n; i++ {
+		extra += max(steps(i)-1, 0)
+	}
+	if extra == 0 {
+		return points
+	}
+
+	out := make([]P, 0, n+extra)
+	out = append(out, points[0])
+	for i := 1; i <= n; i++ {
+		a, b := points[i-1], points[i%n]
+		ax, ay := pos(a)
+		bx, by := pos(b)
+		k := steps(i)
+		for j := 1; j < k; j++ {
+			t := float64(j) / float64(k)
+			out = append(out, move(b, float64(ax)+(float64(bx)-float64(ax))*t, float64(ay)+(float64(by)-float64(ay))*t))
+		}
+		if i < n {
+			out = append(out, b)
+		}
+	}
+	return out
+}
@@ -0,0 +1,70 @@
+package helios
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLimitSlew(t *testing.T) {
+	lit := Point{X: 3000, Y: 1000, G: 255, I: 255}
+	points := []Point{{X: 1000, Y: 1000}, lit, {X: 3100, Y: 1000}}
+	out := LimitSlew(points, 500)
+	want := []Point{
+		{X: 1000, Y: 1000},
+		{X: 1500, Y: 1000, G: 255, I: 255},
+		{X: 2000, Y: 1000, G: 255, I: 255},
+		{X: 2500, Y: 1000, G: 255, I: 255},
+		lit,
+		{X: 3100, Y: 1000},
+		// Back to the first point, as the frame loops.
+		{X: 2680, Y: 1000},
+		{X: 2260, Y: 1000},
+		{X: 1840, Y: 1000},
+		{X: 1420, Y: 1000},
+	}
+	if len(out) != len(want) {
+		t.Fatalf("LimitSlew = %+v", out)
+	}
+	for i := range want {
+		if out[i] != want[i] {
+			t.Errorf("point %d = %+v, want %+v", i, out[i], want[i])
+		}
+	}
+	if points[1] != lit || len(points) != 3 {
+		t.Fatal("LimitSlew modified the frame")
+	}
+
+	// Steps within the limit on both axes leave the frame as it is.
+	small := []Point{{X: 0, Y: 0}, {X: 500, Y: 500}}
+	if got := LimitSlew(small, 500); &got[0] != &small[0] {
+		t.Error("frame within the limit was copied")
+	}
+	if got := LimitSlew(points, 0); &got[0] != &points[0] {
+		t.Error("zero limit copied the frame")
+	}
+}
+
+func TestLimitSlewHighRes(t *testing.T) {
+	// The limit is in 12-bit units, so a full-scale 16-bit step at a
+	// limit of half the range takes two steps.
+	out := slewPointsHighRes([]PointHighRes{{X: 0}, {X: 0xFFFF}}, maxCoord/2.0)
+	if len(out) != 4 || out[1].X != 0x8000 || out[3].X != 0x8000 {
+		t.Fatalf("slewPointsHighRes = %+v", out)
+	}
+}
+
+func TestSetSlewLimit(t *testing.T) {
+	d := &DAC{levels: newLevels()}
+	for _, bad := range []float64{-1, math.NaN(), math.Inf(1)} {
+		if err := d.SetSlewLimit(0, bad); err == nil {
+			t.Errorf("SetSlewLimit(%v) accepted", bad)
+		}
+	}
+	if err := d.SetSlewLimit(1, 300); err != nil || d.SlewLimit(1) != 300 || d.SlewLimit(0) != 0 {
+		t.Fatalf("SlewLimit = %v, %v, err %v", d.SlewLimit(1), d.SlewLimit(0), err)
+	}
+	d.SetSlewLimit(1, 0)
+	if _, ok := d.levels.slewLimits[1]; ok {
+		t.Error("zero limit kept")
+	}
+}
@@ -0,0 +1,28 @@
+package helios
+
+import "time"
+
+// SetSoftStart makes each device fade in over d when its output starts: on
+// the first frame written to it, after Stop, and after a global or device
+// blackout ends. The fade multiplies with the other intensities, so nothing
+// is ever switched on at full power at once. Zero, the default, turns it
+// off; fades in progress follow the new duration.
+func (d *DAC) SetSoftStart(dur time.Duration) {
+	d.levels.mu.Lock()
+	defer d.levels.mu.Unlock()
+	d.levels.softStart = max(dur, 0)
+}
+
+// SoftStart returns the soft start duration.
+func (d *DAC) SoftStart() time.Duration {
+	d.levels.mu.Lock()
+	defer d.levels.mu.Unlock()
+	return d.levels.softStart
+}
+
+// restart makes the next frame written to a device start its output again.
+func (l *levels) restart(deviceIndex int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.started, deviceIndex)
+}
@@ -0,0 +1,57 @@
+package helios
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSoftStart(t *testing.T) {
+	now := time.Unix(0, 0)
+	d := &DAC{levels: newLevels()}
+	d.levels.now = func() time.Time { return now }
+	d.SetSoftStart(time.Second)
+	d.SetMasterIntensity(0.5)
+
+	// Explaining a frame does not start the output.
+	if got := d.levels.peek(0); got != 0 {
+		t.Fatalf("peek before output = %v, want 0", got)
+	}
+	now = now.Add(time.Second)
+	if got := d.levels.scale(0); got != 0 {
+		t.Fatalf("first frame = %v, want 0", got)
+	}
+	now = now.Add(250 * time.Millisecond)
+	if got := d.levels.scale(0); got != 0.125 {
+		t.Fatalf("after 250ms = %v, want 0.125", got)
+	}
+	now = now.Add(time.Second)
+	if got := d.levels.scale(0); got != 0.5 {
+		t.Fatalf("after the fade = %v, want 0.5", got)
+	}
+
+	// A blackout, even one no frame was written during, restarts the fade.
+	d.SetBlackout(true)
+	d.SetBlackout(false)
+	d.levels.scale(0)
+	now = now.Add(500 * time.Millisecond)
+	if got := d.levels.scale(0); got != 0.25 {
+		t.Fatalf("after a blackout = %v, want 0.25", got)
+	}
+
+	// So does Stop, for that device only.
+	d.levels.scale(1)
+	now = now.Add(time.Second)
+	d.levels.restart(0)
+	if d.levels.scale(0) != 0 || d.levels.scale(1) != 0.5 {
+		t.Fatal("restart affected the wrong device")
+	}
+
+	// Turning soft start on does not dim running output.
+	d.SetSoftStart(0)
+	d.levels.scale(2)
+	now = now.Add(time.Minute)
+	d.SetSoftStart(time.Second)
+	if got := d.levels.scale(2); got != 0.5 {
+		t.Fatalf("running device = %v, want 0.5", got)
+	}
+}
@@ -0,0 +1,78 @@
+package solar
+
+import (
+	"context"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/color"
+	"github.com/Grix/helios_dac/sdk/go/ease"
+)
+
+// Schedule ramps brightness between Day and Night levels around sunset and
+// sunrise.
+type Schedule struct {
+	Location Location `json:"location"`
+
+	// Day and Night are the brightness levels (0.0 - 1.0) while the sun is
+	// up and down.
+	Day   float64 `json:"day"`
+	Night float64 `json:"night"`
+
+	// Ramp is the length of each transition. The transition is centered on
+	// sunset (or sunrise) shifted by the corresponding offset; a positive
+	// SunsetOffset delays dimming into the evening.
+	Ramp          time.Duration `json:"ramp"`
+	SunsetOffset  time.Duration `json:"sunset_offset"`
+	SunriseOffset time.Duration `json:"sunrise_offset"`
+
+	// Easing shapes the ramps. Nil means linear.
+	Easing ease.Func `json:"-"`
+}
+
+// Brightness returns the scheduled brightness at t.
+func (s *Schedule) Brightness(t time.Time) float64 {
+	sun := s.Location.SunTimes(t)
+	switch {
+	case sun.AlwaysUp:
+		return s.Day
+	case sun.AlwaysDown:
+		return s.Night
+	}
+	sunrise := sun.Sunrise.Add(s.SunriseOffset)
+	sunset := sun.Sunset.Add(s.SunsetOffset)
+	if t.Before(sunrise.Add(sunset.Sub(sunrise) / 2)) {
+		return ease.Lerp(s.Easing, s.Night, s.Day, s.progress(t, sunrise))
+	}
+	return ease.Lerp(s.Easing, s.Day, s.Night, s.progress(t, sunset))
+}
+
+// progress returns how far t is through the ramp centered on center.
+func (s *Schedule) progress(t, center time.Time) float64 {
+	if s.Ramp <= 0 {
+		if t.Before(center) {
+			return 0
+		}
+		return 1
+	}
+	return float64(t.Sub(center)+s.Ramp/2) / float64(s.Ramp)
+}
+
+// Profile returns base dimmed to the scheduled brightness at t.
+func (s *Schedule) Profile(base *color.Profile, t time.Time) *color.Profile {
+	return base.WithBrightness(s.Brightness(t))
+}
+
+// Run sets the profile of sw to base at the scheduled brightness now and
+// then every interval, until ctx is done. It returns ctx.Err().
+func (s *Schedule) Run(ctx context.Context, sw *color.Switch, base *color.Profile, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		sw.Set(s.Profile(base, time.Now()))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package solar
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/color"
+)
+
+var london = Location{Latitude: 51.5074, Longitude: -0.1278}
+
+func near(t *testing.T, what string, got time.Time, want string) {
+	t.Helper()
+	w, err := time.ParseInLocation("2006-01-02 15:04", want, got.Location())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := got.Sub(w); d < -3*time.Minute || d > 3*time.Minute {
+		t.Errorf("%s = %v, want about %v", what, got, w)
+	}
+}
+
+func TestSunTimes(t *testing.T) {
+	utc := time.Date(2024, 6, 21, 0, 0, 0, 0, time.UTC)
+	sun := london.SunTimes(utc)
+	near(t, "sunrise", sun.Sunrise, "2024-06-21 03:43")
+	near(t, "sunset", sun.Sunset, "2024-06-21 20:21")
+
+	winter := london.SunTimes(time.Date(2024, 12, 21, 0, 0, 0, 0, time.UTC))
+	near(t, "winter sunrise", winter.Sunrise, "2024-12-21 08:04")
+	near(t, "winter sunset", winter.Sunset, "2024-12-21 15:53")
+
+	tromso := Location{Latitude: 69.65, Longitude: 18.96}
+	if s := tromso.SunTimes(utc); !s.AlwaysUp {
+		t.Errorf("Tromsø midsummer = %+v, want AlwaysUp", s)
+	}
+	if s := tromso.SunTimes(time.Date(2024, 12, 21, 0, 0, 0, 0, time.UTC)); !s.AlwaysDown {
+		t.Errorf("Tromsø midwinter = %+v, want AlwaysDown", s)
+	}
+}
+
+func TestScheduleBrightness(t *testing.T) {
+	s := &Schedule{Location: london, Day: 1, Night: 0.2, Ramp: time.Hour}
+	day := time.Date(2024, 6, 21, 0, 0, 0, 0, time.UTC)
+	sun := london.SunTimes(day)
+
+	cases := []struct {
+		t    time.Time
+		want float64
+	}{
+		{day.Add(12 * time.Hour), 1},
+		{day.Add(1 * time.Hour), 0.2},
+		{sun.Sunset, 0.6},
+		{sun.Sunset.Add(30 * time.Minute), 0.2},
+		{sun.Sunrise.Add(-15 * time.Minute), 0.4},
+	}
+	for _, c := range cases {
+		if got := s.Brightness(c.t); math.Abs(got-c.want) > 1e-6 {
+			t.Errorf("Brightness(%v) = %v, want %v", c.t, got, c.want)
+		}
+	}
+
+	half := 0.5
+	p := s.Profile(&color.Profile{Name: "facade", Brightness: &half}, sun.Sunset)
+	if p.Name != "facade" || p.Brightness == nil {
+		t.Fatalf("Profile at sunset = %+v", p)
+	}
+	if math.Abs(*p.Brightness-0.3) > 1e-6 {
+		t.Errorf("Profile at sunset brightness %v, want 0.3", *p.Brightness)
+	}
+}
@@ -0,0 +1,74 @@
+// Package solar schedules brightness relative to local sunrise and sunset.
+//
+// Sun times are computed from latitude and longitude with the NOAA sunrise
+// equation, which is accurate to about a minute away from the polar circles.
+// A Schedule ramps between a day and a night brightness around those times
+// and can drive a color.Switch, the usual setup for façade projections that
+// must follow the ambient light.
+package solar
+
+import (
+	"math"
+	"time"
+)
+
+// Location is a position on Earth in degrees. Latitude is positive north,
+// Longitude positive east.
+type Location struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// SunTimes holds the sunrise and sunset of one day. Above the polar circles
+// the sun may not rise or set; then Sunrise and Sunset are zero and either
+// AlwaysUp or AlwaysDown is set.
+type SunTimes struct {
+	Sunrise, Sunset      time.Time
+	AlwaysUp, AlwaysDown bool
+}
+
+const (
+	julianUnixEpoch = 2440587.5
+	julian2000      = 2451545.0
+
+	// Sun altitude at sunrise/sunset, accounting for refraction and the
+	// solar disc.
+	horizon = -0.833
+)
+
+func rad(deg float64) float64 { return deg * math.Pi / 180 }
+func deg(rad float64) float64 { return rad * 180 / math.Pi }
+
+// SunTimes computes sunrise and sunset on the calendar day of date in
+// date's time zone. The returned times are in the same time zone.
+func (l Location) SunTimes(date time.Time) SunTimes {
+	y, m, d := date.Date()
+	noon := time.Date(y, m, d, 12, 0, 0, 0, time.UTC)
+	n := math.Round(float64(noon.Unix())/86400 + julianUnixEpoch - julian2000 + 0.0008)
+
+	meanNoon := n - l.Longitude/360
+	anomaly := math.Mod(357.5291+0.98560028*meanNoon, 360)
+	center := 1.9148*math.Sin(rad(anomaly)) + 0.02*math.Sin(rad(2*anomaly)) + 0.0003*math.Sin(rad(3*anomaly))
+	longitude := math.Mod(anomaly+center+180+102.9372, 360)
+	transit := julian2000 + meanNoon + 0.0053*math.Sin(rad(anomaly)) - 0.0069*math.Sin(rad(2*longitude))
+
+	sinDecl := math.Sin(rad(longitude)) * math.Sin(rad(23.4397))
+	cosDecl := math.Cos(math.Asin(sinDecl))
+	cosHour := (math.Sin(rad(horizon)) - math.Sin(rad(l.Latitude))*sinDecl) / (math.Cos(rad(l.Latitude)) * cosDecl)
+	switch {
+	case cosHour < -1:
+		return SunTimes{AlwaysUp: true}
+	case cosHour > 1:
+		return SunTimes{AlwaysDown: true}
+	}
+	hour := deg(math.Acos(cosHour)) / 360
+	return SunTimes{
+		Sunrise: fromJulian(transit-hour, date.Location()),
+		Sunset:  fromJulian(transit+hour, date.Location()),
+	}
+}
+
+func fromJulian(j float64, loc *time.Location) time.Time {
+	sec := (j - julianUnixEpoch) * 86400
+	return time.Unix(0, int64(sec*float64(time.Second))).In(loc)
+}
@@ -0,0 +1,75 @@
+package helios
+
+import "time"
+
+// SetSplitFrames sets whether frames with more points than the device
+// accepts are split into consecutive writes that scan back to back, instead
+// of failing with ErrTooManyPoints. The default is on; turn it off for
+// strict behavior. Frames fitted by SetAdaptFrames are never split.
+//
+// A split write holds the device until the last chunk has been queued, which
+// takes about as long as scanning all chunks but the last.
+func (d *DAC) SetSplitFrames(on bool) {
+	d.noSplit.Store(!on)
+}
+
+// SplitFrames reports whether SetSplitFrames is on.
+func (d *DAC) SplitFrames() bool {
+	return !d.noSplit.Load()
+}
+
+// splitLimits returns the limits to validate a frame of n points against
+// and the chunk size to write it in.
+func (d *DAC) splitLimits(limits FrameLimits, n int) (FrameLimits, int) {
+	if !d.SplitFrames() || n <= limits.MaxPoints {
+		return limits, n
+	}
+	chunk := limits.MaxPoints
+	limits.MaxPoints = n
+	return limits, chunk
+}
+
+// splitPollInterval is how often writeSplit polls the device between
+// chunks.
+var splitPollInterval = DefaultPollInterval
+
+// splitReadyMargin is how long past the scan time of the chunk before
+// writeSplit waits for the device to become ready for the next one.
+var splitReadyMargin = 500 * time.Millisecond
+
+// writeSplit writes points in chunks of at most size points, played at pps.
+// Each chunk after the first waits until the device is ready, so it is
+// queued behind the one playing; a device not ready within the scan time of
+// that chunk and splitReadyMargin fails the write with ErrTimeout, so a
+// stuck device does not hold the device lock forever. Only the first chunk
+// may interrupt the current frame, and every chunk but the last plays
+// once; the last keeps the caller's looping choice. It returns the first
+// failing result code, or the result of the last write.
+func writeSplit[P any](points []P, size, pps, flags int, status func() int, write func(chunk []P, flags int) int) int {
+	size = max(size, 1)
+	result := Success
+	for start := 0; start < len(points); start += size {
+		end := min(start+size, len(points))
+		f := flags
+		if start > 0 {
+			f &^= FlagStartImmediately
+			deadline := time.Now().Add(time.Duration(size)*time.Second/time.Duration(max(pps, 1)) + splitReadyMargin)
+			for s := status(); s != 1; s = status() {
+				if s < 0 {
+					return s
+				}
+				if time.Now().After(deadline) {
+					return int(ErrTimeout)
+				}
+				time.Sleep(splitPollInterval)
+			}
+		}
+		if end < len(points) {
+			f |= FlagSingleMode
+		}
+		if result = write(points[start:end], f); result < 0 {
+			return result
+		}
+	}
+	return result
+}
@@ -0,0 +1,74 @@
+package helios
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestWriteSplit(t *testing.T) {
+	defer func(d time.Duration) { splitPollInterval = d }(splitPollInterval)
+	splitPollInterval = 0
+	points := []int{0, 1, 2, 3, 4, 5, 6}
+	var chunks [][]int
+	var flags []int
+	polls := 0
+	status := func() int {
+		polls++
+		return polls % 2 // busy on every other poll
+	}
+	write := func(chunk []int, f int) int {
+		chunks = append(chunks, chunk)
+		flags = append(flags, f)
+		return Success
+	}
+
+	if r := writeSplit(points, 3, 30000, FlagStartImmediately, status, write); r != Success {
+		t.Fatalf("result = %d", r)
+	}
+	if len(chunks) != 3 || !slices.Equal(chunks[2], []int{6}) {
+		t.Fatalf("chunks = %v", chunks)
+	}
+	// Only the first chunk interrupts, and only the last one loops.
+	want := []int{FlagStartImmediately | FlagSingleMode, FlagSingleMode, 0}
+	if !slices.Equal(flags, want) {
+		t.Fatalf("flags = %v, want %v", flags, want)
+	}
+	if polls < 2 {
+		t.Fatalf("polled %d times between chunks", polls)
+	}
+
+	chunks, flags = nil, nil
+	writeSplit(points, len(points), 30000, FlagsDefault, status, write)
+	if len(chunks) != 1 || flags[0] != FlagsDefault {
+		t.Fatalf("unsplit frame written as %v with flags %v", chunks, flags)
+	}
+
+	closed := func() int { return int(ErrDeviceClosed) }
+	chunks = nil
+	if r := writeSplit(points, 3, 30000, FlagsDefault, closed, write); r != int(ErrDeviceClosed) || len(chunks) != 1 {
+		t.Fatalf("closed device: result %d after %d chunks", r, len(chunks))
+	}
+
+	// A device that never becomes ready times out instead of holding the
+	// write forever.
+	defer func(d time.Duration) { splitReadyMargin = d }(splitReadyMargin)
+	splitReadyMargin = time.Millisecond
+	busy := func() int { return 0 }
+	chunks = nil
+	if r := writeSplit(points, 3, 30000, FlagsDefault, busy, write); r != int(ErrTimeout) || len(chunks) != 1 {
+		t.Fatalf("busy device: result %d after %d chunks", r, len(chunks))
+	}
+}
+
+func TestSplitLimits(t *testing.T) {
+	d := &DAC{}
+	limits, chunk := d.splitLimits(LimitsUsb, 10000)
+	if limits.MaxPoints != 10000 || chunk != LimitsUsb.MaxPoints {
+		t.Fatalf("split: limits %+v, chunk %d", limits, chunk)
+	}
+	d.SetSplitFrames(false)
+	if limits, chunk := d.splitLimits(LimitsUsb, 10000); limits != LimitsUsb || chunk != 10000 {
+		t.Fatalf("strict: limits %+v, chunk %d", limits, chunk)
+	}
+}
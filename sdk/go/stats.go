@@ -0,0 +1,161 @@
+package helios
+
+import (
+	"sync"
+	"time"
+)
+
+// DeviceStats counts the output of one device since its counters were last
+// reset, so applications notice when they are not sustaining the point
+// rate they request and can simplify their content.
+type DeviceStats struct {
+	// Frames and Points count the frames written successfully and their
+	// points, after adapting to the device's limits.
+	Frames int64
+	Points int64
+
+	// Failed counts writes the device or validation rejected.
+	Failed int64
+
+	// Dropped counts frames submitted to a DeviceManager that were
+	// replaced by a newer frame before they could be written.
+	Dropped int64
+
+	// Playback is how long the frames written take to scan at their
+	// point rates.
+	Playback time.Duration
+
+	// Elapsed is the time from the first frame written to when the stats
+	// were read.
+	Elapsed time.Duration
+
+	// PPS is the point rate requested by the last frame written.
+	PPS int
+}
+
+// EffectivePPS returns the points written per second of Elapsed. It falls
+// below PPS when frames are not written as fast as the device scans them.
+func (s DeviceStats) EffectivePPS() float64 {
+	if s.Elapsed <= 0 {
+		return 0
+	}
+	return float64(s.Points) / s.Elapsed.Seconds()
+}
+
+// Utilization returns the share of Elapsed covered by Playback: about 1
+// when the device is kept busy, less when it runs out of frames.
+func (s DeviceStats) Utilization() float64 {
+	if s.Elapsed <= 0 {
+		return 0
+	}
+	return float64(s.Playback) / float64(s.Elapsed)
+}
+
+// stats holds the counters of every device.
+type stats struct {
+	mu      sync.Mutex
+	devices map[int]*deviceCounters
+	now     func() time.Time
+}
+
+type deviceCounters struct {
+	DeviceStats
+	first time.Time
+	end   time.Time // when the frames written finish scanning
+}
+
+func (s *stats) device(deviceIndex int) *deviceCounters {
+	if s.devices == nil {
+		s.devices = make(map[int]*deviceCounters)
+	}
+	c := s.devices[deviceIndex]
+	if c == nil {
+		c = &deviceCounters{}
+		s.devices[deviceIndex] = c
+	}
+	return c
+}
+
+func (s *stats) clock() time.Time {
+	if s.now != nil {
+		return s.now()
+	}
+	return time.Now()
+}
+
+// record counts a write of n points at pps with the given result code.
+func (s *stats) record(deviceIndex, n, pps, result int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := s.device(deviceIndex)
+	if result < 0 {
+		c.Failed++
+		return
+	}
+	if c.first.IsZero() {
+		c.first = s.clock()
+	}
+	c.Frames++
+	c.Points += int64(n)
+	c.PPS = pps
+	if pps > 0 {
+		play := time.Duration(n) * time.Second / time.Duration(pps)
+		c.Playback += play
+		// Each frame starts scanning when the frames before it finish.
+		start := s.clock()
+		if c.end.After(start) {
+			start = c.end
+		}
+		c.end = start.Add(play)
+	}
+}
+
+// stop forgets the output queued on a device.
+func (s *stats) stop(deviceIndex int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, ok := s.devices[deviceIndex]; ok {
+		c.end = time.Time{}
+	}
+}
+
+func (s *stats) drop(deviceIndex int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.device(deviceIndex).Dropped++
+}
+
+func (s *stats) get(deviceIndex int) DeviceStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.devices[deviceIndex]
+	if !ok {
+		return DeviceStats{}
+	}
+	out := c.DeviceStats
+	if !c.first.IsZero() {
+		out.Elapsed = s.clock().Sub(c.first)
+	}
+	return out
+}
+
+func (s *stats) reset(deviceIndex int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.devices, deviceIndex)
+}
+
+// Stats returns the output counters of a device.
+func (d *DAC) Stats(deviceIndex int) DeviceStats {
+	return d.stats.get(deviceIndex)
+}
+
+// ResetStats zeroes the output counters of a device.
+func (d *DAC) ResetStats(deviceIndex int) {
+	d.stats.reset(deviceIndex)
+}
+
+// dropFrame counts a frame a writer replaced before writing it.
+func (d *DAC) dropFrame(deviceIndex int) {
+	d.stats.drop(deviceIndex)
+}
@@ -0,0 +1,51 @@
+package helios
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	now := time.Unix(0, 0)
+	s := stats{now: func() time.Time { return now }}
+
+	if got := s.get(0); got != (DeviceStats{}) {
+		t.Fatalf("stats before any write = %+v", got)
+	}
+	// Ten frames of 1000 points at 30kpps take 1/3 s to scan, but only
+	// three are written per 100ms.
+	for range 10 {
+		s.record(0, 1000, 30000, 1)
+		now = now.Add(100 * time.Millisecond / 3)
+	}
+	s.record(0, 0, 30000, int(ErrPpsTooHigh))
+	s.drop(0)
+	s.record(1, 5, 1000, 1)
+
+	got := s.get(0)
+	want := DeviceStats{
+		Frames: 10, Points: 10000, Failed: 1, Dropped: 1,
+		Playback: 10 * (time.Second / 30), Elapsed: now.Sub(time.Unix(0, 0)), PPS: 30000,
+	}
+	if got != want {
+		t.Fatalf("stats = %+v, want %+v", got, want)
+	}
+	if pps := got.EffectivePPS(); pps < 29990 || pps > 30010 {
+		t.Errorf("EffectivePPS = %v, want 30000", pps)
+	}
+
+	// Time passing without frames lowers the effective rate.
+	now = now.Add(time.Second / 3)
+	got = s.get(0)
+	if pps, u := got.EffectivePPS(), got.Utilization(); pps > 15010 || u > 0.501 {
+		t.Errorf("after a stall EffectivePPS = %v, Utilization = %v", pps, u)
+	}
+
+	s.reset(0)
+	if got := s.get(0); got != (DeviceStats{}) {
+		t.Fatalf("stats after reset = %+v", got)
+	}
+	if got := s.get(1); got.Points != 5 {
+		t.Fatalf("reset cleared another device: %+v", got)
+	}
+}
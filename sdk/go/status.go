@@ -0,0 +1,101 @@
+package helios
+
+import "fmt"
+
+// StatusKind is the state of a device as reported by DeviceStatus.
+type StatusKind int
+
+const (
+	// StatusBusy means the device is still playing the previous frame.
+	StatusBusy StatusKind = iota
+
+	// StatusReady means the device accepts the next frame.
+	StatusReady
+
+	// StatusError means polling the device failed; DeviceStatus.Err holds
+	// the result code.
+	StatusError
+
+	// StatusClosed means the device has been closed, for example after it
+	// was unplugged. ReScanDevices reopens it if it is found again.
+	StatusClosed
+
+	// StatusReconnecting means the link to a network device failed. The
+	// device may answer again on a later poll.
+	StatusReconnecting
+)
+
+func (k StatusKind) String() string {
+	switch k {
+	case StatusBusy:
+		return "busy"
+	case StatusReady:
+		return "ready"
+	case StatusError:
+		return "error"
+	case StatusClosed:
+		return "closed"
+	case StatusReconnecting:
+		return "reconnecting"
+	}
+	return fmt.Sprintf("StatusKind(%d)", int(k))
+}
+
+// DeviceStatus is the typed form of the result code returned by GetStatus.
+type DeviceStatus struct {
+	Kind StatusKind
+
+	// Err is the result code for every kind but StatusReady and
+	// StatusBusy.
+	Err Error
+}
+
+// StatusFromCode converts a result code returned by GetStatus to a
+// DeviceStatus.
+func StatusFromCode(code int) DeviceStatus {
+	switch {
+	case code > 0:
+		return DeviceStatus{Kind: StatusReady}
+	case code == 0:
+		return DeviceStatus{Kind: StatusBusy}
+	case Error(code) == ErrDeviceClosed:
+		return DeviceStatus{Kind: StatusClosed, Err: ErrDeviceClosed}
+	case Error(code) == ErrNetwork:
+		return DeviceStatus{Kind: StatusReconnecting, Err: ErrNetwork}
+	}
+	return DeviceStatus{Kind: StatusError, Err: Error(code)}
+}
+
+// Ready reports whether the device accepts the next frame.
+func (s DeviceStatus) Ready() bool {
+	return s.Kind == StatusReady
+}
+
+// Healthy reports whether the device is ready or busy, that is, working
+// normally.
+func (s DeviceStatus) Healthy() bool {
+	return s.Kind == StatusReady || s.Kind == StatusBusy
+}
+
+// Code returns the GetStatus result code of s.
+func (s DeviceStatus) Code() int {
+	switch s.Kind {
+	case StatusReady:
+		return Success
+	case StatusBusy:
+		return 0
+	}
+	return int(s.Err)
+}
+
+func (s DeviceStatus) String() string {
+	if s.Kind == StatusError {
+		return fmt.Sprintf("error (%v)", s.Err)
+	}
+	return s.Kind.String()
+}
+
+// Status returns the typed status of the device.
+func (d *DAC) Status(deviceIndex int) DeviceStatus {
+	return StatusFromCode(d.GetStatus(deviceIndex))
+}
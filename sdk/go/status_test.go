@@ -0,0 +1,28 @@
+package helios
+
+import "testing"
+
+func TestStatusFromCode(t *testing.T) {
+	for _, tc := range []struct {
+		code int
+		want DeviceStatus
+		str  string
+	}{
+		{1, DeviceStatus{Kind: StatusReady}, "ready"},
+		{0, DeviceStatus{Kind: StatusBusy}, "busy"},
+		{int(ErrDeviceClosed), DeviceStatus{StatusClosed, ErrDeviceClosed}, "closed"},
+		{int(ErrNetwork), DeviceStatus{StatusReconnecting, ErrNetwork}, "reconnecting"},
+		{int(ErrInvalidDevNum), DeviceStatus{StatusError, ErrInvalidDevNum}, "error (helios: invalid device number)"},
+	} {
+		s := StatusFromCode(tc.code)
+		if s != tc.want {
+			t.Errorf("StatusFromCode(%d) = %+v, want %+v", tc.code, s, tc.want)
+		}
+		if s.String() != tc.str {
+			t.Errorf("StatusFromCode(%d).String() = %q, want %q", tc.code, s, tc.str)
+		}
+		if s.Code() != tc.code {
+			t.Errorf("StatusFromCode(%d).Code() = %d", tc.code, s.Code())
+		}
+	}
+}
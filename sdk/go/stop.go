@@ -0,0 +1,84 @@
+package helios
+
+import (
+	"sync"
+	"time"
+)
+
+// Stopping a device blocks for about 100ms, and a device that stops
+// responding can stall Stop and SetShutter for much longer. The variants
+// here keep emergency-stop paths from waiting on a slow device: the Async
+// ones return at once, the WithTimeout ones stop waiting after a timeout,
+// and StopAll stops every device in parallel. A call that is no longer
+// waited for still runs to completion in the background, holding its
+// device, so later calls for that device wait for it.
+
+// StopAsync stops output of one device without waiting for it. The
+// returned channel receives the result of Stop.
+func (d *DAC) StopAsync(deviceIndex int) <-chan int {
+	return async(func() int { return d.Stop(deviceIndex) })
+}
+
+// StopWithTimeout stops output of one device, waiting at most timeout for
+// the result. If the device takes longer it returns ErrTimeout, and the
+// stop carries on in the background.
+func (d *DAC) StopWithTimeout(deviceIndex int, timeout time.Duration) int {
+	return within(d.StopAsync(deviceIndex), timeout)
+}
+
+// StopAll stops output of every device in parallel, taking as long as the
+// slowest device rather than the sum of them, and returns the result of
+// Stop for each device by index.
+func (d *DAC) StopAll() []int {
+	return fanOut(d.NumDevices(), d.Stop)
+}
+
+// SetShutterAsync sets the shutter level of one device without waiting for
+// it. The returned channel receives the result of SetShutter.
+func (d *DAC) SetShutterAsync(deviceIndex int, level bool) <-chan int {
+	return async(func() int { return d.SetShutter(deviceIndex, level) })
+}
+
+// SetShutterWithTimeout sets the shutter level of one device, waiting at
+// most timeout for the result. If the device takes longer it returns
+// ErrTimeout, and the call carries on in the background.
+func (d *DAC) SetShutterWithTimeout(deviceIndex int, level bool, timeout time.Duration) int {
+	return within(d.SetShutterAsync(deviceIndex, level), timeout)
+}
+
+// async runs f in a new goroutine and returns a channel receiving its
+// result, buffered so the goroutine never blocks if nobody receives it.
+func async(f func() int) <-chan int {
+	c := make(chan int, 1)
+	go func() { c <- f() }()
+	return c
+}
+
+// within returns the result received from c, or ErrTimeout if none arrives
+// within timeout.
+func within(c <-chan int, timeout time.Duration) int {
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+	select {
+	case code := <-c:
+		return code
+	case <-t.C:
+		return int(ErrTimeout)
+	}
+}
+
+// fanOut calls f for device indexes 0 to n-1 in parallel and returns the
+// results by index.
+func fanOut(n int, f func(deviceIndex int) int) []int {
+	results := make([]int, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = f(i)
+		}()
+	}
+	wg.Wait()
+	return results
+}
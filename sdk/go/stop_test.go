@@ -0,0 +1,52 @@
+package helios
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithin(t *testing.T) {
+	if got := within(async(func() int { return Success }), time.Second); got != Success {
+		t.Errorf("prompt call = %d, want %d", got, Success)
+	}
+
+	release := make(chan struct{})
+	defer close(release)
+	start := time.Now()
+	slow := async(func() int {
+		<-release
+		return Success
+	})
+	if got := within(slow, 20*time.Millisecond); got != int(ErrTimeout) {
+		t.Errorf("stalled call = %d, want ErrTimeout", got)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("gave up after %v", elapsed)
+	}
+}
+
+func TestFanOut(t *testing.T) {
+	var running, most atomic.Int32
+	start := time.Now()
+	results := fanOut(8, func(i int) int {
+		n := running.Add(1)
+		for m := most.Load(); n > m && !most.CompareAndSwap(m, n); m = most.Load() {
+		}
+		time.Sleep(50 * time.Millisecond)
+		running.Add(-1)
+		return -i
+	})
+	for i, r := range results {
+		if r != -i {
+			t.Errorf("result %d = %d", i, r)
+		}
+	}
+	// Eight 50ms stops in a row would take 400ms.
+	if elapsed := time.Since(start); elapsed >= 400*time.Millisecond || most.Load() < 2 {
+		t.Errorf("took %v with at most %d at once", elapsed, most.Load())
+	}
+	if got := fanOut(0, nil); len(got) != 0 {
+		t.Errorf("no devices gave %v", got)
+	}
+}
@@ -0,0 +1,103 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrNewerVersion is returned by Schema.Decode for documents written by a
+// newer version of the software, which this version would misread.
+var ErrNewerVersion = errors.New("store: document is from a newer version")
+
+// Migration upgrades a decoded JSON document by one version. Objects are
+// map[string]any, arrays []any and numbers json.Number.
+type Migration func(doc any) (any, error)
+
+// Schema versions a JSON document format, so stored configuration,
+// calibration and safety settings are upgraded explicitly when the format
+// changes instead of being silently reinterpreted or dropped.
+//
+// Encode adds a "version" member to the document's top-level object.
+// Decode reads it, treating documents without one (including those that
+// are not objects) as version 1, applies the migrations up to the current
+// version and decodes the result strictly: members the current type does
+// not have are an error rather than lost.
+type Schema struct {
+	// Name describes the documents in errors, such as "show snapshot".
+	Name string
+
+	// Version is the current version, at least 1.
+	Version int
+
+	// Migrations[v] upgrades a document from version v to v+1.
+	Migrations map[int]Migration
+}
+
+// Encode marshals v, which must encode as a JSON object, as indented JSON
+// with the current version.
+func (s Schema) Encode(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", s.Name, err)
+	}
+	var doc map[string]any
+	if err := unmarshal(data, &doc); err != nil || doc == nil {
+		return nil, fmt.Errorf("%s: %T does not encode as an object", s.Name, v)
+	}
+	doc["version"] = s.Version
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// Decode upgrades data to the current version and decodes it into v.
+func (s Schema) Decode(data []byte, v any) error {
+	var doc any
+	if err := unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("%s: %w", s.Name, err)
+	}
+	version := 1
+	if obj, ok := doc.(map[string]any); ok {
+		if n, ok := obj["version"]; ok {
+			num, ok := n.(json.Number)
+			i, err := num.Int64()
+			if !ok || err != nil || i < 1 {
+				return fmt.Errorf("%s: invalid version %v", s.Name, n)
+			}
+			version = int(i)
+		}
+	}
+	if version > s.Version {
+		return fmt.Errorf("%s: version %d, newest supported %d: %w", s.Name, version, s.Version, ErrNewerVersion)
+	}
+	for ; version < s.Version; version++ {
+		m := s.Migrations[version]
+		if m == nil {
+			return fmt.Errorf("%s: no migration from version %d", s.Name, version)
+		}
+		var err error
+		if doc, err = m(doc); err != nil {
+			return fmt.Errorf("%s: migrating from version %d: %w", s.Name, version, err)
+		}
+	}
+	if obj, ok := doc.(map[string]any); ok {
+		delete(obj, "version")
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("%s: %w", s.Name, err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("%s: %w", s.Name, err)
+	}
+	return nil
+}
+
+// unmarshal decodes data keeping numbers exact.
+func unmarshal(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
@@ -0,0 +1,95 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"unicode/utf8"
+)
+
+// SQL stores values in a two-column table of a database/sql database. The
+// statements use SQLite syntax ("?" placeholders and upsert), which
+// PostgreSQL-compatible drivers translating placeholders also accept.
+//
+// Register a driver by importing it, then open the database as usual:
+//
+//	db, _ := sql.Open("sqlite", "/var/lib/laser/store.db")
+//	s, _ := store.NewSQL(ctx, db, "store")
+type SQL struct {
+	db    *sql.DB
+	table string
+}
+
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// NewSQL returns a store using table in db, creating the table if needed.
+func NewSQL(ctx context.Context, db *sql.DB, table string) (*SQL, error) {
+	if !identifier.MatchString(table) {
+		return nil, fmt.Errorf("store: invalid table name %q", table)
+	}
+	_, err := db.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+table+" (name TEXT PRIMARY KEY, data BLOB NOT NULL)")
+	if err != nil {
+		return nil, err
+	}
+	return &SQL{db: db, table: table}, nil
+}
+
+func (s *SQL) Get(ctx context.Context, key string) ([]byte, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	var data []byte
+	err := s.db.QueryRowContext(ctx, "SELECT data FROM "+s.table+" WHERE name = ?", key).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+func (s *SQL) Put(ctx context.Context, key string, data []byte) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx, "INSERT INTO "+s.table+" (name, data) VALUES (?, ?) "+
+		"ON CONFLICT (name) DO UPDATE SET data = excluded.data", key, data)
+	return err
+}
+
+// Append concatenates in the database, so appends from several hosts are not
+// lost. The values are concatenated as text, which suits log records.
+func (s *SQL) Append(ctx context.Context, key string, data []byte) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx, "INSERT INTO "+s.table+" (name, data) VALUES (?, ?) "+
+		"ON CONFLICT (name) DO UPDATE SET data = "+s.table+".data || excluded.data", key, data)
+	return err
+}
+
+func (s *SQL) Delete(ctx context.Context, key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx, "DELETE FROM "+s.table+" WHERE name = ?", key)
+	return err
+}
+
+func (s *SQL) List(ctx context.Context, prefix string) ([]string, error) {
+	// substr counts characters, not bytes.
+	rows, err := s.db.QueryContext(ctx, "SELECT name FROM "+s.table+" WHERE substr(name, 1, ?) = ? ORDER BY name", utf8.RuneCountInString(prefix), prefix)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var keys []string
+	for rows.Next() {
+		var k string
+		if err := rows.Scan(&k); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
@@ -0,0 +1,67 @@
+// Package store persists configuration, calibration and log data behind a
+// common key/value interface.
+//
+// Keys are slash-separated paths such as "calibration/projector-1.json".
+// Memory keeps data in process, Dir in a local directory, SQL in a table of
+// any database/sql database (SQLite with a registered driver, for example)
+// and S3 in an S3-compatible bucket, so a fleet of installations can share
+// one central store.
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNotFound is returned by Get for keys that are not stored.
+var ErrNotFound = errors.New("store: not found")
+
+// Store is a key/value store. Implementations are safe for concurrent use.
+type Store interface {
+	// Get returns the data stored under key, or ErrNotFound.
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// Put stores data under key, replacing any previous value.
+	Put(ctx context.Context, key string, data []byte) error
+
+	// Delete removes key. Deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+
+	// List returns the stored keys starting with prefix, sorted.
+	List(ctx context.Context, prefix string) ([]string, error)
+}
+
+// Appender is implemented by stores that can append to a value in place.
+type Appender interface {
+	Append(ctx context.Context, key string, data []byte) error
+}
+
+// Append appends data to the value under key, creating it if needed. It is
+// meant for logs. Stores without in-place append (see Appender) read and
+// rewrite the whole value, so concurrent appends to one key from several
+// hosts may lose records there; give each host its own log key.
+func Append(ctx context.Context, s Store, key string, data []byte) error {
+	if a, ok := s.(Appender); ok {
+		return a.Append(ctx, key, data)
+	}
+	old, err := s.Get(ctx, key)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	return s.Put(ctx, key, append(old, data...))
+}
+
+// checkKey rejects keys that could escape a store's namespace.
+func checkKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.HasSuffix(key, "/") {
+		return fmt.Errorf("store: invalid key %q", key)
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return fmt.Errorf("store: invalid key %q", key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,299 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// exercise runs the behavior every Store must share.
+func exercise(t *testing.T, s Store) {
+	t.Helper()
+	ctx := context.Background()
+
+	if _, err := s.Get(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get missing: %v, want ErrNotFound", err)
+	}
+	if err := s.Put(ctx, "../escape", []byte("x")); err == nil {
+		t.Fatal("Put accepted a key escaping the store")
+	}
+	if _, err := s.Get(ctx, "../escape"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get of a key escaping the store: %v", err)
+	}
+	if err := s.Delete(ctx, "../escape"); err == nil {
+		t.Fatal("Delete accepted a key escaping the store")
+	}
+	for _, k := range []string{"shows/b.json", "shows/a.json", "calibration/p1.json"} {
+		if err := s.Put(ctx, k, []byte(k)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if b, err := s.Get(ctx, "shows/a.json"); err != nil || string(b) != "shows/a.json" {
+		t.Fatalf("Get = %q, %v", b, err)
+	}
+	keys, err := s.List(ctx, "shows/")
+	if err != nil || !reflect.DeepEqual(keys, []string{"shows/a.json", "shows/b.json"}) {
+		t.Fatalf("List = %v, %v", keys, err)
+	}
+
+	Append(ctx, s, "logs/audit.log", []byte("one\n"))
+	Append(ctx, s, "logs/audit.log", []byte("two\n"))
+	if b, _ := s.Get(ctx, "logs/audit.log"); string(b) != "one\ntwo\n" {
+		t.Fatalf("log = %q", b)
+	}
+
+	if err := s.Delete(ctx, "shows/a.json"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(ctx, "shows/a.json"); err != nil {
+		t.Fatalf("second Delete: %v", err)
+	}
+	if _, err := s.Get(ctx, "shows/a.json"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get after Delete: %v", err)
+	}
+}
+
+func TestMemory(t *testing.T) {
+	exercise(t, NewMemory())
+}
+
+func TestDir(t *testing.T) {
+	d, err := NewDir(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	exercise(t, d)
+}
+
+// fakeS3 is a minimal path-style S3 server for one bucket.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") {
+		http.Error(w, "unsigned", http.StatusForbidden)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := strings.TrimPrefix(r.URL.Path, "/bucket/")
+	switch {
+	case r.URL.Path == "/bucket" && r.Method == http.MethodGet:
+		var result struct {
+			XMLName  xml.Name `xml:"ListBucketResult"`
+			Contents []struct{ Key string }
+		}
+		var keys []string
+		for k := range f.objects {
+			if strings.HasPrefix(k, r.URL.Query().Get("prefix")) {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			result.Contents = append(result.Contents, struct{ Key string }{k})
+		}
+		xml.NewEncoder(w).Encode(result)
+	case r.Method == http.MethodGet:
+		b, ok := f.objects[key]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(b)
+	case r.Method == http.MethodPut:
+		f.objects[key], _ = io.ReadAll(r.Body)
+	case r.Method == http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestS3(t *testing.T) {
+	fake := &fakeS3{objects: map[string][]byte{}}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	s := &S3{Endpoint: srv.URL, Region: "us-east-1", Bucket: "bucket", Prefix: "site-7/", AccessKey: "AKID", SecretKey: "secret"}
+	exercise(t, s)
+	if _, ok := fake.objects["site-7/calibration/p1.json"]; !ok {
+		t.Fatalf("objects = %v, want keys under the prefix", fake.objects)
+	}
+}
+
+// fakeSQL is a minimal database/sql driver for the statements SQL runs,
+// holding the table in memory. Like SQLite's, its substr counts characters.
+type fakeSQL struct {
+	mu   sync.Mutex
+	rows map[string][]byte
+}
+
+func (f *fakeSQL) Connect(context.Context) (driver.Conn, error) { return fakeSQLConn{f}, nil }
+func (f *fakeSQL) Driver() driver.Driver                        { return f }
+func (f *fakeSQL) Open(string) (driver.Conn, error)             { return fakeSQLConn{f}, nil }
+
+type fakeSQLConn struct{ f *fakeSQL }
+
+func (c fakeSQLConn) Prepare(query string) (driver.Stmt, error) { return fakeSQLStmt{c.f, query}, nil }
+func (c fakeSQLConn) Close() error                              { return nil }
+func (c fakeSQLConn) Begin() (driver.Tx, error)                 { return nil, errors.New("fake sql: no transactions") }
+
+type fakeSQLStmt struct {
+	f     *fakeSQL
+	query string
+}
+
+func (s fakeSQLStmt) Close() error  { return nil }
+func (s fakeSQLStmt) NumInput() int { return -1 }
+
+func (s fakeSQLStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.f.mu.Lock()
+	defer s.f.mu.Unlock()
+	switch {
+	case strings.HasPrefix(s.query, "CREATE TABLE "):
+	case strings.HasPrefix(s.query, "INSERT ") && strings.Contains(s.query, "|| excluded.data"):
+		key := args[0].(string)
+		s.f.rows[key] = append(s.f.rows[key], args[1].([]byte)...)
+	case strings.HasPrefix(s.query, "INSERT "):
+		s.f.rows[args[0].(string)] = append([]byte(nil), args[1].([]byte)...)
+	case strings.HasPrefix(s.query, "DELETE "):
+		delete(s.f.rows, args[0].(string))
+	default:
+		return nil, fmt.Errorf("fake sql: unexpected statement %q", s.query)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s fakeSQLStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.f.mu.Lock()
+	defer s.f.mu.Unlock()
+	rows := &fakeSQLRows{}
+	switch {
+	case strings.HasPrefix(s.query, "SELECT data "):
+		if b, ok := s.f.rows[args[0].(string)]; ok {
+			rows.values = append(rows.values, append([]byte(nil), b...))
+		}
+	case strings.HasPrefix(s.query, "SELECT name "):
+		n, prefix := int(args[0].(int64)), args[1].(string)
+		var keys []string
+		for k := range s.f.rows {
+			if r := []rune(k); string(r[:min(n, len(r))]) == prefix {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			rows.values = append(rows.values, k)
+		}
+	default:
+		return nil, fmt.Errorf("fake sql: unexpected query %q", s.query)
+	}
+	return rows, nil
+}
+
+// fakeSQLRows returns rows of one column.
+type fakeSQLRows struct {
+	values []driver.Value
+}
+
+func (r *fakeSQLRows) Columns() []string { return []string{"value"} }
+func (r *fakeSQLRows) Close() error      { return nil }
+
+func (r *fakeSQLRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	dest[0], r.values = r.values[0], r.values[1:]
+	return nil
+}
+
+func TestSQL(t *testing.T) {
+	ctx := context.Background()
+	db := sql.OpenDB(&fakeSQL{rows: map[string][]byte{}})
+	defer db.Close()
+	if _, err := NewSQL(ctx, db, "store; DROP TABLE x"); err == nil {
+		t.Fatal("NewSQL accepted an invalid table name")
+	}
+	s, err := NewSQL(ctx, db, "store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	exercise(t, s)
+
+	// The prefix is matched by characters, not bytes.
+	for _, k := range []string{"café/menu.json", "cafe/menu.json"} {
+		if err := s.Put(ctx, k, []byte(k)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if keys, err := s.List(ctx, "café/"); err != nil || !reflect.DeepEqual(keys, []string{"café/menu.json"}) {
+		t.Fatalf("List of a non-ASCII prefix = %v, %v", keys, err)
+	}
+}
+
+func TestSchema(t *testing.T) {
+	type doc struct {
+		Gain  float64 `json:"gain"`
+		Count int64   `json:"count"`
+	}
+	s := Schema{
+		Name:    "test doc",
+		Version: 3,
+		Migrations: map[int]Migration{
+			// Version 2 renamed "level" to "gain".
+			1: func(d any) (any, error) {
+				obj := d.(map[string]any)
+				obj["gain"] = obj["level"]
+				delete(obj, "level")
+				return obj, nil
+			},
+			// Version 3 added "count".
+			2: func(d any) (any, error) {
+				d.(map[string]any)["count"] = 7
+				return d, nil
+			},
+		},
+	}
+
+	data, err := s.Encode(doc{Gain: 0.5, Count: 1 << 60})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"version": 3`) {
+		t.Fatalf("encoded without version: %s", data)
+	}
+	var got doc
+	if err := s.Decode(data, &got); err != nil || got != (doc{0.5, 1 << 60}) {
+		t.Fatalf("round trip = %+v, %v", got, err)
+	}
+
+	got = doc{}
+	if err := s.Decode([]byte(`{"level": 0.25}`), &got); err != nil || got != (doc{0.25, 7}) {
+		t.Fatalf("unversioned doc = %+v, %v", got, err)
+	}
+	if err := s.Decode([]byte(`{"version": 4, "gain": 1}`), &got); !errors.Is(err, ErrNewerVersion) {
+		t.Fatalf("newer doc: err = %v", err)
+	}
+	if err := s.Decode([]byte(`{"version": 3, "gain": 1, "trim": 2}`), &got); err == nil {
+		t.Fatal("unknown member silently dropped")
+	}
+	if err := (Schema{Name: "gap", Version: 2}).Decode([]byte(`{}`), &got); err == nil {
+		t.Fatal("missing migration not reported")
+	}
+	if _, err := s.Encode([]int{1}); err == nil {
+		t.Fatal("array encoded without a version")
+	}
+}
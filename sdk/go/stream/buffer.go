@@ -0,0 +1,87 @@
+package stream
+
+import (
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/output"
+)
+
+// Buffering selects how far ahead of the output a Streamer renders,
+// trading the delay between rendering and scanning a frame against
+// tolerance for slow renders. It is chosen per Streamer, so per output.
+type Buffering int
+
+const (
+	// BufferDouble renders a frame whenever the output is ready for one,
+	// so a frame waits while the one before it scans. A render may take
+	// up to a frame's scan time without the output running out.
+	BufferDouble Buffering = iota
+
+	// BufferLowLatency waits after the output is ready until Lead before
+	// it runs out, then renders, so the frame scanned is as recent as
+	// possible. A render taking longer than Lead blanks the output until
+	// it is written. Outputs not implementing output.Buffered are written
+	// to as with BufferDouble.
+	BufferLowLatency
+
+	// BufferDeep renders up to Depth frames ahead while the output is
+	// busy, each for the animation time it will be scanned at, so a slow
+	// render is covered by the frames before it. Seeking, changing speed
+	// or pausing discards the frames rendered ahead.
+	BufferDeep
+)
+
+// DefaultLead is the Lead of a Streamer whose Lead is zero.
+const DefaultLead = 2 * time.Millisecond
+
+// DefaultDepth is the Depth of a Streamer whose Depth is zero.
+const DefaultDepth = 3
+
+// ahead is a rendered frame, kept by BufferDeep until the output is ready
+// for it and by GovernFrameRate to repeat it.
+type ahead struct {
+	points []helios.Point
+	meta   helios.FrameMeta
+	pps    int           // the rate the frame is played at
+	length time.Duration // scan time at pps, repeats included
+}
+
+func (s *Streamer) lead() time.Duration {
+	if s.Lead > 0 {
+		return s.Lead
+	}
+	return DefaultLead
+}
+
+func (s *Streamer) depth() int {
+	if s.Depth > 0 {
+		return s.Depth
+	}
+	return DefaultDepth
+}
+
+// buffered returns how long the output takes to scan what was written to
+// it, or false if neither it nor the outputs it wraps report that.
+func (s *Streamer) buffered() (time.Duration, bool, error) {
+	b, ok := output.AsBuffered(s.out)
+	if !ok {
+		return 0, false, nil
+	}
+	buf, err := b.Buffered()
+	return buf.Duration, err == nil, err
+}
+
+// renderAhead renders the frame scanned after the output's buffer and the
+// frames in queue.
+func (s *Streamer) renderAhead(queue []ahead, g *governor) (ahead, error) {
+	offset, _, err := s.buffered()
+	if err != nil {
+		return ahead{}, err
+	}
+	for _, f := range queue {
+		offset += f.length
+	}
+	t := s.transport.Now() + time.Duration(float64(offset)*s.transport.Speed())
+	return s.render(t, g), nil
+}
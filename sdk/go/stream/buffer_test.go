@@ -0,0 +1,123 @@
+package stream
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/output"
+)
+
+// gatedOutput is ready only when opened, reports a buffer draining in real
+// time, and records the X of the first point of each frame written.
+type gatedOutput struct {
+	fakeOutput
+	open    bool
+	end     time.Time // when the buffer runs out
+	written []uint16
+	at      []time.Time // when each frame was written
+}
+
+func (o *gatedOutput) Ready() (bool, error) {
+	time.Sleep(100 * time.Microsecond)
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.open, nil
+}
+
+func (o *gatedOutput) Buffered() (output.Buffer, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return output.Buffer{Duration: max(time.Until(o.end), 0)}, nil
+}
+
+func (o *gatedOutput) WriteFrame(pps int, points []helios.Point) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.written = append(o.written, points[0].X)
+	o.at = append(o.at, time.Now())
+	return nil
+}
+
+// Points draws budget points at X = t in milliseconds.
+func (o *gatedOutput) Points(t time.Duration, budget int) []helios.Point {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.times = append(o.times, t)
+	points := make([]helios.Point, budget)
+	for i := range points {
+		points[i].X = uint16(t / time.Millisecond)
+	}
+	return points
+}
+
+func (o *gatedOutput) setOpen(open bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.open = open
+}
+
+func (o *gatedOutput) state() (rendered []time.Duration, written []uint16, at []time.Time) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append(rendered, o.times...), append(written, o.written...), append(at, o.at...)
+}
+
+func run(t *testing.T, s *Streamer) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() { defer wg.Done(); s.Run(ctx) }()
+	t.Cleanup(func() { cancel(); wg.Wait() })
+}
+
+func TestBufferDeep(t *testing.T) {
+	out := &gatedOutput{}
+	s := New(out, out, &clock{})
+	s.PPS, s.Budget, s.Buffering = 1000, 10, BufferDeep
+	run(t, s)
+
+	// Frames of 10ms are rendered for when they follow each other.
+	waitFor(t, func() bool { r, _, _ := out.state(); return len(r) == DefaultDepth })
+	time.Sleep(5 * time.Millisecond)
+	rendered, written, _ := out.state()
+	if len(rendered) != 3 || rendered[0] != 0 || rendered[1] != 10*time.Millisecond || rendered[2] != 20*time.Millisecond || len(written) != 0 {
+		t.Fatalf("busy output: rendered %v, wrote %v", rendered, written)
+	}
+	out.setOpen(true)
+	waitFor(t, func() bool { _, w, _ := out.state(); return len(w) >= 3 })
+	if _, w, _ := out.state(); w[0] != 0 || w[1] != 10 || w[2] != 20 {
+		t.Fatalf("wrote %v, want 0 10 20 first", w)
+	}
+
+	// Seeking discards the frames rendered ahead.
+	out.setOpen(false)
+	s.Seek(time.Second)
+	waitFor(t, func() bool { r, _, _ := out.state(); return r[len(r)-1] == time.Second+20*time.Millisecond })
+	_, written, _ = out.state()
+	n := len(written)
+	out.setOpen(true)
+	waitFor(t, func() bool { _, w, _ := out.state(); return len(w) > n })
+	if _, w, _ := out.state(); w[n] != 1000 {
+		t.Fatalf("first frame after seeking is at %vms, want 1000ms", w[n])
+	}
+}
+
+func TestBufferLowLatency(t *testing.T) {
+	out := &gatedOutput{open: true}
+	start := time.Now()
+	out.end = start.Add(30 * time.Millisecond)
+	s := New(out, out, &clock{})
+	s.Buffering = BufferLowLatency
+	run(t, s)
+
+	// The output is ready, but the frame is rendered only Lead before its
+	// buffer runs out.
+	waitFor(t, func() bool { _, w, _ := out.state(); return len(w) > 0 })
+	if _, _, at := out.state(); at[0].Sub(start) < 30*time.Millisecond-2*DefaultLead {
+		t.Fatalf("frame written after %v, want about %v", at[0].Sub(start), 30*time.Millisecond-DefaultLead)
+	}
+}
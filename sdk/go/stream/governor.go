@@ -0,0 +1,167 @@
+package stream
+
+import (
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+// Governing selects what a Streamer gives up when the layer renders too
+// slowly to keep the output fed, as on a small single-board computer: each
+// step down halves the frame rate or the point budget, so the output keeps
+// scanning whole frames instead of running dry and flickering.
+//
+// A render misses its deadline when it takes longer than the output takes
+// to scan the frames written for it. After GovernMisses misses in a row the
+// stream steps down a level, up to MaxGovernLevel; after GovernRecover
+// renders in a row that would have been on time a level up, it steps back
+// up.
+type Governing int
+
+const (
+	// GovernOff never degrades the stream.
+	GovernOff Governing = iota
+
+	// GovernFrameRate renders every second, fourth or eighth frame and
+	// writes each rendered frame again in place of those skipped. Content
+	// keeps its detail but moves in coarser steps.
+	GovernFrameRate
+
+	// GovernBudget renders frames of a half, a quarter or an eighth of the
+	// points, so each takes less to render and to scan. Content keeps its
+	// motion but loses detail.
+	GovernBudget
+)
+
+// MaxGovernLevel is the lowest level a governed Streamer steps down to.
+const MaxGovernLevel = 3
+
+// DefaultGovernMisses is the GovernMisses of a Streamer whose GovernMisses
+// is zero.
+const DefaultGovernMisses = 3
+
+// DefaultGovernRecover is the GovernRecover of a Streamer whose
+// GovernRecover is zero.
+const DefaultGovernRecover = 120
+
+// Degradation is the level a governed Streamer runs at, passed to OnGovern
+// when it changes.
+type Degradation struct {
+	// Level is the number of steps down, from 0, running at full rate, to
+	// MaxGovernLevel.
+	Level int
+
+	// Repeat is how many times each rendered frame is written.
+	Repeat int
+
+	// Budget is the number of points rendered per frame.
+	Budget int
+
+	// Took and Deadline are the time the render changing the level took
+	// and the time it had. Streamer.Degradation leaves them zero.
+	Took, Deadline time.Duration
+}
+
+// governor counts renders missing or beating their deadline. It is only
+// used by Run.
+type governor struct {
+	misses, onTime int
+}
+
+func (s *Streamer) governMisses() int {
+	if s.GovernMisses > 0 {
+		return s.GovernMisses
+	}
+	return DefaultGovernMisses
+}
+
+func (s *Streamer) governRecover() int {
+	if s.GovernRecover > 0 {
+		return s.GovernRecover
+	}
+	return DefaultGovernRecover
+}
+
+// level returns the level the stream runs at. Only Run changes it, so Run
+// reads it without the lock.
+func (s *Streamer) level() int {
+	if s.Govern == GovernOff {
+		return 0
+	}
+	return s.governLevel
+}
+
+// repeat returns how many times each rendered frame is written.
+func (s *Streamer) repeat() int {
+	if s.Govern != GovernFrameRate {
+		return 1
+	}
+	return 1 << s.level()
+}
+
+// renderBudget returns the number of points to render per frame.
+func (s *Streamer) renderBudget() int {
+	if s.Govern != GovernBudget {
+		return s.budget()
+	}
+	return max(s.budget()>>s.level(), 1)
+}
+
+// deadline returns how long the output takes to scan the frames written
+// for a render of points played at pps.
+func (s *Streamer) deadline(points []helios.Point, pps int) time.Duration {
+	return time.Duration(len(points)*s.repeat()) * time.Second / time.Duration(pps)
+}
+
+// govern records a render that took took, against its deadline, and
+// changes the level if the renders have kept missing or beating their
+// deadlines. A render beats its deadline if it took less than half of it,
+// as a level up halves the deadline.
+func (s *Streamer) govern(g *governor, took, deadline time.Duration) {
+	if s.Govern == GovernOff {
+		return
+	}
+	level := s.level()
+	switch {
+	case took > deadline:
+		g.onTime = 0
+		if g.misses++; g.misses >= s.governMisses() && level < MaxGovernLevel {
+			g.misses = 0
+			s.setGovernLevel(level+1, took, deadline)
+		}
+	case level > 0 && took < deadline/2:
+		g.misses = 0
+		if g.onTime++; g.onTime >= s.governRecover() {
+			g.onTime = 0
+			s.setGovernLevel(level-1, took, deadline)
+		}
+	default:
+		g.misses, g.onTime = 0, 0
+	}
+}
+
+func (s *Streamer) setGovernLevel(level int, took, deadline time.Duration) {
+	s.mu.Lock()
+	s.governLevel = level
+	s.mu.Unlock()
+	if s.OnGovern != nil {
+		s.OnGovern(Degradation{
+			Level:    level,
+			Repeat:   s.repeat(),
+			Budget:   s.renderBudget(),
+			Took:     took,
+			Deadline: deadline,
+		})
+	}
+}
+
+// Degradation returns the level the stream runs at.
+func (s *Streamer) Degradation() Degradation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return Degradation{
+		Level:  s.level(),
+		Repeat: s.repeat(),
+		Budget: s.renderBudget(),
+	}
+}
@@ -0,0 +1,127 @@
+package stream
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+func TestGovernSteps(t *testing.T) {
+	var events []Degradation
+	s := New(&fakeOutput{}, nil, &clock{})
+	s.Govern = GovernBudget
+	s.Budget = 800
+	s.GovernRecover = 2
+	s.OnGovern = func(d Degradation) { events = append(events, d) }
+	var g governor
+
+	// Misses step down after three in a row, and an on-time render resets
+	// the count.
+	s.govern(&g, 2*time.Millisecond, time.Millisecond)
+	s.govern(&g, 2*time.Millisecond, time.Millisecond)
+	s.govern(&g, 900*time.Microsecond, time.Millisecond)
+	s.govern(&g, 2*time.Millisecond, time.Millisecond)
+	s.govern(&g, 2*time.Millisecond, time.Millisecond)
+	if len(events) != 0 {
+		t.Fatalf("stepped down after misses broken by an on-time render: %+v", events)
+	}
+	s.govern(&g, 2*time.Millisecond, time.Millisecond)
+	if d := s.Degradation(); d.Level != 1 || d.Budget != 400 || d.Repeat != 1 {
+		t.Fatalf("after 3 misses: %+v", d)
+	}
+	if len(events) != 1 || events[0].Took != 2*time.Millisecond || events[0].Deadline != time.Millisecond {
+		t.Fatalf("events = %+v", events)
+	}
+
+	// The level stops at MaxGovernLevel.
+	for range 20 {
+		s.govern(&g, 2*time.Millisecond, time.Millisecond)
+	}
+	if d := s.Degradation(); d.Level != MaxGovernLevel || d.Budget != 100 {
+		t.Fatalf("after many misses: %+v", d)
+	}
+
+	// Renders on time but not within half the deadline hold the level.
+	for range 5 {
+		s.govern(&g, 600*time.Microsecond, time.Millisecond)
+	}
+	if d := s.Degradation(); d.Level != MaxGovernLevel {
+		t.Fatalf("stepped up on renders not fast enough for the level up: %+v", d)
+	}
+	s.govern(&g, 400*time.Microsecond, time.Millisecond)
+	s.govern(&g, 400*time.Microsecond, time.Millisecond)
+	if d := s.Degradation(); d.Level != MaxGovernLevel-1 || d.Budget != 200 {
+		t.Fatalf("after 2 fast renders: %+v", d)
+	}
+}
+
+func TestGovernOff(t *testing.T) {
+	s := New(&fakeOutput{}, nil, &clock{})
+	s.OnGovern = func(d Degradation) { t.Fatalf("OnGovern(%+v) with GovernOff", d) }
+	var g governor
+	for range 10 {
+		s.govern(&g, time.Second, time.Millisecond)
+	}
+	if d := s.Degradation(); d.Level != 0 || d.Repeat != 1 || d.Budget != DefaultPPS/60 {
+		t.Fatalf("Degradation = %+v", d)
+	}
+}
+
+// slowLayer takes 3ms to render a frame of budget points.
+type slowLayer struct {
+	mu      sync.Mutex
+	renders int
+}
+
+func (l *slowLayer) Points(t time.Duration, budget int) []helios.Point {
+	time.Sleep(3 * time.Millisecond)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.renders++
+	return make([]helios.Point, budget)
+}
+
+func TestGovernFrameRate(t *testing.T) {
+	out, layer := &fakeOutput{}, &slowLayer{}
+	s := New(out, layer, &clock{})
+	// Frames scan in 1ms, so a 3ms render needs a level of at least 2,
+	// writing each frame 4 times.
+	s.PPS, s.Budget = 30000, 30
+	s.Govern = GovernFrameRate
+	s.GovernRecover = 1000
+	var mu sync.Mutex
+	var levels []int
+	s.OnGovern = func(d Degradation) {
+		mu.Lock()
+		defer mu.Unlock()
+		levels = append(levels, d.Level)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.Run(ctx) }()
+	waitFor(t, func() bool { return s.Degradation().Level >= 2 })
+	layer.mu.Lock()
+	renders := layer.renders
+	layer.mu.Unlock()
+	frames, _, _ := out.state()
+	waitFor(t, func() bool { f, _, _ := out.state(); return f >= frames+20 })
+	cancel()
+	<-done
+
+	mu.Lock()
+	defer mu.Unlock()
+	for i, l := range levels {
+		if l != i+1 {
+			t.Fatalf("levels = %v, want steps down by one", levels)
+		}
+	}
+	layer.mu.Lock()
+	defer layer.mu.Unlock()
+	f, _, _ := out.state()
+	if rendered, written := layer.renders-renders, f-frames; rendered*2 > written {
+		t.Errorf("rendered %d frames for %d written at level %d", rendered, written, s.Degradation().Level)
+	}
+}
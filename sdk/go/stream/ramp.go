@@ -0,0 +1,89 @@
+package stream
+
+import (
+	"math"
+	"time"
+)
+
+// Paced is implemented by layers that choose the scan rate of their own
+// frames, such as beam effects that look best scanned slowly. A Streamer
+// rendering a Paced layer ramps to the rate the layer returns for each
+// frame, over PPSRamp.
+type Paced interface {
+	// PPS returns the scan rate of the frame at animation time t. Zero
+	// keeps the streamer's rate.
+	PPS(t time.Duration) int
+}
+
+// ramp moves the scan rate linearly from one rate to another.
+type ramp struct {
+	from, to float64
+	elapsed  time.Duration // scan time since the ramp to to began
+}
+
+// at returns the rate after the ramp's elapsed time, for a ramp lasting
+// length.
+func (r *ramp) at(length time.Duration) float64 {
+	if length <= 0 || r.elapsed >= length {
+		return r.to
+	}
+	return r.from + (r.to-r.from)*float64(r.elapsed)/float64(length)
+}
+
+// SetPPS changes the scan rate, ramping to it over PPSRamp. Zero returns
+// to PPS.
+func (s *Streamer) SetPPS(pps int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.target = max(pps, 0)
+}
+
+// CurrentPPS returns the rate the ramp has reached, which the next frame
+// is played at unless the target rate changes.
+func (s *Streamer) CurrentPPS() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ramp.to == 0 {
+		return s.targetLocked(0)
+	}
+	return int(math.Round(s.ramp.at(s.PPSRamp)))
+}
+
+// targetLocked returns the rate to ramp to, paced if the layer asked for a
+// rate.
+func (s *Streamer) targetLocked(paced int) int {
+	switch {
+	case paced > 0:
+		return paced
+	case s.target > 0:
+		return s.target
+	}
+	return s.pps()
+}
+
+// rate returns the rate to play the frame at animation time t at, starting
+// a new ramp if the target rate has changed. The first frame is played at
+// the target rate.
+func (s *Streamer) rate(t time.Duration) int {
+	paced := 0
+	if p, ok := s.layer.(Paced); ok {
+		paced = p.PPS(t)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	target := float64(s.targetLocked(paced))
+	switch r := &s.ramp; {
+	case r.to == 0:
+		*r = ramp{from: target, to: target}
+	case target != r.to:
+		*r = ramp{from: r.at(s.PPSRamp), to: target}
+	}
+	return max(int(math.Round(s.ramp.at(s.PPSRamp))), 1)
+}
+
+// advanceRamp moves the ramp on by the scan time of a frame.
+func (s *Streamer) advanceRamp(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ramp.elapsed += d
+}
@@ -0,0 +1,103 @@
+package stream
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+// pacedLayer renders ten points per frame and asks for slow scanning from
+// one second on.
+type pacedLayer struct{}
+
+func (pacedLayer) Points(t time.Duration, budget int) []helios.Point {
+	return make([]helios.Point, 10)
+}
+
+func (pacedLayer) PPS(t time.Duration) int {
+	if t >= time.Second {
+		return 5000
+	}
+	return 0
+}
+
+func TestPPSRamp(t *testing.T) {
+	s := New(&fakeOutput{}, pacedLayer{}, &clock{})
+	s.PPS, s.PPSRamp = 10000, 4*time.Millisecond
+	var gov governor
+	if f := s.render(0, &gov); f.pps != 10000 || f.length != time.Millisecond {
+		t.Fatalf("first frame at %d pps for %v", f.pps, f.length)
+	}
+
+	// Ten points take a millisecond at 10000 pps and less as the rate rises,
+	// so the ramp to 20000 over 4ms plays out over six frames.
+	s.SetPPS(20000)
+	var rates []int
+	for range 7 {
+		rates = append(rates, s.render(0, &gov).pps)
+	}
+	want := []int{10000, 12500, 14500, 16224, 17765, 19172, 20000}
+	for i := range want {
+		if rates[i] != want[i] {
+			t.Fatalf("rates = %v, want %v", rates, want)
+		}
+	}
+	if got := s.CurrentPPS(); got != 20000 {
+		t.Fatalf("CurrentPPS = %d", got)
+	}
+
+	// A paced layer overrides the rate; without a ramp it takes effect at
+	// once.
+	s.PPSRamp = 0
+	if f := s.render(time.Second, &gov); f.pps != 5000 || f.length != 2*time.Millisecond {
+		t.Fatalf("paced frame at %d pps for %v", f.pps, f.length)
+	}
+	if f := s.render(0, &gov); f.pps != 20000 {
+		t.Fatalf("unpaced frame at %d pps", f.pps)
+	}
+}
+
+// ppsOutput is a fakeOutput recording the rates frames are written at.
+type ppsOutput struct {
+	*fakeOutput
+	rates []int
+}
+
+func (o *ppsOutput) WriteFrame(pps int, points []helios.Point) error {
+	o.mu.Lock()
+	o.rates = append(o.rates, pps)
+	o.mu.Unlock()
+	return o.fakeOutput.WriteFrame(pps, points)
+}
+
+func TestPPSRampRun(t *testing.T) {
+	out, c := &ppsOutput{fakeOutput: &fakeOutput{}}, &clock{}
+	s := New(out, pacedLayer{}, c)
+	s.PPS, s.PPSRamp = 10000, 10*time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.Run(ctx) }()
+	waitFor(t, func() bool { f, _, _ := out.state(); return f > 0 })
+	c.advance(time.Second)
+	waitFor(t, func() bool { return s.CurrentPPS() == 5000 })
+	cancel()
+	<-done
+
+	// The frames slow down step by step rather than at once.
+	out.mu.Lock()
+	defer out.mu.Unlock()
+	between := 0
+	for i, pps := range out.rates {
+		if i > 0 && pps > out.rates[i-1] {
+			t.Fatalf("rates = %v do not fall", out.rates)
+		}
+		if pps > 5000 && pps < 10000 {
+			between++
+		}
+	}
+	if out.rates[0] != 10000 || between < 2 {
+		t.Fatalf("rates = %v", out.rates)
+	}
+}
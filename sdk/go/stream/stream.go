@@ -0,0 +1,325 @@
+// Package stream feeds frames rendered from a scene.Layer to an output,
+// paced by the output and timed by a show.Transport, with pause and resume
+// that keep the pipeline set up.
+package stream
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/output"
+	"github.com/Grix/helios_dac/sdk/go/scene"
+	"github.com/Grix/helios_dac/sdk/go/show"
+)
+
+// DefaultPPS is the scan rate of a Streamer whose PPS is zero.
+const DefaultPPS = 30000
+
+// DefaultPollInterval is how often a Streamer polls a busy output.
+const DefaultPollInterval = 500 * time.Microsecond
+
+// Streamer renders the layer at the transport's animation time whenever the
+// output is ready for a frame, and writes it. Pause blanks the output and
+// stops rendering until Resume, so an interactive application can suspend
+// projection without tearing anything down; the animation time holds while
+// paused and, with Resync, catches up on Resume. Its methods are safe for
+// concurrent use with Run.
+type Streamer struct {
+	// PPS is the scan rate frames are written at. Zero means DefaultPPS.
+	PPS int
+
+	// Budget is the number of points rendered per frame. Zero means one
+	// 60th of a second at PPS.
+	Budget int
+
+	// PPSRamp is how long a change of scan rate, by SetPPS or a Paced
+	// layer, takes to play out. The rate moves linearly from frame to
+	// frame over that much scan time, so the galvos are not jolted by a
+	// sudden change of speed. Zero changes the rate from the next frame.
+	PPSRamp time.Duration
+
+	// Resync, if set, makes Resume jump the animation time to where it
+	// would be had the stream not paused, keeping content in step with
+	// wall-clock driven media such as music. By default it continues from
+	// where it paused.
+	Resync bool
+
+	// Buffering chooses how far ahead of the output frames are rendered.
+	// Zero is BufferDouble.
+	Buffering Buffering
+
+	// Lead is how long before the output runs out BufferLowLatency
+	// renders the next frame. It must cover rendering and writing a
+	// frame. Zero means DefaultLead.
+	Lead time.Duration
+
+	// Depth is the number of frames BufferDeep renders ahead. Zero means
+	// DefaultDepth.
+	Depth int
+
+	// Source names the stream in the helios.FrameMeta of its frames, which
+	// are written with output.WriteFrameMeta.
+	Source string
+
+	// Govern chooses what the stream gives up when the layer renders too
+	// slowly to keep the output fed. Zero is GovernOff.
+	Govern Governing
+
+	// GovernMisses is the number of renders in a row missing their
+	// deadline that step the stream down a level. Zero means
+	// DefaultGovernMisses.
+	GovernMisses int
+
+	// GovernRecover is the number of renders in a row that would have
+	// been on time a level up that step the stream back up. Zero means
+	// DefaultGovernRecover.
+	GovernRecover int
+
+	// OnGovern, if set, is called by Run whenever the stream steps down
+	// or up a level.
+	OnGovern func(Degradation)
+
+	// ScanFail configures the scan-fail interlock, which writes frames
+	// that would hold the lit beam still blanked, as for
+	// helios.DeviceManager. It is helios.DefaultScanFail unless changed
+	// before Run; a zero Duration disables it.
+	ScanFail helios.ScanFailSettings
+
+	// OnScanFail, if set, is called by Run when the interlock starts or
+	// stops blanking frames.
+	OnScanFail func(helios.ScanFailEvent)
+
+	out       output.Output
+	layer     scene.Layer
+	clock     show.Clock
+	transport *show.Transport
+
+	mu       sync.Mutex
+	paused   bool
+	pausedAt time.Duration // clock reading at Pause
+	changed  chan struct{} // closed when paused changes
+	gen      int           // counts changes invalidating frames rendered ahead
+
+	governLevel int // set by Run under mu
+
+	target int  // the rate set by SetPPS, zero for PPS
+	ramp   ramp // advanced by Run under mu
+}
+
+// New creates a playing Streamer rendering layer to out, timed by clock
+// from zero. A nil clock uses a show.WallClock.
+func New(out output.Output, layer scene.Layer, clock show.Clock) *Streamer {
+	if clock == nil {
+		clock = show.NewWallClock()
+	}
+	return &Streamer{
+		out:       out,
+		layer:     layer,
+		clock:     clock,
+		transport: show.NewTransport(clock),
+		changed:   make(chan struct{}),
+		ScanFail:  helios.DefaultScanFail,
+	}
+}
+
+// Pause stops rendering and holds the animation time. Run blanks the output
+// before it would write another frame. Pausing a paused stream does
+// nothing.
+func (s *Streamer) Pause() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.paused {
+		return
+	}
+	s.transport.Pause()
+	s.pausedAt = s.clock.Now()
+	s.gen++
+	s.setPaused(true)
+}
+
+// Resume continues rendering, from the held animation time or, with
+// Resync, from the time it would have reached. Resuming a playing stream
+// does nothing.
+func (s *Streamer) Resume() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.paused {
+		return
+	}
+	if s.Resync {
+		elapsed := float64(s.clock.Now()-s.pausedAt) * s.transport.Speed()
+		s.transport.Scrub(s.transport.Now() + time.Duration(elapsed))
+	}
+	s.transport.Resume()
+	s.setPaused(false)
+}
+
+func (s *Streamer) setPaused(paused bool) {
+	s.paused = paused
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// Paused reports whether the stream is paused.
+func (s *Streamer) Paused() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.paused
+}
+
+// Seek moves the animation time to pos, paused or not. A paused stream
+// stays blank and resumes from pos.
+func (s *Streamer) Seek(pos time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.transport.Scrub(pos)
+	s.gen++
+}
+
+// Position returns the animation time.
+func (s *Streamer) Position() time.Duration {
+	return s.transport.Now()
+}
+
+// SetSpeed sets the rate of animation time; see show.Transport.SetSpeed.
+func (s *Streamer) SetSpeed(speed float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.transport.SetSpeed(speed)
+	s.gen++
+}
+
+func (s *Streamer) pps() int {
+	if s.PPS > 0 {
+		return s.PPS
+	}
+	return DefaultPPS
+}
+
+func (s *Streamer) budget() int {
+	if s.Budget > 0 {
+		return s.Budget
+	}
+	return max(s.pps()/60, 1)
+}
+
+// render renders the frame at animation time t, at the rate of the PPS
+// ramp, timing the render for the governor.
+func (s *Streamer) render(t time.Duration, g *governor) ahead {
+	pps := s.rate(t)
+	start := time.Now()
+	f := ahead{points: s.layer.Points(t, s.renderBudget()), meta: helios.NewFrameMeta(s.Source), pps: pps}
+	if len(f.points) > 0 {
+		f.length = s.deadline(f.points, pps)
+		s.govern(g, time.Since(start), f.length)
+		s.advanceRamp(f.length)
+	}
+	return f
+}
+
+// Run feeds the output until ctx is done, returning ctx.Err(), or until
+// the output fails, returning its error. A layer rendering no points
+// leaves the output idle until its next poll, or until it changes if it is
+// a Subframes.
+func (s *Streamer) Run(ctx context.Context) error {
+	blanked := false
+	var queue []ahead // frames rendered ahead by BufferDeep
+	gen := 0
+	var gov governor
+	var last ahead // the frame written last, for GovernFrameRate
+	repeats := 0   // times left to write last
+	var interlock helios.ScanFailInterlock
+	for {
+		s.mu.Lock()
+		paused, changed := s.paused, s.changed
+		if s.gen != gen {
+			queue, gen, repeats = nil, s.gen, 0
+		}
+		s.mu.Unlock()
+		if paused {
+			if !blanked {
+				if err := s.out.Stop(); err != nil {
+					return err
+				}
+				blanked = true
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-changed:
+			}
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var update <-chan struct{}
+		if c, ok := s.layer.(changer); ok {
+			update = c.Changed()
+		}
+		ready, err := s.out.Ready()
+		if err != nil {
+			return err
+		}
+		poll := DefaultPollInterval
+		var f ahead
+		fresh := true
+		switch {
+		case ready && repeats > 0:
+			f, fresh = last, false
+			repeats--
+		case s.Buffering == BufferDeep:
+			if ready && len(queue) > 0 {
+				f, queue = queue[0], queue[1:]
+				break
+			}
+			if len(queue) < s.depth() {
+				f, err := s.renderAhead(queue, &gov)
+				if err != nil {
+					return err
+				}
+				if len(f.points) > 0 {
+					queue = append(queue, f)
+					continue
+				}
+			}
+		case ready && s.Buffering == BufferLowLatency:
+			remaining, ok, err := s.buffered()
+			if err != nil {
+				return err
+			}
+			if wait := remaining - s.lead(); ok && wait > 0 {
+				poll = wait
+				break
+			}
+			f = s.render(s.transport.Now(), &gov)
+		case ready:
+			f = s.render(s.transport.Now(), &gov)
+		}
+		if len(f.points) == 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-changed:
+			case <-update:
+			case <-time.After(poll):
+			}
+			continue
+		}
+		// Outputs write frames in turn, as with helios.FlagSingleMode.
+		points, ev, switched := interlock.Check(s.ScanFail, f.points, f.pps, helios.FlagSingleMode)
+		if switched && s.OnScanFail != nil {
+			s.OnScanFail(ev)
+		}
+		if err := output.WriteFrameMeta(s.out, f.pps, points, f.meta); err != nil {
+			return err
+		}
+		if fresh {
+			last = f
+			repeats = s.repeat() - 1
+		}
+		blanked = false
+	}
+}
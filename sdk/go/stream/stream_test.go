@@ -0,0 +1,223 @@
+package stream
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/scene"
+)
+
+// clock is a manual show.Clock safe for use by the streaming goroutine.
+type clock struct {
+	mu  sync.Mutex
+	now time.Duration
+}
+
+func (c *clock) Now() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now += d
+}
+
+// fakeOutput records frames and stops, and a layer the times it renders.
+type fakeOutput struct {
+	mu     sync.Mutex
+	times  []time.Duration
+	frames int
+	stops  int
+}
+
+func (o *fakeOutput) Ready() (bool, error) {
+	time.Sleep(100 * time.Microsecond)
+	return true, nil
+}
+
+func (o *fakeOutput) WriteFrame(pps int, points []helios.Point) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.frames++
+	return nil
+}
+
+func (o *fakeOutput) Stop() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.stops++
+	return nil
+}
+
+func (o *fakeOutput) Close() error { return nil }
+
+func (o *fakeOutput) Points(t time.Duration, budget int) []helios.Point {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.times = append(o.times, t)
+	return []helios.Point{{X: 1}}
+}
+
+// state returns the frames and stops so far and the last time rendered.
+func (o *fakeOutput) state() (frames, stops int, last time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if len(o.times) > 0 {
+		last = o.times[len(o.times)-1]
+	}
+	return o.frames, o.stops, last
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); !cond(); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out")
+		}
+	}
+}
+
+func TestPauseResume(t *testing.T) {
+	out, c := &fakeOutput{}, &clock{}
+	s := New(out, out, c)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.Run(ctx) }()
+
+	c.advance(time.Second)
+	waitFor(t, func() bool { _, _, last := out.state(); return last == time.Second })
+
+	s.Pause()
+	waitFor(t, func() bool { _, stops, _ := out.state(); return stops == 1 })
+	frames, _, _ := out.state()
+	c.advance(time.Second)
+	time.Sleep(5 * time.Millisecond)
+	if f, stops, _ := out.state(); f != frames || stops != 1 || !s.Paused() {
+		t.Fatalf("paused stream wrote %d frames and stopped %d times", f-frames, stops)
+	}
+	if s.Position() != time.Second {
+		t.Fatalf("paused position = %v, want 1s", s.Position())
+	}
+
+	// Resuming continues from the held time.
+	s.Resume()
+	waitFor(t, func() bool { f, _, _ := out.state(); return f > frames })
+	if _, _, last := out.state(); last != time.Second {
+		t.Fatalf("resumed at %v, want 1s", last)
+	}
+
+	// With Resync it catches up on the time spent paused.
+	s.Pause()
+	s.Resync = true
+	c.advance(2 * time.Second)
+	s.Resume()
+	waitFor(t, func() bool { _, _, last := out.state(); return last == 3*time.Second })
+
+	// Seeking while paused resumes from the new position.
+	s.Pause()
+	s.Resync = false
+	s.Seek(10 * time.Second)
+	c.advance(time.Second)
+	s.Resume()
+	waitFor(t, func() bool { _, _, last := out.state(); return last == 10*time.Second })
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("Run = %v, want context.Canceled", err)
+	}
+}
+
+// metaOutput is a fakeOutput recording the metadata of frames.
+type metaOutput struct {
+	*fakeOutput
+	metas []helios.FrameMeta
+}
+
+func (o *metaOutput) WriteFrameMeta(pps int, points []helios.Point, meta helios.FrameMeta) error {
+	o.mu.Lock()
+	o.metas = append(o.metas, meta)
+	o.mu.Unlock()
+	return o.WriteFrame(pps, points)
+}
+
+func TestFrameMeta(t *testing.T) {
+	out := &metaOutput{fakeOutput: &fakeOutput{}}
+	s := New(out, out, &clock{})
+	s.Source = "tunnel"
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.Run(ctx) }()
+	waitFor(t, func() bool { f, _, _ := out.state(); return f >= 2 })
+	cancel()
+	<-done
+
+	out.mu.Lock()
+	defer out.mu.Unlock()
+	for i, m := range out.metas {
+		if m.Source != "tunnel" || m.ID == 0 || m.Generated.IsZero() {
+			t.Fatalf("frame %d meta = %+v", i, m)
+		}
+		if i > 0 && m.ID <= out.metas[i-1].ID {
+			t.Fatalf("frame IDs %d, %d do not increase", out.metas[i-1].ID, m.ID)
+		}
+	}
+}
+
+// frameOutput is a fakeOutput keeping the frames written.
+type frameOutput struct {
+	*fakeOutput
+	written [][]helios.Point
+}
+
+func (o *frameOutput) WriteFrame(pps int, points []helios.Point) error {
+	o.mu.Lock()
+	o.written = append(o.written, points)
+	o.mu.Unlock()
+	return o.fakeOutput.WriteFrame(pps, points)
+}
+
+func TestScanFail(t *testing.T) {
+	out := &frameOutput{fakeOutput: &fakeOutput{}}
+	// 1000 points at 1000 pps hold the lit beam still for a second.
+	dot := make([]helios.Point, 1000)
+	for i := range dot {
+		dot[i] = helios.Point{X: 2000, Y: 2000, R: 255, I: 255}
+	}
+	s := New(out, scene.LayerFunc(func(time.Duration, int) []helios.Point { return dot }), &clock{})
+	s.PPS = 1000
+	var mu sync.Mutex
+	var events []helios.ScanFailEvent
+	s.OnScanFail = func(ev helios.ScanFailEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, ev)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.Run(ctx) }()
+	waitFor(t, func() bool { f, _, _ := out.state(); return f >= 1 })
+	cancel()
+	<-done
+
+	out.mu.Lock()
+	defer out.mu.Unlock()
+	for _, p := range out.written[0] {
+		if p.R != 0 || p.G != 0 || p.B != 0 {
+			t.Fatalf("point %+v written lit", p)
+		}
+	}
+	if dot[0].R != 255 {
+		t.Fatal("rendered frame was modified")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != 1 || !events[0].Tripped {
+		t.Fatalf("events = %+v, want one trip", events)
+	}
+}
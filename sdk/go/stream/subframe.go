@@ -0,0 +1,143 @@
+package stream
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/motion"
+)
+
+// changer is implemented by layers that signal changes to their content,
+// so Run renders them at once instead of at its next poll.
+type changer interface {
+	Changed() <-chan struct{}
+}
+
+// Subframes is a layer for interactive content, such as a beam following a
+// tracked object, built from named segments that are replaced one at a
+// time. Updating the cursor segment leaves the others as they are, and the
+// frame is the segments joined by blanked jumps and nothing more, so it is
+// as short as the content allows and the output takes the next update
+// after only a few milliseconds of scanning.
+//
+// A Streamer rendering Subframes wakes as soon as a segment changes rather
+// than on its next poll. With BufferLowLatency, which renders just before
+// the output runs out, an update is scanned within two frames of being
+// set; BufferDeep renders frames ahead and suits it poorly.
+type Subframes struct {
+	// PPS times the blanked jumps between segments. It should be the
+	// Streamer's PPS. Zero means DefaultPPS.
+	PPS int
+
+	// Profile shapes the blanked jumps between segments.
+	Profile motion.GalvoProfile
+
+	mu       sync.Mutex
+	names    []string // in the order first set
+	segments map[string][]helios.Point
+	frame    []helios.Point // the joined segments, nil when stale
+	changed  chan struct{}  // closed when a segment changes
+	updated  time.Time
+}
+
+// NewSubframes creates a Subframes with no segments, jumping between
+// segments with motion.DefaultProfile.
+func NewSubframes() *Subframes {
+	return &Subframes{
+		Profile:  motion.DefaultProfile,
+		segments: make(map[string][]helios.Point),
+		changed:  make(chan struct{}),
+	}
+}
+
+// Set replaces the points of the named segment, adding it after the
+// others if it is new. The caller's slice is not retained.
+func (f *Subframes) Set(name string, points []helios.Point) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.segments[name]; !ok {
+		f.names = append(f.names, name)
+	}
+	f.segments[name] = append([]helios.Point(nil), points...)
+	f.changeLocked()
+}
+
+// Remove removes the named segment. Removing a segment that is not set
+// does nothing.
+func (f *Subframes) Remove(name string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.segments[name]; !ok {
+		return
+	}
+	delete(f.segments, name)
+	for i, n := range f.names {
+		if n == name {
+			f.names = append(f.names[:i:i], f.names[i+1:]...)
+			break
+		}
+	}
+	f.changeLocked()
+}
+
+func (f *Subframes) changeLocked() {
+	f.frame = nil
+	f.updated = time.Now()
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+// Changed returns a channel closed at the next change to a segment.
+func (f *Subframes) Changed() <-chan struct{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.changed
+}
+
+// Updated returns when a segment last changed, the zero time if none has.
+// Comparing it with the time a frame is written gives the latency the
+// stream adds.
+func (f *Subframes) Updated() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.updated
+}
+
+// Points returns the segments joined in order, with a blanked jump into
+// each and back from the last to the first, decimated to budget if they
+// are longer. The frame is rebuilt only after a segment changes. It
+// returns nil if no segment has points.
+func (f *Subframes) Points(t time.Duration, budget int) []helios.Point {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.frame == nil {
+		f.frame = f.joinLocked()
+	}
+	if len(f.frame) > budget {
+		return motion.Decimate(f.frame, budget)
+	}
+	return append([]helios.Point(nil), f.frame...)
+}
+
+func (f *Subframes) joinLocked() []helios.Point {
+	pps := f.PPS
+	if pps <= 0 {
+		pps = DefaultPPS
+	}
+	var frame []helios.Point
+	for _, name := range f.names {
+		seg := f.segments[name]
+		if len(seg) == 0 {
+			continue
+		}
+		if len(frame) > 0 {
+			frame = append(frame, f.Profile.Travel(frame[len(frame)-1], seg[0], pps)...)
+		}
+		frame = append(frame, seg...)
+	}
+	if len(frame) == 0 {
+		return []helios.Point{}
+	}
+	return append(frame, f.Profile.Travel(frame[len(frame)-1], frame[0], pps)...)
+}
@@ -0,0 +1,74 @@
+package stream
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+func TestSubframes(t *testing.T) {
+	f := NewSubframes()
+	if got := f.Points(0, 100); got != nil {
+		t.Fatalf("empty Subframes drew %v", got)
+	}
+	border := []helios.Point{{X: 0, Y: 0, G: 255}, {X: 4095, Y: 0, G: 255}}
+	f.Set("border", border)
+	changed := f.Changed()
+	f.Set("cursor", []helios.Point{{X: 2048, Y: 2048, R: 255}})
+	select {
+	case <-changed:
+	default:
+		t.Fatal("Set did not signal a change")
+	}
+
+	frame := f.Points(0, 1000)
+	if frame[0] != border[0] || frame[1] != border[1] {
+		t.Fatalf("frame starts %v", frame[:2])
+	}
+	cursor := -1
+	for i, p := range frame {
+		if p.R == 255 {
+			cursor = i
+		} else if i > 1 && (p.G != 0 || p.R != 0) {
+			t.Fatalf("point %d of a jump is lit: %+v", i, p)
+		}
+	}
+	if cursor < 2 || frame[len(frame)-1] != (helios.Point{}) {
+		t.Fatalf("frame = %v", frame)
+	}
+
+	// Moving the cursor keeps the border and its place in the frame.
+	f.Set("cursor", []helios.Point{{X: 3000, Y: 2048, R: 255}})
+	moved := f.Points(0, 1000)
+	if moved[0] != border[0] || moved[1] != border[1] {
+		t.Fatalf("moved frame starts %v", moved[:2])
+	}
+	if len(f.Points(0, 10)) > 10 {
+		t.Fatal("frame not decimated to the budget")
+	}
+
+	f.Remove("border")
+	if got := f.Points(0, 1000); got[0] != (helios.Point{X: 3000, Y: 2048, R: 255}) {
+		t.Fatalf("after removing the border the frame starts %+v", got[0])
+	}
+	f.Remove("cursor")
+	if got := f.Points(0, 100); got != nil {
+		t.Fatalf("emptied Subframes drew %v", got)
+	}
+}
+
+func TestSubframesWakeStream(t *testing.T) {
+	out, f := &fakeOutput{}, NewSubframes()
+	s := New(out, f, &clock{})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.Run(ctx) }()
+
+	f.Set("cursor", []helios.Point{{X: 100, R: 255}})
+	waitFor(t, func() bool { frames, _, _ := out.state(); return frames > 0 })
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("Run = %v", err)
+	}
+}
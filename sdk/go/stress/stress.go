@@ -0,0 +1,216 @@
+// Package stress generates pathological content for unattended soak tests.
+//
+// A Generator cycles through patterns chosen to shake out buffer, timing and
+// firmware edge cases: full-scale sweeps at the highest point rate, dense
+// text-like clusters of tiny strokes and blanked jumps, frames several times
+// larger than the device accepts, and frames of one to three points at the
+// lowest rate. Content is a pure function of the seed and the time, so a
+// failure seen hours into a run can be reproduced by asking for the same
+// frame again.
+package stress
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+// Pattern is a kind of stress content.
+type Pattern int
+
+const (
+	// Sweep jumps lit between opposite corners on every point at the
+	// highest point rate, the fastest the scanners can be driven.
+	Sweep Pattern = iota
+
+	// Text fills the frame with rows of tiny glyph-like strokes separated
+	// by short blanked jumps, as dense text would.
+	Text
+
+	// Huge frames have two to four times as many points as the device
+	// accepts, exercising frame splitting and adapting.
+	Huge
+
+	// Tiny frames have one to three points and play at the lowest rate.
+	Tiny
+
+	numPatterns
+)
+
+func (p Pattern) String() string {
+	switch p {
+	case Sweep:
+		return "sweep"
+	case Text:
+		return "text"
+	case Huge:
+		return "huge"
+	case Tiny:
+		return "tiny"
+	}
+	return fmt.Sprintf("Pattern(%d)", int(p))
+}
+
+// Frame is one generated frame.
+type Frame struct {
+	Pattern Pattern
+	PPS     int
+	Points  []helios.Point
+}
+
+// DefaultPeriod is how long each pattern runs before the next one starts.
+const DefaultPeriod = 30 * time.Second
+
+// Generator produces stress frames. The zero Generator is ready to use.
+type Generator struct {
+	// Seed selects the content; runs with the same seed produce the same
+	// frames at the same times.
+	Seed uint64
+
+	// Period is how long each pattern runs. Zero means DefaultPeriod.
+	Period time.Duration
+
+	// Limits are the device limits to push against. The zero value means
+	// helios.LimitsNetwork.
+	Limits helios.FrameLimits
+}
+
+// Pattern returns the pattern running at t.
+func (g *Generator) Pattern(t time.Duration) Pattern {
+	period := g.Period
+	if period <= 0 {
+		period = DefaultPeriod
+	}
+	return Pattern(max(t, 0) / period % time.Duration(numPatterns))
+}
+
+// Frame returns the frame for t, measured from the start of the run. Calls
+// with the same t return the same frame.
+func (g *Generator) Frame(t time.Duration) Frame {
+	limits := g.Limits
+	if limits == (helios.FrameLimits{}) {
+		limits = helios.LimitsNetwork
+	}
+	rng := rand.New(rand.NewPCG(g.Seed, uint64(t)))
+	f := Frame{Pattern: g.Pattern(t)}
+	switch f.Pattern {
+	case Sweep:
+		f.PPS, f.Points = limits.MaxPPS, sweep(rng, limits.MaxPoints)
+	case Text:
+		f.PPS, f.Points = clampPPS(30000, limits), text(rng, limits.MaxPoints)
+	case Huge:
+		f.PPS = limits.MinPPS + rng.IntN(limits.MaxPPS-limits.MinPPS+1)
+		f.Points = huge(rng, limits.MaxPoints*(2+rng.IntN(3)))
+	case Tiny:
+		f.PPS, f.Points = limits.MinPPS, tiny(rng)
+	}
+	return f
+}
+
+func clampPPS(pps int, limits helios.FrameLimits) int {
+	return min(max(pps, limits.MinPPS), limits.MaxPPS)
+}
+
+// randomColor returns a lit point color with random channels, at least one
+// of them at full brightness.
+func randomColor(rng *rand.Rand) helios.Point {
+	p := helios.Point{R: uint8(rng.IntN(256)), G: uint8(rng.IntN(256)), B: uint8(rng.IntN(256)), I: 255}
+	switch rng.IntN(3) {
+	case 0:
+		p.R = 255
+	case 1:
+		p.G = 255
+	default:
+		p.B = 255
+	}
+	return p
+}
+
+// sweep alternates between opposite corners, lit, for up to maxPoints
+// points.
+func sweep(rng *rand.Rand, maxPoints int) []helios.Point {
+	n := max(2, maxPoints/2+rng.IntN(maxPoints/2+1))
+	color := randomColor(rng)
+	horizontal := rng.IntN(2) == 0
+	out := make([]helios.Point, n)
+	for i := range out {
+		p := color
+		far := uint16(i%2) * 0xFFF
+		if horizontal {
+			p.X, p.Y = far, uint16(rng.IntN(0x1000))
+		} else {
+			p.X, p.Y = far, far
+		}
+		out[i] = p
+	}
+	return out
+}
+
+// Glyph cell size and spacing for Text, in device units.
+const (
+	glyphWidth  = 40
+	glyphHeight = 60
+	glyphGap    = 16
+)
+
+// text draws rows of random glyphs of three to six strokes each, with two
+// blanked points for the jump to each glyph, until maxPoints is reached.
+func text(rng *rand.Rand, maxPoints int) []helios.Point {
+	color := randomColor(rng)
+	out := make([]helios.Point, 0, maxPoints)
+	x, y := glyphGap, 0xFFF-glyphHeight-glyphGap
+	for {
+		strokes := 3 + rng.IntN(4)
+		if len(out)+2+strokes*2 > maxPoints {
+			return out
+		}
+		at := func() helios.Point {
+			p := color
+			p.X = uint16(x + rng.IntN(glyphWidth+1))
+			p.Y = uint16(y + rng.IntN(glyphHeight+1))
+			return p
+		}
+		start := at()
+		out = append(out, helios.Point{X: start.X, Y: start.Y}, helios.Point{X: start.X, Y: start.Y}, start)
+		for range strokes*2 - 1 {
+			out = append(out, at())
+		}
+		if x += glyphWidth + glyphGap; x+glyphWidth > 0xFFF {
+			x = glyphGap
+			if y -= glyphHeight + glyphGap; y < 0 {
+				y = 0xFFF - glyphHeight - glyphGap
+			}
+		}
+	}
+}
+
+// huge draws a lit circle of n points with a random radius.
+func huge(rng *rand.Rand, n int) []helios.Point {
+	color := randomColor(rng)
+	r := 200 + rng.Float64()*1800
+	out := make([]helios.Point, n)
+	for i := range out {
+		s, c := math.Sincos(2 * math.Pi * float64(i) / float64(n))
+		p := color
+		p.X, p.Y = helios.ClampToCoord(2048+r*c), helios.ClampToCoord(2048+r*s)
+		out[i] = p
+	}
+	return out
+}
+
+// tiny returns one to three random points, possibly blanked.
+func tiny(rng *rand.Rand) []helios.Point {
+	out := make([]helios.Point, 1+rng.IntN(3))
+	for i := range out {
+		p := helios.Point{}
+		if rng.IntN(2) == 0 {
+			p = randomColor(rng)
+		}
+		p.X, p.Y = uint16(rng.IntN(0x1000)), uint16(rng.IntN(0x1000))
+		out[i] = p
+	}
+	return out
+}
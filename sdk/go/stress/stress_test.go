@@ -0,0 +1,64 @@
+package stress
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+func TestGeneratorCyclesPatterns(t *testing.T) {
+	g := &Generator{Seed: 1, Period: time.Second}
+	want := []Pattern{Sweep, Text, Huge, Tiny, Sweep}
+	for i, p := range want {
+		if got := g.Frame(time.Duration(i)*time.Second + time.Millisecond).Pattern; got != p {
+			t.Errorf("pattern at %ds = %v, want %v", i, got, p)
+		}
+	}
+}
+
+func TestGeneratorIsDeterministic(t *testing.T) {
+	a := &Generator{Seed: 7}
+	b := &Generator{Seed: 7}
+	at := 95*time.Second + 3*time.Millisecond
+	fa, fb := a.Frame(at), b.Frame(at)
+	if fa.PPS != fb.PPS || !slices.Equal(fa.Points, fb.Points) {
+		t.Fatal("same seed and time gave different frames")
+	}
+	if c := (&Generator{Seed: 8}).Frame(at); slices.Equal(c.Points, fa.Points) {
+		t.Fatal("different seeds gave the same frame")
+	}
+}
+
+func TestGeneratorFramesStressLimits(t *testing.T) {
+	limits := helios.LimitsUsb
+	g := &Generator{Seed: 3, Period: time.Second, Limits: limits}
+	for ms := 0; ms < 4000; ms += 37 {
+		f := g.Frame(time.Duration(ms) * time.Millisecond)
+		n := len(f.Points)
+		switch f.Pattern {
+		case Sweep:
+			if f.PPS != limits.MaxPPS || n > limits.MaxPoints {
+				t.Fatalf("sweep: %d points at %d pps", n, f.PPS)
+			}
+		case Text:
+			if n > limits.MaxPoints || n < limits.MaxPoints-16 {
+				t.Fatalf("text: %d points", n)
+			}
+		case Huge:
+			if n < 2*limits.MaxPoints || f.PPS < limits.MinPPS || f.PPS > limits.MaxPPS {
+				t.Fatalf("huge: %d points at %d pps", n, f.PPS)
+			}
+		case Tiny:
+			if n < 1 || n > 3 || f.PPS != limits.MinPPS {
+				t.Fatalf("tiny: %d points at %d pps", n, f.PPS)
+			}
+		}
+		for _, p := range f.Points {
+			if p.X > 0xFFF || p.Y > 0xFFF {
+				t.Fatalf("%v: point %+v outside the coordinate range", f.Pattern, p)
+			}
+		}
+	}
+}
@@ -0,0 +1,389 @@
+// Package svg imports the outlines of SVG drawings as laser frames.
+//
+// The stroked geometry of path, line, polyline, polygon and rect elements is
+// read; fills, text, transforms and styles other than the stroke color are
+// ignored. Path data may use move, line, cubic and quadratic Bézier and
+// close commands; curves are flattened into line segments. The drawing is
+// scaled to fit the projection area, centered, with the SVG's Y axis
+// flipped so the top of the drawing is projected at the top.
+package svg
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+// Path is one stroked outline in normalized coordinates (-1 to 1).
+type Path struct {
+	// R, G, B is the stroke color (0 to 1).
+	R, G, B float64
+
+	Points [][2]float64
+}
+
+// curveSegments is the number of line segments a Bézier curve is flattened
+// into.
+const curveSegments = 16
+
+// Read reads the outlines of an SVG document.
+func Read(r io.Reader) ([]Path, error) {
+	var (
+		paths   []Path
+		viewBox []float64
+	)
+	dec := xml.NewDecoder(r)
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("svg: %w", err)
+		}
+		el, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		a := attrs(el)
+		if el.Name.Local == "svg" && viewBox == nil {
+			viewBox, err = parseViewBox(a)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		polys, err := shape(el.Name.Local, a)
+		if err != nil {
+			return nil, err
+		}
+		if len(polys) == 0 || a["stroke"] == "none" {
+			continue
+		}
+		r, g, b := parseColor(a["stroke"])
+		for _, pts := range polys {
+			if len(pts) > 1 {
+				paths = append(paths, Path{R: r, G: g, B: b, Points: pts})
+			}
+		}
+	}
+	if len(paths) == 0 {
+		return nil, errors.New("svg: no outlines found")
+	}
+	normalize(paths, viewBox)
+	return paths, nil
+}
+
+// Frame traces paths into a single frame. Segments are sampled so that
+// consecutive points are at most spacing apart (in normalized units), and
+// blank blanked points are inserted at each end of the jump between paths.
+func Frame(paths []Path, spacing float64, blank int) []helios.Point {
+	var frame []helios.Point
+	for _, p := range paths {
+		start := helios.PointF{X: p.Points[0][0], Y: p.Points[0][1]}.Point()
+		for range blank {
+			frame = append(frame, start)
+		}
+		frame = append(frame, helios.PointF{X: p.Points[0][0], Y: p.Points[0][1], R: p.R, G: p.G, B: p.B, I: 1}.Point())
+		for i := 1; i < len(p.Points); i++ {
+			a, b := p.Points[i-1], p.Points[i]
+			n := max(1, int(math.Ceil(math.Hypot(b[0]-a[0], b[1]-a[1])/spacing)))
+			for j := 1; j <= n; j++ {
+				t := float64(j) / float64(n)
+				frame = append(frame, helios.PointF{
+					X: a[0] + (b[0]-a[0])*t,
+					Y: a[1] + (b[1]-a[1])*t,
+					R: p.R, G: p.G, B: p.B, I: 1,
+				}.Point())
+			}
+		}
+		end := frame[len(frame)-1]
+		for range blank {
+			frame = append(frame, helios.Point{X: end.X, Y: end.Y})
+		}
+	}
+	return frame
+}
+
+func attrs(el xml.StartElement) map[string]string {
+	m := make(map[string]string, len(el.Attr))
+	for _, a := range el.Attr {
+		m[a.Name.Local] = a.Value
+	}
+	// A stroke set through the style attribute overrides the attribute.
+	for _, decl := range strings.Split(m["style"], ";") {
+		if k, v, ok := strings.Cut(decl, ":"); ok && strings.TrimSpace(k) == "stroke" {
+			m["stroke"] = strings.TrimSpace(v)
+		}
+	}
+	return m
+}
+
+func parseViewBox(a map[string]string) ([]float64, error) {
+	if vb := a["viewBox"]; vb != "" {
+		f, err := numbers(vb)
+		if err != nil || len(f) != 4 || f[2] <= 0 || f[3] <= 0 {
+			return nil, fmt.Errorf("svg: invalid viewBox %q", vb)
+		}
+		return f, nil
+	}
+	w, errW := length(a["width"])
+	h, errH := length(a["height"])
+	if errW != nil || errH != nil || w <= 0 || h <= 0 {
+		// Fit the drawing's own bounds instead.
+		return nil, nil
+	}
+	return []float64{0, 0, w, h}, nil
+}
+
+// length parses an SVG length, ignoring its unit.
+func length(s string) (float64, error) {
+	return strconv.ParseFloat(strings.TrimRight(s, "abcdefghijklmnopqrstuvwxyz%"), 64)
+}
+
+func shape(name string, a map[string]string) ([][][2]float64, error) {
+	num := func(k string) float64 {
+		v, _ := length(a[k])
+		return v
+	}
+	switch name {
+	case "path":
+		return parsePath(a["d"])
+	case "line":
+		return [][][2]float64{{{num("x1"), num("y1")}, {num("x2"), num("y2")}}}, nil
+	case "polyline", "polygon":
+		f, err := numbers(a["points"])
+		if err != nil || len(f)%2 != 0 {
+			return nil, fmt.Errorf("svg: invalid %s points %q", name, a["points"])
+		}
+		var pts [][2]float64
+		for i := 0; i < len(f); i += 2 {
+			pts = append(pts, [2]float64{f[i], f[i+1]})
+		}
+		if name == "polygon" && len(pts) > 0 {
+			pts = append(pts, pts[0])
+		}
+		return [][][2]float64{pts}, nil
+	case "rect":
+		x, y, w, h := num("x"), num("y"), num("width"), num("height")
+		return [][][2]float64{{{x, y}, {x + w, y}, {x + w, y + h}, {x, y + h}, {x, y}}}, nil
+	}
+	return nil, nil
+}
+
+// parsePath converts path data to polylines, one per subpath.
+func parsePath(d string) ([][][2]float64, error) {
+	var (
+		polys      [][][2]float64
+		cur        [][2]float64
+		pos, start [2]float64
+		cmd        byte
+	)
+	toks := tokenize(d)
+	bad := func() error { return fmt.Errorf("svg: invalid path data %q", d) }
+	flush := func() {
+		if len(cur) > 1 {
+			polys = append(polys, cur)
+		}
+		cur = nil
+	}
+	// args consumes n numbers, or reports false if they are not there.
+	args := func(n int) ([]float64, bool) {
+		if len(toks) < n {
+			return nil, false
+		}
+		f := make([]float64, n)
+		for i := range f {
+			v, err := strconv.ParseFloat(toks[i], 64)
+			if err != nil {
+				return nil, false
+			}
+			f[i] = v
+		}
+		toks = toks[n:]
+		return f, true
+	}
+	for len(toks) > 0 {
+		if c := toks[0][0]; len(toks[0]) == 1 && strings.IndexByte("MmLlHhVvCcQqZz", c) >= 0 {
+			cmd = c
+			toks = toks[1:]
+		} else if cmd == 0 {
+			return nil, bad()
+		}
+		rel := cmd >= 'a'
+		var off [2]float64
+		if rel {
+			off = pos
+		}
+		switch cmd | 0x20 {
+		case 'm':
+			f, ok := args(2)
+			if !ok {
+				return nil, bad()
+			}
+			flush()
+			pos = [2]float64{off[0] + f[0], off[1] + f[1]}
+			start = pos
+			cur = [][2]float64{pos}
+			// Further coordinate pairs are implicit line-tos.
+			cmd = 'L' | (cmd & 0x20)
+		case 'l':
+			f, ok := args(2)
+			if !ok {
+				return nil, bad()
+			}
+			pos = [2]float64{off[0] + f[0], off[1] + f[1]}
+			cur = append(cur, pos)
+		case 'h':
+			f, ok := args(1)
+			if !ok {
+				return nil, bad()
+			}
+			pos[0] = off[0] + f[0]
+			cur = append(cur, pos)
+		case 'v':
+			f, ok := args(1)
+			if !ok {
+				return nil, bad()
+			}
+			pos[1] = off[1] + f[0]
+			cur = append(cur, pos)
+		case 'c', 'q':
+			n := 6
+			if cmd|0x20 == 'q' {
+				n = 4
+			}
+			f, ok := args(n)
+			if !ok {
+				return nil, bad()
+			}
+			ctrl := [][2]float64{pos}
+			for i := 0; i < n; i += 2 {
+				ctrl = append(ctrl, [2]float64{off[0] + f[i], off[1] + f[i+1]})
+			}
+			for i := 1; i <= curveSegments; i++ {
+				cur = append(cur, bezier(ctrl, float64(i)/curveSegments))
+			}
+			pos = ctrl[len(ctrl)-1]
+		case 'z':
+			if len(cur) > 0 {
+				cur = append(cur, start)
+			}
+			pos = start
+			flush()
+			cur = [][2]float64{pos}
+			cmd = 0
+		}
+	}
+	flush()
+	return polys, nil
+}
+
+// bezier evaluates the Bézier curve with control points ctrl at t.
+func bezier(ctrl [][2]float64, t float64) [2]float64 {
+	p := append([][2]float64(nil), ctrl...)
+	for n := len(p) - 1; n > 0; n-- {
+		for i := 0; i < n; i++ {
+			p[i][0] += (p[i+1][0] - p[i][0]) * t
+			p[i][1] += (p[i+1][1] - p[i][1]) * t
+		}
+	}
+	return p[0]
+}
+
+// tokenize splits path data into command letters and numbers.
+func tokenize(d string) []string {
+	var toks []string
+	i := 0
+	for i < len(d) {
+		c := d[i]
+		switch {
+		case c == ' ' || c == ',' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' && c != 'e':
+			toks = append(toks, d[i:i+1])
+			i++
+		default:
+			j := i + 1
+			dot := c == '.'
+			for j < len(d) {
+				c := d[j]
+				if c >= '0' && c <= '9' {
+					j++
+				} else if c == '.' && !dot {
+					dot = true
+					j++
+				} else if (c == 'e' || c == 'E') && j+1 < len(d) {
+					j++
+					if d[j] == '-' || d[j] == '+' {
+						j++
+					}
+				} else {
+					break
+				}
+			}
+			toks = append(toks, d[i:j])
+			i = j
+		}
+	}
+	return toks
+}
+
+func numbers(s string) ([]float64, error) {
+	var f []float64
+	for _, t := range tokenize(s) {
+		v, err := strconv.ParseFloat(t, 64)
+		if err != nil {
+			return nil, err
+		}
+		f = append(f, v)
+	}
+	return f, nil
+}
+
+// parseColor parses a #rgb or #rrggbb stroke color. Anything else,
+// including named colors, is drawn white.
+func parseColor(s string) (r, g, b float64) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(s) == 3 {
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	}
+	if v, err := strconv.ParseUint(s, 16, 32); err == nil && len(s) == 6 {
+		return float64(v>>16&0xFF) / 255, float64(v>>8&0xFF) / 255, float64(v&0xFF) / 255
+	}
+	return 1, 1, 1
+}
+
+// normalize maps paths from the viewBox (or, without one, their own bounds)
+// to -1..1, preserving the aspect ratio.
+func normalize(paths []Path, viewBox []float64) {
+	var minX, minY, w, h float64
+	if viewBox != nil {
+		minX, minY, w, h = viewBox[0], viewBox[1], viewBox[2], viewBox[3]
+	} else {
+		maxX, maxY := math.Inf(-1), math.Inf(-1)
+		minX, minY = math.Inf(1), math.Inf(1)
+		for _, p := range paths {
+			for _, pt := range p.Points {
+				minX, maxX = min(minX, pt[0]), max(maxX, pt[0])
+				minY, maxY = min(minY, pt[1]), max(maxY, pt[1])
+			}
+		}
+		w, h = maxX-minX, maxY-minY
+	}
+	scale := 2 / max(w, h, 1e-9)
+	for _, p := range paths {
+		for i, pt := range p.Points {
+			p.Points[i] = [2]float64{
+				(pt[0] - minX - w/2) * scale,
+				-(pt[1] - minY - h/2) * scale,
+			}
+		}
+	}
+}
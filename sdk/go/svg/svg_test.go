@@ -0,0 +1,133 @@
+package svg
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+const doc = `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 100 100">
+  <rect x="0" y="0" width="100" height="100" stroke="#f00" fill="none"/>
+  <path d="M 50 50 l 10 0 v 10 h -10 z" style="stroke:#00ff00"/>
+  <path d="M0,100 Q50,0 100,100" stroke="#fff"/>
+  <line x1="0" y1="0" x2="10" y2="10" stroke="none"/>
+  <text>ignored</text>
+</svg>`
+
+func near(a, b [2]float64) bool {
+	return math.Abs(a[0]-b[0]) < 1e-9 && math.Abs(a[1]-b[1]) < 1e-9
+}
+
+func TestRead(t *testing.T) {
+	paths, err := Read(strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 3 {
+		t.Fatalf("got %d paths, want 3", len(paths))
+	}
+
+	rect := paths[0]
+	if rect.R != 1 || rect.G != 0 || len(rect.Points) != 5 {
+		t.Fatalf("rect = %+v", rect)
+	}
+	// The SVG's top left corner is the projection's top left.
+	if !near(rect.Points[0], [2]float64{-1, 1}) || !near(rect.Points[2], [2]float64{1, -1}) {
+		t.Fatalf("rect corners = %v", rect.Points)
+	}
+
+	square := paths[1]
+	want := [][2]float64{{0, 0}, {0.2, 0}, {0.2, -0.2}, {0, -0.2}, {0, 0}}
+	if square.G != 1 || len(square.Points) != len(want) {
+		t.Fatalf("square = %+v", square)
+	}
+	for i := range want {
+		if !near(square.Points[i], want[i]) {
+			t.Fatalf("square point %d = %v, want %v", i, square.Points[i], want[i])
+		}
+	}
+
+	curve := paths[2]
+	if len(curve.Points) != curveSegments+1 || !near(curve.Points[curveSegments/2], [2]float64{0, 0}) {
+		t.Fatalf("curve midpoint = %v", curve.Points[curveSegments/2])
+	}
+}
+
+func TestFrame(t *testing.T) {
+	paths := []Path{{R: 1, Points: [][2]float64{{-1, 0}, {1, 0}}}}
+	frame := Frame(paths, 0.5, 2)
+	// 2 blanks, the start, 4 samples along the line, 2 blanks.
+	if len(frame) != 9 {
+		t.Fatalf("len = %d, want 9", len(frame))
+	}
+	if frame[0].R != 0 || frame[2].R != 255 || frame[6].X != 4095 || frame[8].I != 0 {
+		t.Fatalf("frame = %+v", frame)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	for _, s := range []string{
+		`<svg><path d="10 10 L 5 5"/></svg>`,
+		`<svg><path d="M 10"/></svg>`,
+		`<svg viewBox="0 0 -1 5"><line x2="1"/></svg>`,
+		`<svg><text>nothing to draw</text></svg>`,
+	} {
+		if _, err := Read(strings.NewReader(s)); err == nil {
+			t.Errorf("Read(%s) succeeded", s)
+		}
+	}
+}
+
+func TestBezierReference(t *testing.T) {
+	cubic := [][2]float64{{0, 0}, {0, 1}, {1, 1}, {1, 0}}
+	quad := [][2]float64{{0, 0}, {1, 2}, {2, 0}}
+	tests := []struct {
+		ctrl [][2]float64
+		t    float64
+		want [2]float64
+	}{
+		{cubic, 0, [2]float64{0, 0}},
+		{cubic, 1, [2]float64{1, 0}},
+		{cubic, 0.5, [2]float64{0.5, 0.75}},
+		{cubic, 0.25, [2]float64{0.15625, 0.5625}},
+		{quad, 0.5, [2]float64{1, 1}},
+		{quad, 0.25, [2]float64{0.5, 0.75}},
+		// Degenerate curves: all control points equal, or a single point.
+		{[][2]float64{{3, 4}, {3, 4}, {3, 4}, {3, 4}}, 0.7, [2]float64{3, 4}},
+		{[][2]float64{{3, 4}}, 0.7, [2]float64{3, 4}},
+	}
+	for _, tt := range tests {
+		if got := bezier(tt.ctrl, tt.t); !near(got, tt.want) {
+			t.Errorf("bezier(%v, %v) = %v, want %v", tt.ctrl, tt.t, got, tt.want)
+		}
+	}
+	if cubic[1] != [2]float64{0, 1} {
+		t.Fatal("bezier modified its control points")
+	}
+}
+
+func TestNormalizeReference(t *testing.T) {
+	tests := []struct {
+		name    string
+		viewBox []float64
+		in      [][2]float64
+		want    [][2]float64
+	}{
+		{"square viewBox", []float64{0, 0, 10, 10}, [][2]float64{{0, 0}, {10, 10}, {5, 5}}, [][2]float64{{-1, 1}, {1, -1}, {0, 0}}},
+		{"offset viewBox", []float64{-5, -5, 10, 10}, [][2]float64{{-5, 5}, {0, 0}}, [][2]float64{{-1, -1}, {0, 0}}},
+		// The longer side spans -1..1; the shorter one stays centered.
+		{"wide viewBox", []float64{0, 0, 20, 10}, [][2]float64{{0, 0}, {20, 10}}, [][2]float64{{-1, 0.5}, {1, -0.5}}},
+		{"own bounds", nil, [][2]float64{{2, 2}, {6, 4}}, [][2]float64{{-1, 0.5}, {1, -0.5}}},
+		// A single point has no extent and must not divide by zero.
+		{"zero extent", nil, [][2]float64{{3, 3}, {3, 3}}, [][2]float64{{0, 0}, {0, 0}}},
+	}
+	for _, tt := range tests {
+		paths := []Path{{Points: append([][2]float64(nil), tt.in...)}}
+		normalize(paths, tt.viewBox)
+		for i, p := range paths[0].Points {
+			if !near(p, tt.want[i]) {
+				t.Errorf("%s: point %d = %v, want %v", tt.name, i, p, tt.want[i])
+			}
+		}
+	}
+}
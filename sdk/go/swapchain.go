@@ -0,0 +1,170 @@
+package helios
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Swapchain presents frames to one device the way a graphics swapchain
+// does. The device loops the frame last presented; Present hands it the
+// next one, which replaces the loop at the end of its current pass, and
+// WaitPresented blocks until the device has started scanning it. Frame
+// pacing then follows the device instead of racing GetStatus.
+type Swapchain struct {
+	// ScanFail configures the scan-fail interlock as for DeviceManager. As
+	// presented frames loop, a frame holding the beam still is blanked at
+	// once. It is DefaultScanFail unless changed before the first Present.
+	ScanFail ScanFailSettings
+
+	// OnScanFail, if set, is called when the interlock starts or stops
+	// blanking the device.
+	OnScanFail func(deviceIndex int, ev ScanFailEvent)
+
+	dac          deviceWriter
+	index        int
+	pollInterval time.Duration
+	queue        chan presentedFrame
+	done         chan struct{}
+	wg           sync.WaitGroup
+
+	mu        sync.Mutex
+	submitted uint64        // generation of the last frame presented
+	presented uint64        // generation of the last frame scanning
+	err       error         // result of writing that frame
+	changed   chan struct{} // closed when presented changes
+}
+
+type presentedFrame struct {
+	managedFrame
+	gen uint64
+}
+
+// NewSwapchain starts presenting frames to one of the DAC's open devices.
+func NewSwapchain(dac *DAC, deviceIndex int) *Swapchain {
+	return newSwapchain(dac, deviceIndex)
+}
+
+func newSwapchain(dac deviceWriter, deviceIndex int) *Swapchain {
+	s := &Swapchain{
+		ScanFail:     DefaultScanFail,
+		dac:          dac,
+		index:        deviceIndex,
+		pollInterval: DefaultPollInterval,
+		queue:        make(chan presentedFrame, 1),
+		done:         make(chan struct{}),
+		changed:      make(chan struct{}),
+	}
+	s.wg.Add(1)
+	go s.run()
+	return s
+}
+
+// Present queues points to replace the looping frame at its next safe
+// boundary. It never blocks: a frame presented earlier that the device has
+// not taken yet is dropped.
+func (s *Swapchain) Present(pps int, points []Point) error {
+	if len(points) == 0 {
+		return ErrNullPoints
+	}
+	s.mu.Lock()
+	s.submitted++
+	f := presentedFrame{managedFrame{pps: pps, points: points}, s.submitted}
+	s.mu.Unlock()
+	for {
+		select {
+		case s.queue <- f:
+			return nil
+		default:
+		}
+		select {
+		case <-s.queue: // drop the stale frame
+		default:
+		}
+	}
+}
+
+// WaitPresented blocks until the device has started scanning the frame
+// last presented, or a newer one, and returns the error writing it, if
+// any. It returns ctx.Err() if ctx is done first, and ErrDeviceClosed if
+// the swapchain is closed.
+func (s *Swapchain) WaitPresented(ctx context.Context) error {
+	s.mu.Lock()
+	want := s.submitted
+	for s.presented < want {
+		changed := s.changed
+		s.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.done:
+			return ErrDeviceClosed
+		case <-changed:
+		}
+		s.mu.Lock()
+	}
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Close stops presenting. The device keeps looping the frame it has.
+func (s *Swapchain) Close() {
+	close(s.done)
+	s.wg.Wait()
+}
+
+func (s *Swapchain) run() {
+	defer s.wg.Done()
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	var interlock scanFail
+	// waitReady polls until the device takes a frame, which for a looping
+	// device is when the frame written last has started.
+	waitReady := func() bool {
+		for s.dac.GetStatus(s.index) != Success {
+			select {
+			case <-s.done:
+				return false
+			case <-time.After(s.pollInterval):
+			}
+		}
+		return true
+	}
+	for {
+		var f presentedFrame
+		select {
+		case <-s.done:
+			return
+		case f = <-s.queue:
+		}
+		if !waitReady() {
+			return
+		}
+		// A frame presented while waiting is more recent.
+		select {
+		case f = <-s.queue:
+		default:
+		}
+		points := f.points
+		if s.ScanFail.Duration > 0 {
+			ev, changed := interlock.check(s.ScanFail, points, f.pps, 0, time.Now())
+			if ev.Tripped {
+				points = blanked(points)
+			}
+			if changed && s.OnScanFail != nil {
+				s.OnScanFail(s.index, ev)
+			}
+		}
+		err := ErrorFromCode(s.dac.WriteFrame(s.index, f.pps, 0, points))
+		if err == nil && !waitReady() {
+			return
+		}
+		s.mu.Lock()
+		s.presented, s.err = f.gen, err
+		close(s.changed)
+		s.changed = make(chan struct{})
+		s.mu.Unlock()
+	}
+}
@@ -0,0 +1,56 @@
+package helios
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSwapchainPresent(t *testing.T) {
+	w := &fakeWriter{frames: map[int][][]Point{}}
+	s := newSwapchain(w, 1)
+	defer s.Close()
+
+	// While the device is busy, only the last presented frame survives.
+	for x := uint16(1); x <= 3; x++ {
+		if err := s.Present(30000, []Point{{X: x}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := s.WaitPresented(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("WaitPresented on a busy device = %v", err)
+	}
+
+	w.mu.Lock()
+	w.ready = true
+	w.mu.Unlock()
+	ctx, cancel = context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := s.WaitPresented(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := w.written(1); len(got) != 1 || got[0][0].X != 3 {
+		t.Fatalf("written = %v, want only the latest frame", got)
+	}
+	w.mu.Lock()
+	flags := w.flags
+	w.mu.Unlock()
+	if flags&FlagSingleMode != 0 {
+		t.Fatalf("flags = %d, want the frame looped", flags)
+	}
+
+	if err := s.Present(30000, []Point{{X: 4}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.WaitPresented(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := w.written(1); len(got) != 2 || got[1][0].X != 4 {
+		t.Fatalf("written = %v", got)
+	}
+	if err := s.Present(30000, nil); err != ErrNullPoints {
+		t.Fatalf("Present(nil) = %v, want ErrNullPoints", err)
+	}
+}
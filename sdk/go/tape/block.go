@@ -0,0 +1,401 @@
+package tape
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+// Version 2 recordings group records into blocks, each compressed on its
+// own so playback can start at any block, and end with an index of the
+// blocks:
+//
+//	header: "HELIOSTAPE" version:uint16 compression:uint8
+//	block:  'B' start:int64 records:uint32 size:uint32 size × byte
+//	index:  'I' n:uint32 n × (start:int64 offset:uint64)
+//	trailer: index offset:uint64 "HELIOSIDX"
+//
+// A block decompresses to its records, each the version 1 record header
+// followed by kind:uint8 and the points: all of them for kind 0, and for
+// kind 1 only those that changed since the previous frame of the same
+// device in the block, as runs:uvarint runs × (skip:uvarint count:uvarint
+// count × point). The index and trailer are written by Recorder.Close; a
+// recording without them is still read in full, and seeking scans the
+// block headers instead.
+
+const (
+	blockVersion = 2
+
+	blockTag       = 'B'
+	indexTag       = 'I'
+	blockHeaderLen = 1 + 8 + 4 + 4
+	indexEntryLen  = 16
+	trailerMagic   = "HELIOSIDX"
+	trailerLen     = 8 + 9 // offset and trailerMagic
+
+	fullFrame  = 0
+	deltaFrame = 1
+
+	// maxBlockSize bounds the size of a block, so a corrupt size cannot
+	// allocate without limit.
+	maxBlockSize = 1 << 30
+)
+
+// DefaultBlockInterval is the time of recording a block covers.
+const DefaultBlockInterval = time.Second
+
+// Options are the settings of a recording made by NewRecorderOptions.
+type Options struct {
+	// Compression compresses each block of the recording.
+	Compression Compression
+
+	// BlockInterval is the time of recording a block covers: seeking
+	// starts decoding at most this far before the time sought. Zero means
+	// DefaultBlockInterval.
+	BlockInterval time.Duration
+}
+
+// blockWriter writes the records of a version 2 recording.
+type blockWriter struct {
+	w        io.Writer
+	codec    codec
+	interval time.Duration
+	offset   int64 // of the next block
+
+	start   time.Duration // of the block being filled
+	records int
+	payload []byte
+	prev    map[int][]helios.Point
+	out     []byte
+	index   []indexEntry
+}
+
+type indexEntry struct {
+	start  time.Duration
+	offset int64
+}
+
+func (b *blockWriter) add(rec Record) error {
+	if b.records > 0 && rec.Time-b.start >= b.interval {
+		if err := b.flush(); err != nil {
+			return err
+		}
+	}
+	if b.records == 0 {
+		b.start = rec.Time
+		clear(b.prev)
+	}
+	b.payload = appendDelta(b.payload, rec, b.prev[rec.Device])
+	b.prev[rec.Device] = append(b.prev[rec.Device][:0], rec.Points...)
+	b.records++
+	return nil
+}
+
+// flush writes the block being filled.
+func (b *blockWriter) flush() error {
+	if b.records == 0 {
+		return nil
+	}
+	out := append(b.out[:0], blockTag)
+	out = binary.LittleEndian.AppendUint64(out, uint64(b.start))
+	out = binary.LittleEndian.AppendUint32(out, uint32(b.records))
+	out = binary.LittleEndian.AppendUint32(out, 0)
+	out, err := b.codec.compress(out, b.payload)
+	if err != nil {
+		return err
+	}
+	binary.LittleEndian.PutUint32(out[13:], uint32(len(out)-blockHeaderLen))
+	b.out = out
+	if _, err := b.w.Write(out); err != nil {
+		return err
+	}
+	b.index = append(b.index, indexEntry{b.start, b.offset})
+	b.offset += int64(len(out))
+	b.payload, b.records = b.payload[:0], 0
+	return nil
+}
+
+// close flushes the last block and writes the index.
+func (b *blockWriter) close() error {
+	if err := b.flush(); err != nil {
+		return err
+	}
+	out := append(b.out[:0], indexTag)
+	out = binary.LittleEndian.AppendUint32(out, uint32(len(b.index)))
+	for _, e := range b.index {
+		out = binary.LittleEndian.AppendUint64(out, uint64(e.start))
+		out = binary.LittleEndian.AppendUint64(out, uint64(e.offset))
+	}
+	out = binary.LittleEndian.AppendUint64(out, uint64(b.offset))
+	out = append(out, trailerMagic...)
+	_, err := b.w.Write(out)
+	return err
+}
+
+// appendDelta appends rec, with its points encoded against prev.
+func appendDelta(b []byte, rec Record, prev []helios.Point) []byte {
+	b = appendRecordHeader(b, rec)
+	if len(prev) != len(rec.Points) || len(prev) == 0 {
+		return appendPoints(append(b, fullFrame), rec.Points)
+	}
+	type run struct{ skip, start, end int }
+	var runs []run
+	last, changed := 0, 0
+	for i := 0; i < len(prev); {
+		if prev[i] == rec.Points[i] {
+			i++
+			continue
+		}
+		j := i + 1
+		for j < len(prev) && prev[j] != rec.Points[j] {
+			j++
+		}
+		runs = append(runs, run{i - last, i, j})
+		changed += j - i
+		last, i = j, j
+	}
+	if changed == len(prev) {
+		return appendPoints(append(b, fullFrame), rec.Points)
+	}
+	b = append(b, deltaFrame)
+	b = binary.AppendUvarint(b, uint64(len(runs)))
+	for _, r := range runs {
+		b = binary.AppendUvarint(b, uint64(r.skip))
+		b = binary.AppendUvarint(b, uint64(r.end-r.start))
+		b = appendPoints(b, rec.Points[r.start:r.end])
+	}
+	return b
+}
+
+// blockReader decodes the records of a block.
+type blockReader struct {
+	data []byte
+	prev map[int][]helios.Point
+}
+
+func (br *blockReader) next() (Record, error) {
+	if len(br.data) < recordHeaderLen+1 {
+		return Record{}, fmt.Errorf("%w: truncated block", ErrFormat)
+	}
+	rec, n := parseRecordHeader(br.data)
+	kind := br.data[recordHeaderLen]
+	br.data = br.data[recordHeaderLen+1:]
+	if n > maxPoints {
+		return Record{}, fmt.Errorf("%w: record of %d points", ErrFormat, n)
+	}
+	switch kind {
+	case fullFrame:
+		if len(br.data) < int(n)*pointLen {
+			return Record{}, fmt.Errorf("%w: truncated block", ErrFormat)
+		}
+		rec.Points = parsePoints(br.data[:int(n)*pointLen])
+		br.data = br.data[int(n)*pointLen:]
+	case deltaFrame:
+		prev := br.prev[rec.Device]
+		if len(prev) != int(n) {
+			return Record{}, fmt.Errorf("%w: delta against a frame of %d points, not %d", ErrFormat, len(prev), n)
+		}
+		rec.Points = slices.Clone(prev)
+		runs, err := br.uvarint()
+		if err != nil {
+			return Record{}, err
+		}
+		at := 0
+		for range runs {
+			skip, err := br.uvarint()
+			if err != nil {
+				return Record{}, err
+			}
+			count, err := br.uvarint()
+			if err != nil {
+				return Record{}, err
+			}
+			at += int(skip)
+			if skip > uint64(n) || count > uint64(n) || at+int(count) > int(n) || len(br.data) < int(count)*pointLen {
+				return Record{}, fmt.Errorf("%w: delta run out of range", ErrFormat)
+			}
+			copy(rec.Points[at:], parsePoints(br.data[:int(count)*pointLen]))
+			br.data = br.data[int(count)*pointLen:]
+			at += int(count)
+		}
+	default:
+		return Record{}, fmt.Errorf("%w: record kind %d", ErrFormat, kind)
+	}
+	br.prev[rec.Device] = rec.Points
+	return rec, nil
+}
+
+func (br *blockReader) uvarint() (uint64, error) {
+	v, n := binary.Uvarint(br.data)
+	if n <= 0 {
+		return 0, fmt.Errorf("%w: bad delta encoding", ErrFormat)
+	}
+	br.data = br.data[n:]
+	return v, nil
+}
+
+// readBlock reads the next block of a version 2 recording into r.pending,
+// or returns io.EOF at the index or the end of the recording.
+func (r *Reader) readBlock() error {
+	tag, err := r.r.ReadByte()
+	if err != nil {
+		return err
+	}
+	switch tag {
+	case indexTag:
+		return io.EOF
+	case blockTag:
+	default:
+		return fmt.Errorf("%w: unknown section %q", ErrFormat, tag)
+	}
+	var h [blockHeaderLen - 1]byte
+	if _, err := io.ReadFull(r.r, h[:]); err != nil {
+		return unexpected(err)
+	}
+	records, size := binary.LittleEndian.Uint32(h[8:]), binary.LittleEndian.Uint32(h[12:])
+	if size > maxBlockSize {
+		return fmt.Errorf("%w: block of %d bytes", ErrFormat, size)
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r.r, data); err != nil {
+		return unexpected(err)
+	}
+	if data, err = r.codec.decompress(r.scratch[:0], data); err != nil {
+		return fmt.Errorf("tape: decompressing block: %w", err)
+	}
+	r.scratch = data
+	br := blockReader{data: data, prev: map[int][]helios.Point{}}
+	r.pending = r.pending[:0]
+	for range records {
+		rec, err := br.next()
+		if err != nil {
+			return err
+		}
+		r.pending = append(r.pending, rec)
+	}
+	return nil
+}
+
+func unexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// ErrNotSeekable is returned by Reader.Seek when the recording was not
+// opened from an io.ReadSeeker.
+var ErrNotSeekable = errors.New("tape: recording is not seekable")
+
+// Seek positions the reader at the first record written at or after t, so
+// that Next returns it and Player.Play starts playback there. It needs a
+// reader created from an io.ReadSeeker, such as an *os.File. Version 2
+// recordings jump to the block holding t using their index; version 1
+// recordings are read from the start.
+func (r *Reader) Seek(t time.Duration) error {
+	s, ok := r.src.(io.ReadSeeker)
+	if !ok {
+		return ErrNotSeekable
+	}
+	offset := int64(r.headerLen)
+	if r.version == blockVersion {
+		if r.index == nil {
+			index, err := readIndex(s, int64(r.headerLen))
+			if err != nil {
+				return err
+			}
+			r.index = index
+		}
+		if i := sort.Search(len(r.index), func(i int) bool { return r.index[i].start > t }); i > 0 {
+			offset = r.index[i-1].offset
+		}
+	}
+	if _, err := s.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	r.r.Reset(s)
+	r.pending = r.pending[:0]
+	r.origin = t
+	for {
+		rec, err := r.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if rec.Time >= t {
+			r.pending = append([]Record{rec}, r.pending...)
+			return nil
+		}
+	}
+}
+
+// readIndex reads the index of a version 2 recording from its trailer, or
+// by scanning the block headers if it has none.
+func readIndex(s io.ReadSeeker, headerLen int64) ([]indexEntry, error) {
+	end, err := s.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	if end >= headerLen+trailerLen {
+		if index, ok := readTrailerIndex(s, end); ok {
+			return index, nil
+		}
+	}
+	index := []indexEntry{}
+	var h [blockHeaderLen]byte
+	for offset := headerLen; offset+blockHeaderLen <= end; {
+		if _, err := s.Seek(offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+		if _, err := io.ReadFull(s, h[:]); err != nil || h[0] != blockTag {
+			break
+		}
+		index = append(index, indexEntry{time.Duration(binary.LittleEndian.Uint64(h[1:])), offset})
+		offset += blockHeaderLen + int64(binary.LittleEndian.Uint32(h[13:]))
+	}
+	return index, nil
+}
+
+func readTrailerIndex(s io.ReadSeeker, end int64) ([]indexEntry, bool) {
+	var t [trailerLen]byte
+	if _, err := s.Seek(end-trailerLen, io.SeekStart); err != nil {
+		return nil, false
+	}
+	if _, err := io.ReadFull(s, t[:]); err != nil || string(t[8:]) != trailerMagic {
+		return nil, false
+	}
+	offset := int64(binary.LittleEndian.Uint64(t[:]))
+	if offset < 0 || offset+5 > end-trailerLen {
+		return nil, false
+	}
+	if _, err := s.Seek(offset, io.SeekStart); err != nil {
+		return nil, false
+	}
+	br := bufio.NewReader(io.LimitReader(s, end-trailerLen-offset))
+	var h [5]byte
+	if _, err := io.ReadFull(br, h[:]); err != nil || h[0] != indexTag {
+		return nil, false
+	}
+	n := int(binary.LittleEndian.Uint32(h[1:]))
+	if int64(n)*indexEntryLen != end-trailerLen-offset-5 {
+		return nil, false
+	}
+	index := make([]indexEntry, n)
+	var e [indexEntryLen]byte
+	for i := range index {
+		if _, err := io.ReadFull(br, e[:]); err != nil {
+			return nil, false
+		}
+		index[i] = indexEntry{time.Duration(binary.LittleEndian.Uint64(e[:])), int64(binary.LittleEndian.Uint64(e[8:]))}
+	}
+	return index, true
+}
@@ -0,0 +1,172 @@
+package tape
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+// recordBlocks records 5s of frames 100ms apart on two devices: device 0
+// repeats a static frame with one point moving, device 1 changes every
+// point.
+func recordBlocks(t *testing.T, opts Options, close bool) ([]byte, []Record) {
+	t.Helper()
+	var buf bytes.Buffer
+	rec, err := NewRecorderOptions(&fakeDAC{}, &buf, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := rec.start
+	rec.now = func() time.Time { return clock }
+	static := make([]helios.Point, 2000)
+	for i := range static {
+		static[i] = helios.Point{X: uint16(i), Y: 100, R: 255, I: 255}
+	}
+	var want []Record
+	for i := range 50 {
+		frame := slices.Clone(static)
+		frame[i] = helios.Point{X: 4095, Y: uint16(i)}
+		moving := []helios.Point{{X: uint16(i)}, {X: uint16(2 * i)}, {Y: uint16(i)}}
+		rec.WriteFrame(0, 30000, helios.FlagsDefault, frame)
+		rec.WriteFrame(1, 20000, 0, moving)
+		at := time.Duration(i) * 100 * time.Millisecond
+		want = append(want,
+			Record{Time: at, Device: 0, PPS: 30000, Flags: helios.FlagsDefault, Result: helios.Success, Points: frame},
+			Record{Time: at, Device: 1, PPS: 20000, Result: helios.Success, Points: moving})
+		clock = clock.Add(100 * time.Millisecond)
+	}
+	if close {
+		if err := rec.Close(); err != nil {
+			t.Fatal(err)
+		}
+	} else if err := rec.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes(), want
+}
+
+func readAll(t *testing.T, r *Reader) []Record {
+	t.Helper()
+	var recs []Record
+	for {
+		rec, err := r.Next()
+		if err == io.EOF {
+			return recs
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		recs = append(recs, rec)
+	}
+}
+
+func equalRecords(a, b []Record) bool {
+	return slices.EqualFunc(a, b, func(x, y Record) bool {
+		return x.Time == y.Time && x.Device == y.Device && x.PPS == y.PPS && x.Flags == y.Flags &&
+			x.Result == y.Result && slices.Equal(x.Points, y.Points)
+	})
+}
+
+func TestBlockRecordingRoundTrip(t *testing.T) {
+	// A stand-in for a registered codec, reversing the bytes.
+	const reversed = Compression(200)
+	reverse := func(dst, src []byte) ([]byte, error) {
+		n := len(dst)
+		dst = append(dst, src...)
+		slices.Reverse(dst[n:])
+		return dst, nil
+	}
+	RegisterCompression(reversed, reverse, reverse)
+
+	v1Size := (len(magic) + 2) + 50*(2*recordHeaderLen+(2000+3)*pointLen)
+	for _, c := range []Compression{NoCompression, Flate, reversed} {
+		data, want := recordBlocks(t, Options{Compression: c}, true)
+		r, err := NewReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := readAll(t, r); !equalRecords(got, want) {
+			t.Fatalf("%v: read %d records that differ from the %d written", c, len(got), len(want))
+		}
+		// Delta encoding alone sends the static frame once a block.
+		if len(data) > v1Size/5 {
+			t.Errorf("%v: recording is %d bytes, version 1 takes %d", c, len(data), v1Size)
+		}
+	}
+}
+
+func TestBlockRecordingSeek(t *testing.T) {
+	for _, close := range []bool{true, false} {
+		data, want := recordBlocks(t, Options{Compression: Flate, BlockInterval: time.Second}, close)
+		r, err := NewReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := r.Seek(2550 * time.Millisecond); err != nil {
+			t.Fatal(err)
+		}
+		if got := readAll(t, r); !equalRecords(got, want[2*26:]) {
+			t.Fatalf("indexed %v: after seeking read %d records, want the last %d", close, len(got), len(want)-2*26)
+		}
+		if len(r.index) != 5 {
+			t.Errorf("indexed %v: %d blocks, want 5", close, len(r.index))
+		}
+		// Seeking back restarts the block.
+		if err := r.Seek(0); err != nil {
+			t.Fatal(err)
+		}
+		if got := readAll(t, r); !equalRecords(got, want) {
+			t.Fatalf("indexed %v: seeking to the start did not read every record", close)
+		}
+	}
+
+	// Version 1 recordings are read from the start.
+	r, _ := NewReader(bytes.NewReader(record(t)))
+	if err := r.Seek(time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if rec, err := r.Next(); err != nil || rec.Time != 20*time.Millisecond {
+		t.Fatalf("after seeking: %+v, %v", rec, err)
+	}
+
+	r, _ = NewReader(io.MultiReader(bytes.NewReader(record(t))))
+	if err := r.Seek(0); !errors.Is(err, ErrNotSeekable) {
+		t.Fatalf("seeking a stream: %v", err)
+	}
+}
+
+func TestBlockRecordingErrors(t *testing.T) {
+	if _, err := NewRecorderOptions(&fakeDAC{}, io.Discard, Options{Compression: 99}); err == nil {
+		t.Fatal("recording with an unregistered compression succeeded")
+	}
+
+	data, _ := recordBlocks(t, Options{}, false)
+	r, _ := NewReader(bytes.NewReader(data[:len(data)-10]))
+	var err error
+	for err == nil {
+		_, err = r.Next()
+	}
+	if err != io.ErrUnexpectedEOF {
+		t.Fatalf("truncated block: %v", err)
+	}
+
+	// Frames are held back until a block is full.
+	var buf bytes.Buffer
+	rec, _ := NewRecorderOptions(&fakeDAC{}, &buf, Options{})
+	header := buf.Len()
+	rec.WriteFrame(0, 30000, 0, []helios.Point{{}})
+	if buf.Len() != header {
+		t.Fatal("frame written before the block was full")
+	}
+	rec.Close()
+	rec.WriteFrame(0, 30000, 0, []helios.Point{{}})
+	r, _ = NewReader(bytes.NewReader(buf.Bytes()))
+	if got := readAll(t, r); len(got) != 1 {
+		t.Fatalf("read %d records, want the 1 written before Close", len(got))
+	}
+}
@@ -0,0 +1,113 @@
+package tape
+
+import (
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Compression is how the blocks of a recording are compressed.
+type Compression uint8
+
+const (
+	// NoCompression stores blocks as they are; delta encoding still
+	// shrinks recordings of static content.
+	NoCompression Compression = iota
+
+	// Flate compresses blocks with DEFLATE, from the standard library.
+	Flate
+
+	// Zstd compresses blocks with Zstandard, faster and smaller than
+	// Flate. The standard library has no Zstandard codec, so one must be
+	// registered with RegisterCompression before recording or reading:
+	//
+	//	enc, _ := zstd.NewWriter(nil)
+	//	dec, _ := zstd.NewReader(nil)
+	//	tape.RegisterCompression(tape.Zstd,
+	//		func(dst, src []byte) ([]byte, error) { return enc.EncodeAll(src, dst), nil },
+	//		func(dst, src []byte) ([]byte, error) { return dec.DecodeAll(src, dst) },
+	//	)
+	//
+	// using github.com/klauspost/compress/zstd.
+	Zstd
+)
+
+func (c Compression) String() string {
+	switch c {
+	case NoCompression:
+		return "none"
+	case Flate:
+		return "flate"
+	case Zstd:
+		return "zstd"
+	}
+	return fmt.Sprintf("Compression(%d)", int(c))
+}
+
+// A codec compresses and decompresses whole blocks, appending the result to
+// dst.
+type codec struct {
+	compress   func(dst, src []byte) ([]byte, error)
+	decompress func(dst, src []byte) ([]byte, error)
+}
+
+var codecs = struct {
+	sync.RWMutex
+	m map[Compression]codec
+}{m: map[Compression]codec{
+	NoCompression: {compress: appendBytes, decompress: appendBytes},
+	Flate:         {compress: flateCompress, decompress: flateDecompress},
+}}
+
+// RegisterCompression sets the functions compressing and decompressing the
+// blocks of recordings with c. Each appends its result to dst and returns
+// it. They may be called concurrently.
+func RegisterCompression(c Compression, compress, decompress func(dst, src []byte) ([]byte, error)) {
+	codecs.Lock()
+	defer codecs.Unlock()
+	codecs.m[c] = codec{compress, decompress}
+}
+
+func lookupCodec(c Compression) (codec, error) {
+	codecs.RLock()
+	defer codecs.RUnlock()
+	cd, ok := codecs.m[c]
+	if !ok {
+		return codec{}, fmt.Errorf("tape: %v compression is not registered", c)
+	}
+	return cd, nil
+}
+
+func appendBytes(dst, src []byte) ([]byte, error) {
+	return append(dst, src...), nil
+}
+
+func flateCompress(dst, src []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(dst)
+	w, err := flate.NewWriter(buf, flate.DefaultCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(src); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func flateDecompress(dst, src []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(dst)
+	r := flate.NewReader(bytes.NewReader(src))
+	defer r.Close()
+	if _, err := io.Copy(buf, io.LimitReader(r, maxBlockSize+1)); err != nil {
+		return nil, err
+	}
+	if buf.Len()-len(dst) > maxBlockSize {
+		return nil, fmt.Errorf("%w: block larger than %d bytes", ErrFormat, maxBlockSize)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,389 @@
+// Package tape records the frames an application writes to its DACs and
+// plays them back later with the original timing, for reproducing "it
+// looked wrong last night" reports.
+//
+// A Recorder wraps a *helios.DAC (or anything else implementing Writer):
+// every WriteFrame call is passed on and saved, with its device, point rate,
+// flags, result and time, to an io.Writer. A Player reads the recording
+// back and writes the frames to any Writer, real hardware or a test double,
+// at the times they were originally written.
+//
+// NewRecorder writes version 1 recordings: a header followed by one record
+// per frame, all little-endian:
+//
+//	header: "HELIOSTAPE" version:uint16
+//	record: time:int64 (ns since the start) device:int32 pps:int32
+//	        flags:int32 result:int32 n:uint32 n × (x:uint16 y:uint16 r g b i:uint8)
+//
+// NewRecorderOptions writes version 2 recordings, for long sessions: frames
+// are delta encoded against the previous frame of their device, grouped
+// into blocks of a second that are compressed with Flate or Zstd, and
+// indexed so Reader.Seek starts playback anywhere without reading what
+// comes before. Readers take either version.
+package tape
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+// Writer is the part of *helios.DAC that is recorded and played back.
+type Writer interface {
+	GetStatus(deviceIndex int) int
+	WriteFrame(deviceIndex int, pps int, flags int, points []helios.Point) int
+}
+
+var (
+	_ Writer = (*helios.DAC)(nil)
+	_ Writer = (*Recorder)(nil)
+)
+
+const (
+	magic   = "HELIOSTAPE"
+	version = 1
+
+	recordHeaderLen = 28
+	pointLen        = 8
+)
+
+// Record is one recorded WriteFrame call.
+type Record struct {
+	// Time is when the frame was written, since the recording started.
+	Time time.Duration
+
+	Device int
+	PPS    int
+	Flags  int
+
+	// Result is the result code the write returned.
+	Result int
+
+	Points []helios.Point
+}
+
+// Recorder is a Writer that records every frame written through it. It is
+// safe for concurrent use.
+type Recorder struct {
+	dac Writer
+	now func() time.Time
+
+	mu     sync.Mutex
+	w      io.Writer
+	blocks *blockWriter // nil for version 1
+	start  time.Time
+	err    error
+	closed bool
+	buf    []byte
+}
+
+// NewRecorder writes the tape header to w and returns a Recorder passing
+// writes on to dac. Time is measured from this call.
+func NewRecorder(dac Writer, w io.Writer) (*Recorder, error) {
+	header := binary.LittleEndian.AppendUint16([]byte(magic), version)
+	if _, err := w.Write(header); err != nil {
+		return nil, fmt.Errorf("tape: %w", err)
+	}
+	return &Recorder{dac: dac, now: time.Now, w: w, start: time.Now()}, nil
+}
+
+// NewRecorderOptions is NewRecorder writing a version 2 recording with
+// opts. Records are written a block at a time, so the recording must be
+// finished with Close.
+func NewRecorderOptions(dac Writer, w io.Writer, opts Options) (*Recorder, error) {
+	cd, err := lookupCodec(opts.Compression)
+	if err != nil {
+		return nil, err
+	}
+	interval := opts.BlockInterval
+	if interval <= 0 {
+		interval = DefaultBlockInterval
+	}
+	header := binary.LittleEndian.AppendUint16([]byte(magic), blockVersion)
+	header = append(header, byte(opts.Compression))
+	if _, err := w.Write(header); err != nil {
+		return nil, fmt.Errorf("tape: %w", err)
+	}
+	return &Recorder{
+		dac:    dac,
+		now:    time.Now,
+		w:      w,
+		blocks: &blockWriter{w: w, codec: cd, interval: interval, offset: int64(len(header)), prev: map[int][]helios.Point{}},
+		start:  time.Now(),
+	}, nil
+}
+
+// GetStatus returns the wrapped DAC's status. Status polls are not
+// recorded.
+func (r *Recorder) GetStatus(deviceIndex int) int {
+	return r.dac.GetStatus(deviceIndex)
+}
+
+// WriteFrame writes the frame to the wrapped DAC and records it. Recording
+// errors never fail the write; they are reported by Err, and the
+// recording stops at the first one.
+func (r *Recorder) WriteFrame(deviceIndex int, pps int, flags int, points []helios.Point) int {
+	result := r.dac.WriteFrame(deviceIndex, pps, flags, points)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil || r.closed {
+		return result
+	}
+	rec := Record{
+		Time:   r.now().Sub(r.start),
+		Device: deviceIndex,
+		PPS:    pps,
+		Flags:  flags,
+		Result: result,
+		Points: points,
+	}
+	if r.blocks != nil {
+		if err := r.blocks.add(rec); err != nil {
+			r.err = fmt.Errorf("tape: %w", err)
+		}
+		return result
+	}
+	r.buf = appendRecord(r.buf[:0], rec)
+	if _, err := r.w.Write(r.buf); err != nil {
+		r.err = fmt.Errorf("tape: %w", err)
+	}
+	return result
+}
+
+// Flush writes the frames recorded so far that are held back to fill a
+// block. Version 1 recordings are never held back.
+func (r *Recorder) Flush() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err == nil && r.blocks != nil && !r.closed {
+		if err := r.blocks.flush(); err != nil {
+			r.err = fmt.Errorf("tape: %w", err)
+		}
+	}
+	return r.err
+}
+
+// Close finishes the recording, writing the frames held back and the index
+// of a version 2 recording. Frames written afterwards are passed on but not
+// recorded. It does not close the underlying io.Writer.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err == nil && r.blocks != nil && !r.closed {
+		if err := r.blocks.close(); err != nil {
+			r.err = fmt.Errorf("tape: %w", err)
+		}
+	}
+	r.closed = true
+	return r.err
+}
+
+// Err returns the first error writing the recording.
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+func appendRecord(b []byte, rec Record) []byte {
+	return appendPoints(appendRecordHeader(b, rec), rec.Points)
+}
+
+func appendRecordHeader(b []byte, rec Record) []byte {
+	b = binary.LittleEndian.AppendUint64(b, uint64(rec.Time))
+	for _, v := range []int{rec.Device, rec.PPS, rec.Flags, rec.Result} {
+		b = binary.LittleEndian.AppendUint32(b, uint32(int32(v)))
+	}
+	return binary.LittleEndian.AppendUint32(b, uint32(len(rec.Points)))
+}
+
+func appendPoints(b []byte, points []helios.Point) []byte {
+	for _, p := range points {
+		b = binary.LittleEndian.AppendUint16(b, p.X)
+		b = binary.LittleEndian.AppendUint16(b, p.Y)
+		b = append(b, p.R, p.G, p.B, p.I)
+	}
+	return b
+}
+
+// parseRecordHeader decodes a record header, returning the record without
+// its points and the number of points.
+func parseRecordHeader(h []byte) (Record, uint32) {
+	field := func(i int) int { return int(int32(binary.LittleEndian.Uint32(h[8+4*i:]))) }
+	rec := Record{
+		Time:   time.Duration(binary.LittleEndian.Uint64(h)),
+		Device: field(0),
+		PPS:    field(1),
+		Flags:  field(2),
+		Result: field(3),
+	}
+	return rec, binary.LittleEndian.Uint32(h[24:])
+}
+
+func parsePoints(data []byte) []helios.Point {
+	points := make([]helios.Point, len(data)/pointLen)
+	for i := range points {
+		d := data[i*pointLen:]
+		points[i] = helios.Point{
+			X: binary.LittleEndian.Uint16(d),
+			Y: binary.LittleEndian.Uint16(d[2:]),
+			R: d[4], G: d[5], B: d[6], I: d[7],
+		}
+	}
+	return points
+}
+
+// ErrFormat is returned for data that is not a tape recording.
+var ErrFormat = errors.New("tape: not a recording")
+
+// maxPoints bounds the points per record, so a corrupt length cannot
+// allocate without limit.
+const maxPoints = 1 << 20
+
+// Reader reads a recording.
+type Reader struct {
+	r         *bufio.Reader
+	src       io.Reader
+	version   uint16
+	headerLen int
+
+	// Version 2 recordings are decoded a block at a time into pending.
+	codec   codec
+	pending []Record
+	scratch []byte
+	index   []indexEntry
+
+	origin time.Duration // time Seek moved to
+}
+
+// NewReader checks the tape header and returns a reader for the records
+// following it. Readers of an io.ReadSeeker can also Seek.
+func NewReader(r io.Reader) (*Reader, error) {
+	br := bufio.NewReader(r)
+	header := make([]byte, len(magic)+2)
+	if _, err := io.ReadFull(br, header); err != nil || !bytes.Equal(header[:len(magic)], []byte(magic)) {
+		return nil, ErrFormat
+	}
+	rd := &Reader{r: br, src: r, headerLen: len(header)}
+	switch rd.version = binary.LittleEndian.Uint16(header[len(magic):]); rd.version {
+	case version:
+	case blockVersion:
+		c, err := br.ReadByte()
+		if err != nil {
+			return nil, ErrFormat
+		}
+		if rd.codec, err = lookupCodec(Compression(c)); err != nil {
+			return nil, err
+		}
+		rd.headerLen++
+	default:
+		return nil, fmt.Errorf("tape: unsupported version %d", rd.version)
+	}
+	return rd, nil
+}
+
+// Next returns the next record, or io.EOF after the last one. A recording
+// cut off in the middle of a record returns io.ErrUnexpectedEOF.
+func (r *Reader) Next() (Record, error) {
+	if r.version == blockVersion && len(r.pending) == 0 {
+		if err := r.readBlock(); err != nil {
+			return Record{}, err
+		}
+	}
+	if len(r.pending) > 0 {
+		rec := r.pending[0]
+		r.pending = r.pending[1:]
+		return rec, nil
+	}
+	var h [recordHeaderLen]byte
+	if _, err := io.ReadFull(r.r, h[:]); err != nil {
+		return Record{}, err
+	}
+	rec, n := parseRecordHeader(h[:])
+	if n > maxPoints {
+		return Record{}, fmt.Errorf("%w: record of %d points", ErrFormat, n)
+	}
+	data := make([]byte, int(n)*pointLen)
+	if _, err := io.ReadFull(r.r, data); err != nil {
+		return Record{}, unexpected(err)
+	}
+	rec.Points = parsePoints(data)
+	return rec, nil
+}
+
+// Player writes a recording to a Writer with its original timing.
+type Player struct {
+	// Speed scales the playback rate; 2 plays twice as fast. Zero means 1.
+	Speed float64
+
+	// PollInterval is how often a busy device's status is polled. Zero
+	// means helios.DefaultPollInterval.
+	PollInterval time.Duration
+
+	// Devices maps recorded device indices to playback devices. Devices
+	// not listed play on the same index.
+	Devices map[int]int
+}
+
+// Play writes every record of r to w at its recorded time, relative to the
+// start of playback, or to the time r was seeked to, waiting for the device
+// to be ready as the application did. Frames that cannot be written on time are written as soon as the
+// device is ready, without delaying the ones after them. Play returns when
+// the recording ends, with nil, or when ctx is done.
+func (p *Player) Play(ctx context.Context, w Writer, r *Reader) error {
+	speed := p.Speed
+	if speed <= 0 {
+		speed = 1
+	}
+	poll := p.PollInterval
+	if poll <= 0 {
+		poll = helios.DefaultPollInterval
+	}
+	start := time.Now()
+	for {
+		rec, err := r.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		device := rec.Device
+		if d, ok := p.Devices[device]; ok {
+			device = d
+		}
+		at := start.Add(time.Duration(float64(rec.Time-r.origin) / speed))
+		if err := sleepUntil(ctx, at); err != nil {
+			return err
+		}
+		for !helios.StatusFromCode(w.GetStatus(device)).Ready() {
+			if err := sleepUntil(ctx, time.Now().Add(poll)); err != nil {
+				return err
+			}
+		}
+		w.WriteFrame(device, rec.PPS, rec.Flags, rec.Points)
+	}
+}
+
+func sleepUntil(ctx context.Context, t time.Time) error {
+	d := time.Until(t)
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
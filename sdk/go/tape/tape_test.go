@@ -0,0 +1,115 @@
+package tape
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+// fakeDAC records writes and reports busy every other poll.
+type fakeDAC struct {
+	polls  int
+	writes []Record
+	start  time.Time
+}
+
+func (f *fakeDAC) GetStatus(int) int {
+	f.polls++
+	return f.polls % 2
+}
+
+func (f *fakeDAC) WriteFrame(device, pps, flags int, points []helios.Point) int {
+	f.writes = append(f.writes, Record{Time: time.Since(f.start), Device: device, PPS: pps, Flags: flags, Points: slices.Clone(points)})
+	return helios.Success
+}
+
+func record(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	rec, err := NewRecorder(&fakeDAC{}, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := rec.start
+	rec.now = func() time.Time { return clock }
+	frame := []helios.Point{{X: 1, Y: 2, R: 3, G: 4, B: 5, I: 6}, {X: 4095, Y: 0, I: 255}}
+	rec.WriteFrame(0, 30000, helios.FlagsDefault, frame)
+	clock = clock.Add(20 * time.Millisecond)
+	rec.WriteFrame(1, 7, 0, frame[:1])
+	if err := rec.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestRecordAndRead(t *testing.T) {
+	r, err := NewReader(bytes.NewReader(record(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := r.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Time != 0 || first.Device != 0 || first.PPS != 30000 || first.Flags != helios.FlagsDefault || first.Result != helios.Success {
+		t.Fatalf("first = %+v", first)
+	}
+	if len(first.Points) != 2 || first.Points[0] != (helios.Point{X: 1, Y: 2, R: 3, G: 4, B: 5, I: 6}) || first.Points[1].X != 4095 {
+		t.Fatalf("points = %+v", first.Points)
+	}
+	second, err := r.Next()
+	if err != nil || second.Time != 20*time.Millisecond || second.Device != 1 || second.PPS != 7 || len(second.Points) != 1 {
+		t.Fatalf("second = %+v, %v", second, err)
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Fatalf("after the last record: %v", err)
+	}
+}
+
+func TestReaderRejectsBadData(t *testing.T) {
+	if _, err := NewReader(strings.NewReader("ILDA0000")); !errors.Is(err, ErrFormat) {
+		t.Fatalf("err = %v", err)
+	}
+	data := record(t)
+	r, _ := NewReader(bytes.NewReader(data[:len(data)-3]))
+	r.Next()
+	if _, err := r.Next(); err != io.ErrUnexpectedEOF {
+		t.Fatalf("truncated record: %v", err)
+	}
+}
+
+func TestPlayKeepsTiming(t *testing.T) {
+	r, _ := NewReader(bytes.NewReader(record(t)))
+	dac := &fakeDAC{start: time.Now()}
+	p := &Player{Speed: 2, PollInterval: time.Microsecond, Devices: map[int]int{1: 3}}
+	if err := p.Play(context.Background(), dac, r); err != nil {
+		t.Fatal(err)
+	}
+	if len(dac.writes) != 2 {
+		t.Fatalf("%d writes", len(dac.writes))
+	}
+	// 20ms apart at double speed.
+	if gap := dac.writes[1].Time - dac.writes[0].Time; gap < 10*time.Millisecond || gap > 50*time.Millisecond {
+		t.Fatalf("frames played %v apart", gap)
+	}
+	if w := dac.writes[1]; w.Device != 3 || w.PPS != 7 || w.Flags != 0 {
+		t.Fatalf("second write = %+v", w)
+	}
+	if dac.polls < 3 {
+		t.Fatalf("polled %d times", dac.polls)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r, _ = NewReader(bytes.NewReader(record(t)))
+	if err := (&Player{}).Play(ctx, &fakeDAC{}, r); !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled playback: %v", err)
+	}
+}
@@ -0,0 +1,213 @@
+package timecode
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/audio"
+	"github.com/Grix/helios_dac/sdk/go/show"
+)
+
+// DefaultFreewheel is how long a new LTC clock keeps running after the
+// signal is lost.
+const DefaultFreewheel = 500 * time.Millisecond
+
+const (
+	// ltcSync is the sync word ending each 80-bit frame, bits 64 to 79.
+	ltcSync = 0xbffc
+
+	// ltcHysteresis is the level a sample must pass to change the decoded
+	// signal's polarity, so noise around zero is not read as transitions.
+	ltcHysteresis = 0.02
+
+	// ltcBatch is the number of samples Run decodes at a time; it bounds
+	// the error of frame timestamps.
+	ltcBatch = 128
+)
+
+// LTC is a show.Clock decoding SMPTE linear timecode from audio samples.
+// Its reading is the timecode position, moving on with the local clock
+// between frames, so an engine locked to it chases the timecode. When the
+// signal is lost the reading freewheels for Freewheel and then holds until
+// timecode returns; a jump in the timecode jumps the reading. Forward play
+// at 24, 25, 29.97 drop-frame and 30 fps is decoded; the frame rate is
+// measured from the signal. It is safe for concurrent use.
+type LTC struct {
+	// Freewheel is how long the reading keeps moving after the last frame
+	// decoded. Zero means DefaultFreewheel.
+	Freewheel time.Duration
+
+	mu         sync.Mutex
+	local      show.Clock
+	sampleRate float64
+
+	high   bool    // decoded polarity
+	run    int     // samples since the last transition
+	bit    float64 // estimated samples per bit
+	half   bool    // the first half of a one is pending
+	lo, hi uint64  // the last 80 bits, the oldest in bit 0 of lo
+
+	tc     Timecode
+	pos    time.Duration // position at local reading at
+	at     time.Duration
+	synced bool
+}
+
+// NewLTC creates a decoder for audio at sampleRate, timing frames by local.
+// A nil local uses a show.WallClock.
+func NewLTC(sampleRate int, local show.Clock) *LTC {
+	if local == nil {
+		local = show.NewWallClock()
+	}
+	sr := float64(sampleRate)
+	return &LTC{local: local, sampleRate: sr, bit: sr / (80 * 27)}
+}
+
+// Process decodes mono samples (-1.0 to 1.0) that have just been captured:
+// the last sample is taken to be at the local clock's current reading.
+func (c *LTC) Process(samples []float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.local.Now()
+	for i, x := range samples {
+		c.run++
+		high := c.high
+		if x > ltcHysteresis {
+			high = true
+		} else if x < -ltcHysteresis {
+			high = false
+		}
+		if high == c.high {
+			continue
+		}
+		c.high = high
+		age := time.Duration(float64(len(samples)-1-i) / c.sampleRate * float64(time.Second))
+		c.transition(float64(c.run), now-age)
+		c.run = 0
+	}
+}
+
+// transition classifies the n samples since the previous transition as a
+// whole bit period (a zero) or half of one (half of a one), tracking the
+// bit rate as it goes.
+func (c *LTC) transition(n float64, at time.Duration) {
+	switch {
+	case n > 1.5*c.bit || n < 0.25*c.bit: // silence or noise
+		c.half = false
+	case n >= 0.75*c.bit:
+		c.adapt(n)
+		c.half = false
+		c.push(0, at)
+	case c.half:
+		c.adapt(2 * n)
+		c.half = false
+		c.push(1, at)
+	default:
+		c.half = true
+	}
+}
+
+// adapt moves the bit period estimate towards n, within the range of the
+// supported frame rates.
+func (c *LTC) adapt(n float64) {
+	c.bit += (n - c.bit) / 8
+	c.bit = max(c.sampleRate/(80*32), min(c.bit, c.sampleRate/(80*22)))
+}
+
+func (c *LTC) push(b uint64, at time.Duration) {
+	c.lo = c.lo>>1 | c.hi<<63
+	c.hi = c.hi>>1 | b<<15
+	if c.hi == ltcSync {
+		c.frame(at)
+	}
+}
+
+// frame decodes the frame just completed by the sync word.
+func (c *LTC) frame(at time.Duration) {
+	field := func(shift, bits uint) int { return int(c.lo >> shift & (1<<bits - 1)) }
+	tc := Timecode{
+		Frames:    field(0, 4) + 10*field(8, 2),
+		Seconds:   field(16, 4) + 10*field(24, 3),
+		Minutes:   field(32, 4) + 10*field(40, 3),
+		Hours:     field(48, 4) + 10*field(56, 2),
+		DropFrame: c.lo>>10&1 == 1,
+		FPS:       30,
+	}
+	if !tc.DropFrame {
+		fps := c.sampleRate / (80 * c.bit)
+		for _, r := range []int{24, 25} {
+			if math.Abs(fps-float64(r)) < math.Abs(fps-float64(tc.FPS)) {
+				tc.FPS = r
+			}
+		}
+	}
+	if tc.Validate() != nil {
+		return
+	}
+	// The sync word ends the frame, so the next one starts now.
+	c.tc, c.pos, c.at, c.synced = tc, tc.Duration()+tc.FrameDuration(), at, true
+}
+
+func (c *LTC) freewheel() time.Duration {
+	if c.Freewheel > 0 {
+		return c.Freewheel
+	}
+	return DefaultFreewheel
+}
+
+// Now returns the timecode position, or zero before the first frame.
+func (c *LTC) Now() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.synced {
+		return 0
+	}
+	return c.pos + max(0, min(c.local.Now()-c.at, c.freewheel()))
+}
+
+// Timecode returns the last frame decoded, and whether one has been.
+func (c *LTC) Timecode() (Timecode, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.tc, c.synced
+}
+
+// Locked reports whether a frame has been decoded within Freewheel.
+func (c *LTC) Locked() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.synced && c.local.Now()-c.at <= c.freewheel()
+}
+
+// Run reads PCM in format f from r and decodes it until r returns an error.
+// The timecode is read from the first channel. Run returns nil when r
+// reaches EOF.
+func (c *LTC) Run(r io.Reader, f audio.Format) error {
+	if f.Channels < 1 {
+		f.Channels = 1
+	}
+	br := bufio.NewReader(r)
+	frame := make([]byte, 2*f.Channels)
+	samples := make([]float64, 0, ltcBatch)
+	for {
+		if _, err := io.ReadFull(br, frame); err != nil {
+			if len(samples) > 0 {
+				c.Process(samples)
+			}
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return nil
+			}
+			return err
+		}
+		samples = append(samples, float64(int16(binary.LittleEndian.Uint16(frame)))/32768)
+		if len(samples) == cap(samples) || br.Buffered() < len(frame) {
+			c.Process(samples)
+			samples = samples[:0]
+		}
+	}
+}
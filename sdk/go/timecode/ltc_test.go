@@ -0,0 +1,112 @@
+package timecode
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/audio"
+	"github.com/Grix/helios_dac/sdk/go/show"
+)
+
+// ltcBits returns the 80 bits of an LTC frame in transmission order.
+func ltcBits(tc Timecode) []int {
+	var word uint64
+	put := func(shift uint, v int) { word |= uint64(v) << shift }
+	put(0, tc.Frames%10)
+	put(8, tc.Frames/10)
+	put(16, tc.Seconds%10)
+	put(24, tc.Seconds/10)
+	put(32, tc.Minutes%10)
+	put(40, tc.Minutes/10)
+	put(48, tc.Hours%10)
+	put(56, tc.Hours/10)
+	if tc.DropFrame {
+		put(10, 1)
+	}
+	bits := make([]int, 80)
+	for i := range 64 {
+		bits[i] = int(word >> i & 1)
+	}
+	for i := range 16 {
+		bits[64+i] = ltcSync >> i & 1
+	}
+	return bits
+}
+
+// ltcSignal encodes frames consecutive frames from tc with biphase mark
+// coding, followed by the transition that ends the last bit.
+func ltcSignal(tc Timecode, frames, samplesPerBit int) []float64 {
+	var out []float64
+	level := 0.5
+	for range frames {
+		for _, b := range ltcBits(tc) {
+			level = -level
+			for i := range samplesPerBit {
+				if b == 1 && i == samplesPerBit/2 {
+					level = -level
+				}
+				out = append(out, level)
+			}
+		}
+		if tc.Frames++; tc.Frames == tc.FPS {
+			tc.Frames = 0
+			tc.Seconds++
+		}
+	}
+	for range samplesPerBit / 2 {
+		out = append(out, -level)
+	}
+	return out
+}
+
+func TestLTCDecode(t *testing.T) {
+	local := &show.ManualClock{}
+	local.Advance(time.Minute)
+	c := NewLTC(48000, local)
+	start := Timecode{Hours: 1, Seconds: 58, Frames: 20, FPS: 25}
+	signal := ltcSignal(start, 10, 48000/(80*25))
+	for i := 0; i < len(signal); i += 480 {
+		local.Advance(10 * time.Millisecond)
+		c.Process(signal[i:min(i+480, len(signal))])
+	}
+
+	tc, ok := c.Timecode()
+	if want := (Timecode{Hours: 1, Seconds: 59, Frames: 4, FPS: 25}); !ok || tc != want {
+		t.Fatalf("Timecode = %v (%d fps), want %v", tc, tc.FPS, want)
+	}
+	// The last frame ended half a bit before the signal did.
+	want := time.Hour + 59*time.Second + 200*time.Millisecond
+	if got := c.Now(); got < want || got > want+time.Millisecond || !c.Locked() {
+		t.Fatalf("Now = %v, want %v", got, want)
+	}
+
+	local.Advance(time.Second)
+	held := c.Now()
+	local.Advance(time.Second)
+	if c.Now() != held || c.Locked() {
+		t.Fatal("clock did not hold after the signal was lost")
+	}
+	if held > want+DefaultFreewheel+time.Millisecond {
+		t.Fatalf("freewheeled to %v", held)
+	}
+}
+
+func TestLTCRunDropFrame(t *testing.T) {
+	start := Timecode{Minutes: 1, Seconds: 59, Frames: 20, FPS: 30, DropFrame: true}
+	signal := ltcSignal(start, 5, 20) // 48kHz at 30 fps
+	var pcm bytes.Buffer
+	for _, x := range signal {
+		binary.Write(&pcm, binary.LittleEndian, int16(x*32767))
+		binary.Write(&pcm, binary.LittleEndian, int16(0)) // second channel
+	}
+	c := NewLTC(48000, nil)
+	if err := c.Run(&pcm, audio.Format{SampleRate: 48000, Channels: 2}); err != nil {
+		t.Fatal(err)
+	}
+	tc, _ := c.Timecode()
+	if want := (Timecode{Minutes: 1, Seconds: 59, Frames: 24, FPS: 30, DropFrame: true}); tc != want {
+		t.Fatalf("Timecode = %v (%d fps), want %v", tc, tc.FPS, want)
+	}
+}
@@ -0,0 +1,151 @@
+package timecode
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/show"
+)
+
+// MIDI system messages followed by MIDIClock.
+const (
+	midiSongPosition = 0xf2
+	midiClock        = 0xf8
+	midiStart        = 0xfa
+	midiContinue     = 0xfb
+	midiStop         = 0xfc
+)
+
+// ticksPerBeat is the MIDI beat clock resolution: 24 ticks per quarter note.
+const ticksPerBeat = 24
+
+// DefaultBPM is the tempo a new MIDIClock's show is authored at.
+const DefaultBPM = 120
+
+// MIDIClock is a show.Clock following MIDI beat clock. Its reading is the
+// musical position since Start, converted to time at BPM, so a show
+// authored at BPM plays in step with the master at any tempo it sets: when
+// the master speeds up, so does the show. Between ticks the reading moves
+// on at the measured tempo, stopping at the next tick until it arrives.
+// Stop holds the reading, Continue resumes it and Song Position Pointer
+// jumps it.
+//
+// MIDIClock is an io.Writer of raw MIDI bytes, such as those read from a
+// rawmidi device, so io.Copy(clock, dev) feeds it. It is safe for
+// concurrent use.
+type MIDIClock struct {
+	// BPM is the tempo the show is authored at. Zero means DefaultBPM.
+	// Set it before the clock is read.
+	BPM float64
+
+	mu      sync.Mutex
+	local   show.Clock
+	running bool
+	ticks   int64
+	frac    float64       // part of a tick passed when the clock stopped
+	last    time.Duration // local reading at the last tick
+	ticked  bool          // whether last is a tick, so the next one measures the tempo
+	period  time.Duration // smoothed tick interval
+	status  byte
+	data    []byte
+}
+
+// NewMIDIClock creates a stopped clock at position zero, timing ticks by
+// local. A nil local uses a show.WallClock.
+func NewMIDIClock(local show.Clock) *MIDIClock {
+	if local == nil {
+		local = show.NewWallClock()
+	}
+	return &MIDIClock{local: local}
+}
+
+// Write processes raw MIDI bytes. Messages other than the ones followed
+// are skipped. It never fails.
+func (c *MIDIClock) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, b := range p {
+		switch {
+		case b >= 0xf8: // real-time messages may appear anywhere
+			c.realtime(b)
+		case b >= 0x80:
+			c.status, c.data = b, c.data[:0]
+		case c.status == midiSongPosition:
+			c.data = append(c.data, b)
+			if len(c.data) == 2 {
+				// The position counts sixteenth notes, six ticks each.
+				c.ticks = (int64(c.data[0]) | int64(c.data[1])<<7) * ticksPerBeat / 4
+				c.frac, c.ticked = 0, false
+				c.status = 0
+			}
+		}
+	}
+	return len(p), nil
+}
+
+func (c *MIDIClock) realtime(b byte) {
+	now := c.local.Now()
+	switch b {
+	case midiClock:
+		if !c.running {
+			return
+		}
+		if d := now - c.last; c.ticked && c.period == 0 {
+			c.period = d
+		} else if c.ticked {
+			c.period += (d - c.period) / 8
+		}
+		c.ticks++
+		c.frac, c.last, c.ticked = 0, now, true
+	case midiStart:
+		c.running, c.ticks, c.frac, c.ticked = true, 0, 0, false
+	case midiContinue:
+		c.running, c.ticked = true, false
+	case midiStop:
+		c.frac = c.fracLocked()
+		c.running = false
+	}
+}
+
+// fracLocked returns the part of a tick the reading has moved on since the
+// last one.
+func (c *MIDIClock) fracLocked() float64 {
+	if !c.running || !c.ticked || c.period <= 0 {
+		return c.frac
+	}
+	return max(c.frac, min(float64(c.local.Now()-c.last)/float64(c.period), 1))
+}
+
+// Now returns the musical position as time at BPM.
+func (c *MIDIClock) Now() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ticks := float64(c.ticks) + c.fracLocked()
+	return time.Duration(ticks / ticksPerBeat * float64(time.Minute) / c.bpm())
+}
+
+func (c *MIDIClock) bpm() float64 {
+	if c.BPM > 0 {
+		return c.BPM
+	}
+	return DefaultBPM
+}
+
+// Tempo returns the master's tempo measured from the tick rate, or zero
+// before two ticks have arrived.
+func (c *MIDIClock) Tempo() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.period <= 0 {
+		return 0
+	}
+	return float64(time.Minute) / float64(c.period*ticksPerBeat)
+}
+
+// Running reports whether the master has started or continued the clock
+// and not stopped it since.
+func (c *MIDIClock) Running() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.running
+}
@@ -0,0 +1,63 @@
+package timecode
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/show"
+)
+
+func TestMIDIClock(t *testing.T) {
+	local := &show.ManualClock{}
+	c := NewMIDIClock(local)
+	tick := func(n int) {
+		for range n {
+			local.Advance(20 * time.Millisecond) // 125 BPM
+			c.Write([]byte{midiClock})
+		}
+	}
+
+	tick(10) // ignored while stopped
+	if c.Now() != 0 || c.Running() {
+		t.Fatal("stopped clock moved")
+	}
+	c.Write([]byte{midiStart})
+	tick(24)
+	if got := c.Now(); got != 500*time.Millisecond {
+		t.Fatalf("one beat = %v, want 500ms at 120 BPM", got)
+	}
+	if bpm := c.Tempo(); math.Abs(bpm-125) > 0.01 {
+		t.Fatalf("Tempo = %v, want 125", bpm)
+	}
+
+	// Between ticks the reading moves on, but not past the next tick.
+	local.Advance(10 * time.Millisecond)
+	half := 500*time.Millisecond + time.Minute/120/24/2
+	if got := c.Now(); got != half {
+		t.Fatalf("half a tick on = %v, want %v", got, half)
+	}
+	local.Advance(time.Second)
+	next := 500*time.Millisecond + time.Minute/120/24
+	if got := c.Now(); got != next {
+		t.Fatalf("late tick: Now = %v, want %v", got, next)
+	}
+
+	c.Write([]byte{midiStop})
+	local.Advance(time.Second)
+	if got := c.Now(); got != next || c.Running() {
+		t.Fatalf("stopped: Now = %v, want %v", got, next)
+	}
+
+	// Song position 8 sixteenths is two beats; a note on in between is
+	// skipped, with clock ticks interleaved.
+	c.Write([]byte{0x90, 0x3c, 0x7f, midiSongPosition, 0x08, midiClock, 0x00})
+	if got := c.Now(); got != time.Second {
+		t.Fatalf("song position 8 = %v, want 1s", got)
+	}
+	c.Write([]byte{midiContinue})
+	tick(1)
+	if got := c.Now(); got != time.Second+time.Minute/120/24 {
+		t.Fatalf("continue: Now = %v", got)
+	}
+}
@@ -0,0 +1,98 @@
+// Package timecode provides show.Clock implementations locked to external
+// sync sources, so laser playback follows the rest of a show instead of
+// free-running on show.WallClock.
+//
+// MIDIClock follows MIDI beat clock from a raw MIDI byte stream, and LTC
+// decodes SMPTE linear timecode from audio. LTC readings are absolute
+// positions, so an engine chasing it is locked:
+//
+//	ltc := timecode.NewLTC(48000, nil)
+//	go ltc.Run(audioIn, audio.Format{SampleRate: 48000, Channels: 1})
+//	engine := show.NewEngine(ltc)
+//	engine.Lock(time.Hour) // timecode 01:00:00:00 is the start of the show
+package timecode
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Timecode is an SMPTE timecode address.
+type Timecode struct {
+	Hours, Minutes, Seconds, Frames int
+
+	// FPS is the nominal frame rate: 24, 25 or 30.
+	FPS int
+
+	// DropFrame marks 29.97 fps drop-frame timecode, which skips frame
+	// numbers 0 and 1 every minute except every tenth to stay in step with
+	// the clock. FPS is 30.
+	DropFrame bool
+}
+
+// Parse parses "hh:mm:ss:ff" at the given nominal frame rate, or
+// "hh:mm:ss;ff" for drop-frame timecode at 30.
+func Parse(s string, fps int) (Timecode, error) {
+	var tc Timecode
+	if len(s) != 11 {
+		return tc, fmt.Errorf("timecode: %q is not hh:mm:ss:ff", s)
+	}
+	sep := s[8]
+	if _, err := fmt.Sscanf(s[:8]+":"+s[9:], "%02d:%02d:%02d:%02d", &tc.Hours, &tc.Minutes, &tc.Seconds, &tc.Frames); err != nil || (sep != ':' && sep != ';') {
+		return tc, fmt.Errorf("timecode: %q is not hh:mm:ss:ff", s)
+	}
+	tc.FPS, tc.DropFrame = fps, sep == ';'
+	return tc, tc.Validate()
+}
+
+// Validate checks the fields are in range for the frame rate.
+func (tc Timecode) Validate() error {
+	switch {
+	case tc.FPS != 24 && tc.FPS != 25 && tc.FPS != 30:
+		return fmt.Errorf("timecode: unsupported frame rate %d", tc.FPS)
+	case tc.DropFrame && tc.FPS != 30:
+		return errors.New("timecode: drop-frame timecode is 30 fps")
+	case tc.Hours < 0 || tc.Hours > 23 || tc.Minutes < 0 || tc.Minutes > 59 || tc.Seconds < 0 || tc.Seconds > 59:
+		return fmt.Errorf("timecode: %v is out of range", tc)
+	case tc.Frames < 0 || tc.Frames >= tc.FPS:
+		return fmt.Errorf("timecode: frame %d is out of range at %d fps", tc.Frames, tc.FPS)
+	case tc.DropFrame && tc.Seconds == 0 && tc.Frames < 2 && tc.Minutes%10 != 0:
+		return fmt.Errorf("timecode: %v is dropped", tc)
+	}
+	return nil
+}
+
+// Frame returns the number of frames since 00:00:00:00.
+func (tc Timecode) Frame() int {
+	n := ((tc.Hours*60+tc.Minutes)*60+tc.Seconds)*tc.FPS + tc.Frames
+	if tc.DropFrame {
+		minutes := tc.Hours*60 + tc.Minutes
+		n -= 2 * (minutes - minutes/10)
+	}
+	return n
+}
+
+// FrameDuration returns the length of one frame.
+func (tc Timecode) FrameDuration() time.Duration {
+	if tc.DropFrame {
+		return time.Second * 1001 / 30000
+	}
+	return time.Second / time.Duration(tc.FPS)
+}
+
+// Duration returns the time since 00:00:00:00.
+func (tc Timecode) Duration() time.Duration {
+	if tc.DropFrame {
+		return time.Duration(tc.Frame()) * time.Second * 1001 / 30000
+	}
+	return time.Duration(tc.Frame()) * time.Second / time.Duration(tc.FPS)
+}
+
+func (tc Timecode) String() string {
+	sep := ':'
+	if tc.DropFrame {
+		sep = ';'
+	}
+	return fmt.Sprintf("%02d:%02d:%02d%c%02d", tc.Hours, tc.Minutes, tc.Seconds, sep, tc.Frames)
+}
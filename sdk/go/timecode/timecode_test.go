@@ -0,0 +1,61 @@
+package timecode
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tc, err := Parse("01:02:03:04", 25)
+	if err != nil || tc != (Timecode{1, 2, 3, 4, 25, false}) {
+		t.Fatalf("Parse = %+v, %v", tc, err)
+	}
+	if got := tc.String(); got != "01:02:03:04" {
+		t.Fatalf("String = %q", got)
+	}
+	want := time.Hour + 2*time.Minute + 3*time.Second + 160*time.Millisecond
+	if got := tc.Duration(); got != want {
+		t.Fatalf("Duration = %v, want %v", got, want)
+	}
+	for _, bad := range []struct {
+		s   string
+		fps int
+	}{
+		{"01:02:03", 25},
+		{"01:02:03:25", 25},
+		{"1:02:03:04", 25},
+		{"01:02:03:04", 29},
+		{"01:01:00;00", 30}, // dropped
+		{"01:00:00;00", 25},
+	} {
+		if _, err := Parse(bad.s, bad.fps); err == nil {
+			t.Errorf("Parse(%q, %d) succeeded", bad.s, bad.fps)
+		}
+	}
+}
+
+func TestDropFrame(t *testing.T) {
+	for _, c := range []struct {
+		s     string
+		frame int
+	}{
+		{"00:00:59;29", 1799},
+		{"00:01:00;02", 1800},
+		{"00:10:00;00", 17982},
+		{"01:00:00;00", 107892},
+	} {
+		tc, err := Parse(c.s, 30)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := tc.Frame(); got != c.frame {
+			t.Errorf("%s is frame %d, want %d", c.s, got, c.frame)
+		}
+	}
+	// An hour of drop-frame timecode is an hour of real time to within
+	// 3.6ms, the rounding of 29.97 fps.
+	tc, _ := Parse("01:00:00;00", 30)
+	if d := tc.Duration() - time.Hour; d < -4*time.Millisecond || d > 4*time.Millisecond {
+		t.Fatalf("01:00:00;00 is %v", tc.Duration())
+	}
+}
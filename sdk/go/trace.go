@@ -0,0 +1,117 @@
+package helios
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// FrameMeta identifies a frame, so a glitch seen on the output can be
+// traced back to the generator that produced it. It travels beside the
+// points: through DAC.WriteFrameMeta, DeviceManager.SubmitFrameMeta and
+// output.WriteFrameMeta, to the write hook and logs.
+type FrameMeta struct {
+	// ID is unique within the process. Zero means the frame is not
+	// traced.
+	ID uint64
+
+	// Generated is when the frame was rendered.
+	Generated time.Time
+
+	// Source names the generator, such as a layer or cue.
+	Source string
+}
+
+var frameIDs atomic.Uint64
+
+// NewFrameMeta returns the metadata of a frame of source rendered now,
+// with the next frame ID.
+func NewFrameMeta(source string) FrameMeta {
+	return FrameMeta{ID: frameIDs.Add(1), Generated: time.Now(), Source: source}
+}
+
+// String formats the frame ID and source, for logs.
+func (m FrameMeta) String() string {
+	if m.ID == 0 {
+		return "untraced frame"
+	}
+	if m.Source == "" {
+		return fmt.Sprintf("frame %d", m.ID)
+	}
+	return fmt.Sprintf("frame %d from %s", m.ID, m.Source)
+}
+
+// WriteInfo describes a frame written to a device.
+type WriteInfo struct {
+	Meta FrameMeta
+
+	// PPS and Points are the rate and size the frame was written with,
+	// after adapting and the other stages of the write pipeline.
+	PPS    int
+	Points int
+
+	// Result is the result code of the write.
+	Result int
+
+	// Written is when the write returned.
+	Written time.Time
+}
+
+// Latency returns the time from rendering the frame to writing it, or zero
+// for untraced frames.
+func (w WriteInfo) Latency() time.Duration {
+	if w.Meta.Generated.IsZero() {
+		return 0
+	}
+	return w.Written.Sub(w.Meta.Generated)
+}
+
+// SetWriteHook sets a function called after every frame written to a
+// device that reached the device or was rejected, with the frame's
+// metadata: zero for frames written without any. It is called on the
+// writing goroutine once the device has been released, so it may call
+// into the DAC, but a slow hook delays the writer. Nil removes the hook.
+func (d *DAC) SetWriteHook(fn func(deviceIndex int, info WriteInfo)) {
+	if fn == nil {
+		d.writeHook.Store(nil)
+		return
+	}
+	d.writeHook.Store(&fn)
+}
+
+// WriteFrameMeta writes a standard frame like WriteFrame, passing meta to
+// the write hook.
+func (d *DAC) WriteFrameMeta(deviceIndex int, pps int, flags int, points []Point, meta FrameMeta) int {
+	if !d.gate.enter() {
+		return int(ErrDeviceClosed)
+	}
+	defer d.gate.leave()
+	d.watchdog.touch(deviceIndex)
+	return d.writeFrame(deviceIndex, pps, flags, points, meta)
+}
+
+// WriteFrameChecked writes a standard frame like WriteFrameMeta, returning
+// an error instead of a result code: a *FrameError describing the problem
+// if validation rejected the frame, as checked after adapting, limiting
+// slew and splitting, or the Error of a failed write.
+func (d *DAC) WriteFrameChecked(deviceIndex int, pps int, flags int, points []Point, meta FrameMeta) error {
+	if !d.gate.enter() {
+		return ErrDeviceClosed
+	}
+	defer d.gate.leave()
+	d.watchdog.touch(deviceIndex)
+	result, ferr := d.writeFrameChecked(deviceIndex, pps, flags, points, meta)
+	if ferr != nil {
+		return ferr
+	}
+	return ErrorFromCode(result)
+}
+
+// recordWrite counts a write in the stats and passes it to the write hook.
+// It must be called without the device lock.
+func (d *DAC) recordWrite(deviceIndex, n, pps, result int, meta FrameMeta) {
+	d.stats.record(deviceIndex, n, pps, result)
+	if fn := d.writeHook.Load(); fn != nil {
+		(*fn)(deviceIndex, WriteInfo{Meta: meta, PPS: pps, Points: n, Result: result, Written: time.Now()})
+	}
+}
@@ -0,0 +1,263 @@
+// Package trace builds laser frames from vector drawing commands.
+//
+// A Builder works like a pen on the projection plane (normalized
+// coordinates, -1 to 1, as used by helios.PointF). Lines, Bézier curves and
+// arcs are traced at a constant scan speed; curves are flattened adaptively,
+// finer where the beam moves slower relative to the point rate. Jumps
+// between strokes are blanked moves following a motion.GalvoProfile, and
+// sharp corners get a short dwell so the scanners do not round them off.
+package trace
+
+import (
+	"math"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/motion"
+)
+
+// DefaultSpeed is the lit scan speed of a new Builder, in normalized units
+// per second: about 3 full widths per second, which typical 30kpps
+// scanners trace without visibly rounding off detail.
+const DefaultSpeed = 6
+
+// DefaultCornerDwell is the dwell at sharp corners of a new Builder.
+const DefaultCornerDwell = 100 * time.Microsecond
+
+// cornerAngle is the change of direction, in radians, above which a corner
+// gets a dwell.
+const cornerAngle = math.Pi / 6
+
+// maxDepth limits curve subdivision.
+const maxDepth = 16
+
+type vec [2]float64
+
+func (a vec) sub(b vec) vec { return vec{a[0] - b[0], a[1] - b[1]} }
+
+func (a vec) len() float64 { return math.Hypot(a[0], a[1]) }
+
+func mid(a, b vec) vec { return vec{(a[0] + b[0]) / 2, (a[1] + b[1]) / 2} }
+
+// Builder accumulates a frame. The zero Builder is not usable; create one
+// with NewBuilder.
+type Builder struct {
+	// Profile shapes the blanked jumps between strokes.
+	Profile motion.GalvoProfile
+
+	// PPS is the rate the frame will be played at.
+	PPS int
+
+	// Speed is the lit scan speed in normalized units per second. Points
+	// along strokes are Speed/PPS apart. Zero means DefaultSpeed.
+	Speed float64
+
+	// CornerDwell is held at corners sharper than 30 degrees. Zero
+	// disables corner dwells.
+	CornerDwell time.Duration
+
+	frame  []helios.Point
+	color  helios.PointF
+	pen    vec  // current position
+	start  vec  // start of the current stroke, for Close
+	moved  bool // pen moved without drawing since the last stroke
+	dir    vec  // direction of the last lit segment, zero if none
+	tail   vec  // exact end of the last lit segment
+	stroke bool // a stroke is in progress
+}
+
+// NewBuilder returns a builder for frames played at pps, drawing in white
+// with motion.DefaultProfile, DefaultSpeed and DefaultCornerDwell.
+func NewBuilder(pps int) *Builder {
+	return &Builder{
+		Profile:     motion.DefaultProfile,
+		PPS:         pps,
+		Speed:       DefaultSpeed,
+		CornerDwell: DefaultCornerDwell,
+		color:       helios.PointF{R: 1, G: 1, B: 1, I: 1},
+	}
+}
+
+// SetColor sets the color of the following strokes (0 to 1).
+func (b *Builder) SetColor(r, g, bl float64) {
+	b.color.R, b.color.G, b.color.B = r, g, bl
+}
+
+// MoveTo lifts the pen and moves it to (x, y). The blanked jump is added
+// when the next stroke starts.
+func (b *Builder) MoveTo(x, y float64) {
+	b.pen = vec{x, y}
+	b.start = b.pen
+	b.moved = true
+	b.stroke = false
+	b.dir = vec{}
+}
+
+// LineTo draws a straight line to (x, y).
+func (b *Builder) LineTo(x, y float64) {
+	b.polyline([]vec{b.pen, {x, y}})
+}
+
+// QuadraticTo draws a quadratic Bézier curve with control point (cx, cy)
+// to (x, y).
+func (b *Builder) QuadraticTo(cx, cy, x, y float64) {
+	p0, c := b.pen, vec{cx, cy}
+	// Raise to a cubic, which has the same shape.
+	c1 := vec{p0[0] + 2.0/3*(c[0]-p0[0]), p0[1] + 2.0/3*(c[1]-p0[1])}
+	c2 := vec{x + 2.0/3*(c[0]-x), y + 2.0/3*(c[1]-y)}
+	b.CubicTo(c1[0], c1[1], c2[0], c2[1], x, y)
+}
+
+// CubicTo draws a cubic Bézier curve with control points (c1x, c1y) and
+// (c2x, c2y) to (x, y).
+func (b *Builder) CubicTo(c1x, c1y, c2x, c2y, x, y float64) {
+	pts := []vec{b.pen}
+	flattenCubic(b.pen, vec{c1x, c1y}, vec{c2x, c2y}, vec{x, y}, b.tolerance(), 0, &pts)
+	b.polyline(pts)
+}
+
+// ArcTo draws a circular arc around (cx, cy) from the current position,
+// turning sweep radians; positive sweeps are counterclockwise.
+func (b *Builder) ArcTo(cx, cy, sweep float64) {
+	c := vec{cx, cy}
+	r := b.pen.sub(c).len()
+	a0 := math.Atan2(b.pen[1]-cy, b.pen[0]-cx)
+	// A chord of angle θ deviates r(1 - cos(θ/2)) from the arc.
+	n := 1
+	if tol := b.tolerance(); r > tol {
+		step := 2 * math.Acos(1-tol/r)
+		n = max(1, int(math.Ceil(math.Abs(sweep)/step)))
+	}
+	pts := make([]vec, 0, n+1)
+	pts = append(pts, b.pen)
+	for i := 1; i <= n; i++ {
+		a := a0 + sweep*float64(i)/float64(n)
+		pts = append(pts, vec{cx + r*math.Cos(a), cy + r*math.Sin(a)})
+	}
+	b.polyline(pts)
+}
+
+// Close draws a line back to the start of the current stroke.
+func (b *Builder) Close() {
+	if b.stroke && b.pen != b.start {
+		b.LineTo(b.start[0], b.start[1])
+	}
+}
+
+// Frame returns the frame built so far.
+func (b *Builder) Frame() []helios.Point {
+	return append([]helios.Point(nil), b.frame...)
+}
+
+// Reset clears the frame, keeping the settings and color.
+func (b *Builder) Reset() {
+	b.frame = b.frame[:0]
+	b.pen, b.start, b.dir, b.tail = vec{}, vec{}, vec{}, vec{}
+	b.moved, b.stroke = false, false
+}
+
+// spacing is the distance between consecutive points along strokes.
+func (b *Builder) spacing() float64 {
+	speed := b.Speed
+	if speed <= 0 {
+		speed = DefaultSpeed
+	}
+	return speed / float64(max(b.PPS, 1))
+}
+
+// tolerance is the largest distance flattened curves may stray from the
+// true curve: half the point spacing, so flattening is never visible
+// through the sampling.
+func (b *Builder) tolerance() float64 {
+	return max(b.spacing()/2, 1e-4)
+}
+
+// flattenCubic appends points approximating the curve from p0, not
+// including p0, subdividing until the control points lie within tol of the
+// chord.
+func flattenCubic(p0, c1, c2, p3 vec, tol float64, depth int, out *[]vec) {
+	if depth >= maxDepth || flat(p0, c1, c2, p3, tol) {
+		*out = append(*out, p3)
+		return
+	}
+	// de Casteljau split at t = 0.5.
+	a, bb, c := mid(p0, c1), mid(c1, c2), mid(c2, p3)
+	d, e := mid(a, bb), mid(bb, c)
+	m := mid(d, e)
+	flattenCubic(p0, a, d, m, tol, depth+1, out)
+	flattenCubic(m, e, c, p3, tol, depth+1, out)
+}
+
+// flat reports whether both control points are within tol of the chord.
+func flat(p0, c1, c2, p3 vec, tol float64) bool {
+	chord := p3.sub(p0)
+	l := chord.len()
+	dist := func(p vec) float64 {
+		d := p.sub(p0)
+		if l == 0 {
+			return d.len()
+		}
+		return math.Abs(d[0]*chord[1]-d[1]*chord[0]) / l
+	}
+	return dist(c1) <= tol && dist(c2) <= tol
+}
+
+// polyline traces pts, which starts at the pen, and leaves the pen at its
+// end.
+func (b *Builder) polyline(pts []vec) {
+	b.begin()
+	spacing := b.spacing()
+	for i := 1; i < len(pts); i++ {
+		from, to := b.tail, pts[i]
+		seg := to.sub(from)
+		l := seg.len()
+		if l == 0 {
+			continue
+		}
+		dir := vec{seg[0] / l, seg[1] / l}
+		if b.CornerDwell > 0 && b.dir != (vec{}) && math.Acos(min(1, max(-1, dir[0]*b.dir[0]+dir[1]*b.dir[1]))) > cornerAngle {
+			b.dwell(b.tail)
+		}
+		n := max(1, int(math.Ceil(l/spacing)))
+		for k := 1; k <= n; k++ {
+			t := float64(k) / float64(n)
+			b.emit(vec{from[0] + seg[0]*t, from[1] + seg[1]*t}, true)
+		}
+		b.dir, b.tail = dir, to
+	}
+	b.pen = pts[len(pts)-1]
+}
+
+// begin starts a stroke at the pen if none is in progress, jumping there
+// blanked from the end of the frame.
+func (b *Builder) begin() {
+	if b.stroke {
+		return
+	}
+	if len(b.frame) > 0 && b.moved {
+		to := b.point(b.pen, false)
+		b.frame = append(b.frame, b.Profile.Travel(b.frame[len(b.frame)-1], to, b.PPS)...)
+	} else if len(b.frame) == 0 {
+		b.emit(b.pen, false)
+	}
+	b.emit(b.pen, true)
+	b.stroke, b.moved = true, false
+	b.start, b.tail, b.dir = b.pen, b.pen, vec{}
+}
+
+// dwell holds the beam lit at p for CornerDwell.
+func (b *Builder) dwell(p vec) {
+	b.frame = append(b.frame, motion.Dwell(b.point(p, true), b.CornerDwell, b.PPS, true)...)
+}
+
+func (b *Builder) emit(p vec, lit bool) {
+	b.frame = append(b.frame, b.point(p, lit))
+}
+
+func (b *Builder) point(p vec, lit bool) helios.Point {
+	f := helios.PointF{X: p[0], Y: p[1]}
+	if lit {
+		f.R, f.G, f.B, f.I = b.color.R, b.color.G, b.color.B, b.color.I
+	}
+	return f.Point()
+}
@@ -0,0 +1,133 @@
+package trace
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+// lit returns the lit points of a frame.
+func lit(frame []helios.Point) []helios.Point {
+	var out []helios.Point
+	for _, p := range frame {
+		if p.I != 0 {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func TestLineSpacing(t *testing.T) {
+	b := NewBuilder(30000)
+	b.CornerDwell = 0
+	b.MoveTo(-1, 0)
+	b.LineTo(1, 0)
+	pts := lit(b.Frame())
+	// 2 units at 6 units/s take 1/3 s: 10000 steps after the start point.
+	if len(pts) != 10001 {
+		t.Fatalf("got %d lit points, want 10001", len(pts))
+	}
+	if pts[0].X != 0 || pts[len(pts)-1].X != 4095 {
+		t.Fatalf("line runs %d to %d", pts[0].X, pts[len(pts)-1].X)
+	}
+}
+
+func TestCornerDwellAndJump(t *testing.T) {
+	b := NewBuilder(30000)
+	b.Speed = 60
+	b.MoveTo(0, 0)
+	b.LineTo(0.5, 0)
+	b.LineTo(0.5, 0.5) // 90 degree corner
+	n := len(b.Frame())
+	b.LineTo(0.5, 0.6) // straight on, no dwell
+	// 0.1 units at 0.002 units per point.
+	if straight := len(b.Frame()) - n; straight != 50 {
+		t.Fatalf("straight segment added %d points, want 50", straight)
+	}
+
+	corner := helios.PointF{X: 0.5, Y: 0, R: 1, G: 1, B: 1, I: 1}.Point()
+	count := 0
+	for _, p := range b.Frame() {
+		if p == corner {
+			count++
+		}
+	}
+	// The segment end plus 3 dwell points at 30kpps for 100µs.
+	if count != 4 {
+		t.Fatalf("corner held for %d points, want 4", count)
+	}
+
+	before := len(b.Frame())
+	b.MoveTo(-0.5, -0.5)
+	b.MoveTo(-1, -1) // only the last move counts
+	b.LineTo(-0.9, -1)
+	jump := b.Frame()[before:]
+	if jump[0].I != 0 || jump[len(jump)-1].I == 0 {
+		t.Fatalf("jump starts %+v and ends %+v", jump[0], jump[len(jump)-1])
+	}
+	for _, p := range jump {
+		if p.I == 0 && p.X > 3072 { // from (0.5, 0.6) to (-1, -1)
+			t.Fatalf("blanked point %+v is off the jump", p)
+		}
+	}
+}
+
+func TestCurvesFlattenAdaptively(t *testing.T) {
+	curvePoints := func(speed float64) []helios.Point {
+		b := NewBuilder(30000)
+		b.Speed = speed
+		b.CornerDwell = 0
+		b.MoveTo(-1, 0)
+		b.CubicTo(-1, 1, 1, 1, 1, 0)
+		return lit(b.Frame())
+	}
+	slow, fast := curvePoints(6), curvePoints(60)
+	if len(slow) < 5*len(fast) {
+		t.Fatalf("slow scan has %d points, fast %d", len(slow), len(fast))
+	}
+	// The midpoint of this curve is (0, 0.75).
+	mid := helios.PointF{X: 0, Y: 0.75}.Point()
+	best := math.Inf(1)
+	for _, p := range fast {
+		best = min(best, math.Hypot(float64(p.X)-float64(mid.X), float64(p.Y)-float64(mid.Y)))
+	}
+	if best > 8 {
+		t.Fatalf("curve passes %v units from its midpoint", best)
+	}
+}
+
+func TestQuadraticAndArc(t *testing.T) {
+	b := NewBuilder(30000)
+	b.Speed = 60
+	b.CornerDwell = 0
+	b.MoveTo(-1, 0)
+	b.QuadraticTo(0, 1, 1, 0)
+	pts := lit(b.Frame())
+	// A quadratic peaks at half its control point's height.
+	top := helios.PointF{Y: 0.5}.Point()
+	var peak uint16
+	for _, p := range pts {
+		peak = max(peak, p.Y)
+	}
+	if d := int(peak) - int(top.Y); d < -2 || d > 2 {
+		t.Fatalf("quadratic peaks at %d, want %d", peak, top.Y)
+	}
+
+	b.Reset()
+	b.MoveTo(0.5, 0)
+	b.ArcTo(0, 0, 2*math.Pi)
+	b.Close()
+	pts = lit(b.Frame())
+	// Chords stray up to half the spacing (about 2 device units) inside
+	// the arc, plus rounding.
+	r := 0.5 * 2047.5
+	for _, p := range pts {
+		if d := math.Hypot(float64(p.X)-2047.5, float64(p.Y)-2047.5); math.Abs(d-r) > 3 {
+			t.Fatalf("arc point %+v is %v from the center, want %v", p, d, r)
+		}
+	}
+	if pts[0] != pts[len(pts)-1] {
+		t.Fatalf("full circle does not close: %+v, %+v", pts[0], pts[len(pts)-1])
+	}
+}
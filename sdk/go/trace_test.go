@@ -0,0 +1,107 @@
+package helios
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestNewFrameMeta(t *testing.T) {
+	a, b := NewFrameMeta("layer"), NewFrameMeta("")
+	if a.ID == 0 || b.ID <= a.ID {
+		t.Fatalf("IDs = %d, %d, want increasing from 1", a.ID, b.ID)
+	}
+	if a.Generated.IsZero() {
+		t.Fatal("generation time not set")
+	}
+	if s := a.String(); s != fmt.Sprintf("frame %d from layer", a.ID) {
+		t.Errorf("String = %q", s)
+	}
+	if s := b.String(); s != fmt.Sprintf("frame %d", b.ID) {
+		t.Errorf("String = %q", s)
+	}
+	if s := (FrameMeta{}).String(); s != "untraced frame" {
+		t.Errorf("String = %q", s)
+	}
+}
+
+func TestWriteHook(t *testing.T) {
+	d := &DAC{levels: newLevels()}
+	var got []WriteInfo
+	d.SetWriteHook(func(i int, info WriteInfo) {
+		if i != 1 {
+			t.Errorf("device = %d, want 1", i)
+		}
+		got = append(got, info)
+	})
+	meta := FrameMeta{ID: 7, Source: "cue", Generated: time.Now().Add(-time.Second)}
+	d.recordWrite(1, 100, 30000, 1, meta)
+	if len(got) != 1 {
+		t.Fatalf("hook called %d times, want 1", len(got))
+	}
+	if info := got[0]; info.Meta != meta || info.Points != 100 || info.PPS != 30000 || info.Result != 1 {
+		t.Fatalf("info = %+v", info)
+	}
+	if l := got[0].Latency(); l < time.Second {
+		t.Errorf("latency = %v, want at least 1s", l)
+	}
+	if l := (WriteInfo{Written: time.Now()}).Latency(); l != 0 {
+		t.Errorf("untraced latency = %v, want 0", l)
+	}
+
+	d.SetWriteHook(nil)
+	d.recordWrite(1, 100, 30000, 1, meta)
+	if len(got) != 1 {
+		t.Fatal("hook called after removal")
+	}
+}
+
+func TestWriteHookMayCallDAC(t *testing.T) {
+	d := NewDAC()
+	defer d.Close()
+	d.SetValidation(ValidateStrict)
+	bad := []Point{{X: 5000}}
+	calls := 0
+	d.SetWriteHook(func(i int, info WriteInfo) {
+		// Writing to the same device from the hook used to deadlock on
+		// the device lock.
+		if calls++; calls == 1 {
+			d.WriteFrame(i, 30000, FlagsDefault, bad)
+		}
+	})
+	done := make(chan struct{})
+	go func() {
+		d.WriteFrame(0, 30000, FlagsDefault, bad)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("write hook deadlocked")
+	}
+	if calls != 2 {
+		t.Fatalf("hook called %d times, want 2", calls)
+	}
+}
+
+func TestWriteFrameChecked(t *testing.T) {
+	d := NewDAC()
+	defer d.Close()
+	d.SetValidation(ValidateStrict)
+	d.SetSplitFrames(false)
+
+	var fe *FrameError
+	err := d.WriteFrameChecked(0, 30000, FlagsDefault, []Point{{}, {X: 5000}}, FrameMeta{})
+	if !errors.As(err, &fe) || fe.Code != ErrCoordinateRange || len(fe.Indices) != 1 || fe.Indices[0] != 1 {
+		t.Fatalf("err = %v", err)
+	}
+
+	// The frame is checked as the DAC sends it, after the slew limit has
+	// filled in the jumps.
+	d.SetSlewLimit(0, 0.9)
+	err = d.WriteFrameChecked(0, 30000, FlagsDefault, []Point{{}, {X: 4095}}, FrameMeta{})
+	if !errors.As(err, &fe) || fe.Code != ErrTooManyPoints || fe.Points != 9100 {
+		t.Fatalf("err = %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+package transition
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/ease"
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/motion"
+	"github.com/Grix/helios_dac/sdk/go/scene"
+)
+
+// Switcher is a scene.Layer drawing one layer at a time, with a transition
+// whenever it switches to another. It is safe for concurrent use; set its
+// fields before it is drawn.
+type Switcher struct {
+	// Transition blends the layers. Nil means Crossfade.
+	Transition Transition
+
+	// Duration is the length of a transition. Zero cuts.
+	Duration time.Duration
+
+	// Easing maps the time through a transition to its progress. Nil is
+	// linear.
+	Easing ease.Func
+
+	mu      sync.Mutex
+	current scene.Layer
+	prev    scene.Layer
+	start   time.Duration
+	started bool
+}
+
+// NewSwitcher returns a switcher drawing l, with transitions of the given
+// kind and duration.
+func NewSwitcher(l scene.Layer, tr Transition, d time.Duration) *Switcher {
+	return &Switcher{Transition: tr, Duration: d, current: l}
+}
+
+// Switch transitions to l, starting from the next time the switcher is
+// drawn. A transition in progress is cut short: the new one starts from
+// the layer it was transitioning to.
+func (s *Switcher) Switch(l scene.Layer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prev, s.current, s.started = s.current, l, false
+}
+
+// Cut switches to l without a transition.
+func (s *Switcher) Cut(l scene.Layer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prev, s.current = nil, l
+}
+
+// Current returns the layer drawn, or transitioned to.
+func (s *Switcher) Current() scene.Layer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.current
+}
+
+// Transitioning reports whether a transition is in progress.
+func (s *Switcher) Transitioning() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.prev != nil
+}
+
+// Points draws the current layer at time t, blended with the previous one
+// during a transition. Both layers are asked for budget points, and the
+// blend is reduced to budget with motion.Decimate.
+func (s *Switcher) Points(t time.Duration, budget int) []helios.Point {
+	s.mu.Lock()
+	cur, prev := s.current, s.prev
+	if prev != nil && !s.started {
+		s.start, s.started = t, true
+	}
+	progress := 1.0
+	if prev != nil && s.Duration > 0 {
+		progress = float64(t-s.start) / float64(s.Duration)
+	}
+	if progress >= 1 {
+		s.prev, prev = nil, nil
+	}
+	tr := s.Transition
+	if tr == nil {
+		tr = Crossfade{}
+	}
+	s.mu.Unlock()
+
+	var from, to []helios.Point
+	if cur != nil {
+		to = cur.Points(t, budget)
+	}
+	if prev == nil {
+		return to
+	}
+	from = prev.Points(t, budget)
+	return motion.Decimate(tr.Blend(from, to, s.Easing.Apply(progress)), budget)
+}
@@ -0,0 +1,48 @@
+package transition
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/scene"
+)
+
+func pointLayer(p helios.Point) scene.Layer {
+	return scene.LayerFunc(func(t time.Duration, budget int) []helios.Point {
+		return []helios.Point{p}
+	})
+}
+
+func TestSwitcher(t *testing.T) {
+	a, b := pointLayer(left), pointLayer(right)
+	s := NewSwitcher(a, nil, time.Second)
+	if out := s.Points(0, 100); len(out) != 1 || out[0] != left {
+		t.Fatalf("before switching = %+v", out)
+	}
+
+	// The transition starts when the switcher is next drawn.
+	s.Switch(b)
+	if out := s.Points(10*time.Second, 100); len(out) != 2+2*DefaultBlankPoints || out[0].R != 255 || out[len(out)-1].G != 0 {
+		t.Fatalf("transition start = %+v", out)
+	}
+	if out := s.Points(10*time.Second+250*time.Millisecond, 100); out[0].R != 191 || out[len(out)-1].G != 64 {
+		t.Fatalf("quarter way = %+v ... %+v", out[0], out[len(out)-1])
+	}
+	// Blends over the budget are decimated to fit.
+	if out := s.Points(10*time.Second+500*time.Millisecond, 4); len(out) > 4 {
+		t.Fatalf("blend has %d points, over the budget", len(out))
+	}
+	if !s.Transitioning() {
+		t.Fatal("transition ended early")
+	}
+	if out := s.Points(11*time.Second, 100); len(out) != 1 || out[0] != right || s.Transitioning() {
+		t.Fatalf("after the transition = %+v", out)
+	}
+
+	s.Switch(a)
+	s.Cut(b)
+	if out := s.Points(12*time.Second, 100); len(out) != 1 || out[0] != right || s.Transitioning() || s.Current() == nil {
+		t.Fatalf("after Cut = %+v", out)
+	}
+}
@@ -0,0 +1,195 @@
+// Package transition blends between two frame sources, so switching
+// content does not produce a hard visual cut.
+//
+// A Transition draws the frame shown at some progress between an outgoing
+// and an incoming frame: Crossfade dims one while brightening the other,
+// Wipe reveals the incoming frame behind a moving edge and Morph moves
+// every point of the outgoing frame onto the incoming one.
+//
+// A Switcher is a scene.Layer that plays one layer and transitions to the
+// next when Switch is called:
+//
+//	sw := transition.NewSwitcher(logo, transition.Morph{}, time.Second)
+//	...
+//	sw.Switch(tunnel)
+//
+// playlist.Playlist uses the same transitions between its items.
+package transition
+
+import (
+	"math"
+	"slices"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/motion"
+)
+
+// DefaultBlankPoints is the number of blanked points inserted at each end
+// of the jump between the two frames drawn by Crossfade and Wipe when their
+// BlankPoints is zero.
+const DefaultBlankPoints = 8
+
+// center is the middle of the device coordinate range.
+const center = 2048
+
+// Transition blends two frames. Implementations return a new slice and
+// leave from and to unchanged.
+type Transition interface {
+	// Blend returns the frame shown at progress (0 - 1) from from to to.
+	Blend(from, to []helios.Point, progress float64) []helios.Point
+}
+
+// Func adapts an ordinary function to the Transition interface.
+type Func func(from, to []helios.Point, progress float64) []helios.Point
+
+// Blend calls f(from, to, progress).
+func (f Func) Blend(from, to []helios.Point, progress float64) []helios.Point {
+	return f(from, to, progress)
+}
+
+// Crossfade draws both frames, the outgoing one dimming and the incoming
+// one brightening, with a blanked jump between them.
+type Crossfade struct {
+	// BlankPoints is the number of blanked points at each end of the
+	// jump. Zero means DefaultBlankPoints.
+	BlankPoints int
+}
+
+// Blend draws from at 1 - progress brightness and to at progress.
+func (c Crossfade) Blend(from, to []helios.Point, progress float64) []helios.Point {
+	progress = clamp01(progress)
+	return join(dim(from, 1-progress), dim(to, progress), c.BlankPoints)
+}
+
+// Wipe reveals the incoming frame as a straight edge sweeps across the
+// scan field, blanking the outgoing frame behind it.
+type Wipe struct {
+	// Angle is the direction the edge moves in, in radians. Zero sweeps
+	// from left to right, π/2 from bottom to top.
+	Angle float64
+
+	// BlankPoints is the number of blanked points at each end of the
+	// jump between the two frames. Zero means DefaultBlankPoints.
+	BlankPoints int
+}
+
+// Blend draws the points of to the edge has passed and the points of from
+// it has not.
+func (w Wipe) Blend(from, to []helios.Point, progress float64) []helios.Point {
+	progress = clamp01(progress)
+	switch progress {
+	case 0:
+		return slices.Clone(from)
+	case 1:
+		return slices.Clone(to)
+	}
+	cos, sin := math.Cos(w.Angle), math.Sin(w.Angle)
+	// The edge moves across the projection of the whole field onto the
+	// direction of travel.
+	reach := center * (math.Abs(cos) + math.Abs(sin))
+	edge := -reach + 2*reach*progress
+	passed := func(p helios.Point) bool {
+		return (float64(p.X)-center)*cos+(float64(p.Y)-center)*sin < edge
+	}
+	keep := func(points []helios.Point, wiped bool) []helios.Point {
+		out := make([]helios.Point, len(points))
+		for i, p := range points {
+			if passed(p) != wiped {
+				p = helios.Point{X: p.X, Y: p.Y}
+			}
+			out[i] = p
+		}
+		return out
+	}
+	return join(keep(from, false), keep(to, true), w.BlankPoints)
+}
+
+// Morph moves the points of the outgoing frame onto those of the incoming
+// one, blending their colors on the way. Both frames are resampled to the
+// same number of points along their paths, so points are paired by their
+// position along the drawing.
+type Morph struct {
+	// Points is the number of points drawn during the morph. Zero means
+	// the larger of the two frames' sizes.
+	Points int
+}
+
+// Blend interpolates every point progress of the way from from to to.
+func (m Morph) Blend(from, to []helios.Point, progress float64) []helios.Point {
+	progress = clamp01(progress)
+	if len(from) == 0 || len(to) == 0 {
+		return Crossfade{}.Blend(from, to, progress)
+	}
+	n := m.Points
+	if n <= 0 {
+		n = max(len(from), len(to))
+	}
+	a, b := resample(from, n), resample(to, n)
+	out := make([]helios.Point, n)
+	for i := range out {
+		p, q := a[i], b[i]
+		out[i] = helios.Point{
+			X: helios.ClampToCoord(mix(p.X, q.X, progress)),
+			Y: helios.ClampToCoord(mix(p.Y, q.Y, progress)),
+			R: uint8(math.Round(mix(p.R, q.R, progress))),
+			G: uint8(math.Round(mix(p.G, q.G, progress))),
+			B: uint8(math.Round(mix(p.B, q.B, progress))),
+			I: uint8(math.Round(mix(p.I, q.I, progress))),
+		}
+	}
+	return out
+}
+
+// resample returns exactly n points along frame, repeating points of
+// frames motion.Resample leaves unchanged.
+func resample(frame []helios.Point, n int) []helios.Point {
+	if out := motion.Resample(frame, n); len(out) == n {
+		return out
+	}
+	out := make([]helios.Point, n)
+	for k := range out {
+		out[k] = frame[k*len(frame)/n]
+	}
+	return out
+}
+
+func mix[T uint8 | uint16](a, b T, t float64) float64 {
+	return float64(a) + (float64(b)-float64(a))*t
+}
+
+func clamp01(t float64) float64 {
+	if !(t > 0) {
+		return 0
+	}
+	return min(t, 1)
+}
+
+// dim returns a copy of points with their colors scaled by level.
+func dim(points []helios.Point, level float64) []helios.Point {
+	out := make([]helios.Point, len(points))
+	for i, p := range points {
+		p.R, p.G, p.B = helios.ScaleColor(p.R, level), helios.ScaleColor(p.G, level), helios.ScaleColor(p.B, level)
+		out[i] = p
+	}
+	return out
+}
+
+// join returns a followed by b with a blanked jump of blank points at each
+// end between them. Zero blank means DefaultBlankPoints.
+func join(a, b []helios.Point, blank int) []helios.Point {
+	if blank <= 0 {
+		blank = DefaultBlankPoints
+	}
+	if len(a) == 0 || len(b) == 0 {
+		return append(a, b...)
+	}
+	out := make([]helios.Point, 0, len(a)+2*blank+len(b))
+	out = append(out, a...)
+	for range blank {
+		out = append(out, helios.Point{X: a[len(a)-1].X, Y: a[len(a)-1].Y})
+	}
+	for range blank {
+		out = append(out, helios.Point{X: b[0].X, Y: b[0].Y})
+	}
+	return append(out, b...)
+}
@@ -0,0 +1,79 @@
+package transition
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+var (
+	left  = helios.Point{X: 100, Y: 2048, R: 255, I: 255}
+	right = helios.Point{X: 4000, Y: 2048, G: 255, I: 255}
+)
+
+func TestCrossfade(t *testing.T) {
+	from, to := []helios.Point{left}, []helios.Point{right}
+	out := Crossfade{BlankPoints: 2}.Blend(from, to, 0.25)
+	if len(out) != 6 {
+		t.Fatalf("blend has %d points", len(out))
+	}
+	if out[0].R != 191 || out[5].G != 64 || out[1] != (helios.Point{X: 100, Y: 2048}) || out[4] != (helios.Point{X: 4000, Y: 2048}) {
+		t.Fatalf("blend = %+v", out)
+	}
+	if from[0].R != 255 {
+		t.Fatal("crossfade modified its input")
+	}
+	if out := (Crossfade{}).Blend(nil, to, 1); len(out) != 1 || out[0] != right {
+		t.Fatalf("blend from nothing = %+v", out)
+	}
+}
+
+func TestWipe(t *testing.T) {
+	from, to := []helios.Point{left, right}, []helios.Point{left, right}
+	lit := func(out []helios.Point) []uint16 {
+		var xs []uint16
+		for _, p := range out {
+			if p.I > 0 {
+				xs = append(xs, p.X)
+			}
+		}
+		return xs
+	}
+	// Halfway from left to right, the left half shows to and the right
+	// half from.
+	out := Wipe{}.Blend(from, to, 0.5)
+	if got := lit(out); len(got) != 2 || got[0] != 4000 || got[1] != 100 || out[len(out)-1].I != 0 {
+		t.Fatalf("lit = %v in %+v", got, out)
+	}
+	// Sweeping right to left reveals the right half first.
+	out = Wipe{Angle: math.Pi}.Blend(from, to, 0.5)
+	if got := lit(out); len(got) != 2 || got[0] != 100 || got[1] != 4000 {
+		t.Fatalf("lit = %v", got)
+	}
+	if out := (Wipe{}).Blend(from, to, 0); len(lit(out)) != 2 || len(out) != 2 {
+		t.Fatalf("wipe at 0 = %+v", out)
+	}
+}
+
+func TestMorph(t *testing.T) {
+	from := []helios.Point{{X: 0, Y: 0, R: 255, I: 255}, {X: 1000, Y: 0, R: 255, I: 255}}
+	to := []helios.Point{{X: 0, Y: 2000, B: 255, I: 255}, {X: 500, Y: 2000, B: 255, I: 255}, {X: 1000, Y: 2000, B: 255, I: 255}}
+	out := Morph{}.Blend(from, to, 0.5)
+	want := []helios.Point{
+		{X: 0, Y: 1000, R: 128, B: 128, I: 255},
+		{X: 500, Y: 1000, R: 128, B: 128, I: 255},
+		{X: 1000, Y: 1000, R: 128, B: 128, I: 255},
+	}
+	if len(out) != len(want) {
+		t.Fatalf("morph has %d points", len(out))
+	}
+	for i := range want {
+		if out[i] != want[i] {
+			t.Errorf("point %d = %+v, want %+v", i, out[i], want[i])
+		}
+	}
+	if out := (Morph{Points: 4}).Blend([]helios.Point{left}, to, 1); len(out) != 4 || out[3] != to[2] {
+		t.Fatalf("morph from one point = %+v", out)
+	}
+}
@@ -0,0 +1,300 @@
+// Package tuning finds how fast a projector's scanners really are.
+//
+// A Signal is a standard test signal: square steps on both axes in
+// quadrature, the calibrate test pattern in the spirit of the ILDA test
+// pattern, or a circle. A Sweep plays one at increasing point rates while
+// an Observer, usually the operator watching the projection, judges each
+// rate, and reports the highest rate the scanners followed. Result.Profile
+// turns that into a suggested motion.GalvoProfile for the projector.
+package tuning
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/calibrate"
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/motion"
+	"github.com/Grix/helios_dac/sdk/go/output"
+)
+
+// Kind is a kind of test signal.
+type Kind int
+
+const (
+	// Step holds the beam on each corner of a square in turn, so both axes
+	// step in quadrature. Scanners keeping up show four sharp dots; slow
+	// ones smear them into the square's edges or round it off.
+	Step Kind = iota
+
+	// TestPattern is calibrate.TestPattern. At the highest rate it stays
+	// undistorted, the circle still touches the outline and the diagonals
+	// meet the corners.
+	TestPattern
+
+	// Circle is a lit circle. Scanners too slow for it shrink it and turn
+	// it into an ellipse.
+	Circle
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Step:
+		return "step"
+	case TestPattern:
+		return "test_pattern"
+	case Circle:
+		return "circle"
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// Defaults of a Signal's zero fields.
+const (
+	DefaultAmplitude    = 0.5
+	DefaultStepHold     = 8
+	DefaultPatternSize  = 600
+	DefaultCirclePoints = 60
+)
+
+// Signal is a test signal.
+type Signal struct {
+	Kind Kind
+
+	// Amplitude is the size of the signal as a fraction of the scan
+	// field (0.0 - 1.0), centered: the side of the step square, the size
+	// of the test pattern or the diameter of the circle. Zero means
+	// DefaultAmplitude.
+	Amplitude float64
+
+	// Points is the number of points the beam holds on each corner of a
+	// Step, the size of a TestPattern frame or the points of a Circle.
+	// Zero means DefaultStepHold, DefaultPatternSize or
+	// DefaultCirclePoints.
+	Points int
+}
+
+func (s Signal) amplitude() float64 {
+	if s.Amplitude > 0 {
+		return min(s.Amplitude, 1)
+	}
+	return DefaultAmplitude
+}
+
+func (s Signal) points() int {
+	switch {
+	case s.Points > 0:
+		return s.Points
+	case s.Kind == Step:
+		return DefaultStepHold
+	case s.Kind == TestPattern:
+		return DefaultPatternSize
+	}
+	return DefaultCirclePoints
+}
+
+// center is the middle of the scan field.
+const center = 0x800
+
+// Frame returns one period of the signal.
+func (s Signal) Frame() []helios.Point {
+	half := s.amplitude() * center
+	lit := func(x, y float64) helios.Point {
+		return helios.Point{X: helios.ClampToCoord(center + x), Y: helios.ClampToCoord(center + y), R: 255, G: 255, B: 255, I: 255}
+	}
+	n := s.points()
+	var frame []helios.Point
+	switch s.Kind {
+	case Step:
+		for _, c := range [][2]float64{{-1, -1}, {1, -1}, {1, 1}, {-1, 1}} {
+			for range n {
+				frame = append(frame, lit(c[0]*half, c[1]*half))
+			}
+		}
+	case TestPattern:
+		frame = calibrate.TestPattern.Points(0, n)
+		for i, p := range frame {
+			frame[i].X = helios.ClampToCoord(center + (float64(p.X)-center)*s.amplitude())
+			frame[i].Y = helios.ClampToCoord(center + (float64(p.Y)-center)*s.amplitude())
+		}
+	case Circle:
+		for i := range n {
+			sin, cos := math.Sincos(2 * math.Pi * float64(i) / float64(n))
+			frame = append(frame, lit(cos*half, sin*half))
+		}
+	}
+	return frame
+}
+
+// stepTime is the time the scanners get to follow the signal at pps, and
+// the jump they make in it, in coordinate units.
+func (s Signal) stepTime(pps int) (time.Duration, float64) {
+	size := s.amplitude() * 2 * center
+	switch s.Kind {
+	case Step:
+		return time.Duration(s.points()) * time.Second / time.Duration(pps), size
+	case Circle:
+		// A scanner crosses the circle in half a period, as it would
+		// following a square wave at the circle's frequency.
+		return time.Duration(s.points()) * time.Second / time.Duration(2*pps), size
+	}
+	return 0, size
+}
+
+// Observer judges a signal playing at pps, returning whether the scanners
+// follow it. It is called while the signal plays, and may block until the
+// operator decides.
+type Observer func(ctx context.Context, pps int) (bool, error)
+
+// Defaults of a Sweep's zero fields.
+const (
+	DefaultFromPPS   = 10000
+	DefaultToPPS     = 60000
+	DefaultIncrement = 2000
+)
+
+// Sweep plays a signal at increasing point rates until the Observer
+// rejects one.
+type Sweep struct {
+	Signal Signal
+
+	// From and To are the first and last point rates tried, and
+	// Increment the step between them. Zero means DefaultFromPPS,
+	// DefaultToPPS and DefaultIncrement.
+	From, To  int
+	Increment int
+}
+
+// Result is the outcome of a Sweep.
+type Result struct {
+	Signal Signal
+
+	// MaxPPS is the highest point rate the Observer accepted.
+	MaxPPS int
+
+	// Limited is set if the Observer accepted every rate up to the
+	// sweep's To, so the scanners may be faster still.
+	Limited bool
+}
+
+// ErrNoPass is returned by Sweep.Run when the Observer rejects the first
+// point rate.
+var ErrNoPass = errors.New("tuning: the scanners did not follow the signal at the lowest point rate")
+
+// pollInterval is how often a playing signal polls the output.
+const pollInterval = 500 * time.Microsecond
+
+// Run sweeps the signal on out, judged by observe. The output is stopped
+// afterwards.
+func (sw Sweep) Run(ctx context.Context, out output.Output, observe Observer) (Result, error) {
+	defer out.Stop()
+	from, to, inc := orDefault(sw.From, DefaultFromPPS), orDefault(sw.To, DefaultToPPS), orDefault(sw.Increment, DefaultIncrement)
+	frame := sw.Signal.Frame()
+	res := Result{Signal: sw.Signal}
+	for pps := from; pps <= to; pps += inc {
+		ok, err := play(ctx, out, pps, frame, observe)
+		if err != nil {
+			return Result{}, err
+		}
+		if !ok {
+			break
+		}
+		res.MaxPPS = pps
+		res.Limited = pps+inc > to
+	}
+	if res.MaxPPS == 0 {
+		return Result{}, ErrNoPass
+	}
+	return res, nil
+}
+
+// play writes frame to out at pps until observe returns.
+func play(ctx context.Context, out output.Output, pps int, frame []helios.Point, observe Observer) (bool, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- loop(ctx, out, pps, frame)
+	}()
+	ok, err := observe(ctx, pps)
+	cancel()
+	if perr := <-done; perr != nil && !errors.Is(perr, context.Canceled) {
+		return false, perr
+	}
+	return ok, err
+}
+
+func loop(ctx context.Context, out output.Output, pps int, frame []helios.Point) error {
+	for {
+		ready, err := out.Ready()
+		if err != nil {
+			return err
+		}
+		if ready {
+			if err := out.WriteFrame(pps, frame); err != nil {
+				return err
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// ratedPPS is the ILDA test pattern rate motion.DefaultProfile matches.
+const ratedPPS = 30000
+
+// Profile suggests a motion.GalvoProfile for the scanners, scaling the
+// timings of motion.DefaultProfile by how much slower or faster they
+// followed the signal than the scanners it matches. It is a starting point
+// for tuning by eye, not a measurement of the step response.
+func (r Result) Profile() motion.GalvoProfile {
+	return scaled(r.scale())
+}
+
+// SuggestProfile suggests a motion.GalvoProfile from the results of
+// several sweeps, such as steps of different sizes and a circle, averaging
+// their scales.
+func SuggestProfile(results ...Result) motion.GalvoProfile {
+	if len(results) == 0 {
+		return motion.DefaultProfile
+	}
+	var sum float64
+	for _, r := range results {
+		sum += r.scale()
+	}
+	return scaled(sum / float64(len(results)))
+}
+
+// scale returns the ratio of the scanners' timings to those of
+// motion.DefaultProfile.
+func (r Result) scale() float64 {
+	if r.MaxPPS <= 0 {
+		return 1
+	}
+	t, dist := r.Signal.stepTime(r.MaxPPS)
+	if t == 0 {
+		return float64(ratedPPS) / float64(r.MaxPPS)
+	}
+	return float64(t) / float64(motion.DefaultProfile.TravelTime(dist))
+}
+
+func scaled(s float64) motion.GalvoProfile {
+	p := motion.DefaultProfile
+	p.SmallStep = time.Duration(float64(p.SmallStep) * s)
+	p.LargeStep = time.Duration(float64(p.LargeStep) * s)
+	p.Settle = time.Duration(float64(p.Settle) * s)
+	return p
+}
+
+func orDefault(v, def int) int {
+	if v > 0 {
+		return v
+	}
+	return def
+}
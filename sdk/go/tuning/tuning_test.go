@@ -0,0 +1,127 @@
+package tuning
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/motion"
+)
+
+func TestSignalFrames(t *testing.T) {
+	step := Signal{Kind: Step, Amplitude: 0.5, Points: 3}.Frame()
+	if len(step) != 12 {
+		t.Fatalf("step frame has %d points, want 12", len(step))
+	}
+	corners := []helios.Point{step[0], step[3], step[6], step[9]}
+	want := [][2]uint16{{1024, 1024}, {3072, 1024}, {3072, 3072}, {1024, 3072}}
+	for i, c := range corners {
+		if c.X != want[i][0] || c.Y != want[i][1] {
+			t.Errorf("corner %d = (%d, %d), want %v", i, c.X, c.Y, want[i])
+		}
+	}
+
+	circle := Signal{Kind: Circle, Amplitude: 1}.Frame()
+	if len(circle) != DefaultCirclePoints {
+		t.Fatalf("circle has %d points, want %d", len(circle), DefaultCirclePoints)
+	}
+	if circle[0].X != helios.ClampToCoord(2*center) || circle[0].Y != center {
+		t.Errorf("circle starts at (%d, %d)", circle[0].X, circle[0].Y)
+	}
+
+	pattern := Signal{Kind: TestPattern, Amplitude: 0.25}.Frame()
+	for _, p := range pattern {
+		if p.X < 1536 || p.X > 2560 || p.Y < 1536 || p.Y > 2560 {
+			t.Fatalf("test pattern point (%d, %d) outside a quarter of the field", p.X, p.Y)
+		}
+	}
+}
+
+// fakeOutput counts frames by point rate.
+type fakeOutput struct {
+	mu     sync.Mutex
+	frames map[int]int
+	stops  int
+}
+
+func (o *fakeOutput) Ready() (bool, error) { return true, nil }
+func (o *fakeOutput) Close() error         { return nil }
+
+func (o *fakeOutput) WriteFrame(pps int, points []helios.Point) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.frames[pps]++
+	return nil
+}
+
+func (o *fakeOutput) Stop() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.stops++
+	return nil
+}
+
+// observer accepts rates up to max once the signal has played.
+func (o *fakeOutput) observer(max int) Observer {
+	return func(ctx context.Context, pps int) (bool, error) {
+		for {
+			o.mu.Lock()
+			n := o.frames[pps]
+			o.mu.Unlock()
+			if n > 0 {
+				return pps <= max, nil
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+}
+
+func TestSweep(t *testing.T) {
+	out := &fakeOutput{frames: map[int]int{}}
+	sw := Sweep{Signal: Signal{Kind: TestPattern}, From: 20000, To: 40000, Increment: 5000}
+	res, err := sw.Run(context.Background(), out, out.observer(31000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.MaxPPS != 30000 || res.Limited {
+		t.Fatalf("result = %+v, want MaxPPS 30000", res)
+	}
+	if out.frames[40000] != 0 || out.stops != 1 {
+		t.Fatalf("frames = %v, stops = %d", out.frames, out.stops)
+	}
+	if p := res.Profile(); p.SmallStep != motion.DefaultProfile.SmallStep || p.LargeStep != motion.DefaultProfile.LargeStep {
+		t.Errorf("profile of rated scanners = %+v, want the default", p)
+	}
+
+	res, err = sw.Run(context.Background(), out, out.observer(50000))
+	if err != nil || res.MaxPPS != 40000 || !res.Limited {
+		t.Fatalf("result = %+v, %v, want MaxPPS 40000 limited by the sweep", res, err)
+	}
+
+	if _, err := sw.Run(context.Background(), out, out.observer(0)); err != ErrNoPass {
+		t.Fatalf("err = %v, want ErrNoPass", err)
+	}
+}
+
+func TestProfile(t *testing.T) {
+	slow := Result{Signal: Signal{Kind: TestPattern}, MaxPPS: 15000}.Profile()
+	if slow.LargeStep != 2*motion.DefaultProfile.LargeStep || slow.Settle != 2*motion.DefaultProfile.Settle {
+		t.Errorf("half-speed profile = %+v, want twice the default timings", slow)
+	}
+
+	// Holding a full-scale step for 1ms matches the default's large step.
+	step := Result{Signal: Signal{Kind: Step, Amplitude: 1, Points: 30}, MaxPPS: 30000}
+	if p := step.Profile(); p.LargeStep != motion.DefaultProfile.LargeStep {
+		t.Errorf("step profile = %+v, want the default", p)
+	}
+
+	p := SuggestProfile(step, Result{Signal: Signal{Kind: TestPattern}, MaxPPS: 15000})
+	if want := 3 * motion.DefaultProfile.LargeStep / 2; p.LargeStep != want {
+		t.Errorf("suggested large step = %v, want %v", p.LargeStep, want)
+	}
+	if p := SuggestProfile(); p.LargeStep != motion.DefaultProfile.LargeStep {
+		t.Error("no results did not suggest the default")
+	}
+}
@@ -0,0 +1,82 @@
+package helios
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// USBPacketSize is the size of the packets of a Helios USB DAC's frame
+// endpoint. USBOptions.BulkTransferSize is rounded down to a multiple of it.
+const USBPacketSize = 64
+
+// USBOptions tunes the transfers to Helios USB DACs. Longer timeouts and
+// smaller transfers help on flaky hubs and long cables, at the cost of
+// latency when a transfer fails. The zero USBOptions keeps the SDK's
+// defaults.
+type USBOptions struct {
+	// FrameTimeout bounds each bulk transfer of frame data. Zero means
+	// 8ms plus 1ms per 32 bytes transferred.
+	FrameTimeout time.Duration `json:"frame_timeout_ns,omitempty"`
+
+	// ControlTimeout bounds the transfers of status requests and other
+	// commands. Zero means 16 or 32ms, depending on the command.
+	ControlTimeout time.Duration `json:"control_timeout_ns,omitempty"`
+
+	// BulkTransferSize is the largest bulk transfer a frame is sent in,
+	// in bytes. Zero sends each frame in one transfer.
+	BulkTransferSize int `json:"bulk_transfer_size,omitempty"`
+
+	// AsyncTransfers is the number of bulk transfers of a frame kept in
+	// flight at once when BulkTransferSize splits it, so the host queues
+	// the next while one is sent. Zero or one sends them one at a time.
+	AsyncTransfers int `json:"async_transfers,omitempty"`
+}
+
+// Validate checks that no option is negative and that BulkTransferSize
+// holds at least one packet.
+func (o USBOptions) Validate() error {
+	if o.FrameTimeout < 0 || o.ControlTimeout < 0 || o.BulkTransferSize < 0 || o.AsyncTransfers < 0 {
+		return errors.New("helios: USB options must not be negative")
+	}
+	if o.BulkTransferSize > 0 && o.BulkTransferSize < USBPacketSize {
+		return errors.New("helios: USB bulk transfers must hold at least one packet")
+	}
+	return nil
+}
+
+// millis converts a timeout to whole milliseconds, rounding up so short
+// timeouts are not taken as the default.
+func millis(d time.Duration) uint32 {
+	return uint32(min((d+time.Millisecond-1)/time.Millisecond, math.MaxUint32))
+}
+
+// clampUint32 limits a size to the range of the C options.
+func clampUint32(n int) uint32 {
+	return uint32(min(n, math.MaxUint32))
+}
+
+// SetUSBOptions sets the transfer options of the USB devices open now and
+// of the ones opened later. Network devices are unaffected, and builds with
+// the helios_purego tag ignore the options.
+func (d *DAC) SetUSBOptions(o USBOptions) error {
+	if err := o.Validate(); err != nil {
+		return err
+	}
+	d.usb.Store(&o)
+	d.lib.setUsbTransferOptions(
+		millis(o.FrameTimeout),
+		millis(o.ControlTimeout),
+		clampUint32(o.BulkTransferSize),
+		clampUint32(o.AsyncTransfers),
+	)
+	return nil
+}
+
+// USBOptions returns the options set by SetUSBOptions.
+func (d *DAC) USBOptions() USBOptions {
+	if o := d.usb.Load(); o != nil {
+		return *o
+	}
+	return USBOptions{}
+}
@@ -0,0 +1,51 @@
+package helios
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUSBOptionsValidate(t *testing.T) {
+	for _, tt := range []struct {
+		o  USBOptions
+		ok bool
+	}{
+		{USBOptions{}, true},
+		{USBOptions{FrameTimeout: time.Second, ControlTimeout: 100 * time.Millisecond, BulkTransferSize: 4096, AsyncTransfers: 4}, true},
+		{USBOptions{BulkTransferSize: USBPacketSize}, true},
+		{USBOptions{BulkTransferSize: USBPacketSize - 1}, false},
+		{USBOptions{FrameTimeout: -time.Millisecond}, false},
+		{USBOptions{AsyncTransfers: -1}, false},
+	} {
+		if err := tt.o.Validate(); (err == nil) != tt.ok {
+			t.Errorf("%+v: Validate = %v", tt.o, err)
+		}
+	}
+}
+
+func TestMillis(t *testing.T) {
+	for _, tt := range []struct {
+		d    time.Duration
+		want uint32
+	}{
+		{0, 0},
+		{time.Microsecond, 1},
+		{time.Millisecond, 1},
+		{1500 * time.Microsecond, 2},
+		{time.Duration(1 << 62), 1<<32 - 1},
+	} {
+		if got := millis(tt.d); got != tt.want {
+			t.Errorf("millis(%v) = %d, want %d", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestSetUSBOptionsRejectsInvalid(t *testing.T) {
+	d := &DAC{levels: newLevels()}
+	if err := d.SetUSBOptions(USBOptions{AsyncTransfers: -2}); err == nil {
+		t.Fatal("SetUSBOptions accepted negative options")
+	}
+	if d.USBOptions() != (USBOptions{}) {
+		t.Fatalf("rejected options were stored: %+v", d.USBOptions())
+	}
+}
@@ -0,0 +1,188 @@
+package helios
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Validation selects how frames are checked before they are written.
+type Validation int32
+
+const (
+	// ValidateOff passes frames to the device unchecked.
+	ValidateOff Validation = iota
+
+	// ValidateClamp clamps coordinates outside the device range to its
+	// edge, and rejects frames with too many points or an unsupported
+	// point rate.
+	ValidateClamp
+
+	// ValidateStrict rejects frames with any point outside the device
+	// range, too many points or an unsupported point rate.
+	ValidateStrict
+)
+
+// String returns the name of the mode, as used in ExplainFrame.
+func (v Validation) String() string {
+	switch v {
+	case ValidateOff:
+		return "off"
+	case ValidateClamp:
+		return "clamp"
+	case ValidateStrict:
+		return "strict"
+	}
+	return fmt.Sprintf("Validation(%d)", int32(v))
+}
+
+// Frame limits of the Helios firmware, mirroring HELIOS_MAX_POINTS,
+// HELIOS_MIN_PPS and HELIOS_MAX_PPS (and their _IDN variants for network
+// devices) of the C++ SDK.
+var (
+	LimitsUsb     = FrameLimits{MaxPoints: 0xFFF, MinPPS: 7, MaxPPS: 0xFFFF}
+	LimitsNetwork = FrameLimits{MaxPoints: 0x2000, MinPPS: 7, MaxPPS: 100000}
+)
+
+// FrameLimits are the bounds a device accepts for one frame.
+type FrameLimits struct {
+	MaxPoints      int
+	MinPPS, MaxPPS int
+}
+
+// maxCoord is the largest X or Y value of a Point.
+const maxCoord = 0xFFF
+
+// FrameError describes why a frame failed validation.
+type FrameError struct {
+	// Code is ErrTooManyPoints, ErrPpsTooHigh, ErrPpsTooLow or
+	// ErrCoordinateRange.
+	Code Error
+
+	// Indices lists the points outside the coordinate range.
+	Indices []int
+
+	// Points and PPS are the size and rate of the rejected frame.
+	Points, PPS int
+	Limits      FrameLimits
+}
+
+// maxListedIndices caps the indices spelled out by FrameError.Error.
+const maxListedIndices = 10
+
+func (e *FrameError) Error() string {
+	switch e.Code {
+	case ErrTooManyPoints:
+		return fmt.Sprintf("helios: frame has %d points, device accepts at most %d", e.Points, e.Limits.MaxPoints)
+	case ErrPpsTooHigh, ErrPpsTooLow:
+		return fmt.Sprintf("helios: %d pps is outside the device range %d-%d", e.PPS, e.Limits.MinPPS, e.Limits.MaxPPS)
+	}
+	listed := e.Indices[:min(len(e.Indices), maxListedIndices)]
+	idx := make([]string, len(listed))
+	for i, n := range listed {
+		idx[i] = fmt.Sprint(n)
+	}
+	s := fmt.Sprintf("helios: %d points outside the coordinate range 0-%d at indices %s", len(e.Indices), maxCoord, strings.Join(idx, ", "))
+	if more := len(e.Indices) - len(listed); more > 0 {
+		s += fmt.Sprintf(" and %d more", more)
+	}
+	return s
+}
+
+// Unwrap returns the result code, so errors.Is(err, ErrTooManyPoints) and
+// the like work.
+func (e *FrameError) Unwrap() error {
+	return e.Code
+}
+
+// ValidateFrame checks a frame against limits. With ValidateClamp,
+// out-of-range coordinates are clamped in a copy of points; the caller's
+// slice is never modified. The returned error is a *FrameError.
+func ValidateFrame(points []Point, pps int, limits FrameLimits, v Validation) ([]Point, error) {
+	if v == ValidateOff {
+		return points, nil
+	}
+	if err := checkFrame(len(points), pps, limits); err != nil {
+		return nil, err
+	}
+	var out []Point
+	var bad []int
+	for i, p := range points {
+		if p.X <= maxCoord && p.Y <= maxCoord {
+			continue
+		}
+		if v == ValidateStrict {
+			bad = append(bad, i)
+			continue
+		}
+		if out == nil {
+			out = append([]Point(nil), points...)
+		}
+		out[i].X, out[i].Y = min(p.X, maxCoord), min(p.Y, maxCoord)
+	}
+	if len(bad) > 0 {
+		return nil, &FrameError{Code: ErrCoordinateRange, Indices: bad, Points: len(points), PPS: pps, Limits: limits}
+	}
+	if out != nil {
+		return out, nil
+	}
+	return points, nil
+}
+
+// checkFrame checks the size and rate of a frame.
+func checkFrame(n, pps int, limits FrameLimits) error {
+	e := &FrameError{Points: n, PPS: pps, Limits: limits}
+	switch {
+	case n > limits.MaxPoints:
+		e.Code = ErrTooManyPoints
+	case pps > limits.MaxPPS:
+		e.Code = ErrPpsTooHigh
+	case pps < limits.MinPPS:
+		e.Code = ErrPpsTooLow
+	default:
+		return nil
+	}
+	return e
+}
+
+// SetValidation sets how frames written with the WriteFrame methods are
+// checked. Rejected frames are not sent and the write returns the
+// FrameError's Code; call ValidateFrame with FrameLimits for the full
+// description. The default is ValidateOff.
+func (d *DAC) SetValidation(v Validation) {
+	d.validation.Store(int32(v))
+}
+
+// Validation returns the validation mode set by SetValidation.
+func (d *DAC) Validation() Validation {
+	return Validation(d.validation.Load())
+}
+
+// FrameLimits returns the frame limits of a device.
+func (d *DAC) FrameLimits(deviceIndex int) FrameLimits {
+	defer d.lockDevice(deviceIndex)()
+	return d.frameLimits(deviceIndex)
+}
+
+// frameLimits is FrameLimits for callers holding the device lock.
+func (d *DAC) frameLimits(deviceIndex int) FrameLimits {
+	if d.lib.getIsUsb(deviceIndex) {
+		return LimitsUsb
+	}
+	return LimitsNetwork
+}
+
+// GetMaxSampleRate returns the highest point rate a device accepts. The C++
+// SDK only exposes this per connection type, so it is derived the same way.
+func (d *DAC) GetMaxSampleRate(deviceIndex int) int {
+	return d.FrameLimits(deviceIndex).MaxPPS
+}
+
+// GetMinSampleRate returns the lowest point rate a device accepts.
+func (d *DAC) GetMinSampleRate(deviceIndex int) int {
+	return d.FrameLimits(deviceIndex).MinPPS
+}
+
+// GetMaxFrameSize returns the most points a device accepts in one frame.
+func (d *DAC) GetMaxFrameSize(deviceIndex int) int {
+	return d.FrameLimits(deviceIndex).MaxPoints
+}
@@ -0,0 +1,52 @@
+package helios
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateFrame(t *testing.T) {
+	frame := []Point{{X: 100, Y: 100}, {X: 5000, Y: 10}, {X: 10, Y: 4096}}
+
+	got, err := ValidateFrame(frame, 30000, LimitsUsb, ValidateClamp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got[1].X != 4095 || got[2].Y != 4095 || got[0] != frame[0] {
+		t.Fatalf("clamped = %+v", got)
+	}
+	if frame[1].X != 5000 {
+		t.Fatal("clamping modified the caller's frame")
+	}
+
+	_, err = ValidateFrame(frame, 30000, LimitsUsb, ValidateStrict)
+	var fe *FrameError
+	if !errors.As(err, &fe) || !errors.Is(err, ErrCoordinateRange) {
+		t.Fatalf("strict: %v, want ErrCoordinateRange", err)
+	}
+	if len(fe.Indices) != 2 || !strings.Contains(err.Error(), "indices 1, 2") {
+		t.Fatalf("strict error = %q", err)
+	}
+
+	big := make([]Point, LimitsUsb.MaxPoints+1)
+	if _, err := ValidateFrame(big, 30000, LimitsUsb, ValidateClamp); !errors.Is(err, ErrTooManyPoints) {
+		t.Fatalf("oversized frame: %v", err)
+	}
+	if _, err := ValidateFrame(big, 30000, LimitsNetwork, ValidateClamp); err != nil {
+		t.Fatalf("oversized for USB but fine for network: %v", err)
+	}
+	if _, err := ValidateFrame(frame[:1], 70000, LimitsUsb, ValidateClamp); !errors.Is(err, ErrPpsTooHigh) {
+		t.Fatalf("pps too high: %v", err)
+	}
+	if got, err := ValidateFrame(frame, 1, LimitsUsb, ValidateOff); err != nil || &got[0] != &frame[0] {
+		t.Fatal("ValidateOff checked the frame")
+	}
+}
+
+func TestFrameErrorListsIndices(t *testing.T) {
+	e := &FrameError{Code: ErrCoordinateRange, Indices: []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}}
+	if s := e.Error(); !strings.HasSuffix(s, "8, 9 and 2 more") {
+		t.Fatalf("Error() = %q", s)
+	}
+}
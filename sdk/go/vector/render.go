@@ -0,0 +1,82 @@
+package vector
+
+import (
+	"math"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/motion"
+)
+
+// DefaultSpacing is the distance between points along segments, in
+// normalized units.
+const DefaultSpacing = 0.01
+
+// Renderer turns paths into frames.
+type Renderer struct {
+	// Profile shapes the blanked jumps between disconnected segments.
+	Profile motion.GalvoProfile
+
+	// PPS is the rate the frames will be played at.
+	PPS int
+
+	// Spacing is the largest distance between consecutive points along a
+	// segment. Zero means DefaultSpacing.
+	Spacing float64
+}
+
+// NewRenderer returns a renderer for frames played at pps, with
+// motion.DefaultProfile and DefaultSpacing.
+func NewRenderer(pps int) Renderer {
+	return Renderer{Profile: motion.DefaultProfile, PPS: pps, Spacing: DefaultSpacing}
+}
+
+func (r Renderer) spacing() float64 {
+	if r.Spacing > 0 {
+		return r.Spacing
+	}
+	return DefaultSpacing
+}
+
+// Render returns the frame drawing p. Segments without points are skipped.
+func (r Renderer) Render(p Path) []helios.Point {
+	var frame []helios.Point
+	spacing := r.spacing()
+	for _, s := range p {
+		if len(s.Points) == 0 {
+			continue
+		}
+		color := helios.PointF{R: s.R, G: s.G, B: s.B, I: 1}
+		if s.Blanked {
+			color = helios.PointF{}
+		}
+		at := func(xy [2]float64) helios.Point {
+			c := color
+			c.X, c.Y = xy[0], xy[1]
+			return c.Point()
+		}
+		start := at(s.Points[0])
+		switch {
+		case len(frame) == 0:
+			frame = append(frame, helios.Point{X: start.X, Y: start.Y}, start)
+		case !samePosition(frame[len(frame)-1], start):
+			frame = append(frame, r.Profile.Travel(frame[len(frame)-1], start, r.PPS)...)
+			frame = append(frame, start)
+		}
+		for i := 1; i < len(s.Points); i++ {
+			a, b := s.Points[i-1], s.Points[i]
+			n := max(1, int(math.Ceil(math.Hypot(b[0]-a[0], b[1]-a[1])/spacing)))
+			for k := 1; k <= n; k++ {
+				t := float64(k) / float64(n)
+				frame = append(frame, at([2]float64{a[0] + (b[0]-a[0])*t, a[1] + (b[1]-a[1])*t}))
+			}
+		}
+		if s.Dwell > 0 {
+			frame = append(frame, motion.Dwell(frame[len(frame)-1], s.Dwell, r.PPS, !s.Blanked)...)
+		}
+	}
+	return frame
+}
+
+func samePosition(a, b helios.Point) bool {
+	return a.X == b.X && a.Y == b.Y
+}
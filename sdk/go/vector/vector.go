@@ -0,0 +1,96 @@
+// Package vector is the common form of vector content between importers and
+// rendering.
+//
+// A Path is an ordered list of segments, each a polyline carrying its own
+// color, blanking and dwell hints. Importers such as svg and ilda produce
+// paths, and a Renderer turns them into frames for a given scan rate, adding
+// the blanked jumps between disconnected segments and sampling lines at an
+// even spacing, so a new content source only has to describe its geometry:
+//
+//	paths, _ := svg.Read(f)
+//	frame := vector.NewRenderer(30000).Render(vector.FromSVG(paths))
+package vector
+
+import (
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/svg"
+)
+
+// Segment is a polyline drawn in one color.
+type Segment struct {
+	// Points is the polyline in normalized coordinates (-1 to 1). A segment
+	// starting away from where the previous one ended is reached by a
+	// blanked jump; one starting where it ended continues the stroke.
+	Points [][2]float64
+
+	// R, G, B is the color (0 to 1). It is ignored if Blanked is set.
+	R, G, B float64
+
+	// Blanked marks a move traced with the beam off, such as a deliberate
+	// path around the projection area.
+	Blanked bool
+
+	// Dwell is how long the beam holds at the end of the segment, lit unless
+	// Blanked is set, to sharpen corners or draw dots.
+	Dwell time.Duration
+}
+
+// Path is a sequence of segments drawn in order.
+type Path []Segment
+
+// FromSVG returns a path drawing each SVG outline as a lit segment.
+func FromSVG(paths []svg.Path) Path {
+	p := make(Path, 0, len(paths))
+	for _, sp := range paths {
+		if len(sp.Points) == 0 {
+			continue
+		}
+		p = append(p, Segment{
+			Points: append([][2]float64(nil), sp.Points...),
+			R:      sp.R, G: sp.G, B: sp.B,
+		})
+	}
+	return p
+}
+
+// FromPoints returns a path tracing a frame played at pps, such as one read
+// from an ILDA file. Runs of points with the same color and blanking become
+// segments, each starting where the previous point left the beam, and
+// repeated points become dwells.
+func FromPoints(frame []helios.Point, pps int) Path {
+	var p Path
+	hold := time.Second / time.Duration(max(pps, 1))
+	for i, pt := range frame {
+		if i > 0 && pt == frame[i-1] && len(p) > 0 {
+			p[len(p)-1].Dwell += hold
+			continue
+		}
+		f := pt.PointF()
+		xy := [2]float64{f.X, f.Y}
+		s := Segment{Blanked: !lit(pt)}
+		if !s.Blanked {
+			s.R, s.G, s.B = f.R, f.G, f.B
+		}
+		if n := len(p); n > 0 && p[n-1].Dwell == 0 && sameStyle(p[n-1], s) {
+			p[n-1].Points = append(p[n-1].Points, xy)
+			continue
+		}
+		if n := len(p); n > 0 {
+			last := p[n-1].Points
+			s.Points = append(s.Points, last[len(last)-1])
+		}
+		s.Points = append(s.Points, xy)
+		p = append(p, s)
+	}
+	return p
+}
+
+func lit(p helios.Point) bool {
+	return p.I != 0 && (p.R != 0 || p.G != 0 || p.B != 0)
+}
+
+func sameStyle(a, b Segment) bool {
+	return a.Blanked == b.Blanked && a.R == b.R && a.G == b.G && a.B == b.B
+}
@@ -0,0 +1,116 @@
+package vector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/svg"
+)
+
+func TestFromSVG(t *testing.T) {
+	paths := []svg.Path{
+		{R: 1, Points: [][2]float64{{0, 0}, {1, 0}}},
+		{G: 1},
+	}
+	p := FromSVG(paths)
+	if len(p) != 1 || p[0].R != 1 || p[0].Blanked || len(p[0].Points) != 2 {
+		t.Fatalf("path = %+v", p)
+	}
+	p[0].Points[0][0] = 5
+	if paths[0].Points[0][0] != 0 {
+		t.Fatal("FromSVG shares the caller's points")
+	}
+}
+
+func TestFromPoints(t *testing.T) {
+	red := helios.Point{X: 100, Y: 100, R: 255, I: 255}
+	frame := []helios.Point{
+		{X: 0, Y: 0},
+		red,
+		{X: 200, Y: 100, R: 255, I: 255},
+		{X: 200, Y: 100, R: 255, I: 255}, // dwell
+		{X: 200, Y: 200, G: 255, I: 255},
+		{X: 0, Y: 0},
+	}
+	p := FromPoints(frame, 1000)
+	if len(p) != 4 {
+		t.Fatalf("got %d segments, want 4: %+v", len(p), p)
+	}
+	if !p[0].Blanked || len(p[0].Points) != 1 {
+		t.Fatalf("segment 0 = %+v", p[0])
+	}
+	// The red run starts where the blanked point left the beam.
+	if p[1].Blanked || p[1].R != 1 || len(p[1].Points) != 3 || p[1].Points[0] != p[0].Points[0] {
+		t.Fatalf("segment 1 = %+v", p[1])
+	}
+	if p[1].Dwell != time.Millisecond {
+		t.Fatalf("dwell = %v, want 1ms", p[1].Dwell)
+	}
+	if p[2].G != 1 || p[2].R != 0 || p[2].Points[0] != p[1].Points[2] {
+		t.Fatalf("segment 2 = %+v", p[2])
+	}
+	if !p[3].Blanked {
+		t.Fatalf("segment 3 = %+v", p[3])
+	}
+}
+
+func TestRender(t *testing.T) {
+	r := NewRenderer(30000)
+	r.Spacing = 0.5
+	p := Path{
+		{Points: [][2]float64{{-1, 0}, {0, 0}}, R: 1},
+		{Points: [][2]float64{{0, 0}, {0, 1}}, G: 1, Dwell: time.Millisecond},
+		{Points: [][2]float64{{1, 1}}, B: 1},
+	}
+	frame := r.Render(p)
+
+	// The blanked start, the lit start, 2 red and 2 green samples.
+	if frame[0].R != 0 || frame[1].R != 255 {
+		t.Fatalf("start = %v", frame[:2])
+	}
+	if frame[3].R != 255 || frame[4].G != 255 || frame[5].G != 255 {
+		t.Fatalf("strokes = %v", frame[:6])
+	}
+	// The dwell holds the green corner lit.
+	dwell := 30
+	for i := 6; i < 6+dwell; i++ {
+		if frame[i] != frame[5] {
+			t.Fatalf("point %d = %v, want %v", i, frame[i], frame[5])
+		}
+	}
+	// The blue dot is reached by a blanked jump.
+	jump := frame[6+dwell : len(frame)-1]
+	if len(jump) == 0 {
+		t.Fatal("no jump before the dot")
+	}
+	for _, pt := range jump {
+		if lit(pt) {
+			t.Fatalf("lit jump point %v", pt)
+		}
+	}
+	dot := helios.PointF{X: 1, Y: 1, B: 1, I: 1}.Point()
+	if frame[len(frame)-1] != dot {
+		t.Fatalf("last = %v, want %v", frame[len(frame)-1], dot)
+	}
+}
+
+func TestRenderRoundTrip(t *testing.T) {
+	frame := NewRenderer(30000).Render(Path{
+		{Points: [][2]float64{{-0.5, -0.5}, {0.5, -0.5}, {0.5, 0.5}}, R: 1},
+		{Points: [][2]float64{{-0.5, 0.5}, {-0.5, 0}}, Blanked: true},
+	})
+	p := FromPoints(frame, 30000)
+	if len(p) < 2 || !p[len(p)-1].Blanked {
+		t.Fatalf("path = %+v", p)
+	}
+	var strokes int
+	for _, s := range p {
+		if !s.Blanked {
+			strokes++
+		}
+	}
+	if strokes != 1 {
+		t.Fatalf("got %d lit segments, want 1", strokes)
+	}
+}
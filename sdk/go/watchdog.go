@@ -0,0 +1,131 @@
+package helios
+
+import (
+	"sync"
+	"time"
+)
+
+// WatchdogAction is what the watchdog does to a device whose frames have
+// stopped arriving.
+type WatchdogAction int
+
+const (
+	// WatchdogStop calls Stop on the device.
+	WatchdogStop WatchdogAction = iota
+
+	// WatchdogBlank replaces the looping frame with a short blank frame at
+	// the center, keeping the device streaming.
+	WatchdogBlank
+)
+
+// watchdog tracks the time of the last frame written to each device.
+type watchdog struct {
+	mu      sync.Mutex
+	timeout time.Duration
+	last    map[int]time.Time
+	done    chan struct{}
+	exited  chan struct{} // closed when the goroutine started last returns
+}
+
+// touch records a frame written to a device.
+func (w *watchdog) touch(deviceIndex int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.last != nil {
+		w.last[deviceIndex] = time.Now()
+	}
+}
+
+// touch records output for a device without writing a frame, for writers
+// that skip frames the device is already repeating.
+func (d *DAC) touch(deviceIndex int) {
+	d.watchdog.touch(deviceIndex)
+}
+
+// expired returns the devices that have received no frame for longer than
+// the timeout, and forgets them until they are written again.
+func (w *watchdog) expired(now time.Time) []int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var out []int
+	for i, t := range w.last {
+		if now.Sub(t) > w.timeout {
+			out = append(out, i)
+			delete(w.last, i)
+		}
+	}
+	return out
+}
+
+// start runs fire for each expired device until stop is called.
+func (w *watchdog) start(timeout time.Duration, fire func(deviceIndex int)) {
+	w.stop()
+	w.mu.Lock()
+	w.timeout = timeout
+	w.last = make(map[int]time.Time)
+	done, exited := make(chan struct{}), make(chan struct{})
+	w.done, w.exited = done, exited
+	w.mu.Unlock()
+
+	go func() {
+		defer close(exited)
+		ticker := time.NewTicker(max(timeout/4, time.Millisecond))
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				for _, i := range w.expired(now) {
+					fire(i)
+				}
+			}
+		}
+	}()
+}
+
+// stop stops the watchdog and returns a channel closed once it has
+// finished acting on the devices it found expired.
+func (w *watchdog) stop() <-chan struct{} {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.done != nil {
+		close(w.done)
+		w.done = nil
+	}
+	w.last = nil
+	if w.exited == nil {
+		w.exited = make(chan struct{})
+		close(w.exited)
+	}
+	return w.exited
+}
+
+// SetWatchdog makes the DAC act on any device that has received no frame
+// for longer than timeout, so a crashed generator does not leave its last
+// frame looping on the laser. The watchdog only covers devices written
+// since it was set, and acts once per device until the next frame is
+// written. A timeout of zero disables the watchdog.
+func (d *DAC) SetWatchdog(timeout time.Duration, action WatchdogAction) {
+	if timeout <= 0 {
+		d.watchdog.stop()
+		return
+	}
+	d.watchdog.start(timeout, func(deviceIndex int) {
+		d.fireWatchdog(deviceIndex, action)
+	})
+}
+
+// fireWatchdog acts on a stalled device. It passes the write gate like the
+// public write paths, so it never writes to a device Shutdown is closing.
+func (d *DAC) fireWatchdog(deviceIndex int, action WatchdogAction) {
+	if !d.gate.enter() {
+		return
+	}
+	defer d.gate.leave()
+	if action == WatchdogBlank {
+		d.writeFrame(deviceIndex, 1000, FlagStartImmediately|FlagSingleMode, blankFrame(), FrameMeta{})
+	} else {
+		d.Stop(deviceIndex)
+	}
+}
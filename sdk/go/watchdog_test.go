@@ -0,0 +1,90 @@
+package helios
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWatchdog(t *testing.T) {
+	var w watchdog
+	fired := make(chan int, 4)
+	w.start(20*time.Millisecond, func(i int) { fired <- i })
+	defer w.stop()
+
+	w.touch(3)
+	select {
+	case i := <-fired:
+		if i != 3 {
+			t.Fatalf("watchdog fired for device %d, want 3", i)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("watchdog did not fire")
+	}
+
+	// Each stall is acted on once.
+	select {
+	case i := <-fired:
+		t.Fatalf("watchdog fired again for device %d", i)
+	case <-time.After(60 * time.Millisecond):
+	}
+
+	w.stop()
+	w.touch(1)
+	select {
+	case <-fired:
+		t.Fatal("stopped watchdog fired")
+	case <-time.After(60 * time.Millisecond):
+	}
+}
+
+func TestWatchdogAfterShutdown(t *testing.T) {
+	d := NewDAC()
+	defer d.Close()
+	writes := 0
+	d.SetWriteHook(func(int, WriteInfo) { writes++ })
+
+	d.fireWatchdog(0, WatchdogBlank)
+	if writes != 1 {
+		t.Fatalf("watchdog blank wrote %d frames, want 1", writes)
+	}
+	<-d.gate.close()
+	d.fireWatchdog(0, WatchdogBlank)
+	if writes != 1 {
+		t.Fatal("watchdog wrote past the closed write gate")
+	}
+}
+
+func TestCloseStopsWatchdogFirst(t *testing.T) {
+	d := NewDAC()
+	var late atomic.Bool
+	d.SetWriteHook(func(_ int, info WriteInfo) {
+		if info.Result == int(ErrDeviceClosed) {
+			late.Store(true)
+		}
+	})
+	d.SetWatchdog(10*time.Millisecond, WatchdogBlank)
+	d.WriteFrame(0, 30000, FlagsDefault, []Point{{}})
+
+	// Close waits for a call on another device while the watchdog fires.
+	unlock := d.lockDevice(1)
+	closed := make(chan struct{})
+	go func() {
+		d.Close()
+		close(closed)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	unlock()
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close did not return")
+	}
+	time.Sleep(20 * time.Millisecond) // for a blank queued behind Close
+	if late.Load() {
+		t.Fatal("watchdog wrote to the device after Close released it")
+	}
+	if code := d.WriteFrame(0, 30000, FlagsDefault, []Point{{}}); code != int(ErrDeviceClosed) {
+		t.Fatalf("WriteFrame after Close = %d, want ErrDeviceClosed", code)
+	}
+}
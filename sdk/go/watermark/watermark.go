@@ -0,0 +1,125 @@
+// Package watermark marks output frames with the ID of the engine that
+// produced them.
+//
+// A marker is a short run of blanked points appended to a frame. The points
+// sit on the frame's last position and encode the ID in one-step X offsets,
+// so they move the galvos by a single DAC step and emit no light: invisible
+// on the projection, but present in any recording or preview of the point
+// stream, where Find recovers the ID. An Injector adds a marker to every
+// n-th frame written to an output.
+package watermark
+
+import (
+	"hash/crc32"
+	"sync"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/output"
+)
+
+var sync8 = [...]uint8{0, 1, 0, 1, 1, 0, 0, 1}
+
+const (
+	idBits    = 32
+	checkBits = 8
+
+	// Len is the number of points in a marker.
+	Len = len(sync8) + idBits + checkBits
+)
+
+func check(id uint32) uint8 {
+	var b [4]byte
+	b[0], b[1], b[2], b[3] = byte(id>>24), byte(id>>16), byte(id>>8), byte(id)
+	return uint8(crc32.ChecksumIEEE(b[:]))
+}
+
+// Marker returns the marker points for id, placed at p.
+func Marker(id uint32, p helios.Point) []helios.Point {
+	base := helios.Point{X: min(p.X, 4094), Y: p.Y}
+	bits := make([]uint8, 0, Len)
+	bits = append(bits, sync8[:]...)
+	for i := idBits - 1; i >= 0; i-- {
+		bits = append(bits, uint8(id>>i&1))
+	}
+	c := check(id)
+	for i := checkBits - 1; i >= 0; i-- {
+		bits = append(bits, c>>i&1)
+	}
+	out := make([]helios.Point, Len)
+	for i, b := range bits {
+		out[i] = base
+		out[i].X += uint16(b)
+	}
+	return out
+}
+
+// Append returns frame followed by a marker for id at the frame's last
+// point.
+func Append(frame []helios.Point, id uint32) []helios.Point {
+	var last helios.Point
+	if len(frame) > 0 {
+		last = frame[len(frame)-1]
+	}
+	out := make([]helios.Point, 0, len(frame)+Len)
+	out = append(out, frame...)
+	return append(out, Marker(id, last)...)
+}
+
+// Find returns the ID of the first valid marker in points.
+func Find(points []helios.Point) (uint32, bool) {
+	for start := 0; start+Len <= len(points); start++ {
+		if id, ok := decode(points[start : start+Len]); ok {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+func decode(m []helios.Point) (uint32, bool) {
+	base := m[0]
+	for i, p := range m {
+		if p.R|p.G|p.B|p.I != 0 || p.Y != base.Y || p.X-base.X > 1 {
+			return 0, false
+		}
+		if i < len(sync8) && uint8(p.X-base.X) != sync8[i] {
+			return 0, false
+		}
+	}
+	var id uint32
+	for _, p := range m[len(sync8) : len(sync8)+idBits] {
+		id = id<<1 | uint32(p.X-base.X)
+	}
+	var c uint8
+	for _, p := range m[len(sync8)+idBits:] {
+		c = c<<1 | uint8(p.X-base.X)
+	}
+	return id, c == check(id)
+}
+
+// Injector appends a marker to every Every-th frame written to an Output.
+type Injector struct {
+	output.Output
+	ID uint32
+
+	// Every is the marker interval in frames. Zero or one marks every
+	// frame.
+	Every int
+
+	mu    sync.Mutex
+	count int
+}
+
+// WriteFrame writes points, with a marker appended if one is due.
+func (in *Injector) WriteFrame(pps int, points []helios.Point) error {
+	in.mu.Lock()
+	mark := in.count%max(in.Every, 1) == 0
+	in.count++
+	in.mu.Unlock()
+	if mark && len(points) > 0 {
+		points = Append(points, in.ID)
+	}
+	return in.Output.WriteFrame(pps, points)
+}
+
+// Unwrap returns the wrapped output.
+func (in *Injector) Unwrap() output.Output { return in.Output }
@@ -0,0 +1,50 @@
+package watermark
+
+import (
+	"testing"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+type recorder struct{ frames [][]helios.Point }
+
+func (r *recorder) Ready() (bool, error) { return true, nil }
+func (r *recorder) WriteFrame(pps int, points []helios.Point) error {
+	r.frames = append(r.frames, points)
+	return nil
+}
+func (r *recorder) Stop() error  { return nil }
+func (r *recorder) Close() error { return nil }
+
+func TestInjectorMarksFrames(t *testing.T) {
+	rec := &recorder{}
+	in := &Injector{Output: rec, ID: 0xC0FFEE42, Every: 3}
+	frame := []helios.Point{{X: 4095, Y: 10, R: 255, I: 255}, {X: 4095, Y: 20, G: 255, I: 255}}
+	for range 4 {
+		in.WriteFrame(30000, frame)
+	}
+
+	for i, f := range rec.frames {
+		id, ok := Find(f)
+		if marked := i%3 == 0; ok != marked {
+			t.Fatalf("frame %d marked = %v, want %v", i, ok, marked)
+		}
+		if ok && id != 0xC0FFEE42 {
+			t.Fatalf("frame %d id = %#x", i, id)
+		}
+	}
+	if m := rec.frames[0][len(frame):]; m[0].X != 4094 || m[0].Y != 20 {
+		t.Fatalf("marker placed at %+v, want the last point", m[0])
+	}
+}
+
+func TestFindRejectsCorruptMarker(t *testing.T) {
+	m := Marker(7, helios.Point{X: 100, Y: 100})
+	m[len(m)-1].X ^= 1
+	if _, ok := Find(m); ok {
+		t.Fatal("Find accepted a marker with a bad check value")
+	}
+	if _, ok := Find(make([]helios.Point, 100)); ok {
+		t.Fatal("Find matched a blank frame")
+	}
+}
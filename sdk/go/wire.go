@@ -0,0 +1,70 @@
+package helios
+
+import (
+	"fmt"
+	"net/netip"
+	"sync/atomic"
+	"time"
+)
+
+// WireTransport is how a WireRecord was exchanged with a DAC.
+type WireTransport int
+
+const (
+	WireUSBInterrupt WireTransport = iota // USB interrupt transfer: control requests and status.
+	WireUSBBulk                           // USB bulk transfer: frames.
+	WireUDP                               // UDP packet: IDN stream or management request.
+)
+
+func (t WireTransport) String() string {
+	switch t {
+	case WireUSBInterrupt:
+		return "usb-interrupt"
+	case WireUSBBulk:
+		return "usb-bulk"
+	case WireUDP:
+		return "udp"
+	}
+	return fmt.Sprintf("WireTransport(%d)", int(t))
+}
+
+// WireRecord is one USB transfer or network packet exchanged with a DAC.
+type WireRecord struct {
+	Time      time.Time
+	Sent      bool
+	Transport WireTransport
+
+	// Endpoint is the USB endpoint address of a transfer.
+	Endpoint int
+
+	// Addr is the address of a network DAC.
+	Addr netip.AddrPort
+
+	// Err is the libusb error of a failed transfer, as ErrLibusbBase plus
+	// the libusb code, or nil.
+	Err error
+
+	// Data holds the bytes transferred; for a failed send, the bytes that
+	// were to be sent. It is only valid during the call.
+	Data []byte
+}
+
+var wireTap atomic.Pointer[func(WireRecord)]
+
+// SetWireTap passes every USB transfer and network packet exchanged with
+// any DAC to fn, with its timestamp, so protocol issues can be reported
+// with a capture; see the output/wiretap package for sinks writing log and
+// pcap files. IDN discovery is not captured. fn is called on the thread
+// doing the transfer, must not call SetWireTap and should return quickly,
+// as output waits for it. A nil fn stops capturing; when SetWireTap
+// returns, calls to the previous fn have finished. Builds with the
+// helios_purego tag capture nothing.
+func SetWireTap(fn func(WireRecord)) {
+	if fn == nil {
+		setWireTap(false)
+		wireTap.Store(nil)
+		return
+	}
+	wireTap.Store(&fn)
+	setWireTap(true)
+}
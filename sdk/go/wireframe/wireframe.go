@@ -0,0 +1,282 @@
+// Package wireframe draws 3D wireframe meshes as laser frames.
+//
+// A Mesh is rotated, projected through a Camera onto the normalized
+// projection plane (-1 to 1, as used by helios.PointF) and traced into a
+// frame. Edges sharing vertices are joined into continuous strokes and the
+// strokes are ordered to keep blanked jumps short. Edges facing away from
+// the camera can optionally be culled.
+package wireframe
+
+import (
+	"math"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+	"github.com/Grix/helios_dac/sdk/go/svg"
+)
+
+// Vec3 is a point or direction in model space. Y is up and the camera looks
+// down the Z axis towards the origin from positive Z.
+type Vec3 struct {
+	X, Y, Z float64
+}
+
+func (a Vec3) sub(b Vec3) Vec3 { return Vec3{a.X - b.X, a.Y - b.Y, a.Z - b.Z} }
+
+func (a Vec3) dot(b Vec3) float64 { return a.X*b.X + a.Y*b.Y + a.Z*b.Z }
+
+func (a Vec3) cross(b Vec3) Vec3 {
+	return Vec3{a.Y*b.Z - a.Z*b.Y, a.Z*b.X - a.X*b.Z, a.X*b.Y - a.Y*b.X}
+}
+
+func lerp(a, b Vec3, t float64) Vec3 {
+	return Vec3{a.X + (b.X-a.X)*t, a.Y + (b.Y-a.Y)*t, a.Z + (b.Z-a.Z)*t}
+}
+
+// Mesh is a wireframe model.
+type Mesh struct {
+	Vertices []Vec3
+
+	// Edges are pairs of indices into Vertices.
+	Edges [][2]int
+
+	// Faces are optional polygons, given as indices into Vertices in
+	// counterclockwise order seen from outside. They are only used to cull
+	// hidden edges.
+	Faces [][]int
+
+	// R, G, B is the line color (0 to 1).
+	R, G, B float64
+}
+
+// Cube returns a cube of the given edge length centered on the origin, with
+// faces for culling.
+func Cube(size float64, r, g, b float64) *Mesh {
+	h := size / 2
+	m := &Mesh{R: r, G: g, B: b}
+	for i := range 8 {
+		v := Vec3{-h, -h, -h}
+		if i&1 != 0 {
+			v.X = h
+		}
+		if i&2 != 0 {
+			v.Y = h
+		}
+		if i&4 != 0 {
+			v.Z = h
+		}
+		m.Vertices = append(m.Vertices, v)
+	}
+	m.Edges = [][2]int{
+		{0, 1}, {2, 3}, {4, 5}, {6, 7},
+		{0, 2}, {1, 3}, {4, 6}, {5, 7},
+		{0, 4}, {1, 5}, {2, 6}, {3, 7},
+	}
+	m.Faces = [][]int{
+		{0, 2, 3, 1}, {4, 5, 7, 6}, // back, front
+		{0, 1, 5, 4}, {2, 6, 7, 3}, // bottom, top
+		{0, 4, 6, 2}, {1, 3, 7, 5}, // left, right
+	}
+	return m
+}
+
+// Rotation is a rotation in radians about the X, Y and Z axes, applied in
+// that order.
+type Rotation struct {
+	X, Y, Z float64
+}
+
+// Apply rotates v.
+func (r Rotation) Apply(v Vec3) Vec3 {
+	s, c := math.Sincos(r.X)
+	v = Vec3{v.X, v.Y*c - v.Z*s, v.Y*s + v.Z*c}
+	s, c = math.Sincos(r.Y)
+	v = Vec3{v.X*c + v.Z*s, v.Y, v.Z*c - v.X*s}
+	s, c = math.Sincos(r.Z)
+	return Vec3{v.X*c - v.Y*s, v.X*s + v.Y*c, v.Z}
+}
+
+// Camera projects model space onto the projection plane.
+type Camera struct {
+	// Distance is how far the camera is from the origin along Z. Zero
+	// selects an orthographic projection, drawing X and Y as they are.
+	Distance float64
+
+	// Focal scales the perspective projection: a point at the origin's
+	// depth is drawn at its X and Y times Focal / Distance. Zero means
+	// Distance, so geometry at the origin keeps its size.
+	Focal float64
+}
+
+// nearPlane is the closest distance to the camera that is drawn. Edges
+// crossing it are clipped.
+const nearPlane = 1e-3
+
+// eye returns the camera position, or for an orthographic camera the view
+// direction towards the camera.
+func (c Camera) eye() Vec3 {
+	if c.Distance == 0 {
+		return Vec3{0, 0, 1}
+	}
+	return Vec3{0, 0, c.Distance}
+}
+
+// project maps v to the projection plane.
+func (c Camera) project(v Vec3) [2]float64 {
+	if c.Distance == 0 {
+		return [2]float64{v.X, v.Y}
+	}
+	f := c.Focal
+	if f == 0 {
+		f = c.Distance
+	}
+	s := f / (c.Distance - v.Z)
+	return [2]float64{v.X * s, v.Y * s}
+}
+
+// clip shortens the segment a-b to the part in front of the camera, and
+// reports whether any of it is.
+func (c Camera) clip(a, b Vec3) (Vec3, Vec3, bool) {
+	if c.Distance == 0 {
+		return a, b, true
+	}
+	da, db := c.Distance-a.Z, c.Distance-b.Z
+	switch {
+	case da < nearPlane && db < nearPlane:
+		return a, b, false
+	case da < nearPlane:
+		a = lerp(a, b, (nearPlane-da)/(db-da))
+	case db < nearPlane:
+		b = lerp(b, a, (nearPlane-db)/(da-db))
+	}
+	return a, b, true
+}
+
+// Project rotates m, culls edges whose faces all face away from the camera
+// if cull is set, and returns the visible edges as strokes on the
+// projection plane.
+func (m *Mesh) Project(rot Rotation, cam Camera, cull bool) []svg.Path {
+	verts := make([]Vec3, len(m.Vertices))
+	for i, v := range m.Vertices {
+		verts[i] = rot.Apply(v)
+	}
+	edges := m.Edges
+	if cull {
+		edges = m.visibleEdges(verts, cam)
+	}
+	var paths []svg.Path
+	for _, stroke := range strokes(edges, verts) {
+		var pts [][2]float64
+		for i := 1; i < len(stroke); i++ {
+			a, b, ok := cam.clip(verts[stroke[i-1]], verts[stroke[i]])
+			if !ok {
+				continue
+			}
+			pa, pb := cam.project(a), cam.project(b)
+			if len(pts) == 0 || pts[len(pts)-1] != pa {
+				if len(pts) > 1 {
+					paths = append(paths, svg.Path{R: m.R, G: m.G, B: m.B, Points: pts})
+				}
+				pts = [][2]float64{pa}
+			}
+			pts = append(pts, pb)
+		}
+		if len(pts) > 1 {
+			paths = append(paths, svg.Path{R: m.R, G: m.G, B: m.B, Points: pts})
+		}
+	}
+	return paths
+}
+
+// visibleEdges returns the edges not bordered only by faces facing away from
+// the camera. Edges on no face are always visible.
+func (m *Mesh) visibleEdges(verts []Vec3, cam Camera) [][2]int {
+	type key [2]int
+	norm := func(a, b int) key { return key{min(a, b), max(a, b)} }
+	faces := make(map[key]int) // faces bordering each edge
+	front := make(map[key]int) // of which facing the camera
+	eye := cam.eye()
+	for _, f := range m.Faces {
+		if len(f) < 3 {
+			continue
+		}
+		v0 := verts[f[0]]
+		n := verts[f[1]].sub(v0).cross(verts[f[2]].sub(v0))
+		view := eye
+		if cam.Distance != 0 {
+			view = eye.sub(v0)
+		}
+		facing := n.dot(view) > 0
+		for i := range f {
+			k := norm(f[i], f[(i+1)%len(f)])
+			faces[k]++
+			if facing {
+				front[k]++
+			}
+		}
+	}
+	var out [][2]int
+	for _, e := range m.Edges {
+		k := norm(e[0], e[1])
+		if faces[k] == 0 || front[k] > 0 {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// strokes joins edges into vertex sequences that can each be drawn without
+// lifting the beam. Each stroke starts at the unused edge end nearest to
+// where the previous one finished.
+func strokes(edges [][2]int, verts []Vec3) [][]int {
+	adj := make(map[int][]int) // edge indices at each vertex
+	for i, e := range edges {
+		adj[e[0]] = append(adj[e[0]], i)
+		adj[e[1]] = append(adj[e[1]], i)
+	}
+	used := make([]bool, len(edges))
+	var out [][]int
+	var at Vec3
+	for range edges {
+		start, best := -1, math.Inf(1)
+		for i, e := range edges {
+			if used[i] {
+				continue
+			}
+			for _, v := range e {
+				d := verts[v].sub(at)
+				if dist := d.dot(d); dist < best {
+					start, best = v, dist
+				}
+			}
+		}
+		if start < 0 {
+			break
+		}
+		stroke := []int{start}
+		for v := start; ; {
+			next := -1
+			for _, i := range adj[v] {
+				if !used[i] {
+					used[i] = true
+					next = edges[i][0] + edges[i][1] - v
+					break
+				}
+			}
+			if next < 0 {
+				break
+			}
+			stroke = append(stroke, next)
+			v = next
+		}
+		out = append(out, stroke)
+		at = verts[stroke[len(stroke)-1]]
+	}
+	return out
+}
+
+// Frame projects m and traces it into a frame with svg.Frame: consecutive
+// points are at most spacing apart (in normalized units) and blank blanked
+// points are inserted at each end of a jump between strokes.
+func (m *Mesh) Frame(rot Rotation, cam Camera, cull bool, spacing float64, blank int) []helios.Point {
+	return svg.Frame(m.Project(rot, cam, cull), spacing, blank)
+}
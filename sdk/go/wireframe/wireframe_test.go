@@ -0,0 +1,91 @@
+package wireframe
+
+import (
+	"math"
+	"testing"
+)
+
+func near(a, b [2]float64) bool {
+	return math.Abs(a[0]-b[0]) < 1e-9 && math.Abs(a[1]-b[1]) < 1e-9
+}
+
+func TestRotation(t *testing.T) {
+	tests := []struct {
+		r    Rotation
+		v    Vec3
+		want Vec3
+	}{
+		{Rotation{}, Vec3{1, 2, 3}, Vec3{1, 2, 3}},
+		{Rotation{X: math.Pi / 2}, Vec3{0, 1, 0}, Vec3{0, 0, 1}},
+		{Rotation{Y: math.Pi / 2}, Vec3{0, 0, 1}, Vec3{1, 0, 0}},
+		{Rotation{Z: math.Pi / 2}, Vec3{1, 0, 0}, Vec3{0, 1, 0}},
+		{Rotation{X: math.Pi / 2, Z: math.Pi / 2}, Vec3{0, 1, 0}, Vec3{0, 0, 1}},
+	}
+	for _, tt := range tests {
+		got := tt.r.Apply(tt.v)
+		if d := got.sub(tt.want); d.dot(d) > 1e-18 {
+			t.Errorf("%+v.Apply(%v) = %v, want %v", tt.r, tt.v, got, tt.want)
+		}
+	}
+}
+
+func segments(m *Mesh, rot Rotation, cam Camera, cull bool) int {
+	n := 0
+	for _, p := range m.Project(rot, cam, cull) {
+		n += len(p.Points) - 1
+	}
+	return n
+}
+
+func TestProjectCube(t *testing.T) {
+	cube := Cube(1, 0, 1, 0)
+	if n := segments(cube, Rotation{}, Camera{}, false); n != 12 {
+		t.Fatalf("unculled cube draws %d edges, want 12", n)
+	}
+	// Face on, only the front square faces the camera.
+	for _, cam := range []Camera{{}, {Distance: 4}} {
+		if n := segments(cube, Rotation{}, cam, true); n != 4 {
+			t.Fatalf("culled cube with %+v draws %d edges, want 4", cam, n)
+		}
+	}
+	// Turned onto a corner, three faces and their nine edges are visible.
+	corner := Rotation{X: math.Atan(1 / math.Sqrt2), Y: math.Pi / 4}
+	if n := segments(cube, corner, Camera{Distance: 4}, true); n != 9 {
+		t.Fatalf("corner view draws %d edges, want 9", n)
+	}
+
+	paths := cube.Project(Rotation{}, Camera{}, true)
+	if len(paths) != 1 || len(paths[0].Points) != 5 || paths[0].G != 1 {
+		t.Fatalf("front face = %+v, want one closed stroke", paths)
+	}
+}
+
+func TestCameraProject(t *testing.T) {
+	cam := Camera{Distance: 4}
+	if got := cam.project(Vec3{1, 1, 0}); !near(got, [2]float64{1, 1}) {
+		t.Fatalf("origin depth = %v", got)
+	}
+	if got := cam.project(Vec3{1, 1, 1}); !near(got, [2]float64{4.0 / 3, 4.0 / 3}) {
+		t.Fatalf("closer point = %v", got)
+	}
+	if got := (Camera{Distance: 4, Focal: 2}).project(Vec3{1, 0, 0}); !near(got, [2]float64{0.5, 0}) {
+		t.Fatalf("focal = %v", got)
+	}
+
+	m := &Mesh{Vertices: []Vec3{{0, 0, 0}, {0, 0, 3}}, Edges: [][2]int{{0, 1}}}
+	a, b, ok := Camera{Distance: 2}.clip(m.Vertices[0], m.Vertices[1])
+	if !ok || a != m.Vertices[0] || math.Abs(b.Z-(2-nearPlane)) > 1e-12 {
+		t.Fatalf("clip = %v, %v, %v", a, b, ok)
+	}
+	behind := &Mesh{Vertices: []Vec3{{0, 0, 5}, {1, 0, 6}}, Edges: [][2]int{{0, 1}}}
+	if paths := behind.Project(Rotation{}, Camera{Distance: 2}, false); len(paths) != 0 {
+		t.Fatalf("edge behind the camera drawn: %+v", paths)
+	}
+}
+
+func TestFrame(t *testing.T) {
+	frame := Cube(1, 1, 0, 0).Frame(Rotation{Y: 0.3}, Camera{Distance: 3}, true, 0.05, 4)
+	if len(frame) == 0 || frame[0].R != 0 || frame[4].R != 255 {
+		t.Fatalf("frame starts %+v", frame[:5])
+	}
+}
@@ -44,6 +44,10 @@ int HeliosDac_ReScanDevicesOnlyNetwork(HeliosDacHandle h) {
      return static_cast<HeliosDac*>(h)->ReScanDevicesOnlyNetwork();
 }
 
+int HeliosDac_OpenNetworkDevice(HeliosDacHandle h, const char* address, int serviceId, const char* name, const uint8_t* unitId) {
+     return static_cast<HeliosDac*>(h)->OpenNetworkDevice(address, serviceId, name, unitId);
+}
+
 int HeliosDac_GetName(HeliosDacHandle h, int deviceIndex, char* buffer, int length) {
     return static_cast<HeliosDac*>(h)->GetName(deviceIndex, buffer);
 }
@@ -88,6 +92,11 @@ int HeliosDac_SetLibusbDebugLogLevel(HeliosDacHandle h, int logLevel) {
     return static_cast<HeliosDac*>(h)->SetLibusbDebugLogLevel(logLevel);
 }
 
+void HeliosDac_SetUsbTransferOptions(HeliosDacHandle h, unsigned int frameTimeout, unsigned int controlTimeout, unsigned int bulkTransferSize, unsigned int asyncTransfers) {
+    HeliosUsbTransferOptions options = { frameTimeout, controlTimeout, bulkTransferSize, asyncTransfers };
+    static_cast<HeliosDac*>(h)->SetUsbTransferOptions(&options);
+}
+
 int HeliosDac_WriteFrame(HeliosDacHandle h, int deviceIndex, int pps, int flags, const WrapperHeliosPoint* points, int numPoints) {
     if (!points || numPoints <= 0) return 0; // Or error code
 
@@ -106,4 +115,12 @@ int HeliosDac_WriteFrameExtended(HeliosDacHandle h, int deviceIndex, int pps, in
     return static_cast<HeliosDac*>(h)->WriteFrameExtended(deviceIndex, pps, flags, (HeliosPointExt*)points, numPoints);
 }
 
+static void wireCallback(void* context, const HeliosWireRecord* record) {
+    heliosGoWireRecord((WrapperWireRecord*)record);
+}
+
+void HeliosDac_SetWireTap(bool enabled) {
+    HeliosDac::SetWireCallback(enabled ? wireCallback : NULL, NULL);
+}
+
 }
@@ -57,6 +57,9 @@ void HeliosDac_CloseDevices(HeliosDacHandle h);
 int HeliosDac_ReScanDevices(HeliosDacHandle h);
 int HeliosDac_ReScanDevicesOnlyUsb(HeliosDacHandle h);
 int HeliosDac_ReScanDevicesOnlyNetwork(HeliosDacHandle h);
+// address is an IPv4 address in dotted-decimal notation. name and unitId
+// (16 bytes) may be NULL. Returns the device index or a negative error code.
+int HeliosDac_OpenNetworkDevice(HeliosDacHandle h, const char* address, int serviceId, const char* name, const uint8_t* unitId);
 
 // Device Info (using index 0 to numDevices-1)
 // buffer must be at least 32 bytes
@@ -74,6 +77,8 @@ int HeliosDac_Stop(HeliosDacHandle h, int deviceIndex);
 int HeliosDac_SetShutter(HeliosDacHandle h, int deviceIndex, bool level);
 int HeliosDac_EraseFirmware(HeliosDacHandle h, int deviceIndex); // Advanced use only
 int HeliosDac_SetLibusbDebugLogLevel(HeliosDacHandle h, int logLevel);
+// Timeouts are in ms and sizes in bytes; zero keeps the default.
+void HeliosDac_SetUsbTransferOptions(HeliosDacHandle h, unsigned int frameTimeout, unsigned int controlTimeout, unsigned int bulkTransferSize, unsigned int asyncTransfers);
 
 // Output
 // pps: Points per second (e.g., 30000)
@@ -82,6 +87,24 @@ int HeliosDac_WriteFrame(HeliosDacHandle h, int deviceIndex, int pps, int flags,
 int HeliosDac_WriteFrameHighResolution(HeliosDacHandle h, int deviceIndex, int pps, int flags, const WrapperHeliosPointHighRes* points, int numPoints);
 int HeliosDac_WriteFrameExtended(HeliosDacHandle h, int deviceIndex, int pps, int flags, const WrapperHeliosPointExt* points, int numPoints);
 
+// Wire capture
+// MUST strictly match the memory layout of HeliosWireRecord.
+typedef struct {
+    int direction;
+    int transport;
+    unsigned int endpoint;
+    uint32_t address;
+    unsigned int port;
+    int result;
+    const uint8_t* data;
+    unsigned int length;
+} WrapperWireRecord;
+
+// Enables or disables passing every transfer to heliosGoWireRecord, which
+// is exported by the Go package.
+void HeliosDac_SetWireTap(bool enabled);
+void heliosGoWireRecord(WrapperWireRecord* record);
+
 #ifdef __cplusplus
 }
 #endif
@@ -0,0 +1,18 @@
+// Package x is the root of the SDK's experimental packages.
+//
+// Subsystems still finding their shape, such as new network protocols,
+// audio analysis or camera feedback, land under x/ first, imported by an
+// explicit path like github.com/Grix/helios_dac/sdk/go/x/camera. Packages
+// under x/ carry weaker compatibility guarantees than the rest of the SDK:
+//
+//   - Their API may change incompatibly in any release, noted in the
+//     release notes.
+//   - They may import any package of the SDK, but no package outside x/
+//     imports them, so the core bindings never depend on experimental
+//     code.
+//   - Once stable, a package graduates by moving out of x/; the old path
+//     is kept for one release, forwarding to the new one, and is then
+//     removed.
+//
+// This package itself contains no code.
+package x
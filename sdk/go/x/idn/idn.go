@@ -0,0 +1,182 @@
+// Package idn discovers IDN network DACs from Go, so applications can list
+// the devices on the network, with their address, name and response time,
+// and open only the ones they want.
+//
+// The C++ SDK's OpenDevices scans the network with IDN-Hello and opens
+// every device that answers. A Scanner sends the same scan requests itself:
+// Discover broadcasts them on every network interface, like the SDK, and
+// Probe sends them to every address of a subnet, to find devices where
+// broadcasts do not reach, such as across routers or VPNs:
+//
+//	servers, err := idn.Scanner{}.Discover(ctx)
+//	for _, s := range servers {
+//		fmt.Println(s.Addr, s.Name, s.Latency)
+//	}
+//	i, err := servers[0].Open(dac, servers[0].Services[0])
+//
+// The package is experimental; see package x.
+package idn
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/Grix/helios_dac/sdk/go/helios"
+)
+
+// Port is the IDN-Hello UDP port devices answer scans on.
+const Port = 7255
+
+// IDN-Hello commands.
+const (
+	cmdScanRequest        = 0x10
+	cmdScanResponse       = 0x11
+	cmdServiceMapRequest  = 0x12
+	cmdServiceMapResponse = 0x13
+)
+
+// Sizes and limits of the IDN-Hello structures.
+const (
+	headerSize        = 4
+	scanResponseSize  = 40
+	serviceMapSize    = 4
+	serviceEntrySize  = 24
+	hostNameSize      = 20
+	serviceNameSize   = 20
+	groupMask         = 0x0F
+	unitIDLengthLimit = 15
+)
+
+// Status flags reported by a device.
+type Status uint8
+
+const (
+	StatusRealtime    Status = 0x01 // Offers realtime streaming through IDN-Hello.
+	StatusOccupied    Status = 0x10 // All sessions are taken by other clients.
+	StatusExcluded    Status = 0x20 // The client group is excluded from streaming.
+	StatusOffline     Status = 0x40 // Unavailable: booting, overheated or emergency stopped.
+	StatusMalfunction Status = 0x80 // The device has a permanent malfunction.
+)
+
+// Available reports whether the device can accept a stream now.
+func (s Status) Available() bool {
+	return s&(StatusOccupied|StatusExcluded|StatusOffline|StatusMalfunction) == 0
+}
+
+// UnitID is a device's unique IDN unit ID as reported: the length of the
+// rest, a category byte and the ID, padded with zeros.
+type UnitID [16]byte
+
+// String formats the category and ID in hexadecimal.
+func (u UnitID) String() string {
+	return hex.EncodeToString(u[1 : 1+min(int(u[0]), unitIDLengthLimit)])
+}
+
+// Service is one output of a device, usually a laser projector.
+type Service struct {
+	ID   uint8
+	Type uint8
+	Name string
+}
+
+// Server is a device that answered a scan.
+type Server struct {
+	// Addr is the address the device answered from.
+	Addr netip.Addr
+
+	// Name is the device's host name.
+	Name string
+
+	UnitID UnitID
+	Status Status
+
+	// Version is the IDN protocol version, the major version in the upper
+	// four bits.
+	Version uint8
+
+	// Latency is the time from sending the scan request to receiving the
+	// device's answer.
+	Latency time.Duration
+
+	// Services are the outputs of the device. Devices that did not answer
+	// the service map request have none.
+	Services []Service
+}
+
+// Device returns the helios.NetworkDevice streaming to service svc of the
+// server, named as OpenDevices names it.
+func (s Server) Device(svc Service) helios.NetworkDevice {
+	return helios.NetworkDevice{
+		Addr:      s.Addr,
+		ServiceID: svc.ID,
+		Name:      s.Name + " - " + svc.Name,
+		UnitID:    s.UnitID,
+	}
+}
+
+// Open opens service svc of the server on dac and returns its device
+// index.
+func (s Server) Open(dac *helios.DAC, svc Service) (int, error) {
+	return dac.OpenNetworkDevice(s.Device(svc))
+}
+
+var errPacket = errors.New("idn: malformed packet")
+
+// request returns an IDN-Hello request packet.
+func request(cmd, group uint8, seq uint16) []byte {
+	b := []byte{cmd, group & groupMask, 0, 0}
+	binary.BigEndian.PutUint16(b[2:], seq)
+	return b
+}
+
+// header splits an IDN-Hello packet into its command, sequence number and
+// payload.
+func header(b []byte) (cmd uint8, seq uint16, payload []byte, err error) {
+	if len(b) < headerSize {
+		return 0, 0, nil, errPacket
+	}
+	return b[0], binary.BigEndian.Uint16(b[2:]), b[headerSize:], nil
+}
+
+// parseScanResponse fills s from the payload of a scan response.
+func parseScanResponse(p []byte, s *Server) error {
+	if len(p) < scanResponseSize || int(p[0]) != scanResponseSize || p[4] > unitIDLengthLimit {
+		return errPacket
+	}
+	s.Version, s.Status = p[1], Status(p[2])
+	copy(s.UnitID[:], p[4:20])
+	s.Name = name(p[20 : 20+hostNameSize])
+	return nil
+}
+
+// parseServiceMap returns the services of the payload of a service map
+// response, skipping the relay entries before them.
+func parseServiceMap(p []byte) ([]Service, error) {
+	if len(p) < serviceMapSize || int(p[0]) != serviceMapSize || int(p[1]) != serviceEntrySize {
+		return nil, errPacket
+	}
+	relays, services := int(p[2]), int(p[3])
+	entries := p[serviceMapSize:]
+	if len(entries) != (relays+services)*serviceEntrySize {
+		return nil, errPacket
+	}
+	out := make([]Service, 0, services)
+	for i := relays; i < relays+services; i++ {
+		e := entries[i*serviceEntrySize:]
+		if e[0] == 0 {
+			return nil, errPacket
+		}
+		out = append(out, Service{ID: e[0], Type: e[1], Name: name(e[4 : 4+serviceNameSize])})
+	}
+	return out, nil
+}
+
+// name returns a zero-padded name field as a string.
+func name(b []byte) string {
+	s, _, _ := strings.Cut(string(b), "\x00")
+	return s
+}
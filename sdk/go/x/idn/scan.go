@@ -0,0 +1,207 @@
+package idn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"sort"
+	"time"
+)
+
+// DefaultTimeout is how long a scan waits for answers when
+// Scanner.Timeout is zero.
+const DefaultTimeout = 600 * time.Millisecond
+
+// MaxProbe is the largest number of addresses Probe sends requests to.
+const MaxProbe = 1 << 16
+
+// Scanner finds IDN devices. The zero Scanner is ready to use.
+//
+// Canceling the context of a scan stops it and returns the context's
+// error; a context deadline before the timeout ends the scan early with
+// the devices found so far.
+type Scanner struct {
+	// Timeout is how long to wait for answers. Scans take the whole
+	// Timeout, as there is no telling when every device has answered.
+	// Zero means DefaultTimeout.
+	Timeout time.Duration
+
+	// Port is the port requests are sent to. Zero means Port.
+	Port int
+
+	// ClientGroup is the IDN client group (0 - 15) scans are made for.
+	ClientGroup uint8
+}
+
+// Discover broadcasts a scan on every network interface and returns the
+// devices that answer, fastest first.
+func (sc Scanner) Discover(ctx context.Context) ([]Server, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, fmt.Errorf("idn: %w", err)
+	}
+	var targets []netip.Addr
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagBroadcast == 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, a := range addrs {
+			if ipnet, ok := a.(*net.IPNet); ok {
+				if p, ok := prefix(ipnet); ok && p.Bits() < 32 {
+					targets = append(targets, broadcast(p))
+				}
+			}
+		}
+	}
+	if len(targets) == 0 {
+		return nil, errors.New("idn: no IPv4 broadcast interfaces")
+	}
+	return sc.scan(ctx, targets)
+}
+
+// Probe sends a scan request to every host address of p, and returns the
+// devices that answer, fastest first. It finds devices broadcasts do not
+// reach. p may have at most MaxProbe addresses.
+func (sc Scanner) Probe(ctx context.Context, p netip.Prefix) ([]Server, error) {
+	p = p.Masked()
+	if !p.Addr().Is4() {
+		return nil, errors.New("idn: probing needs an IPv4 prefix")
+	}
+	if p.Bits() < 32-16 {
+		return nil, fmt.Errorf("idn: %s has more than %d addresses", p, MaxProbe)
+	}
+	var targets []netip.Addr
+	for a := p.Addr(); p.Contains(a); a = a.Next() {
+		targets = append(targets, a)
+	}
+	// The network and broadcast addresses are not hosts, except in /31
+	// and /32 prefixes.
+	if len(targets) > 2 {
+		targets = targets[1 : len(targets)-1]
+	}
+	return sc.scan(ctx, targets)
+}
+
+// ProbeAddrs sends a scan request to each of addrs and returns the
+// devices that answer, fastest first.
+func (sc Scanner) ProbeAddrs(ctx context.Context, addrs ...netip.Addr) ([]Server, error) {
+	return sc.scan(ctx, addrs)
+}
+
+// scan sends scan requests to targets and collects the answers until the
+// timeout, asking each device that answers for its service map.
+func (sc Scanner) scan(ctx context.Context, targets []netip.Addr) ([]Server, error) {
+	conn, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		return nil, fmt.Errorf("idn: %w", err)
+	}
+	defer conn.Close()
+	timeout := sc.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetReadDeadline(deadline)
+	stop := context.AfterFunc(ctx, func() { conn.SetReadDeadline(time.Now()) })
+	defer stop()
+
+	port := uint16(sc.Port)
+	if port == 0 {
+		port = Port
+	}
+	// Scan requests are numbered from 0, so the sequence number of an
+	// answer finds when its request was sent; service map requests follow.
+	sent := make([]time.Time, len(targets))
+	for i, a := range targets {
+		sent[i] = time.Now()
+		if _, err := conn.WriteToUDPAddrPort(request(cmdScanRequest, sc.ClientGroup, uint16(i)), netip.AddrPortFrom(a.Unmap(), port)); err != nil && len(targets) == 1 {
+			return nil, fmt.Errorf("idn: %w", err)
+		}
+	}
+
+	servers := map[netip.Addr]*Server{}
+	units := map[UnitID]bool{}
+	mapSeq := map[netip.Addr]uint16{}
+	buf := make([]byte, 1500)
+	for {
+		n, from, err := conn.ReadFromUDPAddrPort(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) || isTimeout(err) {
+				break
+			}
+			return nil, fmt.Errorf("idn: %w", err)
+		}
+		addr := from.Addr().Unmap()
+		cmd, seq, payload, err := header(buf[:n])
+		if err != nil {
+			continue
+		}
+		switch cmd {
+		case cmdScanResponse:
+			s := &Server{Addr: addr}
+			if int(seq) >= len(sent) || servers[addr] != nil || parseScanResponse(payload, s) != nil || units[s.UnitID] {
+				continue
+			}
+			// Devices on several networks answer on each; the first
+			// answer is the fastest address.
+			s.Latency = time.Since(sent[seq])
+			servers[addr], units[s.UnitID] = s, true
+			mapSeq[addr] = uint16(len(targets) + len(mapSeq))
+			conn.WriteToUDPAddrPort(request(cmdServiceMapRequest, sc.ClientGroup, mapSeq[addr]), from)
+		case cmdServiceMapResponse:
+			s := servers[addr]
+			if s == nil || seq != mapSeq[addr] {
+				continue
+			}
+			if services, err := parseServiceMap(payload); err == nil {
+				s.Services = services
+			}
+		}
+	}
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return nil, ctx.Err()
+	}
+
+	out := make([]Server, 0, len(servers))
+	for _, s := range servers {
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Latency < out[j].Latency })
+	return out, nil
+}
+
+func isTimeout(err error) bool {
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
+
+// prefix returns the IPv4 network of an interface address.
+func prefix(ipnet *net.IPNet) (netip.Prefix, bool) {
+	ip, ok := netip.AddrFromSlice(ipnet.IP.To4())
+	if !ok {
+		return netip.Prefix{}, false
+	}
+	ones, bits := ipnet.Mask.Size()
+	if bits != 32 {
+		return netip.Prefix{}, false
+	}
+	return netip.PrefixFrom(ip, ones), true
+}
+
+// broadcast returns the directed broadcast address of p.
+func broadcast(p netip.Prefix) netip.Addr {
+	a := p.Masked().Addr().As4()
+	for i := p.Bits(); i < 32; i++ {
+		a[i/8] |= 0x80 >> (i % 8)
+	}
+	return netip.AddrFrom4(a)
+}
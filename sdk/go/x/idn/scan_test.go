@@ -0,0 +1,130 @@
+package idn
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+// fakeDevice answers IDN-Hello scans and service map requests on a
+// loopback port, as a device with one relay and two services would.
+func fakeDevice(t *testing.T, host string) int {
+	t.Helper()
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		buf := make([]byte, 64)
+		for {
+			n, from, err := conn.ReadFromUDPAddrPort(buf)
+			if err != nil {
+				return
+			}
+			if n != headerSize {
+				continue
+			}
+			rsp := []byte{buf[0] + 1, 0, buf[2], buf[3]}
+			switch buf[0] {
+			case cmdScanRequest:
+				p := make([]byte, scanResponseSize)
+				p[0], p[1], p[2] = scanResponseSize, 0x10, byte(StatusRealtime)
+				copy(p[4:], []byte{3, 0x01, 0xAB, 0xCD})
+				copy(p[20:], host)
+				rsp = append(rsp, p...)
+			case cmdServiceMapRequest:
+				rsp = append(rsp, serviceMapSize, serviceEntrySize, 1, 2)
+				for _, e := range []struct {
+					id, relay byte
+					name      string
+				}{{0, 1, "relay"}, {1, 0, "Laser 1"}, {2, 1, "Laser 2"}} {
+					entry := make([]byte, serviceEntrySize)
+					entry[0], entry[3] = e.id, e.relay
+					copy(entry[4:], e.name)
+					rsp = append(rsp, entry...)
+				}
+			default:
+				continue
+			}
+			conn.WriteToUDPAddrPort(rsp, from)
+		}
+	}()
+	return conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+func TestProbe(t *testing.T) {
+	sc := Scanner{Timeout: 200 * time.Millisecond, Port: fakeDevice(t, "HeliosPRO")}
+	start := time.Now()
+	servers, err := sc.Probe(context.Background(), netip.MustParsePrefix("127.0.0.1/32"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(start) < sc.Timeout {
+		t.Fatal("scan ended before the timeout")
+	}
+	if len(servers) != 1 {
+		t.Fatalf("found %d servers", len(servers))
+	}
+	s := servers[0]
+	if s.Addr != netip.MustParseAddr("127.0.0.1") || s.Name != "HeliosPRO" || s.UnitID.String() != "01abcd" || !s.Status.Available() || s.Version != 0x10 {
+		t.Fatalf("server = %+v", s)
+	}
+	if s.Latency <= 0 || s.Latency > sc.Timeout {
+		t.Fatalf("latency = %v", s.Latency)
+	}
+	if len(s.Services) != 2 || s.Services[0] != (Service{ID: 1, Name: "Laser 1"}) || s.Services[1].Name != "Laser 2" {
+		t.Fatalf("services = %+v", s.Services)
+	}
+	dev := s.Device(s.Services[1])
+	if dev.Name != "HeliosPRO - Laser 2" || dev.ServiceID != 2 || dev.UnitID != s.UnitID || dev.Addr != s.Addr {
+		t.Fatalf("device = %+v", dev)
+	}
+}
+
+func TestProbeCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	sc := Scanner{Timeout: time.Minute, Port: fakeDevice(t, "a")}
+	if _, err := sc.ProbeAddrs(ctx, netip.MustParseAddr("127.0.0.1")); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v", err)
+	}
+
+	// A deadline ends the scan with what was found.
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if servers, err := sc.ProbeAddrs(ctx, netip.MustParseAddr("127.0.0.1")); err != nil || len(servers) != 1 {
+		t.Fatalf("servers = %+v, err = %v", servers, err)
+	}
+}
+
+func TestProbeRejectsLargePrefixes(t *testing.T) {
+	for _, p := range []string{"10.0.0.0/15", "fe80::/120"} {
+		if _, err := (Scanner{}).Probe(context.Background(), netip.MustParsePrefix(p)); err == nil {
+			t.Errorf("Probe(%s) accepted", p)
+		}
+	}
+}
+
+func TestParseRejectsMalformed(t *testing.T) {
+	var s Server
+	if parseScanResponse(make([]byte, scanResponseSize-1), &s) == nil {
+		t.Error("short scan response accepted")
+	}
+	p := []byte{serviceMapSize, serviceEntrySize, 0, 1}
+	if _, err := parseServiceMap(append(p, make([]byte, serviceEntrySize)...)); err == nil {
+		t.Error("service with ID 0 accepted")
+	}
+	if _, _, _, err := header([]byte{1, 2}); err == nil {
+		t.Error("short header accepted")
+	}
+}
+
+func TestBroadcast(t *testing.T) {
+	if got := broadcast(netip.MustParsePrefix("192.168.1.20/23")); got != netip.MustParseAddr("192.168.1.255") {
+		t.Fatalf("broadcast = %s", got)
+	}
+}
@@ -0,0 +1,51 @@
+package x
+
+import (
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const (
+	modulePath = "github.com/Grix/helios_dac/sdk/go"
+	prefix     = modulePath + "/x"
+)
+
+// TestCoreDoesNotImportExperimental enforces that packages outside x/
+// never depend on experimental ones.
+func TestCoreDoesNotImportExperimental(t *testing.T) {
+	root := ".."
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			// Experimental packages may import each other.
+			if path == filepath.Join(root, "x") || d.Name() == "testdata" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+		f, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.ImportsOnly)
+		if err != nil {
+			return err
+		}
+		for _, imp := range f.Imports {
+			p, _ := strconv.Unquote(imp.Path.Value)
+			if p == prefix || strings.HasPrefix(p, prefix+"/") {
+				t.Errorf("%s imports experimental package %s", path, p)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}